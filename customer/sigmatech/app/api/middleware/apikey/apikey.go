@@ -0,0 +1,87 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	merchantApiKeys_DBModels "customer/sigmatech/app/db/dto/merchant_api_keys"
+	merchants_DBModels "customer/sigmatech/app/db/dto/merchants"
+	merchantDB "customer/sigmatech/app/db/repository/merchant"
+	merchantApiKeyDB "customer/sigmatech/app/db/repository/merchant_api_key"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/apikey"
+	"customer/sigmatech/app/service/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedPrecision limits how often the last_used_at of a busy key is written.
+const lastUsedPrecision = time.Minute
+
+type IApiKeyService interface {
+	VerifyKey(ctx context.Context, key string) (*merchants_DBModels.Merchant, *merchantApiKeys_DBModels.MerchantApiKey, bool)
+}
+
+type ApiKeyService struct {
+	MerchantDBClient       merchantDB.IMerchantRepository
+	MerchantApiKeyDBClient merchantApiKeyDB.IMerchantApiKeyRepository
+}
+
+func NewApiKeyService(
+	MerchantDBClient merchantDB.IMerchantRepository,
+	MerchantApiKeyDBClient merchantApiKeyDB.IMerchantApiKeyRepository,
+) *ApiKeyService {
+	return &ApiKeyService{
+		MerchantDBClient:       MerchantDBClient,
+		MerchantApiKeyDBClient: MerchantApiKeyDBClient,
+	}
+}
+
+// VerifyKey looks the key up by its prefix and returns its merchant when the key is usable
+// and the merchant is active.
+func (a *ApiKeyService) VerifyKey(ctx context.Context, key string) (*merchants_DBModels.Merchant, *merchantApiKeys_DBModels.MerchantApiKey, bool) {
+	log := logger.Logger(ctx)
+
+	prefix, err := apikey.Prefix(key)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	filter := where.Eq(merchantApiKeys_DBModels.COLUMN_PREFIX, prefix)
+
+	apiKey, err := a.MerchantApiKeyDBClient.GetMerchantApiKey(ctx, filter)
+	if err != nil {
+		log.Errorf("unable to get api key %s: %v", prefix, err)
+		return nil, nil, false
+	}
+
+	if apiKey.Uuid == uuid.Nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(apikey.Hash(key))) != 1 {
+		return nil, nil, false
+	}
+
+	now := time.Now()
+	if !apiKey.IsUsable(now) {
+		return nil, nil, false
+	}
+
+	merchant, err := a.MerchantDBClient.GetMerchant(ctx, where.Eq(merchants_DBModels.COLUM_UUID, apiKey.MerchantUuid))
+	if err != nil {
+		log.Errorf("unable to get merchant %s: %v", apiKey.MerchantUuid, err)
+		return nil, nil, false
+	}
+
+	if merchant.Uuid == uuid.Nil || merchant.IsActive == nil || !*merchant.IsActive {
+		return nil, nil, false
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedPrecision {
+		var patcher = make(map[string]interface{})
+		patcher[merchantApiKeys_DBModels.COLUMN_LAST_USED_AT] = now
+
+		if err := a.MerchantApiKeyDBClient.UpdateMerchantApiKey(ctx, filter, patcher); err != nil {
+			log.Errorf("unable to update last use of api key %s: %v", prefix, err)
+		}
+	}
+
+	return &merchant, &apiKey, true
+}
@@ -1,10 +1,21 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"customer/sigmatech/app/api/middleware/apikey"
 	"customer/sigmatech/app/api/middleware/jwt"
+	"customer/sigmatech/app/api/middleware/ratelimit"
+	"customer/sigmatech/app/api/middleware/signature"
 	"customer/sigmatech/app/constants"
 	"customer/sigmatech/app/controller"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	merchantApiKeys_DBModels "customer/sigmatech/app/db/dto/merchant_api_keys"
+	apikeyService "customer/sigmatech/app/service/apikey"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/logger"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -59,6 +70,110 @@ func Authentication(jwt jwt.IJwtService) gin.HandlerFunc {
 	}
 }
 
+// PartnerAuthentication is a middleware that verifies the x-api-key header of a merchant
+func PartnerAuthentication(apiKey apikey.IApiKeyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(constants.API_KEY)
+		if key == "" {
+			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, errors.New("x-api-key is required"))
+			return
+		}
+
+		merchant, merchantApiKey, valid := apiKey.VerifyKey(ctx, key)
+		if !valid {
+			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, errors.New("invalid api key"))
+			return
+		}
+
+		ctx.Set(constants.CTK_MERCHANT_KEY.String(), merchant)
+		ctx.Set(constants.CTK_API_KEY_KEY.String(), merchantApiKey)
+
+		ctx.Next()
+	}
+}
+
+// RequireScope is a middleware that only lets through api keys granted the scope, use it after PartnerAuthentication
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		context, exist := ctx.Get(constants.CTK_API_KEY_KEY.String())
+		if !exist {
+			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, errors.New("x-api-key is required"))
+			return
+		}
+		merchantApiKey := context.(*merchantApiKeys_DBModels.MerchantApiKey)
+
+		if !apikeyService.HasScope(merchantApiKey.GetScopes(), scope) {
+			controller.RespondWithError(ctx, http.StatusForbidden, constants.PERMISSION_DENIED, fmt.Errorf("api key is missing the %s scope", scope))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Signature is an optional middleware that verifies the HMAC signature, timestamp and nonce headers
+// of server-to-server requests. It is used next to Authentication, not instead of it.
+func Signature(verifier signature.IVerifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keyId, err := verifier.Verify(ctx, ctx.Request)
+		if err != nil {
+			if !signature.IsVerificationError(err) {
+				controller.RespondWithError(ctx, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+				return
+			}
+			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, err)
+			return
+		}
+
+		ctx.Set(constants.CTK_SIGNATURE_KEY.String(), keyId)
+
+		ctx.Next()
+	}
+}
+
+// RateLimit is a middleware that refuses the requests over the limit of the policy with a 429, it does
+// nothing for a disabled policy. Policies keyed by the principal are used after Authentication. When the
+// limiter fails the request is let through, an outage of Redis mustn't take the sign-in down with it.
+func RateLimit(limiter ratelimit.ILimiter, policy ratelimit.Policy) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !policy.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		result, err := limiter.Allow(ctx, policy.Name+":"+rateLimitKey(ctx, policy.Key), policy)
+		if err != nil {
+			logger.Logger(correlation.WithReqContext(ctx)).Errorf("Error counting %s rate limit: %v", policy.Name, err)
+			ctx.Next()
+			return
+		}
+
+		ratelimit.SetHeaders(ctx.Writer.Header(), policy, result)
+		if !result.Allowed {
+			controller.RespondWithError(ctx, http.StatusTooManyRequests, constants.TOO_MANY_REQUESTS, ratelimit.ErrRateLimited)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitKey identifies who the request is counted for. API keys are hashed so they aren't kept as is.
+func rateLimitKey(ctx *gin.Context, key string) string {
+	switch key {
+	case ratelimit.KEY_PRINCIPAL:
+		if context, exist := ctx.Get(constants.CTK_CLAIM_KEY.String()); exist {
+			return key + ":" + context.(*customers_DBModels.Customer).Uuid.String()
+		}
+	case ratelimit.KEY_API_KEY:
+		if apiKey := ctx.GetHeader(constants.API_KEY); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return key + ":" + hex.EncodeToString(sum[:])
+		}
+	}
+	return ratelimit.KEY_IP + ":" + ctx.ClientIP()
+}
+
 func getHeaderToken(ctx *gin.Context) (string, error) {
 	header := string(ctx.GetHeader(constants.AUTHORIZATION))
 	return extractToken(header)
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"customer/sigmatech/app/api/middleware/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		want           string
+	}{
+		{
+			name: "Given no trusted proxy When a client forwards an ip Then it is keyed on the connection's ip",
+			want: ratelimit.KEY_IP + ":203.0.113.9",
+		},
+		{
+			name:           "Given a trusted proxy When it forwards the client ip Then it is keyed on the forwarded ip",
+			trustedProxies: []string{"203.0.113.0/24"},
+			want:           ratelimit.KEY_IP + ":198.51.100.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatalf("SetTrustedProxies() error = %v", err)
+			}
+
+			var got string
+			router.GET("/", func(c *gin.Context) {
+				got = rateLimitKey(c, ratelimit.KEY_IP)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.9:41234"
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("rateLimitKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
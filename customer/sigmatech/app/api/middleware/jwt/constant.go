@@ -0,0 +1,24 @@
+package jwt
+
+import "errors"
+
+const (
+	// Access token signing algorithms
+	SIGNING_ALG_HS256 = "HS256"
+	SIGNING_ALG_RS256 = "RS256"
+	SIGNING_ALG_EDDSA = "EdDSA"
+
+	// Principal cache backends
+	PRINCIPAL_CACHE_MEMORY = "memory"
+	PRINCIPAL_CACHE_REDIS  = "redis"
+
+	// TOKEN_TYPE_CUSTOMER is the typ claim of customer access tokens
+	TOKEN_TYPE_CUSTOMER = "customer"
+
+	principalKeyPrefix = "principal:customer:"
+)
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrRefreshTokenRevoked = errors.New("refresh token is revoked or unknown")
+)
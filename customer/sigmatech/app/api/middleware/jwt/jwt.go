@@ -8,8 +8,6 @@ import (
 
 	"context"
 	"customer/sigmatech/app/service/logger"
-	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -20,16 +18,31 @@ type IJwtService interface {
 	GenerateCustomerTokens(ctx context.Context, customer customers_DBModels.Customer) (*TokenDetails, error)
 	VerifyCustomerToken(ctx context.Context, tokenString string) (*customers_DBModels.Customer, bool)
 	RefreshCustomerToken(ctx context.Context, tokenString string) (*TokenDetails, error)
+	// Logout revokes the session of an access token, its refresh tokens are no longer accepted.
+	Logout(ctx context.Context, tokenString string) error
+	// RevokeSessions ends every session of the customer, on every device.
+	RevokeSessions(ctx context.Context, customerUuid uuid.UUID) error
+	// InvalidatePrincipal drops the cached customer, call it whenever the customer is updated.
+	InvalidatePrincipal(ctx context.Context, customerUuid uuid.UUID) error
 	VerifyToken(ctx context.Context, tokenString string) (*customers_DBModels.Customer, bool)
 }
 
+// JwtService signs access tokens with Keys. Refresh tokens are only ever read back by this service, they
+// stay signed with JWT_REFRESH_SECRET. Tokens only carry the uuid of the customer, the customer itself is
+// loaded through Principals.
 type JwtService struct {
 	CustomerDBClient customerDB.ICustomerRepository
+	RefreshTokens    IRefreshTokenStore
+	Keys             *KeySet
+	Principals       IPrincipalCache
 }
 
-func NewJwtService(CustomerDBClient customerDB.ICustomerRepository) *JwtService {
+func NewJwtService(CustomerDBClient customerDB.ICustomerRepository, RefreshTokens IRefreshTokenStore, Keys *KeySet, Principals IPrincipalCache) *JwtService {
 	return &JwtService{
 		CustomerDBClient: CustomerDBClient,
+		RefreshTokens:    RefreshTokens,
+		Keys:             Keys,
+		Principals:       Principals,
 	}
 }
 
@@ -40,38 +53,49 @@ type TokenDetails struct {
 	RefreshUuid  string `json:"refresh_uuid"`
 	AtExpires    int64  `json:"at_expires"`
 	RtExpires    int64  `json:"rt_expires"`
+
+	// FamilyUuid identifies the session the tokens were issued for, SubjectUuid who they were issued to
+	FamilyUuid  uuid.UUID `json:"family_uuid"`
+	SubjectUuid uuid.UUID `json:"-"`
 }
 
+// GenerateCustomerTokens starts a new session, its refresh token is the first of a new family.
 func (j *JwtService) GenerateCustomerTokens(ctx context.Context, customer customers_DBModels.Customer) (*TokenDetails, error) {
+	return j.generateCustomerTokens(ctx, customer, uuid.New())
+}
+
+func (j *JwtService) generateCustomerTokens(ctx context.Context, customer customers_DBModels.Customer, familyUuid uuid.UUID) (*TokenDetails, error) {
 	log := logger.Logger(ctx)
-	log.Infof("Creating token for ", customer)
+	log.Infof("Creating token for %s", customer.Uuid)
 
-	customer.Password = ""
 	var err error
 
-	td := &TokenDetails{}
-	td.AtExpires = time.Now().Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_ACCESS_EXP)).Unix()
+	now := time.Now()
+	td := &TokenDetails{FamilyUuid: familyUuid, SubjectUuid: customer.Uuid}
+	td.AtExpires = now.Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_ACCESS_EXP)).Unix()
 	td.AccessUuid = uuid.NewString()
 
 	atClaims := jwt.MapClaims{}
-	atClaims["authorized"] = true
-	atClaims["access_uuid"] = td.AccessUuid
-	atClaims["customer"] = customer
+	atClaims["sub"] = customer.Uuid
+	atClaims["typ"] = TOKEN_TYPE_CUSTOMER
+	atClaims["jti"] = td.AccessUuid
+	atClaims["family_uuid"] = familyUuid
+	atClaims["iat"] = now.Unix()
 	atClaims["exp"] = td.AtExpires
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-
-	td.AccessToken, err = at.SignedString([]byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET))
+	td.AccessToken, err = j.Keys.Sign(atClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	//Creating Refresh Token
-	td.RefreshUuid = td.AccessUuid + "++" + customer.Email
-	td.RtExpires = time.Now().Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_REFRESH_EXP)).Unix()
+	td.RefreshUuid = uuid.NewString()
+	td.RtExpires = now.Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_REFRESH_EXP)).Unix()
 
 	rtClaims := jwt.MapClaims{}
+	rtClaims["sub"] = customer.Uuid
 	rtClaims["refresh_uuid"] = td.RefreshUuid
-	rtClaims["customer"] = customer
+	rtClaims["family_uuid"] = familyUuid
+	rtClaims["iat"] = now.Unix()
 	rtClaims["exp"] = td.RtExpires
 
 	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
@@ -80,45 +104,22 @@ func (j *JwtService) GenerateCustomerTokens(ctx context.Context, customer custom
 		log.Errorf("error while generating access id ", err)
 		return nil, err
 	}
-	return td, nil
-}
-
-func (j *JwtService) VerifyCustomerToken(ctx context.Context, tokenString string) (*customers_DBModels.Customer, bool) {
-	log := logger.Logger(ctx)
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET), nil
+	err = j.RefreshTokens.Issue(ctx, RefreshToken{
+		Uuid:        uuid.MustParse(td.RefreshUuid),
+		FamilyUuid:  familyUuid,
+		SubjectUuid: customer.Uuid,
+		ExpiresAt:   time.Unix(td.RtExpires, 0),
 	})
 	if err != nil {
-		return nil, false
+		log.Errorf("error while storing the refresh token: %v", err)
+		return nil, err
 	}
+	return td, nil
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		customer := customers_DBModels.Customer{}
-		jsonString, err := json.Marshal(claims["customer"])
-		if err != nil {
-			log.Errorf("unable to marshal customer claims", err.Error())
-			return nil, false
-		}
-		// convert json to struct
-		err = json.Unmarshal(jsonString, &customer)
-		if err != nil {
-			return nil, false
-		}
-
-		u, err := j.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-			customers_DBModels.COLUM_UUID, customer.Uuid))
-		if err != nil {
-			return nil, false
-		}
-
-		return &u, true
-	}
-	return nil, false
+func (j *JwtService) VerifyCustomerToken(ctx context.Context, tokenString string) (*customers_DBModels.Customer, bool) {
+	return j.VerifyToken(ctx, tokenString)
 }
 
 func (j *JwtService) RefreshCustomerToken(ctx context.Context, tokenString string) (*TokenDetails, error) {
@@ -137,90 +138,135 @@ func (j *JwtService) RefreshCustomerToken(ctx context.Context, tokenString strin
 		return nil, err
 	}
 
-	_, ok = claims["refresh_uuid"].(string)
-	if !ok {
-		return nil, err
+	// Tokens issued before rotation carry no family, their customers have to sign in again
+	refreshUuid, err := uuid.Parse(stringFromClaim(claims["refresh_uuid"]))
+	if err != nil {
+		return nil, ErrRefreshTokenRevoked
 	}
-
-	customerClaim, ok := claims["customer"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("invalid customer claim")
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
+	if err != nil {
+		return nil, ErrRefreshTokenRevoked
 	}
 
-	customer := customers_DBModels.Customer{}
-	customerJSON, err := json.Marshal(customerClaim)
+	exp, _ := claims["exp"].(float64)
+	err = j.RefreshTokens.Rotate(ctx, RefreshToken{
+		Uuid:       refreshUuid,
+		FamilyUuid: familyUuid,
+		ExpiresAt:  time.Unix(int64(exp), 0),
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// A rotated token coming back means it leaked, the session is ended for the legitimate holder too
+		log.Warnf("refresh token %s was reused, revoking family %s", refreshUuid, familyUuid)
+		if err := j.endSession(ctx, claims, familyUuid); err != nil {
+			log.Errorf("unable to revoke family %s: %v", familyUuid, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
 	if err != nil {
-		log.Errorf("unable to marshal customer claim: %v", err)
 		return nil, err
 	}
 
-	err = json.Unmarshal(customerJSON, &customer)
+	// Tokens issued before the claims were slimmed carry no subject, their customers have to sign in again
+	customerUuid, err := uuid.Parse(stringFromClaim(claims["sub"]))
 	if err != nil {
-		log.Errorf("unable to unmarshal customer claim: %v", err)
-		return nil, err
+		return nil, ErrRefreshTokenRevoked
 	}
 
-	// Check if the customer exists in the database
-	u, err := j.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'", customers_DBModels.COLUMN_EMAIL, customer.Email))
-	if err != nil {
-		return nil, err
+	customer, ok := j.loadPrincipal(ctx, customerUuid, uuid.Nil)
+	if !ok {
+		return nil, errors.New("customer not found or inactive")
 	}
 
-	// Create new pairs of refresh and access tokens
-	td, err := j.GenerateCustomerTokens(ctx, u)
+	// Create new pairs of refresh and access tokens, in the same family
+	td, err := j.generateCustomerTokens(ctx, *customer, familyUuid)
 	if err != nil {
 		return nil, err
 	}
 	return td, nil
 }
 
+// VerifyToken returns the customer of a valid access token, the session of the token has to be active
+// and the customer active and not deleted.
 func (j *JwtService) VerifyToken(ctx context.Context, tokenString string) (*customers_DBModels.Customer, bool) {
-	log := logger.Logger(ctx)
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
+	if err != nil {
+		return nil, false
+	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET), nil
-	})
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != TOKEN_TYPE_CUSTOMER {
+		return nil, false
+	}
+
+	customerUuid, err := uuid.Parse(stringFromClaim(claims["sub"]))
+	if err != nil {
+		return nil, false
+	}
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
 	if err != nil {
 		return nil, false
 	}
 
+	return j.loadPrincipal(ctx, customerUuid, familyUuid)
+}
+
+func (j *JwtService) Logout(ctx context.Context, tokenString string) error {
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
+	if err != nil {
+		return err
+	}
+
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return nil, false
+		return errors.New(constants.INVALID_TOKEN)
 	}
 
-	var customer customers_DBModels.Customer
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
+	if err != nil {
+		return ErrRefreshTokenRevoked
+	}
 
-	if customerClaims, ok := claims["customer"].(map[string]interface{}); ok {
-		jsonString, err := json.Marshal(customerClaims)
-		if err != nil {
-			log.Errorf("unable to marshal customer claims: %v", err)
-			return nil, false
-		}
+	return j.endSession(ctx, claims, familyUuid)
+}
 
-		err = json.Unmarshal(jsonString, &customer)
-		if err != nil {
-			log.Errorf("unable to unmarshal customer claims: %v", err)
-			return nil, false
-		}
+func (j *JwtService) RevokeSessions(ctx context.Context, customerUuid uuid.UUID) error {
+	if err := j.RefreshTokens.RevokeAll(ctx, customerUuid); err != nil {
+		return err
 	}
+	return j.Principals.Invalidate(ctx, customerUuid)
+}
 
-	if customer.Uuid != uuid.Nil {
-		u, err := j.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-			customers_DBModels.COLUM_UUID, customer.Uuid))
-		if err != nil {
-			return nil, false
-		}
+func (j *JwtService) InvalidatePrincipal(ctx context.Context, customerUuid uuid.UUID) error {
+	return j.Principals.Invalidate(ctx, customerUuid)
+}
 
-		if !u.IsActive {
-			return nil, false
-		}
+// endSession revokes the family of a token and drops the cached principal of its subject, which
+// remembers the session as active.
+func (j *JwtService) endSession(ctx context.Context, claims jwt.MapClaims, familyUuid uuid.UUID) error {
+	if err := j.RefreshTokens.Revoke(ctx, familyUuid); err != nil {
+		return err
+	}
 
-		return &u, true
+	customerUuid, err := uuid.Parse(stringFromClaim(claims["sub"]))
+	if err != nil {
+		// Tokens without a subject were never cached
+		return nil
 	}
+	return j.Principals.Invalidate(ctx, customerUuid)
+}
+
+// sessionActive reports whether a family is still active, access tokens are refused as soon as their
+// session is logged out rather than when they expire.
+func (j *JwtService) sessionActive(ctx context.Context, familyUuid uuid.UUID) bool {
+	active, err := j.RefreshTokens.Active(ctx, familyUuid)
+	if err != nil {
+		logger.Logger(ctx).Errorf("unable to check family %s: %v", familyUuid, err)
+		return false
+	}
+	return active
+}
 
-	return nil, false
+func stringFromClaim(claim interface{}) string {
+	str, _ := claim.(string)
+	return str
 }
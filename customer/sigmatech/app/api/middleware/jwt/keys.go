@@ -0,0 +1,239 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// KeySet signs access tokens with one key and verifies them with any key it holds, picked by the kid
+// header. With RS256 or EdDSA the keys are read from a directory of <kid>.pem files, private or public,
+// and their public halves are published as a JWKS.
+//
+// To rotate, add the new private key to the directory next to the current one and restart with
+// JWT_KEY_ID set to its kid. Tokens signed with the previous key keep verifying while its file stays,
+// remove it once they all expired, JWT_REFRESH_EXP minutes later. The previous key may be replaced by its
+// public half in the meantime.
+//
+// Tokens signed with the HS256 secret keep verifying while it is set, so switching from HS256 doesn't
+// sign anyone out. Unset it once they expired, as anyone holding it can mint tokens.
+type KeySet struct {
+	method     jwt.SigningMethod
+	signingKid string
+	signingKey interface{}
+
+	keys   map[string]verificationKey
+	secret []byte
+}
+
+var errUnsupportedKey = errors.New("only RSA and Ed25519 keys are supported")
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the set of public keys tokens are verified with.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeySet signs and verifies tokens with the HS256 secret alone.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{
+		method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		keys:       map[string]verificationKey{},
+		secret:     []byte(secret),
+	}
+}
+
+// LoadKeySet reads the keys of the algorithm from dir and signs with the key kid. HS256 ignores dir and
+// kid and uses the secret, the other algorithms still verify HS256 tokens when the secret is set.
+func LoadKeySet(algorithm, dir, kid, secret string) (*KeySet, error) {
+	if algorithm == "" || algorithm == SIGNING_ALG_HS256 {
+		if secret == "" {
+			return nil, errors.New("JWT_ACCESS_SECRET is required to sign with HS256")
+		}
+		return NewHMACKeySet(secret), nil
+	}
+
+	if algorithm != SIGNING_ALG_RS256 && algorithm != SIGNING_ALG_EDDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", algorithm)
+	}
+	if dir == "" || kid == "" {
+		return nil, fmt.Errorf("JWT_KEYS_DIR and JWT_KEY_ID are required to sign with %s", algorithm)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeySet{keys: make(map[string]verificationKey, len(files))}
+	if secret != "" {
+		k.secret = []byte(secret)
+	}
+
+	for _, file := range files {
+		pemBytes, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		fileKid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		private, public, err := parseKey(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", fileKid, err)
+		}
+
+		method := methodOf(public)
+		k.keys[fileKid] = verificationKey{method: method, key: public}
+
+		if fileKid == kid {
+			if private == nil {
+				return nil, fmt.Errorf("key %s signs tokens, it must be a private key", kid)
+			}
+			if method.Alg() != algorithm {
+				return nil, fmt.Errorf("key %s is a %s key, not %s", kid, method.Alg(), algorithm)
+			}
+			k.method, k.signingKid, k.signingKey = method, kid, private
+		}
+	}
+
+	if k.signingKey == nil {
+		return nil, fmt.Errorf("no key %s.pem in %s", kid, dir)
+	}
+
+	return k, nil
+}
+
+// Sign returns the token of the claims, signed with the current key and naming it in the kid header.
+func (k *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signingKid != "" {
+		token.Header["kid"] = k.signingKid
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// Keyfunc returns the key of the kid header for jwt.Parse, refusing tokens whose algorithm isn't the
+// one of that key.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(k.secret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
+
+// JWKS returns the public keys tokens are verified with, the HS256 secret is never published.
+func (k *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: kid}
+
+		switch public := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// parseKey reads a PEM private key, PKCS #8 or PKCS #1, or a PKIX public key. The private key is nil
+// for public keys.
+func parseKey(pemBytes []byte) (interface{}, interface{}, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, nil, errors.New("not a PEM key")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch public.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return nil, public, nil
+		}
+		return nil, nil, errUnsupportedKey
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return private, &private.PublicKey, nil
+	default:
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch private := private.(type) {
+		case *rsa.PrivateKey:
+			return private, &private.PublicKey, nil
+		case ed25519.PrivateKey:
+			return private, private.Public(), nil
+		}
+		return nil, nil, errUnsupportedKey
+	}
+}
+
+// methodOf returns the signing method of a public key, parseKey only returns RSA and Ed25519 keys.
+func methodOf(public interface{}) jwt.SigningMethod {
+	if _, ok := public.(ed25519.PublicKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
@@ -0,0 +1,191 @@
+package jwt
+
+import (
+	"context"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/redis"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IPrincipalCache keeps the customers access tokens were issued to for a short while, so verifying a
+// token doesn't read the customers table on every request. Only active customers are cached.
+type IPrincipalCache interface {
+	// Get returns the cached principal, false when it isn't cached or expired.
+	Get(ctx context.Context, customerUuid uuid.UUID) (*Principal, bool, error)
+	Set(ctx context.Context, principal Principal) error
+	// Invalidate drops the principal, the next request loads it from the database again.
+	Invalidate(ctx context.Context, customerUuid uuid.UUID) error
+}
+
+// Principal is a cached customer with the sessions its access tokens were seen active in. Sessions are
+// only checked against the refresh tokens once per entry, so ending one has to invalidate the principal.
+type Principal struct {
+	Customer customers_DBModels.Customer `json:"customer"`
+	Sessions []uuid.UUID                 `json:"sessions"`
+}
+
+func (p *Principal) inSession(familyUuid uuid.UUID) bool {
+	for _, session := range p.Sessions {
+		if session == familyUuid {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryPrincipalCache keeps customers in process memory. Changes made by the admin API only show up
+// once the entry expires, deactivations are still immediate as they revoke the sessions.
+type MemoryPrincipalCache struct {
+	mu         sync.Mutex
+	principals map[uuid.UUID]memoryPrincipal
+	TTL        time.Duration
+	Now        func() time.Time
+}
+
+type memoryPrincipal struct {
+	principal Principal
+	expiresAt time.Time
+}
+
+// NewMemoryPrincipalCache is a constructor function that creates a new MemoryPrincipalCache.
+func NewMemoryPrincipalCache(TTL time.Duration) *MemoryPrincipalCache {
+	return &MemoryPrincipalCache{
+		principals: make(map[uuid.UUID]memoryPrincipal),
+		TTL:        TTL,
+		Now:        time.Now,
+	}
+}
+
+func (c *MemoryPrincipalCache) Get(ctx context.Context, customerUuid uuid.UUID) (*Principal, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.principals[customerUuid]
+	if !ok {
+		return nil, false, nil
+	}
+	if c.Now().After(entry.expiresAt) {
+		delete(c.principals, customerUuid)
+		return nil, false, nil
+	}
+
+	principal := entry.principal
+	principal.Sessions = append([]uuid.UUID(nil), entry.principal.Sessions...)
+	return &principal, true, nil
+}
+
+func (c *MemoryPrincipalCache) Set(ctx context.Context, principal Principal) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.Now()
+	for u, entry := range c.principals {
+		if now.After(entry.expiresAt) {
+			delete(c.principals, u)
+		}
+	}
+
+	c.principals[principal.Customer.Uuid] = memoryPrincipal{principal: principal, expiresAt: now.Add(c.TTL)}
+	return nil
+}
+
+func (c *MemoryPrincipalCache) Invalidate(ctx context.Context, customerUuid uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.principals, customerUuid)
+	return nil
+}
+
+// RedisPrincipalCache keeps customers in Redis, so the user service can invalidate them on updates.
+type RedisPrincipalCache struct {
+	Redis redis.IRedisClient
+	TTL   time.Duration
+}
+
+// NewRedisPrincipalCache is a constructor function that creates a new RedisPrincipalCache.
+func NewRedisPrincipalCache(Redis redis.IRedisClient, TTL time.Duration) *RedisPrincipalCache {
+	return &RedisPrincipalCache{
+		Redis: Redis,
+		TTL:   TTL,
+	}
+}
+
+func (c *RedisPrincipalCache) Get(ctx context.Context, customerUuid uuid.UUID) (*Principal, bool, error) {
+	cached, err := c.Redis.Exists(principalKeyPrefix + customerUuid.String())
+	if err != nil || !cached {
+		return nil, false, err
+	}
+
+	value, err := c.Redis.Get(principalKeyPrefix + customerUuid.String())
+	if err != nil {
+		// The entry expired in between
+		return nil, false, nil
+	}
+
+	var principal Principal
+	if err := json.Unmarshal([]byte(value), &principal); err != nil {
+		return nil, false, err
+	}
+	if principal.Customer.Uuid == uuid.Nil {
+		// Cached before the sessions were, the customer is loaded again
+		return nil, false, nil
+	}
+	return &principal, true, nil
+}
+
+func (c *RedisPrincipalCache) Set(ctx context.Context, principal Principal) error {
+	value, err := json.Marshal(principal)
+	if err != nil {
+		return err
+	}
+	return c.Redis.Set(principalKeyPrefix+principal.Customer.Uuid.String(), string(value), c.TTL)
+}
+
+func (c *RedisPrincipalCache) Invalidate(ctx context.Context, customerUuid uuid.UUID) error {
+	_, err := c.Redis.Delete(principalKeyPrefix + customerUuid.String())
+	return err
+}
+
+// loadPrincipal returns the active customer the token was issued to, from the cache when it's there.
+// Deleted and deactivated customers are refused. Unless familyUuid is nil the session has to be active
+// too, it is checked against the refresh tokens once and then remembered with the customer.
+func (j *JwtService) loadPrincipal(ctx context.Context, customerUuid, familyUuid uuid.UUID) (*customers_DBModels.Customer, bool) {
+	log := logger.Logger(ctx)
+
+	principal, cached, err := j.Principals.Get(ctx, customerUuid)
+	if err != nil {
+		log.Errorf("unable to read the cached customer %s: %v", customerUuid, err)
+	}
+	if cached && (familyUuid == uuid.Nil || principal.inSession(familyUuid)) {
+		return &principal.Customer, true
+	}
+
+	if familyUuid != uuid.Nil && !j.sessionActive(ctx, familyUuid) {
+		return nil, false
+	}
+
+	if !cached {
+		u, err := j.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, customerUuid))
+		if err != nil || u.Uuid == uuid.Nil || !u.IsActive {
+			return nil, false
+		}
+
+		u.Password = ""
+		principal = &Principal{Customer: u}
+	}
+	if familyUuid != uuid.Nil {
+		principal.Sessions = append(principal.Sessions, familyUuid)
+	}
+
+	if err := j.Principals.Set(ctx, *principal); err != nil {
+		log.Errorf("unable to cache the customer %s: %v", customerUuid, err)
+	}
+	return &principal.Customer, true
+}
@@ -0,0 +1,208 @@
+package jwt
+
+import (
+	"context"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/logger"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// customerRepository holds a single customer and counts how often it was read.
+type customerRepository struct {
+	customer customers_DBModels.Customer
+	reads    int
+}
+
+func (r *customerRepository) CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error {
+	return nil
+}
+
+func (r *customerRepository) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
+	r.reads++
+	if !reflect.DeepEqual(whr, where.Eq(customers_DBModels.COLUM_UUID, r.customer.Uuid)) {
+		return customers_DBModels.Customer{}, nil
+	}
+	return r.customer, nil
+}
+
+func (r *customerRepository) GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *customerRepository) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *customerRepository) DeleteCustomer(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// refreshTokenStore knows which families were revoked and counts how often one was checked.
+type refreshTokenStore struct {
+	revoked map[uuid.UUID]bool
+	checks  int
+}
+
+func (s *refreshTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	return nil
+}
+
+func (s *refreshTokenStore) Rotate(ctx context.Context, token RefreshToken) error {
+	return nil
+}
+
+func (s *refreshTokenStore) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	s.revoked[familyUuid] = true
+	return nil
+}
+
+func (s *refreshTokenStore) RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error {
+	return nil
+}
+
+func (s *refreshTokenStore) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	s.checks++
+	return !s.revoked[familyUuid], nil
+}
+
+func TestLoadPrincipal(t *testing.T) {
+	logger.SugarLogger = zap.NewNop().Sugar()
+	ctx := context.Background()
+
+	newService := func(active bool) (*JwtService, *customerRepository, *MemoryPrincipalCache) {
+		customers := &customerRepository{customer: customers_DBModels.Customer{
+			Uuid:     uuid.New(),
+			Password: "hash",
+			IsActive: active,
+		}}
+		principals := NewMemoryPrincipalCache(time.Minute)
+		return NewJwtService(customers, nil, nil, principals), customers, principals
+	}
+
+	t.Run("Given an active customer When loading it twice Then the database is read once and the password left out", func(t *testing.T) {
+		j, customers, _ := newService(true)
+
+		for i := 0; i < 2; i++ {
+			customer, ok := j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil)
+			if !ok || customer.Password != "" {
+				t.Fatalf("loadPrincipal() = %+v, %v, want the customer without password", customer, ok)
+			}
+		}
+		if customers.reads != 1 {
+			t.Errorf("customer read %d times, want 1", customers.reads)
+		}
+	})
+
+	t.Run("Given an inactive or unknown customer When loading it Then it is refused", func(t *testing.T) {
+		j, customers, _ := newService(false)
+
+		if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil); ok {
+			t.Error("loadPrincipal() accepted an inactive customer")
+		}
+		if _, ok := j.loadPrincipal(ctx, uuid.New(), uuid.Nil); ok {
+			t.Error("loadPrincipal() accepted an unknown customer")
+		}
+	})
+
+	t.Run("Given a cached customer When it is deactivated and invalidated Then it is refused", func(t *testing.T) {
+		j, customers, _ := newService(true)
+
+		j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil)
+		customers.customer.IsActive = false
+		if err := j.InvalidatePrincipal(ctx, customers.customer.Uuid); err != nil {
+			t.Fatalf("InvalidatePrincipal() error = %v", err)
+		}
+
+		if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil); ok {
+			t.Error("loadPrincipal() accepted a deactivated customer")
+		}
+	})
+
+	t.Run("Given an expired entry When loading it Then the database is read again", func(t *testing.T) {
+		j, customers, principals := newService(true)
+		now := time.Now()
+		principals.Now = func() time.Time { return now }
+
+		j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil)
+		now = now.Add(2 * time.Minute)
+		j.loadPrincipal(ctx, customers.customer.Uuid, uuid.Nil)
+
+		if customers.reads != 2 {
+			t.Errorf("customer read %d times, want 2", customers.reads)
+		}
+	})
+}
+
+func TestLoadPrincipalSession(t *testing.T) {
+	logger.SugarLogger = zap.NewNop().Sugar()
+	ctx := context.Background()
+
+	newService := func() (*JwtService, *customerRepository, *refreshTokenStore) {
+		customers := &customerRepository{customer: customers_DBModels.Customer{Uuid: uuid.New(), IsActive: true}}
+		tokens := &refreshTokenStore{revoked: make(map[uuid.UUID]bool)}
+		return NewJwtService(customers, tokens, nil, NewMemoryPrincipalCache(time.Minute)), customers, tokens
+	}
+
+	t.Run("Given an active session When loading it twice Then the refresh tokens and the customer are read once", func(t *testing.T) {
+		j, customers, tokens := newService()
+		family := uuid.New()
+
+		for i := 0; i < 2; i++ {
+			if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, family); !ok {
+				t.Fatal("loadPrincipal() refused an active session")
+			}
+		}
+		if tokens.checks != 1 || customers.reads != 1 {
+			t.Errorf("family checked %d times and customer read %d times, want 1 and 1", tokens.checks, customers.reads)
+		}
+	})
+
+	t.Run("Given a revoked session When loading it Then it is refused before the customer is read", func(t *testing.T) {
+		j, customers, tokens := newService()
+		family := uuid.New()
+		tokens.revoked[family] = true
+
+		if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, family); ok {
+			t.Error("loadPrincipal() accepted a revoked session")
+		}
+		if customers.reads != 0 {
+			t.Errorf("customer read %d times, want 0", customers.reads)
+		}
+	})
+
+	t.Run("Given a cached customer When another session is loaded Then only that session is checked", func(t *testing.T) {
+		j, customers, tokens := newService()
+
+		j.loadPrincipal(ctx, customers.customer.Uuid, uuid.New())
+		if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, uuid.New()); !ok {
+			t.Fatal("loadPrincipal() refused an active session")
+		}
+		if tokens.checks != 2 || customers.reads != 1 {
+			t.Errorf("family checked %d times and customer read %d times, want 2 and 1", tokens.checks, customers.reads)
+		}
+	})
+
+	t.Run("Given a cached session When it is ended Then it is refused right away", func(t *testing.T) {
+		j, customers, _ := newService()
+		family := uuid.New()
+		claims := jwt.MapClaims{"sub": customers.customer.Uuid.String(), "family_uuid": family.String()}
+
+		j.loadPrincipal(ctx, customers.customer.Uuid, family)
+		if err := j.endSession(ctx, claims, family); err != nil {
+			t.Fatalf("endSession() error = %v", err)
+		}
+
+		if _, ok := j.loadPrincipal(ctx, customers.customer.Uuid, family); ok {
+			t.Error("loadPrincipal() accepted an ended session")
+		}
+	})
+}
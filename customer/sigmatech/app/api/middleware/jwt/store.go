@@ -0,0 +1,98 @@
+package jwt
+
+import (
+	"context"
+	refreshTokens_DBModels "customer/sigmatech/app/db/dto/refresh_tokens"
+	refreshTokenDB "customer/sigmatech/app/db/repository/refresh_token"
+	"customer/sigmatech/app/db/where"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken identifies an issued refresh token. Rotating a token issues the next one of its family,
+// the family lives as long as the session started by the sign in.
+type RefreshToken struct {
+	Uuid        uuid.UUID
+	FamilyUuid  uuid.UUID
+	SubjectUuid uuid.UUID
+	ExpiresAt   time.Time
+}
+
+// IRefreshTokenStore tracks the refresh token families, so every refresh token is accepted once.
+// Unlike the user service, refresh tokens of customers are only kept in Postgres so far.
+type IRefreshTokenStore interface {
+	// Issue records a new refresh token of its family.
+	Issue(ctx context.Context, token RefreshToken) error
+	// Rotate consumes the token. It returns ErrRefreshTokenReused when the token was consumed before and
+	// ErrRefreshTokenRevoked when the token is unknown, expired or its family was revoked.
+	Rotate(ctx context.Context, token RefreshToken) error
+	// Revoke ends the family, its tokens are no longer accepted.
+	Revoke(ctx context.Context, familyUuid uuid.UUID) error
+	// RevokeAll ends every family of the subject.
+	RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error
+	// Active reports whether the family wasn't revoked.
+	Active(ctx context.Context, familyUuid uuid.UUID) (bool, error)
+}
+
+// PostgresRefreshTokenStore keeps refresh tokens in the refresh_tokens table.
+type PostgresRefreshTokenStore struct {
+	RefreshTokenDBClient refreshTokenDB.IRefreshTokenRepository
+	SubjectType          string
+}
+
+// NewPostgresRefreshTokenStore is a constructor function that creates a new PostgresRefreshTokenStore.
+func NewPostgresRefreshTokenStore(RefreshTokenDBClient refreshTokenDB.IRefreshTokenRepository, SubjectType string) *PostgresRefreshTokenStore {
+	return &PostgresRefreshTokenStore{
+		RefreshTokenDBClient: RefreshTokenDBClient,
+		SubjectType:          SubjectType,
+	}
+}
+
+func (s *PostgresRefreshTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	return s.RefreshTokenDBClient.CreateRefreshToken(ctx, &refreshTokens_DBModels.RefreshToken{
+		Uuid:        token.Uuid,
+		FamilyUuid:  token.FamilyUuid,
+		SubjectUuid: token.SubjectUuid,
+		SubjectType: s.SubjectType,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func (s *PostgresRefreshTokenStore) Rotate(ctx context.Context, token RefreshToken) error {
+	rotated, err := s.RefreshTokenDBClient.RotateRefreshToken(ctx, token.Uuid)
+	if err != nil {
+		return err
+	}
+	if rotated {
+		return nil
+	}
+
+	// Tell a reused token apart from an unknown, expired or revoked one
+	issued, err := s.RefreshTokenDBClient.GetRefreshToken(ctx, where.Eq(refreshTokens_DBModels.COLUM_UUID, token.Uuid))
+	if err != nil {
+		return err
+	}
+	if issued.Uuid != uuid.Nil && issued.RevokedAt == nil && issued.RotatedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	return ErrRefreshTokenRevoked
+}
+
+func (s *PostgresRefreshTokenStore) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	return s.RefreshTokenDBClient.RevokeRefreshTokenFamily(ctx, familyUuid)
+}
+
+func (s *PostgresRefreshTokenStore) RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error {
+	return s.RefreshTokenDBClient.RevokeSubjectRefreshTokens(ctx, s.SubjectType, subjectUuid)
+}
+
+func (s *PostgresRefreshTokenStore) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	token, err := s.RefreshTokenDBClient.GetRefreshToken(ctx, where.Eq(refreshTokens_DBModels.COLUMN_FAMILY_UUID, familyUuid).IsNull(refreshTokens_DBModels.COLUMN_REVOKED_AT))
+	if err != nil {
+		return false, err
+	}
+
+	return token.Uuid != uuid.Nil, nil
+}
@@ -0,0 +1,32 @@
+package ratelimit
+
+import "errors"
+
+const (
+	// Algorithms a policy counts its requests with
+	ALGORITHM_TOKEN_BUCKET   = "token_bucket"
+	ALGORITHM_SLIDING_WINDOW = "sliding_window"
+
+	// What requests are counted per, requests without a principal or API key are counted per IP
+	KEY_IP        = "ip"
+	KEY_PRINCIPAL = "principal"
+	KEY_API_KEY   = "api_key"
+
+	// Limiter backends
+	STORE_MEMORY = "memory"
+	STORE_REDIS  = "redis"
+
+	// Headers of the IETF RateLimit header fields draft
+	HEADER_LIMIT       = "RateLimit-Limit"
+	HEADER_REMAINING   = "RateLimit-Remaining"
+	HEADER_RESET       = "RateLimit-Reset"
+	HEADER_POLICY      = "RateLimit-Policy"
+	HEADER_RETRY_AFTER = "Retry-After"
+
+	rateLimitKeyPrefix = "ratelimit:"
+)
+
+var (
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrInvalidPolicy = errors.New("invalid rate limit policy")
+)
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ILimiter counts requests against policies.
+type ILimiter interface {
+	// Allow counts a request of the key against the policy and reports whether it is within the limit.
+	// Requests that aren't allowed aren't counted.
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// MemoryLimiter counts requests in process memory. It is meant for tests and single instance setups,
+// every replica would count on its own.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	windows   map[string]*slidingWindow
+	lastSweep time.Time
+	Now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+type slidingWindow struct {
+	requests  []time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryLimiter is a constructor function that creates a new MemoryLimiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*tokenBucket),
+		windows: make(map[string]*slidingWindow),
+		Now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+	l.sweep(now)
+
+	key = policy.Algorithm + ":" + key
+	if policy.Algorithm == ALGORITHM_SLIDING_WINDOW {
+		return l.slidingWindow(key, policy, now), nil
+	}
+	return l.tokenBucket(key, policy, now), nil
+}
+
+// tokenBucket holds Limit tokens refilled evenly over the Window, every request takes one.
+func (l *MemoryLimiter) tokenBucket(key string, policy Policy, now time.Time) Result {
+	limit := float64(policy.Limit)
+	perToken := policy.Window / time.Duration(policy.Limit)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: limit, updatedAt: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(limit, bucket.tokens+float64(now.Sub(bucket.updatedAt))/float64(perToken))
+	bucket.updatedAt = now
+
+	result := Result{Limit: policy.Limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(bucket.tokens)
+	result.Reset = time.Duration((limit - bucket.tokens) * float64(perToken))
+	bucket.expiresAt = now.Add(result.Reset)
+	return result
+}
+
+// slidingWindow allows Limit requests in any Window long period.
+func (l *MemoryLimiter) slidingWindow(key string, policy Policy, now time.Time) Result {
+	window, ok := l.windows[key]
+	if !ok {
+		window = &slidingWindow{}
+		l.windows[key] = window
+	}
+
+	start := now.Add(-policy.Window)
+	kept := window.requests[:0]
+	for _, at := range window.requests {
+		if at.After(start) {
+			kept = append(kept, at)
+		}
+	}
+	window.requests = kept
+
+	result := Result{Limit: policy.Limit}
+	if len(window.requests) < policy.Limit {
+		window.requests = append(window.requests, now)
+		result.Allowed = true
+	}
+
+	result.Remaining = policy.Limit - len(window.requests)
+	result.Reset = window.requests[0].Add(policy.Window).Sub(now)
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+	window.expiresAt = now.Add(policy.Window)
+	return result
+}
+
+// sweep drops the counters that expired, at most once a minute.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.After(bucket.expiresAt) {
+			delete(l.buckets, key)
+		}
+	}
+	for key, window := range l.windows {
+		if now.After(window.expiresAt) {
+			delete(l.windows, key)
+		}
+	}
+}
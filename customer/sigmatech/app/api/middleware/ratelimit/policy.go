@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy limits the requests of a route to Limit per Window, counted per Key with Algorithm.
+// The zero Policy doesn't limit anything.
+type Policy struct {
+	Name      string
+	Limit     int
+	Window    time.Duration
+	Key       string
+	Algorithm string
+}
+
+// Result is the outcome of counting a request against a policy.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the full limit is available again, RetryAfter how long until the next
+	// request is allowed when this one wasn't.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// ParsePolicy parses a "<limit>/<window>[,<key>[,<algorithm>]]" policy such as "10/1m,ip,sliding_window".
+// The key defaults to ip and the algorithm to token_bucket, an empty spec gives the zero Policy.
+func ParsePolicy(name, spec string) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Policy{}, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) > 3 {
+		return Policy{}, fmt.Errorf("%w %s: %q", ErrInvalidPolicy, name, spec)
+	}
+
+	limit, window, ok := strings.Cut(strings.TrimSpace(parts[0]), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("%w %s: %q has no window", ErrInvalidPolicy, name, spec)
+	}
+
+	policy := Policy{Name: name, Key: KEY_IP, Algorithm: ALGORITHM_TOKEN_BUCKET}
+
+	var err error
+	if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit < 1 {
+		return Policy{}, fmt.Errorf("%w %s: limit %q", ErrInvalidPolicy, name, limit)
+	}
+	if policy.Window, err = time.ParseDuration(window); err != nil || policy.Window < time.Millisecond {
+		return Policy{}, fmt.Errorf("%w %s: window %q", ErrInvalidPolicy, name, window)
+	}
+
+	if len(parts) > 1 {
+		policy.Key = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		policy.Algorithm = strings.TrimSpace(parts[2])
+	}
+
+	switch policy.Key {
+	case KEY_IP, KEY_PRINCIPAL, KEY_API_KEY:
+	default:
+		return Policy{}, fmt.Errorf("%w %s: key %q", ErrInvalidPolicy, name, policy.Key)
+	}
+	switch policy.Algorithm {
+	case ALGORITHM_TOKEN_BUCKET, ALGORITHM_SLIDING_WINDOW:
+	default:
+		return Policy{}, fmt.Errorf("%w %s: algorithm %q", ErrInvalidPolicy, name, policy.Algorithm)
+	}
+
+	return policy, nil
+}
+
+// Enabled reports whether the policy limits anything.
+func (p Policy) Enabled() bool {
+	return p.Limit > 0
+}
+
+// SetHeaders writes the RateLimit headers of the result, and Retry-After when the request was refused.
+func SetHeaders(header http.Header, policy Policy, result Result) {
+	header.Set(HEADER_LIMIT, strconv.Itoa(result.Limit))
+	header.Set(HEADER_REMAINING, strconv.Itoa(result.Remaining))
+	header.Set(HEADER_RESET, strconv.Itoa(seconds(result.Reset)))
+	header.Set(HEADER_POLICY, fmt.Sprintf("%d;w=%d", policy.Limit, seconds(policy.Window)))
+
+	if !result.Allowed {
+		retryAfter := seconds(result.RetryAfter)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		header.Set(HEADER_RETRY_AFTER, strconv.Itoa(retryAfter))
+	}
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"customer/sigmatech/app/service/redis"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tokenBucketScript refills and takes from the bucket in one step, on the clock of Redis so every replica
+// counts alike. It returns whether the request is allowed, the remaining tokens, and the reset and retry
+// after in milliseconds.
+const tokenBucketScript = `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local per_token = window / limit
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1]) or limit
+local updated_at = tonumber(bucket[2]) or now
+tokens = math.min(limit, tokens + (now - updated_at) / per_token)
+
+local allowed, retry_after = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) * per_token)
+end
+
+local reset = math.ceil((limit - tokens) * per_token)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', now)
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry_after}
+`
+
+// slidingWindowScript keeps the requests of the window in a sorted set scored by their time. ARGV[3]
+// makes the member of the request unique.
+const slidingWindowScript = `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local reset = tonumber(oldest[2]) + window - now
+local retry_after = 0
+if allowed == 0 then
+	retry_after = reset
+end
+
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, limit - count, reset, retry_after}
+`
+
+// RedisLimiter counts requests in Redis, so the limits hold across every replica of the service.
+type RedisLimiter struct {
+	Redis redis.IRedisClient
+}
+
+// NewRedisLimiter is a constructor function that creates a new RedisLimiter.
+func NewRedisLimiter(Redis redis.IRedisClient) *RedisLimiter {
+	return &RedisLimiter{Redis: Redis}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	script, args := tokenBucketScript, []interface{}{policy.Limit, policy.Window.Milliseconds()}
+	if policy.Algorithm == ALGORITHM_SLIDING_WINDOW {
+		script, args = slidingWindowScript, append(args, uuid.NewString())
+	}
+
+	reply, err := l.Redis.Eval(script, []string{rateLimitKeyPrefix + policy.Algorithm + ":" + key}, args...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+		}
+	}
+
+	return Result{
+		Allowed:    numbers[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(numbers[1]),
+		Reset:      time.Duration(numbers[2]) * time.Millisecond,
+		RetryAfter: time.Duration(numbers[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package signature
+
+import "errors"
+
+const (
+	// Headers carried by a signed request
+	HEADER_SIGNATURE = "X-Signature"
+	HEADER_KEY_ID    = "X-Signature-Key"
+	HEADER_TIMESTAMP = "X-Signature-Timestamp"
+	HEADER_NONCE     = "X-Signature-Nonce"
+
+	SIGNATURE_PREFIX = "hmac-sha256="
+
+	// Nonce store backends
+	NONCE_STORE_MEMORY = "memory"
+	NONCE_STORE_REDIS  = "redis"
+
+	nonceKeyPrefix = "signature:nonce:"
+	nonceLength    = 16
+)
+
+var (
+	ErrMissingHeaders   = errors.New("signature headers are required")
+	ErrUnknownKey       = errors.New("unknown signature key")
+	ErrInvalidTimestamp = errors.New("invalid signature timestamp")
+	ErrClockSkew        = errors.New("signature timestamp is outside the allowed clock skew")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrReplayedNonce    = errors.New("nonce has already been used")
+)
+
+// IsVerificationError reports whether err means the request itself failed verification,
+// as opposed to the nonce store being unavailable.
+func IsVerificationError(err error) bool {
+	for _, e := range []error{ErrMissingHeaders, ErrUnknownKey, ErrInvalidTimestamp, ErrClockSkew, ErrInvalidSignature, ErrReplayedNonce} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package signature
+
+import (
+	"context"
+	"customer/sigmatech/app/service/redis"
+	"sync"
+	"time"
+)
+
+// INonceStore remembers the nonces of verified requests for as long as they could be replayed.
+type INonceStore interface {
+	// Remember records the nonce and reports whether it was seen for the first time.
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore keeps nonces in process memory. It is meant for tests and single instance setups.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	Now    func() time.Time
+}
+
+// NewMemoryNonceStore is a constructor function that creates a new MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: make(map[string]time.Time),
+		Now:    time.Now,
+	}
+}
+
+func (s *MemoryNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	for n, expiresAt := range s.nonces {
+		if now.After(expiresAt) {
+			delete(s.nonces, n)
+		}
+	}
+
+	if _, seen := s.nonces[nonce]; seen {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisNonceStore keeps nonces in Redis, so they are shared by every instance of the service.
+type RedisNonceStore struct {
+	Redis redis.IRedisClient
+}
+
+// NewRedisNonceStore is a constructor function that creates a new RedisNonceStore.
+func NewRedisNonceStore(redis redis.IRedisClient) *RedisNonceStore {
+	return &RedisNonceStore{Redis: redis}
+}
+
+func (s *RedisNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.Redis.SetNX(nonceKeyPrefix+nonce, "1", ttl)
+}
@@ -0,0 +1,79 @@
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IVerifier is an interface that defines the methods for verifying signed requests.
+type IVerifier interface {
+	// Verify checks the signature headers of the request and returns the key id it was signed with.
+	Verify(ctx context.Context, req *http.Request) (string, error)
+}
+
+// Verifier is a struct that implements the IVerifier interface.
+type Verifier struct {
+	Keys      map[string]string // secrets by key id
+	Nonces    INonceStore
+	Tolerance time.Duration // allowed clock skew between the signer and us
+	Now       func() time.Time
+}
+
+// NewVerifier is a constructor function that creates a new Verifier.
+func NewVerifier(keys map[string]string, nonces INonceStore, tolerance time.Duration) IVerifier {
+	return &Verifier{
+		Keys:      keys,
+		Nonces:    nonces,
+		Tolerance: tolerance,
+		Now:       time.Now,
+	}
+}
+
+func (v *Verifier) Verify(ctx context.Context, req *http.Request) (string, error) {
+	keyId := req.Header.Get(HEADER_KEY_ID)
+	signature := req.Header.Get(HEADER_SIGNATURE)
+	ts := req.Header.Get(HEADER_TIMESTAMP)
+	nonce := req.Header.Get(HEADER_NONCE)
+	if keyId == "" || signature == "" || ts == "" || nonce == "" {
+		return "", ErrMissingHeaders
+	}
+
+	secret, ok := v.Keys[keyId]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", ErrInvalidTimestamp
+	}
+
+	skew := v.Now().Sub(time.Unix(timestamp, 0))
+	if skew > v.Tolerance || skew < -v.Tolerance {
+		return "", ErrClockSkew
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	expected := Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", ErrInvalidSignature
+	}
+
+	// Only remember nonces of valid requests, a nonce outlives the window its timestamp is accepted in
+	fresh, err := v.Nonces.Remember(ctx, keyId+":"+nonce, 2*v.Tolerance)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", ErrReplayedNonce
+	}
+
+	return keyId, nil
+}
@@ -2,12 +2,17 @@ package server
 
 import (
 	"context"
+	"customer/sigmatech/app/api/middleware/apikey"
 	"customer/sigmatech/app/api/middleware/auth"
 	"customer/sigmatech/app/api/middleware/jwt"
+	"customer/sigmatech/app/api/middleware/ratelimit"
+	"customer/sigmatech/app/api/middleware/signature"
 	timeoutMiddleware "customer/sigmatech/app/api/middleware/timeout"
 	"customer/sigmatech/app/constants"
 	"customer/sigmatech/app/controller/healthcheck"
+	"customer/sigmatech/app/controller/wellknown"
 	"customer/sigmatech/app/db"
+	"customer/sigmatech/app/service/partnerconsent"
 
 	awsS3 "customer/sigmatech/app/service/aws/s3"
 
@@ -20,12 +25,48 @@ import (
 
 	variableGlobalDBClient "customer/sigmatech/app/db/repository/variable_global"
 
+	notificationController "customer/sigmatech/app/controller/notification"
+	notificationDBClient "customer/sigmatech/app/db/repository/notification"
+	notificationPreferenceDBClient "customer/sigmatech/app/db/repository/notification_preference"
+	notificationTemplateDBClient "customer/sigmatech/app/db/repository/notification_template"
+	webhookDeliveryDBClient "customer/sigmatech/app/db/repository/webhook_delivery"
+	webhookSubscriptionDBClient "customer/sigmatech/app/db/repository/webhook_subscription"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/redis"
+	"customer/sigmatech/app/service/webhook"
+
 	transactionController "customer/sigmatech/app/controller/transaction"
 	transactionDBClient "customer/sigmatech/app/db/repository/transaction"
 	transactionInstallmentDBClient "customer/sigmatech/app/db/repository/transaction_installment"
+	transactionService "customer/sigmatech/app/service/transaction"
+
+	partnerController "customer/sigmatech/app/controller/partner"
+	paymentController "customer/sigmatech/app/controller/payment"
+	loginThrottles_DBModels "customer/sigmatech/app/db/dto/login_throttles"
+	refreshTokens_DBModels "customer/sigmatech/app/db/dto/refresh_tokens"
+	sessions_DBModels "customer/sigmatech/app/db/dto/sessions"
+	emailVerificationDBClient "customer/sigmatech/app/db/repository/email_verification"
+	loginThrottleDBClient "customer/sigmatech/app/db/repository/login_throttle"
+	merchantDBClient "customer/sigmatech/app/db/repository/merchant"
+	merchantApiKeyDBClient "customer/sigmatech/app/db/repository/merchant_api_key"
+	partnerConsentDBClient "customer/sigmatech/app/db/repository/partner_consent"
+	passwordResetDBClient "customer/sigmatech/app/db/repository/password_reset"
+	paymentCallbackDBClient "customer/sigmatech/app/db/repository/payment_callback"
+	refreshTokenDBClient "customer/sigmatech/app/db/repository/refresh_token"
+	sessionDBClient "customer/sigmatech/app/db/repository/session"
+	virtualAccountDBClient "customer/sigmatech/app/db/repository/virtual_account"
+	apikeyService "customer/sigmatech/app/service/apikey"
+	"customer/sigmatech/app/service/emailverification"
+	"customer/sigmatech/app/service/ipgeolocation"
+	"customer/sigmatech/app/service/lockout"
+	"customer/sigmatech/app/service/mailer"
+	"customer/sigmatech/app/service/passwordreset"
+	"customer/sigmatech/app/service/payment"
+	"customer/sigmatech/app/service/session"
 
 	"customer/sigmatech/app/service/logger"
 	"strings"
+	"time"
 
 	helmet "github.com/danielkov/gin-helmet"
 	"github.com/gin-contrib/cors"
@@ -75,6 +116,12 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 
 	router := gin.New()
 
+	// Rate limits, lockouts and sessions key on the client IP, X-Forwarded-For is only believed from the
+	// configured proxies so clients can't pick their own
+	if err := router.SetTrustedProxies(constants.Config.HTTPServerConfig.HTTPSERVER_TRUSTED_PROXIES); err != nil {
+		log.Fatalf("Parsing the trusted proxies failed with error: %v", err)
+	}
+
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(helmet.Default())
@@ -93,7 +140,8 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
 	config.AllowMethods = []string{"GET", "POST", "PATCH", "DELETE", "PUT", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Accept", "Content-Type", constants.AUTHORIZATION, constants.CORRELATION_KEY_ID.String()}
+	config.AllowHeaders = []string{"Origin", "Accept", "Content-Type", constants.AUTHORIZATION, constants.API_KEY, constants.CORRELATION_KEY_ID.String(),
+		signature.HEADER_SIGNATURE, signature.HEADER_KEY_ID, signature.HEADER_TIMESTAMP, signature.HEADER_NONCE}
 	router.Use(cors.New(config))
 
 	router.Use(uuidInjectionMiddleware())
@@ -107,21 +155,78 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 		variableGlobalDBClient         = variableGlobalDBClient.NewVariableGlobalRepository(dbConnection)
 		transactionDBClient            = transactionDBClient.NewTransactionRepository(dbConnection)
 		transactionInstallmentDBClient = transactionInstallmentDBClient.NewTransactionInstallmentRepository(dbConnection)
+		notificationDBClient           = notificationDBClient.NewNotificationRepository(dbConnection)
+		notificationPreferenceDBClient = notificationPreferenceDBClient.NewNotificationPreferenceRepository(dbConnection)
+		notificationTemplateDBClient   = notificationTemplateDBClient.NewNotificationTemplateRepository(dbConnection)
+		webhookSubscriptionDBClient    = webhookSubscriptionDBClient.NewWebhookSubscriptionRepository(dbConnection)
+		webhookDeliveryDBClient        = webhookDeliveryDBClient.NewWebhookDeliveryRepository(dbConnection)
+		merchantDBClient               = merchantDBClient.NewMerchantRepository(dbConnection)
+		merchantApiKeyDBClient         = merchantApiKeyDBClient.NewMerchantApiKeyRepository(dbConnection)
+		partnerConsentDBClient         = partnerConsentDBClient.NewPartnerConsentRepository(dbConnection)
+		virtualAccountDBClient         = virtualAccountDBClient.NewVirtualAccountRepository(dbConnection)
+		paymentCallbackDBClient        = paymentCallbackDBClient.NewPaymentCallbackRepository(dbConnection)
+		refreshTokenDBClient           = refreshTokenDBClient.NewRefreshTokenRepository(dbConnection)
+		passwordResetDBClient          = passwordResetDBClient.NewPasswordResetRepository(dbConnection)
+		emailVerificationDBClient      = emailVerificationDBClient.NewEmailVerificationRepository(dbConnection)
+		loginThrottleDBClient          = loginThrottleDBClient.NewLoginThrottleRepository(dbConnection)
+		sessionDBClient                = sessionDBClient.NewSessionRepository(dbConnection)
 	)
 
 	// SERVICES
 	var (
-		jwt = jwt.NewJwtService(customerDBClient)
-		s3  = awsS3.NewS3Service()
+		refreshStore = jwt.NewPostgresRefreshTokenStore(refreshTokenDBClient, refreshTokens_DBModels.SUBJECT_CUSTOMER)
+		keys         = newKeySet(ctx)
+		jwt          = jwt.NewJwtService(customerDBClient, refreshStore, keys, newPrincipalCache(ctx))
+		s3           = awsS3.NewS3Service()
+
+		mail              = newMailer()
+		passwordReset     = passwordreset.NewPasswordResetService(customerDBClient, passwordResetDBClient, mail, jwt)
+		emailVerification = emailverification.NewEmailVerificationService(customerDBClient, emailVerificationDBClient, mail)
+		lockout           = lockout.NewLockoutService(loginThrottleDBClient, loginThrottles_DBModels.SUBJECT_CUSTOMER)
+		session           = session.NewSessionService(sessionDBClient, refreshStore, newGeolocation(), sessions_DBModels.SUBJECT_CUSTOMER)
+
+		notification = notification.NewNotificationService(notificationTemplateDBClient, notificationPreferenceDBClient, notificationDBClient)
+		webhook      = webhook.NewWebhookService(webhookSubscriptionDBClient, webhookDeliveryDBClient, webhook.NewClient(time.Duration(constants.Config.WebhookConfig.WEBHOOK_TIMEOUT)*time.Second))
+		transaction  = transactionService.NewTransactionService(customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient, variableGlobalDBClient, notification, webhook)
+		apiKey       = apikey.NewApiKeyService(merchantDBClient, merchantApiKeyDBClient)
+
+		partnerConsent = partnerconsent.NewPartnerConsentService(partnerConsentDBClient, notification)
+
+		simulator = newPaymentSimulator(ctx)
+		payment   = payment.NewPaymentService(customerDBClient, transactionDBClient, transactionInstallmentDBClient, virtualAccountDBClient, paymentCallbackDBClient, notification, webhook, paymentProviders(simulator)...)
+	)
+
+	// Signed requests are opt-in, routes only check them when SIGNATURE_ENABLED is set
+	signed := []gin.HandlerFunc{}
+	if constants.Config.SignatureConfig.SIGNATURE_ENABLED {
+		signed = append(signed, auth.Signature(newSignatureVerifier(ctx)))
+	}
+
+	// Rate limits are opt-in as well, their policies are disabled unless RATE_LIMIT_ENABLED is set
+	limiter := newRateLimiter(ctx)
+	var (
+		signInLimit             = auth.RateLimit(limiter, rateLimitPolicy(ctx, "customer_sign_in", constants.Config.RateLimitConfig.RATE_LIMIT_SIGN_IN))
+		signUpLimit             = auth.RateLimit(limiter, rateLimitPolicy(ctx, "customer_sign_up", constants.Config.RateLimitConfig.RATE_LIMIT_SIGN_UP))
+		refreshTokenLimit       = auth.RateLimit(limiter, rateLimitPolicy(ctx, "customer_refresh_token", constants.Config.RateLimitConfig.RATE_LIMIT_REFRESH_TOKEN))
+		passwordResetLimit      = auth.RateLimit(limiter, rateLimitPolicy(ctx, "customer_password_reset", constants.Config.RateLimitConfig.RATE_LIMIT_PASSWORD_RESET))
+		transactionLimit        = auth.RateLimit(limiter, rateLimitPolicy(ctx, "customer_transaction", constants.Config.RateLimitConfig.RATE_LIMIT_TRANSACTION))
+		partnerTransactionLimit = auth.RateLimit(limiter, rateLimitPolicy(ctx, "partner_transaction", constants.Config.RateLimitConfig.RATE_LIMIT_PARTNER_TRANSACTION))
 	)
 
 	// Controller
 	var (
-		healthCheckController = healthcheck.NewHealthCheckController()
-		customerController    = customerController.NewCustomerController(customerDBClient, cifDBClient, customerLimitDBClient, jwt, s3)
-		transactionController = transactionController.NewTransactionController(customerDBClient, customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient, variableGlobalDBClient)
+		healthCheckController  = healthcheck.NewHealthCheckController()
+		wellKnownController    = wellknown.NewWellKnownController(keys)
+		customerController     = customerController.NewCustomerController(customerDBClient, cifDBClient, customerLimitDBClient, jwt, s3, passwordReset, emailVerification, lockout, session)
+		transactionController  = transactionController.NewTransactionController(customerDBClient, customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient, transaction)
+		notificationController = notificationController.NewNotificationController(notificationDBClient, notificationPreferenceDBClient)
+		paymentController      = paymentController.NewPaymentController(virtualAccountDBClient, payment, simulator)
+		partnerController      = partnerController.NewPartnerController(customerDBClient, customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient, transaction, partnerConsent)
 	)
 
+	// Token verification keys, at the well-known path outside of the API versions
+	router.GET(JWKS, wellKnownController.GetJwks)
+
 	// API version v1
 	v1 := router.Group("/v1")
 	{
@@ -132,15 +237,22 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 		customer := v1.Group(CUSTOMER)
 		{
 			// Public customer sign-up and sign-in routes
-			v1.POST(CUSTOMER+SIGN_UP+"/", customerController.SignUp)
-			v1.POST(CUSTOMER+SIGN_IN+"/", customerController.SignIn)
-			v1.POST(CUSTOMER+REFRESH_TOKEN+"/", customerController.RefreshToken)
+			v1.POST(CUSTOMER+SIGN_UP+"/", signUpLimit, customerController.SignUp)
+			v1.POST(CUSTOMER+SIGN_IN+"/", signInLimit, customerController.SignIn)
+			v1.POST(CUSTOMER+REFRESH_TOKEN+"/", refreshTokenLimit, customerController.RefreshToken)
+			v1.POST(CUSTOMER+FORGOT_PASSWORD+"/", passwordResetLimit, customerController.ForgotPassword)
+			v1.POST(CUSTOMER+RESET_PASSWORD+"/", passwordResetLimit, customerController.ResetPassword)
+			v1.POST(CUSTOMER+VERIFY_EMAIL+"/", customerController.VerifyEmail)
+			v1.POST(CUSTOMER+RESEND_VERIFICATION+"/", customerController.ResendVerification)
 
 			// User profile routes
 			customer.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
 			customer.GET(PROFILE+"/", customerController.GetProfile)
 			customer.PATCH(PROFILE+"/", customerController.UpdateProfile)
 			customer.PATCH(PROFILE_PASSWORD+"/", customerController.UpdateProfilePassword)
+			customer.POST(LOGOUT+"/", customerController.Logout)
+			customer.GET(SESSIONS+"/", customerController.GetSessions)
+			customer.DELETE(SESSIONS+"/:id/", customerController.RevokeSession)
 
 			// Limit routes
 			limit := customer.Group(LIMIT)
@@ -148,22 +260,197 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 				limit.GET("/", customerController.GetLimits)
 			}
 
+			// Notification inbox routes
+			notification := customer.Group(NOTIFICATION)
+			{
+				notification.GET("/", notificationController.GetNotifications)
+				notification.GET(UNREAD_COUNT+"/", notificationController.GetUnreadCount)
+				notification.PATCH(READ+"/", notificationController.ReadAllNotifications)
+				notification.PATCH("/:id/"+READ+"/", notificationController.ReadNotification)
+				notification.GET(PREFERENCE+"/", notificationController.GetPreference)
+				notification.PATCH(PREFERENCE+"/", notificationController.UpdatePreference)
+			}
+
 		}
 
 		// Transaction routes
 		transaction := v1.Group(TRANSACTION)
 		{
 			transaction.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
-			transaction.POST("/", transactionController.CreateTransaction)
+			transaction.POST("/", transactionLimit, transactionController.CreateTransaction)
 			transaction.GET("/", transactionController.GetTransactions)
 			transaction.GET("/:id/", transactionController.GetTransaction)
 		}
 
+		// Payment routes
+		payment := v1.Group(PAYMENT)
+		{
+			// Public provider callbacks, verified with the provider signature
+			payment.POST(CALLBACK+"/:provider/", paymentController.Callback)
+
+			if simulator != nil {
+				payment.POST(SIMULATOR+"/"+PAY+"/", paymentController.SimulatePayment)
+			}
+
+			virtualAccount := payment.Group(VIRTUAL_ACCOUNT)
+			{
+				virtualAccount.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
+				virtualAccount.POST("/", paymentController.CreateVirtualAccount)
+				virtualAccount.GET("/", paymentController.GetVirtualAccounts)
+			}
+		}
+
+		// Partner routes, authenticated with a merchant API key instead of a customer token
+		partner := v1.Group(PARTNER)
+		{
+			partner.Use(auth.PartnerAuthentication(apiKey))
+			partner.Use(signed...)
+			partner.POST(TRANSACTION+"/"+CONSENT+"/", auth.RequireScope(apikeyService.SCOPE_TRANSACTION_CREATE), partnerController.RequestConsent)
+			partner.POST(TRANSACTION+"/", auth.RequireScope(apikeyService.SCOPE_TRANSACTION_CREATE), partnerTransactionLimit, partnerController.CreateTransaction)
+			partner.GET(TRANSACTION+"/", auth.RequireScope(apikeyService.SCOPE_TRANSACTION_READ), partnerController.GetTransactions)
+			partner.GET(TRANSACTION+"/:id/", auth.RequireScope(apikeyService.SCOPE_TRANSACTION_READ), partnerController.GetTransaction)
+		}
+
 	}
 
 	return router
 }
 
+// newGeolocation builds the cached geolocation client, sessions aren't located without an API key
+func newGeolocation() ipgeolocation.IClient {
+	if constants.Config.IPGeoLocationConfig.IPGEOLOCATION_API_KEY == "" {
+		return nil
+	}
+
+	return ipgeolocation.NewCachedClient(
+		ipgeolocation.NewClient(constants.Config.IPGeoLocationConfig.IPGEOLOCATION_API_KEY),
+		time.Duration(constants.Config.IPGeoLocationConfig.IPGEOLOCATION_CACHE_TTL)*time.Second,
+		constants.Config.IPGeoLocationConfig.IPGEOLOCATION_CACHE_SIZE,
+	)
+}
+
+// newPaymentSimulator builds the payment simulator when it is enabled. It signs the callbacks that mark
+// installments paid, so it is refused in production and without a secret.
+func newPaymentSimulator(ctx context.Context) *payment.SimulatorProvider {
+	if !constants.Config.PaymentConfig.PAYMENT_SIMULATOR_ENABLED {
+		return nil
+	}
+
+	log := logger.Logger(ctx)
+	if strings.EqualFold(constants.Config.Environment, string(constants.Production)) {
+		log.Fatalf("The payment simulator can't be enabled in production")
+	}
+	if constants.Config.PaymentConfig.PAYMENT_SIMULATOR_SECRET == "" {
+		log.Fatalf("The payment simulator is enabled without a PAYMENT_SIMULATOR_SECRET")
+	}
+	return payment.NewSimulatorProvider(constants.Config.PaymentConfig.PAYMENT_SIMULATOR_SECRET)
+}
+
+// paymentProviders lists the providers callbacks are accepted from, the simulator is the only one so far.
+// The first one issues the virtual accounts.
+func paymentProviders(simulator *payment.SimulatorProvider) []payment.IProvider {
+	var providers []payment.IProvider
+	if simulator != nil {
+		providers = append(providers, simulator)
+	}
+	return providers
+}
+
+// newMailer builds the mailer of the configured driver
+func newMailer() mailer.IMailer {
+	switch constants.Config.MailConfig.MAIL_DRIVER {
+	case mailer.DRIVER_SMTP:
+		return mailer.NewSMTPMailer(
+			constants.Config.MailConfig.MAIL_SMTP_HOST,
+			constants.Config.MailConfig.MAIL_SMTP_PORT,
+			constants.Config.MailConfig.MAIL_SMTP_USERNAME,
+			constants.Config.MailConfig.MAIL_SMTP_PASSWORD,
+			constants.Config.MailConfig.MAIL_FROM,
+		)
+	default:
+		return mailer.NewLogMailer()
+	}
+}
+
+// newKeySet loads the keys access tokens are signed with
+func newKeySet(ctx context.Context) *jwt.KeySet {
+	keys, err := jwt.LoadKeySet(
+		constants.Config.JwtConfig.JWT_SIGNING_ALG,
+		constants.Config.JwtConfig.JWT_KEYS_DIR,
+		constants.Config.JwtConfig.JWT_KEY_ID,
+		constants.Config.JwtConfig.JWT_ACCESS_SECRET,
+	)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Loading the token signing keys failed with error: %v", err)
+	}
+	return keys
+}
+
+// newPrincipalCache builds the cache of the customers behind access tokens with the configured backend
+func newPrincipalCache(ctx context.Context) jwt.IPrincipalCache {
+	ttl := time.Duration(constants.Config.JwtConfig.JWT_PRINCIPAL_TTL) * time.Second
+
+	switch constants.Config.JwtConfig.JWT_PRINCIPAL_CACHE {
+	case jwt.PRINCIPAL_CACHE_REDIS:
+		redisClient, err := redis.Init(ctx)
+		if err != nil {
+			logger.Logger(ctx).Fatalf("Redis connection for the principal cache failed with error: %v", err)
+		}
+		return jwt.NewRedisPrincipalCache(redisClient, ttl)
+	default:
+		return jwt.NewMemoryPrincipalCache(ttl)
+	}
+}
+
+// newRateLimiter builds the rate limiter of the configured backend
+func newRateLimiter(ctx context.Context) ratelimit.ILimiter {
+	if !constants.Config.RateLimitConfig.RATE_LIMIT_ENABLED || constants.Config.RateLimitConfig.RATE_LIMIT_STORE != ratelimit.STORE_REDIS {
+		return ratelimit.NewMemoryLimiter()
+	}
+
+	redisClient, err := redis.Init(ctx)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Redis connection for the rate limiter failed with error: %v", err)
+	}
+	return ratelimit.NewRedisLimiter(redisClient)
+}
+
+// rateLimitPolicy parses the policy of a route, it is disabled while rate limiting is off
+func rateLimitPolicy(ctx context.Context, name, spec string) ratelimit.Policy {
+	if !constants.Config.RateLimitConfig.RATE_LIMIT_ENABLED {
+		return ratelimit.Policy{}
+	}
+
+	policy, err := ratelimit.ParsePolicy(name, spec)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Parsing the rate limit policies failed with error: %v", err)
+	}
+	return policy
+}
+
+// newSignatureVerifier builds the request signature verifier with the configured nonce store
+func newSignatureVerifier(ctx context.Context) signature.IVerifier {
+	log := logger.Logger(ctx)
+
+	var nonces signature.INonceStore
+	switch constants.Config.SignatureConfig.SIGNATURE_NONCE_STORE {
+	case signature.NONCE_STORE_MEMORY:
+		nonces = signature.NewMemoryNonceStore()
+	default:
+		redisClient, err := redis.Init(ctx)
+		if err != nil {
+			log.Fatalf("Redis connection for the signature nonce store failed with error: %v", err)
+		}
+		nonces = signature.NewRedisNonceStore(redisClient)
+	}
+
+	return signature.NewVerifier(
+		signature.ParseKeys(constants.Config.SignatureConfig.SIGNATURE_KEYS),
+		nonces,
+		time.Duration(constants.Config.SignatureConfig.SIGNATURE_TOLERANCE)*time.Second,
+	)
+}
+
 // uuidInjectionMiddleware injects the request context with a correlation id of type uuid
 func uuidInjectionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
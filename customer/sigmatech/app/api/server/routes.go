@@ -3,6 +3,7 @@ package server
 const (
 	// General Routes
 	HEALTH_CHECK = "/health-check"
+	JWKS         = "/.well-known/jwks.json"
 
 	// Customer Routes
 	CUSTOMER = "/customer"
@@ -10,13 +11,38 @@ const (
 
 	PASSWORD = "password"
 
+	// Notification Routes
+	NOTIFICATION = "/notifications"
+	READ         = "read"
+	UNREAD_COUNT = "unread-count"
+	PREFERENCE   = "preference"
+
 	// Transaction Routes
 	TRANSACTION = "transaction"
 
+	// Payment Routes
+	PAYMENT         = "payment"
+	CALLBACK        = "callback"
+	VIRTUAL_ACCOUNT = "virtual-account"
+	SIMULATOR       = "simulator"
+	PAY             = "pay"
+
+	// Partner Routes
+	PARTNER = "partner"
+	CONSENT = "consent"
+
 	// Authentication Routes
 	SIGN_UP       = "/sign-up"
 	SIGN_IN       = "/sign-in"
 	REFRESH_TOKEN = "/refresh-token"
+	LOGOUT        = "/logout"
+	SESSIONS      = "/sessions"
+
+	FORGOT_PASSWORD = "/forgot-password"
+	RESET_PASSWORD  = "/reset-password"
+
+	VERIFY_EMAIL        = "/verify-email"
+	RESEND_VERIFICATION = "/resend-verification"
 
 	// Account Routes (Reused for Customer)
 	ACCOUNT          = "/account"
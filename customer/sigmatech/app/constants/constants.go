@@ -8,7 +8,11 @@ const (
 	//Header constants
 	AUTHORIZATION      = "Authorization"
 	BEARER             = "Bearer "
+	API_KEY            = "X-API-Key"
 	CTK_CLAIM_KEY      = CONTEXT_KEY("claims")
+	CTK_MERCHANT_KEY   = CONTEXT_KEY("merchant")
+	CTK_API_KEY_KEY    = CONTEXT_KEY("api_key")
+	CTK_SIGNATURE_KEY  = CONTEXT_KEY("signature_key")
 	CORRELATION_KEY_ID = CORRELATION_KEY("X-Correlation-ID")
 	DEFAULT_ID         = 1
 	STATUS_CODE        = "status_code"
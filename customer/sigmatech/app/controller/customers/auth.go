@@ -3,6 +3,7 @@ package customers
 import (
 	cif_DBModels "customer/sigmatech/app/db/dto/customer_information_files"
 	customerLimits_DBModels "customer/sigmatech/app/db/dto/customer_limits"
+	"customer/sigmatech/app/db/where"
 	reqCustomer "customer/sigmatech/app/service/dto/request/customer"
 	"errors"
 	"fmt"
@@ -164,7 +165,7 @@ func (u CustomerController) SignUp(c *gin.Context) {
 		return
 	}
 
-	fCheck := fmt.Sprintf("%s='%s'", cif_DBModels.COLUMN_NIK, dataFromBody.Nik)
+	fCheck := where.Eq(cif_DBModels.COLUMN_NIK, dataFromBody.Nik)
 	check, err := u.CIFDBClient.GetCustomerInformationFile(ctx, fCheck)
 	if err != nil {
 		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
@@ -292,6 +293,14 @@ func (u CustomerController) SignUp(c *gin.Context) {
 		}
 	}
 
+	// The verification email is sent in the background, a failed email must not fail the sign-up, the
+	// customer can ask for another one
+	go func() {
+		if err := u.EmailVerification.Send(ctx, customerData); err != nil {
+			log.Errorf("Error sending verification email to customer %s: %v", customerData.Uuid, err)
+		}
+	}()
+
 	// Create the vendor profile struct
 	customerProfile := struct {
 		Customer customers_DBModels.Customer          `json:"customer"`
@@ -321,7 +330,21 @@ func (u CustomerController) SignIn(c *gin.Context) {
 		return
 	}
 
-	filter := fmt.Sprintf("%s='%s'", customers_DBModels.COLUMN_EMAIL, dataFromBody.Email)
+	ip := c.ClientIP()
+
+	// A locked out account isn't told whether the password was right
+	lockedFor, err := u.Lockout.Check(ctx, dataFromBody.Email, ip)
+	if err != nil {
+		log.Errorf("Error checking sign-in lockout: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	if lockedFor > 0 {
+		controller.RespondWithLockout(c, lockedFor)
+		return
+	}
+
+	filter := where.Eq(customers_DBModels.COLUMN_EMAIL, dataFromBody.Email)
 
 	customer, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -332,10 +355,17 @@ func (u CustomerController) SignIn(c *gin.Context) {
 
 	if !util.ValidatePassword(dataFromBody.Password, customer.Password) {
 		log.Errorf("Wrong credentials")
+		if err := u.Lockout.Fail(ctx, dataFromBody.Email, ip); err != nil {
+			log.Errorf("Error counting failed sign-in: %v", err)
+		}
 		controller.RespondWithError(c, http.StatusUnauthorized, "Wrong credentials", errors.New(constants.UNAUTHORIZED_ACCESS))
 		return
 	}
 
+	if err := u.Lockout.Succeed(ctx, dataFromBody.Email); err != nil {
+		log.Errorf("Error clearing failed sign-ins: %v", err)
+	}
+
 	if !customer.IsActive {
 		controller.RespondWithError(c, http.StatusUnauthorized, "Harap tunggu untuk konfirmasi Admin", errors.New(constants.UNAUTHORIZED_ACCESS))
 		return
@@ -348,6 +378,18 @@ func (u CustomerController) SignIn(c *gin.Context) {
 		return
 	}
 
+	if err := u.CustomerDBClient.UpdateCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, customer.Uuid), map[string]interface{}{
+		customers_DBModels.COLUMN_LAST_LOGIN:    time.Now(),
+		customers_DBModels.COLUMN_LAST_LOGIN_IP: ip,
+	}); err != nil {
+		log.Errorf("Error recording last login of customer %s: %v", customer.Uuid, err)
+	}
+	if err := u.JWT.InvalidatePrincipal(ctx, customer.Uuid); err != nil {
+		log.Errorf("Error invalidating the cached customer %s: %v", customer.Uuid, err)
+	}
+
+	u.recordSession(ctx, c, token)
+
 	controller.RespondWithSuccess(c, http.StatusAccepted, "Login Successfully", token)
 }
 
@@ -365,5 +407,23 @@ func (u CustomerController) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	u.recordSession(ctx, c, claims)
+
 	controller.RespondWithSuccess(c, http.StatusAccepted, "Refresh Token Successfully", claims)
 }
+
+// Logout revokes the session of the access token, the refresh token issued with it can't be used anymore
+func (u CustomerController) Logout(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	tokenString := strings.TrimPrefix(c.GetHeader(constants.AUTHORIZATION), constants.BEARER)
+
+	if err := u.JWT.Logout(ctx, tokenString); err != nil {
+		log.Errorf("Error while revoking the session: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.LOGOUT_SUCCESSFULLY, nil)
+}
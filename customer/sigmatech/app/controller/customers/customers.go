@@ -6,6 +6,10 @@ import (
 	cifDB "customer/sigmatech/app/db/repository/customer_information_file"
 	customerLimitDB "customer/sigmatech/app/db/repository/customer_limit"
 	"customer/sigmatech/app/service/aws/s3"
+	"customer/sigmatech/app/service/emailverification"
+	"customer/sigmatech/app/service/lockout"
+	"customer/sigmatech/app/service/passwordreset"
+	"customer/sigmatech/app/service/session"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +19,13 @@ type ICustomerController interface {
 	SignUp(c *gin.Context)
 	SignIn(c *gin.Context)
 	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+	GetSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
 
 	GetProfile(c *gin.Context)
 	UpdateProfile(c *gin.Context)
@@ -32,6 +43,11 @@ type CustomerController struct {
 	JWT jwt.IJwtService
 
 	S3Client s3.IS3Client // S3Client represents the AWS S3 client for file storage.
+
+	PasswordReset     passwordreset.IPasswordResetService
+	EmailVerification emailverification.IEmailVerificationService
+	Lockout           lockout.ILockoutService
+	Session           session.ISessionService
 }
 
 // NewCustomerController is a constructor function that creates a new CustomerController.
@@ -41,6 +57,10 @@ func NewCustomerController(
 	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
 	jwt jwt.IJwtService,
 	S3Client s3.IS3Client,
+	PasswordReset passwordreset.IPasswordResetService,
+	EmailVerification emailverification.IEmailVerificationService,
+	Lockout lockout.ILockoutService,
+	Session session.ISessionService,
 ) ICustomerController {
 	return &CustomerController{
 		CustomerDBClient:      CustomerDBClient,
@@ -48,5 +68,9 @@ func NewCustomerController(
 		CustomerLimitDBClient: CustomerLimitDBClient,
 		JWT:                   jwt,
 		S3Client:              S3Client,
+		PasswordReset:         PasswordReset,
+		EmailVerification:     EmailVerification,
+		Lockout:               Lockout,
+		Session:               Session,
 	}
 }
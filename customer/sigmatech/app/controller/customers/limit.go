@@ -32,7 +32,13 @@ func (u CustomerController) GetLimits(c *gin.Context) {
 	}
 	p.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	f, err := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[customerLimits_DBModels.COLUMN_CUSTOMER_UUID] = usr.Uuid.String()
 
 	customerLimits, _, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, p, f)
@@ -0,0 +1,72 @@
+package customers
+
+import (
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/passwordreset"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword emails a reset OTP to the customer. It answers the same whether the email belongs to a
+// customer or not, the OTP is sent in the background so the response time doesn't tell either.
+func (u CustomerController) ForgotPassword(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var dataFromBody request.ForgotPassword
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.ValidateRequest(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	go func() {
+		if err := u.PasswordReset.Request(ctx, dataFromBody.Email); err != nil {
+			log.Errorf("unable to send the password reset otp: %v", err)
+		}
+	}()
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, "If the email belongs to an account, a reset code was sent to it", nil)
+}
+
+// ResetPassword sets a new password with the OTP sent by ForgotPassword, signing the customer out everywhere
+func (u CustomerController) ResetPassword(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var dataFromBody request.ResetPassword
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.ValidateRequest(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	if err := u.PasswordReset.Reset(ctx, dataFromBody.Email, dataFromBody.Otp, dataFromBody.Password); err != nil {
+		if errors.Is(err, passwordreset.ErrInvalidOtp) {
+			controller.RespondWithError(c, http.StatusBadRequest, "Invalid or expired otp", err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, "Password Reset Successfully", nil)
+}
@@ -5,6 +5,7 @@ import (
 	"customer/sigmatech/app/controller"
 	cif_DBModels "customer/sigmatech/app/db/dto/customer_information_files"
 	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	"customer/sigmatech/app/db/where"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -32,9 +33,7 @@ func (u CustomerController) GetProfile(c *gin.Context) {
 	}
 	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
 
-	customer, err := u.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	customer, err := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, usr.Email))
 	if err != nil {
 		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
 		log.Error(errorMsg)
@@ -43,7 +42,7 @@ func (u CustomerController) GetProfile(c *gin.Context) {
 	}
 	customer.Password = "" // Clear the password field for security reasons
 
-	fCIF := fmt.Sprintf("%s='%s'", cif_DBModels.COLUMN_CUSTOMER_UUID, customer.Uuid)
+	fCIF := where.Eq(cif_DBModels.COLUMN_CUSTOMER_UUID, customer.Uuid)
 
 	cif, err := u.CIFDBClient.GetCustomerInformationFile(ctx, fCIF)
 	if err != nil {
@@ -120,7 +119,7 @@ func (u CustomerController) UpdateProfile(c *gin.Context) {
 		patcher[cif_DBModels.COLUMN_SALARY] = dataFromBody.Salary
 	}
 
-	filter := fmt.Sprintf(`%s='%s'`, cif_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid) // Create a filter string to match the customer ID
+	filter := where.Eq(cif_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid) // Create a filter to match the customer ID
 
 	if err := u.CIFDBClient.UpdateCustomerInformationFile(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -134,14 +133,10 @@ func (u CustomerController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	r, _ := u.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	r, _ := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, usr.Email))
 	r.Password = ""
 
-	cifData, _ := u.CIFDBClient.GetCustomerInformationFile(ctx, fmt.Sprintf("%s='%s'",
-		cif_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid,
-	))
+	cifData, _ := u.CIFDBClient.GetCustomerInformationFile(ctx, where.Eq(cif_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid))
 
 	// Create the vendor profile struct
 	customerProfile := struct {
@@ -185,7 +180,16 @@ func (u CustomerController) UpdateProfilePassword(c *gin.Context) {
 		return
 	}
 
-	if !util.ValidatePassword(dataFromBody.OldPassword, usr.Password) {
+	// The customer of the token carries no password hash, it's read from the database
+	customer, err := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, usr.Uuid))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if !util.ValidatePassword(dataFromBody.OldPassword, customer.Password) {
 		log.Errorf("Wrong credentials")
 		controller.RespondWithError(c, http.StatusUnauthorized, "Password lama salah.", nil)
 		return
@@ -198,7 +202,7 @@ func (u CustomerController) UpdateProfilePassword(c *gin.Context) {
 		return
 	}
 
-	filter := fmt.Sprintf("%s='%s'", customers_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter string to match the customer ID
+	filter := where.Eq(customers_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter to match the customer ID
 
 	if err := u.CustomerDBClient.UpdateCustomer(ctx, filter, map[string]interface{}{
 		customers_DBModels.COLUMN_PASSWORD: hashedPassword,
@@ -209,9 +213,7 @@ func (u CustomerController) UpdateProfilePassword(c *gin.Context) {
 		return
 	}
 
-	r, _ := u.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	r, _ := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, usr.Email))
 	r.Password = ""
 
 	// Respond with success message and the updated customer profile (with password field cleared)
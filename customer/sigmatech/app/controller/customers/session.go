@@ -0,0 +1,96 @@
+package customers
+
+import (
+	"context"
+	"customer/sigmatech/app/api/middleware/jwt"
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/session"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetSessions lists the active sessions of the signed in customer, with history=true the ended ones too
+func (u CustomerController) GetSessions(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	customer := context.(*customers_DBModels.Customer)
+
+	sessions, err := u.Session.GetSessions(ctx, customer.Uuid, c.Query("history") == "true")
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, sessions)
+}
+
+// RevokeSession signs the customer out of one of their sessions
+func (u CustomerController) RevokeSession(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	customer := context.(*customers_DBModels.Customer)
+
+	sessionUuid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	if err := u.Session.Revoke(ctx, customer.Uuid, sessionUuid); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			controller.RespondWithError(c, http.StatusNotFound, "Session not found", err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	// The cached customer remembers the session as active
+	if err := u.JWT.InvalidatePrincipal(ctx, customer.Uuid); err != nil {
+		log.Errorf("Error invalidating the cached customer %s: %v", customer.Uuid, err)
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+// recordSession records the sign-in or refresh the tokens were issued for, a failure doesn't fail the request
+func (u CustomerController) recordSession(ctx context.Context, c *gin.Context, token *jwt.TokenDetails) {
+	if err := u.Session.Record(ctx, session.Login{
+		SessionUuid: token.FamilyUuid,
+		SubjectUuid: token.SubjectUuid,
+		Ip:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		ExpiresAt:   time.Unix(token.RtExpires, 0),
+	}); err != nil {
+		logger.Logger(ctx).Errorf("Error recording session %s: %v", token.FamilyUuid, err)
+	}
+}
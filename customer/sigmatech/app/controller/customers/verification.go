@@ -0,0 +1,71 @@
+package customers
+
+import (
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/emailverification"
+	"customer/sigmatech/app/service/logger"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail confirms the email of the customer with the token emailed at sign-up
+func (u CustomerController) VerifyEmail(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var dataFromBody request.VerifyEmail
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.ValidateRequest(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	if err := u.EmailVerification.Verify(ctx, dataFromBody.Token); err != nil {
+		if errors.Is(err, emailverification.ErrInvalidToken) {
+			controller.RespondWithError(c, http.StatusBadRequest, "Invalid or expired token", err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, "Email Verified Successfully", nil)
+}
+
+// ResendVerification sends a new verification token, it answers the same for unknown and verified emails
+func (u CustomerController) ResendVerification(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var dataFromBody request.ResendVerification
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.ValidateRequest(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	go func() {
+		if err := u.EmailVerification.Resend(ctx, dataFromBody.Email); err != nil {
+			log.Errorf("unable to resend the verification email: %v", err)
+		}
+	}()
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, "If the email needs verifying, a new token was sent to it", nil)
+}
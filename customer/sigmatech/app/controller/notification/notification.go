@@ -0,0 +1,347 @@
+package notification
+
+import (
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	notificationPreferences_DBModels "customer/sigmatech/app/db/dto/notification_preferences"
+	notifications_DBModels "customer/sigmatech/app/db/dto/notifications"
+	notificationDB "customer/sigmatech/app/db/repository/notification"
+	notificationPreferenceDB "customer/sigmatech/app/db/repository/notification_preference"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// INotificationController is an interface that defines the methods for a notification controller.
+type INotificationController interface {
+	GetNotifications(c *gin.Context)
+	GetUnreadCount(c *gin.Context)
+	ReadNotification(c *gin.Context)
+	ReadAllNotifications(c *gin.Context)
+
+	GetPreference(c *gin.Context)
+	UpdatePreference(c *gin.Context)
+}
+
+// NotificationController is a struct that implements the INotificationController interface.
+type NotificationController struct {
+	NotificationDBClient           notificationDB.INotificationRepository
+	NotificationPreferenceDBClient notificationPreferenceDB.INotificationPreferenceRepository
+}
+
+// NewNotificationController is a constructor function that creates a new NotificationController.
+func NewNotificationController(
+	NotificationDBClient notificationDB.INotificationRepository,
+	NotificationPreferenceDBClient notificationPreferenceDB.INotificationPreferenceRepository,
+) INotificationController {
+	return &NotificationController{
+		NotificationDBClient:           NotificationDBClient,
+		NotificationPreferenceDBClient: NotificationPreferenceDBClient,
+	}
+}
+
+// GetNotifications lists the inbox of the signed in customer, filterable by is_read and event_type
+func (u NotificationController) GetNotifications(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, notifications_DBModels.Notification{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[notifications_DBModels.COLUMN_CUSTOMER_UUID] = usr.Uuid.String()
+
+	notifications, paginationResponse, err := u.NotificationDBClient.GetNotifications(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, notifications, paginationResponse)
+}
+
+// GetUnreadCount returns the number of unread notifications of the signed in customer
+func (u NotificationController) GetUnreadCount(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	p := request.Pagination{
+		Limit: util.Int(1),
+		Page:  util.Int(1),
+	}
+	p.Validate()
+
+	f := map[string]interface{}{
+		notifications_DBModels.COLUMN_CUSTOMER_UUID: usr.Uuid.String(),
+		notifications_DBModels.COLUMN_IS_READ:       "false",
+	}
+
+	_, paginationResponse, err := u.NotificationDBClient.GetNotifications(ctx, p, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, struct {
+		Unread int `json:"unread"`
+	}{
+		Unread: paginationResponse.TotalCount,
+	})
+}
+
+// ReadNotification marks a single notification of the signed in customer as read
+func (u NotificationController) ReadNotification(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	id := c.Param("id")
+	filter := where.Eq(notifications_DBModels.COLUM_UUID, id).Eq(notifications_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String())
+
+	r, err := u.NotificationDBClient.GetNotification(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Notification not found", err)
+		return
+	}
+
+	if r.IsRead == nil || !*r.IsRead {
+		now := time.Now()
+
+		var patcher = make(map[string]interface{})
+		patcher[notifications_DBModels.COLUMN_IS_READ] = true
+		patcher[notifications_DBModels.COLUMN_READ_AT] = now
+		patcher[notifications_DBModels.COLUMN_UPDATED_AT] = now
+
+		if err := u.NotificationDBClient.UpdateNotification(ctx, filter, patcher); err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		r, _ = u.NotificationDBClient.GetNotification(ctx, filter)
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+// ReadAllNotifications marks every unread notification of the signed in customer as read
+func (u NotificationController) ReadAllNotifications(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	filter := where.Eq(notifications_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String()).Eq(notifications_DBModels.COLUMN_IS_READ, false)
+
+	now := time.Now()
+
+	var patcher = make(map[string]interface{})
+	patcher[notifications_DBModels.COLUMN_IS_READ] = true
+	patcher[notifications_DBModels.COLUMN_READ_AT] = now
+	patcher[notifications_DBModels.COLUMN_UPDATED_AT] = now
+
+	if err := u.NotificationDBClient.UpdateNotification(ctx, filter, patcher); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, nil)
+}
+
+// GetPreference returns the notification channels and locale of the signed in customer
+func (u NotificationController) GetPreference(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	filter := where.Eq(notificationPreferences_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String())
+
+	preference, err := u.NotificationPreferenceDBClient.GetNotificationPreference(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if preference.Uuid == uuid.Nil {
+		preference = notification.DefaultPreference(usr.Uuid)
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, preference)
+}
+
+// UpdatePreference stores the notification channels and locale of the signed in customer
+func (u NotificationController) UpdatePreference(c *gin.Context) {
+	ctx := correlation.WithReqContext(c) // Get the request context
+	log := logger.Logger(ctx)            // Get the logger
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	dataFromBody := notificationPreferences_DBModels.NotificationPreference{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	filter := where.Eq(notificationPreferences_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String())
+
+	preference, err := u.NotificationPreferenceDBClient.GetNotificationPreference(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	// First change of the customer, store the defaults merged with the request
+	if preference.Uuid == uuid.Nil {
+		now := time.Now()
+
+		preference = notification.DefaultPreference(usr.Uuid)
+		preference.Uuid = uuid.New()
+		preference.CreatedAt = now
+		preference.UpdatedAt = now
+
+		if dataFromBody.Locale != "" {
+			preference.Locale = dataFromBody.Locale
+		}
+		if dataFromBody.InApp != nil {
+			preference.InApp = dataFromBody.InApp
+		}
+		if dataFromBody.Email != nil {
+			preference.Email = dataFromBody.Email
+		}
+		if dataFromBody.Sms != nil {
+			preference.Sms = dataFromBody.Sms
+		}
+
+		if err := u.NotificationPreferenceDBClient.CreateNotificationPreference(ctx, &preference); err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, preference)
+		return
+	}
+
+	var patcher = make(map[string]interface{})
+
+	if dataFromBody.Locale != "" {
+		patcher[notificationPreferences_DBModels.COLUMN_LOCALE] = dataFromBody.Locale
+	}
+	if dataFromBody.InApp != nil {
+		patcher[notificationPreferences_DBModels.COLUMN_IN_APP] = *dataFromBody.InApp
+	}
+	if dataFromBody.Email != nil {
+		patcher[notificationPreferences_DBModels.COLUMN_EMAIL] = *dataFromBody.Email
+	}
+	if dataFromBody.Sms != nil {
+		patcher[notificationPreferences_DBModels.COLUMN_SMS] = *dataFromBody.Sms
+	}
+
+	patcher[notificationPreferences_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+	if err := u.NotificationPreferenceDBClient.UpdateNotificationPreference(ctx, filter, patcher); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	preference, _ = u.NotificationPreferenceDBClient.GetNotificationPreference(ctx, filter)
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, preference)
+}
@@ -0,0 +1,326 @@
+package partner
+
+import (
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	transactionController "customer/sigmatech/app/controller/transaction"
+	customerLimits_DBModels "customer/sigmatech/app/db/dto/customer_limits"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	merchants_DBModels "customer/sigmatech/app/db/dto/merchants"
+	partnerConsents_DBModels "customer/sigmatech/app/db/dto/partner_consents"
+	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
+	customerDB "customer/sigmatech/app/db/repository/customer"
+	customerLimitDB "customer/sigmatech/app/db/repository/customer_limit"
+	transactionDB "customer/sigmatech/app/db/repository/transaction"
+	transactionInstallmentDB "customer/sigmatech/app/db/repository/transaction_installment"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/dto/request"
+	partnerRequest "customer/sigmatech/app/service/dto/request/partner"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/partnerconsent"
+	transactionService "customer/sigmatech/app/service/transaction"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IPartnerController is an interface that defines the methods for the partner API.
+type IPartnerController interface {
+	RequestConsent(c *gin.Context)
+	CreateTransaction(c *gin.Context)
+	GetTransactions(c *gin.Context)
+	GetTransaction(c *gin.Context)
+}
+
+// PartnerController is a struct that implements the IPartnerController interface.
+type PartnerController struct {
+	CustomerDBClient               customerDB.ICustomerRepository
+	CustomerLimitDBClient          customerLimitDB.ICustomerLimitRepository
+	TransactionDBClient            transactionDB.ITransactionRepository
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
+	Transaction                    transactionService.ITransactionService
+	PartnerConsent                 partnerconsent.IPartnerConsentService
+}
+
+// NewPartnerController is a constructor function that creates a new PartnerController.
+func NewPartnerController(
+	CustomerDBClient customerDB.ICustomerRepository,
+	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
+	Transaction transactionService.ITransactionService,
+	PartnerConsent partnerconsent.IPartnerConsentService,
+) IPartnerController {
+	return &PartnerController{
+		CustomerDBClient:               CustomerDBClient,
+		CustomerLimitDBClient:          CustomerLimitDBClient,
+		TransactionDBClient:            TransactionDBClient,
+		TransactionInstallmentDBClient: TransactionInstallmentDBClient,
+		Transaction:                    Transaction,
+		PartnerConsent:                 PartnerConsent,
+	}
+}
+
+// RequestConsent sends an OTP to the customer for the transaction the merchant wants to book on their behalf
+func (u PartnerController) RequestConsent(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_MERCHANT_KEY.String()) // Retrieve the merchant context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	merchant := context.(*merchants_DBModels.Merchant) // Type assertion to retrieve the merchant information
+
+	dataFromBody := partnerRequest.ConsentRequest{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	customer, err := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, dataFromBody.CustomerEmail))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if customer.Uuid == uuid.Nil || !customer.IsActive {
+		controller.RespondWithError(c, http.StatusNotFound, "Customer not found", err)
+		return
+	}
+
+	fCustLimit := where.Eq(customerLimits_DBModels.COLUMN_CUSTOMER_UUID, customer.Uuid).Eq(customerLimits_DBModels.COLUMN_TERM, dataFromBody.Term)
+
+	customerLimit, err := u.CustomerLimitDBClient.GetCustomerLimit(ctx, fCustLimit)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if customerLimit.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Customer limit not found for the term", err)
+		return
+	}
+
+	data, err := u.PartnerConsent.Request(ctx, merchant, &customer, partnerConsents_DBModels.PartnerConsent{
+		CustomerLimitUuid: customerLimit.Uuid,
+		AssetName:         dataFromBody.AssetName,
+		Otr:               dataFromBody.Otr,
+	})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+// CreateTransaction books the transaction of a consent once the OTP of the customer matches
+func (u PartnerController) CreateTransaction(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_MERCHANT_KEY.String()) // Retrieve the merchant context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	merchant := context.(*merchants_DBModels.Merchant) // Type assertion to retrieve the merchant information
+
+	dataFromBody := partnerRequest.TransactionRequest{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	consent, err := u.PartnerConsent.Consume(ctx, merchant.Uuid, dataFromBody.ConsentUuid, dataFromBody.Otp)
+	if err != nil {
+		switch {
+		case errors.Is(err, partnerconsent.ErrConsentNotFound):
+			controller.RespondWithError(c, http.StatusNotFound, "Consent not found", err)
+		case errors.Is(err, partnerconsent.ErrConsentUsed):
+			controller.RespondWithError(c, http.StatusConflict, "Consent has already been used", err)
+		case errors.Is(err, partnerconsent.ErrConsentExpired):
+			controller.RespondWithError(c, http.StatusGone, "Consent has expired, request a new one", err)
+		case errors.Is(err, partnerconsent.ErrInvalidOtp):
+			controller.RespondWithError(c, http.StatusBadRequest, "Invalid otp", err)
+		default:
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		}
+		return
+	}
+
+	customer, err := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, consent.CustomerUuid))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if customer.Uuid == uuid.Nil || !customer.IsActive {
+		controller.RespondWithError(c, http.StatusNotFound, "Customer not found", err)
+		return
+	}
+
+	data, err := u.Transaction.Book(ctx, &customer, transactions_DBModels.Transaction{
+		CustomerLimitUuid: consent.CustomerLimitUuid,
+		MerchantUuid:      &merchant.Uuid,
+		AssetName:         consent.AssetName,
+		Otr:               consent.Otr,
+	})
+	if err != nil {
+		transactionController.RespondWithBookingError(c, err)
+		return
+	}
+
+	if err := u.PartnerConsent.Link(ctx, consent, data.Uuid); err != nil {
+		log.Errorf("unable to link consent %s to transaction %s: %v", consent.Uuid, data.Uuid, err)
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+// GetTransactions lists the contracts originated by the merchant
+func (u PartnerController) GetTransactions(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_MERCHANT_KEY.String()) // Retrieve the merchant context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	merchant := context.(*merchants_DBModels.Merchant) // Type assertion to retrieve the merchant information
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[transactions_DBModels.COLUMN_MERCHANT_UUID] = merchant.Uuid.String()
+
+	transactions, paginationResponse, err := u.TransactionDBClient.GetTransactions(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, transactions, paginationResponse)
+}
+
+// GetTransaction returns the status and installments of a contract originated by the merchant
+func (u PartnerController) GetTransaction(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_MERCHANT_KEY.String()) // Retrieve the merchant context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	merchant := context.(*merchants_DBModels.Merchant) // Type assertion to retrieve the merchant information
+
+	id := c.Param("id")
+	filter := where.Eq(transactions_DBModels.COLUM_UUID, id).Eq(transactions_DBModels.COLUMN_MERCHANT_UUID, merchant.Uuid.String())
+
+	r, err := u.TransactionDBClient.GetTransaction(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Transaction not found", err)
+		return
+	}
+
+	var transactionData struct {
+		Transaction             transactions_DBModels.Transaction                           `json:"transaction"`
+		TransactionInstallments []*transaction_installments_DBModels.TransactionInstallment `json:"transaction_installments"`
+	}
+
+	p := request.Pagination{
+		GetAllData: true,
+		Order:      transaction_installments_DBModels.COLUMN_TERM,
+		Sort:       "ASC",
+	}
+	p.Validate()
+
+	f := map[string]interface{}{
+		transaction_installments_DBModels.COLUMN_TRANSACTION_UUID: r.Uuid.String(),
+	}
+
+	transactionInstallments, _, err := u.TransactionInstallmentDBClient.GetTransactionInstallments(ctx, p, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	transactionData.Transaction = r
+	transactionData.TransactionInstallments = transactionInstallments
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, transactionData)
+}
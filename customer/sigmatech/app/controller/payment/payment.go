@@ -0,0 +1,221 @@
+package payment
+
+import (
+	"customer/sigmatech/app/constants"
+	"customer/sigmatech/app/controller"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	virtualAccounts_DBModels "customer/sigmatech/app/db/dto/virtual_accounts"
+	virtualAccountDB "customer/sigmatech/app/db/repository/virtual_account"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/dto/request"
+	paymentRequest "customer/sigmatech/app/service/dto/request/payment"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/payment"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPaymentController is an interface that defines the methods for a payment controller.
+type IPaymentController interface {
+	CreateVirtualAccount(c *gin.Context)
+	GetVirtualAccounts(c *gin.Context)
+	Callback(c *gin.Context)
+	SimulatePayment(c *gin.Context)
+}
+
+// PaymentController is a struct that implements the IPaymentController interface.
+type PaymentController struct {
+	VirtualAccountDBClient virtualAccountDB.IVirtualAccountRepository
+	Payment                payment.IPaymentService
+	Simulator              *payment.SimulatorProvider
+}
+
+// NewPaymentController is a constructor function that creates a new PaymentController.
+func NewPaymentController(
+	VirtualAccountDBClient virtualAccountDB.IVirtualAccountRepository,
+	Payment payment.IPaymentService,
+	Simulator *payment.SimulatorProvider,
+) IPaymentController {
+	return &PaymentController{
+		VirtualAccountDBClient: VirtualAccountDBClient,
+		Payment:                Payment,
+		Simulator:              Simulator,
+	}
+}
+
+// CreateVirtualAccount returns the virtual account the customer pays an installment, or any installment, into
+func (u PaymentController) CreateVirtualAccount(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	dataFromBody := paymentRequest.VirtualAccountRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil && !errors.Is(err, io.EOF) {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	data, err := u.Payment.IssueVirtualAccount(ctx, usr, dataFromBody.TransactionInstallmentUuid)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInstallmentNotFound):
+			controller.RespondWithError(c, http.StatusNotFound, "Installment not found", err)
+		case errors.Is(err, payment.ErrInstallmentAlreadyPaid):
+			controller.RespondWithError(c, http.StatusConflict, err.Error(), err)
+		case errors.Is(err, payment.ErrNoProvider):
+			controller.RespondWithError(c, http.StatusServiceUnavailable, err.Error(), err)
+		default:
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		}
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+// GetVirtualAccounts lists the virtual accounts of the customer
+func (u PaymentController) GetVirtualAccounts(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the customer context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, virtualAccounts_DBModels.VirtualAccount{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[virtualAccounts_DBModels.COLUMN_CUSTOMER_UUID] = usr.Uuid.String()
+
+	virtualAccounts, paginationResponse, err := u.VirtualAccountDBClient.GetVirtualAccounts(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, virtualAccounts, paginationResponse)
+}
+
+// Callback receives the payment notifications of a provider. It is public, callbacks are
+// authenticated by the provider signature instead.
+func (u PaymentController) Callback(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	data, err := u.Payment.HandleCallback(ctx, c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		respondWithCallbackError(c, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, data)
+}
+
+// SimulatePayment pays into a simulator virtual account by running a signed callback through
+// the same path as a real one. It is only routed outside production.
+func (u PaymentController) SimulatePayment(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	dataFromBody := paymentRequest.SimulatePaymentRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	header, body, err := u.Simulator.SimulatePayment(dataFromBody.AccountNumber, dataFromBody.Amount)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	data, err := u.Payment.HandleCallback(ctx, u.Simulator.Name(), header, body)
+	if err != nil {
+		respondWithCallbackError(c, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, data)
+}
+
+func respondWithCallbackError(c *gin.Context, err error) {
+	log := logger.Logger(correlation.WithReqContext(c))
+
+	switch {
+	case errors.Is(err, payment.ErrUnknownProvider), errors.Is(err, payment.ErrVirtualAccountNotFound):
+		controller.RespondWithError(c, http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, payment.ErrInvalidCallbackSignature):
+		controller.RespondWithError(c, http.StatusUnauthorized, err.Error(), err)
+	case errors.Is(err, payment.ErrInvalidCallback):
+		controller.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+	default:
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"customer/sigmatech/app/constants"
 	"customer/sigmatech/app/service/dto/response"
 	"customer/sigmatech/pkg/encrypt"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -41,3 +46,10 @@ func RespondWithSuccessAndPagination(c *gin.Context, code int, message string, d
 	c.Set(constants.STATUS_CODE, code)
 	c.JSON(code, response.ResponseV3{Success: true, Message: message, Data: data, Meta: pagination})
 }
+
+// RespondWithLockout refuses a request of a client locked out for lockedFor, telling it when to retry.
+func RespondWithLockout(c *gin.Context, lockedFor time.Duration) {
+	seconds := int(math.Ceil(lockedFor.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	RespondWithError(c, http.StatusTooManyRequests, constants.TOO_MANY_ATTEMPTS, fmt.Errorf("locked out for %d seconds", seconds))
+}
@@ -7,17 +7,16 @@ import (
 	customers_DBModels "customer/sigmatech/app/db/dto/customers"
 	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
 	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
-	variableGlobals_DBModels "customer/sigmatech/app/db/dto/variable_globals"
 	customerDB "customer/sigmatech/app/db/repository/customer"
 	customerLimitDB "customer/sigmatech/app/db/repository/customer_limit"
 	transactionDB "customer/sigmatech/app/db/repository/transaction"
 	transactionInstallmentDB "customer/sigmatech/app/db/repository/transaction_installment"
-	variableGlobalDB "customer/sigmatech/app/db/repository/variable_global"
+	"customer/sigmatech/app/db/where"
+	transactionService "customer/sigmatech/app/service/transaction"
 	"customer/sigmatech/app/service/util"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	"strconv"
-	"time"
 
 	"encoding/json"
 
@@ -42,7 +41,7 @@ type TransactionController struct {
 	CustomerLimitDBClient          customerLimitDB.ICustomerLimitRepository
 	TransactionDBClient            transactionDB.ITransactionRepository
 	transactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
-	variableGlobalDBClient         variableGlobalDB.IVariableGlobalRepository
+	Transaction                    transactionService.ITransactionService
 }
 
 // NewTransactionController is a constructor function that creates a new TransactionController.
@@ -51,14 +50,14 @@ func NewTransactionController(
 	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
 	TransactionDBClient transactionDB.ITransactionRepository,
 	transactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
-	variableGlobalDBClient variableGlobalDB.IVariableGlobalRepository,
+	Transaction transactionService.ITransactionService,
 ) ITransactionController {
 	return &TransactionController{
 		CustomerDBClient:               CustomerDBClient,
 		CustomerLimitDBClient:          CustomerLimitDBClient,
 		TransactionDBClient:            TransactionDBClient,
 		transactionInstallmentDBClient: transactionInstallmentDBClient,
-		variableGlobalDBClient:         variableGlobalDBClient,
+		Transaction:                    Transaction,
 	}
 }
 
@@ -91,194 +90,36 @@ func (u TransactionController) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	fCustLimit := fmt.Sprintf("%s='%s' AND %s='%s'",
-		customerLimits_DBModels.COLUM_UUID, dataFromBody.CustomerLimitUuid, customerLimits_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid,
-	)
+	// Transactions booked by the customer have no originating merchant
+	dataFromBody.MerchantUuid = nil
 
-	customerLimit, err := u.CustomerLimitDBClient.GetCustomerLimit(ctx, fCustLimit)
+	data, err := u.Transaction.Book(ctx, usr, dataFromBody)
 	if err != nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	if customerLimit.Uuid == uuid.Nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	fFeeAdmin := fmt.Sprintf("%s='%s'",
-		variableGlobals_DBModels.COLUMN_CODE, constants.VARIABLE_ADMIN_FEE,
-	)
-
-	variableGlobalFeeAdmin, err := u.variableGlobalDBClient.GetVariableGlobal(ctx, fFeeAdmin)
-	if err != nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	if variableGlobalFeeAdmin.Uuid == uuid.Nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	fFeeInterest := fmt.Sprintf("%s='%s'",
-		variableGlobals_DBModels.COLUMN_CODE, constants.VARIABLE_INTEREST_FEE,
-	)
-
-	variableGlobalFeeInterest, err := u.variableGlobalDBClient.GetVariableGlobal(ctx, fFeeInterest)
-	if err != nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	if variableGlobalFeeInterest.Uuid == uuid.Nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-		return
-	}
-
-	admin, _ := strconv.ParseFloat(variableGlobalFeeAdmin.Value, 64)
-	interest, _ := strconv.ParseFloat(variableGlobalFeeInterest.Value, 64)
-
-	// Calculate total interest
-	totalInterest := dataFromBody.Otr * interest / 100
-
-	// Calculate total repayment (Loan amount + Interest + Admin fee)
-	totalRepayment := dataFromBody.Otr + totalInterest + admin
-
-	if customerLimit.RemainingLimit < totalRepayment {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, "limit tidak mencukupi", err)
+		RespondWithBookingError(c, err)
 		return
 	}
 
-	// Calculate monthly installment
-	monthlyInstallment := totalRepayment / float64(customerLimit.Term)
-
-	contractNumber, err := u.TransactionDBClient.GenerateContractNumber(ctx)
-	if err != nil {
-		contractNumber = fmt.Sprintf("TX_%06d_%v", 1, time.Now().Unix())
-	}
-
-	data := transactions_DBModels.Transaction{
-		Uuid:              uuid.New(),
-		CustomerUuid:      usr.Uuid,
-		CustomerLimitUuid: customerLimit.Uuid,
-		AssetName:         dataFromBody.AssetName,
-		ContractNumber:    contractNumber,
-		IsDone:            util.Boolean(false),
-		Otr:               dataFromBody.Otr,
-		AdminFee:          admin,
-		Total:             totalRepayment,
-		InstallmentAmount: monthlyInstallment,
-		InstallmentCount:  customerLimit.Term,
-		TotalInterest:     totalInterest,
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
-	}
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
 
-	if err = u.TransactionDBClient.CreateTransaction(ctx, &data); err != nil {
-		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
-			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
-			return
-		}
+// RespondWithBookingError maps an error returned by ITransactionService.Book to the response.
+func RespondWithBookingError(c *gin.Context, err error) {
+	log := logger.Logger(correlation.WithReqContext(c))
 
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	if errors.Is(err, transactionService.ErrInsufficientLimit) {
+		log.Error(err.Error())
+		controller.RespondWithError(c, http.StatusInternalServerError, err.Error(), err)
 		return
 	}
-	// Set the initial date
-	currentDate := time.Now()
-
-	for i := 1; i <= customerLimit.Term; i++ {
-		nextMonth := currentDate.AddDate(0, i, 0)
-		dataInstallment := transaction_installments_DBModels.TransactionInstallment{
-			Uuid:            uuid.New(),
-			TransactionUuid: data.Uuid,
-			MethodPayment:   nil,
-			Term:            i,
-			DueDate:         &nextMonth,
-			PaymentAt:       nil,
-			Amount:          monthlyInstallment,
-			AmountPaid:      0,
-			CreatedAt:       currentDate,
-			UpdatedAt:       currentDate,
-		}
-
-		if err = u.transactionInstallmentDBClient.CreateTransactionInstallment(ctx, &dataInstallment); err != nil {
-			if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
-				controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
-				return
-			}
-
-			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-			log.Error(errorMsg)
-			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-			return
-		}
-
-	}
 
-	p := request.Pagination{
-		GetAllData: true,
-		Order:      customerLimits_DBModels.COLUMN_TERM,
-		Sort:       "ASC",
-	}
-	p.Validate()
-
-	f := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
-	f[customerLimits_DBModels.COLUMN_CUSTOMER_UUID] = usr.Uuid.String()
-
-	customerLimits, _, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, p, f)
-	if err != nil {
-		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-		log.Error(errorMsg)
-		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+		controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
 		return
 	}
-	remainingLimit := 0.0
-
-	for _, v := range customerLimits {
-		scalingFactor := v.RemainingLimit / customerLimit.RemainingLimit
-
-		remainingLimit = v.RemainingLimit - (totalRepayment * scalingFactor)
-
-		if remainingLimit < 0 {
-			remainingLimit = 0
-		}
-
-		var patcher = make(map[string]interface{}) // Create a patcher map to hold the fields to be updated
-
-		patcher[customerLimits_DBModels.COLUMN_REMAINING_LIMIT] = remainingLimit
 
-		fUpdLimit := fmt.Sprintf(`%s='%s'`, customerLimits_DBModels.COLUM_UUID, v.Uuid) // Create a filter string to match the customer ID
-
-		if err := u.CustomerLimitDBClient.UpdateCustomerLimit(ctx, fUpdLimit, patcher); err != nil {
-			if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
-				controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
-				return
-			}
-
-			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
-			log.Error(errorMsg)
-			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
-			return
-		}
-	}
-	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+	errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+	log.Error(errorMsg)
+	controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
 }
 
 func (u TransactionController) GetTransactions(c *gin.Context) {
@@ -305,7 +146,13 @@ func (u TransactionController) GetTransactions(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	f, err := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[transactions_DBModels.COLUMN_CUSTOMER_UUID] = usr.Uuid.String()
 
 	transactions, paginationResponse, err := u.TransactionDBClient.GetTransactions(ctx, pagination, f)
@@ -333,9 +180,7 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 	usr := context.(*customers_DBModels.Customer) // Type assertion to retrieve the customer information
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s' AND %s='%s'",
-		transactions_DBModels.COLUM_UUID, id, transactions_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String(),
-	)
+	filter := where.Eq(transactions_DBModels.COLUM_UUID, id).Eq(transactions_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid.String())
 
 	r, err := u.TransactionDBClient.GetTransaction(ctx, filter)
 	if err != nil {
@@ -364,7 +209,13 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 	}
 	p.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, transaction_installments_DBModels.TransactionInstallment{})
+	f, err := request.ExtractFilteredQueryParams(c, transaction_installments_DBModels.TransactionInstallment{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[transaction_installments_DBModels.COLUMN_TRANSACTION_UUID] = r.Uuid.String()
 
 	transactionInstallments, _, err := u.transactionInstallmentDBClient.GetTransactionInstallments(ctx, p, f)
@@ -0,0 +1,43 @@
+package login_throttles
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME             = "login_throttles"
+	COLUM_UUID             = "uuid"
+	COLUMN_SUBJECT_TYPE    = "subject_type"
+	COLUMN_SCOPE           = "scope"
+	COLUMN_IDENTIFIER      = "identifier"
+	COLUMN_FAILURES        = "failures"
+	COLUMN_LOCKOUTS        = "lockouts"
+	COLUMN_LOCKED_UNTIL    = "locked_until"
+	COLUMN_LAST_FAILURE_AT = "last_failure_at"
+	COLUMN_CREATED_AT      = "created_at"
+	COLUMN_UPDATED_AT      = "updated_at"
+
+	// Subjects that sign in
+	SUBJECT_USER     = "user"
+	SUBJECT_CUSTOMER = "customer"
+
+	// Scopes failed sign-ins are counted in, the identifier is the email or the IP
+	SCOPE_ACCOUNT = "account"
+	SCOPE_IP      = "ip"
+)
+
+// LoginThrottle counts the failed sign-ins of an account or an IP, Lockouts is how many times in a row
+// it was locked out, each lockout lasting longer than the last.
+type LoginThrottle struct {
+	Uuid          uuid.UUID  `json:"uuid"`
+	SubjectType   string     `json:"subject_type"`
+	Scope         string     `json:"scope"`
+	Identifier    string     `json:"identifier"`
+	Failures      int        `json:"failures"`
+	Lockouts      int        `json:"lockouts"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,73 @@
+package merchant_api_keys
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	TABLE_NAME           = "merchant_api_keys"
+	COLUM_UUID           = "uuid"
+	COLUMN_MERCHANT_UUID = "merchant_uuid"
+	COLUMN_NAME          = "name"
+	COLUMN_PREFIX        = "prefix"
+	COLUMN_KEY_HASH      = "key_hash"
+	COLUMN_SCOPES        = "scopes"
+	COLUMN_EXPIRES_AT    = "expires_at"
+	COLUMN_LAST_USED_AT  = "last_used_at"
+	COLUMN_REVOKED_AT    = "revoked_at"
+	COLUMN_CREATED_AT    = "created_at"
+	COLUMN_CREATED_BY    = "created_by"
+	COLUMN_UPDATED_AT    = "updated_at"
+)
+
+// MerchantApiKey is a credential of a merchant for the partner API. Only the SHA-256 hash of
+// the key is stored, Prefix identifies the key without revealing it. Scopes is a comma separated list.
+type MerchantApiKey struct {
+	Uuid         uuid.UUID  `json:"uuid"`
+	MerchantUuid uuid.UUID  `json:"merchant_uuid"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	KeyHash      string     `json:"-"`
+	Scopes       string     `json:"scopes"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CreatedBy    *uuid.UUID `json:"created_by"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (u *MerchantApiKey) Validate() error {
+	if u.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	if len(u.GetScopes()) == 0 {
+		return fmt.Errorf("scopes can't be empty")
+	}
+	if u.ExpiresAt != nil && u.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("expires at must be in the future")
+	}
+	return nil
+}
+
+// GetScopes splits the stored scopes into a slice.
+func (u *MerchantApiKey) GetScopes() []string {
+	var scopes []string
+	for _, scope := range strings.Split(u.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// IsUsable reports whether the key is neither revoked nor expired at the given time.
+func (u *MerchantApiKey) IsUsable(now time.Time) bool {
+	if u.RevokedAt != nil {
+		return false
+	}
+	return u.ExpiresAt == nil || u.ExpiresAt.After(now)
+}
@@ -0,0 +1,33 @@
+package notification_preferences
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME           = "notification_preferences"
+	COLUM_UUID           = "uuid"
+	COLUMN_CUSTOMER_UUID = "customer_uuid"
+	COLUMN_LOCALE        = "locale"
+	COLUMN_IN_APP        = "in_app"
+	COLUMN_EMAIL         = "email"
+	COLUMN_SMS           = "sms"
+	COLUMN_CREATED_AT    = "created_at"
+	COLUMN_UPDATED_AT    = "updated_at"
+)
+
+type NotificationPreference struct {
+	Uuid         uuid.UUID `json:"uuid"`
+	CustomerUuid uuid.UUID `json:"customer_uuid"`
+	Locale       string    `json:"locale"`
+	InApp        *bool     `json:"in_app"`
+	Email        *bool     `json:"email"`
+	Sms          *bool     `json:"sms"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (u *NotificationPreference) Validate() error {
+	return nil
+}
@@ -0,0 +1,65 @@
+package notification_templates
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"text/template"
+	"time"
+)
+
+const (
+	TABLE_NAME        = "notification_templates"
+	COLUM_UUID        = "uuid"
+	COLUMN_EVENT_TYPE = "event_type"
+	COLUMN_CHANNEL    = "channel"
+	COLUMN_LOCALE     = "locale"
+	COLUMN_SUBJECT    = "subject"
+	COLUMN_BODY       = "body"
+	COLUMN_IS_ACTIVE  = "is_active"
+	COLUMN_CREATED_AT = "created_at"
+	COLUMN_CREATED_BY = "created_by"
+	COLUMN_UPDATED_AT = "updated_at"
+	COLUMN_UPDATED_BY = "updated_by"
+)
+
+type NotificationTemplate struct {
+	Uuid      uuid.UUID  `json:"uuid"`
+	EventType string     `json:"event_type"`
+	Channel   string     `json:"channel"`
+	Locale    string     `json:"locale"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	IsActive  *bool      `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *uuid.UUID `json:"updated_by"`
+}
+
+func (u *NotificationTemplate) Validate() error {
+	if u.EventType == "" {
+		return fmt.Errorf("event type can't be empty")
+	}
+	if u.Channel == "" {
+		return fmt.Errorf("channel can't be empty")
+	}
+	if u.Locale == "" {
+		return fmt.Errorf("locale can't be empty")
+	}
+	if u.Subject == "" {
+		return fmt.Errorf("subject can't be empty")
+	}
+	if u.Body == "" {
+		return fmt.Errorf("body can't be empty")
+	}
+
+	// Make sure both parts are valid templates before they are stored
+	if _, err := template.New(COLUMN_SUBJECT).Parse(u.Subject); err != nil {
+		return fmt.Errorf("subject is not a valid template: %v", err)
+	}
+	if _, err := template.New(COLUMN_BODY).Parse(u.Body); err != nil {
+		return fmt.Errorf("body is not a valid template: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package partner_consents
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                 = "partner_consents"
+	COLUM_UUID                 = "uuid"
+	COLUMN_MERCHANT_UUID       = "merchant_uuid"
+	COLUMN_CUSTOMER_UUID       = "customer_uuid"
+	COLUMN_CUSTOMER_LIMIT_UUID = "customer_limit_uuid"
+	COLUMN_ASSET_NAME          = "asset_name"
+	COLUMN_OTR                 = "otr"
+	COLUMN_OTP_HASH            = "otp_hash"
+	COLUMN_ATTEMPTS            = "attempts"
+	COLUMN_EXPIRES_AT          = "expires_at"
+	COLUMN_CONSUMED_AT         = "consumed_at"
+	COLUMN_TRANSACTION_UUID    = "transaction_uuid"
+	COLUMN_CREATED_AT          = "created_at"
+	COLUMN_UPDATED_AT          = "updated_at"
+)
+
+// PartnerConsent is a transaction a merchant wants to book for a customer, waiting for the
+// customer to confirm it with the one time password sent to them.
+type PartnerConsent struct {
+	Uuid              uuid.UUID  `json:"uuid"`
+	MerchantUuid      uuid.UUID  `json:"merchant_uuid"`
+	CustomerUuid      uuid.UUID  `json:"customer_uuid"`
+	CustomerLimitUuid uuid.UUID  `json:"customer_limit_uuid"`
+	AssetName         string     `json:"asset_name"`
+	Otr               float64    `json:"otr"`
+	OtpHash           string     `json:"-"`
+	Attempts          int        `json:"attempts"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	ConsumedAt        *time.Time `json:"consumed_at"`
+	TransactionUuid   *uuid.UUID `json:"transaction_uuid"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (u *PartnerConsent) Validate() error {
+	return nil
+}
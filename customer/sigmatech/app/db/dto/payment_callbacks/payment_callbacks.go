@@ -0,0 +1,47 @@
+package payment_callbacks
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                          = "payment_callbacks"
+	COLUM_UUID                          = "uuid"
+	COLUMN_PROVIDER                     = "provider"
+	COLUMN_EXTERNAL_ID                  = "external_id"
+	COLUMN_VIRTUAL_ACCOUNT_UUID         = "virtual_account_uuid"
+	COLUMN_TRANSACTION_INSTALLMENT_UUID = "transaction_installment_uuid"
+	COLUMN_AMOUNT                       = "amount"
+	COLUMN_SURPLUS                      = "surplus"
+	COLUMN_PAID_AT                      = "paid_at"
+	COLUMN_STATUS                       = "status"
+	COLUMN_PAYLOAD                      = "payload"
+	COLUMN_CREATED_AT                   = "created_at"
+
+	// Status of a received callback
+	STATUS_PENDING   = "pending"   // received, its payment isn't applied yet
+	STATUS_APPLIED   = "applied"   // its payment was added to the installment
+	STATUS_UNAPPLIED = "unapplied" // the money arrived but there was no unpaid installment to apply it to
+	STATUS_OVERPAID  = "overpaid"  // it paid off its installment, the surplus above that waits for review
+)
+
+// PaymentCallback is a payment notification received from a provider. The provider and its
+// external id are unique, so a callback sent twice is only applied once.
+type PaymentCallback struct {
+	Uuid                       uuid.UUID  `json:"uuid"`
+	Provider                   string     `json:"provider"`
+	ExternalId                 string     `json:"external_id"`
+	VirtualAccountUuid         *uuid.UUID `json:"virtual_account_uuid"`
+	TransactionInstallmentUuid *uuid.UUID `json:"transaction_installment_uuid"`
+	Amount                     float64    `json:"amount"`
+	Surplus                    float64    `json:"surplus"`
+	PaidAt                     time.Time  `json:"paid_at"`
+	Status                     string     `json:"status"`
+	Payload                    string     `json:"payload"`
+	CreatedAt                  time.Time  `json:"created_at"`
+}
+
+func (u *PaymentCallback) Validate() error {
+	return nil
+}
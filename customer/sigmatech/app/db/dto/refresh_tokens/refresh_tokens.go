@@ -0,0 +1,35 @@
+package refresh_tokens
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME          = "refresh_tokens"
+	COLUM_UUID          = "uuid"
+	COLUMN_FAMILY_UUID  = "family_uuid"
+	COLUMN_SUBJECT_UUID = "subject_uuid"
+	COLUMN_SUBJECT_TYPE = "subject_type"
+	COLUMN_EXPIRES_AT   = "expires_at"
+	COLUMN_ROTATED_AT   = "rotated_at"
+	COLUMN_REVOKED_AT   = "revoked_at"
+	COLUMN_CREATED_AT   = "created_at"
+
+	// Subjects a refresh token is issued to
+	SUBJECT_USER     = "user"
+	SUBJECT_CUSTOMER = "customer"
+)
+
+// RefreshToken is an issued refresh token. Tokens rotated from one another share a family, which is
+// the session started by a sign in.
+type RefreshToken struct {
+	Uuid        uuid.UUID  `json:"uuid"`
+	FamilyUuid  uuid.UUID  `json:"family_uuid"`
+	SubjectUuid uuid.UUID  `json:"subject_uuid"`
+	SubjectType string     `json:"subject_type"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RotatedAt   *time.Time `json:"rotated_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
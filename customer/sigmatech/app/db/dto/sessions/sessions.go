@@ -0,0 +1,60 @@
+package sessions
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME          = "sessions"
+	COLUM_UUID          = "uuid"
+	COLUMN_SUBJECT_UUID = "subject_uuid"
+	COLUMN_SUBJECT_TYPE = "subject_type"
+	COLUMN_IP           = "ip"
+	COLUMN_USER_AGENT   = "user_agent"
+	COLUMN_DEVICE       = "device"
+	COLUMN_COUNTRY_CODE = "country_code"
+	COLUMN_COUNTRY_NAME = "country_name"
+	COLUMN_CITY         = "city"
+	COLUMN_NEW_COUNTRY  = "new_country"
+	COLUMN_EXPIRES_AT   = "expires_at"
+	COLUMN_LAST_SEEN_AT = "last_seen_at"
+	COLUMN_CREATED_AT   = "created_at"
+	COLUMN_UPDATED_AT   = "updated_at"
+
+	// Subjects that sign in
+	SUBJECT_USER     = "user"
+	SUBJECT_CUSTOMER = "customer"
+
+	// Devices told apart by the user agent
+	DEVICE_DESKTOP = "desktop"
+	DEVICE_MOBILE  = "mobile"
+	DEVICE_TABLET  = "tablet"
+	DEVICE_UNKNOWN = "unknown"
+)
+
+// Session is a sign-in, its uuid is the family of the refresh tokens issued for it. Refreshing updates
+// where it was last seen from. The location is resolved after the session is recorded, NewCountry is
+// set when the subject never signed in from that country before.
+type Session struct {
+	Uuid        uuid.UUID `json:"uuid"`
+	SubjectUuid uuid.UUID `json:"subject_uuid"`
+	SubjectType string    `json:"subject_type"`
+	Ip          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Device      string    `json:"device"`
+	CountryCode *string   `json:"country_code"`
+	CountryName *string   `json:"country_name"`
+	City        *string   `json:"city"`
+	NewCountry  bool      `json:"new_country"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SessionDetail is a session with whether it can still be used.
+type SessionDetail struct {
+	Session
+	Active bool `json:"active"`
+}
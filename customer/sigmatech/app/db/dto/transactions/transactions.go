@@ -11,6 +11,7 @@ const (
 	COLUM_UUID                 = "uuid"
 	COLUMN_CUSTOMER_UUID       = "customer_uuid"
 	COLUMN_CUSTOMER_LIMIT_UUID = "customer_limit_uuid"
+	COLUMN_MERCHANT_UUID       = "merchant_uuid"
 	COLUMN_ASSET_NAME          = "asset_name"
 	COLUMN_CONTRACT_NUMBER     = "contract_number"
 	COLUMN_IS_DONE             = "is_done"
@@ -25,20 +26,21 @@ const (
 )
 
 type Transaction struct {
-	Uuid              uuid.UUID `json:"uuid"`
-	CustomerUuid      uuid.UUID `json:"customer_uuid"`
-	CustomerLimitUuid uuid.UUID `json:"customer_limit_uuid"`
-	AssetName         string    `json:"asset_name"`
-	ContractNumber    string    `json:"contract_number"`
-	IsDone            *bool     `json:"is_done"`
-	Otr               float64   `json:"otr"`
-	AdminFee          float64   `json:"admin_fee"`
-	Total             float64   `json:"total"`
-	InstallmentAmount float64   `json:"installment_amount"`
-	InstallmentCount  int       `json:"installment_count"`
-	TotalInterest     float64   `json:"total_interest"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	Uuid              uuid.UUID  `json:"uuid"`
+	CustomerUuid      uuid.UUID  `json:"customer_uuid"`
+	CustomerLimitUuid uuid.UUID  `json:"customer_limit_uuid"`
+	MerchantUuid      *uuid.UUID `json:"merchant_uuid"`
+	AssetName         string     `json:"asset_name"`
+	ContractNumber    string     `json:"contract_number"`
+	IsDone            *bool      `json:"is_done"`
+	Otr               float64    `json:"otr"`
+	AdminFee          float64    `json:"admin_fee"`
+	Total             float64    `json:"total"`
+	InstallmentAmount float64    `json:"installment_amount"`
+	InstallmentCount  int        `json:"installment_count"`
+	TotalInterest     float64    `json:"total_interest"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 func (u *Transaction) Validate() error {
@@ -0,0 +1,47 @@
+package virtual_accounts
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                          = "virtual_accounts"
+	COLUM_UUID                          = "uuid"
+	COLUMN_PROVIDER                     = "provider"
+	COLUMN_ACCOUNT_NUMBER               = "account_number"
+	COLUMN_CUSTOMER_UUID                = "customer_uuid"
+	COLUMN_TRANSACTION_INSTALLMENT_UUID = "transaction_installment_uuid"
+	COLUMN_AMOUNT                       = "amount"
+	COLUMN_IS_ACTIVE                    = "is_active"
+	COLUMN_EXPIRES_AT                   = "expires_at"
+	COLUMN_CREATED_AT                   = "created_at"
+	COLUMN_UPDATED_AT                   = "updated_at"
+)
+
+// VirtualAccount is an account number issued by a payment provider that a customer pays into.
+// It is bound to one installment, or to the customer when TransactionInstallmentUuid is empty.
+type VirtualAccount struct {
+	Uuid                       uuid.UUID  `json:"uuid"`
+	Provider                   string     `json:"provider"`
+	AccountNumber              string     `json:"account_number"`
+	CustomerUuid               uuid.UUID  `json:"customer_uuid"`
+	TransactionInstallmentUuid *uuid.UUID `json:"transaction_installment_uuid"`
+	Amount                     *float64   `json:"amount"`
+	IsActive                   *bool      `json:"is_active"`
+	ExpiresAt                  *time.Time `json:"expires_at"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+func (u *VirtualAccount) Validate() error {
+	return nil
+}
+
+// IsUsable reports whether the virtual account can still receive payments at the given time.
+func (u *VirtualAccount) IsUsable(now time.Time) bool {
+	if u.IsActive == nil || !*u.IsActive {
+		return false
+	}
+	return u.ExpiresAt == nil || now.Before(*u.ExpiresAt)
+}
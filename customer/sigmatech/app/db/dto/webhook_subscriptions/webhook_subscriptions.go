@@ -0,0 +1,65 @@
+package webhook_subscriptions
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TABLE_NAME         = "webhook_subscriptions"
+	COLUM_UUID         = "uuid"
+	COLUMN_NAME        = "name"
+	COLUMN_URL         = "url"
+	COLUMN_EVENT_TYPES = "event_types"
+	COLUMN_SECRET      = "secret"
+	COLUMN_IS_ACTIVE   = "is_active"
+	COLUMN_CREATED_AT  = "created_at"
+	COLUMN_CREATED_BY  = "created_by"
+	COLUMN_UPDATED_AT  = "updated_at"
+	COLUMN_UPDATED_BY  = "updated_by"
+)
+
+// WebhookSubscription is a partner endpoint that receives the events listed in EventTypes.
+// EventTypes is stored as a comma separated list.
+type WebhookSubscription struct {
+	Uuid       uuid.UUID  `json:"uuid"`
+	Name       string     `json:"name"`
+	Url        string     `json:"url"`
+	EventTypes string     `json:"event_types"`
+	Secret     string     `json:"secret,omitempty"`
+	IsActive   *bool      `json:"is_active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  *uuid.UUID `json:"created_by"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UpdatedBy  *uuid.UUID `json:"updated_by"`
+}
+
+func (u *WebhookSubscription) Validate() error {
+	if u.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	if u.Url == "" {
+		return fmt.Errorf("url can't be empty")
+	}
+	if parsed, err := url.ParseRequestURI(u.Url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https url")
+	}
+	if len(u.GetEventTypes()) == 0 {
+		return fmt.Errorf("event types can't be empty")
+	}
+	return nil
+}
+
+// GetEventTypes splits the stored event types into a slice.
+func (u *WebhookSubscription) GetEventTypes() []string {
+	var eventTypes []string
+	for _, eventType := range strings.Split(u.EventTypes, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes
+}
@@ -5,10 +5,11 @@ import (
 	"customer/sigmatech/app/constants"
 	db "customer/sigmatech/app/db"
 	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
 	"customer/sigmatech/app/service/dto/request"
 	"customer/sigmatech/app/service/dto/response"
 	"customer/sigmatech/app/service/util"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,10 +20,10 @@ import (
 
 type ICustomerRepository interface {
 	CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error
-	GetCustomer(ctx context.Context, whr string) (customers_DBModels.Customer, error)
+	GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error)
 	GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error)
-	UpdateCustomer(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteCustomer(ctx context.Context, filter string) error
+	UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteCustomer(ctx context.Context, filter where.Filter) error
 }
 
 type CustomerRepository struct {
@@ -50,11 +51,11 @@ func (u *CustomerRepository) CreateCustomer(ctx context.Context, customer *custo
 	return nil // Return the created customer and no error
 }
 
-func (u *CustomerRepository) GetCustomer(ctx context.Context, whr string) (customers_DBModels.Customer, error) {
+func (u *CustomerRepository) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer customers_DBModels.Customer                       // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customers_DBModels.Customer{}, nil // Return an empty customer if the record is not found
 		}
@@ -73,16 +74,8 @@ func (u *CustomerRepository) GetCustomers(ctx context.Context, paginationRequest
 		customers_DBModels.COLUMN_EMAIL,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	// Count isn't given the model, so deleted rows are left out here rather than by gorm
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope, where.IsNull(tableName+"."+customers_DBModels.COLUMN_DELETED_AT).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -97,34 +90,11 @@ func (u *CustomerRepository) GetCustomers(ctx context.Context, paginationRequest
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, customers_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -133,7 +103,7 @@ func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, pat
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if customer update fails
 		return err
 	}
@@ -141,7 +111,7 @@ func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, pat
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter string) error {
+func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -150,7 +120,7 @@ func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter string)
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&customers_DBModels.Customer{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&customers_DBModels.Customer{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
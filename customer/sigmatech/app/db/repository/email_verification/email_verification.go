@@ -0,0 +1,78 @@
+package email_verification
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	db "customer/sigmatech/app/db"
+	emailVerifications_DBModels "customer/sigmatech/app/db/dto/email_verifications"
+	"customer/sigmatech/app/db/where"
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+type IEmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error
+	GetEmailVerification(ctx context.Context, whr where.Filter) (emailVerifications_DBModels.EmailVerification, error)
+	CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error)
+	UpdateEmailVerification(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+}
+
+type EmailVerificationRepository struct {
+	DBService *db.DBService
+}
+
+func NewEmailVerificationRepository(dbService *db.DBService) IEmailVerificationRepository {
+	return &EmailVerificationRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *EmailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(emailVerifications_DBModels.TABLE_NAME).Create(verification).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *EmailVerificationRepository) GetEmailVerification(ctx context.Context, whr where.Filter) (emailVerifications_DBModels.EmailVerification, error) {
+	tx := u.DBService.GetDB().Table(emailVerifications_DBModels.TABLE_NAME)
+	var verification emailVerifications_DBModels.EmailVerification
+
+	if err := tx.Scopes(whr.Scope).First(&verification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return emailVerifications_DBModels.EmailVerification{}, nil
+		}
+
+		return verification, err
+	}
+
+	return verification, nil
+}
+
+func (u *EmailVerificationRepository) CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error) {
+	var count int
+	if err := u.DBService.GetDB().Table(emailVerifications_DBModels.TABLE_NAME).Scopes(whr.Scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdateEmailVerification returns how many verifications were updated, so a token consumed concurrently can be told apart.
+func (u *EmailVerificationRepository) UpdateEmailVerification(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(emailVerifications_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,181 @@
+package partner_consent
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	db "customer/sigmatech/app/db"
+	partnerConsents_DBModels "customer/sigmatech/app/db/dto/partner_consents"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/util"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+type IPartnerConsentRepository interface {
+	CreatePartnerConsent(ctx context.Context, customer *partnerConsents_DBModels.PartnerConsent) error
+	GetPartnerConsent(ctx context.Context, whr where.Filter) (partnerConsents_DBModels.PartnerConsent, error)
+	GetPartnerConsents(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*partnerConsents_DBModels.PartnerConsent, response.Pagination, error)
+	UpdatePartnerConsent(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	AddPartnerConsentAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error)
+	DeletePartnerConsent(ctx context.Context, filter where.Filter) error
+}
+
+type PartnerConsentRepository struct {
+	DBService *db.DBService
+}
+
+func NewPartnerConsentRepository(dbService *db.DBService) IPartnerConsentRepository {
+	return &PartnerConsentRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = partnerConsents_DBModels.TABLE_NAME
+
+func (u *PartnerConsentRepository) CreatePartnerConsent(ctx context.Context, customer *partnerConsents_DBModels.PartnerConsent) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(partnerConsents_DBModels.TABLE_NAME).Create(&customer).Error; err != nil {
+		return err // Return the error if customer creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil // Return the created customer and no error
+}
+
+func (u *PartnerConsentRepository) GetPartnerConsent(ctx context.Context, whr where.Filter) (partnerConsents_DBModels.PartnerConsent, error) {
+	tx := u.DBService.GetDB().Table(partnerConsents_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer partnerConsents_DBModels.PartnerConsent                 // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return partnerConsents_DBModels.PartnerConsent{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *PartnerConsentRepository) GetPartnerConsents(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*partnerConsents_DBModels.PartnerConsent, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(partnerConsents_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		partnerConsents_DBModels.COLUMN_ASSET_NAME,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, partnerConsents_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+// UpdatePartnerConsent returns how many consents were updated, so a consent consumed concurrently can be told apart.
+func (u *PartnerConsentRepository) UpdatePartnerConsent(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(partnerConsents_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// AddPartnerConsentAttempt counts an OTP attempt on the consents matched by whr that have had fewer than
+// maxAttempts, in SQL so concurrent attempts are all counted. It returns how many consents were updated.
+func (u *PartnerConsentRepository) AddPartnerConsentAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error) {
+	tx := u.DBService.GetDB().Table(partnerConsents_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Lt(partnerConsents_DBModels.COLUMN_ATTEMPTS, maxAttempts).Scope).Updates(map[string]interface{}{
+		partnerConsents_DBModels.COLUMN_ATTEMPTS:   gorm.Expr(partnerConsents_DBModels.COLUMN_ATTEMPTS + " + 1"),
+		partnerConsents_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (u *PartnerConsentRepository) DeletePartnerConsent(ctx context.Context, filter where.Filter) error {
+	tx := u.DBService.GetDB().Table(partnerConsents_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(filter.Scope).Delete(&partnerConsents_DBModels.PartnerConsent{}).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if the deletion fails
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			// Foreign key constraint violation detected, rollback the transaction
+			tx.Rollback()
+
+			// Start a new transaction for the schema query
+			schemaTx := u.DBService.GetDB().Begin()
+			defer func() {
+				if r := recover(); r != nil {
+					schemaTx.Rollback() // Rollback the schema transaction if a panic occurs
+				}
+			}()
+
+			// Query the database schema to get tables with foreign key constraints referencing the specified table
+			var foreignKeys []struct {
+				ConstraintName   string
+				ReferencingTable string
+			}
+			err := schemaTx.Raw(`
+			SELECT con.conname AS constraint_name, con.conrelid::regclass AS referencing_table
+			FROM pg_constraint con
+			WHERE con.contype = 'f'
+			AND con.confrelid = ?::regclass`, tableName).Scan(&foreignKeys).Error
+
+			if err != nil {
+				return err
+			}
+
+			var referencingTables []string
+			// Print or process the foreign key information
+			for _, fk := range foreignKeys {
+				referencingTables = append(referencingTables, fk.ReferencingTable)
+			}
+
+			// Rollback the schema transaction
+			schemaTx.Rollback()
+
+			// Return the foreign key constraint violation error
+			return fmt.Errorf("cannot delete from table %s because of foreign key constraints on tables %s", tableName, strings.Join(referencingTables, ", "))
+		}
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
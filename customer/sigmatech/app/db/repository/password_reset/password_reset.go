@@ -0,0 +1,100 @@
+package password_reset
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	db "customer/sigmatech/app/db"
+	passwordResets_DBModels "customer/sigmatech/app/db/dto/password_resets"
+	"customer/sigmatech/app/db/where"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type IPasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset *passwordResets_DBModels.PasswordReset) error
+	GetPasswordReset(ctx context.Context, whr where.Filter) (passwordResets_DBModels.PasswordReset, error)
+	CountPasswordResets(ctx context.Context, whr where.Filter) (int, error)
+	UpdatePasswordReset(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	AddPasswordResetAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error)
+}
+
+type PasswordResetRepository struct {
+	DBService *db.DBService
+}
+
+func NewPasswordResetRepository(dbService *db.DBService) IPasswordResetRepository {
+	return &PasswordResetRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *PasswordResetRepository) CreatePasswordReset(ctx context.Context, reset *passwordResets_DBModels.PasswordReset) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(passwordResets_DBModels.TABLE_NAME).Create(reset).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetPasswordReset returns the latest reset matching whr.
+func (u *PasswordResetRepository) GetPasswordReset(ctx context.Context, whr where.Filter) (passwordResets_DBModels.PasswordReset, error) {
+	tx := u.DBService.GetDB().Table(passwordResets_DBModels.TABLE_NAME)
+	var reset passwordResets_DBModels.PasswordReset
+
+	err := tx.Scopes(whr.Scope).Order(fmt.Sprintf("%s desc", passwordResets_DBModels.COLUMN_CREATED_AT)).First(&reset).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return passwordResets_DBModels.PasswordReset{}, nil
+		}
+
+		return reset, err
+	}
+
+	return reset, nil
+}
+
+func (u *PasswordResetRepository) CountPasswordResets(ctx context.Context, whr where.Filter) (int, error) {
+	var count int
+	if err := u.DBService.GetDB().Table(passwordResets_DBModels.TABLE_NAME).Scopes(whr.Scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdatePasswordReset returns how many resets were updated, so a reset consumed concurrently can be told apart.
+func (u *PasswordResetRepository) UpdatePasswordReset(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(passwordResets_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// AddPasswordResetAttempt counts an OTP attempt on the resets matched by whr that have had fewer than
+// maxAttempts, in SQL so concurrent attempts are all counted. It returns how many resets were updated.
+func (u *PasswordResetRepository) AddPasswordResetAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error) {
+	tx := u.DBService.GetDB().Table(passwordResets_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Lt(passwordResets_DBModels.COLUMN_ATTEMPTS, maxAttempts).Scope).Updates(map[string]interface{}{
+		passwordResets_DBModels.COLUMN_ATTEMPTS:   gorm.Expr(passwordResets_DBModels.COLUMN_ATTEMPTS + " + 1"),
+		passwordResets_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -5,10 +5,12 @@ import (
 	"customer/sigmatech/app/constants"
 	db "customer/sigmatech/app/db"
 	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
 	"customer/sigmatech/app/service/dto/request"
 	"customer/sigmatech/app/service/dto/response"
 	"customer/sigmatech/app/service/util"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,10 +21,11 @@ import (
 
 type ITransactionInstallmentRepository interface {
 	CreateTransactionInstallment(ctx context.Context, customer *transaction_installments_DBModels.TransactionInstallment) error
-	GetTransactionInstallment(ctx context.Context, whr string) (transaction_installments_DBModels.TransactionInstallment, error)
+	GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error)
 	GetTransactionInstallments(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transaction_installments_DBModels.TransactionInstallment, response.Pagination, error)
-	UpdateTransactionInstallment(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteTransactionInstallment(ctx context.Context, filter string) error
+	UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error
+	GetNextUnpaidTransactionInstallment(ctx context.Context, customerUuid string) (transaction_installments_DBModels.TransactionInstallment, error)
 }
 
 type TransactionInstallmentRepository struct {
@@ -50,11 +53,11 @@ func (u *TransactionInstallmentRepository) CreateTransactionInstallment(ctx cont
 	return nil // Return the created customer and no error
 }
 
-func (u *TransactionInstallmentRepository) GetTransactionInstallment(ctx context.Context, whr string) (transaction_installments_DBModels.TransactionInstallment, error) {
+func (u *TransactionInstallmentRepository) GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error) {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer transaction_installments_DBModels.TransactionInstallment         // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return transaction_installments_DBModels.TransactionInstallment{}, nil // Return an empty customer if the record is not found
 		}
@@ -70,16 +73,7 @@ func (u *TransactionInstallmentRepository) GetTransactionInstallments(ctx contex
 
 	var columnsToSearch = []string{}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -94,34 +88,11 @@ func (u *TransactionInstallmentRepository) GetTransactionInstallments(ctx contex
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, transaction_installments_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).Begin() // Start a database transaction_installment
 	defer func() {
 		if r := recover(); r != nil {
@@ -130,7 +101,7 @@ func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx cont
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction_installment if customer update fails
 		return err
 	}
@@ -138,7 +109,7 @@ func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx cont
 	return tx.Commit().Error // Commit the transaction_installment and return any error
 }
 
-func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx context.Context, filter string) error {
+func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).Begin() // Start a database transaction_installment
 	defer func() {
 		if r := recover(); r != nil {
@@ -147,7 +118,7 @@ func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx cont
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&transaction_installments_DBModels.TransactionInstallment{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&transaction_installments_DBModels.TransactionInstallment{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction_installment if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction_installment
@@ -193,3 +164,30 @@ func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx cont
 
 	return tx.Commit().Error // Commit the transaction_installment and return any error
 }
+
+// GetNextUnpaidTransactionInstallment returns the unpaid installment of the customer that is due first.
+func (u *TransactionInstallmentRepository) GetNextUnpaidTransactionInstallment(ctx context.Context, customerUuid string) (transaction_installments_DBModels.TransactionInstallment, error) {
+	var record transaction_installments_DBModels.TransactionInstallment
+
+	err := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).
+		Select(fmt.Sprintf("%s.*", transaction_installments_DBModels.TABLE_NAME)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.%s = %s.%s",
+			transactions_DBModels.TABLE_NAME,
+			transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUM_UUID,
+			transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_TRANSACTION_UUID,
+		)).
+		Where(fmt.Sprintf("%s.%s = ? AND %s.%s IS NULL",
+			transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CUSTOMER_UUID,
+			transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_PAYMENT_AT,
+		), customerUuid).
+		Order(fmt.Sprintf("%s.%s ASC", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_DUE_DATE)).
+		First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return transaction_installments_DBModels.TransactionInstallment{}, nil
+		}
+		return record, err
+	}
+
+	return record, nil
+}
@@ -5,10 +5,11 @@ import (
 	"customer/sigmatech/app/constants"
 	db "customer/sigmatech/app/db"
 	variableGlobals_DBModels "customer/sigmatech/app/db/dto/variable_globals"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
 	"customer/sigmatech/app/service/dto/request"
 	"customer/sigmatech/app/service/dto/response"
 	"customer/sigmatech/app/service/util"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -19,10 +20,10 @@ import (
 
 type IVariableGlobalRepository interface {
 	CreateVariableGlobal(ctx context.Context, customer *variableGlobals_DBModels.VariableGlobal) error
-	GetVariableGlobal(ctx context.Context, whr string) (variableGlobals_DBModels.VariableGlobal, error)
+	GetVariableGlobal(ctx context.Context, whr where.Filter) (variableGlobals_DBModels.VariableGlobal, error)
 	GetVariableGlobals(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*variableGlobals_DBModels.VariableGlobal, response.Pagination, error)
-	UpdateVariableGlobal(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteVariableGlobal(ctx context.Context, filter string) error
+	UpdateVariableGlobal(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteVariableGlobal(ctx context.Context, filter where.Filter) error
 }
 
 type VariableGlobalRepository struct {
@@ -50,11 +51,11 @@ func (u *VariableGlobalRepository) CreateVariableGlobal(ctx context.Context, cus
 	return nil // Return the created customer and no error
 }
 
-func (u *VariableGlobalRepository) GetVariableGlobal(ctx context.Context, whr string) (variableGlobals_DBModels.VariableGlobal, error) {
+func (u *VariableGlobalRepository) GetVariableGlobal(ctx context.Context, whr where.Filter) (variableGlobals_DBModels.VariableGlobal, error) {
 	tx := u.DBService.GetDB().Table(variableGlobals_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer variableGlobals_DBModels.VariableGlobal                 // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return variableGlobals_DBModels.VariableGlobal{}, nil // Return an empty customer if the record is not found
 		}
@@ -70,16 +71,7 @@ func (u *VariableGlobalRepository) GetVariableGlobals(ctx context.Context, pagin
 
 	var columnsToSearch = []string{}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -94,34 +86,11 @@ func (u *VariableGlobalRepository) GetVariableGlobals(ctx context.Context, pagin
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, variableGlobals_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *VariableGlobalRepository) UpdateVariableGlobal(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *VariableGlobalRepository) UpdateVariableGlobal(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(variableGlobals_DBModels.TABLE_NAME).Begin() // Start a database variableGlobal
 	defer func() {
 		if r := recover(); r != nil {
@@ -130,7 +99,7 @@ func (u *VariableGlobalRepository) UpdateVariableGlobal(ctx context.Context, whr
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the variableGlobal if customer update fails
 		return err
 	}
@@ -138,7 +107,7 @@ func (u *VariableGlobalRepository) UpdateVariableGlobal(ctx context.Context, whr
 	return tx.Commit().Error // Commit the variableGlobal and return any error
 }
 
-func (u *VariableGlobalRepository) DeleteVariableGlobal(ctx context.Context, filter string) error {
+func (u *VariableGlobalRepository) DeleteVariableGlobal(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(variableGlobals_DBModels.TABLE_NAME).Begin() // Start a database variableGlobal
 	defer func() {
 		if r := recover(); r != nil {
@@ -147,7 +116,7 @@ func (u *VariableGlobalRepository) DeleteVariableGlobal(ctx context.Context, fil
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&variableGlobals_DBModels.VariableGlobal{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&variableGlobals_DBModels.VariableGlobal{}).Error; err != nil {
 		tx.Rollback() // Rollback the variableGlobal if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the variableGlobal
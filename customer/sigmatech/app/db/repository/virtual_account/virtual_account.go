@@ -0,0 +1,166 @@
+package virtual_account
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	db "customer/sigmatech/app/db"
+	virtualAccounts_DBModels "customer/sigmatech/app/db/dto/virtual_accounts"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/util"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+type IVirtualAccountRepository interface {
+	CreateVirtualAccount(ctx context.Context, customer *virtualAccounts_DBModels.VirtualAccount) error
+	GetVirtualAccount(ctx context.Context, whr where.Filter) (virtualAccounts_DBModels.VirtualAccount, error)
+	GetVirtualAccounts(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*virtualAccounts_DBModels.VirtualAccount, response.Pagination, error)
+	UpdateVirtualAccount(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteVirtualAccount(ctx context.Context, filter where.Filter) error
+}
+
+type VirtualAccountRepository struct {
+	DBService *db.DBService
+}
+
+func NewVirtualAccountRepository(dbService *db.DBService) IVirtualAccountRepository {
+	return &VirtualAccountRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = virtualAccounts_DBModels.TABLE_NAME
+
+func (u *VirtualAccountRepository) CreateVirtualAccount(ctx context.Context, customer *virtualAccounts_DBModels.VirtualAccount) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(virtualAccounts_DBModels.TABLE_NAME).Create(&customer).Error; err != nil {
+		return err // Return the error if customer creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil // Return the created customer and no error
+}
+
+func (u *VirtualAccountRepository) GetVirtualAccount(ctx context.Context, whr where.Filter) (virtualAccounts_DBModels.VirtualAccount, error) {
+	tx := u.DBService.GetDB().Table(virtualAccounts_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer virtualAccounts_DBModels.VirtualAccount                 // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return virtualAccounts_DBModels.VirtualAccount{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *VirtualAccountRepository) GetVirtualAccounts(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*virtualAccounts_DBModels.VirtualAccount, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(virtualAccounts_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		virtualAccounts_DBModels.COLUMN_ACCOUNT_NUMBER,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, virtualAccounts_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+func (u *VirtualAccountRepository) UpdateVirtualAccount(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	tx := u.DBService.GetDB().Table(virtualAccounts_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if customer update fails
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
+
+func (u *VirtualAccountRepository) DeleteVirtualAccount(ctx context.Context, filter where.Filter) error {
+	tx := u.DBService.GetDB().Table(virtualAccounts_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(filter.Scope).Delete(&virtualAccounts_DBModels.VirtualAccount{}).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if the deletion fails
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			// Foreign key constraint violation detected, rollback the transaction
+			tx.Rollback()
+
+			// Start a new transaction for the schema query
+			schemaTx := u.DBService.GetDB().Begin()
+			defer func() {
+				if r := recover(); r != nil {
+					schemaTx.Rollback() // Rollback the schema transaction if a panic occurs
+				}
+			}()
+
+			// Query the database schema to get tables with foreign key constraints referencing the specified table
+			var foreignKeys []struct {
+				ConstraintName   string
+				ReferencingTable string
+			}
+			err := schemaTx.Raw(`
+			SELECT con.conname AS constraint_name, con.conrelid::regclass AS referencing_table
+			FROM pg_constraint con
+			WHERE con.contype = 'f'
+			AND con.confrelid = ?::regclass`, tableName).Scan(&foreignKeys).Error
+
+			if err != nil {
+				return err
+			}
+
+			var referencingTables []string
+			// Print or process the foreign key information
+			for _, fk := range foreignKeys {
+				referencingTables = append(referencingTables, fk.ReferencingTable)
+			}
+
+			// Rollback the schema transaction
+			schemaTx.Rollback()
+
+			// Return the foreign key constraint violation error
+			return fmt.Errorf("cannot delete from table %s because of foreign key constraints on tables %s", tableName, strings.Join(referencingTables, ", "))
+		}
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
@@ -0,0 +1,184 @@
+package webhook_delivery
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	db "customer/sigmatech/app/db"
+	webhookDeliveries_DBModels "customer/sigmatech/app/db/dto/webhook_deliveries"
+	"customer/sigmatech/app/db/paginate"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/util"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+type IWebhookDeliveryRepository interface {
+	CreateWebhookDelivery(ctx context.Context, customer *webhookDeliveries_DBModels.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, whr where.Filter) (webhookDeliveries_DBModels.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*webhookDeliveries_DBModels.WebhookDelivery, response.Pagination, error)
+	UpdateWebhookDelivery(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteWebhookDelivery(ctx context.Context, filter where.Filter) error
+	GetDueWebhookDeliveries(ctx context.Context, statuses []string, now time.Time, limit int) ([]*webhookDeliveries_DBModels.WebhookDelivery, error)
+}
+
+type WebhookDeliveryRepository struct {
+	DBService *db.DBService
+}
+
+func NewWebhookDeliveryRepository(dbService *db.DBService) IWebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = webhookDeliveries_DBModels.TABLE_NAME
+
+func (u *WebhookDeliveryRepository) CreateWebhookDelivery(ctx context.Context, customer *webhookDeliveries_DBModels.WebhookDelivery) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(webhookDeliveries_DBModels.TABLE_NAME).Create(&customer).Error; err != nil {
+		return err // Return the error if customer creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil // Return the created customer and no error
+}
+
+func (u *WebhookDeliveryRepository) GetWebhookDelivery(ctx context.Context, whr where.Filter) (webhookDeliveries_DBModels.WebhookDelivery, error) {
+	tx := u.DBService.GetDB().Table(webhookDeliveries_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer webhookDeliveries_DBModels.WebhookDelivery                // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return webhookDeliveries_DBModels.WebhookDelivery{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *WebhookDeliveryRepository) GetWebhookDeliveries(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*webhookDeliveries_DBModels.WebhookDelivery, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(webhookDeliveries_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		webhookDeliveries_DBModels.COLUMN_EVENT_TYPE,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, webhookDeliveries_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+func (u *WebhookDeliveryRepository) UpdateWebhookDelivery(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	tx := u.DBService.GetDB().Table(webhookDeliveries_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if customer update fails
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
+
+func (u *WebhookDeliveryRepository) DeleteWebhookDelivery(ctx context.Context, filter where.Filter) error {
+	tx := u.DBService.GetDB().Table(webhookDeliveries_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(filter.Scope).Delete(&webhookDeliveries_DBModels.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if the deletion fails
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			// Foreign key constraint violation detected, rollback the transaction
+			tx.Rollback()
+
+			// Start a new transaction for the schema query
+			schemaTx := u.DBService.GetDB().Begin()
+			defer func() {
+				if r := recover(); r != nil {
+					schemaTx.Rollback() // Rollback the schema transaction if a panic occurs
+				}
+			}()
+
+			// Query the database schema to get tables with foreign key constraints referencing the specified table
+			var foreignKeys []struct {
+				ConstraintName   string
+				ReferencingTable string
+			}
+			err := schemaTx.Raw(`
+			SELECT con.conname AS constraint_name, con.conrelid::regclass AS referencing_table
+			FROM pg_constraint con
+			WHERE con.contype = 'f'
+			AND con.confrelid = ?::regclass`, tableName).Scan(&foreignKeys).Error
+
+			if err != nil {
+				return err
+			}
+
+			var referencingTables []string
+			// Print or process the foreign key information
+			for _, fk := range foreignKeys {
+				referencingTables = append(referencingTables, fk.ReferencingTable)
+			}
+
+			// Rollback the schema transaction
+			schemaTx.Rollback()
+
+			// Return the foreign key constraint violation error
+			return fmt.Errorf("cannot delete from table %s because of foreign key constraints on tables %s", tableName, strings.Join(referencingTables, ", "))
+		}
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
+
+// GetDueWebhookDeliveries returns the oldest deliveries in one of the given statuses whose next attempt is due.
+func (u *WebhookDeliveryRepository) GetDueWebhookDeliveries(ctx context.Context, statuses []string, now time.Time, limit int) ([]*webhookDeliveries_DBModels.WebhookDelivery, error) {
+	var records []*webhookDeliveries_DBModels.WebhookDelivery
+
+	err := u.DBService.GetDB().Table(webhookDeliveries_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s IN (?) AND %s <= ?", webhookDeliveries_DBModels.COLUMN_STATUS, webhookDeliveries_DBModels.COLUMN_NEXT_ATTEMPT_AT), statuses, now).
+		Order(fmt.Sprintf("%s ASC", webhookDeliveries_DBModels.COLUMN_NEXT_ATTEMPT_AT)).
+		Limit(limit).
+		Find(&records).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,94 @@
+// Package apikey generates and hashes the API keys merchants use on the partner API.
+//
+// A key looks like "sgt_<prefix>_<secret>". The prefix is stored in clear to find the key,
+// only the SHA-256 hash of the whole key is stored, so a leaked table can't be used to call the API.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	KEY_PREFIX = "sgt_"
+
+	prefixBytes = 6
+	secretBytes = 32
+)
+
+// Scopes.
+const (
+	SCOPE_TRANSACTION_CREATE = "transaction:create"
+	SCOPE_TRANSACTION_READ   = "transaction:read"
+)
+
+// Scopes lists every scope a key can be granted.
+var Scopes = []string{
+	SCOPE_TRANSACTION_CREATE,
+	SCOPE_TRANSACTION_READ,
+}
+
+var ErrMalformedKey = errors.New("malformed api key")
+
+// Generate returns a new key, its lookup prefix and the hash to store.
+// The key itself is shown once to the merchant and never stored.
+func Generate() (key string, prefix string, hash string, err error) {
+	p := make([]byte, prefixBytes)
+	if _, err = rand.Read(p); err != nil {
+		return "", "", "", err
+	}
+
+	s := make([]byte, secretBytes)
+	if _, err = rand.Read(s); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(p)
+	key = KEY_PREFIX + prefix + "_" + hex.EncodeToString(s)
+
+	return key, prefix, Hash(key), nil
+}
+
+// Hash returns the hex encoded SHA-256 of the key. Keys carry 256 bits of randomness,
+// so a fast hash is enough, unlike passwords.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prefix extracts the lookup prefix of a key.
+func Prefix(key string) (string, error) {
+	if !strings.HasPrefix(key, KEY_PREFIX) {
+		return "", ErrMalformedKey
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, KEY_PREFIX), "_", 2)
+	if len(parts) != 2 || len(parts[0]) != prefixBytes*2 || parts[1] == "" {
+		return "", ErrMalformedKey
+	}
+
+	return parts[0], nil
+}
+
+// IsValidScope reports whether the given value is a supported scope.
+func IsValidScope(value string) bool {
+	for _, s := range Scopes {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether scope is in the granted list.
+func HasScope(granted []string, scope string) bool {
+	for _, s := range granted {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -52,12 +52,11 @@ func (u *SignUpReq) Validate() error {
 		return fmt.Errorf("salary can't be empty")
 	}
 	if u.Email == "" {
-		if !util.IsValidEmail(u.Email) {
-			return fmt.Errorf("email is not valid")
-		}
-
 		return fmt.Errorf("email can't be empty")
 	}
+	if !util.IsValidEmail(u.Email) {
+		return fmt.Errorf("email is not valid")
+	}
 	if u.Password == "" {
 		return fmt.Errorf("password can't be empty")
 	}
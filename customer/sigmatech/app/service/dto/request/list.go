@@ -0,0 +1,198 @@
+package request
+
+import (
+	"customer/sigmatech/app/service/util"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+
+	orderColumn = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// listFields returns the fields of a model that lists can be filtered and sorted by, by their json
+// name. They are the fields json always writes: fields tagged "-" or omitempty are left out, which
+// keeps secrets such as password hashes out.
+func listFields(model interface{}) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			for name, fieldType := range listFields(reflect.Zero(field.Type).Interface()) {
+				fields[name] = fieldType
+			}
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" || strings.Contains(options, "omitempty") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+// parseFilter checks a field[operator]=value filter against the type of the field, so a bad value is
+// refused here instead of failing the query.
+func parseFilter(field, operator, value string, fieldType reflect.Type) (interface{}, error) {
+	if !util.IsFilterOperator(operator) {
+		return nil, fmt.Errorf("%s: unknown filter operator %q", field, operator)
+	}
+
+	switch operator {
+	case util.FILTER_NULL:
+		isNull, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%s] must be true or false", field, operator)
+		}
+		return isNull, nil
+	case util.FILTER_LIKE:
+		return value, nil
+	case util.FILTER_IN, util.FILTER_NOT_IN:
+		values := strings.Split(value, ",")
+		for _, v := range values {
+			if err := checkValue(v, fieldType); err != nil {
+				return nil, fmt.Errorf("%s[%s]: %w", field, operator, err)
+			}
+		}
+		return values, nil
+	}
+
+	if err := checkValue(value, fieldType); err != nil {
+		return nil, fmt.Errorf("%s[%s]: %w", field, operator, err)
+	}
+	return value, nil
+}
+
+func checkValue(value string, fieldType reflect.Type) error {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	var err error
+	switch {
+	case fieldType == uuidType:
+		_, err = uuid.Parse(value)
+	case fieldType == timeType:
+		err = checkTime(value)
+	case fieldType.Kind() == reflect.Bool:
+		_, err = strconv.ParseBool(value)
+	case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Uint64:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+		_, err = strconv.ParseFloat(value, 64)
+	case fieldType.Kind() == reflect.String:
+	default:
+		return fmt.Errorf("can't be filtered")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q", value)
+	}
+	return nil
+}
+
+func checkTime(value string) error {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+		if _, err := time.Parse(layout, value); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("not a date")
+}
+
+// parseSort splits the comma separated order columns and sort directions. A single direction applies
+// to every column, otherwise there is one per column.
+func parseSort(order, sort string) ([]string, []string, error) {
+	columns := strings.Split(order, ",")
+	directions := strings.Split(strings.ToUpper(sort), ",")
+	if len(directions) != 1 && len(directions) != len(columns) {
+		return nil, nil, fmt.Errorf("sort must have one direction or one per order column")
+	}
+
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if !orderColumn.MatchString(columns[i]) {
+			return nil, nil, fmt.Errorf("invalid order column %q", column)
+		}
+	}
+	for i, direction := range directions {
+		directions[i] = strings.TrimSpace(direction)
+		if directions[i] != "ASC" && directions[i] != "DESC" {
+			return nil, nil, fmt.Errorf("sort must be ASC or DESC")
+		}
+	}
+
+	return columns, directions, nil
+}
+
+// checkSort checks the order and sort query parameters against the fields of the model.
+func checkSort(order, sort string, fields map[string]reflect.Type) error {
+	if order == "" && sort == "" {
+		return nil
+	}
+	if sort == "" {
+		sort = defaultSort
+	}
+
+	columns, _, err := parseSort(order, sort)
+	if err != nil || order == "" {
+		return err
+	}
+	for _, column := range columns {
+		if _, ok := fields[column]; !ok {
+			return fmt.Errorf("can't order by %q", column)
+		}
+	}
+	return nil
+}
+
+// OrderBy returns the ORDER BY clause of comma separated order columns and sort directions, with table
+// put before the columns that have none when it isn't empty. Invalid columns are left out.
+func OrderBy(order, sort, table string) string {
+	columns, directions, err := parseSort(order, sort)
+	if err != nil {
+		return ""
+	}
+
+	clauses := make([]string, len(columns))
+	for i, column := range columns {
+		if table != "" {
+			column = table + "." + column
+		}
+		direction := directions[0]
+		if len(directions) > 1 {
+			direction = directions[i]
+		}
+		clauses[i] = column + " " + direction
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// OrderBy returns the ORDER BY clause of the Order and Sort of the pagination.
+func (r Pagination) OrderBy() string {
+	return OrderBy(r.Order, r.Sort, "")
+}
@@ -0,0 +1,48 @@
+package partner
+
+import (
+	"customer/sigmatech/app/service/util"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ConsentRequest starts a transaction on behalf of a customer, the customer confirms it with the OTP sent to them.
+type ConsentRequest struct {
+	CustomerEmail string  `json:"customer_email"`
+	Term          int     `json:"term"`
+	AssetName     string  `json:"asset_name"`
+	Otr           float64 `json:"otr"`
+}
+
+func (s *ConsentRequest) Validate() error {
+	if !util.IsValidEmail(s.CustomerEmail) {
+		return errors.New("customer email is not valid")
+	}
+	if s.Term <= 0 {
+		return errors.New("term is required")
+	}
+	if s.AssetName == "" {
+		return errors.New("asset name can't be empty")
+	}
+	if s.Otr <= 0 {
+		return errors.New("otr must be greater than zero")
+	}
+	return nil
+}
+
+// TransactionRequest books the transaction of a consent once the customer shared the OTP with the merchant.
+type TransactionRequest struct {
+	ConsentUuid uuid.UUID `json:"consent_uuid"`
+	Otp         string    `json:"otp"`
+}
+
+func (s *TransactionRequest) Validate() error {
+	if s.ConsentUuid == uuid.Nil {
+		return errors.New("consent uuid is required")
+	}
+	if s.Otp == "" {
+		return errors.New("otp is required")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package payment
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// VirtualAccountRequest asks for a virtual account for one installment, or for the customer when
+// no installment is given.
+type VirtualAccountRequest struct {
+	TransactionInstallmentUuid *uuid.UUID `json:"transaction_installment_uuid"`
+}
+
+func (s *VirtualAccountRequest) Validate() error {
+	if s.TransactionInstallmentUuid != nil && *s.TransactionInstallmentUuid == uuid.Nil {
+		return errors.New("transaction installment uuid is not valid")
+	}
+	return nil
+}
+
+// SimulatePaymentRequest pays into a virtual account of the simulator provider.
+type SimulatePaymentRequest struct {
+	AccountNumber string  `json:"account_number"`
+	Amount        float64 `json:"amount"`
+}
+
+func (s *SimulatePaymentRequest) Validate() error {
+	if s.AccountNumber == "" {
+		return errors.New("account number can't be empty")
+	}
+	if s.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	return nil
+}
@@ -2,33 +2,80 @@ package request
 
 import (
 	"customer/sigmatech/app/service/util"
-	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 )
 
+// ForgotPassword asks for a password reset OTP to be emailed.
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+func (r ForgotPassword) ValidateRequest() error {
+	if r.Email == "" {
+		return fmt.Errorf("invalid request body, require email")
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with the OTP emailed after a ForgotPassword.
 type ResetPassword struct {
 	Email    string `json:"email"`
+	Otp      string `json:"otp"`
 	Password string `json:"password"`
 }
 
-type PaginationV2 struct {
-	Query  string `form:"query" json:"query"`
-	Sort   string `form:"sort" json:"sort"`
-	Order  string `form:"order" json:"order"`
-	Limit  int    `form:"limit,default=10" json:"limit"`
-	Offset int    `form:"offset,default=0" json:"offset"`
+func (r ResetPassword) ValidateRequest() error {
+	if r.Email == "" || r.Otp == "" || r.Password == "" {
+		return fmt.Errorf("invalid request body, require email/otp/password")
+	}
+	return nil
+}
+
+// VerifyEmail confirms the email of a customer with the token emailed at sign-up.
+type VerifyEmail struct {
+	Token string `json:"token"`
+}
+
+func (r VerifyEmail) ValidateRequest() error {
+	if r.Token == "" {
+		return fmt.Errorf("invalid request body, require token")
+	}
+	return nil
+}
+
+// ResendVerification asks for a new email verification token.
+type ResendVerification struct {
+	Email string `json:"email"`
 }
 
+func (r ResendVerification) ValidateRequest() error {
+	if r.Email == "" {
+		return fmt.Errorf("invalid request body, require email")
+	}
+	return nil
+}
+
+const (
+	defaultOrder = "created_at"
+	defaultSort  = "DESC"
+)
+
+// Pagination picks a page of a list by its number, or by a cursor when Cursor is set: an empty cursor
+// asks for the first page, the next_cursor and prev_cursor of a page for the pages around it. Cursor
+// pages are counted only when Count is set.
 type Pagination struct {
-	Limit      *int   `json:"limit,omitempty" form:"limit"`
-	Page       *int   `json:"page,omitempty" form:"page"`
-	Offset     int    `json:"offset,omitempty" form:"offset"`
-	Sort       string `json:"sort,omitempty" form:"sort"`
-	Order      string `json:"order,omitempty" form:"order"`
-	Query      string `json:"query,omitempty" form:"query"`
-	GetAllData bool   `json:"get_all_data,omitempty" form:"get_all_data"`
-	Total      int    `json:"total" form:"total"`
-	TotalPage  int    `json:"total_page" form:"total_page"`
+	Limit      *int    `json:"limit,omitempty" form:"limit"`
+	Page       *int    `json:"page,omitempty" form:"page"`
+	Offset     int     `json:"offset,omitempty" form:"offset"`
+	Sort       string  `json:"sort,omitempty" form:"sort"`
+	Order      string  `json:"order,omitempty" form:"order"`
+	Query      string  `json:"query,omitempty" form:"query"`
+	GetAllData bool    `json:"get_all_data,omitempty" form:"get_all_data"`
+	Cursor     *string `json:"cursor,omitempty" form:"cursor"`
+	Count      bool    `json:"count,omitempty" form:"count"`
+	Total      int     `json:"total" form:"total"`
+	TotalPage  int     `json:"total_page" form:"total_page"`
 }
 
 func (r *Pagination) Validate() error {
@@ -54,18 +101,31 @@ func (r *Pagination) Validate() error {
 	}
 
 	if r.Sort == "" {
-		r.Sort = "DESC"
+		r.Sort = defaultSort
 	}
 	if r.Order == "" {
-		r.Order = "created_at"
+		r.Order = defaultOrder
 	}
 	return nil
 }
 
-// ExtractFilteredQueryParams maps query parameters from the URL to a struct or map, excluding pagination fields.
-func ExtractFilteredQueryParams(c *gin.Context, filter interface{}) map[string]interface{} {
+// ExtractFilteredQueryParams maps query parameters from the URL to filters on the fields of a model, excluding
+// pagination fields. A field=value parameter keeps its older meaning, field[operator]=value uses one of the
+// util.FILTER_* operators and is checked against the type of the field. The order and sort parameters may
+// list several comma separated columns, each one has to be a field of the model.
+func ExtractFilteredQueryParams(c *gin.Context, model interface{}) (map[string]interface{}, error) {
 	// Define the pagination query parameters to exclude
-	paginationFields := []string{"limit", "page", "offset", "sort", "order", "query", "get_all_data"}
+	paginationFields := []string{"limit", "page", "offset", "sort", "order", "query", "get_all_data", "cursor", "count"}
+
+	fields := listFields(model)
+	if err := checkSort(c.Query("order"), c.Query("sort"), fields); err != nil {
+		return nil, err
+	}
+	if _, ok := c.GetQuery("cursor"); ok {
+		if err := checkCursor(c.Query("cursor"), c.Query("order"), c.Query("sort"), fields); err != nil {
+			return nil, err
+		}
+	}
 
 	// Remove pagination query parameters from the URL
 	queryParams := c.Request.URL.Query()
@@ -73,25 +133,35 @@ func ExtractFilteredQueryParams(c *gin.Context, filter interface{}) map[string]i
 		queryParams.Del(field)
 	}
 
-	// Create a map to store the extracted query parameters from the filter
-	filterMap := make(map[string]interface{})
-	filterJSON, _ := json.Marshal(filter)
-	json.Unmarshal(filterJSON, &filterMap)
-
 	// Create a result map to store the filtered query parameters
 	result := make(map[string]interface{})
 
 	// Iterate over the remaining query parameters
 	for key, value := range queryParams {
 		// Check if the value is not empty
-		if len(value) > 0 && value[0] != "" {
-			// Check if the key exists in the filter map
-			if _, ok := filterMap[key]; ok {
-				// Map the query parameter value to the corresponding key in the result map
+		if len(value) == 0 || value[0] == "" {
+			continue
+		}
+
+		field, operator, ok := util.ParseFilterKey(key)
+		fieldType, known := fields[field]
+		if !ok {
+			// Plain parameters that aren't fields belong to the endpoint, such as export
+			if known {
 				result[key] = value[0]
 			}
+			continue
+		}
+		if !known {
+			return nil, fmt.Errorf("can't filter by %q", field)
+		}
+
+		parsed, err := parseFilter(field, operator, value[0], fieldType)
+		if err != nil {
+			return nil, err
 		}
+		result[key] = parsed
 	}
 
-	return result
+	return result, nil
 }
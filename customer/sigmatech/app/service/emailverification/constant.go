@@ -0,0 +1,14 @@
+package emailverification
+
+import "errors"
+
+const (
+	// tokenLength is the number of random bytes of a verification token, it is sent hex encoded.
+	tokenLength = 32
+
+	mailSubject = "Verify your email"
+	mailBody    = "Hi %s,\n\nUse %s to verify your email. The token expires in %d hours.\n\nIf you didn't sign up, ignore this email.\n"
+)
+
+// ErrInvalidToken is returned for a wrong, expired or used token alike.
+var ErrInvalidToken = errors.New("invalid or expired token")
@@ -0,0 +1,144 @@
+// Package emailverification confirms that customers own the email they signed up with, admins only
+// approve customers whose email is verified.
+package emailverification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	emailVerifications_DBModels "customer/sigmatech/app/db/dto/email_verifications"
+	customerDB "customer/sigmatech/app/db/repository/customer"
+	emailVerificationDB "customer/sigmatech/app/db/repository/email_verification"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/mailer"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IEmailVerificationService interface {
+	// Send emails a verification token to the customer, unless the email already got too many lately.
+	Send(ctx context.Context, customer customers_DBModels.Customer) error
+	// Resend sends a new token when the email belongs to an unverified customer. It returns nil whether
+	// it does or not, so the response doesn't tell which emails are registered.
+	Resend(ctx context.Context, email string) error
+	// Verify marks the email of the token's customer verified.
+	Verify(ctx context.Context, token string) error
+}
+
+// EmailVerificationService is a struct that implements the IEmailVerificationService interface.
+type EmailVerificationService struct {
+	CustomerDBClient          customerDB.ICustomerRepository
+	EmailVerificationDBClient emailVerificationDB.IEmailVerificationRepository
+	Mailer                    mailer.IMailer
+}
+
+// NewEmailVerificationService is a constructor function that creates a new EmailVerificationService.
+func NewEmailVerificationService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	EmailVerificationDBClient emailVerificationDB.IEmailVerificationRepository,
+	Mailer mailer.IMailer,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		CustomerDBClient:          CustomerDBClient,
+		EmailVerificationDBClient: EmailVerificationDBClient,
+		Mailer:                    Mailer,
+	}
+}
+
+func (s *EmailVerificationService) Send(ctx context.Context, customer customers_DBModels.Customer) error {
+	now := time.Now()
+	window := time.Duration(constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_WINDOW) * time.Second
+
+	sent, err := s.EmailVerificationDBClient.CountEmailVerifications(ctx, where.Eq(emailVerifications_DBModels.COLUMN_EMAIL, customer.Email).Gt(emailVerifications_DBModels.COLUMN_CREATED_AT, now.Add(-window).Format("2006-01-02 15:04:05")))
+	if err != nil {
+		return err
+	}
+	if sent >= constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT {
+		logger.Logger(ctx).Warnf("email verification of customer %s is rate limited, %d tokens sent in the last %s", customer.Uuid, sent, window)
+		return nil
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_TTL) * time.Second
+
+	if err := s.EmailVerificationDBClient.CreateEmailVerification(ctx, &emailVerifications_DBModels.EmailVerification{
+		Uuid:         uuid.New(),
+		CustomerUuid: customer.Uuid,
+		Email:        customer.Email,
+		TokenHash:    hashToken(token),
+		ExpiresAt:    now.Add(ttl),
+		CreatedAt:    now,
+	}); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, mailer.Mail{
+		To:      customer.Email,
+		Subject: mailSubject,
+		Body:    fmt.Sprintf(mailBody, customer.Name, token, int(ttl.Hours())),
+	})
+}
+
+func (s *EmailVerificationService) Resend(ctx context.Context, email string) error {
+	customer, err := s.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, email))
+	if err != nil {
+		return err
+	}
+	if customer.Uuid == uuid.Nil || customer.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.Send(ctx, customer)
+}
+
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	verification, err := s.EmailVerificationDBClient.GetEmailVerification(ctx, where.Eq(emailVerifications_DBModels.COLUMN_TOKEN_HASH, hashToken(token)).IsNull(emailVerifications_DBModels.COLUMN_CONSUMED_AT))
+	if err != nil {
+		return err
+	}
+	if verification.Uuid == uuid.Nil || time.Now().After(verification.ExpiresAt) {
+		return ErrInvalidToken
+	}
+
+	consumed, err := s.EmailVerificationDBClient.UpdateEmailVerification(ctx, where.Eq(emailVerifications_DBModels.COLUM_UUID, verification.Uuid).IsNull(emailVerifications_DBModels.COLUMN_CONSUMED_AT), map[string]interface{}{
+		emailVerifications_DBModels.COLUMN_CONSUMED_AT: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if consumed == 0 {
+		return ErrInvalidToken
+	}
+
+	// The token only verifies the email it was sent to
+	return s.CustomerDBClient.UpdateCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, verification.CustomerUuid).
+		Eq(customers_DBModels.COLUMN_EMAIL, verification.Email).
+		IsNull(customers_DBModels.COLUMN_EMAIL_VERIFIED_AT), map[string]interface{}{
+		customers_DBModels.COLUMN_EMAIL_VERIFIED_AT: time.Now(),
+		customers_DBModels.COLUMN_UPDATED_AT:        time.Now(),
+	})
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is what is stored, a leaked table doesn't verify anyone.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
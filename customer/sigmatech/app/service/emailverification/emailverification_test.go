@@ -0,0 +1,195 @@
+package emailverification
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	emailVerifications_DBModels "customer/sigmatech/app/db/dto/email_verifications"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/mailer"
+	"customer/sigmatech/config"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// customerRepository holds a single customer, returned for its email only.
+type customerRepository struct {
+	customer customers_DBModels.Customer
+}
+
+func (r *customerRepository) CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error {
+	return nil
+}
+
+func (r *customerRepository) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
+	if !reflect.DeepEqual(whr, where.Eq(customers_DBModels.COLUMN_EMAIL, r.customer.Email)) {
+		return customers_DBModels.Customer{}, nil
+	}
+	return r.customer, nil
+}
+
+func (r *customerRepository) GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *customerRepository) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	verifiedAt := patch[customers_DBModels.COLUMN_EMAIL_VERIFIED_AT].(time.Time)
+	r.customer.EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+func (r *customerRepository) DeleteCustomer(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// emailVerificationRepository matches the verifications on the hash or uuid in the where clause.
+type emailVerificationRepository struct {
+	verifications []*emailVerifications_DBModels.EmailVerification
+}
+
+func (r *emailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error {
+	r.verifications = append(r.verifications, verification)
+	return nil
+}
+
+func (r *emailVerificationRepository) GetEmailVerification(ctx context.Context, whr where.Filter) (emailVerifications_DBModels.EmailVerification, error) {
+	if verification := r.pending(whr); verification != nil {
+		return *verification, nil
+	}
+	return emailVerifications_DBModels.EmailVerification{}, nil
+}
+
+func (r *emailVerificationRepository) CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error) {
+	return len(r.verifications), nil
+}
+
+func (r *emailVerificationRepository) UpdateEmailVerification(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	verification := r.pending(whr)
+	if verification == nil {
+		return 0, nil
+	}
+	consumedAt := patch[emailVerifications_DBModels.COLUMN_CONSUMED_AT].(time.Time)
+	verification.ConsumedAt = &consumedAt
+	return 1, nil
+}
+
+func (r *emailVerificationRepository) pending(whr where.Filter) *emailVerifications_DBModels.EmailVerification {
+	for _, verification := range r.verifications {
+		if verification.ConsumedAt != nil {
+			continue
+		}
+		for _, condition := range whr.Conditions {
+			if condition.Value == verification.TokenHash || condition.Value == verification.Uuid {
+				return verification
+			}
+		}
+	}
+	return nil
+}
+
+// outbox stands in for the mailer, it keeps the mails sent.
+type outbox struct {
+	mails []mailer.Mail
+}
+
+func (o *outbox) Send(ctx context.Context, mail mailer.Mail) error {
+	o.mails = append(o.mails, mail)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+func (o *outbox) lastToken() string {
+	return tokenPattern.FindString(o.mails[len(o.mails)-1].Body)
+}
+
+func newTestService() (*EmailVerificationService, *customerRepository, *emailVerificationRepository, *outbox) {
+	constants.Config = &config.ServiceConfig{EmailVerificationConfig: config.EmailVerificationConfig{
+		EMAIL_VERIFICATION_TTL:    86400,
+		EMAIL_VERIFICATION_LIMIT:  2,
+		EMAIL_VERIFICATION_WINDOW: 3600,
+	}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	customers := &customerRepository{customer: customers_DBModels.Customer{
+		Uuid:  uuid.New(),
+		Name:  "Budi",
+		Email: "budi@sigmatech.id",
+	}}
+	verifications := &emailVerificationRepository{}
+	mails := &outbox{}
+
+	return NewEmailVerificationService(customers, verifications, mails), customers, verifications, mails
+}
+
+func TestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Given the emailed token When verifying Then the email is verified once", func(t *testing.T) {
+		s, customers, _, mails := newTestService()
+
+		if err := s.Send(ctx, customers.customer); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if err := s.Verify(ctx, mails.lastToken()); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if customers.customer.EmailVerifiedAt == nil {
+			t.Errorf("Verify() didn't mark the email verified")
+		}
+
+		if err := s.Verify(ctx, mails.lastToken()); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify() with a used token error = %v, want %v", err, ErrInvalidToken)
+		}
+	})
+
+	t.Run("Given an expired token When verifying Then it is refused", func(t *testing.T) {
+		s, customers, verifications, mails := newTestService()
+		_ = s.Send(ctx, customers.customer)
+		verifications.verifications[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+		if err := s.Verify(ctx, mails.lastToken()); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+		}
+		if customers.customer.EmailVerifiedAt != nil {
+			t.Errorf("Verify() marked the email verified")
+		}
+	})
+
+	t.Run("Given a verified or unknown email When resending Then nothing is sent", func(t *testing.T) {
+		s, customers, _, mails := newTestService()
+		now := time.Now()
+		customers.customer.EmailVerifiedAt = &now
+
+		for _, email := range []string{customers.customer.Email, "nobody@sigmatech.id"} {
+			if err := s.Resend(ctx, email); err != nil {
+				t.Fatalf("Resend(%s) error = %v", email, err)
+			}
+		}
+		if len(mails.mails) != 0 {
+			t.Errorf("Resend() sent %d mails, want none", len(mails.mails))
+		}
+	})
+
+	t.Run("Given the limit of tokens was sent When resending Then no mail is sent", func(t *testing.T) {
+		s, customers, _, mails := newTestService()
+
+		for i := 0; i < constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT+1; i++ {
+			if err := s.Resend(ctx, customers.customer.Email); err != nil {
+				t.Fatalf("Resend() error = %v", err)
+			}
+		}
+		if len(mails.mails) != constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT {
+			t.Errorf("Resend() sent %d mails, want %d", len(mails.mails), constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT)
+		}
+	})
+}
@@ -7,17 +7,24 @@ import (
 	"net/http"
 )
 
+// IClient resolves where an IP address is, Client calls the API and CachedClient remembers its answers.
+type IClient interface {
+	GetLocationInfo(ipAddress string) (LocationInfo, error)
+}
+
 // Client is the main struct for interacting with the ipgeolocation.io API.
 type Client struct {
-	BaseURL string
-	APIKey  string
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
 }
 
 // NewClient creates a new ipgeolocation.io API client with the provided API key.
 func NewClient(apiKey string) *Client {
 	return &Client{
-		BaseURL: BaseURL,
-		APIKey:  apiKey,
+		BaseURL:    BaseURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: requestTimeout},
 	}
 }
 
@@ -30,7 +37,7 @@ type ErrorResponse struct {
 func (c *Client) GetLocationInfo(ipAddress string) (LocationInfo, error) {
 	url := fmt.Sprintf("%s%s?%s=%s&%s=%s&%s=%s", c.BaseURL, GetLocationInfoEndpoint, APIKeyParam, c.APIKey, IPAddressParam, ipAddress, LanguageParam, "en")
 
-	resp, err := http.Get(url)
+	resp, err := c.HTTPClient.Get(url)
 	if err != nil {
 		return LocationInfo{}, err
 	}
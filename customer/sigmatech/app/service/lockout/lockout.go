@@ -0,0 +1,138 @@
+// Package lockout locks accounts and IPs out of signing in for a while after too many failed attempts.
+// Each lockout of the same account or IP in a row lasts twice as long as the last, up to a maximum.
+package lockout
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	loginThrottles_DBModels "customer/sigmatech/app/db/dto/login_throttles"
+	loginThrottleDB "customer/sigmatech/app/db/repository/login_throttle"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ILockoutService interface {
+	// Check returns how long the email or the IP is still locked out for, zero when neither is.
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	// Fail counts a failed sign-in of the email from the IP, locking either out once it has failed too often.
+	Fail(ctx context.Context, email, ip string) error
+	// Succeed forgets the failed sign-ins of the email, the ones of the IP are kept.
+	Succeed(ctx context.Context, email string) error
+}
+
+// LockoutService is a struct that implements the ILockoutService interface.
+type LockoutService struct {
+	LoginThrottleDBClient loginThrottleDB.ILoginThrottleRepository
+	SubjectType           string
+}
+
+// NewLockoutService is a constructor function that creates a new LockoutService for the subjects of SubjectType.
+func NewLockoutService(LoginThrottleDBClient loginThrottleDB.ILoginThrottleRepository, SubjectType string) *LockoutService {
+	return &LockoutService{
+		LoginThrottleDBClient: LoginThrottleDBClient,
+		SubjectType:           SubjectType,
+	}
+}
+
+func (s *LockoutService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	throttles, err := s.LoginThrottleDBClient.GetLoginThrottles(ctx, where.Eq(loginThrottles_DBModels.COLUMN_SUBJECT_TYPE, s.SubjectType).Any(
+		where.Eq(loginThrottles_DBModels.COLUMN_SCOPE, loginThrottles_DBModels.SCOPE_ACCOUNT).Eq(loginThrottles_DBModels.COLUMN_IDENTIFIER, normalize(email)),
+		where.Eq(loginThrottles_DBModels.COLUMN_SCOPE, loginThrottles_DBModels.SCOPE_IP).Eq(loginThrottles_DBModels.COLUMN_IDENTIFIER, ip),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	var remaining time.Duration
+	for _, throttle := range throttles {
+		if throttle.LockedUntil == nil {
+			continue
+		}
+		if left := time.Until(*throttle.LockedUntil); left > remaining {
+			remaining = left
+		}
+	}
+
+	return remaining, nil
+}
+
+func (s *LockoutService) Fail(ctx context.Context, email, ip string) error {
+	if err := s.fail(ctx, loginThrottles_DBModels.SCOPE_ACCOUNT, normalize(email), constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_ACCOUNT_THRESHOLD); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+
+	return s.fail(ctx, loginThrottles_DBModels.SCOPE_IP, ip, constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_IP_THRESHOLD)
+}
+
+func (s *LockoutService) fail(ctx context.Context, scope, identifier string, threshold int) error {
+	now := time.Now()
+	window := time.Duration(constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_WINDOW) * time.Second
+	reset := time.Duration(constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_RESET) * time.Second
+
+	throttle, err := s.LoginThrottleDBClient.RecordLoginFailure(ctx, &loginThrottles_DBModels.LoginThrottle{
+		Uuid:          uuid.New(),
+		SubjectType:   s.SubjectType,
+		Scope:         scope,
+		Identifier:    identifier,
+		LastFailureAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, now.Add(-window), now.Add(-reset))
+	if err != nil {
+		return err
+	}
+	if throttle.Failures < threshold {
+		return nil
+	}
+
+	lockouts := throttle.Lockouts + 1
+	lockedUntil := now.Add(lockoutDuration(lockouts))
+
+	// Matching the failures counted locks out once when several failures reach the threshold together
+	locked, err := s.LoginThrottleDBClient.UpdateLoginThrottle(ctx, where.Eq(loginThrottles_DBModels.COLUM_UUID, throttle.Uuid).Eq(loginThrottles_DBModels.COLUMN_FAILURES, throttle.Failures), map[string]interface{}{
+		loginThrottles_DBModels.COLUMN_FAILURES:     0,
+		loginThrottles_DBModels.COLUMN_LOCKOUTS:     lockouts,
+		loginThrottles_DBModels.COLUMN_LOCKED_UNTIL: lockedUntil,
+		loginThrottles_DBModels.COLUMN_UPDATED_AT:   now,
+	})
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		logger.Logger(ctx).Warnf("%s %s %s locked out until %s after %d failed sign-ins", s.SubjectType, scope, identifier, lockedUntil.Format(time.RFC3339), throttle.Failures)
+	}
+
+	return nil
+}
+
+func (s *LockoutService) Succeed(ctx context.Context, email string) error {
+	return s.LoginThrottleDBClient.DeleteLoginThrottles(ctx, where.Eq(loginThrottles_DBModels.COLUMN_SUBJECT_TYPE, s.SubjectType).
+		Eq(loginThrottles_DBModels.COLUMN_SCOPE, loginThrottles_DBModels.SCOPE_ACCOUNT).
+		Eq(loginThrottles_DBModels.COLUMN_IDENTIFIER, normalize(email)))
+}
+
+// lockoutDuration doubles the first lockout duration for each lockout before, up to the maximum.
+func lockoutDuration(lockouts int) time.Duration {
+	duration := time.Duration(constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_DURATION) * time.Second
+	max := time.Duration(constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_MAX_DURATION) * time.Second
+
+	for i := 1; i < lockouts && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		return max
+	}
+	return duration
+}
+
+// normalize makes the email the same however it was typed, so case changes don't get extra attempts.
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
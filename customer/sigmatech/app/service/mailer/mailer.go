@@ -0,0 +1,80 @@
+// Package mailer sends plain text emails through SMTP, or writes them to the log when there is no
+// SMTP server, which is enough to go through the flows that email a code locally.
+package mailer
+
+import (
+	"context"
+	"customer/sigmatech/app/service/logger"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Mail is a plain text email.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// IMailer delivers emails.
+type IMailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+// SMTPMailer sends emails through an SMTP server, authenticating when a username is set.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailer is a constructor function that creates a new SMTPMailer.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, strconv.Itoa(m.Port)), auth, m.From, []string{mail.To}, m.message(mail))
+}
+
+func (m *SMTPMailer) message(mail Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(mail.Body)
+	return []byte(b.String())
+}
+
+// LogMailer writes emails to the log instead of sending them. It is a stand-in for local setups and
+// must not be used in production, the log then holds the codes sent by email.
+type LogMailer struct{}
+
+// NewLogMailer is a constructor function that creates a new LogMailer.
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, mail Mail) error {
+	logger.Logger(ctx).Infof("mail to %s, subject %q:\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
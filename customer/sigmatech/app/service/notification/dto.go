@@ -0,0 +1,28 @@
+package notification
+
+import "github.com/google/uuid"
+
+// Recipient is the customer a notification is addressed to.
+type Recipient struct {
+	CustomerUuid uuid.UUID
+	Name         string
+	Email        string
+}
+
+// Event is a business event to notify a customer about.
+// Data is exposed to the templates next to the recipient "name" and "email".
+type Event struct {
+	Type          EventType
+	ReferenceUuid *uuid.UUID
+	Data          map[string]interface{}
+}
+
+// Message is a rendered notification handed to a channel sender.
+type Message struct {
+	Recipient     Recipient
+	EventType     EventType
+	Channel       Channel
+	ReferenceUuid *uuid.UUID
+	Subject       string
+	Body          string
+}
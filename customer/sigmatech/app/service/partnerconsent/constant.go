@@ -0,0 +1,13 @@
+package partnerconsent
+
+import "errors"
+
+// otpLength is the number of digits of a consent OTP.
+const otpLength = 6
+
+var (
+	ErrConsentNotFound = errors.New("consent not found")
+	ErrConsentUsed     = errors.New("consent has already been used")
+	ErrConsentExpired  = errors.New("consent has expired, request a new one")
+	ErrInvalidOtp      = errors.New("invalid otp")
+)
@@ -0,0 +1,157 @@
+// Package partnerconsent lets a merchant book a transaction on behalf of a customer once the customer
+// shares the one time password sent to them.
+package partnerconsent
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	merchants_DBModels "customer/sigmatech/app/db/dto/merchants"
+	partnerConsents_DBModels "customer/sigmatech/app/db/dto/partner_consents"
+	partnerConsentDB "customer/sigmatech/app/db/repository/partner_consent"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/util"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IPartnerConsentService interface {
+	// Request stores the consent for the transaction the merchant wants to book and sends its OTP to the
+	// customer.
+	Request(ctx context.Context, merchant *merchants_DBModels.Merchant, customer *customers_DBModels.Customer, consent partnerConsents_DBModels.PartnerConsent) (partnerConsents_DBModels.PartnerConsent, error)
+	// Consume uses up the consent of the merchant when the OTP matches, so it books a single transaction.
+	// Every OTP tried is counted, the consent is void once too many were wrong.
+	Consume(ctx context.Context, merchantUuid, consentUuid uuid.UUID, otp string) (partnerConsents_DBModels.PartnerConsent, error)
+	// Link records the transaction booked with a consumed consent.
+	Link(ctx context.Context, consent partnerConsents_DBModels.PartnerConsent, transactionUuid uuid.UUID) error
+}
+
+// PartnerConsentService is a struct that implements the IPartnerConsentService interface.
+type PartnerConsentService struct {
+	PartnerConsentDBClient partnerConsentDB.IPartnerConsentRepository
+	Notification           notification.INotificationService
+}
+
+// NewPartnerConsentService is a constructor function that creates a new PartnerConsentService.
+func NewPartnerConsentService(
+	PartnerConsentDBClient partnerConsentDB.IPartnerConsentRepository,
+	Notification notification.INotificationService,
+) *PartnerConsentService {
+	return &PartnerConsentService{
+		PartnerConsentDBClient: PartnerConsentDBClient,
+		Notification:           Notification,
+	}
+}
+
+func (s *PartnerConsentService) Request(ctx context.Context, merchant *merchants_DBModels.Merchant, customer *customers_DBModels.Customer, consent partnerConsents_DBModels.PartnerConsent) (partnerConsents_DBModels.PartnerConsent, error) {
+	otp, err := util.GenerateOTP(otpLength)
+	if err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+
+	ttl := time.Duration(constants.Config.PartnerConfig.PARTNER_CONSENT_TTL) * time.Second
+	now := time.Now()
+
+	consent.Uuid = uuid.New()
+	consent.MerchantUuid = merchant.Uuid
+	consent.CustomerUuid = customer.Uuid
+	consent.OtpHash = hashOtp(consent.Uuid, otp)
+	consent.ExpiresAt = now.Add(ttl)
+	consent.CreatedAt = now
+	consent.UpdatedAt = now
+
+	if err := s.PartnerConsentDBClient.CreatePartnerConsent(ctx, &consent); err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+
+	// The OTP only reaches the customer, without it the merchant can't book the transaction
+	if err := s.Notification.Notify(ctx, notification.Recipient{
+		CustomerUuid: customer.Uuid,
+		Name:         customer.Name,
+		Email:        customer.Email,
+	}, notification.Event{
+		Type:          notification.EventPartnerConsent,
+		ReferenceUuid: &consent.Uuid,
+		Data: map[string]interface{}{
+			"otp":           otp,
+			"merchant_name": merchant.Name,
+			"asset_name":    consent.AssetName,
+			"otr":           consent.Otr,
+			"ttl_minutes":   int(ttl.Minutes()),
+		},
+	}); err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+
+	return consent, nil
+}
+
+func (s *PartnerConsentService) Consume(ctx context.Context, merchantUuid, consentUuid uuid.UUID, otp string) (partnerConsents_DBModels.PartnerConsent, error) {
+	filter := where.Eq(partnerConsents_DBModels.COLUM_UUID, consentUuid).Eq(partnerConsents_DBModels.COLUMN_MERCHANT_UUID, merchantUuid)
+
+	consent, err := s.PartnerConsentDBClient.GetPartnerConsent(ctx, filter)
+	if err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+	if consent.Uuid == uuid.Nil {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrConsentNotFound
+	}
+	if consent.ConsumedAt != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrConsentUsed
+	}
+
+	maxAttempts := constants.Config.PartnerConfig.PARTNER_CONSENT_MAX_ATTEMPTS
+	if time.Now().After(consent.ExpiresAt) || consent.Attempts >= maxAttempts {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrConsentExpired
+	}
+
+	pending := filter.IsNull(partnerConsents_DBModels.COLUMN_CONSUMED_AT)
+
+	// The attempt is counted before the OTP is checked, so parallel guesses can't get past the limit
+	counted, err := s.PartnerConsentDBClient.AddPartnerConsentAttempt(ctx, pending, maxAttempts)
+	if err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+	if counted == 0 {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrConsentExpired
+	}
+
+	if subtle.ConstantTimeCompare([]byte(consent.OtpHash), []byte(hashOtp(consent.Uuid, otp))) != 1 {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrInvalidOtp
+	}
+
+	// Use the consent up before booking, so the same OTP can't book twice
+	now := time.Now()
+	consumed, err := s.PartnerConsentDBClient.UpdatePartnerConsent(ctx, pending, map[string]interface{}{
+		partnerConsents_DBModels.COLUMN_CONSUMED_AT: now,
+		partnerConsents_DBModels.COLUMN_UPDATED_AT:  now,
+	})
+	if err != nil {
+		return partnerConsents_DBModels.PartnerConsent{}, err
+	}
+	if consumed == 0 {
+		return partnerConsents_DBModels.PartnerConsent{}, ErrConsentUsed
+	}
+
+	consent.ConsumedAt = &now
+	return consent, nil
+}
+
+func (s *PartnerConsentService) Link(ctx context.Context, consent partnerConsents_DBModels.PartnerConsent, transactionUuid uuid.UUID) error {
+	_, err := s.PartnerConsentDBClient.UpdatePartnerConsent(ctx, where.Eq(partnerConsents_DBModels.COLUM_UUID, consent.Uuid), map[string]interface{}{
+		partnerConsents_DBModels.COLUMN_TRANSACTION_UUID: transactionUuid,
+		partnerConsents_DBModels.COLUMN_UPDATED_AT:       time.Now(),
+	})
+	return err
+}
+
+// hashOtp binds the OTP to its consent, so the stored hash can't be matched against other consents.
+func hashOtp(consentUuid uuid.UUID, otp string) string {
+	sum := sha256.Sum256([]byte(consentUuid.String() + ":" + otp))
+	return hex.EncodeToString(sum[:])
+}
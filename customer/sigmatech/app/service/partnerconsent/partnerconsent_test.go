@@ -0,0 +1,317 @@
+package partnerconsent
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	merchants_DBModels "customer/sigmatech/app/db/dto/merchants"
+	partnerConsents_DBModels "customer/sigmatech/app/db/dto/partner_consents"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/config"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// partnerConsentRepository keeps the consents in memory, matching them on the equality conditions of
+// the filters. It counts attempts and consumes consents under a lock, as the database does in SQL.
+type partnerConsentRepository struct {
+	mu       sync.Mutex
+	consents []*partnerConsents_DBModels.PartnerConsent
+}
+
+func (r *partnerConsentRepository) CreatePartnerConsent(ctx context.Context, consent *partnerConsents_DBModels.PartnerConsent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *consent
+	r.consents = append(r.consents, &stored)
+	return nil
+}
+
+func (r *partnerConsentRepository) GetPartnerConsent(ctx context.Context, whr where.Filter) (partnerConsents_DBModels.PartnerConsent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if consent := r.find(whr); consent != nil {
+		return *consent, nil
+	}
+	return partnerConsents_DBModels.PartnerConsent{}, nil
+}
+
+func (r *partnerConsentRepository) GetPartnerConsents(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*partnerConsents_DBModels.PartnerConsent, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *partnerConsentRepository) UpdatePartnerConsent(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	consent := r.find(whr)
+	if consent == nil {
+		return 0, nil
+	}
+	if consumedAt, ok := patch[partnerConsents_DBModels.COLUMN_CONSUMED_AT].(time.Time); ok {
+		consent.ConsumedAt = &consumedAt
+	}
+	if transactionUuid, ok := patch[partnerConsents_DBModels.COLUMN_TRANSACTION_UUID].(uuid.UUID); ok {
+		consent.TransactionUuid = &transactionUuid
+	}
+	return 1, nil
+}
+
+func (r *partnerConsentRepository) AddPartnerConsentAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	consent := r.find(whr)
+	if consent == nil || consent.Attempts >= maxAttempts {
+		return 0, nil
+	}
+	consent.Attempts++
+	return 1, nil
+}
+
+func (r *partnerConsentRepository) DeletePartnerConsent(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (r *partnerConsentRepository) find(whr where.Filter) *partnerConsents_DBModels.PartnerConsent {
+	for _, consent := range r.consents {
+		matches := true
+		for _, condition := range whr.Conditions {
+			switch condition.Column {
+			case partnerConsents_DBModels.COLUM_UUID:
+				matches = matches && condition.Value == consent.Uuid
+			case partnerConsents_DBModels.COLUMN_MERCHANT_UUID:
+				matches = matches && condition.Value == consent.MerchantUuid
+			case partnerConsents_DBModels.COLUMN_CONSUMED_AT:
+				matches = matches && consent.ConsumedAt == nil
+			}
+		}
+		if matches {
+			return consent
+		}
+	}
+	return nil
+}
+
+// notifier stands in for the notification service, it keeps the events sent.
+type notifier struct {
+	events []notification.Event
+}
+
+func (n *notifier) Notify(ctx context.Context, recipient notification.Recipient, event notification.Event) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+func (n *notifier) RegisterSender(channel notification.Channel, sender notification.ISender) {}
+
+func (n *notifier) lastOtp() string {
+	return n.events[len(n.events)-1].Data["otp"].(string)
+}
+
+func newTestService(t *testing.T) (*PartnerConsentService, *partnerConsentRepository, *notifier, partnerConsents_DBModels.PartnerConsent) {
+	constants.Config = &config.ServiceConfig{PartnerConfig: config.PartnerConfig{
+		PARTNER_CONSENT_TTL:          300,
+		PARTNER_CONSENT_MAX_ATTEMPTS: 3,
+	}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	consents := &partnerConsentRepository{}
+	notifications := &notifier{}
+	s := NewPartnerConsentService(consents, notifications)
+
+	merchant := &merchants_DBModels.Merchant{Uuid: uuid.New(), Name: "Dealer Motor"}
+	customer := &customers_DBModels.Customer{Uuid: uuid.New(), Name: "Budi", Email: "budi@sigmatech.id"}
+
+	consent, err := s.Request(context.Background(), merchant, customer, partnerConsents_DBModels.PartnerConsent{
+		CustomerLimitUuid: uuid.New(),
+		AssetName:         "Motor",
+		Otr:               15000000,
+	})
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	return s, consents, notifications, consent
+}
+
+func TestRequest(t *testing.T) {
+	_, consents, notifications, consent := newTestService(t)
+
+	if len(consents.consents) != 1 || consents.consents[0].Uuid != consent.Uuid {
+		t.Fatalf("Request() stored %v, want the consent %s", consents.consents, consent.Uuid)
+	}
+	if len(notifications.events) != 1 || notifications.events[0].Type != notification.EventPartnerConsent {
+		t.Fatalf("Request() sent %v, want a %s event", notifications.events, notification.EventPartnerConsent)
+	}
+
+	otp := notifications.lastOtp()
+	if len(otp) != otpLength {
+		t.Errorf("Request() sent otp %q, want %d digits", otp, otpLength)
+	}
+	if consent.OtpHash == otp || consent.OtpHash != hashOtp(consent.Uuid, otp) {
+		t.Errorf("Request() stored otp hash %q, want the hash of the otp bound to the consent", consent.OtpHash)
+	}
+	if ttl := consent.ExpiresAt.Sub(consent.CreatedAt); ttl != 300*time.Second {
+		t.Errorf("Request() consent expires after %s, want %s", ttl, 300*time.Second)
+	}
+}
+
+func TestConsume(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func(consent *partnerConsents_DBModels.PartnerConsent)
+		otp     func(otp string) string
+		other   bool
+		wantErr error
+	}{
+		{
+			name: "Given the sent otp When consuming Then the consent is used up",
+		},
+		{
+			name:    "Given a wrong otp When consuming Then it is refused",
+			otp:     wrongOtp,
+			wantErr: ErrInvalidOtp,
+		},
+		{
+			name:    "Given the consent of another merchant When consuming Then it is not found",
+			other:   true,
+			wantErr: ErrConsentNotFound,
+		},
+		{
+			name: "Given an expired consent When consuming with the sent otp Then it is refused",
+			prepare: func(consent *partnerConsents_DBModels.PartnerConsent) {
+				consent.ExpiresAt = time.Now().Add(-time.Second)
+			},
+			wantErr: ErrConsentExpired,
+		},
+		{
+			name: "Given a consent out of attempts When consuming with the sent otp Then it is refused",
+			prepare: func(consent *partnerConsents_DBModels.PartnerConsent) {
+				consent.Attempts = 3
+			},
+			wantErr: ErrConsentExpired,
+		},
+		{
+			name: "Given a used consent When consuming with the sent otp Then it is refused",
+			prepare: func(consent *partnerConsents_DBModels.PartnerConsent) {
+				consumedAt := time.Now()
+				consent.ConsumedAt = &consumedAt
+			},
+			wantErr: ErrConsentUsed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, consents, notifications, consent := newTestService(t)
+			if tt.prepare != nil {
+				tt.prepare(consents.consents[0])
+			}
+
+			otp := notifications.lastOtp()
+			if tt.otp != nil {
+				otp = tt.otp(otp)
+			}
+			merchantUuid := consent.MerchantUuid
+			if tt.other {
+				merchantUuid = uuid.New()
+			}
+
+			got, err := s.Consume(ctx, merchantUuid, consent.Uuid, otp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Consume() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.Uuid != consent.Uuid || got.ConsumedAt == nil || consents.consents[0].ConsumedAt == nil) {
+				t.Errorf("Consume() = %+v, want the consent %s used up", got, consent.Uuid)
+			}
+			if tt.wantErr != nil && tt.wantErr != ErrConsentUsed && consents.consents[0].ConsumedAt != nil {
+				t.Errorf("Consume() used up the consent, want it left pending")
+			}
+		})
+	}
+
+	t.Run("Given the sent otp When consuming twice Then the second is refused", func(t *testing.T) {
+		s, _, notifications, consent := newTestService(t)
+
+		if _, err := s.Consume(ctx, consent.MerchantUuid, consent.Uuid, notifications.lastOtp()); err != nil {
+			t.Fatalf("Consume() error = %v", err)
+		}
+		if _, err := s.Consume(ctx, consent.MerchantUuid, consent.Uuid, notifications.lastOtp()); !errors.Is(err, ErrConsentUsed) {
+			t.Errorf("Consume() again error = %v, want %v", err, ErrConsentUsed)
+		}
+	})
+
+	t.Run("Given too many wrong otps When consuming with the sent otp Then the consent is void", func(t *testing.T) {
+		s, consents, notifications, consent := newTestService(t)
+		otp := notifications.lastOtp()
+
+		for i := 0; i < constants.Config.PartnerConfig.PARTNER_CONSENT_MAX_ATTEMPTS; i++ {
+			if _, err := s.Consume(ctx, consent.MerchantUuid, consent.Uuid, wrongOtp(otp)); !errors.Is(err, ErrInvalidOtp) {
+				t.Fatalf("Consume() with a wrong otp error = %v, want %v", err, ErrInvalidOtp)
+			}
+		}
+
+		if _, err := s.Consume(ctx, consent.MerchantUuid, consent.Uuid, otp); !errors.Is(err, ErrConsentExpired) {
+			t.Errorf("Consume() error = %v, want %v", err, ErrConsentExpired)
+		}
+		if consents.consents[0].ConsumedAt != nil {
+			t.Errorf("Consume() used up a void consent")
+		}
+	})
+
+	t.Run("Given parallel wrong otps When consuming Then no more than the max are tried", func(t *testing.T) {
+		s, consents, notifications, consent := newTestService(t)
+		otp := wrongOtp(notifications.lastOtp())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = s.Consume(ctx, consent.MerchantUuid, consent.Uuid, otp)
+			}()
+		}
+		wg.Wait()
+
+		if attempts := consents.consents[0].Attempts; attempts != constants.Config.PartnerConfig.PARTNER_CONSENT_MAX_ATTEMPTS {
+			t.Errorf("Consume() counted %d attempts, want %d", attempts, constants.Config.PartnerConfig.PARTNER_CONSENT_MAX_ATTEMPTS)
+		}
+	})
+}
+
+func TestLink(t *testing.T) {
+	s, consents, notifications, consent := newTestService(t)
+
+	consent, err := s.Consume(context.Background(), consent.MerchantUuid, consent.Uuid, notifications.lastOtp())
+	if err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+
+	transactionUuid := uuid.New()
+	if err := s.Link(context.Background(), consent, transactionUuid); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+	if got := consents.consents[0].TransactionUuid; got == nil || *got != transactionUuid {
+		t.Errorf("Link() linked %v, want %s", got, transactionUuid)
+	}
+}
+
+func wrongOtp(otp string) string {
+	if otp == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
@@ -0,0 +1,187 @@
+// Package passwordreset lets customers who forgot their password set a new one with a one time
+// password emailed to them.
+package passwordreset
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	passwordResets_DBModels "customer/sigmatech/app/db/dto/password_resets"
+	customerDB "customer/sigmatech/app/db/repository/customer"
+	passwordResetDB "customer/sigmatech/app/db/repository/password_reset"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/mailer"
+	"customer/sigmatech/app/service/util"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ISessionRevoker ends every session of a customer.
+type ISessionRevoker interface {
+	RevokeSessions(ctx context.Context, customerUuid uuid.UUID) error
+}
+
+type IPasswordResetService interface {
+	// Request emails a reset OTP when the email belongs to a customer. It returns nil whether it does or
+	// not, and when the email already got too many OTPs lately.
+	Request(ctx context.Context, email string) error
+	// Reset sets the password when the OTP matches the latest one sent to the email, then ends every
+	// session of the customer.
+	Reset(ctx context.Context, email, otp, password string) error
+}
+
+// PasswordResetService is a struct that implements the IPasswordResetService interface.
+type PasswordResetService struct {
+	CustomerDBClient      customerDB.ICustomerRepository
+	PasswordResetDBClient passwordResetDB.IPasswordResetRepository
+	Mailer                mailer.IMailer
+	Sessions              ISessionRevoker
+}
+
+// NewPasswordResetService is a constructor function that creates a new PasswordResetService.
+func NewPasswordResetService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	PasswordResetDBClient passwordResetDB.IPasswordResetRepository,
+	Mailer mailer.IMailer,
+	Sessions ISessionRevoker,
+) *PasswordResetService {
+	return &PasswordResetService{
+		CustomerDBClient:      CustomerDBClient,
+		PasswordResetDBClient: PasswordResetDBClient,
+		Mailer:                Mailer,
+		Sessions:              Sessions,
+	}
+}
+
+func (s *PasswordResetService) Request(ctx context.Context, email string) error {
+	log := logger.Logger(ctx)
+
+	customer, err := s.getCustomer(ctx, email)
+	if err != nil {
+		return err
+	}
+	if customer.Uuid == uuid.Nil {
+		return nil
+	}
+
+	now := time.Now()
+	window := time.Duration(constants.Config.PasswordResetConfig.PASSWORD_RESET_WINDOW) * time.Second
+
+	sent, err := s.PasswordResetDBClient.CountPasswordResets(ctx, where.Eq(passwordResets_DBModels.COLUMN_SUBJECT_TYPE, passwordResets_DBModels.SUBJECT_CUSTOMER).
+		Eq(passwordResets_DBModels.COLUMN_EMAIL, customer.Email).
+		Gt(passwordResets_DBModels.COLUMN_CREATED_AT, now.Add(-window).Format("2006-01-02 15:04:05")))
+	if err != nil {
+		return err
+	}
+	if sent >= constants.Config.PasswordResetConfig.PASSWORD_RESET_LIMIT {
+		log.Warnf("password reset of customer %s is rate limited, %d otps sent in the last %s", customer.Uuid, sent, window)
+		return nil
+	}
+
+	otp, err := util.GenerateOTP(otpLength)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(constants.Config.PasswordResetConfig.PASSWORD_RESET_TTL) * time.Second
+
+	data := passwordResets_DBModels.PasswordReset{
+		Uuid:        uuid.New(),
+		SubjectUuid: customer.Uuid,
+		SubjectType: passwordResets_DBModels.SUBJECT_CUSTOMER,
+		Email:       customer.Email,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	data.OtpHash = hashOtp(data.Uuid, otp)
+
+	if err := s.PasswordResetDBClient.CreatePasswordReset(ctx, &data); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, mailer.Mail{
+		To:      customer.Email,
+		Subject: mailSubject,
+		Body:    fmt.Sprintf(mailBody, customer.Name, otp, int(ttl.Minutes())),
+	})
+}
+
+func (s *PasswordResetService) Reset(ctx context.Context, email, otp, password string) error {
+	customer, err := s.getCustomer(ctx, email)
+	if err != nil {
+		return err
+	}
+	if customer.Uuid == uuid.Nil {
+		return ErrInvalidOtp
+	}
+
+	pending := where.Eq(passwordResets_DBModels.COLUMN_SUBJECT_TYPE, passwordResets_DBModels.SUBJECT_CUSTOMER).
+		Eq(passwordResets_DBModels.COLUMN_SUBJECT_UUID, customer.Uuid).
+		IsNull(passwordResets_DBModels.COLUMN_CONSUMED_AT)
+
+	reset, err := s.PasswordResetDBClient.GetPasswordReset(ctx, pending)
+	if err != nil {
+		return err
+	}
+	maxAttempts := constants.Config.PasswordResetConfig.PASSWORD_RESET_MAX_ATTEMPTS
+	if reset.Uuid == uuid.Nil || time.Now().After(reset.ExpiresAt) || reset.Attempts >= maxAttempts {
+		return ErrInvalidOtp
+	}
+
+	// The attempt is counted before the OTP is checked, so parallel guesses can't get past the limit
+	counted, err := s.PasswordResetDBClient.AddPasswordResetAttempt(ctx, where.Eq(passwordResets_DBModels.COLUM_UUID, reset.Uuid).
+		IsNull(passwordResets_DBModels.COLUMN_CONSUMED_AT), maxAttempts)
+	if err != nil {
+		return err
+	}
+	if counted == 0 {
+		return ErrInvalidOtp
+	}
+
+	if subtle.ConstantTimeCompare([]byte(reset.OtpHash), []byte(hashOtp(reset.Uuid, otp))) != 1 {
+		return ErrInvalidOtp
+	}
+
+	// Consuming every pending reset voids the older OTPs too, the one matched is only counted once
+	consumed, err := s.PasswordResetDBClient.UpdatePasswordReset(ctx, pending, map[string]interface{}{
+		passwordResets_DBModels.COLUMN_CONSUMED_AT: time.Now(),
+		passwordResets_DBModels.COLUMN_UPDATED_AT:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if consumed == 0 {
+		return ErrInvalidOtp
+	}
+
+	hashedPassword, err := util.GenerateHash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.CustomerDBClient.UpdateCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, customer.Uuid), map[string]interface{}{
+		customers_DBModels.COLUMN_PASSWORD:   hashedPassword,
+		customers_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	return s.Sessions.RevokeSessions(ctx, customer.Uuid)
+}
+
+func (s *PasswordResetService) getCustomer(ctx context.Context, email string) (customers_DBModels.Customer, error) {
+	return s.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUMN_EMAIL, email))
+}
+
+// hashOtp binds the OTP to its reset, so the stored hash can't be matched against other resets.
+func hashOtp(resetUuid uuid.UUID, otp string) string {
+	sum := sha256.Sum256([]byte(resetUuid.String() + ":" + otp))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,260 @@
+package passwordreset
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	passwordResets_DBModels "customer/sigmatech/app/db/dto/password_resets"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/mailer"
+	"customer/sigmatech/app/service/util"
+	"customer/sigmatech/config"
+	"errors"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// customerRepository holds a single customer, returned for its email only.
+type customerRepository struct {
+	customer customers_DBModels.Customer
+}
+
+func (r *customerRepository) CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error {
+	return nil
+}
+
+func (r *customerRepository) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
+	if !reflect.DeepEqual(whr, where.Eq(customers_DBModels.COLUMN_EMAIL, r.customer.Email)) {
+		return customers_DBModels.Customer{}, nil
+	}
+	return r.customer, nil
+}
+
+func (r *customerRepository) GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *customerRepository) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	r.customer.Password = patch[customers_DBModels.COLUMN_PASSWORD].(string)
+	return nil
+}
+
+func (r *customerRepository) DeleteCustomer(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// passwordResetRepository keeps the resets of a single customer, it ignores the where clauses. It counts
+// attempts under a lock, as the database does in SQL.
+type passwordResetRepository struct {
+	mu     sync.Mutex
+	resets []*passwordResets_DBModels.PasswordReset
+}
+
+func (r *passwordResetRepository) CreatePasswordReset(ctx context.Context, reset *passwordResets_DBModels.PasswordReset) error {
+	r.resets = append(r.resets, reset)
+	return nil
+}
+
+func (r *passwordResetRepository) GetPasswordReset(ctx context.Context, whr where.Filter) (passwordResets_DBModels.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reset := r.pending(); reset != nil {
+		return *reset, nil
+	}
+	return passwordResets_DBModels.PasswordReset{}, nil
+}
+
+func (r *passwordResetRepository) CountPasswordResets(ctx context.Context, whr where.Filter) (int, error) {
+	return len(r.resets), nil
+}
+
+// UpdatePasswordReset consumes every pending reset, like the service does.
+func (r *passwordResetRepository) UpdatePasswordReset(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var consumed int64
+	for reset := r.pending(); reset != nil; reset = r.pending() {
+		reset.ConsumedAt = &reset.CreatedAt
+		consumed++
+	}
+	return consumed, nil
+}
+
+// AddPasswordResetAttempt counts an attempt on the latest reset while it is pending and under maxAttempts.
+func (r *passwordResetRepository) AddPasswordResetAttempt(ctx context.Context, whr where.Filter, maxAttempts int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reset := r.pending()
+	if reset == nil || reset.Attempts >= maxAttempts {
+		return 0, nil
+	}
+	reset.Attempts++
+	return 1, nil
+}
+
+func (r *passwordResetRepository) pending() *passwordResets_DBModels.PasswordReset {
+	for i := len(r.resets) - 1; i >= 0; i-- {
+		if r.resets[i].ConsumedAt == nil {
+			return r.resets[i]
+		}
+	}
+	return nil
+}
+
+// outbox stands in for the mailer, it keeps the mails sent.
+type outbox struct {
+	mails []mailer.Mail
+}
+
+func (o *outbox) Send(ctx context.Context, mail mailer.Mail) error {
+	o.mails = append(o.mails, mail)
+	return nil
+}
+
+var otpPattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func (o *outbox) lastOtp() string {
+	return otpPattern.FindString(o.mails[len(o.mails)-1].Body)
+}
+
+type sessions struct {
+	revoked []uuid.UUID
+}
+
+func (s *sessions) RevokeSessions(ctx context.Context, customerUuid uuid.UUID) error {
+	s.revoked = append(s.revoked, customerUuid)
+	return nil
+}
+
+func newTestService() (*PasswordResetService, *customerRepository, *outbox, *sessions) {
+	constants.Config = &config.ServiceConfig{PasswordResetConfig: config.PasswordResetConfig{
+		PASSWORD_RESET_TTL:          900,
+		PASSWORD_RESET_MAX_ATTEMPTS: 3,
+		PASSWORD_RESET_LIMIT:        2,
+		PASSWORD_RESET_WINDOW:       3600,
+	}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	customers := &customerRepository{customer: customers_DBModels.Customer{
+		Uuid:  uuid.New(),
+		Name:  "Budi",
+		Email: "budi@sigmatech.id",
+	}}
+	mails := &outbox{}
+	revoker := &sessions{}
+
+	return NewPasswordResetService(customers, &passwordResetRepository{}, mails, revoker), customers, mails, revoker
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Given the emailed otp When resetting Then the password changes and every session is revoked", func(t *testing.T) {
+		s, customers, mails, revoker := newTestService()
+
+		if err := s.Request(ctx, customers.customer.Email); err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		if len(mails.mails) != 1 || mails.mails[0].To != customers.customer.Email {
+			t.Fatalf("Request() sent %v, want a mail to %s", mails.mails, customers.customer.Email)
+		}
+
+		if err := s.Reset(ctx, customers.customer.Email, mails.lastOtp(), "n3w-password"); err != nil {
+			t.Fatalf("Reset() error = %v", err)
+		}
+		if !util.ValidatePassword("n3w-password", customers.customer.Password) {
+			t.Errorf("Reset() didn't set the new password")
+		}
+		if len(revoker.revoked) != 1 || revoker.revoked[0] != customers.customer.Uuid {
+			t.Errorf("Reset() revoked %v, want the sessions of %s", revoker.revoked, customers.customer.Uuid)
+		}
+
+		if err := s.Reset(ctx, customers.customer.Email, mails.lastOtp(), "an0ther-password"); !errors.Is(err, ErrInvalidOtp) {
+			t.Errorf("Reset() with a used otp error = %v, want %v", err, ErrInvalidOtp)
+		}
+	})
+
+	t.Run("Given an unknown email When requesting Then nothing is sent and no error is returned", func(t *testing.T) {
+		s, _, mails, _ := newTestService()
+
+		if err := s.Request(ctx, "nobody@sigmatech.id"); err != nil {
+			t.Fatalf("Request() error = %v", err)
+		}
+		if len(mails.mails) != 0 {
+			t.Errorf("Request() sent %d mails, want none", len(mails.mails))
+		}
+		if err := s.Reset(ctx, "nobody@sigmatech.id", "123456", "n3w-password"); !errors.Is(err, ErrInvalidOtp) {
+			t.Errorf("Reset() error = %v, want %v", err, ErrInvalidOtp)
+		}
+	})
+
+	t.Run("Given too many wrong otps When resetting with the right one Then it is refused", func(t *testing.T) {
+		s, customers, mails, _ := newTestService()
+		_ = s.Request(ctx, customers.customer.Email)
+		otp := mails.lastOtp()
+
+		wrong := "000000"
+		if otp == wrong {
+			wrong = "111111"
+		}
+		for i := 0; i < constants.Config.PasswordResetConfig.PASSWORD_RESET_MAX_ATTEMPTS; i++ {
+			if err := s.Reset(ctx, customers.customer.Email, wrong, "n3w-password"); !errors.Is(err, ErrInvalidOtp) {
+				t.Fatalf("Reset() with a wrong otp error = %v, want %v", err, ErrInvalidOtp)
+			}
+		}
+
+		if err := s.Reset(ctx, customers.customer.Email, otp, "n3w-password"); !errors.Is(err, ErrInvalidOtp) {
+			t.Errorf("Reset() after too many attempts error = %v, want %v", err, ErrInvalidOtp)
+		}
+	})
+
+	t.Run("Given parallel wrong otps When resetting Then no more than the max are tried", func(t *testing.T) {
+		s, customers, mails, _ := newTestService()
+		_ = s.Request(ctx, customers.customer.Email)
+		otp := mails.lastOtp()
+
+		wrong := "000000"
+		if otp == wrong {
+			wrong = "111111"
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = s.Reset(ctx, customers.customer.Email, wrong, "n3w-password")
+			}()
+		}
+		wg.Wait()
+
+		reset, _ := s.PasswordResetDBClient.GetPasswordReset(ctx, where.Filter{})
+		if reset.Attempts != constants.Config.PasswordResetConfig.PASSWORD_RESET_MAX_ATTEMPTS {
+			t.Errorf("Reset() counted %d attempts, want %d", reset.Attempts, constants.Config.PasswordResetConfig.PASSWORD_RESET_MAX_ATTEMPTS)
+		}
+	})
+
+	t.Run("Given the limit of otps was sent When requesting again Then no mail is sent", func(t *testing.T) {
+		s, customers, mails, _ := newTestService()
+
+		for i := 0; i < constants.Config.PasswordResetConfig.PASSWORD_RESET_LIMIT+1; i++ {
+			if err := s.Request(ctx, customers.customer.Email); err != nil {
+				t.Fatalf("Request() error = %v", err)
+			}
+		}
+		if len(mails.mails) != constants.Config.PasswordResetConfig.PASSWORD_RESET_LIMIT {
+			t.Errorf("Request() sent %d mails, want %d", len(mails.mails), constants.Config.PasswordResetConfig.PASSWORD_RESET_LIMIT)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package payment
+
+import (
+	paymentCallbacks_DBModels "customer/sigmatech/app/db/dto/payment_callbacks"
+	"errors"
+)
+
+const (
+	// Providers
+	PROVIDER_SIMULATOR = "simulator"
+
+	// Status of a received callback
+	CALLBACK_STATUS_PENDING   = paymentCallbacks_DBModels.STATUS_PENDING
+	CALLBACK_STATUS_APPLIED   = paymentCallbacks_DBModels.STATUS_APPLIED
+	CALLBACK_STATUS_UNAPPLIED = paymentCallbacks_DBModels.STATUS_UNAPPLIED
+	CALLBACK_STATUS_OVERPAID  = paymentCallbacks_DBModels.STATUS_OVERPAID
+
+	// METHOD_PAYMENT_VIRTUAL_ACCOUNT is stored in method_payment of a paid installment, followed by the provider
+	METHOD_PAYMENT_VIRTUAL_ACCOUNT = "virtual_account"
+
+	// Header carrying the signature of a simulator callback
+	HEADER_SIMULATOR_SIGNATURE = "X-Callback-Signature"
+
+	simulatorAccountPrefix = "988"
+	simulatorAccountDigits = 13
+
+	// amountTolerance absorbs rounding of installment amounts when deciding if an installment is paid off
+	amountTolerance = 0.01
+)
+
+var (
+	ErrUnknownProvider          = errors.New("unknown payment provider")
+	ErrNoProvider               = errors.New("no payment provider is configured")
+	ErrInvalidCallbackSignature = errors.New("invalid callback signature")
+	ErrInvalidCallback          = errors.New("invalid callback payload")
+	ErrVirtualAccountNotFound   = errors.New("virtual account not found")
+	ErrInstallmentNotFound      = errors.New("installment not found")
+	ErrInstallmentAlreadyPaid   = errors.New("installment has already been paid")
+)
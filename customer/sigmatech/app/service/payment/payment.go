@@ -0,0 +1,404 @@
+// Package payment issues virtual accounts through a payment provider and applies the payments
+// the provider reports to the installments of the customer.
+package payment
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	paymentCallbacks_DBModels "customer/sigmatech/app/db/dto/payment_callbacks"
+	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
+	virtualAccounts_DBModels "customer/sigmatech/app/db/dto/virtual_accounts"
+	customerDB "customer/sigmatech/app/db/repository/customer"
+	paymentCallbackDB "customer/sigmatech/app/db/repository/payment_callback"
+	transactionDB "customer/sigmatech/app/db/repository/transaction"
+	transactionInstallmentDB "customer/sigmatech/app/db/repository/transaction_installment"
+	virtualAccountDB "customer/sigmatech/app/db/repository/virtual_account"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/util"
+	"customer/sigmatech/app/service/webhook"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IPaymentService interface {
+	// IssueVirtualAccount returns the active virtual account of the installment, or of the customer
+	// when installmentUuid is nil, issuing a new one through the provider when there is none.
+	IssueVirtualAccount(ctx context.Context, customer *customers_DBModels.Customer, installmentUuid *uuid.UUID) (virtualAccounts_DBModels.VirtualAccount, error)
+	// HandleCallback verifies a provider callback and applies the payment once, however often it is sent.
+	HandleCallback(ctx context.Context, provider string, header http.Header, body []byte) (paymentCallbacks_DBModels.PaymentCallback, error)
+}
+
+// PaymentService is a struct that implements the IPaymentService interface.
+type PaymentService struct {
+	CustomerDBClient               customerDB.ICustomerRepository
+	TransactionDBClient            transactionDB.ITransactionRepository
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
+	VirtualAccountDBClient         virtualAccountDB.IVirtualAccountRepository
+	PaymentCallbackDBClient        paymentCallbackDB.IPaymentCallbackRepository
+	Provider                       IProvider // provider new virtual accounts are issued with
+	Providers                      map[string]IProvider
+	Notification                   notification.INotificationService
+	Webhook                        webhook.IWebhookService
+	Now                            func() time.Time
+}
+
+// NewPaymentService is a constructor function that creates a new PaymentService.
+// Callbacks are accepted from every given provider, the first one issues new virtual accounts.
+func NewPaymentService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
+	VirtualAccountDBClient virtualAccountDB.IVirtualAccountRepository,
+	PaymentCallbackDBClient paymentCallbackDB.IPaymentCallbackRepository,
+	Notification notification.INotificationService,
+	Webhook webhook.IWebhookService,
+	providers ...IProvider,
+) *PaymentService {
+	s := &PaymentService{
+		CustomerDBClient:               CustomerDBClient,
+		TransactionDBClient:            TransactionDBClient,
+		TransactionInstallmentDBClient: TransactionInstallmentDBClient,
+		VirtualAccountDBClient:         VirtualAccountDBClient,
+		PaymentCallbackDBClient:        PaymentCallbackDBClient,
+		Providers:                      make(map[string]IProvider),
+		Notification:                   Notification,
+		Webhook:                        Webhook,
+		Now:                            time.Now,
+	}
+	for i, p := range providers {
+		if i == 0 {
+			s.Provider = p
+		}
+		s.Providers[p.Name()] = p
+	}
+	return s
+}
+
+func (s *PaymentService) IssueVirtualAccount(ctx context.Context, customer *customers_DBModels.Customer, installmentUuid *uuid.UUID) (virtualAccounts_DBModels.VirtualAccount, error) {
+	if s.Provider == nil {
+		return virtualAccounts_DBModels.VirtualAccount{}, ErrNoProvider
+	}
+
+	now := s.Now()
+
+	whr := where.Eq(virtualAccounts_DBModels.COLUMN_PROVIDER, s.Provider.Name()).
+		Eq(virtualAccounts_DBModels.COLUMN_CUSTOMER_UUID, customer.Uuid).
+		Eq(virtualAccounts_DBModels.COLUMN_IS_ACTIVE, true)
+
+	var amount *float64
+	if installmentUuid != nil {
+		installment, err := s.getCustomerInstallment(ctx, customer.Uuid, *installmentUuid)
+		if err != nil {
+			return virtualAccounts_DBModels.VirtualAccount{}, err
+		}
+
+		if installment.PaymentAt != nil {
+			return virtualAccounts_DBModels.VirtualAccount{}, ErrInstallmentAlreadyPaid
+		}
+
+		outstanding := installment.Amount - installment.AmountPaid
+		amount = &outstanding
+		whr = whr.Eq(virtualAccounts_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID, installment.Uuid)
+	} else {
+		whr = whr.IsNull(virtualAccounts_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID)
+	}
+
+	existing, err := s.VirtualAccountDBClient.GetVirtualAccount(ctx, whr)
+	if err != nil {
+		return virtualAccounts_DBModels.VirtualAccount{}, err
+	}
+
+	if existing.Uuid != uuid.Nil && existing.IsUsable(now) {
+		return existing, nil
+	}
+
+	var expiresAt *time.Time
+	if ttl := constants.Config.PaymentConfig.PAYMENT_VIRTUAL_ACCOUNT_TTL; ttl > 0 {
+		expiresAt = util.Time(now.Add(time.Duration(ttl) * time.Second))
+	}
+
+	issued, err := s.Provider.CreateVirtualAccount(ctx, VirtualAccountRequest{
+		CustomerUuid:               customer.Uuid,
+		CustomerName:               customer.Name,
+		TransactionInstallmentUuid: installmentUuid,
+		Amount:                     amount,
+		ExpiresAt:                  expiresAt,
+	})
+	if err != nil {
+		return virtualAccounts_DBModels.VirtualAccount{}, err
+	}
+
+	data := virtualAccounts_DBModels.VirtualAccount{
+		Uuid:                       uuid.New(),
+		Provider:                   s.Provider.Name(),
+		AccountNumber:              issued.AccountNumber,
+		CustomerUuid:               customer.Uuid,
+		TransactionInstallmentUuid: installmentUuid,
+		Amount:                     amount,
+		IsActive:                   util.Boolean(true),
+		ExpiresAt:                  issued.ExpiresAt,
+		CreatedAt:                  now,
+		UpdatedAt:                  now,
+	}
+
+	if err := s.VirtualAccountDBClient.CreateVirtualAccount(ctx, &data); err != nil {
+		return virtualAccounts_DBModels.VirtualAccount{}, err
+	}
+
+	// An expired account is replaced, not reused
+	if existing.Uuid != uuid.Nil {
+		s.deactivate(ctx, existing.Uuid)
+	}
+
+	return data, nil
+}
+
+func (s *PaymentService) HandleCallback(ctx context.Context, providerName string, header http.Header, body []byte) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	log := logger.Logger(ctx)
+
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return paymentCallbacks_DBModels.PaymentCallback{}, ErrUnknownProvider
+	}
+
+	callback, err := provider.ParseCallback(header, body)
+	if err != nil {
+		return paymentCallbacks_DBModels.PaymentCallback{}, err
+	}
+
+	// Providers retry until they get a success, a callback we already have is acknowledged as is unless
+	// applying it failed, then the retry applies it
+	existing, err := s.getCallback(ctx, providerName, callback.ExternalId)
+	if err != nil || (existing.Uuid != uuid.Nil && existing.Status != CALLBACK_STATUS_PENDING) {
+		return existing, err
+	}
+
+	va, err := s.VirtualAccountDBClient.GetVirtualAccount(ctx, where.Eq(virtualAccounts_DBModels.COLUMN_PROVIDER, providerName).Eq(virtualAccounts_DBModels.COLUMN_ACCOUNT_NUMBER, callback.AccountNumber))
+	if err != nil {
+		return paymentCallbacks_DBModels.PaymentCallback{}, err
+	}
+
+	if va.Uuid == uuid.Nil {
+		return paymentCallbacks_DBModels.PaymentCallback{}, ErrVirtualAccountNotFound
+	}
+
+	data := existing
+	if data.Uuid == uuid.Nil {
+		var installment transaction_installments_DBModels.TransactionInstallment
+		if va.TransactionInstallmentUuid != nil {
+			installment, err = s.getCustomerInstallment(ctx, va.CustomerUuid, *va.TransactionInstallmentUuid)
+		} else {
+			installment, err = s.TransactionInstallmentDBClient.GetNextUnpaidTransactionInstallment(ctx, va.CustomerUuid.String())
+		}
+		if err != nil && !errors.Is(err, ErrInstallmentNotFound) {
+			return paymentCallbacks_DBModels.PaymentCallback{}, err
+		}
+
+		data = paymentCallbacks_DBModels.PaymentCallback{
+			Uuid:               uuid.New(),
+			Provider:           providerName,
+			ExternalId:         callback.ExternalId,
+			VirtualAccountUuid: &va.Uuid,
+			Amount:             callback.Amount,
+			PaidAt:             callback.PaidAt,
+			Status:             CALLBACK_STATUS_PENDING,
+			Payload:            string(body),
+			CreatedAt:          s.Now(),
+		}
+
+		if installment.Uuid == uuid.Nil || installment.PaymentAt != nil {
+			data.Status = CALLBACK_STATUS_UNAPPLIED
+		} else {
+			data.TransactionInstallmentUuid = &installment.Uuid
+		}
+
+		// The unique provider and external id make the insert the idempotency guard, a concurrent
+		// duplicate fails here and is answered with the callback that won
+		if err := s.PaymentCallbackDBClient.CreatePaymentCallback(ctx, &data); err != nil {
+			if util.ExtractConstraintName(err.Error()) != "" {
+				return s.getCallback(ctx, providerName, callback.ExternalId)
+			}
+			return paymentCallbacks_DBModels.PaymentCallback{}, err
+		}
+	}
+
+	if data.Status == CALLBACK_STATUS_UNAPPLIED {
+		log.Warnf("payment %s of %.2f into virtual account %s has no unpaid installment to apply to", callback.ExternalId, callback.Amount, va.AccountNumber)
+		return data, nil
+	}
+
+	// A failed apply leaves the callback pending, so the provider's retry applies it
+	return s.apply(ctx, va, data)
+}
+
+// apply adds the payment of a pending callback to its installment and, once the installment is paid off,
+// closes its virtual account, marks the contract done when it was the last one and lets everyone know.
+// What follows the payment is only logged when it fails, the payment itself is already recorded.
+func (s *PaymentService) apply(ctx context.Context, va virtualAccounts_DBModels.VirtualAccount, callback paymentCallbacks_DBModels.PaymentCallback) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	log := logger.Logger(ctx)
+
+	methodPayment := fmt.Sprintf("%s:%s", METHOD_PAYMENT_VIRTUAL_ACCOUNT, va.Provider)
+
+	var patcher = make(map[string]interface{})
+	patcher[transaction_installments_DBModels.COLUMN_METHOD_PAYMENT] = methodPayment
+	patcher[transaction_installments_DBModels.COLUMN_UPDATED_AT] = s.Now()
+
+	applied, surplus, err := s.PaymentCallbackDBClient.ApplyPaymentCallback(ctx, callback, patcher, amountTolerance)
+	if err != nil {
+		return paymentCallbacks_DBModels.PaymentCallback{}, err
+	}
+
+	if !applied {
+		// A concurrent retry applied the callback first, or the installment was paid off in the meantime
+		current, err := s.getCallback(ctx, callback.Provider, callback.ExternalId)
+		if err != nil || current.Status != CALLBACK_STATUS_PENDING {
+			return current, err
+		}
+
+		patcher = make(map[string]interface{})
+		patcher[paymentCallbacks_DBModels.COLUMN_STATUS] = CALLBACK_STATUS_UNAPPLIED
+		patcher[paymentCallbacks_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID] = nil
+
+		fCallback := where.Eq(paymentCallbacks_DBModels.COLUM_UUID, callback.Uuid).Eq(paymentCallbacks_DBModels.COLUMN_STATUS, CALLBACK_STATUS_PENDING)
+		if err := s.PaymentCallbackDBClient.UpdatePaymentCallback(ctx, fCallback, patcher); err != nil {
+			return paymentCallbacks_DBModels.PaymentCallback{}, err
+		}
+
+		log.Warnf("payment %s of %.2f into virtual account %s has no unpaid installment to apply to", callback.ExternalId, callback.Amount, va.AccountNumber)
+		return s.getCallback(ctx, callback.Provider, callback.ExternalId)
+	}
+
+	callback.Status = CALLBACK_STATUS_APPLIED
+	if surplus > 0 {
+		// Only what the installment owed is applied, the rest is kept on the callback to be refunded or moved
+		callback.Status, callback.Surplus = CALLBACK_STATUS_OVERPAID, surplus
+		log.Warnf("payment %s of %.2f into virtual account %s overpaid installment %s by %.2f, the surplus needs review", callback.ExternalId, callback.Amount, va.AccountNumber, *callback.TransactionInstallmentUuid, surplus)
+	}
+
+	installment, err := s.TransactionInstallmentDBClient.GetTransactionInstallment(ctx, where.Eq(transaction_installments_DBModels.COLUM_UUID, *callback.TransactionInstallmentUuid))
+	if err != nil {
+		log.Errorf("Error getting installment %s paid by payment %s: %v", *callback.TransactionInstallmentUuid, callback.ExternalId, err)
+		return callback, nil
+	}
+
+	if installment.PaymentAt == nil {
+		return callback, nil
+	}
+
+	if va.TransactionInstallmentUuid != nil {
+		s.deactivate(ctx, va.Uuid)
+	}
+
+	if err := s.settle(ctx, va, installment); err != nil {
+		log.Errorf("Error settling installment %s paid by payment %s: %v", installment.Uuid, callback.ExternalId, err)
+	}
+
+	return callback, nil
+}
+
+// settle marks the contract of a paid off installment done when it was the last one, and lets the
+// customer and subscribed partners know.
+func (s *PaymentService) settle(ctx context.Context, va virtualAccounts_DBModels.VirtualAccount, installment transaction_installments_DBModels.TransactionInstallment) error {
+	log := logger.Logger(ctx)
+
+	transaction, err := s.TransactionDBClient.GetTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, installment.TransactionUuid))
+	if err != nil {
+		return err
+	}
+
+	p := request.Pagination{GetAllData: true}
+	p.Validate()
+
+	unpaid, _, err := s.TransactionInstallmentDBClient.GetTransactionInstallments(ctx, p, map[string]interface{}{
+		transaction_installments_DBModels.COLUMN_TRANSACTION_UUID: transaction.Uuid.String(),
+		transaction_installments_DBModels.COLUMN_PAYMENT_AT:       nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(unpaid) == 0 {
+		var patcher = make(map[string]interface{})
+		patcher[transactions_DBModels.COLUMN_IS_DONE] = true
+		patcher[transactions_DBModels.COLUMN_UPDATED_AT] = s.Now()
+
+		if err := s.TransactionDBClient.UpdateTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, transaction.Uuid), patcher); err != nil {
+			return err
+		}
+		transaction.IsDone = util.Boolean(true)
+	}
+
+	customer, err := s.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, va.CustomerUuid))
+	if err != nil {
+		log.Errorf("Error getting customer %s to notify about payment: %v", va.CustomerUuid, err)
+	} else if err := s.Notification.Notify(ctx, notification.Recipient{
+		CustomerUuid: customer.Uuid,
+		Name:         customer.Name,
+		Email:        customer.Email,
+	}, notification.Event{
+		Type:          notification.EventInstallmentPaid,
+		ReferenceUuid: &installment.Uuid,
+		Data: map[string]interface{}{
+			"contract_number": transaction.ContractNumber,
+			"term":            installment.Term,
+			"amount_paid":     installment.AmountPaid,
+		},
+	}); err != nil {
+		log.Errorf("Error sending payment notification to customer %s: %v", customer.Uuid, err)
+	}
+
+	if err := s.Webhook.Publish(ctx, webhook.EventTransactionPaid, &transaction.Uuid, map[string]interface{}{
+		"transaction": transaction,
+		"installment": installment,
+	}); err != nil {
+		log.Errorf("Error publishing payment webhook for contract %s: %v", transaction.ContractNumber, err)
+	}
+
+	return nil
+}
+
+// getCustomerInstallment returns the installment when it belongs to a contract of the customer.
+func (s *PaymentService) getCustomerInstallment(ctx context.Context, customerUuid, installmentUuid uuid.UUID) (transaction_installments_DBModels.TransactionInstallment, error) {
+	installment, err := s.TransactionInstallmentDBClient.GetTransactionInstallment(ctx, where.Eq(transaction_installments_DBModels.COLUM_UUID, installmentUuid))
+	if err != nil {
+		return transaction_installments_DBModels.TransactionInstallment{}, err
+	}
+
+	if installment.Uuid == uuid.Nil {
+		return transaction_installments_DBModels.TransactionInstallment{}, ErrInstallmentNotFound
+	}
+
+	transaction, err := s.TransactionDBClient.GetTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, installment.TransactionUuid).Eq(transactions_DBModels.COLUMN_CUSTOMER_UUID, customerUuid))
+	if err != nil {
+		return transaction_installments_DBModels.TransactionInstallment{}, err
+	}
+
+	if transaction.Uuid == uuid.Nil {
+		return transaction_installments_DBModels.TransactionInstallment{}, ErrInstallmentNotFound
+	}
+
+	return installment, nil
+}
+
+func (s *PaymentService) getCallback(ctx context.Context, provider, externalId string) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	return s.PaymentCallbackDBClient.GetPaymentCallback(ctx, where.Eq(paymentCallbacks_DBModels.COLUMN_PROVIDER, provider).Eq(paymentCallbacks_DBModels.COLUMN_EXTERNAL_ID, externalId))
+}
+
+func (s *PaymentService) deactivate(ctx context.Context, vaUuid uuid.UUID) {
+	var patcher = make(map[string]interface{})
+	patcher[virtualAccounts_DBModels.COLUMN_IS_ACTIVE] = false
+	patcher[virtualAccounts_DBModels.COLUMN_UPDATED_AT] = s.Now()
+
+	if err := s.VirtualAccountDBClient.UpdateVirtualAccount(ctx, where.Eq(virtualAccounts_DBModels.COLUM_UUID, vaUuid), patcher); err != nil {
+		logger.Logger(ctx).Errorf("Error deactivating virtual account %s: %v", vaUuid, err)
+	}
+}
@@ -0,0 +1,612 @@
+package payment
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	paymentCallbacks_DBModels "customer/sigmatech/app/db/dto/payment_callbacks"
+	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
+	virtualAccounts_DBModels "customer/sigmatech/app/db/dto/virtual_accounts"
+	webhookDeliveries_DBModels "customer/sigmatech/app/db/dto/webhook_deliveries"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/dto/response"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/util"
+	"customer/sigmatech/app/service/webhook"
+	"customer/sigmatech/config"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	installmentAccount = "9881111111111111" // virtual account of the first installment
+	customerAccount    = "9882222222222222" // virtual account of the customer
+	installmentAmount  = 1000000
+)
+
+// database keeps the rows of every repository the payment service reads in memory, matching them on the
+// equality and null conditions of the filters. The callback is unique on its provider and external id and
+// is applied under a lock, as the database does.
+type database struct {
+	mu              sync.Mutex
+	customer        customers_DBModels.Customer
+	transaction     transactions_DBModels.Transaction
+	installments    []*transaction_installments_DBModels.TransactionInstallment
+	virtualAccounts []*virtualAccounts_DBModels.VirtualAccount
+	callbacks       []*paymentCallbacks_DBModels.PaymentCallback
+	failApply       error // returned once by the next ApplyPaymentCallback
+}
+
+// matches reports whether the row, keyed by column, meets the conditions of the filter. Null columns are
+// left out of the row.
+func matches(whr where.Filter, row map[string]interface{}) bool {
+	for _, condition := range whr.Conditions {
+		value, ok := row[condition.Column]
+		switch condition.Operator {
+		case where.EQ:
+			if !ok || fmt.Sprint(value) != fmt.Sprint(condition.Value) {
+				return false
+			}
+		case where.IS_NULL:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (d *database) CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error {
+	return nil
+}
+
+func (d *database) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
+	if matches(whr, map[string]interface{}{customers_DBModels.COLUM_UUID: d.customer.Uuid}) {
+		return d.customer, nil
+	}
+	return customers_DBModels.Customer{}, nil
+}
+
+func (d *database) GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (d *database) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (d *database) DeleteCustomer(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (d *database) CreateTransaction(ctx context.Context, transaction *transactions_DBModels.Transaction) error {
+	return nil
+}
+
+func (d *database) GetTransaction(ctx context.Context, whr where.Filter) (transactions_DBModels.Transaction, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if matches(whr, map[string]interface{}{
+		transactions_DBModels.COLUM_UUID:           d.transaction.Uuid,
+		transactions_DBModels.COLUMN_CUSTOMER_UUID: d.transaction.CustomerUuid,
+	}) {
+		return d.transaction, nil
+	}
+	return transactions_DBModels.Transaction{}, nil
+}
+
+func (d *database) GetTransactions(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transactions_DBModels.Transaction, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (d *database) UpdateTransaction(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if isDone, ok := patch[transactions_DBModels.COLUMN_IS_DONE].(bool); ok {
+		d.transaction.IsDone = &isDone
+	}
+	return nil
+}
+
+func (d *database) DeleteTransaction(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (d *database) GenerateContractNumber(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func (d *database) CreateTransactionInstallment(ctx context.Context, installment *transaction_installments_DBModels.TransactionInstallment) error {
+	return nil
+}
+
+func (d *database) GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if installment := d.findInstallment(whr); installment != nil {
+		return *installment, nil
+	}
+	return transaction_installments_DBModels.TransactionInstallment{}, nil
+}
+
+func (d *database) GetTransactionInstallments(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transaction_installments_DBModels.TransactionInstallment, response.Pagination, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var unpaid []*transaction_installments_DBModels.TransactionInstallment
+	for _, installment := range d.installments {
+		if installment.PaymentAt == nil && installment.TransactionUuid.String() == filter[transaction_installments_DBModels.COLUMN_TRANSACTION_UUID] {
+			stored := *installment
+			unpaid = append(unpaid, &stored)
+		}
+	}
+	return unpaid, response.Pagination{}, nil
+}
+
+func (d *database) UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (d *database) DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (d *database) GetNextUnpaidTransactionInstallment(ctx context.Context, customerUuid string) (transaction_installments_DBModels.TransactionInstallment, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if installment := d.findInstallment(where.IsNull(transaction_installments_DBModels.COLUMN_PAYMENT_AT)); installment != nil && d.transaction.CustomerUuid.String() == customerUuid {
+		return *installment, nil
+	}
+	return transaction_installments_DBModels.TransactionInstallment{}, nil
+}
+
+func (d *database) findInstallment(whr where.Filter) *transaction_installments_DBModels.TransactionInstallment {
+	for _, installment := range d.installments {
+		row := map[string]interface{}{transaction_installments_DBModels.COLUM_UUID: installment.Uuid}
+		if installment.PaymentAt != nil {
+			row[transaction_installments_DBModels.COLUMN_PAYMENT_AT] = *installment.PaymentAt
+		}
+		if matches(whr, row) {
+			return installment
+		}
+	}
+	return nil
+}
+
+func (d *database) CreateVirtualAccount(ctx context.Context, va *virtualAccounts_DBModels.VirtualAccount) error {
+	return nil
+}
+
+func (d *database) GetVirtualAccount(ctx context.Context, whr where.Filter) (virtualAccounts_DBModels.VirtualAccount, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if va := d.findVirtualAccount(whr); va != nil {
+		return *va, nil
+	}
+	return virtualAccounts_DBModels.VirtualAccount{}, nil
+}
+
+func (d *database) GetVirtualAccounts(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*virtualAccounts_DBModels.VirtualAccount, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (d *database) UpdateVirtualAccount(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if va := d.findVirtualAccount(whr); va != nil {
+		if isActive, ok := patch[virtualAccounts_DBModels.COLUMN_IS_ACTIVE].(bool); ok {
+			va.IsActive = &isActive
+		}
+	}
+	return nil
+}
+
+func (d *database) DeleteVirtualAccount(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (d *database) findVirtualAccount(whr where.Filter) *virtualAccounts_DBModels.VirtualAccount {
+	for _, va := range d.virtualAccounts {
+		if matches(whr, map[string]interface{}{
+			virtualAccounts_DBModels.COLUM_UUID:            va.Uuid,
+			virtualAccounts_DBModels.COLUMN_PROVIDER:       va.Provider,
+			virtualAccounts_DBModels.COLUMN_ACCOUNT_NUMBER: va.AccountNumber,
+		}) {
+			return va
+		}
+	}
+	return nil
+}
+
+func (d *database) CreatePaymentCallback(ctx context.Context, callback *paymentCallbacks_DBModels.PaymentCallback) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, existing := range d.callbacks {
+		if existing.Provider == callback.Provider && existing.ExternalId == callback.ExternalId {
+			return errors.New(`pq: duplicate key value violates unique constraint "uq_payment_callbacks_provider_external_id"`)
+		}
+	}
+	stored := *callback
+	d.callbacks = append(d.callbacks, &stored)
+	return nil
+}
+
+func (d *database) GetPaymentCallback(ctx context.Context, whr where.Filter) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if callback := d.findCallback(whr); callback != nil {
+		return *callback, nil
+	}
+	return paymentCallbacks_DBModels.PaymentCallback{}, nil
+}
+
+func (d *database) GetPaymentCallbacks(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*paymentCallbacks_DBModels.PaymentCallback, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (d *database) UpdatePaymentCallback(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if callback := d.findCallback(whr); callback != nil {
+		if status, ok := patch[paymentCallbacks_DBModels.COLUMN_STATUS].(string); ok {
+			callback.Status = status
+		}
+		if installmentUuid, ok := patch[paymentCallbacks_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID]; ok && installmentUuid == nil {
+			callback.TransactionInstallmentUuid = nil
+		}
+	}
+	return nil
+}
+
+func (d *database) ApplyPaymentCallback(ctx context.Context, callback paymentCallbacks_DBModels.PaymentCallback, installmentPatch map[string]interface{}, tolerance float64) (bool, float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.failApply; err != nil {
+		d.failApply = nil
+		return false, 0, err
+	}
+
+	if callback.TransactionInstallmentUuid == nil {
+		return false, 0, nil
+	}
+
+	stored := d.findCallback(where.Eq(paymentCallbacks_DBModels.COLUM_UUID, callback.Uuid).Eq(paymentCallbacks_DBModels.COLUMN_STATUS, CALLBACK_STATUS_PENDING))
+	installment := d.findInstallment(where.Eq(transaction_installments_DBModels.COLUM_UUID, *callback.TransactionInstallmentUuid).IsNull(transaction_installments_DBModels.COLUMN_PAYMENT_AT))
+	if stored == nil || installment == nil {
+		return false, 0, nil
+	}
+
+	amount, surplus := callback.Amount, 0.0
+	stored.Status = CALLBACK_STATUS_APPLIED
+	if owed := installment.Amount - installment.AmountPaid; amount-owed > tolerance {
+		amount, surplus = owed, amount-owed
+		stored.Status, stored.Surplus = CALLBACK_STATUS_OVERPAID, surplus
+	}
+
+	installment.AmountPaid += amount
+	if installment.AmountPaid >= installment.Amount-tolerance {
+		installment.PaymentAt = util.Time(callback.PaidAt)
+	}
+	if methodPayment, ok := installmentPatch[transaction_installments_DBModels.COLUMN_METHOD_PAYMENT].(string); ok {
+		installment.MethodPayment = &methodPayment
+	}
+	return true, surplus, nil
+}
+
+func (d *database) DeletePaymentCallback(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (d *database) findCallback(whr where.Filter) *paymentCallbacks_DBModels.PaymentCallback {
+	for _, callback := range d.callbacks {
+		if matches(whr, map[string]interface{}{
+			paymentCallbacks_DBModels.COLUM_UUID:         callback.Uuid,
+			paymentCallbacks_DBModels.COLUMN_PROVIDER:    callback.Provider,
+			paymentCallbacks_DBModels.COLUMN_EXTERNAL_ID: callback.ExternalId,
+			paymentCallbacks_DBModels.COLUMN_STATUS:      callback.Status,
+		}) {
+			return callback
+		}
+	}
+	return nil
+}
+
+// installment returns the stored state of the nth installment.
+func (d *database) installment(n int) transaction_installments_DBModels.TransactionInstallment {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return *d.installments[n]
+}
+
+// notifier stands in for the notification service, it keeps the events sent.
+type notifier struct {
+	mu     sync.Mutex
+	events []notification.Event
+}
+
+func (n *notifier) Notify(ctx context.Context, recipient notification.Recipient, event notification.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.events = append(n.events, event)
+	return nil
+}
+
+func (n *notifier) RegisterSender(channel notification.Channel, sender notification.ISender) {}
+
+// publisher stands in for the webhook service, it keeps the events published.
+type publisher struct {
+	mu     sync.Mutex
+	events []webhook.EventType
+}
+
+func (p *publisher) Publish(ctx context.Context, eventType webhook.EventType, referenceUuid *uuid.UUID, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, eventType)
+	return nil
+}
+
+func (p *publisher) Dispatch(ctx context.Context) error {
+	return nil
+}
+
+func (p *publisher) Replay(ctx context.Context, deliveryUuid uuid.UUID) (webhookDeliveries_DBModels.WebhookDelivery, error) {
+	return webhookDeliveries_DBModels.WebhookDelivery{}, nil
+}
+
+func (p *publisher) Run(ctx context.Context) {}
+
+// newTestService returns the service over a customer with a contract of two unpaid installments, the first
+// one with its own virtual account next to the virtual account of the customer.
+func newTestService(t *testing.T) (*PaymentService, *database, *SimulatorProvider, *notifier) {
+	constants.Config = &config.ServiceConfig{}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	customer := customers_DBModels.Customer{Uuid: uuid.New(), Name: "Budi", Email: "budi@sigmatech.id"}
+	transaction := transactions_DBModels.Transaction{Uuid: uuid.New(), CustomerUuid: customer.Uuid, ContractNumber: "KTR-0001", IsDone: util.Boolean(false)}
+
+	db := &database{customer: customer, transaction: transaction}
+	for term := 1; term <= 2; term++ {
+		db.installments = append(db.installments, &transaction_installments_DBModels.TransactionInstallment{
+			Uuid:            uuid.New(),
+			TransactionUuid: transaction.Uuid,
+			Term:            term,
+			DueDate:         util.Time(time.Now().AddDate(0, term, 0)),
+			Amount:          installmentAmount,
+		})
+	}
+	db.virtualAccounts = []*virtualAccounts_DBModels.VirtualAccount{
+		{Uuid: uuid.New(), Provider: PROVIDER_SIMULATOR, AccountNumber: installmentAccount, CustomerUuid: customer.Uuid, TransactionInstallmentUuid: &db.installments[0].Uuid, IsActive: util.Boolean(true)},
+		{Uuid: uuid.New(), Provider: PROVIDER_SIMULATOR, AccountNumber: customerAccount, CustomerUuid: customer.Uuid, IsActive: util.Boolean(true)},
+	}
+
+	provider := NewSimulatorProvider("s3cr3t")
+	notifications := &notifier{}
+	s := NewPaymentService(db, db, db, db, db, notifications, &publisher{}, provider)
+	return s, db, provider, notifications
+}
+
+func simulatePayment(t *testing.T, provider *SimulatorProvider, accountNumber string, amount float64) (http.Header, []byte) {
+	header, body, err := provider.SimulatePayment(accountNumber, amount)
+	if err != nil {
+		t.Fatalf("SimulatePayment() error = %v", err)
+	}
+	return header, body
+}
+
+func TestHandleCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		accountNumber  string
+		amount         float64
+		wantStatus     string
+		wantSurplus    float64
+		wantAmountPaid float64
+		wantPaid       bool
+	}{
+		{
+			name:           "Given the installment amount, When call HandleCallback, Then the installment is paid off",
+			accountNumber:  installmentAccount,
+			amount:         installmentAmount,
+			wantStatus:     CALLBACK_STATUS_APPLIED,
+			wantAmountPaid: installmentAmount,
+			wantPaid:       true,
+		},
+		{
+			name:           "Given less than the installment amount, When call HandleCallback, Then it is added and the installment stays unpaid",
+			accountNumber:  installmentAccount,
+			amount:         400000,
+			wantStatus:     CALLBACK_STATUS_APPLIED,
+			wantAmountPaid: 400000,
+		},
+		{
+			name:           "Given a rounding difference above the installment amount, When call HandleCallback, Then it is applied as a whole",
+			accountNumber:  installmentAccount,
+			amount:         installmentAmount + 0.005,
+			wantStatus:     CALLBACK_STATUS_APPLIED,
+			wantAmountPaid: installmentAmount + 0.005,
+			wantPaid:       true,
+		},
+		{
+			name:           "Given more than the installment amount, When call HandleCallback, Then the installment is paid off and the surplus kept on an overpaid callback",
+			accountNumber:  installmentAccount,
+			amount:         installmentAmount + 250000,
+			wantStatus:     CALLBACK_STATUS_OVERPAID,
+			wantSurplus:    250000,
+			wantAmountPaid: installmentAmount,
+			wantPaid:       true,
+		},
+		{
+			name:           "Given a payment into the customer account, When call HandleCallback, Then it goes to the installment due first",
+			accountNumber:  customerAccount,
+			amount:         installmentAmount,
+			wantStatus:     CALLBACK_STATUS_APPLIED,
+			wantAmountPaid: installmentAmount,
+			wantPaid:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db, provider, notifications := newTestService(t)
+
+			header, body := simulatePayment(t, provider, tt.accountNumber, tt.amount)
+			callback, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+			if err != nil {
+				t.Fatalf("HandleCallback() error = %v", err)
+			}
+			if callback.Status != tt.wantStatus || callback.Surplus != tt.wantSurplus {
+				t.Errorf("HandleCallback() status %q surplus %.2f, want %q surplus %.2f", callback.Status, callback.Surplus, tt.wantStatus, tt.wantSurplus)
+			}
+
+			stored, _ := db.GetPaymentCallback(context.Background(), where.Eq(paymentCallbacks_DBModels.COLUM_UUID, callback.Uuid))
+			if stored.Status != tt.wantStatus || stored.Surplus != tt.wantSurplus {
+				t.Errorf("stored callback status %q surplus %.2f, want %q surplus %.2f", stored.Status, stored.Surplus, tt.wantStatus, tt.wantSurplus)
+			}
+
+			installment := db.installment(0)
+			if installment.AmountPaid != tt.wantAmountPaid || (installment.PaymentAt != nil) != tt.wantPaid {
+				t.Errorf("installment amount paid %.3f paid %v, want %.3f paid %v", installment.AmountPaid, installment.PaymentAt != nil, tt.wantAmountPaid, tt.wantPaid)
+			}
+			if next := db.installment(1); next.AmountPaid != 0 {
+				t.Errorf("next installment amount paid %.2f, want nothing", next.AmountPaid)
+			}
+			if sent := len(notifications.events) > 0; sent != tt.wantPaid {
+				t.Errorf("customer notified %v, want %v", sent, tt.wantPaid)
+			}
+		})
+	}
+
+	t.Run("Given the customer has nothing left to pay, When call HandleCallback, Then the callback is unapplied", func(t *testing.T) {
+		s, db, provider, _ := newTestService(t)
+		for _, installment := range db.installments {
+			installment.AmountPaid, installment.PaymentAt = installment.Amount, util.Time(time.Now())
+		}
+
+		header, body := simulatePayment(t, provider, customerAccount, installmentAmount)
+		callback, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+		if err != nil {
+			t.Fatalf("HandleCallback() error = %v", err)
+		}
+		if callback.Status != CALLBACK_STATUS_UNAPPLIED || callback.TransactionInstallmentUuid != nil {
+			t.Errorf("HandleCallback() = %+v, want an unapplied callback", callback)
+		}
+	})
+
+	t.Run("Given an unknown account, When call HandleCallback, Then return ErrVirtualAccountNotFound", func(t *testing.T) {
+		s, _, provider, _ := newTestService(t)
+
+		header, body := simulatePayment(t, provider, "9889999999999999", installmentAmount)
+		if _, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body); !errors.Is(err, ErrVirtualAccountNotFound) {
+			t.Errorf("HandleCallback() error = %v, want %v", err, ErrVirtualAccountNotFound)
+		}
+	})
+}
+
+func TestHandleCallbackRetries(t *testing.T) {
+	t.Run("Given a callback already applied, When the provider sends it again, Then the payment is not added twice", func(t *testing.T) {
+		s, db, provider, _ := newTestService(t)
+
+		header, body := simulatePayment(t, provider, installmentAccount, 400000)
+		first, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+		if err != nil {
+			t.Fatalf("HandleCallback() error = %v", err)
+		}
+
+		again, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+		if err != nil {
+			t.Fatalf("HandleCallback() again error = %v", err)
+		}
+		if again.Uuid != first.Uuid || again.Status != CALLBACK_STATUS_APPLIED {
+			t.Errorf("HandleCallback() again = %s %q, want %s %q", again.Uuid, again.Status, first.Uuid, CALLBACK_STATUS_APPLIED)
+		}
+		if paid := db.installment(0).AmountPaid; paid != 400000 {
+			t.Errorf("installment amount paid %.2f, want 400000.00", paid)
+		}
+	})
+
+	t.Run("Given a callback left pending by a failed apply, When the provider retries it, Then the retry applies it once", func(t *testing.T) {
+		s, db, provider, _ := newTestService(t)
+		db.failApply = errors.New("connection reset")
+
+		header, body := simulatePayment(t, provider, installmentAccount, installmentAmount)
+		if _, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body); err == nil {
+			t.Fatalf("HandleCallback() error = nil, want the failed apply")
+		}
+		if len(db.callbacks) != 1 || db.callbacks[0].Status != CALLBACK_STATUS_PENDING {
+			t.Fatalf("stored callbacks = %+v, want one pending", db.callbacks)
+		}
+		if paid := db.installment(0).AmountPaid; paid != 0 {
+			t.Fatalf("installment amount paid %.2f before the retry, want nothing", paid)
+		}
+
+		for i := 0; i < 2; i++ {
+			callback, err := s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+			if err != nil {
+				t.Fatalf("HandleCallback() retry %d error = %v", i, err)
+			}
+			if callback.Uuid != db.callbacks[0].Uuid || callback.Status != CALLBACK_STATUS_APPLIED {
+				t.Errorf("HandleCallback() retry %d = %s %q, want %s %q", i, callback.Uuid, callback.Status, db.callbacks[0].Uuid, CALLBACK_STATUS_APPLIED)
+			}
+		}
+		if installment := db.installment(0); installment.AmountPaid != installmentAmount || installment.PaymentAt == nil {
+			t.Errorf("installment amount paid %.2f paid %v, want %d paid", installment.AmountPaid, installment.PaymentAt != nil, installmentAmount)
+		}
+	})
+
+	t.Run("Given the same callback sent concurrently, When call HandleCallback, Then one callback is stored and applied once", func(t *testing.T) {
+		s, db, provider, _ := newTestService(t)
+
+		header, body := simulatePayment(t, provider, installmentAccount, 400000)
+
+		const senders = 10
+		var wg sync.WaitGroup
+		callbacks := make([]paymentCallbacks_DBModels.PaymentCallback, senders)
+		errs := make([]error, senders)
+		for i := 0; i < senders; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				callbacks[i], errs[i] = s.HandleCallback(context.Background(), PROVIDER_SIMULATOR, header, body)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range callbacks {
+			if errs[i] != nil {
+				t.Fatalf("HandleCallback() %d error = %v", i, errs[i])
+			}
+			if callbacks[i].Uuid != db.callbacks[0].Uuid {
+				t.Errorf("HandleCallback() %d = %s, want the stored callback %s", i, callbacks[i].Uuid, db.callbacks[0].Uuid)
+			}
+		}
+		if len(db.callbacks) != 1 || db.callbacks[0].Status != CALLBACK_STATUS_APPLIED {
+			t.Errorf("stored callbacks = %+v, want one applied", db.callbacks)
+		}
+		if paid := db.installment(0).AmountPaid; paid != 400000 {
+			t.Errorf("installment amount paid %.2f, want 400000.00", paid)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IProvider is a payment gateway that issues virtual accounts and notifies us of payments into them.
+type IProvider interface {
+	// Name is stored with the virtual accounts and callbacks of the provider.
+	Name() string
+	// CreateVirtualAccount issues an account number, for the given amount when it is set.
+	CreateVirtualAccount(ctx context.Context, request VirtualAccountRequest) (IssuedVirtualAccount, error)
+	// ParseCallback verifies the signature of a callback and returns the payment it reports.
+	ParseCallback(header http.Header, body []byte) (Callback, error)
+}
+
+// VirtualAccountRequest describes the virtual account to issue.
+type VirtualAccountRequest struct {
+	CustomerUuid               uuid.UUID
+	CustomerName               string
+	TransactionInstallmentUuid *uuid.UUID
+	Amount                     *float64
+	ExpiresAt                  *time.Time
+}
+
+// IssuedVirtualAccount is the virtual account returned by the provider.
+type IssuedVirtualAccount struct {
+	AccountNumber string
+	ExpiresAt     *time.Time
+}
+
+// Callback is a payment reported by the provider.
+type Callback struct {
+	ExternalId    string    `json:"external_id"`
+	AccountNumber string    `json:"account_number"`
+	Amount        float64   `json:"amount"`
+	PaidAt        time.Time `json:"paid_at"`
+}
+
+func (c *Callback) Validate() error {
+	if c.ExternalId == "" || c.AccountNumber == "" || c.Amount <= 0 || c.PaidAt.IsZero() {
+		return ErrInvalidCallback
+	}
+	return nil
+}
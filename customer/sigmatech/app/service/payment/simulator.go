@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"customer/sigmatech/app/service/util"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SimulatorProvider is a local stand-in for a payment gateway. It issues random account numbers
+// and signs the callbacks it simulates with a shared secret, the way a real gateway would.
+type SimulatorProvider struct {
+	Secret string
+	Now    func() time.Time
+}
+
+// NewSimulatorProvider is a constructor function that creates a new SimulatorProvider.
+func NewSimulatorProvider(secret string) *SimulatorProvider {
+	return &SimulatorProvider{
+		Secret: secret,
+		Now:    time.Now,
+	}
+}
+
+func (p *SimulatorProvider) Name() string {
+	return PROVIDER_SIMULATOR
+}
+
+func (p *SimulatorProvider) CreateVirtualAccount(ctx context.Context, request VirtualAccountRequest) (IssuedVirtualAccount, error) {
+	digits, err := util.GenerateOTP(simulatorAccountDigits)
+	if err != nil {
+		return IssuedVirtualAccount{}, err
+	}
+
+	return IssuedVirtualAccount{
+		AccountNumber: simulatorAccountPrefix + digits,
+		ExpiresAt:     request.ExpiresAt,
+	}, nil
+}
+
+func (p *SimulatorProvider) ParseCallback(header http.Header, body []byte) (Callback, error) {
+	if !hmac.Equal([]byte(p.sign(body)), []byte(header.Get(HEADER_SIMULATOR_SIGNATURE))) {
+		return Callback{}, ErrInvalidCallbackSignature
+	}
+
+	var callback Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return Callback{}, ErrInvalidCallback
+	}
+
+	if err := callback.Validate(); err != nil {
+		return Callback{}, err
+	}
+
+	return callback, nil
+}
+
+// SimulatePayment builds the signed callback the simulator sends when the account is paid.
+func (p *SimulatorProvider) SimulatePayment(accountNumber string, amount float64) (http.Header, []byte, error) {
+	body, err := json.Marshal(Callback{
+		ExternalId:    uuid.New().String(),
+		AccountNumber: accountNumber,
+		Amount:        amount,
+		PaidAt:        p.Now().UTC(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(HEADER_SIMULATOR_SIGNATURE, p.sign(body))
+	return header, body, nil
+}
+
+// sign returns the hex HMAC-SHA256 of the callback body.
+func (p *SimulatorProvider) sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSimulatorProvider_ParseCallback(t *testing.T) {
+	provider := NewSimulatorProvider("s3cr3t")
+
+	header, body, err := provider.SimulatePayment("9881234567890123", 150000)
+	if err != nil {
+		t.Fatalf("SimulatePayment() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:    "Given a simulated payment, When call ParseCallback, Then return the callback",
+			header:  header,
+			body:    body,
+			wantErr: nil,
+		},
+		{
+			name:    "Given a tampered body, When call ParseCallback, Then return ErrInvalidCallbackSignature",
+			header:  header,
+			body:    append([]byte{' '}, body...),
+			wantErr: ErrInvalidCallbackSignature,
+		},
+		{
+			name:    "Given no signature, When call ParseCallback, Then return ErrInvalidCallbackSignature",
+			header:  http.Header{},
+			body:    body,
+			wantErr: ErrInvalidCallbackSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := provider.ParseCallback(tt.header, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (callback.AccountNumber != "9881234567890123" || callback.Amount != 150000) {
+				t.Errorf("ParseCallback() = %+v", callback)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package session
+
+import "errors"
+
+// ErrSessionNotFound is returned for a session that doesn't exist or isn't the subject's.
+var ErrSessionNotFound = errors.New("session not found")
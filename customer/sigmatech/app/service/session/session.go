@@ -0,0 +1,243 @@
+// Package session keeps the history of sign-ins, one session per refresh token family, with the device and
+// the location they were made from. Sessions are listed to their subject, who can revoke any of them.
+package session
+
+import (
+	"context"
+	sessions_DBModels "customer/sigmatech/app/db/dto/sessions"
+	sessionDB "customer/sigmatech/app/db/repository/session"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/correlation"
+	"customer/sigmatech/app/service/ipgeolocation"
+	"customer/sigmatech/app/service/logger"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ITokenFamilies tells whether the refresh tokens of a session are still accepted, and stops accepting them.
+type ITokenFamilies interface {
+	Active(ctx context.Context, familyUuid uuid.UUID) (bool, error)
+	Revoke(ctx context.Context, familyUuid uuid.UUID) error
+}
+
+// Login is a sign-in or a refresh of the session SessionUuid.
+type Login struct {
+	SessionUuid uuid.UUID
+	SubjectUuid uuid.UUID
+	Ip          string
+	UserAgent   string
+	ExpiresAt   time.Time
+}
+
+type ISessionService interface {
+	// Record stores the session of a sign-in, or where it was last seen from when it is refreshed. The
+	// location is resolved in the background.
+	Record(ctx context.Context, login Login) error
+	// GetSessions returns the active sessions of the subject, or every session it had when history is set.
+	GetSessions(ctx context.Context, subjectUuid uuid.UUID, history bool) ([]*sessions_DBModels.SessionDetail, error)
+	// Revoke ends a session of the subject, returning ErrSessionNotFound when it has no such session.
+	Revoke(ctx context.Context, subjectUuid, sessionUuid uuid.UUID) error
+}
+
+// SessionService is a struct that implements the ISessionService interface.
+type SessionService struct {
+	SessionDBClient sessionDB.ISessionRepository
+	Tokens          ITokenFamilies
+	Geolocation     ipgeolocation.IClient
+	SubjectType     string
+}
+
+// NewSessionService is a constructor function that creates a new SessionService for the subjects of
+// SubjectType. Locations aren't resolved when Geolocation is nil.
+func NewSessionService(
+	SessionDBClient sessionDB.ISessionRepository,
+	Tokens ITokenFamilies,
+	Geolocation ipgeolocation.IClient,
+	SubjectType string,
+) *SessionService {
+	return &SessionService{
+		SessionDBClient: SessionDBClient,
+		Tokens:          Tokens,
+		Geolocation:     Geolocation,
+		SubjectType:     SubjectType,
+	}
+}
+
+func (s *SessionService) Record(ctx context.Context, login Login) error {
+	existing, err := s.SessionDBClient.GetSession(ctx, s.filter(login.SubjectUuid, login.SessionUuid))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session := sessions_DBModels.Session{
+		Uuid:        login.SessionUuid,
+		SubjectUuid: login.SubjectUuid,
+		SubjectType: s.SubjectType,
+		Ip:          login.Ip,
+		UserAgent:   login.UserAgent,
+		Device:      deviceOf(login.UserAgent),
+		ExpiresAt:   login.ExpiresAt,
+		LastSeenAt:  now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	// The location is resolved after the request is answered, with a context of its own
+	locateCtx := correlation.ContextFromCorrelation(correlation.ContextCorrelationId(ctx))
+
+	// Sessions started before they were recorded are recorded on their first refresh
+	if existing.Uuid == uuid.Nil {
+		if err := s.SessionDBClient.CreateSession(ctx, &session); err != nil {
+			return err
+		}
+		go s.locate(locateCtx, session)
+		return nil
+	}
+
+	err = s.SessionDBClient.UpdateSession(ctx, s.filter(login.SubjectUuid, login.SessionUuid), map[string]interface{}{
+		sessions_DBModels.COLUMN_IP:           session.Ip,
+		sessions_DBModels.COLUMN_USER_AGENT:   session.UserAgent,
+		sessions_DBModels.COLUMN_DEVICE:       session.Device,
+		sessions_DBModels.COLUMN_EXPIRES_AT:   session.ExpiresAt,
+		sessions_DBModels.COLUMN_LAST_SEEN_AT: now,
+		sessions_DBModels.COLUMN_UPDATED_AT:   now,
+	})
+	if err != nil {
+		return err
+	}
+	if existing.Ip != session.Ip {
+		go s.locate(locateCtx, session)
+	}
+
+	return nil
+}
+
+// locate resolves where the session was seen from, flagging it when its subject never signed in from
+// that country before. Subjects without a located session yet aren't flagged.
+func (s *SessionService) locate(ctx context.Context, session sessions_DBModels.Session) {
+	log := logger.Logger(ctx)
+
+	if s.Geolocation == nil || !routable(session.Ip) {
+		return
+	}
+
+	location, err := s.Geolocation.GetLocationInfo(session.Ip)
+	if err != nil {
+		log.Warnf("unable to locate session %s: %v", session.Uuid, err)
+		return
+	}
+	if location.CountryCode2 == "" {
+		return
+	}
+
+	others := where.Eq(sessions_DBModels.COLUMN_SUBJECT_TYPE, s.SubjectType).
+		Eq(sessions_DBModels.COLUMN_SUBJECT_UUID, session.SubjectUuid).
+		NotEq(sessions_DBModels.COLUM_UUID, session.Uuid)
+
+	located, err := s.SessionDBClient.CountSessions(ctx, others.IsNotNull(sessions_DBModels.COLUMN_COUNTRY_CODE))
+	if err != nil {
+		log.Errorf("unable to count the located sessions of %s: %v", session.SubjectUuid, err)
+		return
+	}
+	fromCountry, err := s.SessionDBClient.CountSessions(ctx, others.Eq(sessions_DBModels.COLUMN_COUNTRY_CODE, location.CountryCode2))
+	if err != nil {
+		log.Errorf("unable to count the sessions of %s from %s: %v", session.SubjectUuid, location.CountryCode2, err)
+		return
+	}
+
+	newCountry := located > 0 && fromCountry == 0
+	if newCountry {
+		log.Warnf("%s %s signed in from a new country %s, session %s", s.SubjectType, session.SubjectUuid, location.CountryCode2, session.Uuid)
+	}
+
+	err = s.SessionDBClient.UpdateSession(ctx, s.filter(session.SubjectUuid, session.Uuid), map[string]interface{}{
+		sessions_DBModels.COLUMN_COUNTRY_CODE: location.CountryCode2,
+		sessions_DBModels.COLUMN_COUNTRY_NAME: location.CountryName,
+		sessions_DBModels.COLUMN_CITY:         location.City,
+		sessions_DBModels.COLUMN_NEW_COUNTRY:  newCountry,
+		sessions_DBModels.COLUMN_UPDATED_AT:   time.Now(),
+	})
+	if err != nil {
+		log.Errorf("unable to store the location of session %s: %v", session.Uuid, err)
+	}
+}
+
+func (s *SessionService) GetSessions(ctx context.Context, subjectUuid uuid.UUID, history bool) ([]*sessions_DBModels.SessionDetail, error) {
+	whr := where.Eq(sessions_DBModels.COLUMN_SUBJECT_TYPE, s.SubjectType).Eq(sessions_DBModels.COLUMN_SUBJECT_UUID, subjectUuid)
+	if !history {
+		whr = whr.Gt(sessions_DBModels.COLUMN_EXPIRES_AT, time.Now())
+	}
+
+	sessions, err := s.SessionDBClient.GetSessions(ctx, whr)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*sessions_DBModels.SessionDetail, 0, len(sessions))
+	for _, session := range sessions {
+		// Signing out and reused refresh tokens revoke the family without going through the session
+		active := time.Now().Before(session.ExpiresAt)
+		if active {
+			if active, err = s.Tokens.Active(ctx, session.Uuid); err != nil {
+				return nil, err
+			}
+		}
+		if !active && !history {
+			continue
+		}
+
+		details = append(details, &sessions_DBModels.SessionDetail{Session: *session, Active: active})
+	}
+
+	return details, nil
+}
+
+func (s *SessionService) Revoke(ctx context.Context, subjectUuid, sessionUuid uuid.UUID) error {
+	session, err := s.SessionDBClient.GetSession(ctx, s.filter(subjectUuid, sessionUuid))
+	if err != nil {
+		return err
+	}
+	if session.Uuid == uuid.Nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.Tokens.Revoke(ctx, session.Uuid); err != nil {
+		return err
+	}
+
+	return s.SessionDBClient.UpdateSession(ctx, s.filter(subjectUuid, sessionUuid), map[string]interface{}{
+		sessions_DBModels.COLUMN_EXPIRES_AT: time.Now(),
+		sessions_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	})
+}
+
+func (s *SessionService) filter(subjectUuid, sessionUuid uuid.UUID) where.Filter {
+	return where.Eq(sessions_DBModels.COLUM_UUID, sessionUuid).
+		Eq(sessions_DBModels.COLUMN_SUBJECT_TYPE, s.SubjectType).
+		Eq(sessions_DBModels.COLUMN_SUBJECT_UUID, subjectUuid)
+}
+
+// deviceOf tells the kind of device from its user agent, tablets are checked first as their user agents
+// often claim to be mobile too.
+func deviceOf(userAgent string) string {
+	switch ua := strings.ToLower(userAgent); {
+	case ua == "":
+		return sessions_DBModels.DEVICE_UNKNOWN
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet"):
+		return sessions_DBModels.DEVICE_TABLET
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "android"):
+		return sessions_DBModels.DEVICE_MOBILE
+	default:
+		return sessions_DBModels.DEVICE_DESKTOP
+	}
+}
+
+// routable reports whether the IP can be located, private and loopback addresses can't.
+func routable(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && !parsed.IsLoopback() && !parsed.IsPrivate() && !parsed.IsUnspecified() && !parsed.IsLinkLocalUnicast()
+}
@@ -0,0 +1,241 @@
+// Package transaction books financing contracts against a customer limit, shared by the
+// customer app and the partner API.
+package transaction
+
+import (
+	"context"
+	"customer/sigmatech/app/constants"
+	customerLimits_DBModels "customer/sigmatech/app/db/dto/customer_limits"
+	customers_DBModels "customer/sigmatech/app/db/dto/customers"
+	transaction_installments_DBModels "customer/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "customer/sigmatech/app/db/dto/transactions"
+	variableGlobals_DBModels "customer/sigmatech/app/db/dto/variable_globals"
+	customerLimitDB "customer/sigmatech/app/db/repository/customer_limit"
+	transactionDB "customer/sigmatech/app/db/repository/transaction"
+	transactionInstallmentDB "customer/sigmatech/app/db/repository/transaction_installment"
+	variableGlobalDB "customer/sigmatech/app/db/repository/variable_global"
+	"customer/sigmatech/app/db/where"
+	"customer/sigmatech/app/service/dto/request"
+	"customer/sigmatech/app/service/logger"
+	"customer/sigmatech/app/service/notification"
+	"customer/sigmatech/app/service/util"
+	"customer/sigmatech/app/service/webhook"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCustomerLimitNotFound = errors.New("customer limit not found")
+	ErrVariableNotFound      = errors.New("fee variable not found")
+	ErrInsufficientLimit     = errors.New("limit tidak mencukupi")
+)
+
+type ITransactionService interface {
+	Book(ctx context.Context, customer *customers_DBModels.Customer, booking transactions_DBModels.Transaction) (transactions_DBModels.Transaction, error)
+}
+
+// TransactionService is a struct that implements the ITransactionService interface.
+type TransactionService struct {
+	CustomerLimitDBClient          customerLimitDB.ICustomerLimitRepository
+	TransactionDBClient            transactionDB.ITransactionRepository
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
+	VariableGlobalDBClient         variableGlobalDB.IVariableGlobalRepository
+	Notification                   notification.INotificationService
+	Webhook                        webhook.IWebhookService
+}
+
+// NewTransactionService is a constructor function that creates a new TransactionService.
+func NewTransactionService(
+	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
+	VariableGlobalDBClient variableGlobalDB.IVariableGlobalRepository,
+	Notification notification.INotificationService,
+	Webhook webhook.IWebhookService,
+) *TransactionService {
+	return &TransactionService{
+		CustomerLimitDBClient:          CustomerLimitDBClient,
+		TransactionDBClient:            TransactionDBClient,
+		TransactionInstallmentDBClient: TransactionInstallmentDBClient,
+		VariableGlobalDBClient:         VariableGlobalDBClient,
+		Notification:                   Notification,
+		Webhook:                        Webhook,
+	}
+}
+
+// Book creates the contract and its installment schedule for the customer limit chosen in booking,
+// lowers the remaining limits of the customer and lets the customer and subscribed partners know.
+// booking carries the customer limit, asset name, OTR and optionally the originating merchant.
+func (u *TransactionService) Book(ctx context.Context, usr *customers_DBModels.Customer, booking transactions_DBModels.Transaction) (transactions_DBModels.Transaction, error) {
+	log := logger.Logger(ctx)
+
+	fCustLimit := where.Eq(customerLimits_DBModels.COLUM_UUID, booking.CustomerLimitUuid).Eq(customerLimits_DBModels.COLUMN_CUSTOMER_UUID, usr.Uuid)
+
+	customerLimit, err := u.CustomerLimitDBClient.GetCustomerLimit(ctx, fCustLimit)
+	if err != nil {
+		return transactions_DBModels.Transaction{}, err
+	}
+
+	if customerLimit.Uuid == uuid.Nil {
+		return transactions_DBModels.Transaction{}, ErrCustomerLimitNotFound
+	}
+
+	admin, err := u.getVariable(ctx, constants.VARIABLE_ADMIN_FEE)
+	if err != nil {
+		return transactions_DBModels.Transaction{}, err
+	}
+
+	interest, err := u.getVariable(ctx, constants.VARIABLE_INTEREST_FEE)
+	if err != nil {
+		return transactions_DBModels.Transaction{}, err
+	}
+
+	// Calculate total interest
+	totalInterest := booking.Otr * interest / 100
+
+	// Calculate total repayment (Loan amount + Interest + Admin fee)
+	totalRepayment := booking.Otr + totalInterest + admin
+
+	if customerLimit.RemainingLimit < totalRepayment {
+		return transactions_DBModels.Transaction{}, ErrInsufficientLimit
+	}
+
+	// Calculate monthly installment
+	monthlyInstallment := totalRepayment / float64(customerLimit.Term)
+
+	contractNumber, err := u.TransactionDBClient.GenerateContractNumber(ctx)
+	if err != nil {
+		contractNumber = fmt.Sprintf("TX_%06d_%v", 1, time.Now().Unix())
+	}
+
+	data := transactions_DBModels.Transaction{
+		Uuid:              uuid.New(),
+		CustomerUuid:      usr.Uuid,
+		CustomerLimitUuid: customerLimit.Uuid,
+		MerchantUuid:      booking.MerchantUuid,
+		AssetName:         booking.AssetName,
+		ContractNumber:    contractNumber,
+		IsDone:            util.Boolean(false),
+		Otr:               booking.Otr,
+		AdminFee:          admin,
+		Total:             totalRepayment,
+		InstallmentAmount: monthlyInstallment,
+		InstallmentCount:  customerLimit.Term,
+		TotalInterest:     totalInterest,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err = u.TransactionDBClient.CreateTransaction(ctx, &data); err != nil {
+		return transactions_DBModels.Transaction{}, err
+	}
+
+	// Set the initial date
+	currentDate := time.Now()
+
+	for i := 1; i <= customerLimit.Term; i++ {
+		nextMonth := currentDate.AddDate(0, i, 0)
+		dataInstallment := transaction_installments_DBModels.TransactionInstallment{
+			Uuid:            uuid.New(),
+			TransactionUuid: data.Uuid,
+			MethodPayment:   nil,
+			Term:            i,
+			DueDate:         &nextMonth,
+			PaymentAt:       nil,
+			Amount:          monthlyInstallment,
+			AmountPaid:      0,
+			CreatedAt:       currentDate,
+			UpdatedAt:       currentDate,
+		}
+
+		if err = u.TransactionInstallmentDBClient.CreateTransactionInstallment(ctx, &dataInstallment); err != nil {
+			return transactions_DBModels.Transaction{}, err
+		}
+	}
+
+	p := request.Pagination{
+		GetAllData: true,
+		Order:      customerLimits_DBModels.COLUMN_TERM,
+		Sort:       "ASC",
+	}
+	p.Validate()
+
+	f := map[string]interface{}{
+		customerLimits_DBModels.COLUMN_CUSTOMER_UUID: usr.Uuid.String(),
+	}
+
+	customerLimits, _, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, p, f)
+	if err != nil {
+		return transactions_DBModels.Transaction{}, err
+	}
+
+	remainingLimit := 0.0
+
+	for _, v := range customerLimits {
+		scalingFactor := v.RemainingLimit / customerLimit.RemainingLimit
+
+		remainingLimit = v.RemainingLimit - (totalRepayment * scalingFactor)
+
+		if remainingLimit < 0 {
+			remainingLimit = 0
+		}
+
+		var patcher = make(map[string]interface{}) // Create a patcher map to hold the fields to be updated
+
+		patcher[customerLimits_DBModels.COLUMN_REMAINING_LIMIT] = remainingLimit
+
+		fUpdLimit := where.Eq(customerLimits_DBModels.COLUM_UUID, v.Uuid) // Create a filter to match the customer ID
+
+		if err := u.CustomerLimitDBClient.UpdateCustomerLimit(ctx, fUpdLimit, patcher); err != nil {
+			return transactions_DBModels.Transaction{}, err
+		}
+	}
+
+	// Let the customer know the contract was booked, a failed notification must not fail the transaction
+	if err := u.Notification.Notify(ctx, notification.Recipient{
+		CustomerUuid: usr.Uuid,
+		Name:         usr.Name,
+		Email:        usr.Email,
+	}, notification.Event{
+		Type:          notification.EventTransactionCreated,
+		ReferenceUuid: &data.Uuid,
+		Data: map[string]interface{}{
+			"contract_number":    data.ContractNumber,
+			"asset_name":         data.AssetName,
+			"otr":                data.Otr,
+			"total":              data.Total,
+			"installment_amount": data.InstallmentAmount,
+			"installment_count":  data.InstallmentCount,
+		},
+	}); err != nil {
+		log.Errorf("Error sending transaction notification to customer %s: %v", usr.Uuid, err)
+	}
+
+	// Queue the event for subscribed partners, delivery happens in the user service worker
+	if err := u.Webhook.Publish(ctx, webhook.EventTransactionCreated, &data.Uuid, data); err != nil {
+		log.Errorf("Error publishing transaction webhook for contract %s: %v", data.ContractNumber, err)
+	}
+
+	return data, nil
+}
+
+// getVariable returns the numeric value of a global variable such as the admin or interest fee.
+func (u *TransactionService) getVariable(ctx context.Context, code string) (float64, error) {
+	filter := where.Eq(variableGlobals_DBModels.COLUMN_CODE, code)
+
+	variable, err := u.VariableGlobalDBClient.GetVariableGlobal(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	if variable.Uuid == uuid.Nil {
+		return 0, ErrVariableNotFound
+	}
+
+	value, _ := strconv.ParseFloat(variable.Value, 64)
+	return value, nil
+}
@@ -11,6 +11,15 @@ import (
 
 func ApplyFilterCondition(query *gorm.DB, filter map[string]interface{}) (*gorm.DB, error) {
 	for condition, value := range filter {
+		if column, operator, ok := ParseFilterKey(condition); ok {
+			whr, err := FilterOf(column, operator, value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Scopes(whr.Scope)
+			continue
+		}
+
 		if value == nil {
 			query = query.Where(fmt.Sprintf("%s IS NULL", condition))
 			continue
@@ -28,7 +37,7 @@ func ApplyFilterCondition(query *gorm.DB, filter map[string]interface{}) (*gorm.
 			if dateRange, err := parseDateRange(v); err == nil {
 				query = query.Where(fmt.Sprintf("%s >= ? AND %s <= ?", condition, condition), dateRange[0], dateRange[1])
 			} else if isValidOperatorCondition(condition) {
-				query = query.Where(fmt.Sprintf("%s %s ?", condition, v))
+				query = query.Where(fmt.Sprintf("%s ?", condition), v)
 			} else {
 				query = applyStringFilterCondition(query, condition, v)
 			}
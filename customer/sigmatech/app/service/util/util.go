@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
@@ -37,6 +38,10 @@ func Int(v int) *int { return &v }
 
 func Boolean(v bool) *bool { return &v }
 
+func String(v string) *string { return &v }
+
+func Time(v time.Time) *time.Time { return &v }
+
 func UnwrapInt(v *int) int {
 	if v == nil {
 		return 0
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IClient posts a signed payload to a subscriber endpoint.
+type IClient interface {
+	Post(ctx context.Context, url string, secret string, deliveryId string, eventType string, body []byte) (Response, error)
+}
+
+// Client is the HTTP implementation of IClient.
+type Client struct {
+	HTTPClient *http.Client
+	Now        func() time.Time
+}
+
+// NewClient creates a Client whose requests give up after timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: timeout},
+		Now:        time.Now,
+	}
+}
+
+// Post signs and sends the body. Any answer other than a 2xx status is returned as an error
+// together with the response, so the caller can log the status code of failed attempts too.
+func (c *Client) Post(ctx context.Context, url string, secret string, deliveryId string, eventType string, body []byte) (Response, error) {
+	timestamp := c.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sigmatech-webhook/1.0")
+	req.Header.Set(HEADER_EVENT, eventType)
+	req.Header.Set(HEADER_DELIVERY_ID, deliveryId)
+	req.Header.Set(HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HEADER_SIGNATURE, Sign(secret, timestamp, body))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	response := Response{
+		StatusCode: resp.StatusCode,
+		Body:       string(respBody),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return response, fmt.Errorf("receiver answered with status %d", resp.StatusCode)
+	}
+
+	return response, nil
+}
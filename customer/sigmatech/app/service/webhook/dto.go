@@ -0,0 +1,21 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payload is the JSON body posted to the subscribers of an event.
+type Payload struct {
+	Id        uuid.UUID   `json:"id"`
+	Type      EventType   `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Response is what the receiver answered to a delivery attempt.
+type Response struct {
+	StatusCode int
+	Body       string
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Sign returns the signature header value of a delivery body.
+// The signed message is the unix timestamp and the raw body joined by a dot,
+// so a receiver can reject replays by checking the timestamp header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SIGNATURE_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches the body and timestamp, using a constant time comparison.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// GenerateSecret returns a random hex encoded signing secret for a new subscription.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -9,11 +9,16 @@ import (
 )
 
 type JwtConfig struct {
-	JWT_MAGIC_SECRET   string `env:"JWT_MAGIC_SECRET"`
-	JWT_ACCESS_SECRET  string `env:"JWT_ACCESS_SECRET"`
-	JWT_REFRESH_SECRET string `env:"JWT_REFRESH_SECRET"`
-	JWT_ACCESS_EXP     int    `env:"JWT_ACCESS_EXP"`
-	JWT_REFRESH_EXP    int    `env:"JWT_REFRESH_EXP"`
+	JWT_MAGIC_SECRET    string `env:"JWT_MAGIC_SECRET"`
+	JWT_ACCESS_SECRET   string `env:"JWT_ACCESS_SECRET"`
+	JWT_REFRESH_SECRET  string `env:"JWT_REFRESH_SECRET"`
+	JWT_ACCESS_EXP      int    `env:"JWT_ACCESS_EXP"`
+	JWT_REFRESH_EXP     int    `env:"JWT_REFRESH_EXP"`
+	JWT_SIGNING_ALG     string `env:"JWT_SIGNING_ALG" envDefault:"HS256"`      // HS256, RS256 or EdDSA for access tokens
+	JWT_KEYS_DIR        string `env:"JWT_KEYS_DIR"`                            // directory of <kid>.pem keys for RS256 and EdDSA
+	JWT_KEY_ID          string `env:"JWT_KEY_ID"`                              // kid of the key signing new tokens
+	JWT_PRINCIPAL_CACHE string `env:"JWT_PRINCIPAL_CACHE" envDefault:"memory"` // memory or redis
+	JWT_PRINCIPAL_TTL   int    `env:"JWT_PRINCIPAL_TTL" envDefault:"30"`       // seconds a customer and its active sessions are cached for
 }
 
 type DatabaseConfig struct {
@@ -43,14 +48,15 @@ type RedisConfig struct {
 }
 
 type HTTPServerConfig struct {
-	HTTPSERVER_URL                         string `env:"HTTPSERVER_URL"`
-	HTTPSERVER_LISTEN                      string `env:"HTTPSERVER_LISTEN"`
-	HTTPSERVER_PORT                        string `env:"HTTPSERVER_PORT"`
-	HTTPSERVER_READ_TIMEOUT                int    `env:"HTTPSERVER_READ_TIMEOUT"`
-	HTTPSERVER_WRITE_TIMEOUT               int    `env:"HTTPSERVER_WRITE_TIMEOUT"`
-	HTTPSERVER_MAX_CONNECTIONS_PER_IP      int    `env:"HTTPSERVER_MAX_CONNECTIONS_PER_IP"`
-	HTTPSERVER_MAX_REQUESTS_PER_CONNECTION int    `env:"HTTPSERVER_MAX_REQUESTS_PER_CONNECTION"`
-	HTTPSERVER_MAX_KEEP_ALIVE_DURATION     int    `env:"HTTPSERVER_MAX_KEEP_ALIVE_DURATION"`
+	HTTPSERVER_URL                         string   `env:"HTTPSERVER_URL"`
+	HTTPSERVER_LISTEN                      string   `env:"HTTPSERVER_LISTEN"`
+	HTTPSERVER_PORT                        string   `env:"HTTPSERVER_PORT"`
+	HTTPSERVER_READ_TIMEOUT                int      `env:"HTTPSERVER_READ_TIMEOUT"`
+	HTTPSERVER_WRITE_TIMEOUT               int      `env:"HTTPSERVER_WRITE_TIMEOUT"`
+	HTTPSERVER_MAX_CONNECTIONS_PER_IP      int      `env:"HTTPSERVER_MAX_CONNECTIONS_PER_IP"`
+	HTTPSERVER_MAX_REQUESTS_PER_CONNECTION int      `env:"HTTPSERVER_MAX_REQUESTS_PER_CONNECTION"`
+	HTTPSERVER_MAX_KEEP_ALIVE_DURATION     int      `env:"HTTPSERVER_MAX_KEEP_ALIVE_DURATION"`
+	HTTPSERVER_TRUSTED_PROXIES             []string `env:"HTTPSERVER_TRUSTED_PROXIES"` // IPs or CIDRs of the proxies whose X-Forwarded-For is trusted, none when empty
 }
 
 type LogConfig struct {
@@ -68,17 +74,27 @@ type LogConfig struct {
 }
 
 type ServiceConfig struct {
-	ProjectVersion      string `env:"VERSION"`
-	JwtConfig           JwtConfig
-	DatabaseConfig      DatabaseConfig
-	RedisConfig         RedisConfig
-	HTTPServerConfig    HTTPServerConfig
-	IntegrationConfig   IntegrationConfig
-	LogConfig           LogConfig
-	EncryptionConfig    EncryptionConfig
-	AWSConfig           AWSConfig
-	Environment         string `env:"ENVIRONMENT"`
-	IPGeoLocationConfig IPGeoLocationConfig
+	ProjectVersion          string `env:"VERSION"`
+	JwtConfig               JwtConfig
+	DatabaseConfig          DatabaseConfig
+	RedisConfig             RedisConfig
+	HTTPServerConfig        HTTPServerConfig
+	IntegrationConfig       IntegrationConfig
+	LogConfig               LogConfig
+	EncryptionConfig        EncryptionConfig
+	AWSConfig               AWSConfig
+	Environment             string `env:"ENVIRONMENT"`
+	IPGeoLocationConfig     IPGeoLocationConfig
+	NotificationConfig      NotificationConfig
+	WebhookConfig           WebhookConfig
+	PartnerConfig           PartnerConfig
+	SignatureConfig         SignatureConfig
+	PaymentConfig           PaymentConfig
+	MailConfig              MailConfig
+	PasswordResetConfig     PasswordResetConfig
+	EmailVerificationConfig EmailVerificationConfig
+	LoginLockoutConfig      LoginLockoutConfig
+	RateLimitConfig         RateLimitConfig
 }
 
 type IntegrationConfig struct {
@@ -87,7 +103,86 @@ type IntegrationConfig struct {
 }
 
 type IPGeoLocationConfig struct {
-	IPGEOLOCATION_API_KEY string `env:"IPGEOLOCATION_API_KEY"`
+	IPGEOLOCATION_API_KEY    string `env:"IPGEOLOCATION_API_KEY"`
+	IPGEOLOCATION_CACHE_TTL  int    `env:"IPGEOLOCATION_CACHE_TTL" envDefault:"86400"`  // seconds a resolved location is reused
+	IPGEOLOCATION_CACHE_SIZE int    `env:"IPGEOLOCATION_CACHE_SIZE" envDefault:"10000"` // ips cached per instance
+}
+
+type NotificationConfig struct {
+	NOTIFICATION_DEFAULT_LOCALE string `env:"NOTIFICATION_DEFAULT_LOCALE" envDefault:"id"`
+}
+
+type WebhookConfig struct {
+	WEBHOOK_TIMEOUT       int `env:"WEBHOOK_TIMEOUT" envDefault:"10"`        // seconds
+	WEBHOOK_MAX_ATTEMPTS  int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`    // dead letter after this many attempts
+	WEBHOOK_BACKOFF_BASE  int `env:"WEBHOOK_BACKOFF_BASE" envDefault:"30"`   // seconds before the first retry
+	WEBHOOK_BACKOFF_MAX   int `env:"WEBHOOK_BACKOFF_MAX" envDefault:"21600"` // seconds
+	WEBHOOK_POLL_INTERVAL int `env:"WEBHOOK_POLL_INTERVAL" envDefault:"15"`  // seconds
+	WEBHOOK_BATCH_SIZE    int `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+}
+
+type SignatureConfig struct {
+	SIGNATURE_ENABLED     bool   `env:"SIGNATURE_ENABLED" envDefault:"false"`
+	SIGNATURE_KEYS        string `env:"SIGNATURE_KEYS"`                           // comma separated key_id:secret pairs
+	SIGNATURE_TOLERANCE   int    `env:"SIGNATURE_TOLERANCE" envDefault:"300"`     // seconds of allowed clock skew
+	SIGNATURE_NONCE_STORE string `env:"SIGNATURE_NONCE_STORE" envDefault:"redis"` // memory or redis
+}
+
+type PaymentConfig struct {
+	PAYMENT_SIMULATOR_ENABLED   bool   `env:"PAYMENT_SIMULATOR_ENABLED" envDefault:"false"` // refused in production
+	PAYMENT_SIMULATOR_SECRET    string `env:"PAYMENT_SIMULATOR_SECRET"`                     // required by the simulator
+	PAYMENT_VIRTUAL_ACCOUNT_TTL int    `env:"PAYMENT_VIRTUAL_ACCOUNT_TTL" envDefault:"0"`   // seconds, 0 never expires
+}
+
+type PartnerConfig struct {
+	PARTNER_CONSENT_TTL          int `env:"PARTNER_CONSENT_TTL" envDefault:"300"`        // seconds the consent otp stays valid
+	PARTNER_CONSENT_MAX_ATTEMPTS int `env:"PARTNER_CONSENT_MAX_ATTEMPTS" envDefault:"5"` // otps tried before the consent is void
+}
+
+type MailConfig struct {
+	MAIL_DRIVER        string `env:"MAIL_DRIVER" envDefault:"log"` // smtp or log
+	MAIL_FROM          string `env:"MAIL_FROM"`
+	MAIL_SMTP_HOST     string `env:"MAIL_SMTP_HOST"`
+	MAIL_SMTP_PORT     int    `env:"MAIL_SMTP_PORT" envDefault:"587"`
+	MAIL_SMTP_USERNAME string `env:"MAIL_SMTP_USERNAME"`
+	MAIL_SMTP_PASSWORD string `env:"MAIL_SMTP_PASSWORD"`
+}
+
+type PasswordResetConfig struct {
+	PASSWORD_RESET_TTL          int `env:"PASSWORD_RESET_TTL" envDefault:"900"`        // seconds the reset otp stays valid
+	PASSWORD_RESET_MAX_ATTEMPTS int `env:"PASSWORD_RESET_MAX_ATTEMPTS" envDefault:"5"` // wrong otps before the reset is void
+	PASSWORD_RESET_LIMIT        int `env:"PASSWORD_RESET_LIMIT" envDefault:"3"`        // otps sent to an email within the window
+	PASSWORD_RESET_WINDOW       int `env:"PASSWORD_RESET_WINDOW" envDefault:"3600"`    // seconds
+}
+
+type EmailVerificationConfig struct {
+	EMAIL_VERIFICATION_TTL    int `env:"EMAIL_VERIFICATION_TTL" envDefault:"86400"`   // seconds the verification token stays valid
+	EMAIL_VERIFICATION_LIMIT  int `env:"EMAIL_VERIFICATION_LIMIT" envDefault:"3"`     // tokens sent to an email within the window
+	EMAIL_VERIFICATION_WINDOW int `env:"EMAIL_VERIFICATION_WINDOW" envDefault:"3600"` // seconds
+}
+
+// LoginLockoutConfig locks accounts and IPs out of signing in after too many failures, a lockout lasts
+// twice as long as the one before it
+type LoginLockoutConfig struct {
+	LOGIN_LOCKOUT_ACCOUNT_THRESHOLD int `env:"LOGIN_LOCKOUT_ACCOUNT_THRESHOLD" envDefault:"5"` // failed sign-ins of an account before it is locked out
+	LOGIN_LOCKOUT_IP_THRESHOLD      int `env:"LOGIN_LOCKOUT_IP_THRESHOLD" envDefault:"20"`     // failed sign-ins from an IP before it is locked out
+	LOGIN_LOCKOUT_WINDOW            int `env:"LOGIN_LOCKOUT_WINDOW" envDefault:"900"`          // seconds failures are counted over
+	LOGIN_LOCKOUT_DURATION          int `env:"LOGIN_LOCKOUT_DURATION" envDefault:"300"`        // seconds of the first lockout
+	LOGIN_LOCKOUT_MAX_DURATION      int `env:"LOGIN_LOCKOUT_MAX_DURATION" envDefault:"86400"`  // seconds
+	LOGIN_LOCKOUT_RESET             int `env:"LOGIN_LOCKOUT_RESET" envDefault:"86400"`         // seconds without failures before lockouts start over
+}
+
+// RateLimitConfig policies are "<limit>/<window>[,<key>[,<algorithm>]]", key is ip, principal or api_key and
+// algorithm token_bucket or sliding_window. An empty policy leaves the route unlimited.
+type RateLimitConfig struct {
+	RATE_LIMIT_ENABLED             bool   `env:"RATE_LIMIT_ENABLED" envDefault:"false"`
+	RATE_LIMIT_STORE               string `env:"RATE_LIMIT_STORE" envDefault:"memory"` // memory or redis
+	RATE_LIMIT_SIGN_IN             string `env:"RATE_LIMIT_SIGN_IN" envDefault:"10/1m,ip,sliding_window"`
+	RATE_LIMIT_SIGN_UP             string `env:"RATE_LIMIT_SIGN_UP" envDefault:"5/1h,ip,sliding_window"`
+	RATE_LIMIT_REFRESH_TOKEN       string `env:"RATE_LIMIT_REFRESH_TOKEN" envDefault:"30/1m,ip,token_bucket"`
+	RATE_LIMIT_PASSWORD_RESET      string `env:"RATE_LIMIT_PASSWORD_RESET" envDefault:"10/1h,ip,sliding_window"` // shared by forgot and reset password
+	RATE_LIMIT_TRANSACTION         string `env:"RATE_LIMIT_TRANSACTION" envDefault:"10/1m,principal,token_bucket"`
+	RATE_LIMIT_PARTNER_TRANSACTION string `env:"RATE_LIMIT_PARTNER_TRANSACTION" envDefault:"120/1m,api_key,token_bucket"`
 }
 
 type ShopeeConfig struct {
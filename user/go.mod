@@ -1,6 +1,6 @@
 module user
 
-go 1.19
+go 1.23.0
 
 require (
 	github.com/danielkov/gin-helmet v0.0.0-20171108135313-1387e224435e
@@ -9,13 +9,13 @@ require (
 	github.com/google/uuid v1.3.1
 	github.com/lib/pq v1.10.5
 	go.uber.org/zap v1.25.0
-	golang.org/x/crypto v0.10.0
+	golang.org/x/crypto v0.38.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 )
 
 require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
-	golang.org/x/net v0.11.0 // indirect
+	golang.org/x/net v0.40.0 // indirect
 )
 
 require (
@@ -29,7 +29,9 @@ require (
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/jinzhu/gorm v1.9.16
 	github.com/joho/godotenv v1.5.1
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/redis/go-redis/v9 v9.2.1
+	github.com/xuri/excelize/v2 v2.9.1
 )
 
 require (
@@ -64,15 +66,19 @@ require (
 	github.com/kylelemons/go-gypsy v1.0.0 // indirect
 	github.com/leodido/go-urn v1.2.4 // indirect
 	github.com/mattn/go-isatty v0.0.16 // indirect
-	github.com/mattn/go-sqlite3 v1.14.0 // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.0.1 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
 	github.com/rogpeppe/go-internal v1.8.1 // indirect
+	github.com/tiendc/go-deepcopy v1.6.0 // indirect
 	github.com/ugorji/go/codec v1.2.7 // indirect
+	github.com/xuri/efp v0.0.1 // indirect
+	github.com/xuri/nfp v0.0.1 // indirect
 	github.com/ziutek/mymysql v1.5.4 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/sys v0.9.0 // indirect
-	golang.org/x/text v0.10.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
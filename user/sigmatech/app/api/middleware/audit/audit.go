@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+	"user/sigmatech/app/constants"
+	auditLogs_DBModels "user/sigmatech/app/db/dto/audit_logs"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	auditService "user/sigmatech/app/service/audit"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditMiddleware records the successful mutations of authenticated users. The action and entity
+// default to the handler and controller names and the entity id to the id path parameter, handlers
+// refine them and add the before and after state through the audit service helpers.
+func AuditMiddleware(service auditService.IAuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutation(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		writer := &statusWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		// Claims are only set once the handler chain went through authentication
+		claims, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+		if !exist {
+			return
+		}
+		usr, ok := claims.(*users_DBModels.User)
+		if !ok || usr == nil {
+			return
+		}
+
+		ctx := correlation.ContextFromCorrelation(c.GetHeader(constants.CORRELATION_KEY_ID.String()))
+
+		entry := auditService.GetEntry(c)
+		action, entity := ParseHandlerName(c.HandlerName())
+		if entry.Action != "" {
+			action = entry.Action
+		}
+		if entry.Entity != "" {
+			entity = entry.Entity
+		}
+
+		entityId := entry.EntityId
+		if entityId == "" {
+			entityId = c.Param("id")
+		}
+
+		auditLog := auditLogs_DBModels.AuditLog{
+			ActorUuid:     &usr.Uuid,
+			ActorEmail:    util.String(usr.Email),
+			Action:        action,
+			Entity:        entity,
+			EntityId:      optional(entityId),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			StatusCode:    writer.Status(),
+			CorrelationId: optional(correlation.ContextCorrelationId(ctx)),
+			ClientIp:      optional(c.ClientIP()),
+			UserAgent:     optional(c.Request.UserAgent()),
+		}
+
+		if entry.Before != nil || entry.After != nil {
+			changes, err := json.Marshal(auditService.Diff(entry.Before, entry.After))
+			if err != nil {
+				logger.Logger(ctx).Errorf("Error encoding audit changes: %v", err)
+			} else {
+				auditLog.Changes = util.String(string(changes))
+			}
+		}
+
+		if err := service.Record(ctx, &auditLog); err != nil {
+			logger.Logger(ctx).Errorf("Error recording audit log of %s %s: %v", auditLog.Method, auditLog.Path, err)
+		}
+	}
+}
+
+// ParseHandlerName derives the action and entity from a handler name such as
+// "user/sigmatech/app/controller/customer.CustomerController.UpdateCustomer-fm",
+// which gives the update_customer action on the customer entity.
+func ParseHandlerName(name string) (string, string) {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	parts := strings.Split(name, ".")
+	method := parts[len(parts)-1]
+
+	entity := ""
+	if len(parts) >= 3 {
+		entity = strings.Trim(parts[len(parts)-2], "(*)")
+		entity = strings.TrimSuffix(entity, "Controller")
+	}
+	if entity == "" {
+		entity = parts[0]
+	}
+
+	return toSnakeCase(method), toSnakeCase(entity)
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word unless the previous letter was part of the same acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// statusWriter remembers the status code written by the handler. The timeout middleware buffers the
+// response, so the status of the underlying writer is only set once the whole chain has returned.
+type statusWriter struct {
+	gin.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user/sigmatech/app/constants"
+	auditLogs_DBModels "user/sigmatech/app/db/dto/audit_logs"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	auditService "user/sigmatech/app/service/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type recorder struct {
+	logs []*auditLogs_DBModels.AuditLog
+}
+
+func (r *recorder) Record(ctx context.Context, log *auditLogs_DBModels.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+type CustomerController struct{}
+
+func (u CustomerController) UpdateCustomer(c *gin.Context) {
+	auditService.SetBefore(c, map[string]interface{}{"name": "Budi", "password": "old"})
+	auditService.SetAfter(c, map[string]interface{}{"name": "Budi Santoso", "password": "new"})
+	c.JSON(http.StatusAccepted, nil)
+}
+
+func (u CustomerController) DeleteCustomer(c *gin.Context) {
+	c.JSON(http.StatusNotFound, nil)
+}
+
+func TestParseHandlerName(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    string
+		wantAction string
+		wantEntity string
+	}{
+		{
+			name:       "Given a controller method When parsing Then the action and entity are snake cased",
+			handler:    "user/sigmatech/app/controller/customer.CustomerController.UpdateCustomerPassword-fm",
+			wantAction: "update_customer_password",
+			wantEntity: "customer",
+		},
+		{
+			name:       "Given a pointer receiver and an acronym When parsing Then they are handled",
+			handler:    "user/sigmatech/app/controller/merchant.(*MerchantController).CreateApiKey-fm",
+			wantAction: "create_api_key",
+			wantEntity: "merchant",
+		},
+		{
+			name:       "Given a function When parsing Then the package is the entity",
+			handler:    "user/sigmatech/app/controller/healthcheck.HealthCheck",
+			wantAction: "health_check",
+			wantEntity: "healthcheck",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, entity := ParseHandlerName(tt.handler)
+			if action != tt.wantAction || entity != tt.wantEntity {
+				t.Errorf("ParseHandlerName() = %s, %s, want %s, %s", action, entity, tt.wantAction, tt.wantEntity)
+			}
+		})
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	actor := &users_DBModels.User{Uuid: uuid.New(), Email: "admin@example.com"}
+	authenticate := func(c *gin.Context) {
+		if c.GetHeader(constants.AUTHORIZATION) != "" {
+			c.Set(constants.CTK_CLAIM_KEY.String(), actor)
+		}
+		c.Next()
+	}
+
+	rec := &recorder{}
+	router := gin.New()
+	router.Use(AuditMiddleware(rec), authenticate)
+	router.PATCH("/v1/customer/:id/", CustomerController{}.UpdateCustomer)
+	router.DELETE("/v1/customer/:id/", CustomerController{}.DeleteCustomer)
+	router.GET("/v1/customer/:id/", CustomerController{}.UpdateCustomer)
+
+	tests := []struct {
+		name          string
+		method        string
+		authenticated bool
+		wantRecorded  bool
+	}{
+		{name: "Given an authenticated update When it succeeds Then it is recorded", method: http.MethodPatch, authenticated: true, wantRecorded: true},
+		{name: "Given an unauthenticated update When it succeeds Then it is not recorded", method: http.MethodPatch},
+		{name: "Given a delete When it fails Then it is not recorded", method: http.MethodDelete, authenticated: true},
+		{name: "Given a read When it succeeds Then it is not recorded", method: http.MethodGet, authenticated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec.logs = nil
+
+			req := httptest.NewRequest(tt.method, "/v1/customer/42/", nil)
+			if tt.authenticated {
+				req.Header.Set(constants.AUTHORIZATION, "Bearer token")
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if recorded := len(rec.logs) == 1; recorded != tt.wantRecorded {
+				t.Fatalf("recorded = %v, want %v", recorded, tt.wantRecorded)
+			}
+			if !tt.wantRecorded {
+				return
+			}
+
+			log := rec.logs[0]
+			if log.Action != "update_customer" || log.Entity != "customer" || log.EntityId == nil || *log.EntityId != "42" {
+				t.Errorf("recorded %s on %s %v, want update_customer on customer 42", log.Action, log.Entity, log.EntityId)
+			}
+			if log.ActorUuid == nil || *log.ActorUuid != actor.Uuid || log.StatusCode != http.StatusAccepted {
+				t.Errorf("recorded actor %v and status %d", log.ActorUuid, log.StatusCode)
+			}
+
+			var changes map[string]auditService.Change
+			if log.Changes == nil || json.Unmarshal([]byte(*log.Changes), &changes) != nil {
+				t.Fatalf("changes = %v, want JSON", log.Changes)
+			}
+			if changes["name"].After != "Budi Santoso" || changes["password"].After != auditService.REDACTED {
+				t.Errorf("changes = %v", changes)
+			}
+		})
+	}
+}
@@ -1,12 +1,21 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"user/sigmatech/app/api/middleware/jwt"
+	"user/sigmatech/app/api/middleware/ratelimit"
+	"user/sigmatech/app/api/middleware/signature"
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/rbac"
 
 	"github.com/gin-gonic/gin"
 )
@@ -47,18 +56,103 @@ func Authentication(jwt jwt.IJwtService) gin.HandlerFunc {
 
 		token = t
 
-		claims, valid := jwt.VerifyToken(ctx, token)
+		claims, access, valid := jwt.VerifyToken(ctx, token)
 		if !valid {
 			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, errors.New("invalid token"))
 			return
 		}
 
 		ctx.Set(constants.CTK_CLAIM_KEY.String(), claims)
+		ctx.Set(constants.CTK_ACCESS_KEY.String(), access)
 
 		ctx.Next()
 	}
 }
 
+// Authorize is a middleware that lets through the users whose token grants every one of the permissions.
+// It is used after Authentication. Users whose roles require a second factor get none of their
+// permissions until they sign in with one.
+func Authorize(permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		access := rbac.GetAccess(ctx)
+		if access.MfaPending() {
+			controller.RespondWithError(ctx, http.StatusForbidden, constants.MFA_REQUIRED,
+				errors.New("a role of the user requires a second factor"))
+			return
+		}
+		if !access.CanAll(permissions...) {
+			controller.RespondWithError(ctx, http.StatusForbidden, constants.PERMISSION_DENIED,
+				fmt.Errorf("missing permission %s", strings.Join(permissions, ", ")))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Signature is an optional middleware that verifies the HMAC signature, timestamp and nonce headers
+// of server-to-server requests. It is used next to Authentication, not instead of it.
+func Signature(verifier signature.IVerifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keyId, err := verifier.Verify(ctx, ctx.Request)
+		if err != nil {
+			if !signature.IsVerificationError(err) {
+				controller.RespondWithError(ctx, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+				return
+			}
+			controller.RespondWithError(ctx, http.StatusUnauthorized, constants.UNAUTHORIZED_ACCESS, err)
+			return
+		}
+
+		ctx.Set(constants.CTK_SIGNATURE_KEY.String(), keyId)
+
+		ctx.Next()
+	}
+}
+
+// RateLimit is a middleware that refuses the requests over the limit of the policy with a 429, it does
+// nothing for a disabled policy. Policies keyed by the principal are used after Authentication. When the
+// limiter fails the request is let through, an outage of Redis mustn't take the sign-in down with it.
+func RateLimit(limiter ratelimit.ILimiter, policy ratelimit.Policy) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !policy.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		result, err := limiter.Allow(ctx, policy.Name+":"+rateLimitKey(ctx, policy.Key), policy)
+		if err != nil {
+			logger.Logger(correlation.WithReqContext(ctx)).Errorf("Error counting %s rate limit: %v", policy.Name, err)
+			ctx.Next()
+			return
+		}
+
+		ratelimit.SetHeaders(ctx.Writer.Header(), policy, result)
+		if !result.Allowed {
+			controller.RespondWithError(ctx, http.StatusTooManyRequests, constants.TOO_MANY_REQUESTS, ratelimit.ErrRateLimited)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitKey identifies who the request is counted for. API keys are hashed so they aren't kept as is.
+func rateLimitKey(ctx *gin.Context, key string) string {
+	switch key {
+	case ratelimit.KEY_PRINCIPAL:
+		if context, exist := ctx.Get(constants.CTK_CLAIM_KEY.String()); exist {
+			return key + ":" + context.(*users_DBModels.User).Uuid.String()
+		}
+	case ratelimit.KEY_API_KEY:
+		if apiKey := ctx.GetHeader(constants.API_KEY); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return key + ":" + hex.EncodeToString(sum[:])
+		}
+	}
+	return ratelimit.KEY_IP + ":" + ctx.ClientIP()
+}
+
 func getHeaderToken(ctx *gin.Context) (string, error) {
 	header := string(ctx.GetHeader(constants.AUTHORIZATION))
 	return extractToken(header)
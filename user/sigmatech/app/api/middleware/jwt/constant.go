@@ -0,0 +1,33 @@
+package jwt
+
+import "errors"
+
+const (
+	// Access token signing algorithms
+	SIGNING_ALG_HS256 = "HS256"
+	SIGNING_ALG_RS256 = "RS256"
+	SIGNING_ALG_EDDSA = "EdDSA"
+
+	// Refresh token store backends
+	REFRESH_STORE_POSTGRES = "postgres"
+	REFRESH_STORE_REDIS    = "redis"
+
+	refreshTokenKeyPrefix   = "refresh:token:"
+	refreshRotatedKeyPrefix = "refresh:rotated:"
+	refreshFamilyKeyPrefix  = "refresh:family:"
+	refreshSubjectKeyPrefix = "refresh:subject:"
+
+	// Backends of the customer service's principal cache, customerPrincipalKeyPrefix matches its keys
+	PRINCIPAL_CACHE_MEMORY = "memory"
+	PRINCIPAL_CACHE_REDIS  = "redis"
+
+	customerPrincipalKeyPrefix = "principal:customer:"
+
+	// mfaChallengePurpose marks the tokens issued between the password and the second factor
+	mfaChallengePurpose = "mfa_challenge"
+)
+
+var (
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrRefreshTokenRevoked = errors.New("refresh token is revoked or unknown")
+)
@@ -5,12 +5,13 @@ import (
 	"user/sigmatech/app/constants"
 	users_DBModels "user/sigmatech/app/db/dto/users"
 	userDB "user/sigmatech/app/db/repository/user"
+	"user/sigmatech/app/db/where"
 
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/rbac"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -18,18 +19,38 @@ import (
 
 type IJwtService interface {
 	GenerateUserTokens(ctx context.Context, user users_DBModels.User) (*TokenDetails, error)
+	// GenerateMfaUserTokens starts a session signed in with a second factor.
+	GenerateMfaUserTokens(ctx context.Context, user users_DBModels.User) (*TokenDetails, error)
+	// GenerateMfaChallenge returns the short lived token the second factor is verified with after the
+	// password, and when it expires.
+	GenerateMfaChallenge(ctx context.Context, user users_DBModels.User) (string, int64, error)
+	// VerifyMfaChallenge returns the user of a challenge token.
+	VerifyMfaChallenge(ctx context.Context, tokenString string) (*users_DBModels.User, error)
 	VerifyUserToken(ctx context.Context, tokenString string) (*users_DBModels.User, bool)
 	RefreshUserToken(ctx context.Context, tokenString string) (*TokenDetails, error)
-	VerifyToken(ctx context.Context, tokenString string) (*users_DBModels.User, bool)
+	// Logout revokes the session of an access token, its refresh tokens are no longer accepted.
+	Logout(ctx context.Context, tokenString string) error
+	// RevokeSessions ends every session of the user, on every device.
+	RevokeSessions(ctx context.Context, userUuid uuid.UUID) error
+	// VerifyToken returns the user of an access token and the roles and permissions it carries.
+	VerifyToken(ctx context.Context, tokenString string) (*users_DBModels.User, *rbac.Access, bool)
 }
 
+// JwtService signs access tokens with Keys. Refresh tokens are only ever read back by this service, they
+// stay signed with JWT_REFRESH_SECRET.
 type JwtService struct {
-	UserDBClient userDB.IUserRepository
+	UserDBClient  userDB.IUserRepository
+	Rbac          rbac.IRbacService
+	RefreshTokens IRefreshTokenStore
+	Keys          *KeySet
 }
 
-func NewJwtService(UserDBClient userDB.IUserRepository) *JwtService {
+func NewJwtService(UserDBClient userDB.IUserRepository, Rbac rbac.IRbacService, RefreshTokens IRefreshTokenStore, Keys *KeySet) *JwtService {
 	return &JwtService{
-		UserDBClient: UserDBClient,
+		UserDBClient:  UserDBClient,
+		Rbac:          Rbac,
+		RefreshTokens: RefreshTokens,
+		Keys:          Keys,
 	}
 }
 
@@ -40,37 +61,62 @@ type TokenDetails struct {
 	RefreshUuid  string `json:"refresh_uuid"`
 	AtExpires    int64  `json:"at_expires"`
 	RtExpires    int64  `json:"rt_expires"`
+
+	// FamilyUuid identifies the session the tokens were issued for, SubjectUuid who they were issued to
+	FamilyUuid  uuid.UUID `json:"family_uuid"`
+	SubjectUuid uuid.UUID `json:"-"`
 }
 
+// GenerateUserTokens starts a new session, its refresh token is the first of a new family.
 func (j *JwtService) GenerateUserTokens(ctx context.Context, user users_DBModels.User) (*TokenDetails, error) {
+	return j.generateUserTokens(ctx, user, uuid.New(), false)
+}
+
+func (j *JwtService) GenerateMfaUserTokens(ctx context.Context, user users_DBModels.User) (*TokenDetails, error) {
+	return j.generateUserTokens(ctx, user, uuid.New(), true)
+}
+
+// generateUserTokens issues tokens in the family, mfa tells whether the session was signed in with a
+// second factor. Refreshed tokens keep it.
+func (j *JwtService) generateUserTokens(ctx context.Context, user users_DBModels.User, familyUuid uuid.UUID, mfa bool) (*TokenDetails, error) {
 	log := logger.Logger(ctx)
 	log.Infof("Creating token for ", user)
 
 	user.Password = ""
-	var err error
 
-	td := &TokenDetails{}
+	access, err := j.Rbac.GetAccess(ctx, user.Uuid)
+	if err != nil {
+		log.Errorf("error while loading the roles of %s: %v", user.Uuid, err)
+		return nil, err
+	}
+
+	td := &TokenDetails{FamilyUuid: familyUuid, SubjectUuid: user.Uuid}
 	td.AtExpires = time.Now().Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_ACCESS_EXP)).Unix()
 	td.AccessUuid = uuid.NewString()
 
 	atClaims := jwt.MapClaims{}
 	atClaims["authorized"] = true
 	atClaims["access_uuid"] = td.AccessUuid
+	atClaims["family_uuid"] = familyUuid
 	atClaims["user"] = user
+	atClaims["roles"] = access.Roles
+	atClaims["permissions"] = access.Permissions
+	atClaims["mfa_required"] = access.MfaRequired
+	atClaims["mfa_verified"] = mfa
 	atClaims["exp"] = td.AtExpires
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-
-	td.AccessToken, err = at.SignedString([]byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET))
+	td.AccessToken, err = j.Keys.Sign(atClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	//Creating Refresh Token
-	td.RefreshUuid = td.AccessUuid + "++" + user.Email
+	td.RefreshUuid = uuid.NewString()
 	td.RtExpires = time.Now().Add(time.Minute * time.Duration(constants.Config.JwtConfig.JWT_REFRESH_EXP)).Unix()
 
 	rtClaims := jwt.MapClaims{}
 	rtClaims["refresh_uuid"] = td.RefreshUuid
+	rtClaims["family_uuid"] = familyUuid
+	rtClaims["mfa_verified"] = mfa
 	rtClaims["user"] = user
 	rtClaims["exp"] = td.RtExpires
 
@@ -80,24 +126,34 @@ func (j *JwtService) GenerateUserTokens(ctx context.Context, user users_DBModels
 		log.Errorf("error while generating access id ", err)
 		return nil, err
 	}
+
+	err = j.RefreshTokens.Issue(ctx, RefreshToken{
+		Uuid:        uuid.MustParse(td.RefreshUuid),
+		FamilyUuid:  familyUuid,
+		SubjectUuid: user.Uuid,
+		ExpiresAt:   time.Unix(td.RtExpires, 0),
+	})
+	if err != nil {
+		log.Errorf("error while storing the refresh token: %v", err)
+		return nil, err
+	}
 	return td, nil
 }
 
 func (j *JwtService) VerifyUserToken(ctx context.Context, tokenString string) (*users_DBModels.User, bool) {
 	log := logger.Logger(ctx)
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET), nil
-	})
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
 	if err != nil {
 		return nil, false
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if ok && token.Valid {
+		if !j.sessionActive(ctx, claims) {
+			return nil, false
+		}
+
 		user := users_DBModels.User{}
 		jsonString, err := json.Marshal(claims["user"])
 		if err != nil {
@@ -110,9 +166,8 @@ func (j *JwtService) VerifyUserToken(ctx context.Context, tokenString string) (*
 			return nil, false
 		}
 
-		u, err := j.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-			users_DBModels.COLUM_UUID, user.Uuid))
-		if err != nil {
+		u, err := j.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, user.Uuid))
+		if err != nil || u.Uuid == uuid.Nil { // deleted users lose access at once
 			return nil, false
 		}
 
@@ -137,8 +192,31 @@ func (j *JwtService) RefreshUserToken(ctx context.Context, tokenString string) (
 		return nil, err
 	}
 
-	_, ok = claims["refresh_uuid"].(string)
-	if !ok {
+	// Tokens issued before rotation carry no family, their users have to sign in again
+	refreshUuid, err := uuid.Parse(stringFromClaim(claims["refresh_uuid"]))
+	if err != nil {
+		return nil, ErrRefreshTokenRevoked
+	}
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
+	if err != nil {
+		return nil, ErrRefreshTokenRevoked
+	}
+
+	exp, _ := claims["exp"].(float64)
+	err = j.RefreshTokens.Rotate(ctx, RefreshToken{
+		Uuid:       refreshUuid,
+		FamilyUuid: familyUuid,
+		ExpiresAt:  time.Unix(int64(exp), 0),
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// The token leaked or the client misbehaved, either way the session can't be trusted anymore
+		log.Warnf("refresh token %s was reused, revoking family %s", refreshUuid, familyUuid)
+		if err := j.RefreshTokens.Revoke(ctx, familyUuid); err != nil {
+			log.Errorf("unable to revoke family %s: %v", familyUuid, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -161,35 +239,77 @@ func (j *JwtService) RefreshUserToken(ctx context.Context, tokenString string) (
 	}
 
 	// Check if the user exists in the database
-	u, err := j.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'", users_DBModels.COLUMN_EMAIL, user.Email))
+	u, err := j.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUMN_EMAIL, user.Email))
 	if err != nil {
 		return nil, err
 	}
+	if u.Uuid == uuid.Nil {
+		return nil, errors.New("user not found")
+	}
 
-	// Create new pairs of refresh and access tokens
-	td, err := j.GenerateUserTokens(ctx, u)
+	// Create new pairs of refresh and access tokens, in the same family
+	mfa, _ := claims["mfa_verified"].(bool)
+	td, err := j.generateUserTokens(ctx, u, familyUuid, mfa)
 	if err != nil {
 		return nil, err
 	}
 	return td, nil
 }
 
-func (j *JwtService) VerifyToken(ctx context.Context, tokenString string) (*users_DBModels.User, bool) {
+func (j *JwtService) GenerateMfaChallenge(ctx context.Context, user users_DBModels.User) (string, int64, error) {
+	expires := time.Now().Add(time.Duration(constants.Config.MfaConfig.MFA_CHALLENGE_TTL) * time.Second).Unix()
+
+	claims := jwt.MapClaims{}
+	claims["purpose"] = mfaChallengePurpose
+	claims["user_uuid"] = user.Uuid
+	claims["exp"] = expires
+
+	token, err := j.Keys.Sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, expires, nil
+}
+
+func (j *JwtService) VerifyMfaChallenge(ctx context.Context, tokenString string) (*users_DBModels.User, error) {
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Access tokens are signed with the same keys, the purpose tells them apart
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || stringFromClaim(claims["purpose"]) != mfaChallengePurpose {
+		return nil, errors.New(constants.INVALID_TOKEN)
+	}
+
+	userUuid, err := uuid.Parse(stringFromClaim(claims["user_uuid"]))
+	if err != nil {
+		return nil, errors.New(constants.INVALID_TOKEN)
+	}
+
+	user, err := j.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, userUuid))
+	if err != nil {
+		return nil, err
+	}
+	if user.Uuid == uuid.Nil {
+		return nil, errors.New("user not found")
+	}
+
+	return &user, nil
+}
+
+func (j *JwtService) VerifyToken(ctx context.Context, tokenString string) (*users_DBModels.User, *rbac.Access, bool) {
 	log := logger.Logger(ctx)
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(constants.Config.JwtConfig.JWT_ACCESS_SECRET), nil
-	})
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
 	if err != nil {
-		return nil, false
+		return nil, nil, false
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return nil, false
+	if !ok || !token.Valid || !j.sessionActive(ctx, claims) {
+		return nil, nil, false
 	}
 
 	var user users_DBModels.User
@@ -198,25 +318,94 @@ func (j *JwtService) VerifyToken(ctx context.Context, tokenString string) (*user
 		jsonString, err := json.Marshal(userClaims)
 		if err != nil {
 			log.Errorf("unable to marshal user claims: %v", err)
-			return nil, false
+			return nil, nil, false
 		}
 
 		err = json.Unmarshal(jsonString, &user)
 		if err != nil {
 			log.Errorf("unable to unmarshal user claims: %v", err)
-			return nil, false
+			return nil, nil, false
 		}
 	}
 
 	if user.Uuid != uuid.Nil {
-		u, err := j.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-			users_DBModels.COLUM_UUID, user.Uuid))
-		if err != nil {
-			return nil, false
+		u, err := j.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, user.Uuid))
+		if err != nil || u.Uuid == uuid.Nil { // deleted users lose access at once
+			return nil, nil, false
 		}
 
-		return &u, true
+		return &u, accessFromClaims(claims), true
 	}
 
-	return nil, false
+	return nil, nil, false
+}
+
+func (j *JwtService) Logout(ctx context.Context, tokenString string) error {
+	token, err := jwt.Parse(tokenString, j.Keys.Keyfunc)
+	if err != nil {
+		return err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return errors.New(constants.INVALID_TOKEN)
+	}
+
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
+	if err != nil {
+		return ErrRefreshTokenRevoked
+	}
+
+	return j.RefreshTokens.Revoke(ctx, familyUuid)
+}
+
+func (j *JwtService) RevokeSessions(ctx context.Context, userUuid uuid.UUID) error {
+	return j.RefreshTokens.RevokeAll(ctx, userUuid)
+}
+
+// sessionActive reports whether the family of an access token wasn't revoked, so logging out or
+// reusing a refresh token also ends the access tokens of the session.
+func (j *JwtService) sessionActive(ctx context.Context, claims jwt.MapClaims) bool {
+	familyUuid, err := uuid.Parse(stringFromClaim(claims["family_uuid"]))
+	if err != nil {
+		return false
+	}
+
+	active, err := j.RefreshTokens.Active(ctx, familyUuid)
+	if err != nil {
+		logger.Logger(ctx).Errorf("unable to check family %s: %v", familyUuid, err)
+		return false
+	}
+	return active
+}
+
+// accessFromClaims reads the roles and permissions of an access token. Tokens issued before roles
+// existed carry none, their users have to sign in again.
+func accessFromClaims(claims jwt.MapClaims) *rbac.Access {
+	mfaRequired, _ := claims["mfa_required"].(bool)
+	mfaVerified, _ := claims["mfa_verified"].(bool)
+
+	return &rbac.Access{
+		Roles:       stringsFromClaim(claims["roles"]),
+		Permissions: stringsFromClaim(claims["permissions"]),
+		MfaRequired: mfaRequired,
+		MfaVerified: mfaVerified,
+	}
+}
+
+func stringsFromClaim(claim interface{}) []string {
+	values, _ := claim.([]interface{})
+
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+func stringFromClaim(claim interface{}) string {
+	str, _ := claim.(string)
+	return str
 }
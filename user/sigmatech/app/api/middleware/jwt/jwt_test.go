@@ -0,0 +1,217 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user/sigmatech/app/constants"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/rbac"
+	"user/sigmatech/config"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// refreshTokenStore is an in memory refresh token store.
+type refreshTokenStore struct {
+	mu       sync.Mutex
+	families map[uuid.UUID]bool
+	tokens   map[uuid.UUID]bool // whether the token was rotated
+}
+
+func (s *refreshTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.families[token.FamilyUuid]; !ok {
+		s.families[token.FamilyUuid] = true
+	}
+	s.tokens[token.Uuid] = false
+	return nil
+}
+
+func (s *refreshTokenStore) Rotate(ctx context.Context, token RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rotated, issued := s.tokens[token.Uuid]
+	if !issued || !s.families[token.FamilyUuid] {
+		return ErrRefreshTokenRevoked
+	}
+	if rotated {
+		return ErrRefreshTokenReused
+	}
+	s.tokens[token.Uuid] = true
+	return nil
+}
+
+func (s *refreshTokenStore) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.families[familyUuid] = false
+	return nil
+}
+
+// RevokeAll revokes every family, the tests have a single user.
+func (s *refreshTokenStore) RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for family := range s.families {
+		s.families[family] = false
+	}
+	return nil
+}
+
+func (s *refreshTokenStore) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.families[familyUuid], nil
+}
+
+// userRepository returns its user whatever the filter.
+type userRepository struct {
+	user users_DBModels.User
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *users_DBModels.User) error {
+	return nil
+}
+
+func (r *userRepository) GetUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error) {
+	return r.user, nil
+}
+
+func (r *userRepository) GetUsers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*users_DBModels.User, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (r *userRepository) GetDeletedUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error) {
+	return users_DBModels.User{}, nil
+}
+
+func (r *userRepository) RestoreUser(ctx context.Context, whr where.Filter) error {
+	return nil
+}
+
+func (r *userRepository) PurgeUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+type rbacService struct{}
+
+func (s rbacService) GetAccess(ctx context.Context, userUuid uuid.UUID) (*rbac.Access, error) {
+	return &rbac.Access{Roles: []string{rbac.ROLE_VIEWER}}, nil
+}
+
+func (s rbacService) GetRoles(ctx context.Context) ([]*rbac.RoleDetail, error) {
+	return nil, nil
+}
+
+func (s rbacService) ResolveRoles(ctx context.Context, codes []string, actor *rbac.Access) ([]*rbac.RoleDetail, error) {
+	return nil, nil
+}
+
+func (s rbacService) SetUserRoles(ctx context.Context, userUuid uuid.UUID, roles []*rbac.RoleDetail, actorUuid *uuid.UUID) error {
+	return nil
+}
+
+func newTestJwtService() *JwtService {
+	constants.Config = &config.ServiceConfig{JwtConfig: config.JwtConfig{
+		JWT_ACCESS_SECRET:  "access",
+		JWT_REFRESH_SECRET: "refresh",
+		JWT_ACCESS_EXP:     5,
+		JWT_REFRESH_EXP:    10,
+	}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	user := users_DBModels.User{Uuid: uuid.New(), Email: "admin@sigmatech.id"}
+	return NewJwtService(&userRepository{user: user}, rbacService{}, &refreshTokenStore{
+		families: make(map[uuid.UUID]bool),
+		tokens:   make(map[uuid.UUID]bool),
+	}, NewHMACKeySet("access"))
+}
+
+func TestRefreshUserToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Given a refresh token When refreshing Then it can't be used again", func(t *testing.T) {
+		j := newTestJwtService()
+		user, _ := j.UserDBClient.GetUser(ctx, where.Filter{})
+
+		signIn, err := j.GenerateUserTokens(ctx, user)
+		if err != nil {
+			t.Fatalf("GenerateUserTokens() error = %v", err)
+		}
+
+		refreshed, err := j.RefreshUserToken(ctx, signIn.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshUserToken() error = %v", err)
+		}
+		if refreshed.RefreshToken == signIn.RefreshToken {
+			t.Fatalf("RefreshUserToken() returned the same refresh token")
+		}
+
+		if _, err := j.RefreshUserToken(ctx, refreshed.RefreshToken); err != nil {
+			t.Errorf("RefreshUserToken() of the rotated token error = %v", err)
+		}
+	})
+
+	t.Run("Given a rotated refresh token When it is reused Then the whole family is revoked", func(t *testing.T) {
+		j := newTestJwtService()
+		user, _ := j.UserDBClient.GetUser(ctx, where.Filter{})
+
+		signIn, _ := j.GenerateUserTokens(ctx, user)
+		refreshed, err := j.RefreshUserToken(ctx, signIn.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshUserToken() error = %v", err)
+		}
+
+		if _, err := j.RefreshUserToken(ctx, signIn.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("RefreshUserToken() of the reused token error = %v, want %v", err, ErrRefreshTokenReused)
+		}
+		if _, err := j.RefreshUserToken(ctx, refreshed.RefreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+			t.Errorf("RefreshUserToken() of the latest token error = %v, want %v", err, ErrRefreshTokenRevoked)
+		}
+		if _, _, ok := j.VerifyToken(ctx, refreshed.AccessToken); ok {
+			t.Errorf("VerifyToken() accepted an access token of the revoked family")
+		}
+	})
+
+	t.Run("Given a session When logging out Then its tokens are no longer accepted", func(t *testing.T) {
+		j := newTestJwtService()
+		user, _ := j.UserDBClient.GetUser(ctx, where.Filter{})
+
+		signIn, _ := j.GenerateUserTokens(ctx, user)
+		if _, _, ok := j.VerifyToken(ctx, signIn.AccessToken); !ok {
+			t.Fatalf("VerifyToken() rejected the access token before logout")
+		}
+
+		if err := j.Logout(ctx, signIn.AccessToken); err != nil {
+			t.Fatalf("Logout() error = %v", err)
+		}
+		if _, err := j.RefreshUserToken(ctx, signIn.RefreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+			t.Errorf("RefreshUserToken() after logout error = %v, want %v", err, ErrRefreshTokenRevoked)
+		}
+		if _, _, ok := j.VerifyToken(ctx, signIn.AccessToken); ok {
+			t.Errorf("VerifyToken() accepted the access token after logout")
+		}
+	})
+}
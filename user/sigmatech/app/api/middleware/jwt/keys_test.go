@@ -0,0 +1,114 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// writeKey stores the private key as <kid>.pem in dir.
+func writeKey(t *testing.T, dir, kid string, private interface{}) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pemBytes, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	writeKey(t, dir, "2026-01", rsaKey)
+	writeKey(t, dir, "2026-10", edKey)
+
+	t.Run("Given a key set When a token is signed Then it names its key and verifies", func(t *testing.T) {
+		keys, err := LoadKeySet(SIGNING_ALG_EDDSA, dir, "2026-10", "")
+		if err != nil {
+			t.Fatalf("LoadKeySet() error = %v", err)
+		}
+
+		signed, err := keys.Sign(jwt.MapClaims{"sub": "user"})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+
+		token, err := jwt.Parse(signed, keys.Keyfunc)
+		if err != nil || !token.Valid {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if token.Header["kid"] != "2026-10" || token.Method.Alg() != SIGNING_ALG_EDDSA {
+			t.Errorf("token header = %v, want kid 2026-10 signed with EdDSA", token.Header)
+		}
+	})
+
+	t.Run("Given a token of the previous key When the key was rotated Then it still verifies", func(t *testing.T) {
+		previous, err := LoadKeySet(SIGNING_ALG_RS256, dir, "2026-01", "")
+		if err != nil {
+			t.Fatalf("LoadKeySet() error = %v", err)
+		}
+		signed, _ := previous.Sign(jwt.MapClaims{"sub": "user"})
+
+		current, err := LoadKeySet(SIGNING_ALG_EDDSA, dir, "2026-10", "")
+		if err != nil {
+			t.Fatalf("LoadKeySet() error = %v", err)
+		}
+		if _, err := jwt.Parse(signed, current.Keyfunc); err != nil {
+			t.Errorf("Parse() error = %v", err)
+		}
+	})
+
+	t.Run("Given an HS256 token When no secret is configured Then it is refused", func(t *testing.T) {
+		keys, _ := LoadKeySet(SIGNING_ALG_EDDSA, dir, "2026-10", "")
+		signed, _ := NewHMACKeySet("leaked").Sign(jwt.MapClaims{"sub": "user"})
+
+		if _, err := jwt.Parse(signed, keys.Keyfunc); err == nil {
+			t.Error("Parse() accepted an HS256 token")
+		}
+	})
+
+	t.Run("Given a token claiming another algorithm than its key When verifying Then it is refused", func(t *testing.T) {
+		keys, _ := LoadKeySet(SIGNING_ALG_EDDSA, dir, "2026-10", "")
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user"})
+		token.Header["kid"] = "2026-10"
+		signed, _ := token.SignedString(rsaKey)
+
+		if _, err := jwt.Parse(signed, keys.Keyfunc); err == nil {
+			t.Error("Parse() accepted a token whose algorithm isn't the one of its key")
+		}
+	})
+
+	t.Run("Given a key set When publishing it Then every public key is in the JWKS", func(t *testing.T) {
+		keys, _ := LoadKeySet(SIGNING_ALG_EDDSA, dir, "2026-10", "secret")
+
+		jwks := keys.JWKS()
+		if len(jwks.Keys) != 2 {
+			t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+		}
+		if jwks.Keys[0].Kid != "2026-01" || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].N == "" {
+			t.Errorf("JWKS() first key = %+v, want the RSA key", jwks.Keys[0])
+		}
+		if jwks.Keys[1].Kid != "2026-10" || jwks.Keys[1].Kty != "OKP" || jwks.Keys[1].X == "" {
+			t.Errorf("JWKS() second key = %+v, want the Ed25519 key", jwks.Keys[1])
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package jwt
+
+import (
+	"context"
+	"user/sigmatech/app/service/redis"
+
+	"github.com/google/uuid"
+)
+
+// ICustomerPrincipals drops the customers cached by the customer service behind their access tokens, so
+// changes made here aren't hidden by the cache.
+type ICustomerPrincipals interface {
+	Invalidate(ctx context.Context, customerUuid uuid.UUID) error
+}
+
+// RedisCustomerPrincipals deletes the customers from the Redis cache shared with the customer service.
+type RedisCustomerPrincipals struct {
+	Redis redis.IRedisClient
+}
+
+// NewRedisCustomerPrincipals is a constructor function that creates a new RedisCustomerPrincipals.
+func NewRedisCustomerPrincipals(Redis redis.IRedisClient) *RedisCustomerPrincipals {
+	return &RedisCustomerPrincipals{Redis: Redis}
+}
+
+func (p *RedisCustomerPrincipals) Invalidate(ctx context.Context, customerUuid uuid.UUID) error {
+	_, err := p.Redis.Delete(customerPrincipalKeyPrefix + customerUuid.String())
+	return err
+}
+
+// MemoryCustomerPrincipals is used when the customer service caches customers in its own memory, which
+// can't be reached from here. Its entries expire on their own.
+type MemoryCustomerPrincipals struct{}
+
+// NewMemoryCustomerPrincipals is a constructor function that creates a new MemoryCustomerPrincipals.
+func NewMemoryCustomerPrincipals() *MemoryCustomerPrincipals {
+	return &MemoryCustomerPrincipals{}
+}
+
+func (p *MemoryCustomerPrincipals) Invalidate(ctx context.Context, customerUuid uuid.UUID) error {
+	return nil
+}
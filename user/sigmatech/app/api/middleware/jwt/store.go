@@ -0,0 +1,176 @@
+package jwt
+
+import (
+	"context"
+	"time"
+	refreshTokens_DBModels "user/sigmatech/app/db/dto/refresh_tokens"
+	refreshTokenDB "user/sigmatech/app/db/repository/refresh_token"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/redis"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken identifies an issued refresh token. Rotating a token issues the next one of its family,
+// the family lives as long as the session started by the sign in.
+type RefreshToken struct {
+	Uuid        uuid.UUID
+	FamilyUuid  uuid.UUID
+	SubjectUuid uuid.UUID
+	ExpiresAt   time.Time
+}
+
+// IRefreshTokenStore tracks the refresh token families, so every refresh token is accepted once.
+type IRefreshTokenStore interface {
+	// Issue records a new refresh token of its family.
+	Issue(ctx context.Context, token RefreshToken) error
+	// Rotate consumes the token. It returns ErrRefreshTokenReused when the token was consumed before and
+	// ErrRefreshTokenRevoked when the token is unknown, expired or its family was revoked.
+	Rotate(ctx context.Context, token RefreshToken) error
+	// Revoke ends the family, its tokens are no longer accepted.
+	Revoke(ctx context.Context, familyUuid uuid.UUID) error
+	// RevokeAll ends every family of the subject.
+	RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error
+	// Active reports whether the family wasn't revoked.
+	Active(ctx context.Context, familyUuid uuid.UUID) (bool, error)
+}
+
+// PostgresRefreshTokenStore keeps refresh tokens in the refresh_tokens table.
+type PostgresRefreshTokenStore struct {
+	RefreshTokenDBClient refreshTokenDB.IRefreshTokenRepository
+	SubjectType          string
+}
+
+// NewPostgresRefreshTokenStore is a constructor function that creates a new PostgresRefreshTokenStore.
+func NewPostgresRefreshTokenStore(RefreshTokenDBClient refreshTokenDB.IRefreshTokenRepository, SubjectType string) *PostgresRefreshTokenStore {
+	return &PostgresRefreshTokenStore{
+		RefreshTokenDBClient: RefreshTokenDBClient,
+		SubjectType:          SubjectType,
+	}
+}
+
+func (s *PostgresRefreshTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	return s.RefreshTokenDBClient.CreateRefreshToken(ctx, &refreshTokens_DBModels.RefreshToken{
+		Uuid:        token.Uuid,
+		FamilyUuid:  token.FamilyUuid,
+		SubjectUuid: token.SubjectUuid,
+		SubjectType: s.SubjectType,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func (s *PostgresRefreshTokenStore) Rotate(ctx context.Context, token RefreshToken) error {
+	rotated, err := s.RefreshTokenDBClient.RotateRefreshToken(ctx, token.Uuid)
+	if err != nil {
+		return err
+	}
+	if rotated {
+		return nil
+	}
+
+	// Tell a reused token apart from an unknown, expired or revoked one
+	issued, err := s.RefreshTokenDBClient.GetRefreshToken(ctx, where.Eq(refreshTokens_DBModels.COLUM_UUID, token.Uuid))
+	if err != nil {
+		return err
+	}
+	if issued.Uuid != uuid.Nil && issued.RevokedAt == nil && issued.RotatedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	return ErrRefreshTokenRevoked
+}
+
+func (s *PostgresRefreshTokenStore) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	return s.RefreshTokenDBClient.RevokeRefreshTokenFamily(ctx, familyUuid)
+}
+
+func (s *PostgresRefreshTokenStore) RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error {
+	return s.RefreshTokenDBClient.RevokeSubjectRefreshTokens(ctx, s.SubjectType, subjectUuid)
+}
+
+func (s *PostgresRefreshTokenStore) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	token, err := s.RefreshTokenDBClient.GetRefreshToken(ctx, where.Eq(refreshTokens_DBModels.COLUMN_FAMILY_UUID, familyUuid).IsNull(refreshTokens_DBModels.COLUMN_REVOKED_AT))
+	if err != nil {
+		return false, err
+	}
+
+	return token.Uuid != uuid.Nil, nil
+}
+
+// RedisRefreshTokenStore keeps refresh tokens in Redis, every key expires with the token that set it.
+type RedisRefreshTokenStore struct {
+	Redis redis.IRedisClient
+}
+
+// NewRedisRefreshTokenStore is a constructor function that creates a new RedisRefreshTokenStore.
+func NewRedisRefreshTokenStore(redis redis.IRedisClient) *RedisRefreshTokenStore {
+	return &RedisRefreshTokenStore{Redis: redis}
+}
+
+// Issue also extends the family, so it expires with its latest token. The families of a subject are
+// kept in a set, which expires with the latest token issued to the subject.
+func (s *RedisRefreshTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	ttl := time.Until(token.ExpiresAt)
+
+	subjectKey := refreshSubjectKeyPrefix + token.SubjectUuid.String()
+	if _, err := s.Redis.SAdd(subjectKey, token.FamilyUuid.String()); err != nil {
+		return err
+	}
+	if _, err := s.Redis.Expire(subjectKey, ttl); err != nil {
+		return err
+	}
+
+	if err := s.Redis.Set(refreshFamilyKeyPrefix+token.FamilyUuid.String(), token.SubjectUuid.String(), ttl); err != nil {
+		return err
+	}
+	return s.Redis.Set(refreshTokenKeyPrefix+token.Uuid.String(), token.FamilyUuid.String(), ttl)
+}
+
+func (s *RedisRefreshTokenStore) Rotate(ctx context.Context, token RefreshToken) error {
+	issued, err := s.Redis.Exists(refreshTokenKeyPrefix + token.Uuid.String())
+	if err != nil {
+		return err
+	}
+	active, err := s.Active(ctx, token.FamilyUuid)
+	if err != nil {
+		return err
+	}
+	if !issued || !active {
+		return ErrRefreshTokenRevoked
+	}
+
+	// SETNX lets a single refresh consume the token, even when several race
+	first, err := s.Redis.SetNX(refreshRotatedKeyPrefix+token.Uuid.String(), "1", time.Until(token.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	if !first {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *RedisRefreshTokenStore) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	_, err := s.Redis.Delete(refreshFamilyKeyPrefix + familyUuid.String())
+	return err
+}
+
+func (s *RedisRefreshTokenStore) RevokeAll(ctx context.Context, subjectUuid uuid.UUID) error {
+	subjectKey := refreshSubjectKeyPrefix + subjectUuid.String()
+
+	families, err := s.Redis.SMembers(subjectKey)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{subjectKey}
+	for _, family := range families {
+		keys = append(keys, refreshFamilyKeyPrefix+family)
+	}
+	_, err = s.Redis.Delete(keys...)
+	return err
+}
+
+func (s *RedisRefreshTokenStore) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	return s.Redis.Exists(refreshFamilyKeyPrefix + familyUuid.String())
+}
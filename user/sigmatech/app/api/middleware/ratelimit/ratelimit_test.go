@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Policy
+		wantErr bool
+	}{
+		{name: "Given an empty spec When parsing Then the policy is disabled", spec: " "},
+		{
+			name: "Given only a limit and window When parsing Then ip and token bucket are the defaults",
+			spec: "10/1m",
+			want: Policy{Name: "sign_in", Limit: 10, Window: time.Minute, Key: KEY_IP, Algorithm: ALGORITHM_TOKEN_BUCKET},
+		},
+		{
+			name: "Given every part When parsing Then they are all kept",
+			spec: "5/1h, principal, sliding_window",
+			want: Policy{Name: "sign_in", Limit: 5, Window: time.Hour, Key: KEY_PRINCIPAL, Algorithm: ALGORITHM_SLIDING_WINDOW},
+		},
+		{name: "Given no window When parsing Then it fails", spec: "10", wantErr: true},
+		{name: "Given a zero limit When parsing Then it fails", spec: "0/1m", wantErr: true},
+		{name: "Given an unknown key When parsing Then it fails", spec: "10/1m,email", wantErr: true},
+		{name: "Given an unknown algorithm When parsing Then it fails", spec: "10/1m,ip,leaky_bucket", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy("sign_in", tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidPolicy) {
+				t.Errorf("ParsePolicy() error = %v, want ErrInvalidPolicy", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	for _, algorithm := range []string{ALGORITHM_TOKEN_BUCKET, ALGORITHM_SLIDING_WINDOW} {
+		policy := Policy{Name: "test", Limit: 3, Window: 3 * time.Second, Key: KEY_IP, Algorithm: algorithm}
+
+		t.Run("Given the "+algorithm+" limit was used up When requesting again Then it is refused until a request frees up", func(t *testing.T) {
+			limiter := NewMemoryLimiter()
+			now := time.Now()
+			limiter.Now = func() time.Time { return now }
+
+			for i := 0; i < policy.Limit; i++ {
+				result, _ := limiter.Allow(ctx, "ip:1", policy)
+				if !result.Allowed || result.Remaining != policy.Limit-i-1 {
+					t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, policy.Limit-i-1)
+				}
+			}
+
+			result, _ := limiter.Allow(ctx, "ip:1", policy)
+			if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > policy.Window {
+				t.Fatalf("request over the limit = %+v, want refused with a retry after", result)
+			}
+
+			if other, _ := limiter.Allow(ctx, "ip:2", policy); !other.Allowed {
+				t.Error("another key was refused")
+			}
+
+			now = now.Add(result.RetryAfter)
+			if result, _ := limiter.Allow(ctx, "ip:1", policy); !result.Allowed {
+				t.Errorf("request after the retry after = %+v, want allowed", result)
+			}
+		})
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	policy := Policy{Limit: 10, Window: time.Minute}
+	header := http.Header{}
+
+	SetHeaders(header, policy, Result{Limit: 10, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 200 * time.Millisecond})
+
+	want := map[string]string{
+		HEADER_LIMIT:       "10",
+		HEADER_REMAINING:   "0",
+		HEADER_RESET:       "2",
+		HEADER_POLICY:      "10;w=60",
+		HEADER_RETRY_AFTER: "1",
+	}
+	for name, value := range want {
+		if got := header.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StringToSign builds the canonical string covered by the signature:
+// method, request URI, timestamp, nonce and the hex sha256 of the body, one per line.
+func StringToSign(method, uri string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		uri,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// Sign returns the value of the X-Signature header for a request.
+func Sign(secret, method, uri string, timestamp int64, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(StringToSign(method, uri, timestamp, nonce, body)))
+	return SIGNATURE_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest signs an outgoing request with the given key, setting the signature headers.
+// The body is read and put back so the request can still be sent.
+func SignRequest(req *http.Request, keyId, secret string) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := GenerateNonce()
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set(HEADER_KEY_ID, keyId)
+	req.Header.Set(HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HEADER_NONCE, nonce)
+	req.Header.Set(HEADER_SIGNATURE, Sign(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	return nil
+}
+
+// GenerateNonce returns a random hex nonce.
+func GenerateNonce() (string, error) {
+	b := make([]byte, nonceLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ParseKeys parses a comma separated list of "key_id:secret" pairs.
+func ParseKeys(s string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		keys[id] = secret
+	}
+	return keys
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
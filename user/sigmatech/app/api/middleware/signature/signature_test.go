@@ -0,0 +1,150 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1760868000, 0)
+	keys := map[string]string{"partner": "s3cr3t"}
+	body := []byte(`{"otr":1000000}`)
+
+	signed := func(secret string, ts time.Time, nonce string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/v1/partner/transaction/?dry_run=true", bytes.NewReader(body))
+		req.Header.Set(HEADER_KEY_ID, "partner")
+		req.Header.Set(HEADER_TIMESTAMP, strconv.FormatInt(ts.Unix(), 10))
+		req.Header.Set(HEADER_NONCE, nonce)
+		req.Header.Set(HEADER_SIGNATURE, Sign(secret, req.Method, req.URL.RequestURI(), ts.Unix(), nonce, body))
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		wantErr error
+	}{
+		{
+			name:    "Given a valid signature, When call Verify, Then return no error",
+			req:     func() *http.Request { return signed("s3cr3t", now, "nonce-1") },
+			wantErr: nil,
+		},
+		{
+			name:    "Given a signature with the wrong secret, When call Verify, Then return ErrInvalidSignature",
+			req:     func() *http.Request { return signed("wrong", now, "nonce-2") },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "Given a tampered body, When call Verify, Then return ErrInvalidSignature",
+			req: func() *http.Request {
+				req := signed("s3cr3t", now, "nonce-3")
+				req.Body = io.NopCloser(bytes.NewReader([]byte(`{"otr":1}`)))
+				return req
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "Given a timestamp outside the tolerance, When call Verify, Then return ErrClockSkew",
+			req:     func() *http.Request { return signed("s3cr3t", now.Add(-10*time.Minute), "nonce-4") },
+			wantErr: ErrClockSkew,
+		},
+		{
+			name:    "Given a replayed nonce, When call Verify, Then return ErrReplayedNonce",
+			req:     func() *http.Request { return signed("s3cr3t", now, "nonce-1") },
+			wantErr: ErrReplayedNonce,
+		},
+		{
+			name: "Given an unknown key, When call Verify, Then return ErrUnknownKey",
+			req: func() *http.Request {
+				req := signed("s3cr3t", now, "nonce-5")
+				req.Header.Set(HEADER_KEY_ID, "unknown")
+				return req
+			},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name: "Given no signature headers, When call Verify, Then return ErrMissingHeaders",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/v1/partner/transaction/", nil)
+			},
+			wantErr: ErrMissingHeaders,
+		},
+	}
+
+	// The cases share one verifier, so the replay case sees the nonce of the first one
+	verifier := &Verifier{
+		Keys:      keys,
+		Nonces:    NewMemoryNonceStore(),
+		Tolerance: 5 * time.Minute,
+		Now:       func() time.Time { return now },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.req())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/internal/ping/", bytes.NewReader(body))
+
+	if err := SignRequest(req, "partner", "s3cr3t"); err != nil {
+		t.Fatalf("SignRequest() error = %v", err)
+	}
+
+	verifier := NewVerifier(map[string]string{"partner": "s3cr3t"}, NewMemoryNonceStore(), time.Minute)
+	keyId, err := verifier.Verify(context.Background(), req)
+	if err != nil || keyId != "partner" {
+		t.Errorf("Verify() = %v, %v, want partner, nil", keyId, err)
+	}
+
+	if got, _ := io.ReadAll(req.Body); !bytes.Equal(got, body) {
+		t.Errorf("body after Verify() = %s, want %s", got, body)
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Unix(1760868000, 0)
+	store := NewMemoryNonceStore()
+	store.Now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    bool
+	}{
+		{
+			name: "Given a new nonce, When call Remember, Then return true",
+			want: true,
+		},
+		{
+			name: "Given the same nonce within its ttl, When call Remember, Then return false",
+			want: false,
+		},
+		{
+			name:    "Given the same nonce after its ttl, When call Remember, Then return true",
+			advance: 2 * time.Minute,
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if got, _ := store.Remember(context.Background(), "nonce", time.Minute); got != tt.want {
+				t.Errorf("Remember() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,26 +2,86 @@ package server
 
 import (
 	"context"
+	auditMiddleware "user/sigmatech/app/api/middleware/audit"
 	"user/sigmatech/app/api/middleware/auth"
 	"user/sigmatech/app/api/middleware/jwt"
+	"user/sigmatech/app/api/middleware/ratelimit"
+	"user/sigmatech/app/api/middleware/signature"
 	timeoutMiddleware "user/sigmatech/app/api/middleware/timeout"
 	"user/sigmatech/app/constants"
+	analyticsController "user/sigmatech/app/controller/analytics"
+	auditController "user/sigmatech/app/controller/audit"
+	collectionController "user/sigmatech/app/controller/collection"
+	exportController "user/sigmatech/app/controller/export"
 	"user/sigmatech/app/controller/healthcheck"
+	merchantController "user/sigmatech/app/controller/merchant"
+	notificationController "user/sigmatech/app/controller/notification"
+	reconciliationController "user/sigmatech/app/controller/reconciliation"
 	transactionController "user/sigmatech/app/controller/transaction"
 	userController "user/sigmatech/app/controller/users"
+	webhookController "user/sigmatech/app/controller/webhook"
+	"user/sigmatech/app/controller/wellknown"
 	"user/sigmatech/app/db"
 	transactionDBClient "user/sigmatech/app/db/repository/transaction"
+	transactionDelinquencyDBClient "user/sigmatech/app/db/repository/transaction_delinquency"
 	transactionInstallmentDBClient "user/sigmatech/app/db/repository/transaction_installment"
 	userDBClient "user/sigmatech/app/db/repository/user"
 
+	loginThrottles_DBModels "user/sigmatech/app/db/dto/login_throttles"
+	refreshTokens_DBModels "user/sigmatech/app/db/dto/refresh_tokens"
+	sessions_DBModels "user/sigmatech/app/db/dto/sessions"
+	analyticsDBClient "user/sigmatech/app/db/repository/analytics"
+	auditLogDBClient "user/sigmatech/app/db/repository/audit_log"
+	collectionActivityDBClient "user/sigmatech/app/db/repository/collection_activity"
+	collectionAssignmentDBClient "user/sigmatech/app/db/repository/collection_assignment"
 	customerDBClient "user/sigmatech/app/db/repository/customer"
 	cifDBClient "user/sigmatech/app/db/repository/customer_information_file"
 	customerLimitDBClient "user/sigmatech/app/db/repository/customer_limit"
+	emailVerificationDBClient "user/sigmatech/app/db/repository/email_verification"
+	exportDBClient "user/sigmatech/app/db/repository/export"
+	exportJobDBClient "user/sigmatech/app/db/repository/export_job"
+	loginThrottleDBClient "user/sigmatech/app/db/repository/login_throttle"
+	merchantDBClient "user/sigmatech/app/db/repository/merchant"
+	merchantApiKeyDBClient "user/sigmatech/app/db/repository/merchant_api_key"
+	mfaRecoveryCodeDBClient "user/sigmatech/app/db/repository/mfa_recovery_code"
+	notificationDBClient "user/sigmatech/app/db/repository/notification"
+	notificationPreferenceDBClient "user/sigmatech/app/db/repository/notification_preference"
+	notificationTemplateDBClient "user/sigmatech/app/db/repository/notification_template"
+	onboardingDBClient "user/sigmatech/app/db/repository/onboarding"
+	passwordResetDBClient "user/sigmatech/app/db/repository/password_reset"
+	paymentCallbackDBClient "user/sigmatech/app/db/repository/payment_callback"
+	reconciliationJobDBClient "user/sigmatech/app/db/repository/reconciliation_job"
+	reconciliationRowDBClient "user/sigmatech/app/db/repository/reconciliation_row"
+	refreshTokenDBClient "user/sigmatech/app/db/repository/refresh_token"
+	roleDBClient "user/sigmatech/app/db/repository/role"
+	sessionDBClient "user/sigmatech/app/db/repository/session"
+	userMfaDBClient "user/sigmatech/app/db/repository/user_mfa"
+	virtualAccountDBClient "user/sigmatech/app/db/repository/virtual_account"
+	webhookDeliveryDBClient "user/sigmatech/app/db/repository/webhook_delivery"
+	webhookSubscriptionDBClient "user/sigmatech/app/db/repository/webhook_subscription"
 
 	customerController "user/sigmatech/app/controller/customer"
 
 	"strings"
+	"time"
+	"user/sigmatech/app/service/audit"
+	"user/sigmatech/app/service/aws/s3"
+	"user/sigmatech/app/service/customerimport"
+	"user/sigmatech/app/service/emailverification"
+	"user/sigmatech/app/service/export"
+	"user/sigmatech/app/service/ipgeolocation"
+	"user/sigmatech/app/service/lockout"
 	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mailer"
+	"user/sigmatech/app/service/mfa"
+	"user/sigmatech/app/service/notification"
+	"user/sigmatech/app/service/passwordreset"
+	"user/sigmatech/app/service/purge"
+	"user/sigmatech/app/service/rbac"
+	"user/sigmatech/app/service/reconciliation"
+	"user/sigmatech/app/service/redis"
+	"user/sigmatech/app/service/session"
+	"user/sigmatech/app/service/webhook"
 
 	helmet "github.com/danielkov/gin-helmet"
 	"github.com/gin-contrib/cors"
@@ -71,6 +131,12 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 
 	router := gin.New()
 
+	// Rate limits, lockouts and sessions key on the client IP, X-Forwarded-For is only believed from the
+	// configured proxies so clients can't pick their own
+	if err := router.SetTrustedProxies(constants.Config.HTTPServerConfig.HTTPSERVER_TRUSTED_PROXIES); err != nil {
+		log.Fatalf("Parsing the trusted proxies failed with error: %v", err)
+	}
+
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(helmet.Default())
@@ -89,7 +155,8 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
 	config.AllowMethods = []string{"GET", "POST", "PATCH", "DELETE", "PUT", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Accept", "Content-Type", constants.AUTHORIZATION, constants.CORRELATION_KEY_ID.String()}
+	config.AllowHeaders = []string{"Origin", "Accept", "Content-Type", constants.AUTHORIZATION, constants.CORRELATION_KEY_ID.String(),
+		signature.HEADER_SIGNATURE, signature.HEADER_KEY_ID, signature.HEADER_TIMESTAMP, signature.HEADER_NONCE}
 	router.Use(cors.New(config))
 
 	router.Use(uuidInjectionMiddleware())
@@ -104,24 +171,134 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 
 		transactionDBClient            = transactionDBClient.NewTransactionRepository(dbConnection)
 		transactionInstallmentDBClient = transactionInstallmentDBClient.NewTransactionInstallmentRepository(dbConnection)
+
+		notificationDBClient           = notificationDBClient.NewNotificationRepository(dbConnection)
+		notificationPreferenceDBClient = notificationPreferenceDBClient.NewNotificationPreferenceRepository(dbConnection)
+		notificationTemplateDBClient   = notificationTemplateDBClient.NewNotificationTemplateRepository(dbConnection)
+
+		webhookSubscriptionDBClient = webhookSubscriptionDBClient.NewWebhookSubscriptionRepository(dbConnection)
+		webhookDeliveryDBClient     = webhookDeliveryDBClient.NewWebhookDeliveryRepository(dbConnection)
+
+		merchantDBClient       = merchantDBClient.NewMerchantRepository(dbConnection)
+		merchantApiKeyDBClient = merchantApiKeyDBClient.NewMerchantApiKeyRepository(dbConnection)
+
+		virtualAccountDBClient    = virtualAccountDBClient.NewVirtualAccountRepository(dbConnection)
+		paymentCallbackDBClient   = paymentCallbackDBClient.NewPaymentCallbackRepository(dbConnection)
+		reconciliationJobDBClient = reconciliationJobDBClient.NewReconciliationJobRepository(dbConnection)
+		reconciliationRowDBClient = reconciliationRowDBClient.NewReconciliationRowRepository(dbConnection)
+
+		transactionDelinquencyDBClient = transactionDelinquencyDBClient.NewTransactionDelinquencyRepository(dbConnection)
+		collectionAssignmentDBClient   = collectionAssignmentDBClient.NewCollectionAssignmentRepository(dbConnection)
+		collectionActivityDBClient     = collectionActivityDBClient.NewCollectionActivityRepository(dbConnection)
+
+		analyticsDBClient = analyticsDBClient.NewAnalyticsRepository(dbConnection)
+
+		exportDBClient    = exportDBClient.NewExportRepository(dbConnection)
+		exportJobDBClient = exportJobDBClient.NewExportJobRepository(dbConnection)
+
+		onboardingDBClient = onboardingDBClient.NewOnboardingRepository(dbConnection)
+
+		auditLogDBClient = auditLogDBClient.NewAuditLogRepository(dbConnection)
+
+		roleDBClient = roleDBClient.NewRoleRepository(dbConnection)
+
+		refreshTokenDBClient      = refreshTokenDBClient.NewRefreshTokenRepository(dbConnection)
+		passwordResetDBClient     = passwordResetDBClient.NewPasswordResetRepository(dbConnection)
+		emailVerificationDBClient = emailVerificationDBClient.NewEmailVerificationRepository(dbConnection)
+		loginThrottleDBClient     = loginThrottleDBClient.NewLoginThrottleRepository(dbConnection)
+		sessionDBClient           = sessionDBClient.NewSessionRepository(dbConnection)
+		userMfaDBClient           = userMfaDBClient.NewUserMfaRepository(dbConnection)
+		recoveryCodeDBClient      = mfaRecoveryCodeDBClient.NewMfaRecoveryCodeRepository(dbConnection)
 	)
 
 	// SERVICES
 	var (
-		jwt = jwt.NewJwtService(userDBClient)
+		rbacService  = rbac.NewRbacService(roleDBClient)
+		refreshStore = newRefreshTokenStore(ctx, refreshTokenDBClient)
+		// The customer service keeps its refresh tokens in Postgres only
+		customerSessions   = jwt.NewPostgresRefreshTokenStore(refreshTokenDBClient, refreshTokens_DBModels.SUBJECT_CUSTOMER)
+		customerPrincipals = newCustomerPrincipals(ctx)
+		keys               = newKeySet(ctx)
+		jwt                = jwt.NewJwtService(userDBClient, rbacService, refreshStore, keys)
+		notification       = notification.NewNotificationService(notificationTemplateDBClient, notificationPreferenceDBClient, notificationDBClient)
+		webhook            = webhook.NewWebhookService(webhookSubscriptionDBClient, webhookDeliveryDBClient, webhook.NewClient(time.Duration(constants.Config.WebhookConfig.WEBHOOK_TIMEOUT)*time.Second))
+
+		reconciliation = reconciliation.NewReconciliationService(customerDBClient, transactionDBClient, transactionInstallmentDBClient, virtualAccountDBClient, paymentCallbackDBClient, reconciliationJobDBClient, reconciliationRowDBClient, notification, webhook)
+
+		emailVerification = emailverification.NewEmailVerificationService(emailVerificationDBClient, newMailer())
+
+		s3Client       = s3.NewS3Service()
+		export         = export.NewExportService(exportDBClient, exportJobDBClient, s3Client)
+		customerImport = customerimport.NewCustomerImportService(customerDBClient, cifDBClient, onboardingDBClient, s3Client, emailVerification)
+
+		audit = audit.NewAuditService(auditLogDBClient)
+
+		purge = purge.NewPurgeService(customerDBClient, userDBClient, refreshTokenDBClient, loginThrottleDBClient, sessionDBClient)
+
+		passwordReset = passwordreset.NewPasswordResetService(userDBClient, passwordResetDBClient, newMailer(), jwt)
+
+		userLockout     = lockout.NewLockoutService(loginThrottleDBClient, loginThrottles_DBModels.SUBJECT_USER)
+		customerLockout = lockout.NewLockoutService(loginThrottleDBClient, loginThrottles_DBModels.SUBJECT_CUSTOMER)
+
+		userSession = session.NewSessionService(sessionDBClient, refreshStore, newGeolocation(), sessions_DBModels.SUBJECT_USER)
+		userMfa     = mfa.NewMfaService(userMfaDBClient, recoveryCodeDBClient)
 	)
 
+	// Signed requests are opt-in, routes only check them when SIGNATURE_ENABLED is set
+	signed := []gin.HandlerFunc{}
+	if constants.Config.SignatureConfig.SIGNATURE_ENABLED {
+		signed = append(signed, auth.Signature(newSignatureVerifier(ctx)))
+	}
+
+	// Rate limits are opt-in as well, their policies are disabled unless RATE_LIMIT_ENABLED is set
+	limiter := newRateLimiter(ctx)
+	var (
+		signInLimit        = auth.RateLimit(limiter, rateLimitPolicy(ctx, "user_sign_in", constants.Config.RateLimitConfig.RATE_LIMIT_SIGN_IN))
+		signUpLimit        = auth.RateLimit(limiter, rateLimitPolicy(ctx, "user_sign_up", constants.Config.RateLimitConfig.RATE_LIMIT_SIGN_UP))
+		refreshTokenLimit  = auth.RateLimit(limiter, rateLimitPolicy(ctx, "user_refresh_token", constants.Config.RateLimitConfig.RATE_LIMIT_REFRESH_TOKEN))
+		passwordResetLimit = auth.RateLimit(limiter, rateLimitPolicy(ctx, "user_password_reset", constants.Config.RateLimitConfig.RATE_LIMIT_PASSWORD_RESET))
+	)
+
+	// Deliver queued webhooks in the background, including the ones queued by the customer service
+	go webhook.Run(ctx)
+
+	// Hard delete the customers and users deleted longer ago than the retention
+	if constants.Config.PurgeConfig.PURGE_ENABLED {
+		go purge.Run(ctx)
+	}
+
 	// Controller
 	var (
 		healthCheckController = healthcheck.NewHealthCheckController()
-		userController        = userController.NewUserController(userDBClient, jwt)
-		customerController    = customerController.NewCustomerController(customerDBClient, customerLimitDBClient, cifDBClient)
+		wellKnownController   = wellknown.NewWellKnownController(keys)
+		userController        = userController.NewUserController(userDBClient, jwt, rbacService, passwordReset, userLockout, userSession, userMfa)
+		customerController    = customerController.NewCustomerController(customerDBClient, customerLimitDBClient, cifDBClient, transactionDBClient, notification, webhook, export, customerImport, customerLockout, emailVerification, customerSessions, customerPrincipals)
+
+		transactionController = transactionController.NewTransactionController(customerDBClient, customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient, transactionDelinquencyDBClient, export)
+
+		notificationController = notificationController.NewNotificationController(notificationTemplateDBClient)
 
-		transactionController = transactionController.NewTransactionController(customerDBClient, customerLimitDBClient, transactionDBClient, transactionInstallmentDBClient)
+		webhookController = webhookController.NewWebhookController(webhookSubscriptionDBClient, webhookDeliveryDBClient, webhook)
+
+		merchantController = merchantController.NewMerchantController(merchantDBClient, merchantApiKeyDBClient)
+
+		reconciliationController = reconciliationController.NewReconciliationController(reconciliationJobDBClient, reconciliationRowDBClient, reconciliation)
+
+		collectionController = collectionController.NewCollectionController(userDBClient, transactionDBClient, transactionDelinquencyDBClient, collectionAssignmentDBClient, collectionActivityDBClient)
+
+		analyticsController = analyticsController.NewAnalyticsController(analyticsDBClient)
+
+		exportController = exportController.NewExportController(exportJobDBClient, export)
+
+		auditController = auditController.NewAuditController(auditLogDBClient)
 	)
 
+	// Token verification keys, at the well-known path outside of the API versions
+	router.GET(JWKS, wellKnownController.GetJwks)
+
 	// API version v1
 	v1 := router.Group("/v1")
+	v1.Use(auditMiddleware.AuditMiddleware(audit)) // records the mutations of authenticated users
 	{
 		// Health Check
 		v1.GET(HEALTH_CHECK, healthCheckController.HealthCheck)
@@ -130,62 +307,330 @@ func NewRouter(ctx context.Context, dbConnection *db.DBService) *gin.Engine {
 		user := v1.Group(USER)
 		{
 			// Public user sign-up and sign-in routes
-			v1.POST(USER+SIGN_UP+"/", userController.SignUp)
-			v1.POST(USER+SIGN_IN+"/", userController.SignIn)
-			v1.POST(USER+REFRESH_TOKEN+"/", userController.RefreshToken)
+			v1.POST(USER+SIGN_UP+"/", signUpLimit, userController.SignUp)
+			v1.POST(USER+SIGN_IN+"/", signInLimit, userController.SignIn)
+			v1.POST(USER+REFRESH_TOKEN+"/", refreshTokenLimit, userController.RefreshToken)
+			v1.POST(USER+FORGOT_PASSWORD+"/", passwordResetLimit, userController.ForgotPassword)
+			v1.POST(USER+RESET_PASSWORD+"/", passwordResetLimit, userController.ResetPassword)
+			v1.POST(USER+MFA_VERIFY+"/", signInLimit, userController.VerifyMfa)
 
 			// User profile routes
-			user.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
+			user.Use(auth.Authentication(jwt)) // permissions are checked per route
 			user.GET(PROFILE+"/", userController.GetProfile)
 			user.PATCH(PROFILE+"/", userController.UpdateProfile)
 			user.PATCH(PROFILE_PASSWORD+"/", userController.UpdateProfilePassword)
+			user.POST(LOGOUT+"/", userController.Logout)
+			user.GET(SESSIONS+"/", userController.GetSessions)
+			user.DELETE(SESSIONS+"/:id/", userController.RevokeSession)
+
+			// MFA enrollment, open to users whose roles require MFA before they enroll
+			user.POST(MFA_ENROLL+"/", userController.EnrollMfa)
+			user.POST(MFA_ACTIVATE+"/", userController.ActivateMfa)
+			user.POST(MFA_RECOVERY_CODES+"/", userController.RegenerateRecoveryCodes)
+			user.DELETE(MFA+"/", userController.DisableMfa)
 
 			// User CRUD routes
-			user.POST("/", userController.CreateUser)
-			user.GET("/", userController.GetUsers)
-			user.GET("/:id/", userController.GetUser)
-			user.PATCH("/:id/", userController.UpdateUser)
-			user.PATCH("/:id/"+PASSWORD+"/", userController.UpdateUserPassword)
-			user.DELETE("/:id/", userController.DeleteUser)
-			user.DELETE("/", userController.DeleteUsers)
+			user.POST("/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.CreateUser)
+			user.GET("/", auth.Authorize(rbac.PERMISSION_USER_READ), userController.GetUsers)
+			user.GET("/:id/", auth.Authorize(rbac.PERMISSION_USER_READ), userController.GetUser)
+			user.PATCH("/:id/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.UpdateUser)
+			user.PATCH("/:id/"+PASSWORD+"/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.UpdateUserPassword)
+			user.DELETE("/:id/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.DeleteUser)
+			user.DELETE("/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.DeleteUsers)
+			user.PATCH("/:id/"+RESTORE+"/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.RestoreUser)
+			user.PATCH("/:id/"+UNLOCK+"/", auth.Authorize(rbac.PERMISSION_USER_WRITE), userController.UnlockUser)
+		}
+
+		// Role routes, roles are given to users through the user routes
+		role := v1.Group(ROLE)
+		{
+			role.Use(auth.Authentication(jwt)) // permissions are checked per route
+			role.GET("/", auth.Authorize(rbac.PERMISSION_USER_READ), userController.GetRoles)
 		}
 
 		// Customer routes
 		customer := v1.Group(CUSTOMER)
 		{
 			// Customer route
-			customer.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
-			customer.GET("/", customerController.GetCustomers)
-			customer.GET("/"+DETAIL, customerController.GetCustomersDetail)
-			customer.GET("/:id/", customerController.GetCustomer)
-			customer.PATCH("/:id/", customerController.UpdateCustomer)
-			customer.PATCH("/:id/"+PASSWORD+"/", customerController.UpdateCustomerPassword)
-			customer.DELETE("/:id/", customerController.DeleteCustomer)
-			customer.DELETE("/", customerController.DeleteCustomers)
+			customer.Use(auth.Authentication(jwt)) // permissions are checked per route
+			customer.GET("/", auth.Authorize(rbac.PERMISSION_CUSTOMER_READ), customerController.GetCustomers)
+			customer.GET("/"+DETAIL, auth.Authorize(rbac.PERMISSION_CUSTOMER_READ), customerController.GetCustomersDetail)
+			customer.GET("/:id/", auth.Authorize(rbac.PERMISSION_CUSTOMER_READ), customerController.GetCustomer)
+			customer.PATCH("/:id/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.UpdateCustomer)
+			customer.PATCH("/:id/"+PASSWORD+"/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.UpdateCustomerPassword)
+			customer.DELETE("/:id/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.DeleteCustomer)
+			customer.DELETE("/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.DeleteCustomers)
+			customer.PATCH("/:id/"+RESTORE+"/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.RestoreCustomer)
+			customer.PATCH("/:id/"+UNLOCK+"/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.UnlockCustomer)
+			customer.POST("/"+IMPORT+"/", auth.Authorize(rbac.PERMISSION_CUSTOMER_WRITE), customerController.ImportCustomers)
 
 			// Customer routes
 			customerLimit := customer.Group(LIMIT)
 			{
 				// Customer Limit route
-				customerLimit.GET("/:id/", customerController.GetCustomerLimits)
-				customerLimit.PATCH(APPROVE+"/", customerController.ApproveCustomer)
+				customerLimit.GET("/:id/", auth.Authorize(rbac.PERMISSION_CUSTOMER_READ), customerController.GetCustomerLimits)
+				customerLimit.PATCH(APPROVE+"/", auth.Authorize(rbac.PERMISSION_CUSTOMER_APPROVE), customerController.ApproveCustomer)
 			}
 		}
 
 		// Transaction routes
 		transaction := v1.Group(TRANSACTION)
 		{
-			transaction.Use(auth.Authentication(jwt)) // pass allowed roles for the APIs
-			transaction.GET("/", transactionController.GetTransactions)
-			transaction.GET(DETAIL+"/", transactionController.GetTransactionDetails)
-			transaction.GET("/:id/", transactionController.GetTransaction)
+			transaction.Use(auth.Authentication(jwt)) // permissions are checked per route
+			transaction.GET("/", auth.Authorize(rbac.PERMISSION_TRANSACTION_READ), transactionController.GetTransactions)
+			transaction.GET(DETAIL+"/", auth.Authorize(rbac.PERMISSION_TRANSACTION_READ), transactionController.GetTransactionDetails)
+			transaction.GET("/:id/", auth.Authorize(rbac.PERMISSION_TRANSACTION_READ), transactionController.GetTransaction)
+		}
+
+		// Notification routes
+		notification := v1.Group(NOTIFICATION)
+		{
+			notification.Use(auth.Authentication(jwt)) // permissions are checked per route
+
+			// Notification template routes
+			template := notification.Group(TEMPLATE)
+			{
+				template.POST("/", auth.Authorize(rbac.PERMISSION_NOTIFICATION_WRITE), notificationController.CreateTemplate)
+				template.GET("/", auth.Authorize(rbac.PERMISSION_NOTIFICATION_READ), notificationController.GetTemplates)
+				template.GET("/:id/", auth.Authorize(rbac.PERMISSION_NOTIFICATION_READ), notificationController.GetTemplate)
+				template.PATCH("/:id/", auth.Authorize(rbac.PERMISSION_NOTIFICATION_WRITE), notificationController.UpdateTemplate)
+				template.DELETE("/:id/", auth.Authorize(rbac.PERMISSION_NOTIFICATION_WRITE), notificationController.DeleteTemplate)
+			}
+		}
+
+		// Webhook routes
+		webhook := v1.Group(WEBHOOK)
+		{
+			webhook.Use(auth.Authentication(jwt)) // permissions are checked per route
+			webhook.Use(signed...)
+
+			// Webhook subscription routes
+			subscription := webhook.Group(SUBSCRIPTION)
+			{
+				subscription.POST("/", auth.Authorize(rbac.PERMISSION_WEBHOOK_WRITE), webhookController.CreateSubscription)
+				subscription.GET("/", auth.Authorize(rbac.PERMISSION_WEBHOOK_READ), webhookController.GetSubscriptions)
+				subscription.GET("/:id/", auth.Authorize(rbac.PERMISSION_WEBHOOK_READ), webhookController.GetSubscription)
+				subscription.PATCH("/:id/", auth.Authorize(rbac.PERMISSION_WEBHOOK_WRITE), webhookController.UpdateSubscription)
+				subscription.DELETE("/:id/", auth.Authorize(rbac.PERMISSION_WEBHOOK_WRITE), webhookController.DeleteSubscription)
+			}
+
+			// Webhook delivery log routes
+			delivery := webhook.Group(DELIVERY)
+			{
+				delivery.GET("/", auth.Authorize(rbac.PERMISSION_WEBHOOK_READ), webhookController.GetDeliveries)
+				delivery.GET("/:id/", auth.Authorize(rbac.PERMISSION_WEBHOOK_READ), webhookController.GetDelivery)
+				delivery.POST("/:id/"+REPLAY+"/", auth.Authorize(rbac.PERMISSION_WEBHOOK_WRITE), webhookController.ReplayDelivery)
+			}
+		}
+
+		// Merchant routes
+		merchant := v1.Group(MERCHANT)
+		{
+			merchant.Use(auth.Authentication(jwt)) // permissions are checked per route
+			merchant.Use(signed...)
+			merchant.POST("/", auth.Authorize(rbac.PERMISSION_MERCHANT_WRITE), merchantController.CreateMerchant)
+			merchant.GET("/", auth.Authorize(rbac.PERMISSION_MERCHANT_READ), merchantController.GetMerchants)
+			merchant.GET("/:id/", auth.Authorize(rbac.PERMISSION_MERCHANT_READ), merchantController.GetMerchant)
+			merchant.PATCH("/:id/", auth.Authorize(rbac.PERMISSION_MERCHANT_WRITE), merchantController.UpdateMerchant)
+			merchant.DELETE("/:id/", auth.Authorize(rbac.PERMISSION_MERCHANT_WRITE), merchantController.DeleteMerchant)
+
+			// Merchant API key routes
+			merchant.POST("/:id/"+API_KEY+"/", auth.Authorize(rbac.PERMISSION_MERCHANT_WRITE), merchantController.CreateApiKey)
+			merchant.GET("/:id/"+API_KEY+"/", auth.Authorize(rbac.PERMISSION_MERCHANT_READ), merchantController.GetApiKeys)
+			merchant.DELETE("/:id/"+API_KEY+"/:key_id/", auth.Authorize(rbac.PERMISSION_MERCHANT_WRITE), merchantController.RevokeApiKey)
+		}
+
+		// Reconciliation routes
+		reconciliation := v1.Group(RECONCILIATION)
+		{
+			reconciliation.Use(auth.Authentication(jwt)) // permissions are checked per route
+
+			// Bank mutation file import routes
+			job := reconciliation.Group(JOB)
+			{
+				job.POST("/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_WRITE), reconciliationController.ImportFile)
+				job.GET("/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_READ), reconciliationController.GetJobs)
+				job.GET("/:id/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_READ), reconciliationController.GetJob)
+				job.GET("/:id/"+ROW+"/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_READ), reconciliationController.GetJobRows)
+			}
+
+			// Review queue routes
+			row := reconciliation.Group(ROW)
+			{
+				row.GET("/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_READ), reconciliationController.GetRows)
+				row.PATCH("/:id/"+RESOLVE+"/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_WRITE), reconciliationController.ResolveRow)
+				row.PATCH("/:id/"+DISMISS+"/", auth.Authorize(rbac.PERMISSION_RECONCILIATION_WRITE), reconciliationController.DismissRow)
+			}
+		}
+
+		// Collection routes
+		collection := v1.Group(COLLECTION)
+		{
+			collection.Use(auth.Authentication(jwt)) // permissions are checked per route
+			collection.GET(WORKLIST+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_READ), collectionController.GetWorklist)
+			collection.GET(BUCKET+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_READ), collectionController.GetBuckets)
+
+			// Contract collection routes, :id is the transaction uuid
+			contract := collection.Group(CONTRACT)
+			{
+				contract.PUT("/:id/"+ASSIGNMENT+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_WRITE), collectionController.AssignAgent)
+				contract.DELETE("/:id/"+ASSIGNMENT+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_WRITE), collectionController.UnassignAgent)
+				contract.GET("/:id/"+ACTIVITY+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_READ), collectionController.GetActivities)
+				contract.POST("/:id/"+ACTIVITY+"/", auth.Authorize(rbac.PERMISSION_COLLECTION_WRITE), collectionController.CreateActivity)
+			}
+		}
+
+		// Analytics routes
+		analytics := v1.Group(ANALYTICS)
+		{
+			analytics.Use(auth.Authentication(jwt)) // permissions are checked per route
+			analytics.GET(DISBURSEMENT+"/", auth.Authorize(rbac.PERMISSION_ANALYTICS_READ), analyticsController.GetDisbursements)
+			analytics.GET(OUTSTANDING+"/", auth.Authorize(rbac.PERMISSION_ANALYTICS_READ), analyticsController.GetOutstanding)
+			analytics.GET(INTEREST+"/", auth.Authorize(rbac.PERMISSION_ANALYTICS_READ), analyticsController.GetInterestEarned)
+			analytics.GET(FUNNEL+"/", auth.Authorize(rbac.PERMISSION_ANALYTICS_READ), analyticsController.GetConversionFunnel)
 		}
 
+		// Export job routes, exports start from the export query parameter of the list endpoints
+		export := v1.Group(EXPORT)
+		{
+			export.Use(auth.Authentication(jwt)) // permissions are checked per route
+			export.GET("/", auth.Authorize(rbac.PERMISSION_EXPORT_READ), exportController.GetJobs)
+			export.GET("/:id/", auth.Authorize(rbac.PERMISSION_EXPORT_READ), exportController.GetJob)
+		}
+
+		// Audit log routes, every mutation of an authenticated user is recorded
+		auditLog := v1.Group(AUDIT)
+		{
+			auditLog.Use(auth.Authentication(jwt)) // permissions are checked per route
+			auditLog.GET("/", auth.Authorize(rbac.PERMISSION_AUDIT_READ), auditController.GetAuditLogs)
+			auditLog.GET("/:id/", auth.Authorize(rbac.PERMISSION_AUDIT_READ), auditController.GetAuditLog)
+		}
 	}
 
 	return router
 }
 
+// newSignatureVerifier builds the request signature verifier with the configured nonce store
+func newSignatureVerifier(ctx context.Context) signature.IVerifier {
+	log := logger.Logger(ctx)
+
+	var nonces signature.INonceStore
+	switch constants.Config.SignatureConfig.SIGNATURE_NONCE_STORE {
+	case signature.NONCE_STORE_MEMORY:
+		nonces = signature.NewMemoryNonceStore()
+	default:
+		redisClient, err := redis.Init(ctx)
+		if err != nil {
+			log.Fatalf("Redis connection for the signature nonce store failed with error: %v", err)
+		}
+		nonces = signature.NewRedisNonceStore(redisClient)
+	}
+
+	return signature.NewVerifier(
+		signature.ParseKeys(constants.Config.SignatureConfig.SIGNATURE_KEYS),
+		nonces,
+		time.Duration(constants.Config.SignatureConfig.SIGNATURE_TOLERANCE)*time.Second,
+	)
+}
+
+// newGeolocation builds the cached geolocation client, sessions aren't located without an API key
+func newGeolocation() ipgeolocation.IClient {
+	if constants.Config.IPGeoLocationConfig.IPGEOLOCATION_API_KEY == "" {
+		return nil
+	}
+
+	return ipgeolocation.NewCachedClient(
+		ipgeolocation.NewClient(constants.Config.IPGeoLocationConfig.IPGEOLOCATION_API_KEY),
+		time.Duration(constants.Config.IPGeoLocationConfig.IPGEOLOCATION_CACHE_TTL)*time.Second,
+		constants.Config.IPGeoLocationConfig.IPGEOLOCATION_CACHE_SIZE,
+	)
+}
+
+// newMailer builds the mailer of the configured driver
+func newMailer() mailer.IMailer {
+	switch constants.Config.MailConfig.MAIL_DRIVER {
+	case mailer.DRIVER_SMTP:
+		return mailer.NewSMTPMailer(
+			constants.Config.MailConfig.MAIL_SMTP_HOST,
+			constants.Config.MailConfig.MAIL_SMTP_PORT,
+			constants.Config.MailConfig.MAIL_SMTP_USERNAME,
+			constants.Config.MailConfig.MAIL_SMTP_PASSWORD,
+			constants.Config.MailConfig.MAIL_FROM,
+		)
+	default:
+		return mailer.NewLogMailer()
+	}
+}
+
+// newKeySet loads the keys access tokens are signed with
+func newKeySet(ctx context.Context) *jwt.KeySet {
+	keys, err := jwt.LoadKeySet(
+		constants.Config.JwtConfig.JWT_SIGNING_ALG,
+		constants.Config.JwtConfig.JWT_KEYS_DIR,
+		constants.Config.JwtConfig.JWT_KEY_ID,
+		constants.Config.JwtConfig.JWT_ACCESS_SECRET,
+	)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Loading the token signing keys failed with error: %v", err)
+	}
+	return keys
+}
+
+// newRefreshTokenStore builds the refresh token store of the configured backend
+func newRefreshTokenStore(ctx context.Context, refreshTokenDBClient refreshTokenDBClient.IRefreshTokenRepository) jwt.IRefreshTokenStore {
+	log := logger.Logger(ctx)
+
+	switch constants.Config.JwtConfig.JWT_REFRESH_STORE {
+	case jwt.REFRESH_STORE_REDIS:
+		redisClient, err := redis.Init(ctx)
+		if err != nil {
+			log.Fatalf("Redis connection for the refresh token store failed with error: %v", err)
+		}
+		return jwt.NewRedisRefreshTokenStore(redisClient)
+	default:
+		return jwt.NewPostgresRefreshTokenStore(refreshTokenDBClient, refreshTokens_DBModels.SUBJECT_USER)
+	}
+}
+
+// newRateLimiter builds the rate limiter of the configured backend
+func newRateLimiter(ctx context.Context) ratelimit.ILimiter {
+	if !constants.Config.RateLimitConfig.RATE_LIMIT_ENABLED || constants.Config.RateLimitConfig.RATE_LIMIT_STORE != ratelimit.STORE_REDIS {
+		return ratelimit.NewMemoryLimiter()
+	}
+
+	redisClient, err := redis.Init(ctx)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Redis connection for the rate limiter failed with error: %v", err)
+	}
+	return ratelimit.NewRedisLimiter(redisClient)
+}
+
+// rateLimitPolicy parses the policy of a route, it is disabled while rate limiting is off
+func rateLimitPolicy(ctx context.Context, name, spec string) ratelimit.Policy {
+	if !constants.Config.RateLimitConfig.RATE_LIMIT_ENABLED {
+		return ratelimit.Policy{}
+	}
+
+	policy, err := ratelimit.ParsePolicy(name, spec)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Parsing the rate limit policies failed with error: %v", err)
+	}
+	return policy
+}
+
+// newCustomerPrincipals builds the invalidation of the customer service's principal cache
+func newCustomerPrincipals(ctx context.Context) jwt.ICustomerPrincipals {
+	if constants.Config.JwtConfig.JWT_CUSTOMER_PRINCIPAL_CACHE != jwt.PRINCIPAL_CACHE_REDIS {
+		return jwt.NewMemoryCustomerPrincipals()
+	}
+
+	redisClient, err := redis.Init(ctx)
+	if err != nil {
+		logger.Logger(ctx).Fatalf("Redis connection for the customer principal cache failed with error: %v", err)
+	}
+	return jwt.NewRedisCustomerPrincipals(redisClient)
+}
+
 // uuidInjectionMiddleware injects the request context with a correlation id of type uuid
 func uuidInjectionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -3,9 +3,11 @@ package server
 const (
 	// General Routes
 	HEALTH_CHECK = "/health-check"
+	JWKS         = "/.well-known/jwks.json"
 
 	// User Routes
 	USER = "user"
+	ROLE = "role"
 
 	// Customer Routes
 	CUSTOMER = "customer"
@@ -14,11 +16,26 @@ const (
 	DETAIL   = "detail"
 	PASSWORD = "password"
 	APPROVE  = "approve"
+	IMPORT   = "import"
+	RESTORE  = "restore"
+	UNLOCK   = "unlock"
 
 	// Authentication Routes
 	SIGN_UP       = "/sign-up"
 	SIGN_IN       = "/sign-in"
 	REFRESH_TOKEN = "/refresh-token"
+	LOGOUT        = "/logout"
+	SESSIONS      = "/sessions"
+
+	// MFA Routes
+	MFA                = "/mfa"
+	MFA_VERIFY         = "/mfa/verify"
+	MFA_ENROLL         = "/mfa/enroll"
+	MFA_ACTIVATE       = "/mfa/activate"
+	MFA_RECOVERY_CODES = "/mfa/recovery-codes"
+
+	FORGOT_PASSWORD = "/forgot-password"
+	RESET_PASSWORD  = "/reset-password"
 
 	// Account Routes (Reused for Customer and Vendor)
 	ACCOUNT          = "/account"
@@ -27,4 +44,46 @@ const (
 
 	// Transaction Routes
 	TRANSACTION = "transaction"
+
+	// Notification Routes
+	NOTIFICATION = "notification"
+	TEMPLATE     = "template"
+
+	// Webhook Routes
+	WEBHOOK      = "webhook"
+	SUBSCRIPTION = "subscription"
+	DELIVERY     = "delivery"
+	REPLAY       = "replay"
+
+	// Merchant Routes
+	MERCHANT = "merchant"
+	API_KEY  = "api-key"
+
+	// Reconciliation Routes
+	RECONCILIATION = "reconciliation"
+	JOB            = "job"
+	ROW            = "row"
+	RESOLVE        = "resolve"
+	DISMISS        = "dismiss"
+
+	// Collection Routes
+	COLLECTION = "collection"
+	WORKLIST   = "worklist"
+	BUCKET     = "bucket"
+	CONTRACT   = "contract"
+	ASSIGNMENT = "assignment"
+	ACTIVITY   = "activity"
+
+	// Analytics Routes
+	ANALYTICS    = "analytics"
+	DISBURSEMENT = "disbursement"
+	OUTSTANDING  = "outstanding"
+	INTEREST     = "interest"
+	FUNNEL       = "funnel"
+
+	// Export Routes
+	EXPORT = "export"
+
+	// Audit Routes
+	AUDIT = "audit"
 )
@@ -8,7 +8,11 @@ const (
 	//Header constants
 	AUTHORIZATION      = "Authorization"
 	BEARER             = "Bearer "
+	API_KEY            = "X-API-Key"
 	CTK_CLAIM_KEY      = CONTEXT_KEY("claims")
+	CTK_SIGNATURE_KEY  = CONTEXT_KEY("signature_key")
+	CTK_AUDIT_KEY      = CONTEXT_KEY("audit")
+	CTK_ACCESS_KEY     = CONTEXT_KEY("access")
 	CORRELATION_KEY_ID = CORRELATION_KEY("X-Correlation-ID")
 	DEFAULT_ID         = 1
 	STATUS_CODE        = "status_code"
@@ -17,6 +17,9 @@ const (
 	OPERATION_NOT_SUPPORTED = "Operation not supported This feature is not available"
 	DUPLICATE_ENTRY         = "The data you're trying to add already exists in our records"
 	CONFLICT                = "There is a conflict with the current state of the resource."
+	TOO_MANY_ATTEMPTS       = "Too many failed attempts Please try again later"
+	TOO_MANY_REQUESTS       = "Too many requests Please try again later"
+	MFA_REQUIRED            = "Multi-factor authentication required Please enroll an authenticator and sign in again"
 
 	FOREIGN_KEY_CONSTRAINT_VIOLATION = "Foreign key constraint violation"
 )
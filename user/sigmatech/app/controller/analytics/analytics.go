@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	analyticsDB "user/sigmatech/app/db/repository/analytics"
+	"user/sigmatech/app/service/correlation"
+	analyticsRequest "user/sigmatech/app/service/dto/request/analytics"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IAnalyticsController is an interface that defines the methods for an analytics controller.
+type IAnalyticsController interface {
+	GetDisbursements(c *gin.Context)
+	GetOutstanding(c *gin.Context)
+	GetInterestEarned(c *gin.Context)
+	GetConversionFunnel(c *gin.Context)
+}
+
+// AnalyticsController is a struct that implements the IAnalyticsController interface.
+type AnalyticsController struct {
+	AnalyticsDBClient analyticsDB.IAnalyticsRepository
+}
+
+// NewAnalyticsController is a constructor function that creates a new AnalyticsController.
+func NewAnalyticsController(
+	AnalyticsDBClient analyticsDB.IAnalyticsRepository,
+) IAnalyticsController {
+	return &AnalyticsController{
+		AnalyticsDBClient: AnalyticsDBClient,
+	}
+}
+
+// series is the response of every analytics endpoint
+type series struct {
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	GroupBy string      `json:"group_by"`
+	Data    interface{} `json:"data"`
+}
+
+// GetDisbursements returns the OTR disbursed per period
+func (u AnalyticsController) GetDisbursements(c *gin.Context) {
+	r, ok := bindRange(c)
+	if !ok {
+		return
+	}
+
+	data, err := u.AnalyticsDBClient.GetDisbursements(correlation.WithReqContext(c), r)
+	respond(c, r, data, err)
+}
+
+// GetOutstanding returns the outstanding principal at the end of each period with its NPL ratio by DPD bucket
+func (u AnalyticsController) GetOutstanding(c *gin.Context) {
+	r, ok := bindRange(c)
+	if !ok {
+		return
+	}
+
+	data, err := u.AnalyticsDBClient.GetOutstanding(correlation.WithReqContext(c), r)
+	respond(c, r, data, err)
+}
+
+// GetInterestEarned returns the interest collected per period
+func (u AnalyticsController) GetInterestEarned(c *gin.Context) {
+	r, ok := bindRange(c)
+	if !ok {
+		return
+	}
+
+	data, err := u.AnalyticsDBClient.GetInterestEarned(correlation.WithReqContext(c), r)
+	respond(c, r, data, err)
+}
+
+// GetConversionFunnel returns the sign-up to approval to first transaction conversion per sign-up period
+func (u AnalyticsController) GetConversionFunnel(c *gin.Context) {
+	r, ok := bindRange(c)
+	if !ok {
+		return
+	}
+
+	data, err := u.AnalyticsDBClient.GetConversionFunnel(correlation.WithReqContext(c), r)
+	respond(c, r, data, err)
+}
+
+// bindRange reads the from, to and group_by query parameters, it responds and returns false when they are invalid
+func bindRange(c *gin.Context) (analyticsRequest.Range, bool) {
+	log := logger.Logger(correlation.WithReqContext(c))
+
+	var r analyticsRequest.Range
+
+	if err := c.ShouldBindQuery(&r); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return r, false
+	}
+
+	if err := r.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return r, false
+	}
+
+	return r, true
+}
+
+func respond(c *gin.Context, r analyticsRequest.Range, data interface{}, err error) {
+	log := logger.Logger(correlation.WithReqContext(c))
+
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, series{
+		From:    r.GetFrom().Format(analyticsRequest.DATE_FORMAT),
+		To:      r.GetTo().Format(analyticsRequest.DATE_FORMAT),
+		GroupBy: r.GroupBy,
+		Data:    data,
+	})
+}
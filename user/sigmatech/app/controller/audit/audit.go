@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	auditLogs_DBModels "user/sigmatech/app/db/dto/audit_logs"
+	auditLogDB "user/sigmatech/app/db/repository/audit_log"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IAuditController is an interface that defines the methods for an audit controller.
+type IAuditController interface {
+	GetAuditLogs(c *gin.Context)
+	GetAuditLog(c *gin.Context)
+}
+
+// AuditController is a struct that implements the IAuditController interface.
+type AuditController struct {
+	AuditLogDBClient auditLogDB.IAuditLogRepository
+}
+
+// NewAuditController is a constructor function that creates a new AuditController.
+func NewAuditController(
+	AuditLogDBClient auditLogDB.IAuditLogRepository,
+) IAuditController {
+	return &AuditController{
+		AuditLogDBClient: AuditLogDBClient,
+	}
+}
+
+// GetAuditLogs lists the audit logs, newest first. They can be filtered on any column, e.g. actor_uuid,
+// entity and entity_id, or created_at with a date range, and searched by action, entity, entity id and actor email.
+func (u AuditController) GetAuditLogs(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, auditLogs_DBModels.AuditLog{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	auditLogs, paginationResponse, err := u.AuditLogDBClient.GetAuditLogs(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, auditLogs, paginationResponse)
+}
+
+func (u AuditController) GetAuditLog(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	filter := where.Eq(auditLogs_DBModels.COLUM_UUID, id)
+
+	r, err := u.AuditLogDBClient.GetAuditLog(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Audit log not found", err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
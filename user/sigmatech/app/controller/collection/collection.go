@@ -0,0 +1,424 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	collectionActivities_DBModels "user/sigmatech/app/db/dto/collection_activities"
+	collectionAssignments_DBModels "user/sigmatech/app/db/dto/collection_assignments"
+	transactionDelinquencies_DBModels "user/sigmatech/app/db/dto/transaction_delinquencies"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	collectionActivityDB "user/sigmatech/app/db/repository/collection_activity"
+	collectionAssignmentDB "user/sigmatech/app/db/repository/collection_assignment"
+	transactionDB "user/sigmatech/app/db/repository/transaction"
+	transactionDelinquencyDB "user/sigmatech/app/db/repository/transaction_delinquency"
+	userDB "user/sigmatech/app/db/repository/user"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	collectionRequest "user/sigmatech/app/service/dto/request/collection"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Values of the agent_uuid query parameter that don't hold an agent uuid
+const (
+	AGENT_ME         = "me"
+	AGENT_UNASSIGNED = "unassigned"
+)
+
+// ICollectionController is an interface that defines the methods for a collection controller.
+type ICollectionController interface {
+	GetWorklist(c *gin.Context)
+	GetBuckets(c *gin.Context)
+
+	AssignAgent(c *gin.Context)
+	UnassignAgent(c *gin.Context)
+
+	GetActivities(c *gin.Context)
+	CreateActivity(c *gin.Context)
+}
+
+// CollectionController is a struct that implements the ICollectionController interface.
+type CollectionController struct {
+	UserDBClient                   userDB.IUserRepository
+	TransactionDBClient            transactionDB.ITransactionRepository
+	TransactionDelinquencyDBClient transactionDelinquencyDB.ITransactionDelinquencyRepository
+	CollectionAssignmentDBClient   collectionAssignmentDB.ICollectionAssignmentRepository
+	CollectionActivityDBClient     collectionActivityDB.ICollectionActivityRepository
+}
+
+// NewCollectionController is a constructor function that creates a new CollectionController.
+func NewCollectionController(
+	UserDBClient userDB.IUserRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	TransactionDelinquencyDBClient transactionDelinquencyDB.ITransactionDelinquencyRepository,
+	CollectionAssignmentDBClient collectionAssignmentDB.ICollectionAssignmentRepository,
+	CollectionActivityDBClient collectionActivityDB.ICollectionActivityRepository,
+) ICollectionController {
+	return &CollectionController{
+		UserDBClient:                   UserDBClient,
+		TransactionDBClient:            TransactionDBClient,
+		TransactionDelinquencyDBClient: TransactionDelinquencyDBClient,
+		CollectionAssignmentDBClient:   CollectionAssignmentDBClient,
+		CollectionActivityDBClient:     CollectionActivityDBClient,
+	}
+}
+
+// GetWorklist lists the open contracts with their days past due, most overdue first. It filters on any
+// column of the worklist, on a dpd_from/dpd_to range, and agent_uuid accepts "me" and "unassigned".
+func (u CollectionController) GetWorklist(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if pagination.Order == "" {
+		pagination.Order = transactionDelinquencies_DBModels.COLUMN_DPD
+	}
+	pagination.Validate()
+
+	var worklist collectionRequest.WorklistFilter
+
+	if err := c.ShouldBindQuery(&worklist); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := worklist.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	f, err := request.ExtractFilteredQueryParams(c, transactionDelinquencies_DBModels.TransactionDelinquency{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	applyAgentFilter(c, f)
+
+	delinquencies, paginationResponse, err := u.TransactionDelinquencyDBClient.GetTransactionDelinquencies(ctx, pagination, f, worklist)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, delinquencies, paginationResponse)
+}
+
+// GetBuckets counts the open contracts and their overdue amount per days past due bucket
+func (u CollectionController) GetBuckets(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	f, err := request.ExtractFilteredQueryParams(c, transactionDelinquencies_DBModels.TransactionDelinquency{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	applyAgentFilter(c, f)
+
+	summaries, err := u.TransactionDelinquencyDBClient.GetBucketSummaries(ctx, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, summaries)
+}
+
+// AssignAgent assigns the contract to a collection agent, replacing the agent already assigned
+func (u CollectionController) AssignAgent(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	transaction, ok := u.getTransaction(c)
+	if !ok {
+		return
+	}
+
+	dataFromBody := collectionRequest.AssignRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	agent, err := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, dataFromBody.AgentUuid))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if agent.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Agent not found", nil)
+		return
+	}
+
+	filter := where.Eq(collectionAssignments_DBModels.COLUMN_TRANSACTION_UUID, transaction.Uuid)
+
+	assignment, err := u.CollectionAssignmentDBClient.GetCollectionAssignment(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if assignment.Uuid == uuid.Nil {
+		assignment = collectionAssignments_DBModels.CollectionAssignment{
+			Uuid:            uuid.New(),
+			TransactionUuid: transaction.Uuid,
+			AgentUuid:       agent.Uuid,
+			Note:            dataFromBody.Note,
+			CreatedBy:       &usr.Uuid,
+		}
+
+		if err := u.CollectionAssignmentDBClient.CreateCollectionAssignment(ctx, &assignment); err != nil {
+			if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+				controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+				return
+			}
+
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, assignment)
+		return
+	}
+
+	patch := map[string]interface{}{
+		collectionAssignments_DBModels.COLUMN_AGENT_UUID: agent.Uuid,
+		collectionAssignments_DBModels.COLUMN_NOTE:       dataFromBody.Note,
+		collectionAssignments_DBModels.COLUMN_CREATED_BY: usr.Uuid,
+		collectionAssignments_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	}
+
+	if err := u.CollectionAssignmentDBClient.UpdateCollectionAssignment(ctx, filter, patch); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	assignment.AgentUuid = agent.Uuid
+	assignment.Note = dataFromBody.Note
+	assignment.CreatedBy = &usr.Uuid
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, assignment)
+}
+
+func (u CollectionController) UnassignAgent(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	transaction, ok := u.getTransaction(c)
+	if !ok {
+		return
+	}
+
+	filter := where.Eq(collectionAssignments_DBModels.COLUMN_TRANSACTION_UUID, transaction.Uuid)
+
+	if err := u.CollectionAssignmentDBClient.DeleteCollectionAssignment(ctx, filter); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+// GetActivities returns the collection history of the contract, latest first
+func (u CollectionController) GetActivities(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if pagination.Order == "" {
+		pagination.Order = collectionActivities_DBModels.COLUMN_CONTACTED_AT
+	}
+	pagination.Validate()
+
+	transaction, ok := u.getTransaction(c)
+	if !ok {
+		return
+	}
+
+	f, err := request.ExtractFilteredQueryParams(c, collectionActivities_DBModels.CollectionActivity{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[collectionActivities_DBModels.COLUMN_TRANSACTION_UUID] = transaction.Uuid.String()
+
+	activities, paginationResponse, err := u.CollectionActivityDBClient.GetCollectionActivities(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, activities, paginationResponse)
+}
+
+// CreateActivity logs a call or visit made by the signed in agent, with its outcome and promise to pay
+func (u CollectionController) CreateActivity(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	transaction, ok := u.getTransaction(c)
+	if !ok {
+		return
+	}
+
+	dataFromBody := collectionRequest.ActivityRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	contactedAt := time.Now()
+	if dataFromBody.ContactedAt != nil {
+		contactedAt = *dataFromBody.ContactedAt
+	}
+
+	activity := collectionActivities_DBModels.CollectionActivity{
+		Uuid:               uuid.New(),
+		TransactionUuid:    transaction.Uuid,
+		AgentUuid:          &usr.Uuid,
+		Type:               dataFromBody.Type,
+		Outcome:            dataFromBody.Outcome,
+		PromiseToPayDate:   dataFromBody.GetPromiseToPayDate(),
+		PromiseToPayAmount: dataFromBody.PromiseToPayAmount,
+		Note:               dataFromBody.Note,
+		ContactedAt:        contactedAt,
+	}
+
+	if err := u.CollectionActivityDBClient.CreateCollectionActivity(ctx, &activity); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, activity)
+}
+
+// getTransaction loads the contract of the :id parameter, it responds and returns false when it can't
+func (u CollectionController) getTransaction(c *gin.Context) (transactions_DBModels.Transaction, bool) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return transactions_DBModels.Transaction{}, false
+	}
+
+	r, err := u.TransactionDBClient.GetTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, id))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return transactions_DBModels.Transaction{}, false
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Transaction not found", nil)
+		return transactions_DBModels.Transaction{}, false
+	}
+
+	return r, true
+}
+
+// applyAgentFilter resolves the "me" and "unassigned" values of the agent_uuid filter
+func applyAgentFilter(c *gin.Context, f map[string]interface{}) {
+	switch f[transactionDelinquencies_DBModels.COLUMN_AGENT_UUID] {
+	case AGENT_UNASSIGNED:
+		f[transactionDelinquencies_DBModels.COLUMN_AGENT_UUID] = nil
+	case AGENT_ME:
+		if context, exist := c.Get(constants.CTK_CLAIM_KEY.String()); exist {
+			f[transactionDelinquencies_DBModels.COLUMN_AGENT_UUID] = context.(*users_DBModels.User).Uuid.String()
+		}
+	}
+}
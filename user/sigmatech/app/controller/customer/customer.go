@@ -1,26 +1,40 @@
 package customer
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"time"
+	"user/sigmatech/app/api/middleware/jwt"
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
+	exportController "user/sigmatech/app/controller/export"
 	cif_DBModels "user/sigmatech/app/db/dto/customer_information_files"
 	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
 	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
 	customerDB "user/sigmatech/app/db/repository/customer"
 	cifDB "user/sigmatech/app/db/repository/customer_information_file"
 	customerLimitDB "user/sigmatech/app/db/repository/customer_limit"
+	transactionDB "user/sigmatech/app/db/repository/transaction"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/audit"
+	"user/sigmatech/app/service/emailverification"
 
 	"encoding/json"
 	"fmt"
 
 	"net/http"
+	"strings"
 	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/customerimport"
 	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/export"
+	"user/sigmatech/app/service/lockout"
 	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/notification"
 	"user/sigmatech/app/service/util"
+	"user/sigmatech/app/service/webhook"
 
 	reqCustomer "user/sigmatech/app/service/dto/request/customer"
 
@@ -37,6 +51,9 @@ type ICustomerController interface {
 	UpdateCustomerPassword(c *gin.Context)
 	DeleteCustomer(c *gin.Context)
 	DeleteCustomers(c *gin.Context)
+	RestoreCustomer(c *gin.Context)
+	UnlockCustomer(c *gin.Context)
+	ImportCustomers(c *gin.Context)
 
 	GetCustomerLimits(c *gin.Context)
 	ApproveCustomer(c *gin.Context)
@@ -47,6 +64,20 @@ type CustomerController struct {
 	CustomerDBClient      customerDB.ICustomerRepository // customerDB represents the database client for crm-user-related operations.
 	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository
 	CifDBClient           cifDB.ICustomerInformationFileRepository
+	TransactionDBClient   transactionDB.ITransactionRepository
+
+	Notification notification.INotificationService
+	Webhook      webhook.IWebhookService
+	Export       export.IExportService
+
+	CustomerImport    customerimport.ICustomerImportService
+	Lockout           lockout.ILockoutService
+	EmailVerification emailverification.IEmailVerificationService
+
+	// CustomerSessions and CustomerPrincipals end what the customer service would still accept of a
+	// customer that was changed, deactivated or deleted here
+	CustomerSessions   jwt.IRefreshTokenStore
+	CustomerPrincipals jwt.ICustomerPrincipals
 }
 
 // NewCustomerController is a constructor function that creates a new CustomerController.
@@ -54,11 +85,29 @@ func NewCustomerController(
 	CustomerDBClient customerDB.ICustomerRepository,
 	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
 	CifDBClient cifDB.ICustomerInformationFileRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	Notification notification.INotificationService,
+	Webhook webhook.IWebhookService,
+	Export export.IExportService,
+	CustomerImport customerimport.ICustomerImportService,
+	Lockout lockout.ILockoutService,
+	EmailVerification emailverification.IEmailVerificationService,
+	CustomerSessions jwt.IRefreshTokenStore,
+	CustomerPrincipals jwt.ICustomerPrincipals,
 ) ICustomerController {
 	return &CustomerController{
 		CustomerDBClient:      CustomerDBClient,
 		CustomerLimitDBClient: CustomerLimitDBClient,
 		CifDBClient:           CifDBClient,
+		TransactionDBClient:   TransactionDBClient,
+		Notification:          Notification,
+		Webhook:               Webhook,
+		Export:                Export,
+		CustomerImport:        CustomerImport,
+		Lockout:               Lockout,
+		EmailVerification:     EmailVerification,
+		CustomerSessions:      CustomerSessions,
+		CustomerPrincipals:    CustomerPrincipals,
 	}
 }
 
@@ -77,7 +126,18 @@ func (u CustomerController) GetCustomers(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, customers_DBModels.Customer{})
+	f, err := request.ExtractFilteredQueryParams(c, customers_DBModels.Customer{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if exportController.IsExport(c) {
+		exportController.RespondWithExport(c, u.Export, export.DATASET_CUSTOMERS, f, pagination)
+		return
+	}
 
 	customers, paginationResponse, err := u.CustomerDBClient.GetCustomers(ctx, pagination, f)
 	if err != nil {
@@ -99,9 +159,7 @@ func (u CustomerController) GetCustomer(c *gin.Context) {
 	log := logger.Logger(ctx)
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(customers_DBModels.COLUM_UUID, id)
 
 	r, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -120,7 +178,7 @@ func (u CustomerController) GetCustomer(c *gin.Context) {
 
 	r.Password = ""
 
-	fCIF := fmt.Sprintf("%s='%s'", cif_DBModels.COLUMN_CUSTOMER_UUID, r.Uuid)
+	fCIF := where.Eq(cif_DBModels.COLUMN_CUSTOMER_UUID, r.Uuid)
 
 	cif, err := u.CifDBClient.GetCustomerInformationFile(ctx, fCIF)
 	if err != nil {
@@ -146,9 +204,7 @@ func (u CustomerController) UpdateCustomer(c *gin.Context) {
 	log := logger.Logger(ctx)
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(customers_DBModels.COLUM_UUID, id)
 
 	r, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -164,6 +220,7 @@ func (u CustomerController) UpdateCustomer(c *gin.Context) {
 		controller.RespondWithError(c, http.StatusInternalServerError, "Customer not found", err)
 		return
 	}
+	audit.SetBefore(c, r)
 
 	dataFromBody := customers_DBModels.Customer{}
 	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
@@ -180,9 +237,14 @@ func (u CustomerController) UpdateCustomer(c *gin.Context) {
 		patcher[customers_DBModels.COLUMN_NAME] = dataFromBody.Name
 	}
 
+	// A new email has to be verified again before the customer can be approved
+	emailChanged := dataFromBody.Email != "" && !strings.EqualFold(dataFromBody.Email, r.Email)
 	if dataFromBody.Email != "" {
 		patcher[customers_DBModels.COLUMN_EMAIL] = dataFromBody.Email
 	}
+	if emailChanged {
+		patcher[customers_DBModels.COLUMN_EMAIL_VERIFIED_AT] = nil
+	}
 
 	if dataFromBody.IsActive != nil {
 		patcher[customers_DBModels.COLUMN_IS_ACTIVE] = dataFromBody.IsActive
@@ -201,9 +263,7 @@ func (u CustomerController) UpdateCustomer(c *gin.Context) {
 
 	patcher[customers_DBModels.COLUMN_UPDATED_AT] = time.Now()
 
-	filter = fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, c.Param("id"),
-	)
+	filter = where.Eq(customers_DBModels.COLUM_UUID, c.Param("id"))
 
 	if err := u.CustomerDBClient.UpdateCustomer(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -217,9 +277,21 @@ func (u CustomerController) UpdateCustomer(c *gin.Context) {
 		return
 	}
 
-	r, _ = u.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, c.Param("id"),
-	))
+	if dataFromBody.IsActive != nil && !*dataFromBody.IsActive {
+		u.signOut(ctx, r.Uuid)
+	} else if err := u.CustomerPrincipals.Invalidate(ctx, r.Uuid); err != nil {
+		log.Errorf("Error invalidating the cached customer %s: %v", r.Uuid, err)
+	}
+
+	r, _ = u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, c.Param("id")))
+	audit.SetAfter(c, r)
+
+	// The customer can ask for another token, a failed email must not fail the update
+	if emailChanged {
+		if err := u.EmailVerification.Send(ctx, r); err != nil {
+			log.Errorf("Error sending the email verification of customer %s: %v", r.Uuid, err)
+		}
+	}
 
 	r.Password = ""
 
@@ -240,9 +312,7 @@ func (u CustomerController) UpdateCustomerPassword(c *gin.Context) {
 	}
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(customers_DBModels.COLUM_UUID, id)
 
 	r, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -258,6 +328,7 @@ func (u CustomerController) UpdateCustomerPassword(c *gin.Context) {
 		controller.RespondWithError(c, http.StatusInternalServerError, "Customer not found", err)
 		return
 	}
+	audit.SetBefore(c, r)
 
 	var patcher = make(map[string]interface{})
 
@@ -278,9 +349,7 @@ func (u CustomerController) UpdateCustomerPassword(c *gin.Context) {
 	patcher[customers_DBModels.COLUMN_PASSWORD] = hashedPassword
 	patcher[customers_DBModels.COLUMN_UPDATED_AT] = time.Now()
 
-	filter = fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, c.Param("id"),
-	)
+	filter = where.Eq(customers_DBModels.COLUM_UUID, c.Param("id"))
 
 	if err := u.CustomerDBClient.UpdateCustomer(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -294,23 +363,23 @@ func (u CustomerController) UpdateCustomerPassword(c *gin.Context) {
 		return
 	}
 
-	r, _ = u.CustomerDBClient.GetCustomer(ctx, fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, c.Param("id"),
-	))
+	r, _ = u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, c.Param("id")))
+	audit.SetAfter(c, r)
 
 	r.Password = ""
 
 	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
 }
 
+// ErrOpenContracts is returned when deleting a customer whose contracts aren't all done.
+var ErrOpenContracts = errors.New("customer has open contracts")
+
 func (u CustomerController) DeleteCustomer(c *gin.Context) {
 	ctx := correlation.WithReqContext(c)
 
 	id := c.Param("id")
 
-	filter := fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(customers_DBModels.COLUM_UUID, id)
 
 	r, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -323,10 +392,18 @@ func (u CustomerController) DeleteCustomer(c *gin.Context) {
 		return
 	}
 
+	if err := u.checkDeletable(ctx, r.Uuid); err != nil {
+		respondWithDeleteError(c, err)
+		return
+	}
+
+	audit.SetBefore(c, r)
+
 	if err := u.CustomerDBClient.DeleteCustomer(ctx, filter); err != nil {
 		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
 		return
 	}
+	u.signOut(ctx, r.Uuid)
 
 	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
 }
@@ -347,19 +424,142 @@ func (u CustomerController) DeleteCustomers(c *gin.Context) {
 		return
 	}
 
+	// Every customer is checked before any is deleted, so the list is deleted entirely or not at all
 	for _, id := range IDs {
-		filter := fmt.Sprintf("%s='%s'", customers_DBModels.COLUM_UUID, id)
+		customerUuid, err := uuid.Parse(id)
+		if err != nil {
+			controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, fmt.Errorf("invalid ID %s: %v", id, err))
+			return
+		}
 
-		if err := u.CustomerDBClient.DeleteCustomer(ctx, filter); err != nil {
-			log.Errorf("Error deleting user with ID %d: %s", id, err.Error())
-			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		if err := u.checkDeletable(ctx, customerUuid); err != nil {
+			respondWithDeleteError(c, err)
 			return
 		}
 	}
 
+	audit.SetTarget(c, "customer", strings.Join(IDs, ","))
+
+	// A single statement deletes them all, a failure leaves every customer in place
+	if err := u.CustomerDBClient.DeleteCustomer(ctx, where.In(customers_DBModels.COLUM_UUID, IDs)); err != nil {
+		log.Errorf("Error deleting customers %s: %v", strings.Join(IDs, ","), err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	for _, id := range IDs {
+		u.signOut(ctx, uuid.MustParse(id))
+	}
+
 	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
 }
 
+// RestoreCustomer undoes the deletion of a customer that wasn't purged yet.
+func (u CustomerController) RestoreCustomer(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	filter := where.Eq(customers_DBModels.COLUM_UUID, c.Param("id"))
+
+	r, err := u.CustomerDBClient.GetDeletedCustomer(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Deleted customer not found", nil)
+		return
+	}
+	audit.SetBefore(c, r)
+
+	if err := u.CustomerDBClient.RestoreCustomer(ctx, filter); err != nil {
+		// Another customer may have signed up with the email since
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	r, _ = u.CustomerDBClient.GetCustomer(ctx, filter)
+	audit.SetAfter(c, r)
+
+	r.Password = ""
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+// UnlockCustomer lifts the sign-in lockout of a customer, lockouts of the IPs they signed in from are kept.
+func (u CustomerController) UnlockCustomer(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	r, err := u.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, c.Param("id")))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Customer not found", nil)
+		return
+	}
+
+	if err := u.Lockout.Unlock(ctx, r.Email); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, nil)
+}
+
+// signOut revokes the sessions of a deactivated or deleted customer, so the customer service refuses its
+// tokens right away instead of once the cached customer expires. Failures are only logged, the change
+// itself went through.
+func (u CustomerController) signOut(ctx context.Context, customerUuid uuid.UUID) {
+	log := logger.Logger(ctx)
+
+	if err := u.CustomerSessions.RevokeAll(ctx, customerUuid); err != nil {
+		log.Errorf("Error revoking the sessions of customer %s: %v", customerUuid, err)
+	}
+	if err := u.CustomerPrincipals.Invalidate(ctx, customerUuid); err != nil {
+		log.Errorf("Error invalidating the cached customer %s: %v", customerUuid, err)
+	}
+}
+
+// checkDeletable returns ErrOpenContracts when the customer has a contract that isn't done.
+func (u CustomerController) checkDeletable(ctx context.Context, customerUuid uuid.UUID) error {
+	open, err := u.TransactionDBClient.GetTransaction(ctx, where.Eq(transactions_DBModels.COLUMN_CUSTOMER_UUID, customerUuid).IsNotTrue(transactions_DBModels.COLUMN_IS_DONE))
+	if err != nil {
+		return err
+	}
+
+	if open.Uuid != uuid.Nil {
+		return fmt.Errorf("%w: customer %s, contract %s", ErrOpenContracts, customerUuid, open.ContractNumber)
+	}
+	return nil
+}
+
+func respondWithDeleteError(c *gin.Context, err error) {
+	if errors.Is(err, ErrOpenContracts) {
+		controller.RespondWithError(c, http.StatusConflict, "Customer has open contracts", err)
+		return
+	}
+
+	logger.Logger(correlation.WithReqContext(c)).Errorf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+	controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+}
+
 func (u CustomerController) GetCustomersDetail(c *gin.Context) {
 	ctx := correlation.WithReqContext(c)
 	log := logger.Logger(ctx)
@@ -380,7 +580,18 @@ func (u CustomerController) GetCustomersDetail(c *gin.Context) {
 		CIF      cif_DBModels.CustomerInformationFile `json:"cif"`
 	}
 
-	f := request.ExtractFilteredQueryParams(c, customers_DBModels.Customer{})
+	f, err := request.ExtractFilteredQueryParams(c, customers_DBModels.Customer{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if exportController.IsExport(c) {
+		exportController.RespondWithExport(c, u.Export, export.DATASET_CUSTOMER_DETAILS, f, pagination)
+		return
+	}
 
 	customers, paginationResponse, err := u.CustomerDBClient.GetCustomers(ctx, pagination, f)
 	if err != nil {
@@ -400,7 +611,7 @@ func (u CustomerController) GetCustomersDetail(c *gin.Context) {
 
 		customerData.Customer = *v
 
-		fCIF := fmt.Sprintf("%s='%s'", cif_DBModels.COLUMN_CUSTOMER_UUID, v.Uuid)
+		fCIF := where.Eq(cif_DBModels.COLUMN_CUSTOMER_UUID, v.Uuid)
 
 		cif, err := u.CifDBClient.GetCustomerInformationFile(ctx, fCIF)
 		if err != nil {
@@ -435,7 +646,13 @@ func (u CustomerController) GetCustomerLimits(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	f, err := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[customerLimits_DBModels.COLUMN_CUSTOMER_UUID] = id
 
 	customerLimits, paginationResponse, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, pagination, f)
@@ -469,9 +686,7 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 		return
 	}
 
-	filter := fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, dataFromBody.CustomerUuid,
-	)
+	filter := where.Eq(customers_DBModels.COLUM_UUID, dataFromBody.CustomerUuid)
 
 	r, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 	if err != nil {
@@ -495,6 +710,32 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 		return
 	}
 
+	if r.EmailVerifiedAt == nil {
+		if !dataFromBody.OverrideEmailVerification {
+			controller.RespondWithError(c, http.StatusConflict, "Customer email not verified", errors.New("customer email not verified"))
+			return
+		}
+		log.Warnf("Approving customer %s with an unverified email", r.Uuid)
+	}
+
+	limitsFilter := map[string]interface{}{customerLimits_DBModels.COLUMN_CUSTOMER_UUID: r.Uuid.String()}
+	limitsPagination := request.Pagination{GetAllData: true}
+	limitsPagination.Validate()
+
+	limitsBefore, _, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, limitsPagination, limitsFilter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	audit.SetTarget(c, "customer", r.Uuid.String())
+	audit.SetBefore(c, map[string]interface{}{
+		customers_DBModels.COLUMN_IS_ACTIVE: r.IsActive,
+		customerLimits_DBModels.TABLE_NAME:  limitsBefore,
+	})
+
 	for _, v := range dataFromBody.CustomerLimits {
 		var patcher = make(map[string]interface{})
 
@@ -503,9 +744,7 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 		patcher[customerLimits_DBModels.COLUMN_STATUS] = true
 		patcher[customerLimits_DBModels.COLUMN_UPDATED_AT] = time.Now()
 
-		filter = fmt.Sprintf("%s='%s'",
-			customerLimits_DBModels.COLUM_UUID, v.Uuid,
-		)
+		filter = where.Eq(customerLimits_DBModels.COLUM_UUID, v.Uuid)
 
 		if err := u.CustomerLimitDBClient.UpdateCustomerLimit(ctx, filter, patcher); err != nil {
 			if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -525,9 +764,7 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 	patcher[customers_DBModels.COLUMN_IS_ACTIVE] = true
 	patcher[customers_DBModels.COLUMN_UPDATED_AT] = time.Now()
 
-	filter = fmt.Sprintf("%s='%s'",
-		customers_DBModels.COLUM_UUID, dataFromBody.CustomerUuid,
-	)
+	filter = where.Eq(customers_DBModels.COLUM_UUID, dataFromBody.CustomerUuid)
 
 	if err := u.CustomerDBClient.UpdateCustomer(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -545,7 +782,13 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 	pagination.GetAllData = true
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	f, err := request.ExtractFilteredQueryParams(c, customerLimits_DBModels.CustomerLimit{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[customerLimits_DBModels.COLUMN_CUSTOMER_UUID] = dataFromBody.CustomerUuid.String()
 
 	customerLimits, _, err := u.CustomerLimitDBClient.GetCustomerLimits(ctx, pagination, f)
@@ -556,6 +799,32 @@ func (u CustomerController) ApproveCustomer(c *gin.Context) {
 		return
 	}
 
+	audit.SetAfter(c, map[string]interface{}{
+		customers_DBModels.COLUMN_IS_ACTIVE: true,
+		customerLimits_DBModels.TABLE_NAME:  customerLimits,
+		"override_email_verification":       r.EmailVerifiedAt == nil,
+	})
+
+	// Let the customer know the application went through, a failed notification must not fail the approval
+	if err := u.Notification.Notify(ctx, notification.Recipient{
+		CustomerUuid: r.Uuid,
+		Name:         r.Name,
+		Email:        r.Email,
+	}, notification.Event{
+		Type:          notification.EventCustomerApproved,
+		ReferenceUuid: &r.Uuid,
+	}); err != nil {
+		log.Errorf("Error sending approval notification to customer %s: %v", r.Uuid, err)
+	}
+
+	// Queue the event for subscribed partners, a failure is logged only like the notification above
+	if err := u.Webhook.Publish(ctx, webhook.EventCustomerApproved, &r.Uuid, map[string]interface{}{
+		"customer_uuid":   r.Uuid,
+		"customer_limits": customerLimits,
+	}); err != nil {
+		log.Errorf("Error publishing approval webhook for customer %s: %v", r.Uuid, err)
+	}
+
 	customerDatas := struct {
 		Customer       customers_DBModels.Customer              `json:"customer"`
 		CustomerLimits []*customerLimits_DBModels.CustomerLimit `json:"customer_limits"`
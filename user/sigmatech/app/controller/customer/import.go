@@ -0,0 +1,98 @@
+package customer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	"user/sigmatech/app/service/audit"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/customerimport"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportCustomers onboards the customers of the CSV file in the "file" form field and responds with a
+// result per line. With the "dry_run" form field set to true the lines are only validated.
+func (u CustomerController) ImportCustomers(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	dryRun := false
+	if v := c.PostForm("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+			return
+		}
+		dryRun = parsed
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if file.Size > constants.Config.CustomerImportConfig.CUSTOMER_IMPORT_MAX_FILE_SIZE {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %s", constants.BAD_REQUEST, "File is too large"), nil)
+		return
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	defer fileReader.Close()
+
+	data, err := io.ReadAll(fileReader)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	report, err := u.CustomerImport.Import(ctx, data, dryRun)
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.Is(err, customerimport.ErrEmptyFile) || errors.Is(err, customerimport.ErrMissingColumn) ||
+			errors.Is(err, customerimport.ErrUnknownColumn) || errors.Is(err, customerimport.ErrTooManyRows) || errors.As(err, &parseErr) {
+			errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	audit.SetAfter(c, map[string]interface{}{
+		"dry_run": report.DryRun,
+		"total":   report.Total,
+		"created": report.Created,
+		"failed":  report.Failed,
+	})
+
+	if dryRun {
+		controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, report)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, report)
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	exportJobs_DBModels "user/sigmatech/app/db/dto/export_jobs"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	exportJobDB "user/sigmatech/app/db/repository/export_job"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	exportRequest "user/sigmatech/app/service/dto/request/export"
+	"user/sigmatech/app/service/export"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IExportController is an interface that defines the methods for an export controller.
+type IExportController interface {
+	GetJobs(c *gin.Context)
+	GetJob(c *gin.Context)
+}
+
+// ExportController is a struct that implements the IExportController interface.
+type ExportController struct {
+	ExportJobDBClient exportJobDB.IExportJobRepository
+	Export            export.IExportService
+}
+
+// NewExportController is a constructor function that creates a new ExportController.
+func NewExportController(
+	ExportJobDBClient exportJobDB.IExportJobRepository,
+	Export export.IExportService,
+) IExportController {
+	return &ExportController{
+		ExportJobDBClient: ExportJobDBClient,
+		Export:            Export,
+	}
+}
+
+// IsExport reports whether a list endpoint is called in export mode.
+func IsExport(c *gin.Context) bool {
+	return c.Query("export") != ""
+}
+
+// RespondWithExport answers a list endpoint called in export mode. Exports up to EXPORT_MAX_SYNC_ROWS rows
+// are streamed in the response, larger ones become a job whose file is uploaded to S3.
+func RespondWithExport(c *gin.Context, exportService export.IExportService, datasetName string, filter map[string]interface{}, pagination request.Pagination) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var r exportRequest.Request
+
+	if err := c.ShouldBindQuery(&r); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if !util.ContainsElement(r.Format, export.Formats) {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v: %s", constants.BAD_REQUEST, export.ErrUnsupportedFormat, r.Format), nil)
+		return
+	}
+
+	dataset, err := export.GetDataset(datasetName)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	columns, err := dataset.SelectColumns(r.Columns)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	q, total, err := exportService.Prepare(ctx, dataset, columns, filter, pagination)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if total > constants.Config.ExportConfig.EXPORT_MAX_SYNC_ROWS {
+		var createdBy *uuid.UUID
+		if context, exist := c.Get(constants.CTK_CLAIM_KEY.String()); exist {
+			createdBy = &context.(*users_DBModels.User).Uuid
+		}
+
+		job, err := exportService.Enqueue(ctx, dataset, r.Format, columns, q, createdBy)
+		if err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		controller.RespondWithSuccess(c, http.StatusAccepted, constants.CREATED_SUCCESSFULLY, job)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s_%s.%s", dataset.Name, time.Now().Format("20060102_150405"), r.Format)
+	c.Header("Content-Type", export.ContentType(r.Format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Status(http.StatusOK)
+
+	// The status is sent with the first bytes, an error past this point can only be logged
+	if _, err := exportService.Write(ctx, c.Writer, r.Format, columns, q); err != nil {
+		log.Errorf("export of %s failed: %v", dataset.Name, err)
+	}
+}
+
+func (u ExportController) GetJobs(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, exportJobs_DBModels.ExportJob{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	jobs, paginationResponse, err := u.ExportJobDBClient.GetExportJobs(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, jobs, paginationResponse)
+}
+
+// GetJob returns the status of an export and, once it is completed, a temporary download link
+func (u ExportController) GetJob(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(exportJobs_DBModels.COLUM_UUID, id)
+
+	r, err := u.ExportJobDBClient.GetExportJob(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, export.ErrExportJobNotFound.Error(), nil)
+		return
+	}
+
+	var jobData struct {
+		exportJobs_DBModels.ExportJob
+		DownloadUrl *string `json:"download_url"`
+	}
+	jobData.ExportJob = r
+
+	url, err := u.Export.DownloadUrl(ctx, r)
+	if err != nil && !errors.Is(err, export.ErrExportNotCompleted) {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	if err == nil {
+		jobData.DownloadUrl = &url
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, jobData)
+}
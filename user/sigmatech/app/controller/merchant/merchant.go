@@ -0,0 +1,456 @@
+package merchant
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	merchantApiKeys_DBModels "user/sigmatech/app/db/dto/merchant_api_keys"
+	merchants_DBModels "user/sigmatech/app/db/dto/merchants"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	merchantDB "user/sigmatech/app/db/repository/merchant"
+	merchantApiKeyDB "user/sigmatech/app/db/repository/merchant_api_key"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/apikey"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IMerchantController is an interface that defines the methods for a merchant controller.
+type IMerchantController interface {
+	GetMerchants(c *gin.Context)
+	GetMerchant(c *gin.Context)
+	CreateMerchant(c *gin.Context)
+	UpdateMerchant(c *gin.Context)
+	DeleteMerchant(c *gin.Context)
+
+	GetApiKeys(c *gin.Context)
+	CreateApiKey(c *gin.Context)
+	RevokeApiKey(c *gin.Context)
+}
+
+// MerchantController is a struct that implements the IMerchantController interface.
+type MerchantController struct {
+	MerchantDBClient       merchantDB.IMerchantRepository
+	MerchantApiKeyDBClient merchantApiKeyDB.IMerchantApiKeyRepository
+}
+
+// NewMerchantController is a constructor function that creates a new MerchantController.
+func NewMerchantController(
+	MerchantDBClient merchantDB.IMerchantRepository,
+	MerchantApiKeyDBClient merchantApiKeyDB.IMerchantApiKeyRepository,
+) IMerchantController {
+	return &MerchantController{
+		MerchantDBClient:       MerchantDBClient,
+		MerchantApiKeyDBClient: MerchantApiKeyDBClient,
+	}
+}
+
+func (u MerchantController) GetMerchants(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, merchants_DBModels.Merchant{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	merchants, paginationResponse, err := u.MerchantDBClient.GetMerchants(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, merchants, paginationResponse)
+}
+
+func (u MerchantController) GetMerchant(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(merchants_DBModels.COLUM_UUID, id)
+
+	r, err := u.MerchantDBClient.GetMerchant(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Merchant not found", err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
+
+func (u MerchantController) CreateMerchant(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	dataFromBody := merchants_DBModels.Merchant{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	isActive := true
+	if dataFromBody.IsActive != nil {
+		isActive = *dataFromBody.IsActive
+	}
+
+	data := merchants_DBModels.Merchant{
+		Uuid:      uuid.New(),
+		Code:      dataFromBody.Code,
+		Name:      dataFromBody.Name,
+		Email:     dataFromBody.Email,
+		IsActive:  util.Boolean(isActive),
+		CreatedAt: time.Now(),
+		CreatedBy: &usr.Uuid,
+		UpdatedAt: time.Now(),
+		UpdatedBy: nil,
+	}
+
+	if err = u.MerchantDBClient.CreateMerchant(ctx, &data); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+func (u MerchantController) UpdateMerchant(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	id := c.Param("id")
+	filter := where.Eq(merchants_DBModels.COLUM_UUID, id)
+
+	r, err := u.MerchantDBClient.GetMerchant(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Merchant not found", err)
+		return
+	}
+
+	dataFromBody := merchants_DBModels.Merchant{}
+	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	var patcher = make(map[string]interface{})
+
+	if dataFromBody.Code != "" {
+		r.Code = dataFromBody.Code
+		patcher[merchants_DBModels.COLUMN_CODE] = dataFromBody.Code
+	}
+	if dataFromBody.Name != "" {
+		r.Name = dataFromBody.Name
+		patcher[merchants_DBModels.COLUMN_NAME] = dataFromBody.Name
+	}
+	if dataFromBody.Email != nil {
+		r.Email = dataFromBody.Email
+		patcher[merchants_DBModels.COLUMN_EMAIL] = *dataFromBody.Email
+	}
+	if dataFromBody.IsActive != nil {
+		patcher[merchants_DBModels.COLUMN_IS_ACTIVE] = *dataFromBody.IsActive
+	}
+
+	if err := r.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	patcher[merchants_DBModels.COLUMN_UPDATED_AT] = time.Now()
+	patcher[merchants_DBModels.COLUMN_UPDATED_BY] = usr.Uuid
+
+	if err := u.MerchantDBClient.UpdateMerchant(ctx, filter, patcher); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	r, _ = u.MerchantDBClient.GetMerchant(ctx, filter)
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+// DeleteMerchant removes a merchant without transactions, merchants that originated contracts must be deactivated instead
+func (u MerchantController) DeleteMerchant(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+
+	id := c.Param("id")
+
+	filter := where.Eq(merchants_DBModels.COLUM_UUID, id)
+
+	r, err := u.MerchantDBClient.GetMerchant(ctx, filter)
+	if err != nil {
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Merchant not found", err)
+		return
+	}
+
+	if err := u.MerchantDBClient.DeleteMerchant(ctx, filter); err != nil {
+		if strings.Contains(err.Error(), "foreign key constraints") {
+			controller.RespondWithError(c, http.StatusConflict, "Merchant has transactions, deactivate it instead", err)
+			return
+		}
+
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+func (u MerchantController) GetApiKeys(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, merchantApiKeys_DBModels.MerchantApiKey{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[merchantApiKeys_DBModels.COLUMN_MERCHANT_UUID] = c.Param("id")
+
+	apiKeys, paginationResponse, err := u.MerchantApiKeyDBClient.GetMerchantApiKeys(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, apiKeys, paginationResponse)
+}
+
+// CreateApiKey issues a key for the merchant. The key is only part of this response, only its hash is stored
+func (u MerchantController) CreateApiKey(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	merchant, err := u.MerchantDBClient.GetMerchant(ctx, where.Eq(merchants_DBModels.COLUM_UUID, c.Param("id")))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if merchant.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Merchant not found", err)
+		return
+	}
+
+	dataFromBody := merchantApiKeys_DBModels.MerchantApiKey{}
+	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	scopes := dataFromBody.GetScopes()
+	for _, scope := range scopes {
+		if !apikey.IsValidScope(scope) {
+			errorMsg := fmt.Sprintf("%s: unsupported scope %s", constants.BAD_REQUEST, scope)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusBadRequest, errorMsg, nil)
+			return
+		}
+	}
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	data := merchantApiKeys_DBModels.MerchantApiKey{
+		Uuid:         uuid.New(),
+		MerchantUuid: merchant.Uuid,
+		Name:         dataFromBody.Name,
+		Prefix:       prefix,
+		KeyHash:      hash,
+		Scopes:       strings.Join(scopes, ","),
+		ExpiresAt:    dataFromBody.ExpiresAt,
+		CreatedAt:    time.Now(),
+		CreatedBy:    &usr.Uuid,
+		UpdatedAt:    time.Now(),
+	}
+
+	if err = u.MerchantApiKeyDBClient.CreateMerchantApiKey(ctx, &data); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, struct {
+		merchantApiKeys_DBModels.MerchantApiKey
+		Key string `json:"key"`
+	}{
+		MerchantApiKey: data,
+		Key:            key,
+	})
+}
+
+// RevokeApiKey stops a key from authenticating, the row is kept for the audit of past calls
+func (u MerchantController) RevokeApiKey(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	filter := where.Eq(merchantApiKeys_DBModels.COLUM_UUID, c.Param("key_id")).Eq(merchantApiKeys_DBModels.COLUMN_MERCHANT_UUID, c.Param("id"))
+
+	r, err := u.MerchantApiKeyDBClient.GetMerchantApiKey(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Api key not found", err)
+		return
+	}
+
+	if r.RevokedAt == nil {
+		var patcher = make(map[string]interface{})
+		patcher[merchantApiKeys_DBModels.COLUMN_REVOKED_AT] = time.Now()
+		patcher[merchantApiKeys_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+		if err := u.MerchantApiKeyDBClient.UpdateMerchantApiKey(ctx, filter, patcher); err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		r, _ = u.MerchantApiKeyDBClient.GetMerchantApiKey(ctx, filter)
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
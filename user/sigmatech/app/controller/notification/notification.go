@@ -0,0 +1,297 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	notificationTemplates_DBModels "user/sigmatech/app/db/dto/notification_templates"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	notificationTemplateDB "user/sigmatech/app/db/repository/notification_template"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/notification"
+	"user/sigmatech/app/service/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// INotificationController is an interface that defines the methods for a notification controller.
+type INotificationController interface {
+	GetTemplates(c *gin.Context)
+	GetTemplate(c *gin.Context)
+	CreateTemplate(c *gin.Context)
+	UpdateTemplate(c *gin.Context)
+	DeleteTemplate(c *gin.Context)
+}
+
+// NotificationController is a struct that implements the INotificationController interface.
+type NotificationController struct {
+	NotificationTemplateDBClient notificationTemplateDB.INotificationTemplateRepository
+}
+
+// NewNotificationController is a constructor function that creates a new NotificationController.
+func NewNotificationController(
+	NotificationTemplateDBClient notificationTemplateDB.INotificationTemplateRepository,
+) INotificationController {
+	return &NotificationController{
+		NotificationTemplateDBClient: NotificationTemplateDBClient,
+	}
+}
+
+func (u NotificationController) GetTemplates(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, notificationTemplates_DBModels.NotificationTemplate{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	templates, paginationResponse, err := u.NotificationTemplateDBClient.GetNotificationTemplates(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, templates, paginationResponse)
+}
+
+func (u NotificationController) GetTemplate(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(notificationTemplates_DBModels.COLUM_UUID, id)
+
+	r, err := u.NotificationTemplateDBClient.GetNotificationTemplate(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Notification template not found", err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
+
+func (u NotificationController) CreateTemplate(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	dataFromBody := notificationTemplates_DBModels.NotificationTemplate{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := validateTemplate(dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	isActive := true
+	if dataFromBody.IsActive != nil {
+		isActive = *dataFromBody.IsActive
+	}
+
+	data := notificationTemplates_DBModels.NotificationTemplate{
+		Uuid:      uuid.New(),
+		EventType: dataFromBody.EventType,
+		Channel:   dataFromBody.Channel,
+		Locale:    dataFromBody.Locale,
+		Subject:   dataFromBody.Subject,
+		Body:      dataFromBody.Body,
+		IsActive:  util.Boolean(isActive),
+		CreatedAt: time.Now(),
+		CreatedBy: &usr.Uuid,
+		UpdatedAt: time.Now(),
+		UpdatedBy: nil,
+	}
+
+	if err = u.NotificationTemplateDBClient.CreateNotificationTemplate(ctx, &data); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+func (u NotificationController) UpdateTemplate(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	id := c.Param("id")
+	filter := where.Eq(notificationTemplates_DBModels.COLUM_UUID, id)
+
+	r, err := u.NotificationTemplateDBClient.GetNotificationTemplate(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Notification template not found", err)
+		return
+	}
+
+	dataFromBody := notificationTemplates_DBModels.NotificationTemplate{}
+	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	var patcher = make(map[string]interface{})
+
+	if dataFromBody.EventType != "" {
+		r.EventType = dataFromBody.EventType
+		patcher[notificationTemplates_DBModels.COLUMN_EVENT_TYPE] = dataFromBody.EventType
+	}
+	if dataFromBody.Channel != "" {
+		r.Channel = dataFromBody.Channel
+		patcher[notificationTemplates_DBModels.COLUMN_CHANNEL] = dataFromBody.Channel
+	}
+	if dataFromBody.Locale != "" {
+		r.Locale = dataFromBody.Locale
+		patcher[notificationTemplates_DBModels.COLUMN_LOCALE] = dataFromBody.Locale
+	}
+	if dataFromBody.Subject != "" {
+		r.Subject = dataFromBody.Subject
+		patcher[notificationTemplates_DBModels.COLUMN_SUBJECT] = dataFromBody.Subject
+	}
+	if dataFromBody.Body != "" {
+		r.Body = dataFromBody.Body
+		patcher[notificationTemplates_DBModels.COLUMN_BODY] = dataFromBody.Body
+	}
+	if dataFromBody.IsActive != nil {
+		patcher[notificationTemplates_DBModels.COLUMN_IS_ACTIVE] = *dataFromBody.IsActive
+	}
+
+	// Validate the merged template so a partial update can't leave it unrenderable
+	if err := validateTemplate(r); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	patcher[notificationTemplates_DBModels.COLUMN_UPDATED_AT] = time.Now()
+	patcher[notificationTemplates_DBModels.COLUMN_UPDATED_BY] = usr.Uuid
+
+	if err := u.NotificationTemplateDBClient.UpdateNotificationTemplate(ctx, filter, patcher); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	r, _ = u.NotificationTemplateDBClient.GetNotificationTemplate(ctx, filter)
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+func (u NotificationController) DeleteTemplate(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+
+	id := c.Param("id")
+
+	filter := where.Eq(notificationTemplates_DBModels.COLUM_UUID, id)
+
+	r, err := u.NotificationTemplateDBClient.GetNotificationTemplate(ctx, filter)
+	if err != nil {
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Notification template not found", err)
+		return
+	}
+
+	if err := u.NotificationTemplateDBClient.DeleteNotificationTemplate(ctx, filter); err != nil {
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+// validateTemplate checks the template fields and that the event type and channel are supported.
+func validateTemplate(tmpl notificationTemplates_DBModels.NotificationTemplate) error {
+	if err := tmpl.Validate(); err != nil {
+		return err
+	}
+	if !notification.IsValidEventType(tmpl.EventType) {
+		return fmt.Errorf("unsupported event type %s", tmpl.EventType)
+	}
+	if !notification.IsValidChannel(tmpl.Channel) {
+		return fmt.Errorf("unsupported channel %s", tmpl.Channel)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	reconciliationJobs_DBModels "user/sigmatech/app/db/dto/reconciliation_jobs"
+	reconciliationRows_DBModels "user/sigmatech/app/db/dto/reconciliation_rows"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	reconciliationJobDB "user/sigmatech/app/db/repository/reconciliation_job"
+	reconciliationRowDB "user/sigmatech/app/db/repository/reconciliation_row"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	reconciliationRequest "user/sigmatech/app/service/dto/request/reconciliation"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/reconciliation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IReconciliationController is an interface that defines the methods for a reconciliation controller.
+type IReconciliationController interface {
+	ImportFile(c *gin.Context)
+	GetJobs(c *gin.Context)
+	GetJob(c *gin.Context)
+	GetJobRows(c *gin.Context)
+
+	GetRows(c *gin.Context)
+	ResolveRow(c *gin.Context)
+	DismissRow(c *gin.Context)
+}
+
+// ReconciliationController is a struct that implements the IReconciliationController interface.
+type ReconciliationController struct {
+	ReconciliationJobDBClient reconciliationJobDB.IReconciliationJobRepository
+	ReconciliationRowDBClient reconciliationRowDB.IReconciliationRowRepository
+	Reconciliation            reconciliation.IReconciliationService
+}
+
+// NewReconciliationController is a constructor function that creates a new ReconciliationController.
+func NewReconciliationController(
+	ReconciliationJobDBClient reconciliationJobDB.IReconciliationJobRepository,
+	ReconciliationRowDBClient reconciliationRowDB.IReconciliationRowRepository,
+	Reconciliation reconciliation.IReconciliationService,
+) IReconciliationController {
+	return &ReconciliationController{
+		ReconciliationJobDBClient: ReconciliationJobDBClient,
+		ReconciliationRowDBClient: ReconciliationRowDBClient,
+		Reconciliation:            Reconciliation,
+	}
+}
+
+// ImportFile accepts a bank mutation file in the "file" form field and reconciles it in the background.
+// The optional "layout" form field is a JSON document overriding the configured layout.
+func (u ReconciliationController) ImportFile(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	layout, err := reconciliation.ParseLayout(reconciliation.DefaultLayout(), c.PostForm("layout"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if file.Size > constants.Config.ReconciliationConfig.RECONCILIATION_MAX_FILE_SIZE {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %s", constants.BAD_REQUEST, "File is too large"), nil)
+		return
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	defer fileReader.Close()
+
+	data, err := io.ReadAll(fileReader)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	job, err := u.Reconciliation.Import(ctx, file.Filename, data, layout, &usr.Uuid)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.CREATED_SUCCESSFULLY, job)
+}
+
+func (u ReconciliationController) GetJobs(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, reconciliationJobs_DBModels.ReconciliationJob{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	jobs, paginationResponse, err := u.ReconciliationJobDBClient.GetReconciliationJobs(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, jobs, paginationResponse)
+}
+
+// GetJob returns the status of an import and, once it is completed, its reconciliation report
+func (u ReconciliationController) GetJob(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(reconciliationJobs_DBModels.COLUM_UUID, id)
+
+	r, err := u.ReconciliationJobDBClient.GetReconciliationJob(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Reconciliation job not found", err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
+
+// GetJobRows lists the lines of an import with their outcome, filterable by status
+func (u ReconciliationController) GetJobRows(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if pagination.Order == "" {
+		pagination.Order = reconciliationRows_DBModels.COLUMN_ROW_NUMBER
+		pagination.Sort = "ASC"
+	}
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, reconciliationRows_DBModels.ReconciliationRow{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	f[reconciliationRows_DBModels.COLUMN_JOB_UUID] = c.Param("id")
+
+	rows, paginationResponse, err := u.ReconciliationRowDBClient.GetReconciliationRows(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, rows, paginationResponse)
+}
+
+// GetRows lists imported lines across jobs, by default the review queue
+func (u ReconciliationController) GetRows(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, reconciliationRows_DBModels.ReconciliationRow{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	if _, ok := f[reconciliationRows_DBModels.COLUMN_STATUS]; !ok {
+		f[reconciliationRows_DBModels.COLUMN_STATUS] = reconciliation.ROW_STATUS_REVIEW
+	}
+
+	rows, paginationResponse, err := u.ReconciliationRowDBClient.GetReconciliationRows(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, rows, paginationResponse)
+}
+
+func (u ReconciliationController) ResolveRow(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	dataFromBody := reconciliationRequest.ResolveRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil && !errors.Is(err, io.EOF) {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	r, err := u.Reconciliation.Resolve(ctx, id, dataFromBody.TransactionInstallmentUuid, usr.Uuid)
+	if err != nil {
+		respondWithRowError(c, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+func (u ReconciliationController) DismissRow(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	dataFromBody := reconciliationRequest.DismissRequest{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	r, err := u.Reconciliation.Dismiss(ctx, id, dataFromBody.Reason, usr.Uuid)
+	if err != nil {
+		respondWithRowError(c, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+func respondWithRowError(c *gin.Context, err error) {
+	log := logger.Logger(correlation.WithReqContext(c))
+
+	switch {
+	case errors.Is(err, reconciliation.ErrRowNotFound), errors.Is(err, reconciliation.ErrInstallmentNotFound):
+		controller.RespondWithError(c, http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, reconciliation.ErrRowNotInReview), errors.Is(err, reconciliation.ErrInstallmentAlreadyPaid), errors.Is(err, reconciliation.ErrRowAlreadyApplied):
+		controller.RespondWithError(c, http.StatusConflict, err.Error(), err)
+	case errors.Is(err, reconciliation.ErrAmbiguousResolution):
+		controller.RespondWithError(c, http.StatusBadRequest, err.Error(), err)
+	default:
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	}
+}
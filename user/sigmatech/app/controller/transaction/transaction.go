@@ -7,16 +7,21 @@ import (
 	"sync"
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
+	exportController "user/sigmatech/app/controller/export"
 	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
 	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	transactionDelinquencies_DBModels "user/sigmatech/app/db/dto/transaction_delinquencies"
 	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
 	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
 	customerDB "user/sigmatech/app/db/repository/customer"
 	customerLimitDB "user/sigmatech/app/db/repository/customer_limit"
 	transactionDB "user/sigmatech/app/db/repository/transaction"
+	transactionDelinquencyDB "user/sigmatech/app/db/repository/transaction_delinquency"
 	transactionInstallmentDB "user/sigmatech/app/db/repository/transaction_installment"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/correlation"
 	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/export"
 	"user/sigmatech/app/service/logger"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +40,8 @@ type TransactionController struct {
 	CustomerLimitDBClient          customerLimitDB.ICustomerLimitRepository
 	TransactionDBClient            transactionDB.ITransactionRepository
 	transactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
+	TransactionDelinquencyDBClient transactionDelinquencyDB.ITransactionDelinquencyRepository
+	Export                         export.IExportService
 }
 
 // NewTransactionController is a constructor function that creates a new TransactionController.
@@ -43,12 +50,16 @@ func NewTransactionController(
 	CustomerLimitDBClient customerLimitDB.ICustomerLimitRepository,
 	TransactionDBClient transactionDB.ITransactionRepository,
 	transactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
+	TransactionDelinquencyDBClient transactionDelinquencyDB.ITransactionDelinquencyRepository,
+	Export export.IExportService,
 ) ITransactionController {
 	return &TransactionController{
 		CustomerDBClient:               CustomerDBClient,
 		CustomerLimitDBClient:          CustomerLimitDBClient,
 		TransactionDBClient:            TransactionDBClient,
 		transactionInstallmentDBClient: transactionInstallmentDBClient,
+		TransactionDelinquencyDBClient: TransactionDelinquencyDBClient,
+		Export:                         Export,
 	}
 }
 
@@ -67,7 +78,18 @@ func (u TransactionController) GetTransactions(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	f, err := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if exportController.IsExport(c) {
+		exportController.RespondWithExport(c, u.Export, export.DATASET_TRANSACTIONS, f, pagination)
+		return
+	}
 
 	transactions, paginationResponse, err := u.TransactionDBClient.GetTransactions(ctx, pagination, f)
 	if err != nil {
@@ -85,9 +107,7 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 	log := logger.Logger(ctx)
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		transactions_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(transactions_DBModels.COLUM_UUID, id)
 
 	r, err := u.TransactionDBClient.GetTransaction(ctx, filter)
 	if err != nil {
@@ -107,6 +127,7 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 	var transactionData struct {
 		Transaction             transactions_DBModels.Transaction                           `json:"transaction"`
 		TransactionInstallments []*transaction_installments_DBModels.TransactionInstallment `json:"transaction_installments"`
+		Delinquency             *transactionDelinquencies_DBModels.TransactionDelinquency   `json:"delinquency"` // nil once the contract is done
 	}
 
 	p := request.Pagination{
@@ -116,7 +137,13 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 	}
 	p.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, transaction_installments_DBModels.TransactionInstallment{})
+	f, err := request.ExtractFilteredQueryParams(c, transaction_installments_DBModels.TransactionInstallment{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 	f[transaction_installments_DBModels.COLUMN_TRANSACTION_UUID] = r.Uuid.String()
 
 	transactionInstallments, _, err := u.transactionInstallmentDBClient.GetTransactionInstallments(ctx, p, f)
@@ -127,8 +154,19 @@ func (u TransactionController) GetTransaction(c *gin.Context) {
 		return
 	}
 
+	delinquency, err := u.TransactionDelinquencyDBClient.GetTransactionDelinquency(ctx, where.Eq(transactionDelinquencies_DBModels.COLUMN_TRANSACTION_UUID, r.Uuid))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
 	transactionData.Transaction = r
 	transactionData.TransactionInstallments = transactionInstallments
+	if delinquency.TransactionUuid != uuid.Nil {
+		transactionData.Delinquency = &delinquency
+	}
 
 	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, transactionData)
 }
@@ -148,7 +186,18 @@ func (u TransactionController) GetTransactionDetails(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	f, err := request.ExtractFilteredQueryParams(c, transactions_DBModels.Transaction{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if exportController.IsExport(c) {
+		exportController.RespondWithExport(c, u.Export, export.DATASET_TRANSACTION_DETAILS, f, pagination)
+		return
+	}
 
 	transactions, paginationResponse, err := u.TransactionDBClient.GetTransactions(ctx, pagination, f)
 	if err != nil {
@@ -174,9 +223,7 @@ func (u TransactionController) GetTransactionDetails(c *gin.Context) {
 		go func(index int, transaction transactions_DBModels.Transaction) {
 			defer wg.Done()
 
-			filter := fmt.Sprintf("%s='%s'",
-				customers_DBModels.COLUM_UUID, transaction.CustomerUuid,
-			)
+			filter := where.Eq(customers_DBModels.COLUM_UUID, transaction.CustomerUuid)
 
 			cust, err := u.CustomerDBClient.GetCustomer(ctx, filter)
 			if err != nil {
@@ -193,8 +240,10 @@ func (u TransactionController) GetTransactionDetails(c *gin.Context) {
 			}
 			p.Validate()
 
-			f := request.ExtractFilteredQueryParams(c, transaction_installments_DBModels.TransactionInstallment{})
-			f[transaction_installments_DBModels.COLUMN_TRANSACTION_UUID] = transaction.Uuid.String()
+			// The query parameters filter the transactions, not their installments
+			f := map[string]interface{}{
+				transaction_installments_DBModels.COLUMN_TRANSACTION_UUID: transaction.Uuid.String(),
+			}
 
 			transactionInstallments, _, err := u.transactionInstallmentDBClient.GetTransactionInstallments(ctx, p, f)
 			if err != nil {
@@ -1,13 +1,17 @@
 package users
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"net/http"
 	"strings"
 	"time"
+	"user/sigmatech/app/api/middleware/jwt"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/dto/request/user"
+	"user/sigmatech/app/service/dto/response"
 
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
@@ -27,7 +31,7 @@ func (u UserController) SignUp(c *gin.Context) {
 
 	var dataFromBody users_DBModels.User
 
-	fCheck := fmt.Sprintf("%s='%s'", users_DBModels.COLUMN_NAME, "super-sigmatech")
+	fCheck := where.Eq(users_DBModels.COLUMN_NAME, "super-sigmatech")
 	check, err := u.UserDBClient.GetUser(ctx, fCheck)
 	if err != nil {
 		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
@@ -85,7 +89,21 @@ func (u UserController) SignIn(c *gin.Context) {
 		return
 	}
 
-	filter := fmt.Sprintf("%s='%s'", users_DBModels.COLUMN_EMAIL, dataFromBody.Email)
+	ip := c.ClientIP()
+
+	// A locked out account isn't told whether the password was right
+	lockedFor, err := u.Lockout.Check(ctx, dataFromBody.Email, ip)
+	if err != nil {
+		log.Errorf("Error checking sign-in lockout: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	if lockedFor > 0 {
+		controller.RespondWithLockout(c, lockedFor)
+		return
+	}
+
+	filter := where.Eq(users_DBModels.COLUMN_EMAIL, dataFromBody.Email)
 
 	user, err := u.UserDBClient.GetUser(ctx, filter)
 	if err != nil {
@@ -96,10 +114,41 @@ func (u UserController) SignIn(c *gin.Context) {
 
 	if !util.ValidatePassword(dataFromBody.Password, user.Password) {
 		log.Errorf("Wrong credentials")
+		if err := u.Lockout.Fail(ctx, dataFromBody.Email, ip); err != nil {
+			log.Errorf("Error counting failed sign-in: %v", err)
+		}
 		controller.RespondWithError(c, http.StatusUnauthorized, "Wrong credentials", errors.New(constants.UNAUTHORIZED_ACCESS))
 		return
 	}
 
+	mfaEnabled, err := u.Mfa.Enabled(ctx, user.Uuid)
+	if err != nil {
+		log.Errorf("Error checking mfa of user %s: %v", user.Uuid, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	// Failures aren't cleared before the second factor, or the password would buy unlimited code guesses
+	if mfaEnabled {
+		challenge, expiresAt, err := u.JWT.GenerateMfaChallenge(ctx, user)
+		if err != nil {
+			log.Errorf("Error while creating mfa challenge: %v", err)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+
+		controller.RespondWithSuccess(c, http.StatusAccepted, "MFA code required", response.MfaChallenge{
+			MfaRequired: true,
+			MfaToken:    challenge,
+			ExpiresAt:   expiresAt,
+		})
+		return
+	}
+
+	if err := u.Lockout.Succeed(ctx, dataFromBody.Email); err != nil {
+		log.Errorf("Error clearing failed sign-ins: %v", err)
+	}
+
 	token, err := u.JWT.GenerateUserTokens(ctx, user)
 	if err != nil {
 		log.Errorf("Error while creating access token: %v", err)
@@ -107,6 +156,20 @@ func (u UserController) SignIn(c *gin.Context) {
 		return
 	}
 
+	u.signedIn(ctx, c, user, token)
+}
+
+// signedIn records the sign-in of the user and responds with their tokens
+func (u UserController) signedIn(ctx context.Context, c *gin.Context, user users_DBModels.User, token *jwt.TokenDetails) {
+	if err := u.UserDBClient.UpdateUser(ctx, where.Eq(users_DBModels.COLUM_UUID, user.Uuid), map[string]interface{}{
+		users_DBModels.COLUMN_LAST_LOGIN:    time.Now(),
+		users_DBModels.COLUMN_LAST_LOGIN_IP: c.ClientIP(),
+	}); err != nil {
+		logger.Logger(ctx).Errorf("Error recording last login of user %s: %v", user.Uuid, err)
+	}
+
+	u.recordSession(ctx, c, token)
+
 	controller.RespondWithSuccess(c, http.StatusAccepted, "Login Successfully", token)
 }
 
@@ -124,5 +187,23 @@ func (u UserController) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	u.recordSession(ctx, c, claims)
+
 	controller.RespondWithSuccess(c, http.StatusAccepted, "Refresh Token Successfully", claims)
 }
+
+// Logout revokes the session of the access token, the refresh token issued with it can't be used anymore
+func (u UserController) Logout(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	tokenString := strings.TrimPrefix(c.GetHeader(constants.AUTHORIZATION), constants.BEARER)
+
+	if err := u.JWT.Logout(ctx, tokenString); err != nil {
+		log.Errorf("Error while revoking the session: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.LOGOUT_SUCCESSFULLY, nil)
+}
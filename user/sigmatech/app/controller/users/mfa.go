@@ -0,0 +1,210 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request/user"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mfa"
+	"user/sigmatech/app/service/rbac"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// VerifyMfa completes a sign-in that needed a second factor, with the challenge token SignIn returned
+// and a code from the authenticator or a recovery code. Wrong codes count as failed sign-ins.
+func (u UserController) VerifyMfa(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var dataFromBody user.VerifyMfaRequest
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	usr, err := u.JWT.VerifyMfaChallenge(ctx, dataFromBody.MfaToken)
+	if err != nil {
+		controller.RespondWithError(c, http.StatusUnauthorized, constants.INVALID_TOKEN, err)
+		return
+	}
+
+	ip := c.ClientIP()
+
+	lockedFor, err := u.Lockout.Check(ctx, usr.Email, ip)
+	if err != nil {
+		log.Errorf("Error checking sign-in lockout: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	if lockedFor > 0 {
+		controller.RespondWithLockout(c, lockedFor)
+		return
+	}
+
+	if err := u.Mfa.Verify(ctx, usr.Uuid, dataFromBody.Code); err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) || errors.Is(err, mfa.ErrMfaNotEnabled) {
+			if err := u.Lockout.Fail(ctx, usr.Email, ip); err != nil {
+				log.Errorf("Error counting failed sign-in: %v", err)
+			}
+			controller.RespondWithError(c, http.StatusUnauthorized, "Invalid MFA code", err)
+			return
+		}
+
+		log.Errorf("Error verifying mfa code of user %s: %v", usr.Uuid, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if err := u.Lockout.Succeed(ctx, usr.Email); err != nil {
+		log.Errorf("Error clearing failed sign-ins: %v", err)
+	}
+
+	token, err := u.JWT.GenerateMfaUserTokens(ctx, *usr)
+	if err != nil {
+		log.Errorf("Error while creating access token: %v", err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	u.signedIn(ctx, c, *usr, token)
+}
+
+// EnrollMfa starts the MFA enrollment of the signed in user, it is enabled by ActivateMfa
+func (u UserController) EnrollMfa(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User)
+
+	enrollment, err := u.Mfa.Enroll(ctx, *usr)
+	if err != nil {
+		if errors.Is(err, mfa.ErrMfaEnabled) {
+			controller.RespondWithError(c, http.StatusConflict, "MFA is already enabled", err)
+			return
+		}
+
+		log.Errorf("Error enrolling user %s in mfa: %v", usr.Uuid, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, enrollment)
+}
+
+// ActivateMfa enables MFA with a first code from the authenticator and returns the recovery codes. The
+// current session stays signed in with the password only, roles requiring MFA need a new sign-in.
+func (u UserController) ActivateMfa(c *gin.Context) {
+	u.recoveryCodes(c, u.Mfa.Activate)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the signed in user
+func (u UserController) RegenerateRecoveryCodes(c *gin.Context) {
+	u.recoveryCodes(c, u.Mfa.RegenerateRecoveryCodes)
+}
+
+// DisableMfa turns MFA off for the signed in user, unless one of their roles requires it
+func (u UserController) DisableMfa(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User)
+
+	if access := rbac.GetAccess(c); access != nil && access.MfaRequired {
+		controller.RespondWithError(c, http.StatusForbidden, "MFA is required by your role", errors.New(constants.PERMISSION_DENIED))
+		return
+	}
+
+	var dataFromBody user.MfaCodeRequest
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := u.Mfa.Disable(ctx, usr.Uuid, dataFromBody.Code); err != nil {
+		u.respondMfaError(c, usr, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DISABLED_SUCCESSFULLY, nil)
+}
+
+// recoveryCodes verifies the code of the signed in user with issue and responds with the recovery codes it returns
+func (u UserController) recoveryCodes(c *gin.Context, issue func(ctx context.Context, userUuid uuid.UUID, code string) ([]string, error)) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User)
+
+	var dataFromBody user.MfaCodeRequest
+	if err := c.ShouldBindJSON(&dataFromBody); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	if err := dataFromBody.Validate(); err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, constants.BAD_REQUEST, err)
+		return
+	}
+
+	codes, err := issue(ctx, usr.Uuid, dataFromBody.Code)
+	if err != nil {
+		u.respondMfaError(c, usr, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.UPDATED_SUCCESSFULLY, response.RecoveryCodes{RecoveryCodes: codes})
+}
+
+func (u UserController) respondMfaError(c *gin.Context, usr *users_DBModels.User, err error) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		controller.RespondWithError(c, http.StatusBadRequest, "Invalid MFA code", err)
+	case errors.Is(err, mfa.ErrMfaNotEnabled):
+		controller.RespondWithError(c, http.StatusConflict, "MFA is not enabled", err)
+	case errors.Is(err, mfa.ErrMfaEnabled):
+		controller.RespondWithError(c, http.StatusConflict, "MFA is already enabled", err)
+	default:
+		logger.Logger(correlation.WithReqContext(c)).Errorf("Error updating mfa of user %s: %v", usr.Uuid, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
 	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/audit"
 
 	"user/sigmatech/app/service/correlation"
 	"user/sigmatech/app/service/dto/request"
@@ -31,9 +33,7 @@ func (u UserController) GetProfile(c *gin.Context) {
 	}
 	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
 
-	user, err := u.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	user, err := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUMN_EMAIL, usr.Email))
 	if err != nil {
 		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
 		log.Error(errorMsg)
@@ -91,7 +91,7 @@ func (u UserController) UpdateProfile(c *gin.Context) {
 		patcher[users_DBModels.COLUMN_EMAIL] = dataFromBody.Email
 	}
 
-	filter := fmt.Sprintf(`%s='%s'`, users_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter string to match the user ID
+	filter := where.Eq(users_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter to match the user ID
 
 	if err := u.UserDBClient.UpdateUser(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -105,9 +105,11 @@ func (u UserController) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	r, _ := u.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	// The email may just have changed, reload the profile by uuid
+	r, _ := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, usr.Uuid))
+	audit.SetTarget(c, "user", usr.Uuid.String())
+	audit.SetBefore(c, usr)
+	audit.SetAfter(c, r)
 	r.Password = ""
 
 	// Respond with success message and the updated user profile (with password field cleared)
@@ -156,7 +158,7 @@ func (u UserController) UpdateProfilePassword(c *gin.Context) {
 		return
 	}
 
-	filter := fmt.Sprintf("%s='%s'", users_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter string to match the user ID
+	filter := where.Eq(users_DBModels.COLUMN_EMAIL, usr.Email) // Create a filter to match the user ID
 
 	if err := u.UserDBClient.UpdateUser(ctx, filter, map[string]interface{}{
 		users_DBModels.COLUMN_PASSWORD: hashedPassword,
@@ -167,9 +169,10 @@ func (u UserController) UpdateProfilePassword(c *gin.Context) {
 		return
 	}
 
-	r, _ := u.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUMN_EMAIL, usr.Email,
-	))
+	r, _ := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUMN_EMAIL, usr.Email))
+	audit.SetTarget(c, "user", usr.Uuid.String())
+	audit.SetBefore(c, usr)
+	audit.SetAfter(c, r)
 	r.Password = ""
 
 	// Respond with success message and the updated user profile (with password field cleared)
@@ -0,0 +1,81 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoles returns the roles that can be given to users, with their permissions.
+func (u UserController) GetRoles(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	roles, err := u.Rbac.GetRoles(ctx)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, roles)
+}
+
+// detail returns the user with the codes of its roles.
+func (u UserController) detail(ctx context.Context, user users_DBModels.User) (users_DBModels.UserDetail, error) {
+	access, err := u.Rbac.GetAccess(ctx, user.Uuid)
+	if err != nil {
+		return users_DBModels.UserDetail{}, err
+	}
+
+	return users_DBModels.UserDetail{User: user, Roles: access.Roles}, nil
+}
+
+// withinReach responds and returns false when the user holds roles the actor couldn't grant, such a user
+// is out of the actor's reach whatever is done to it.
+func (u UserController) withinReach(c *gin.Context, user users_DBModels.User) bool {
+	ctx := correlation.WithReqContext(c)
+
+	target, err := u.detail(ctx, user)
+	if err != nil {
+		logger.Logger(ctx).Errorf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return false
+	}
+
+	if _, err := u.Rbac.ResolveRoles(ctx, target.Roles, rbac.GetAccess(c)); err != nil {
+		respondWithRoleError(c, err)
+		return false
+	}
+	return true
+}
+
+func roleCodes(roles []*rbac.RoleDetail) []string {
+	codes := make([]string, len(roles))
+	for i, role := range roles {
+		codes[i] = role.Code
+	}
+	return codes
+}
+
+func respondWithRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rbac.ErrUnknownRole):
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+	case errors.Is(err, rbac.ErrRoleNotGrantable):
+		controller.RespondWithError(c, http.StatusForbidden, constants.PERMISSION_DENIED, err)
+	default:
+		logger.Logger(correlation.WithReqContext(c)).Errorf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+	}
+}
@@ -0,0 +1,92 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"user/sigmatech/app/api/middleware/jwt"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/session"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetSessions lists the active sessions of the signed in user, with history=true the ended ones too
+func (u UserController) GetSessions(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User)
+
+	sessions, err := u.Session.GetSessions(ctx, usr.Uuid, c.Query("history") == "true")
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, sessions)
+}
+
+// RevokeSession signs the user out of one of their sessions
+func (u UserController) RevokeSession(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String())
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User)
+
+	sessionUuid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		controller.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err), err)
+		return
+	}
+
+	if err := u.Session.Revoke(ctx, usr.Uuid, sessionUuid); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			controller.RespondWithError(c, http.StatusNotFound, "Session not found", err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+// recordSession records the sign-in or refresh the tokens were issued for, a failure doesn't fail the request
+func (u UserController) recordSession(ctx context.Context, c *gin.Context, token *jwt.TokenDetails) {
+	if err := u.Session.Record(ctx, session.Login{
+		SessionUuid: token.FamilyUuid,
+		SubjectUuid: token.SubjectUuid,
+		Ip:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		ExpiresAt:   time.Unix(token.RtExpires, 0),
+	}); err != nil {
+		logger.Logger(ctx).Errorf("Error recording session %s: %v", token.FamilyUuid, err)
+	}
+}
@@ -1,11 +1,14 @@
 package users
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"user/sigmatech/app/api/middleware/jwt"
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/controller"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/audit"
 
 	"time"
 	users_DBModels "user/sigmatech/app/db/dto/users"
@@ -15,10 +18,16 @@ import (
 	"fmt"
 
 	"net/http"
+	"strings"
 	"user/sigmatech/app/service/correlation"
 	"user/sigmatech/app/service/dto/request"
 	reqUser "user/sigmatech/app/service/dto/request/user"
+	"user/sigmatech/app/service/lockout"
 	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mfa"
+	"user/sigmatech/app/service/passwordreset"
+	"user/sigmatech/app/service/rbac"
+	"user/sigmatech/app/service/session"
 	"user/sigmatech/app/service/util"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +39,16 @@ type IUserController interface {
 	SignUp(c *gin.Context)
 	SignIn(c *gin.Context)
 	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	GetSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	VerifyMfa(c *gin.Context)
+	EnrollMfa(c *gin.Context)
+	ActivateMfa(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
+	DisableMfa(c *gin.Context)
 
 	GetProfile(c *gin.Context)
 	UpdateProfile(c *gin.Context)
@@ -42,23 +61,42 @@ type IUserController interface {
 	UpdateUserPassword(c *gin.Context)
 	DeleteUser(c *gin.Context)
 	DeleteUsers(c *gin.Context)
+	RestoreUser(c *gin.Context)
+	UnlockUser(c *gin.Context)
+
+	GetRoles(c *gin.Context)
 }
 
 // UserController is a struct that implements the IUserController interface.
 type UserController struct {
 	UserDBClient userDB.IUserRepository // userDB represents the database client for crm-user-related operations.
 
-	JWT jwt.IJwtService
+	JWT           jwt.IJwtService
+	Rbac          rbac.IRbacService
+	PasswordReset passwordreset.IPasswordResetService
+	Lockout       lockout.ILockoutService
+	Session       session.ISessionService
+	Mfa           mfa.IMfaService
 }
 
 // NewUserController is a constructor function that creates a new UserController.
 func NewUserController(
 	UserDBClient userDB.IUserRepository,
 	jwt jwt.IJwtService,
+	rbac rbac.IRbacService,
+	PasswordReset passwordreset.IPasswordResetService,
+	Lockout lockout.ILockoutService,
+	Session session.ISessionService,
+	Mfa mfa.IMfaService,
 ) IUserController {
 	return &UserController{
-		UserDBClient: UserDBClient,
-		JWT:          jwt,
+		UserDBClient:  UserDBClient,
+		JWT:           jwt,
+		Rbac:          rbac,
+		PasswordReset: PasswordReset,
+		Lockout:       Lockout,
+		Session:       Session,
+		Mfa:           Mfa,
 	}
 }
 
@@ -101,7 +139,7 @@ func (u UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
-	fCheck := fmt.Sprintf("%s='%s'", users_DBModels.COLUMN_EMAIL, data.Email)
+	fCheck := where.Eq(users_DBModels.COLUMN_EMAIL, data.Email)
 	check, err := u.UserDBClient.GetUser(ctx, fCheck)
 	if err != nil {
 		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
@@ -115,6 +153,12 @@ func (u UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
+	roles, err := u.Rbac.ResolveRoles(ctx, dataFromBody.Roles, rbac.GetAccess(c))
+	if err != nil {
+		respondWithRoleError(c, err)
+		return
+	}
+
 	if err = u.UserDBClient.CreateUser(ctx, &data); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
 			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
@@ -127,7 +171,19 @@ func (u UserController) CreateUser(c *gin.Context) {
 		return
 	}
 
-	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+	if err := u.Rbac.SetUserRoles(ctx, data.Uuid, roles, &usr.Uuid); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	detail := users_DBModels.UserDetail{User: data, Roles: roleCodes(roles)}
+
+	audit.SetTarget(c, "user", data.Uuid.String())
+	audit.SetAfter(c, detail)
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, detail)
 }
 
 func (u UserController) GetUsers(c *gin.Context) {
@@ -145,7 +201,13 @@ func (u UserController) GetUsers(c *gin.Context) {
 
 	pagination.Validate()
 
-	f := request.ExtractFilteredQueryParams(c, users_DBModels.User{})
+	f, err := request.ExtractFilteredQueryParams(c, users_DBModels.User{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
 
 	users, paginationResponse, err := u.UserDBClient.GetUsers(ctx, pagination, f)
 	if err != nil {
@@ -167,9 +229,7 @@ func (u UserController) GetUser(c *gin.Context) {
 	log := logger.Logger(ctx)
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(users_DBModels.COLUM_UUID, id)
 
 	r, err := u.UserDBClient.GetUser(ctx, filter)
 	if err != nil {
@@ -188,17 +248,32 @@ func (u UserController) GetUser(c *gin.Context) {
 
 	r.Password = ""
 
-	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+	detail, err := u.detail(ctx, r)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, detail)
 }
 
 func (u UserController) UpdateUser(c *gin.Context) {
 	ctx := correlation.WithReqContext(c)
 	log := logger.Logger(ctx)
 
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(users_DBModels.COLUM_UUID, id)
 
 	r, err := u.UserDBClient.GetUser(ctx, filter)
 	if err != nil {
@@ -215,6 +290,21 @@ func (u UserController) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	before, err := u.detail(ctx, r)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	audit.SetBefore(c, before)
+
+	// A user holding roles the actor couldn't grant is out of the actor's reach, whatever is changed
+	if _, err := u.Rbac.ResolveRoles(ctx, before.Roles, rbac.GetAccess(c)); err != nil {
+		respondWithRoleError(c, err)
+		return
+	}
+
 	dataFromBody := reqUser.CreateUserReq{}
 	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
 	if err != nil {
@@ -224,6 +314,16 @@ func (u UserController) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Roles are replaced only when given
+	var roles []*rbac.RoleDetail
+	if dataFromBody.Roles != nil {
+		roles, err = u.Rbac.ResolveRoles(ctx, dataFromBody.Roles, rbac.GetAccess(c))
+		if err != nil {
+			respondWithRoleError(c, err)
+			return
+		}
+	}
+
 	var patcher = make(map[string]interface{})
 
 	if dataFromBody.Name != "" {
@@ -245,10 +345,9 @@ func (u UserController) UpdateUser(c *gin.Context) {
 	}
 
 	patcher[users_DBModels.COLUMN_UPDATED_AT] = time.Now()
+	patcher[users_DBModels.COLUMN_UPDATED_BY] = usr.Uuid
 
-	filter = fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, c.Param("id"),
-	)
+	filter = where.Eq(users_DBModels.COLUM_UUID, c.Param("id"))
 
 	if err := u.UserDBClient.UpdateUser(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -262,13 +361,29 @@ func (u UserController) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	r, _ = u.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, c.Param("id"),
-	))
+	if dataFromBody.Roles != nil {
+		if err := u.Rbac.SetUserRoles(ctx, r.Uuid, roles, &usr.Uuid); err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+	}
 
-	r.Password = ""
+	r, _ = u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, c.Param("id")))
 
-	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+	after, err := u.detail(ctx, r)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	audit.SetAfter(c, after)
+
+	after.Password = ""
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, after)
 }
 
 func (u UserController) UpdateUserPassword(c *gin.Context) {
@@ -294,9 +409,7 @@ func (u UserController) UpdateUserPassword(c *gin.Context) {
 	}
 
 	id := c.Param("id")
-	filter := fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(users_DBModels.COLUM_UUID, id)
 
 	r, err := u.UserDBClient.GetUser(ctx, filter)
 	if err != nil {
@@ -312,6 +425,20 @@ func (u UserController) UpdateUserPassword(c *gin.Context) {
 		controller.RespondWithError(c, http.StatusInternalServerError, "User not found", err)
 		return
 	}
+	audit.SetBefore(c, r)
+
+	// Setting the password of a user holding roles the actor couldn't grant would hand the actor those roles
+	target, err := u.detail(ctx, r)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	if _, err := u.Rbac.ResolveRoles(ctx, target.Roles, rbac.GetAccess(c)); err != nil {
+		respondWithRoleError(c, err)
+		return
+	}
 
 	var patcher = make(map[string]interface{})
 
@@ -333,9 +460,7 @@ func (u UserController) UpdateUserPassword(c *gin.Context) {
 	patcher[users_DBModels.COLUMN_UPDATED_AT] = time.Now()
 	patcher[users_DBModels.COLUMN_UPDATED_BY] = usr.Uuid
 
-	filter = fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, c.Param("id"),
-	)
+	filter = where.Eq(users_DBModels.COLUM_UUID, c.Param("id"))
 
 	if err := u.UserDBClient.UpdateUser(ctx, filter, patcher); err != nil {
 		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
@@ -349,23 +474,52 @@ func (u UserController) UpdateUserPassword(c *gin.Context) {
 		return
 	}
 
-	r, _ = u.UserDBClient.GetUser(ctx, fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, c.Param("id"),
-	))
+	r, _ = u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, c.Param("id")))
+	audit.SetAfter(c, r)
 
 	r.Password = ""
 
 	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
 }
 
+// UnlockUser lifts the sign-in lockout of a user, lockouts of the IPs they signed in from are kept.
+func (u UserController) UnlockUser(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	r, err := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, c.Param("id")))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	if !u.withinReach(c, r) {
+		return
+	}
+
+	if err := u.Lockout.Unlock(ctx, r.Email); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, nil)
+}
+
 func (u UserController) DeleteUser(c *gin.Context) {
 	ctx := correlation.WithReqContext(c)
 
 	id := c.Param("id")
 
-	filter := fmt.Sprintf("%s='%s'",
-		users_DBModels.COLUM_UUID, id,
-	)
+	filter := where.Eq(users_DBModels.COLUM_UUID, id)
 
 	r, err := u.UserDBClient.GetUser(ctx, filter)
 	if err != nil {
@@ -378,10 +532,17 @@ func (u UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	audit.SetBefore(c, r)
+
+	if !u.withinReach(c, r) {
+		return
+	}
+
 	if err := u.UserDBClient.DeleteUser(ctx, filter); err != nil {
 		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
 		return
 	}
+	u.signOut(ctx, r.Uuid)
 
 	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
 }
@@ -402,15 +563,92 @@ func (u UserController) DeleteUsers(c *gin.Context) {
 		return
 	}
 
-	for _, id := range IDs {
-		filter := fmt.Sprintf("%s='%s'", users_DBModels.COLUM_UUID, id)
+	audit.SetTarget(c, "user", strings.Join(IDs, ","))
 
-		if err := u.UserDBClient.DeleteUser(ctx, filter); err != nil {
-			log.Errorf("Error deleting user with ID %d: %s", id, err.Error())
+	// Every user is checked before any is deleted, so a refused one leaves them all in place
+	userUuids := make([]uuid.UUID, 0, len(IDs))
+	for _, id := range IDs {
+		r, err := u.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUM_UUID, id))
+		if err != nil {
+			log.Errorf("Error getting user with ID %s: %v", id, err)
 			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
 			return
 		}
+
+		if r.Uuid == uuid.Nil {
+			controller.RespondWithError(c, http.StatusNotFound, fmt.Sprintf("User %s not found", id), nil)
+			return
+		}
+
+		if !u.withinReach(c, r) {
+			return
+		}
+		userUuids = append(userUuids, r.Uuid)
+	}
+
+	// A single statement deletes them all, a failure leaves every user in place
+	if err := u.UserDBClient.DeleteUser(ctx, where.In(users_DBModels.COLUM_UUID, IDs)); err != nil {
+		log.Errorf("Error deleting users %s: %v", strings.Join(IDs, ","), err)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+	for _, userUuid := range userUuids {
+		u.signOut(ctx, userUuid)
 	}
 
 	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
 }
+
+// signOut revokes the refresh token families of a deleted user, so its tokens stop working right away
+// instead of once they expire. A failure is only logged, the deletion itself went through.
+func (u UserController) signOut(ctx context.Context, userUuid uuid.UUID) {
+	if err := u.JWT.RevokeSessions(ctx, userUuid); err != nil {
+		logger.Logger(ctx).Errorf("Error revoking the sessions of user %s: %v", userUuid, err)
+	}
+}
+
+// RestoreUser undoes the deletion of a user that wasn't purged yet.
+func (u UserController) RestoreUser(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	filter := where.Eq(users_DBModels.COLUM_UUID, c.Param("id"))
+
+	r, err := u.UserDBClient.GetDeletedUser(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Deleted user not found", nil)
+		return
+	}
+	audit.SetBefore(c, r)
+
+	if !u.withinReach(c, r) {
+		return
+	}
+
+	if err := u.UserDBClient.RestoreUser(ctx, filter); err != nil {
+		// Another user may have been created with the email since
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	r, _ = u.UserDBClient.GetUser(ctx, filter)
+	audit.SetAfter(c, r)
+
+	r.Password = ""
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
@@ -0,0 +1,420 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/app/controller"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	webhookDeliveries_DBModels "user/sigmatech/app/db/dto/webhook_deliveries"
+	webhookSubscriptions_DBModels "user/sigmatech/app/db/dto/webhook_subscriptions"
+	webhookDeliveryDB "user/sigmatech/app/db/repository/webhook_delivery"
+	webhookSubscriptionDB "user/sigmatech/app/db/repository/webhook_subscription"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+	"user/sigmatech/app/service/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IWebhookController is an interface that defines the methods for a webhook controller.
+type IWebhookController interface {
+	GetSubscriptions(c *gin.Context)
+	GetSubscription(c *gin.Context)
+	CreateSubscription(c *gin.Context)
+	UpdateSubscription(c *gin.Context)
+	DeleteSubscription(c *gin.Context)
+
+	GetDeliveries(c *gin.Context)
+	GetDelivery(c *gin.Context)
+	ReplayDelivery(c *gin.Context)
+}
+
+// WebhookController is a struct that implements the IWebhookController interface.
+type WebhookController struct {
+	WebhookSubscriptionDBClient webhookSubscriptionDB.IWebhookSubscriptionRepository
+	WebhookDeliveryDBClient     webhookDeliveryDB.IWebhookDeliveryRepository
+	Webhook                     webhook.IWebhookService
+}
+
+// NewWebhookController is a constructor function that creates a new WebhookController.
+func NewWebhookController(
+	WebhookSubscriptionDBClient webhookSubscriptionDB.IWebhookSubscriptionRepository,
+	WebhookDeliveryDBClient webhookDeliveryDB.IWebhookDeliveryRepository,
+	Webhook webhook.IWebhookService,
+) IWebhookController {
+	return &WebhookController{
+		WebhookSubscriptionDBClient: WebhookSubscriptionDBClient,
+		WebhookDeliveryDBClient:     WebhookDeliveryDBClient,
+		Webhook:                     Webhook,
+	}
+}
+
+func (u WebhookController) GetSubscriptions(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, webhookSubscriptions_DBModels.WebhookSubscription{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+	delete(f, webhookSubscriptions_DBModels.COLUMN_SECRET)
+
+	subscriptions, paginationResponse, err := u.WebhookSubscriptionDBClient.GetWebhookSubscriptions(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	// The secret is only shown when it is created
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, subscriptions, paginationResponse)
+}
+
+func (u WebhookController) GetSubscription(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(webhookSubscriptions_DBModels.COLUM_UUID, id)
+
+	r, err := u.WebhookSubscriptionDBClient.GetWebhookSubscription(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Webhook subscription not found", err)
+		return
+	}
+
+	r.Secret = ""
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
+
+func (u WebhookController) CreateSubscription(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	dataFromBody := webhookSubscriptions_DBModels.WebhookSubscription{}
+	err := json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if err := validateSubscription(&dataFromBody); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	secret := dataFromBody.Secret
+	if secret == "" {
+		secret, err = webhook.GenerateSecret()
+		if err != nil {
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+			return
+		}
+	}
+
+	isActive := true
+	if dataFromBody.IsActive != nil {
+		isActive = *dataFromBody.IsActive
+	}
+
+	data := webhookSubscriptions_DBModels.WebhookSubscription{
+		Uuid:       uuid.New(),
+		Name:       dataFromBody.Name,
+		Url:        dataFromBody.Url,
+		EventTypes: dataFromBody.EventTypes,
+		Secret:     secret,
+		IsActive:   util.Boolean(isActive),
+		CreatedAt:  time.Now(),
+		CreatedBy:  &usr.Uuid,
+		UpdatedAt:  time.Now(),
+		UpdatedBy:  nil,
+	}
+
+	if err = u.WebhookSubscriptionDBClient.CreateWebhookSubscription(ctx, &data); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.CREATED_SUCCESSFULLY, data)
+}
+
+func (u WebhookController) UpdateSubscription(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	context, exist := c.Get(constants.CTK_CLAIM_KEY.String()) // Retrieve the user context from the request
+	if !exist {
+		errorMsg := fmt.Sprintf("%s: %s", constants.UNAUTHORIZED_ACCESS, constants.INVALID_TOKEN)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusUnauthorized, errorMsg, nil)
+		return
+	}
+	usr := context.(*users_DBModels.User) // Type assertion to retrieve the user information
+
+	id := c.Param("id")
+	filter := where.Eq(webhookSubscriptions_DBModels.COLUM_UUID, id)
+
+	r, err := u.WebhookSubscriptionDBClient.GetWebhookSubscription(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Webhook subscription not found", err)
+		return
+	}
+
+	dataFromBody := webhookSubscriptions_DBModels.WebhookSubscription{}
+	err = json.NewDecoder(c.Request.Body).Decode(&dataFromBody)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	var patcher = make(map[string]interface{})
+
+	if dataFromBody.Name != "" {
+		r.Name = dataFromBody.Name
+		patcher[webhookSubscriptions_DBModels.COLUMN_NAME] = dataFromBody.Name
+	}
+	if dataFromBody.Url != "" {
+		r.Url = dataFromBody.Url
+		patcher[webhookSubscriptions_DBModels.COLUMN_URL] = dataFromBody.Url
+	}
+	if dataFromBody.EventTypes != "" {
+		r.EventTypes = dataFromBody.EventTypes
+	}
+	if dataFromBody.Secret != "" {
+		patcher[webhookSubscriptions_DBModels.COLUMN_SECRET] = dataFromBody.Secret
+	}
+	if dataFromBody.IsActive != nil {
+		patcher[webhookSubscriptions_DBModels.COLUMN_IS_ACTIVE] = *dataFromBody.IsActive
+	}
+
+	// Validate the merged subscription so a partial update can't leave it undeliverable
+	if err := validateSubscription(&r); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	if dataFromBody.EventTypes != "" {
+		patcher[webhookSubscriptions_DBModels.COLUMN_EVENT_TYPES] = r.EventTypes
+	}
+
+	patcher[webhookSubscriptions_DBModels.COLUMN_UPDATED_AT] = time.Now()
+	patcher[webhookSubscriptions_DBModels.COLUMN_UPDATED_BY] = usr.Uuid
+
+	if err := u.WebhookSubscriptionDBClient.UpdateWebhookSubscription(ctx, filter, patcher); err != nil {
+		if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+			controller.RespondWithError(c, http.StatusConflict, fmt.Sprintf("%s already exists", constraintName), err)
+			return
+		}
+
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	r, _ = u.WebhookSubscriptionDBClient.GetWebhookSubscription(ctx, filter)
+	r.Secret = ""
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+func (u WebhookController) DeleteSubscription(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+
+	id := c.Param("id")
+
+	filter := where.Eq(webhookSubscriptions_DBModels.COLUM_UUID, id)
+
+	r, err := u.WebhookSubscriptionDBClient.GetWebhookSubscription(ctx, filter)
+	if err != nil {
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Webhook subscription not found", err)
+		return
+	}
+
+	if err := u.WebhookSubscriptionDBClient.DeleteWebhookSubscription(ctx, filter); err != nil {
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.DELETED_SUCCESSFULLY, nil)
+}
+
+// GetDeliveries lists the delivery log, filterable by subscription_uuid, event_type and status
+func (u WebhookController) GetDeliveries(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	var pagination request.Pagination
+
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	pagination.Validate()
+
+	f, err := request.ExtractFilteredQueryParams(c, webhookDeliveries_DBModels.WebhookDelivery{})
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	deliveries, paginationResponse, err := u.WebhookDeliveryDBClient.GetWebhookDeliveries(ctx, pagination, f)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	controller.RespondWithSuccessAndPagination(c, http.StatusOK, constants.GET_SUCCESSFULLY, deliveries, paginationResponse)
+}
+
+func (u WebhookController) GetDelivery(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id := c.Param("id")
+	filter := where.Eq(webhookDeliveries_DBModels.COLUM_UUID, id)
+
+	r, err := u.WebhookDeliveryDBClient.GetWebhookDelivery(ctx, filter)
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		return
+	}
+
+	if r.Uuid == uuid.Nil {
+		controller.RespondWithError(c, http.StatusNotFound, "Webhook delivery not found", err)
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusOK, constants.GET_SUCCESSFULLY, r)
+}
+
+// ReplayDelivery sends a dead-lettered delivery again and returns the outcome of the new attempt
+func (u WebhookController) ReplayDelivery(c *gin.Context) {
+	ctx := correlation.WithReqContext(c)
+	log := logger.Logger(ctx)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errorMsg := fmt.Sprintf("%s: %v", constants.BAD_REQUEST, err)
+		log.Error(errorMsg)
+		controller.RespondWithError(c, http.StatusBadRequest, errorMsg, err)
+		return
+	}
+
+	r, err := u.Webhook.Replay(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrDeliveryNotFound):
+			controller.RespondWithError(c, http.StatusNotFound, "Webhook delivery not found", err)
+		case errors.Is(err, webhook.ErrDeliveryNotDead):
+			controller.RespondWithError(c, http.StatusConflict, err.Error(), err)
+		default:
+			errorMsg := fmt.Sprintf("%s: %v", constants.INTERNAL_SERVER_ERROR, err)
+			log.Error(errorMsg)
+			controller.RespondWithError(c, http.StatusInternalServerError, constants.INTERNAL_SERVER_ERROR, err)
+		}
+		return
+	}
+
+	controller.RespondWithSuccess(c, http.StatusAccepted, constants.UPDATED_SUCCESSFULLY, r)
+}
+
+// validateSubscription checks the subscription fields and normalizes its event types.
+func validateSubscription(subscription *webhookSubscriptions_DBModels.WebhookSubscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+
+	eventTypes := subscription.GetEventTypes()
+	for _, eventType := range eventTypes {
+		if !webhook.IsValidEventType(eventType) {
+			return fmt.Errorf("unsupported event type %s", eventType)
+		}
+	}
+	subscription.EventTypes = strings.Join(eventTypes, ",")
+
+	return nil
+}
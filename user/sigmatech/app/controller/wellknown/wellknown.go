@@ -0,0 +1,29 @@
+package wellknown
+
+import (
+	"net/http"
+	"user/sigmatech/app/api/middleware/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IWellKnownController interface {
+	GetJwks(c *gin.Context)
+}
+
+type WellKnownController struct {
+	Keys *jwt.KeySet
+}
+
+func NewWellKnownController(Keys *jwt.KeySet) IWellKnownController {
+	return &WellKnownController{
+		Keys: Keys,
+	}
+}
+
+// GetJwks publishes the public keys access tokens are verified with. It isn't wrapped in the usual
+// response so JWT libraries can read it, and may be cached a few minutes as rotations add keys ahead.
+func (w *WellKnownController) GetJwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, w.Keys.JWKS())
+}
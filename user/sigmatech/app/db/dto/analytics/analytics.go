@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"time"
+)
+
+// Disbursement is the number and OTR of the contracts booked in a period.
+type Disbursement struct {
+	Period    time.Time `json:"period"`
+	Contracts int       `json:"contracts"`
+	Otr       float64   `json:"otr"`
+}
+
+// InterestEarned is the interest share of the installments paid in a period. Each paid amount
+// is split between principal, interest and admin fee in the proportions of its contract.
+type InterestEarned struct {
+	Period   time.Time `json:"period"`
+	Interest float64   `json:"interest"`
+}
+
+// Outstanding is the outstanding principal at the end of a period, bucketed by days past due.
+type Outstanding struct {
+	Period               time.Time            `json:"period"`
+	Contracts            int                  `json:"contracts"`
+	OutstandingPrincipal float64              `json:"outstanding_principal"`
+	NplRatio             float64              `json:"npl_ratio"` // share of the principal more than 90 days past due
+	Buckets              []*OutstandingBucket `json:"buckets"`
+}
+
+// OutstandingBucket is the outstanding principal of one days past due bucket and its share of the total.
+type OutstandingBucket struct {
+	Bucket    string  `json:"bucket"`
+	Contracts int     `json:"contracts"`
+	Principal float64 `json:"principal"`
+	Ratio     float64 `json:"ratio"`
+}
+
+// ConversionFunnel follows the customers who signed up in a period through approval and their first transaction.
+type ConversionFunnel struct {
+	Period         time.Time `json:"period"`
+	SignedUp       int       `json:"signed_up"`
+	Approved       int       `json:"approved"`
+	Transacted     int       `json:"transacted"`
+	ApprovalRate   float64   `json:"approval_rate"`
+	ConversionRate float64   `json:"conversion_rate"` // share of the sign-ups that made a transaction
+}
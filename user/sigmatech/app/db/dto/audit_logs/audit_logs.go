@@ -0,0 +1,47 @@
+package audit_logs
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME            = "audit_logs"
+	COLUM_UUID            = "uuid"
+	COLUMN_ACTOR_UUID     = "actor_uuid"
+	COLUMN_ACTOR_EMAIL    = "actor_email"
+	COLUMN_ACTION         = "action"
+	COLUMN_ENTITY         = "entity"
+	COLUMN_ENTITY_ID      = "entity_id"
+	COLUMN_METHOD         = "method"
+	COLUMN_PATH           = "path"
+	COLUMN_STATUS_CODE    = "status_code"
+	COLUMN_CHANGES        = "changes"
+	COLUMN_CORRELATION_ID = "correlation_id"
+	COLUMN_CLIENT_IP      = "client_ip"
+	COLUMN_USER_AGENT     = "user_agent"
+	COLUMN_CREATED_AT     = "created_at"
+)
+
+// AuditLog is one admin mutation. Changes holds the before and after values of the fields that
+// changed as JSON, with sensitive fields redacted. Audit logs are never updated nor deleted.
+type AuditLog struct {
+	Uuid          uuid.UUID  `json:"uuid"`
+	ActorUuid     *uuid.UUID `json:"actor_uuid"`
+	ActorEmail    *string    `json:"actor_email"`
+	Action        string     `json:"action"`
+	Entity        string     `json:"entity"`
+	EntityId      *string    `json:"entity_id"`
+	Method        string     `json:"method"`
+	Path          string     `json:"path"`
+	StatusCode    int        `json:"status_code"`
+	Changes       *string    `json:"changes"`
+	CorrelationId *string    `json:"correlation_id"`
+	ClientIp      *string    `json:"client_ip"`
+	UserAgent     *string    `json:"user_agent"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (u *AuditLog) Validate() error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package collection_activities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                   = "collection_activities"
+	COLUM_UUID                   = "uuid"
+	COLUMN_TRANSACTION_UUID      = "transaction_uuid"
+	COLUMN_AGENT_UUID            = "agent_uuid"
+	COLUMN_TYPE                  = "type"
+	COLUMN_OUTCOME               = "outcome"
+	COLUMN_PROMISE_TO_PAY_DATE   = "promise_to_pay_date"
+	COLUMN_PROMISE_TO_PAY_AMOUNT = "promise_to_pay_amount"
+	COLUMN_NOTE                  = "note"
+	COLUMN_CONTACTED_AT          = "contacted_at"
+	COLUMN_CREATED_AT            = "created_at"
+	COLUMN_UPDATED_AT            = "updated_at"
+)
+
+const (
+	TYPE_CALL  = "call"
+	TYPE_VISIT = "visit"
+)
+
+const (
+	OUTCOME_PROMISE_TO_PAY = "promise_to_pay"
+	OUTCOME_PAID           = "paid"
+	OUTCOME_NO_ANSWER      = "no_answer"
+	OUTCOME_NOT_FOUND      = "not_found"
+	OUTCOME_WRONG_NUMBER   = "wrong_number"
+	OUTCOME_REFUSED        = "refused"
+	OUTCOME_OTHER          = "other"
+)
+
+var (
+	Types    = []string{TYPE_CALL, TYPE_VISIT}
+	Outcomes = []string{OUTCOME_PROMISE_TO_PAY, OUTCOME_PAID, OUTCOME_NO_ANSWER, OUTCOME_NOT_FOUND, OUTCOME_WRONG_NUMBER, OUTCOME_REFUSED, OUTCOME_OTHER}
+)
+
+// CollectionActivity is one call or visit made to collect a contract, with its outcome.
+type CollectionActivity struct {
+	Uuid               uuid.UUID  `json:"uuid"`
+	TransactionUuid    uuid.UUID  `json:"transaction_uuid"`
+	AgentUuid          *uuid.UUID `json:"agent_uuid"`
+	Type               string     `json:"type"`
+	Outcome            string     `json:"outcome"`
+	PromiseToPayDate   *time.Time `json:"promise_to_pay_date"`
+	PromiseToPayAmount *float64   `json:"promise_to_pay_amount"`
+	Note               *string    `json:"note"`
+	ContactedAt        time.Time  `json:"contacted_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (u *CollectionActivity) Validate() error {
+	return nil
+}
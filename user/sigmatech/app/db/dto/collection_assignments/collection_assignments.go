@@ -0,0 +1,32 @@
+package collection_assignments
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME              = "collection_assignments"
+	COLUM_UUID              = "uuid"
+	COLUMN_TRANSACTION_UUID = "transaction_uuid"
+	COLUMN_AGENT_UUID       = "agent_uuid"
+	COLUMN_NOTE             = "note"
+	COLUMN_CREATED_AT       = "created_at"
+	COLUMN_CREATED_BY       = "created_by"
+	COLUMN_UPDATED_AT       = "updated_at"
+)
+
+// CollectionAssignment is the collection agent in charge of a contract, a contract has at most one.
+type CollectionAssignment struct {
+	Uuid            uuid.UUID  `json:"uuid"`
+	TransactionUuid uuid.UUID  `json:"transaction_uuid"`
+	AgentUuid       uuid.UUID  `json:"agent_uuid"`
+	Note            *string    `json:"note"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CreatedBy       *uuid.UUID `json:"created_by"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (u *CollectionAssignment) Validate() error {
+	return nil
+}
@@ -8,24 +8,34 @@ import (
 )
 
 const (
-	TABLE_NAME        = "customers"
-	COLUM_UUID        = "uuid"
-	COLUMN_NAME       = "name"
-	COLUMN_EMAIL      = "email"
-	COLUMN_PASSWORD   = "password"
-	COLUMN_IS_ACTIVE  = "is_active"
-	COLUMN_CREATED_AT = "created_at"
-	COLUMN_UPDATED_AT = "updated_at"
+	TABLE_NAME               = "customers"
+	COLUM_UUID               = "uuid"
+	COLUMN_NAME              = "name"
+	COLUMN_EMAIL             = "email"
+	COLUMN_PASSWORD          = "password"
+	COLUMN_LAST_LOGIN        = "last_login"
+	COLUMN_LAST_LOGIN_IP     = "last_login_ip"
+	COLUMN_IS_ACTIVE         = "is_active"
+	COLUMN_EMAIL_VERIFIED_AT = "email_verified_at"
+	COLUMN_CREATED_AT        = "created_at"
+	COLUMN_UPDATED_AT        = "updated_at"
+	COLUMN_DELETED_AT        = "deleted_at"
 )
 
+// Customer rows are soft deleted: gorm sets DeletedAt instead of deleting them, and leaves them out
+// of the queries it builds on the model. Queries without the model filter on COLUMN_DELETED_AT themselves.
 type Customer struct {
-	Uuid      uuid.UUID `json:"uuid"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password,omitempty"`
-	IsActive  *bool     `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Uuid            uuid.UUID  `json:"uuid"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password,omitempty"`
+	IsActive        *bool      `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	LastLogin       *time.Time `json:"last_login"`
+	LastLoginIp     *string    `json:"last_login_ip"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
 }
 
 func (u *Customer) Validate() error {
@@ -0,0 +1,28 @@
+package email_verifications
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME           = "email_verifications"
+	COLUM_UUID           = "uuid"
+	COLUMN_CUSTOMER_UUID = "customer_uuid"
+	COLUMN_EMAIL         = "email"
+	COLUMN_TOKEN_HASH    = "token_hash"
+	COLUMN_EXPIRES_AT    = "expires_at"
+	COLUMN_CONSUMED_AT   = "consumed_at"
+	COLUMN_CREATED_AT    = "created_at"
+)
+
+// EmailVerification is a token emailed to a customer to confirm they own the email they signed up with.
+type EmailVerification struct {
+	Uuid         uuid.UUID  `json:"uuid"`
+	CustomerUuid uuid.UUID  `json:"customer_uuid"`
+	Email        string     `json:"email"`
+	TokenHash    string     `json:"-"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	ConsumedAt   *time.Time `json:"consumed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
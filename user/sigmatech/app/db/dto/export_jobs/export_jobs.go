@@ -0,0 +1,47 @@
+package export_jobs
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME         = "export_jobs"
+	COLUM_UUID         = "uuid"
+	COLUMN_DATASET     = "dataset"
+	COLUMN_FORMAT      = "format"
+	COLUMN_PARAMS      = "params"
+	COLUMN_STATUS      = "status"
+	COLUMN_TOTAL_ROWS  = "total_rows"
+	COLUMN_FILE_NAME   = "file_name"
+	COLUMN_OBJECT_KEY  = "object_key"
+	COLUMN_ERROR       = "error"
+	COLUMN_STARTED_AT  = "started_at"
+	COLUMN_FINISHED_AT = "finished_at"
+	COLUMN_CREATED_AT  = "created_at"
+	COLUMN_CREATED_BY  = "created_by"
+	COLUMN_UPDATED_AT  = "updated_at"
+)
+
+// ExportJob is an export too large to be streamed in the response, it is uploaded to S3 once completed.
+// Params holds the filters and columns of the export as JSON.
+type ExportJob struct {
+	Uuid       uuid.UUID  `json:"uuid"`
+	Dataset    string     `json:"dataset"`
+	Format     string     `json:"format"`
+	Params     string     `json:"params"`
+	Status     string     `json:"status"`
+	TotalRows  int        `json:"total_rows"`
+	FileName   *string    `json:"file_name"`
+	ObjectKey  *string    `json:"-"`
+	Error      *string    `json:"error"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  *uuid.UUID `json:"created_by"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (u *ExportJob) Validate() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package merchants
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+	"user/sigmatech/app/service/util"
+)
+
+const (
+	TABLE_NAME        = "merchants"
+	COLUM_UUID        = "uuid"
+	COLUMN_CODE       = "code"
+	COLUMN_NAME       = "name"
+	COLUMN_EMAIL      = "email"
+	COLUMN_IS_ACTIVE  = "is_active"
+	COLUMN_CREATED_AT = "created_at"
+	COLUMN_CREATED_BY = "created_by"
+	COLUMN_UPDATED_AT = "updated_at"
+	COLUMN_UPDATED_BY = "updated_by"
+)
+
+type Merchant struct {
+	Uuid      uuid.UUID  `json:"uuid"`
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	Email     *string    `json:"email"`
+	IsActive  *bool      `json:"is_active"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UpdatedBy *uuid.UUID `json:"updated_by"`
+}
+
+func (u *Merchant) Validate() error {
+	if u.Code == "" {
+		return fmt.Errorf("code can't be empty")
+	}
+	if u.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	if u.Email != nil && *u.Email != "" && !util.IsValidEmail(*u.Email) {
+		return fmt.Errorf("email is not valid")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package mfa_recovery_codes
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME        = "mfa_recovery_codes"
+	COLUM_UUID        = "uuid"
+	COLUMN_USER_UUID  = "user_uuid"
+	COLUMN_CODE_HASH  = "code_hash"
+	COLUMN_USED_AT    = "used_at"
+	COLUMN_CREATED_AT = "created_at"
+)
+
+// RecoveryCode signs a user in once when they lost their authenticator, only its hash is stored.
+type RecoveryCode struct {
+	Uuid      uuid.UUID  `json:"uuid"`
+	UserUuid  uuid.UUID  `json:"user_uuid"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package notifications
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME            = "notifications"
+	COLUM_UUID            = "uuid"
+	COLUMN_CUSTOMER_UUID  = "customer_uuid"
+	COLUMN_EVENT_TYPE     = "event_type"
+	COLUMN_REFERENCE_UUID = "reference_uuid"
+	COLUMN_TITLE          = "title"
+	COLUMN_BODY           = "body"
+	COLUMN_IS_READ        = "is_read"
+	COLUMN_READ_AT        = "read_at"
+	COLUMN_CREATED_AT     = "created_at"
+	COLUMN_UPDATED_AT     = "updated_at"
+)
+
+type Notification struct {
+	Uuid          uuid.UUID  `json:"uuid"`
+	CustomerUuid  uuid.UUID  `json:"customer_uuid"`
+	EventType     string     `json:"event_type"`
+	ReferenceUuid *uuid.UUID `json:"reference_uuid"`
+	Title         string     `json:"title"`
+	Body          string     `json:"body"`
+	IsRead        *bool      `json:"is_read"`
+	ReadAt        *time.Time `json:"read_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (u *Notification) Validate() error {
+	return nil
+}
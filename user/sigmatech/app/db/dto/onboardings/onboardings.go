@@ -0,0 +1,14 @@
+package onboardings
+
+import (
+	cif_DBModels "user/sigmatech/app/db/dto/customer_information_files"
+	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+)
+
+// Onboarding is everything sign-up creates for one customer: the customer, its CIF and its limits.
+type Onboarding struct {
+	Customer       customers_DBModels.Customer              `json:"customer"`
+	CIF            cif_DBModels.CustomerInformationFile     `json:"cif"`
+	CustomerLimits []*customerLimits_DBModels.CustomerLimit `json:"customer_limits"`
+}
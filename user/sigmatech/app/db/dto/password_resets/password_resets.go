@@ -0,0 +1,38 @@
+package password_resets
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME          = "password_resets"
+	COLUM_UUID          = "uuid"
+	COLUMN_SUBJECT_UUID = "subject_uuid"
+	COLUMN_SUBJECT_TYPE = "subject_type"
+	COLUMN_EMAIL        = "email"
+	COLUMN_OTP_HASH     = "otp_hash"
+	COLUMN_ATTEMPTS     = "attempts"
+	COLUMN_EXPIRES_AT   = "expires_at"
+	COLUMN_CONSUMED_AT  = "consumed_at"
+	COLUMN_CREATED_AT   = "created_at"
+	COLUMN_UPDATED_AT   = "updated_at"
+
+	// Subjects a password is reset for
+	SUBJECT_USER     = "user"
+	SUBJECT_CUSTOMER = "customer"
+)
+
+// PasswordReset is a one time password emailed to reset a forgotten password.
+type PasswordReset struct {
+	Uuid        uuid.UUID  `json:"uuid"`
+	SubjectUuid uuid.UUID  `json:"subject_uuid"`
+	SubjectType string     `json:"subject_type"`
+	Email       string     `json:"email"`
+	OtpHash     string     `json:"-"`
+	Attempts    int        `json:"attempts"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ConsumedAt  *time.Time `json:"consumed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
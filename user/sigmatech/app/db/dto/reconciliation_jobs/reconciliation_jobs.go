@@ -0,0 +1,53 @@
+package reconciliation_jobs
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME            = "reconciliation_jobs"
+	COLUM_UUID            = "uuid"
+	COLUMN_FILE_NAME      = "file_name"
+	COLUMN_LAYOUT         = "layout"
+	COLUMN_STATUS         = "status"
+	COLUMN_TOTAL_ROWS     = "total_rows"
+	COLUMN_MATCHED_ROWS   = "matched_rows"
+	COLUMN_REVIEW_ROWS    = "review_rows"
+	COLUMN_UNMATCHED_ROWS = "unmatched_rows"
+	COLUMN_DUPLICATE_ROWS = "duplicate_rows"
+	COLUMN_INVALID_ROWS   = "invalid_rows"
+	COLUMN_MATCHED_AMOUNT = "matched_amount"
+	COLUMN_ERROR          = "error"
+	COLUMN_STARTED_AT     = "started_at"
+	COLUMN_FINISHED_AT    = "finished_at"
+	COLUMN_CREATED_AT     = "created_at"
+	COLUMN_CREATED_BY     = "created_by"
+	COLUMN_UPDATED_AT     = "updated_at"
+)
+
+// ReconciliationJob is one imported bank mutation file. The row counters make up the
+// reconciliation report once the job is completed.
+type ReconciliationJob struct {
+	Uuid          uuid.UUID  `json:"uuid"`
+	FileName      string     `json:"file_name"`
+	Layout        string     `json:"layout"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	MatchedRows   int        `json:"matched_rows"`
+	ReviewRows    int        `json:"review_rows"`
+	UnmatchedRows int        `json:"unmatched_rows"`
+	DuplicateRows int        `json:"duplicate_rows"`
+	InvalidRows   int        `json:"invalid_rows"`
+	MatchedAmount float64    `json:"matched_amount"`
+	Error         *string    `json:"error"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CreatedBy     *uuid.UUID `json:"created_by"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (u *ReconciliationJob) Validate() error {
+	return nil
+}
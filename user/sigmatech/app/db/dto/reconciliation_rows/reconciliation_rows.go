@@ -0,0 +1,64 @@
+package reconciliation_rows
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	TABLE_NAME                          = "reconciliation_rows"
+	COLUM_UUID                          = "uuid"
+	COLUMN_JOB_UUID                     = "job_uuid"
+	COLUMN_ROW_NUMBER                   = "row_number"
+	COLUMN_TRANSACTION_DATE             = "transaction_date"
+	COLUMN_REFERENCE                    = "reference"
+	COLUMN_DESCRIPTION                  = "description"
+	COLUMN_VA_NUMBER                    = "va_number"
+	COLUMN_CONTRACT_NUMBER              = "contract_number"
+	COLUMN_AMOUNT                       = "amount"
+	COLUMN_STATUS                       = "status"
+	COLUMN_REASON                       = "reason"
+	COLUMN_TRANSACTION_INSTALLMENT_UUID = "transaction_installment_uuid"
+	COLUMN_CANDIDATE_UUIDS              = "candidate_uuids"
+	COLUMN_RAW                          = "raw"
+	COLUMN_RESOLVED_AT                  = "resolved_at"
+	COLUMN_RESOLVED_BY                  = "resolved_by"
+	COLUMN_CREATED_AT                   = "created_at"
+	COLUMN_UPDATED_AT                   = "updated_at"
+)
+
+// ReconciliationRow is one mutation line of an imported file and the installment it was matched to.
+// CandidateUuids is a comma separated list of the installments a row in review could belong to.
+type ReconciliationRow struct {
+	Uuid                       uuid.UUID  `json:"uuid"`
+	JobUuid                    uuid.UUID  `json:"job_uuid"`
+	RowNumber                  int        `json:"row_number"`
+	TransactionDate            *time.Time `json:"transaction_date"`
+	Reference                  *string    `json:"reference"`
+	Description                *string    `json:"description"`
+	VaNumber                   *string    `json:"va_number"`
+	ContractNumber             *string    `json:"contract_number"`
+	Amount                     float64    `json:"amount"`
+	Status                     string     `json:"status"`
+	Reason                     *string    `json:"reason"`
+	TransactionInstallmentUuid *uuid.UUID `json:"transaction_installment_uuid"`
+	CandidateUuids             *string    `json:"candidate_uuids"`
+	Raw                        string     `json:"raw"`
+	ResolvedAt                 *time.Time `json:"resolved_at"`
+	ResolvedBy                 *uuid.UUID `json:"resolved_by"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+func (u *ReconciliationRow) Validate() error {
+	return nil
+}
+
+// GetCandidateUuids returns the candidate installments as a slice.
+func (u *ReconciliationRow) GetCandidateUuids() []string {
+	if u.CandidateUuids == nil || *u.CandidateUuids == "" {
+		return nil
+	}
+	return strings.Split(*u.CandidateUuids, ",")
+}
@@ -0,0 +1,17 @@
+package role_permissions
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	TABLE_NAME        = "role_permissions"
+	COLUMN_ROLE_UUID  = "role_uuid"
+	COLUMN_PERMISSION = "permission"
+)
+
+// RolePermission is a permission of a role, e.g. customer.read, or * for every permission.
+type RolePermission struct {
+	RoleUuid   uuid.UUID `json:"role_uuid"`
+	Permission string    `json:"permission"`
+}
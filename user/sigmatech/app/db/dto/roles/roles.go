@@ -0,0 +1,29 @@
+package roles
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME          = "roles"
+	COLUM_UUID          = "uuid"
+	COLUMN_CODE         = "code"
+	COLUMN_NAME         = "name"
+	COLUMN_DESCRIPTION  = "description"
+	COLUMN_MFA_REQUIRED = "mfa_required"
+	COLUMN_CREATED_AT   = "created_at"
+	COLUMN_UPDATED_AT   = "updated_at"
+)
+
+// Role is a named set of permissions given to admin users. Roles are seeded by migrations. Users with
+// a role that has MfaRequired must sign in with a second factor.
+type Role struct {
+	Uuid        uuid.UUID `json:"uuid"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description"`
+	MfaRequired bool      `json:"mfa_required"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
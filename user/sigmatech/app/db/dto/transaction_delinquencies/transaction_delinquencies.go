@@ -0,0 +1,68 @@
+package transaction_delinquencies
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                  = "transaction_delinquencies"
+	COLUMN_TRANSACTION_UUID     = "transaction_uuid"
+	COLUMN_CUSTOMER_UUID        = "customer_uuid"
+	COLUMN_CUSTOMER_NAME        = "customer_name"
+	COLUMN_CONTRACT_NUMBER      = "contract_number"
+	COLUMN_ASSET_NAME           = "asset_name"
+	COLUMN_DPD                  = "dpd"
+	COLUMN_BUCKET               = "bucket"
+	COLUMN_OLDEST_DUE_DATE      = "oldest_due_date"
+	COLUMN_OVERDUE_INSTALLMENTS = "overdue_installments"
+	COLUMN_OVERDUE_AMOUNT       = "overdue_amount"
+	COLUMN_OUTSTANDING_AMOUNT   = "outstanding_amount"
+	COLUMN_AGENT_UUID           = "agent_uuid"
+	COLUMN_LAST_OUTCOME         = "last_outcome"
+	COLUMN_LAST_CONTACTED_AT    = "last_contacted_at"
+	COLUMN_PROMISE_TO_PAY_DATE  = "promise_to_pay_date"
+	COLUMN_CREATED_AT           = "created_at"
+	COLUMN_UPDATED_AT           = "updated_at"
+)
+
+// Days past due buckets, as computed by the transaction_delinquencies view
+const (
+	BUCKET_CURRENT = "current"
+	BUCKET_1_30    = "1-30"
+	BUCKET_31_60   = "31-60"
+	BUCKET_61_90   = "61-90"
+	BUCKET_OVER_90 = "90+"
+)
+
+var Buckets = []string{BUCKET_CURRENT, BUCKET_1_30, BUCKET_31_60, BUCKET_61_90, BUCKET_OVER_90}
+
+// TransactionDelinquency is the days past due of an open contract, read from the
+// transaction_delinquencies view together with its collection status.
+type TransactionDelinquency struct {
+	TransactionUuid     uuid.UUID  `json:"transaction_uuid"`
+	CustomerUuid        uuid.UUID  `json:"customer_uuid"`
+	CustomerName        string     `json:"customer_name"`
+	ContractNumber      string     `json:"contract_number"`
+	AssetName           string     `json:"asset_name"`
+	Dpd                 int        `json:"dpd"`
+	Bucket              string     `json:"bucket"`
+	OldestDueDate       *time.Time `json:"oldest_due_date"`
+	OverdueInstallments int        `json:"overdue_installments"`
+	OverdueAmount       float64    `json:"overdue_amount"`
+	OutstandingAmount   float64    `json:"outstanding_amount"`
+	AgentUuid           *uuid.UUID `json:"agent_uuid"`
+	LastOutcome         *string    `json:"last_outcome"`
+	LastContactedAt     *time.Time `json:"last_contacted_at"`
+	PromiseToPayDate    *time.Time `json:"promise_to_pay_date"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// BucketSummary is the number of open contracts and their overdue amount in one bucket.
+type BucketSummary struct {
+	Bucket            string  `json:"bucket"`
+	Contracts         int     `json:"contracts"`
+	OverdueAmount     float64 `json:"overdue_amount"`
+	OutstandingAmount float64 `json:"outstanding_amount"`
+}
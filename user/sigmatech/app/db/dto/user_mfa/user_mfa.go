@@ -0,0 +1,28 @@
+package user_mfa
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME            = "user_mfa"
+	COLUMN_USER_UUID      = "user_uuid"
+	COLUMN_SECRET         = "secret"
+	COLUMN_ENABLED_AT     = "enabled_at"
+	COLUMN_LAST_USED_STEP = "last_used_step"
+	COLUMN_CREATED_AT     = "created_at"
+	COLUMN_UPDATED_AT     = "updated_at"
+)
+
+// UserMfa is the TOTP enrollment of a user. The secret is stored encrypted, the enrollment only counts
+// once EnabledAt is set by a first valid code. LastUsedStep is the time step of the last accepted code,
+// so a code can't be used twice.
+type UserMfa struct {
+	UserUuid     uuid.UUID  `json:"user_uuid"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
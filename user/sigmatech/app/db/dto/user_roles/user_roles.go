@@ -0,0 +1,22 @@
+package user_roles
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME        = "user_roles"
+	COLUMN_USER_UUID  = "user_uuid"
+	COLUMN_ROLE_UUID  = "role_uuid"
+	COLUMN_CREATED_AT = "created_at"
+	COLUMN_CREATED_BY = "created_by"
+)
+
+// UserRole gives a role to a user.
+type UserRole struct {
+	UserUuid  uuid.UUID  `json:"user_uuid"`
+	RoleUuid  uuid.UUID  `json:"role_uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+}
@@ -8,26 +8,33 @@ import (
 )
 
 const (
-	TABLE_NAME        = "users"
-	COLUM_UUID        = "uuid"
-	COLUMN_NAME       = "name"
-	COLUMN_EMAIL      = "email"
-	COLUMN_PASSWORD   = "password"
-	COLUMN_CREATED_AT = "created_at"
-	COLUMN_CREATED_BY = "created_by"
-	COLUMN_UPDATED_AT = "updated_at"
-	COLUMN_UPDATED_BY = "updated_by"
+	TABLE_NAME           = "users"
+	COLUM_UUID           = "uuid"
+	COLUMN_NAME          = "name"
+	COLUMN_EMAIL         = "email"
+	COLUMN_PASSWORD      = "password"
+	COLUMN_LAST_LOGIN    = "last_login"
+	COLUMN_LAST_LOGIN_IP = "last_login_ip"
+	COLUMN_CREATED_AT    = "created_at"
+	COLUMN_CREATED_BY    = "created_by"
+	COLUMN_UPDATED_AT    = "updated_at"
+	COLUMN_UPDATED_BY    = "updated_by"
+	COLUMN_DELETED_AT    = "deleted_at"
 )
 
+// User is soft deleted like Customer, see there.
 type User struct {
-	Uuid      uuid.UUID  `json:"uuid"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	Password  string     `json:"password,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	CreatedBy *uuid.UUID `json:"created_by"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	UpdatedBy *uuid.UUID `json:"updated_by"`
+	Uuid        uuid.UUID  `json:"uuid"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	Password    string     `json:"password,omitempty"`
+	LastLogin   *time.Time `json:"last_login"`
+	LastLoginIp *string    `json:"last_login_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	UpdatedBy   *uuid.UUID `json:"updated_by"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
 
 func (u *User) ValidateUser() error {
@@ -66,3 +73,9 @@ func (u User) ValidateSignUpDetails() error {
 	}
 	return nil
 }
+
+// UserDetail is a user with the codes of its roles.
+type UserDetail struct {
+	User
+	Roles []string `json:"roles"`
+}
@@ -0,0 +1,48 @@
+package webhook_deliveries
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TABLE_NAME                = "webhook_deliveries"
+	COLUM_UUID                = "uuid"
+	COLUMN_SUBSCRIPTION_UUID  = "subscription_uuid"
+	COLUMN_EVENT_UUID         = "event_uuid"
+	COLUMN_EVENT_TYPE         = "event_type"
+	COLUMN_REFERENCE_UUID     = "reference_uuid"
+	COLUMN_PAYLOAD            = "payload"
+	COLUMN_STATUS             = "status"
+	COLUMN_ATTEMPTS           = "attempts"
+	COLUMN_NEXT_ATTEMPT_AT    = "next_attempt_at"
+	COLUMN_LAST_RESPONSE_CODE = "last_response_code"
+	COLUMN_LAST_RESPONSE_BODY = "last_response_body"
+	COLUMN_LAST_ERROR         = "last_error"
+	COLUMN_DELIVERED_AT       = "delivered_at"
+	COLUMN_CREATED_AT         = "created_at"
+	COLUMN_UPDATED_AT         = "updated_at"
+)
+
+// WebhookDelivery is one event queued for one subscription, together with the outcome of its last attempt.
+type WebhookDelivery struct {
+	Uuid             uuid.UUID  `json:"uuid"`
+	SubscriptionUuid uuid.UUID  `json:"subscription_uuid"`
+	EventUuid        uuid.UUID  `json:"event_uuid"`
+	EventType        string     `json:"event_type"`
+	ReferenceUuid    *uuid.UUID `json:"reference_uuid"`
+	Payload          string     `json:"payload"`
+	Status           string     `json:"status"`
+	Attempts         int        `json:"attempts"`
+	NextAttemptAt    *time.Time `json:"next_attempt_at"`
+	LastResponseCode *int       `json:"last_response_code"`
+	LastResponseBody *string    `json:"last_response_body"`
+	LastError        *string    `json:"last_error"`
+	DeliveredAt      *time.Time `json:"delivered_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (u *WebhookDelivery) Validate() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS notification_templates (
+    uuid UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    CONSTRAINT uq_notification_templates_event_channel_locale UNIQUE (event_type, channel, locale)
+);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    uuid UUID PRIMARY KEY,
+    customer_uuid UUID UNIQUE REFERENCES customers(uuid) ON DELETE CASCADE,
+    locale VARCHAR(10) NOT NULL DEFAULT 'id',
+    in_app BOOLEAN NOT NULL DEFAULT true,
+    email BOOLEAN NOT NULL DEFAULT true,
+    sms BOOLEAN NOT NULL DEFAULT false,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    uuid UUID PRIMARY KEY,
+    customer_uuid UUID REFERENCES customers(uuid) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    reference_uuid UUID NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    is_read BOOLEAN NOT NULL DEFAULT false,
+    read_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_customer_uuid_is_read ON notifications (customer_uuid, is_read);
+
+INSERT INTO notification_templates (uuid, event_type, channel, locale, subject, body)
+values (gen_random_uuid(), 'customer.approved', 'in_app', 'id', 'Pengajuan disetujui', 'Halo {{.name}}, pengajuan limit Anda telah disetujui. Anda sudah dapat melakukan transaksi.'),
+       (gen_random_uuid(), 'customer.approved', 'in_app', 'en', 'Application approved', 'Hi {{.name}}, your limit application has been approved. You can start making transactions.'),
+       (gen_random_uuid(), 'customer.approved', 'email', 'id', 'Pengajuan limit Anda disetujui', 'Halo {{.name}}, pengajuan limit Anda telah disetujui. Silakan masuk ke aplikasi untuk melihat limit Anda.'),
+       (gen_random_uuid(), 'customer.approved', 'email', 'en', 'Your limit application is approved', 'Hi {{.name}}, your limit application has been approved. Sign in to the app to see your limits.'),
+       (gen_random_uuid(), 'transaction.created', 'in_app', 'id', 'Transaksi berhasil', 'Kontrak {{.contract_number}} untuk {{.asset_name}} berhasil dibuat dengan {{.installment_count}}x cicilan.'),
+       (gen_random_uuid(), 'transaction.created', 'in_app', 'en', 'Transaction booked', 'Contract {{.contract_number}} for {{.asset_name}} has been created with {{.installment_count}} installments.'),
+       (gen_random_uuid(), 'transaction.created', 'email', 'id', 'Kontrak {{.contract_number}} berhasil dibuat', 'Halo {{.name}}, kontrak {{.contract_number}} untuk {{.asset_name}} berhasil dibuat. Cicilan per bulan {{.installment_amount}} sebanyak {{.installment_count}}x.'),
+       (gen_random_uuid(), 'transaction.created', 'email', 'en', 'Contract {{.contract_number}} created', 'Hi {{.name}}, contract {{.contract_number}} for {{.asset_name}} has been created. Monthly installment {{.installment_amount}} for {{.installment_count}} months.'),
+       (gen_random_uuid(), 'installment.paid', 'in_app', 'id', 'Pembayaran diterima', 'Pembayaran cicilan ke-{{.term}} kontrak {{.contract_number}} sebesar {{.amount_paid}} telah diterima.'),
+       (gen_random_uuid(), 'installment.paid', 'in_app', 'en', 'Payment received', 'Payment of {{.amount_paid}} for installment {{.term}} of contract {{.contract_number}} has been received.'),
+       (gen_random_uuid(), 'installment.overdue', 'in_app', 'id', 'Cicilan terlambat', 'Cicilan ke-{{.term}} kontrak {{.contract_number}} telah melewati jatuh tempo {{.due_date}}. Segera lakukan pembayaran.'),
+       (gen_random_uuid(), 'installment.overdue', 'in_app', 'en', 'Installment overdue', 'Installment {{.term}} of contract {{.contract_number}} was due on {{.due_date}}. Please pay as soon as possible.'),
+       (gen_random_uuid(), 'installment.overdue', 'email', 'id', 'Cicilan kontrak {{.contract_number}} terlambat', 'Halo {{.name}}, cicilan ke-{{.term}} kontrak {{.contract_number}} telah melewati jatuh tempo {{.due_date}}. Segera lakukan pembayaran.'),
+       (gen_random_uuid(), 'installment.overdue', 'email', 'en', 'Contract {{.contract_number}} installment overdue', 'Hi {{.name}}, installment {{.term}} of contract {{.contract_number}} was due on {{.due_date}}. Please pay as soon as possible.');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notifications_customer_uuid_is_read;
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notification_templates;
+-- +goose StatementEnd
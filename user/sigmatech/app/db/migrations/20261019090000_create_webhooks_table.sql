@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    uuid UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    event_types TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_by UUID REFERENCES users(uuid) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    uuid UUID PRIMARY KEY,
+    subscription_uuid UUID REFERENCES webhook_subscriptions(uuid) ON DELETE CASCADE,
+    event_uuid UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    reference_uuid UUID NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at timestamp without time zone NULL,
+    last_response_code INT NULL,
+    last_response_body TEXT NULL,
+    last_error TEXT NULL,
+    delivered_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_uuid ON webhook_deliveries (subscription_uuid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_uuid;
+DROP INDEX IF EXISTS idx_webhook_deliveries_status_next_attempt_at;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS merchants (
+    uuid UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    CONSTRAINT uq_merchants_code UNIQUE (code)
+);
+
+CREATE TABLE IF NOT EXISTS merchant_api_keys (
+    uuid UUID PRIMARY KEY,
+    merchant_uuid UUID REFERENCES merchants(uuid) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT NOT NULL,
+    expires_at timestamp without time zone NULL,
+    last_used_at timestamp without time zone NULL,
+    revoked_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    CONSTRAINT uq_merchant_api_keys_prefix UNIQUE (prefix)
+);
+
+CREATE TABLE IF NOT EXISTS partner_consents (
+    uuid UUID PRIMARY KEY,
+    merchant_uuid UUID REFERENCES merchants(uuid) ON DELETE CASCADE,
+    customer_uuid UUID REFERENCES customers(uuid) ON DELETE CASCADE,
+    customer_limit_uuid UUID REFERENCES customer_limits(uuid) ON DELETE CASCADE,
+    asset_name VARCHAR(255) NOT NULL,
+    otr DECIMAL(15, 2) NOT NULL,
+    otp_hash VARCHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at timestamp without time zone NOT NULL,
+    consumed_at timestamp without time zone NULL,
+    transaction_uuid UUID NULL REFERENCES transactions(uuid) ON DELETE SET NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS merchant_uuid UUID NULL REFERENCES merchants(uuid);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_merchant_uuid ON transactions (merchant_uuid);
+
+INSERT INTO notification_templates (uuid, event_type, channel, locale, subject, body)
+values (gen_random_uuid(), 'partner.consent', 'in_app', 'id', 'Kode persetujuan transaksi', 'Kode {{.otp}} untuk menyetujui transaksi {{.asset_name}} di {{.merchant_name}}. Berlaku {{.ttl_minutes}} menit, jangan berikan kode ini kepada siapa pun selain merchant tersebut.'),
+       (gen_random_uuid(), 'partner.consent', 'in_app', 'en', 'Transaction consent code', 'Use {{.otp}} to approve the {{.asset_name}} transaction at {{.merchant_name}}. Valid for {{.ttl_minutes}} minutes, only share it with that merchant.'),
+       (gen_random_uuid(), 'partner.consent', 'email', 'id', 'Kode persetujuan transaksi {{.merchant_name}}', 'Halo {{.name}}, kode {{.otp}} untuk menyetujui transaksi {{.asset_name}} di {{.merchant_name}}. Berlaku {{.ttl_minutes}} menit, jangan berikan kode ini kepada siapa pun selain merchant tersebut.'),
+       (gen_random_uuid(), 'partner.consent', 'email', 'en', '{{.merchant_name}} transaction consent code', 'Hi {{.name}}, use {{.otp}} to approve the {{.asset_name}} transaction at {{.merchant_name}}. Valid for {{.ttl_minutes}} minutes, only share it with that merchant.');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_templates WHERE event_type = 'partner.consent';
+
+DROP INDEX IF EXISTS idx_transactions_merchant_uuid;
+ALTER TABLE transactions DROP COLUMN IF EXISTS merchant_uuid;
+
+DROP TABLE IF EXISTS partner_consents;
+DROP TABLE IF EXISTS merchant_api_keys;
+DROP TABLE IF EXISTS merchants;
+-- +goose StatementEnd
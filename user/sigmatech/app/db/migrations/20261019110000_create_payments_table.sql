@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS virtual_accounts (
+    uuid UUID PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    account_number VARCHAR(50) NOT NULL,
+    customer_uuid UUID NOT NULL REFERENCES customers(uuid) ON DELETE CASCADE,
+    transaction_installment_uuid UUID NULL REFERENCES transaction_installments(uuid) ON DELETE CASCADE,
+    amount DECIMAL(15, 2) NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    expires_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    CONSTRAINT uq_virtual_accounts_provider_account_number UNIQUE (provider, account_number)
+);
+
+CREATE TABLE IF NOT EXISTS payment_callbacks (
+    uuid UUID PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    external_id VARCHAR(100) NOT NULL,
+    virtual_account_uuid UUID NULL REFERENCES virtual_accounts(uuid) ON DELETE SET NULL,
+    transaction_installment_uuid UUID NULL REFERENCES transaction_installments(uuid) ON DELETE SET NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    paid_at timestamp without time zone NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_payment_callbacks_provider_external_id UNIQUE (provider, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_virtual_accounts_customer_uuid ON virtual_accounts (customer_uuid);
+CREATE INDEX IF NOT EXISTS idx_virtual_accounts_transaction_installment_uuid ON virtual_accounts (transaction_installment_uuid);
+CREATE INDEX IF NOT EXISTS idx_payment_callbacks_virtual_account_uuid ON payment_callbacks (virtual_account_uuid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payment_callbacks_virtual_account_uuid;
+DROP INDEX IF EXISTS idx_virtual_accounts_transaction_installment_uuid;
+DROP INDEX IF EXISTS idx_virtual_accounts_customer_uuid;
+
+DROP TABLE IF EXISTS payment_callbacks;
+DROP TABLE IF EXISTS virtual_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS reconciliation_jobs (
+    uuid UUID PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    layout TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total_rows INT NOT NULL DEFAULT 0,
+    matched_rows INT NOT NULL DEFAULT 0,
+    review_rows INT NOT NULL DEFAULT 0,
+    unmatched_rows INT NOT NULL DEFAULT 0,
+    duplicate_rows INT NOT NULL DEFAULT 0,
+    invalid_rows INT NOT NULL DEFAULT 0,
+    matched_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at timestamp without time zone NULL,
+    finished_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_rows (
+    uuid UUID PRIMARY KEY,
+    job_uuid UUID NOT NULL REFERENCES reconciliation_jobs(uuid) ON DELETE CASCADE,
+    row_number INT NOT NULL,
+    transaction_date timestamp without time zone NULL,
+    reference VARCHAR(255) NULL,
+    description TEXT NULL,
+    va_number VARCHAR(50) NULL,
+    contract_number VARCHAR(255) NULL,
+    amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    reason TEXT NULL,
+    transaction_installment_uuid UUID NULL REFERENCES transaction_installments(uuid) ON DELETE SET NULL,
+    candidate_uuids TEXT NULL,
+    raw TEXT NOT NULL,
+    resolved_at timestamp without time zone NULL,
+    resolved_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_rows_job_uuid ON reconciliation_rows (job_uuid);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_rows_status ON reconciliation_rows (status);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_rows_reference ON reconciliation_rows (reference);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_reconciliation_rows_reference;
+DROP INDEX IF EXISTS idx_reconciliation_rows_status;
+DROP INDEX IF EXISTS idx_reconciliation_rows_job_uuid;
+
+DROP TABLE IF EXISTS reconciliation_rows;
+DROP TABLE IF EXISTS reconciliation_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,91 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS collection_assignments (
+    uuid UUID PRIMARY KEY,
+    transaction_uuid UUID NOT NULL REFERENCES transactions(uuid) ON DELETE CASCADE,
+    agent_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    note TEXT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    CONSTRAINT uq_collection_assignments_transaction_uuid UNIQUE (transaction_uuid)
+);
+
+CREATE TABLE IF NOT EXISTS collection_activities (
+    uuid UUID PRIMARY KEY,
+    transaction_uuid UUID NOT NULL REFERENCES transactions(uuid) ON DELETE CASCADE,
+    agent_uuid UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL,
+    outcome VARCHAR(30) NOT NULL,
+    promise_to_pay_date DATE NULL,
+    promise_to_pay_amount DECIMAL(15, 2) NULL,
+    note TEXT NULL,
+    contacted_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_assignments_agent_uuid ON collection_assignments (agent_uuid);
+CREATE INDEX IF NOT EXISTS idx_collection_activities_transaction_uuid ON collection_activities (transaction_uuid, contacted_at);
+CREATE INDEX IF NOT EXISTS idx_transaction_installments_transaction_uuid ON transaction_installments (transaction_uuid, due_date);
+
+-- Days past due counts from the oldest installment that is due and not fully paid
+CREATE OR REPLACE VIEW transaction_delinquencies AS
+SELECT
+    t.uuid AS transaction_uuid,
+    t.customer_uuid,
+    c.name AS customer_name,
+    t.contract_number,
+    t.asset_name,
+    o.dpd,
+    CASE
+        WHEN o.dpd = 0 THEN 'current'
+        WHEN o.dpd <= 30 THEN '1-30'
+        WHEN o.dpd <= 60 THEN '31-60'
+        WHEN o.dpd <= 90 THEN '61-90'
+        ELSE '90+'
+    END AS bucket,
+    o.oldest_due_date,
+    o.overdue_installments,
+    o.overdue_amount,
+    o.outstanding_amount,
+    a.agent_uuid,
+    l.outcome AS last_outcome,
+    l.contacted_at AS last_contacted_at,
+    l.promise_to_pay_date,
+    t.created_at,
+    t.updated_at
+FROM transactions t
+JOIN customers c ON c.uuid = t.customer_uuid
+CROSS JOIN LATERAL (
+    SELECT
+        COALESCE(CURRENT_DATE - MIN(ti.due_date) FILTER (WHERE ti.due_date < CURRENT_DATE AND ti.amount_paid < ti.amount), 0) AS dpd,
+        MIN(ti.due_date) FILTER (WHERE ti.due_date < CURRENT_DATE AND ti.amount_paid < ti.amount) AS oldest_due_date,
+        COUNT(*) FILTER (WHERE ti.due_date < CURRENT_DATE AND ti.amount_paid < ti.amount) AS overdue_installments,
+        COALESCE(SUM(ti.amount - ti.amount_paid) FILTER (WHERE ti.due_date < CURRENT_DATE AND ti.amount_paid < ti.amount), 0) AS overdue_amount,
+        COALESCE(SUM(ti.amount - ti.amount_paid) FILTER (WHERE ti.amount_paid < ti.amount), 0) AS outstanding_amount
+    FROM transaction_installments ti
+    WHERE ti.transaction_uuid = t.uuid
+) o
+LEFT JOIN collection_assignments a ON a.transaction_uuid = t.uuid
+LEFT JOIN LATERAL (
+    SELECT ca.outcome, ca.contacted_at, ca.promise_to_pay_date
+    FROM collection_activities ca
+    WHERE ca.transaction_uuid = t.uuid
+    ORDER BY ca.contacted_at DESC
+    LIMIT 1
+) l ON true
+WHERE t.is_done IS NOT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS transaction_delinquencies;
+
+DROP INDEX IF EXISTS idx_transaction_installments_transaction_uuid;
+DROP INDEX IF EXISTS idx_collection_activities_transaction_uuid;
+DROP INDEX IF EXISTS idx_collection_assignments_agent_uuid;
+
+DROP TABLE IF EXISTS collection_activities;
+DROP TABLE IF EXISTS collection_assignments;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE INDEX IF NOT EXISTS idx_transaction_installments_payment_at ON transaction_installments (payment_at);
+CREATE INDEX IF NOT EXISTS idx_customers_created_at ON customers (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_customers_created_at;
+DROP INDEX IF EXISTS idx_transaction_installments_payment_at;
+DROP INDEX IF EXISTS idx_transactions_created_at;
+-- +goose StatementEnd
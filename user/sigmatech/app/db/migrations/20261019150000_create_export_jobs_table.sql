@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS export_jobs (
+    uuid UUID PRIMARY KEY,
+    dataset VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    params TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total_rows INT NOT NULL DEFAULT 0,
+    file_name VARCHAR(255) NULL,
+    object_key VARCHAR(255) NULL,
+    error TEXT NULL,
+    started_at timestamp without time zone NULL,
+    finished_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_created_by ON export_jobs (created_by);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_export_jobs_created_by;
+
+DROP TABLE IF EXISTS export_jobs;
+-- +goose StatementEnd
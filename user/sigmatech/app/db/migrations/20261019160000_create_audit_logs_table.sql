@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_logs (
+    uuid UUID PRIMARY KEY,
+    actor_uuid UUID NULL,
+    actor_email VARCHAR(100) NULL,
+    action VARCHAR(100) NOT NULL,
+    entity VARCHAR(100) NOT NULL,
+    entity_id TEXT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    status_code INT NOT NULL,
+    changes TEXT NULL,
+    correlation_id VARCHAR(100) NULL,
+    client_ip VARCHAR(45) NULL,
+    user_agent TEXT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_uuid ON audit_logs (actor_uuid, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_entity;
+DROP INDEX IF EXISTS idx_audit_logs_actor_uuid;
+
+DROP TABLE IF EXISTS audit_logs;
+-- +goose StatementEnd
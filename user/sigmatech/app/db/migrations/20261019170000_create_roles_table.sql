@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS roles (
+    uuid UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_uuid UUID NOT NULL REFERENCES roles(uuid) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_uuid, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    role_uuid UUID NOT NULL REFERENCES roles(uuid) ON DELETE CASCADE,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    PRIMARY KEY (user_uuid, role_uuid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_uuid ON user_roles (role_uuid);
+
+INSERT INTO roles (uuid, code, name, description) VALUES
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e01', 'super_admin', 'Super Admin', 'Every permission, including user and role management'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'credit_approver', 'Credit Approver', 'Reviews customers and approves their limits'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'collections', 'Collections', 'Works the collections worklist of delinquent contracts'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'viewer', 'Viewer', 'Reads everything, changes nothing')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_uuid, permission) VALUES
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e01', '*'),
+
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'customer.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'customer.approve'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'transaction.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'analytics.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e02', 'export.read'),
+
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'customer.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'transaction.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'collection.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'collection.write'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e03', 'export.read'),
+
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'user.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'customer.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'transaction.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'notification.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'webhook.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'merchant.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'reconciliation.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'collection.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'analytics.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'export.read'),
+    ('6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e04', 'audit.read')
+ON CONFLICT DO NOTHING;
+
+-- Users created before roles keep the access they had
+INSERT INTO user_roles (user_uuid, role_uuid)
+SELECT uuid, '6f1c2a3e-0d1b-4c57-9a51-2f3b4c5d6e01' FROM users
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_roles_role_uuid;
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
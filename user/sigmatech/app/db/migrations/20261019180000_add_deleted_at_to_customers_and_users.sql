@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS deleted_at timestamp without time zone NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamp without time zone NULL;
+
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_customers_deleted_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
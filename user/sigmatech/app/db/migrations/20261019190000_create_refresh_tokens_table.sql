@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    uuid UUID PRIMARY KEY,
+    family_uuid UUID NOT NULL,
+    subject_uuid UUID NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    rotated_at timestamp without time zone NULL,
+    revoked_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_uuid ON refresh_tokens (family_uuid);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_subject ON refresh_tokens (subject_type, subject_uuid);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
+DROP INDEX IF EXISTS idx_refresh_tokens_subject;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_uuid;
+
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
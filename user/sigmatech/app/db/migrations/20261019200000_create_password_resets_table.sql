@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS password_resets (
+    uuid UUID PRIMARY KEY,
+    subject_uuid UUID NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    otp_hash VARCHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at timestamp without time zone NOT NULL,
+    consumed_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_subject ON password_resets (subject_type, subject_uuid);
+CREATE INDEX IF NOT EXISTS idx_password_resets_email_created_at ON password_resets (subject_type, email, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_password_resets_email_created_at;
+DROP INDEX IF EXISTS idx_password_resets_subject;
+
+DROP TABLE IF EXISTS password_resets;
+-- +goose StatementEnd
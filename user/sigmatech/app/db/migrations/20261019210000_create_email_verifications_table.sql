@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS email_verified_at timestamp without time zone NULL;
+
+-- Customers who signed up before verification existed keep their standing
+UPDATE customers SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    uuid UUID PRIMARY KEY,
+    customer_uuid UUID NOT NULL REFERENCES customers(uuid) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    consumed_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_verifications_token_hash ON email_verifications (token_hash);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_email_created_at ON email_verifications (email, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_email_verifications_email_created_at;
+DROP INDEX IF EXISTS idx_email_verifications_token_hash;
+
+DROP TABLE IF EXISTS email_verifications;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS email_verified_at;
+-- +goose StatementEnd
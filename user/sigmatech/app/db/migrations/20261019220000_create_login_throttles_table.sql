@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS last_login_ip VARCHAR(45) NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_ip VARCHAR(45) NULL;
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    uuid UUID PRIMARY KEY,
+    subject_type VARCHAR(20) NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    identifier VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    lockouts INT NOT NULL DEFAULT 0,
+    locked_until timestamp without time zone NULL,
+    last_failure_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_throttles_key ON login_throttles (subject_type, scope, identifier);
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles (last_failure_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_login_throttles_last_failure_at;
+DROP INDEX IF EXISTS idx_login_throttles_key;
+
+DROP TABLE IF EXISTS login_throttles;
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_ip;
+ALTER TABLE customers DROP COLUMN IF EXISTS last_login_ip;
+-- +goose StatementEnd
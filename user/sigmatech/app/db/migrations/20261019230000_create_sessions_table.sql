@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sessions (
+    uuid UUID PRIMARY KEY,
+    subject_uuid UUID NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    device VARCHAR(20) NOT NULL,
+    country_code VARCHAR(2) NULL,
+    country_name VARCHAR(100) NULL,
+    city VARCHAR(100) NULL,
+    new_country boolean NOT NULL DEFAULT 'false',
+    expires_at timestamp without time zone NOT NULL,
+    last_seen_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_subject ON sessions (subject_type, subject_uuid, created_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+DROP INDEX IF EXISTS idx_sessions_subject;
+
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
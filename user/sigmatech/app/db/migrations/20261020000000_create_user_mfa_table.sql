@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS mfa_required boolean NOT NULL DEFAULT 'false';
+
+-- Roles that approve limits or manage users and customers can't rely on a password alone
+UPDATE roles SET mfa_required = 'true' WHERE code IN ('super_admin', 'credit_approver');
+
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_uuid UUID PRIMARY KEY REFERENCES users(uuid) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at timestamp without time zone NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    uuid UUID PRIMARY KEY,
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at timestamp without time zone NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mfa_recovery_codes_code ON mfa_recovery_codes (user_uuid, code_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_mfa_recovery_codes_code;
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
+
+ALTER TABLE roles DROP COLUMN IF EXISTS mfa_required;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Soft deleted customers and users keep their rows, their emails are free to sign up with again
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_email_key;
+DROP INDEX IF EXISTS customers_email_key;
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE customers ADD CONSTRAINT customers_email_key UNIQUE (email);
+-- +goose StatementEnd
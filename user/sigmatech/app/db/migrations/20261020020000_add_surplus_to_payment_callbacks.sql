@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The part of a payment above what its installment still owed, kept on the callback for review
+ALTER TABLE payment_callbacks ADD COLUMN IF NOT EXISTS surplus DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_payment_callbacks_status ON payment_callbacks (status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payment_callbacks_status;
+
+ALTER TABLE payment_callbacks DROP COLUMN IF EXISTS surplus;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A bank mutation is applied once, however many files or concurrent imports carry it. Lines without a
+-- reference are told apart by their account, date and amount.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reconciliation_rows_applied_reference ON reconciliation_rows (reference)
+    WHERE reference IS NOT NULL AND status IN ('matched', 'resolved');
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reconciliation_rows_applied_va_number ON reconciliation_rows (va_number, transaction_date, amount)
+    WHERE reference IS NULL AND va_number IS NOT NULL AND status IN ('matched', 'resolved');
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reconciliation_rows_applied_contract_number ON reconciliation_rows (contract_number, transaction_date, amount)
+    WHERE reference IS NULL AND va_number IS NULL AND status IN ('matched', 'resolved');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_reconciliation_rows_applied_contract_number;
+DROP INDEX IF EXISTS uq_reconciliation_rows_applied_va_number;
+DROP INDEX IF EXISTS uq_reconciliation_rows_applied_reference;
+-- +goose StatementEnd
@@ -0,0 +1,176 @@
+package paginate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Find loads a page of the list query into records, a pointer to a slice of models. Pages picked by
+// number are counted and skipped to with OFFSET, as lists always did. Pages picked by cursor are read
+// from an index on the sort column and key, the column of table that tells records apart, and counted
+// only when the pagination asks for it.
+func Find(query *gorm.DB, table, key string, pagination request.Pagination, records interface{}) (response.Pagination, error) {
+	if pagination.IsCursor() {
+		return findByCursor(query, table, key, pagination, records)
+	}
+	return findByPage(query, pagination, records)
+}
+
+func findByPage(query *gorm.DB, pagination request.Pagination, records interface{}) (response.Pagination, error) {
+	limit, page := *pagination.Limit, *pagination.Page
+
+	var totalCount int
+	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
+		return response.Pagination{}, nil
+	}
+	if totalCount == 0 || page > (totalCount+limit-1)/limit {
+		return response.Pagination{}, nil
+	}
+
+	paginationResponse := response.Pagination{
+		Page:       page,
+		PerPage:    limit,
+		TotalPages: (totalCount + limit - 1) / limit,
+		TotalCount: totalCount,
+	}
+
+	query = query.Order(pagination.OrderBy()).Limit(limit).Offset((page - 1) * limit)
+	if err := query.Find(records).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.Pagination{}, nil
+		}
+		return paginationResponse, err
+	}
+	return paginationResponse, nil
+}
+
+func findByCursor(query *gorm.DB, table, key string, pagination request.Pagination, records interface{}) (response.Pagination, error) {
+	limit := *pagination.Limit
+	paginationResponse := response.Pagination{PerPage: limit}
+
+	if pagination.Count {
+		var totalCount int
+		if err := query.Count(&totalCount).Error; err != nil && err != sql.ErrNoRows {
+			return response.Pagination{}, err
+		}
+		paginationResponse.TotalCount = totalCount
+		paginationResponse.TotalPages = (totalCount + limit - 1) / limit
+	}
+
+	cursor, err := request.DecodeCursor(*pagination.Cursor)
+	if err != nil {
+		return response.Pagination{}, err
+	}
+	sort := strings.ToUpper(pagination.Sort)
+	if cursor != nil && (cursor.Order != pagination.Order || cursor.Sort != sort) {
+		return response.Pagination{}, fmt.Errorf("%w: the cursor was made for another order", request.ErrInvalidCursor)
+	}
+	backward := cursor != nil && cursor.Backward
+
+	// Going back reads the records before the cursor nearest first, they are put back in order below
+	direction := sort
+	if backward {
+		direction = map[string]string{"ASC": "DESC", "DESC": "ASC"}[sort]
+	}
+	orderBy := request.OrderBy(pagination.Order+","+key, direction, table)
+	if strings.Count(orderBy, ",") != 1 {
+		return response.Pagination{}, fmt.Errorf("cursor pagination sorts by a single column, not %q %q", pagination.Order, pagination.Sort)
+	}
+
+	if cursor != nil {
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		column := table + "." + pagination.Order
+		query = query.Where(fmt.Sprintf("(%s, %s.%s) %s (?, ?)", column, table, key, comparison), cursor.Value, cursor.Key)
+	}
+
+	// The record past the limit only tells whether there is another page
+	if err := query.Order(orderBy).Limit(limit + 1).Find(records).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return paginationResponse, nil
+		}
+		return response.Pagination{}, err
+	}
+
+	list := reflect.ValueOf(records).Elem()
+	more := list.Len() > limit
+	if more {
+		list.Set(list.Slice(0, limit))
+	}
+	if backward {
+		swap := reflect.Swapper(list.Interface())
+		for i, j := 0, list.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	if list.Len() == 0 {
+		return paginationResponse, nil
+	}
+
+	if more || backward {
+		next, err := cursorOf(list.Index(list.Len()-1), pagination.Order, sort, key, false)
+		if err != nil {
+			return response.Pagination{}, err
+		}
+		paginationResponse.NextCursor = next
+	}
+	if (more && backward) || (cursor != nil && !backward) {
+		prev, err := cursorOf(list.Index(0), pagination.Order, sort, key, true)
+		if err != nil {
+			return response.Pagination{}, err
+		}
+		paginationResponse.PrevCursor = prev
+	}
+	return paginationResponse, nil
+}
+
+// cursorOf returns the encoded cursor of a record, read from the fields json names order and key.
+func cursorOf(record reflect.Value, order, sort, key string, backward bool) (string, error) {
+	value, ok := jsonField(record, order)
+	if !ok {
+		return "", fmt.Errorf("can't page by %q, the record has no such field", order)
+	}
+	keyValue, ok := jsonField(record, key)
+	if !ok {
+		return "", fmt.Errorf("can't page by %q, the record has no such field", key)
+	}
+
+	cursor := request.Cursor{Order: order, Sort: sort, Value: value, Key: fmt.Sprint(keyValue), Backward: backward}
+	return cursor.Encode(), nil
+}
+
+func jsonField(record reflect.Value, name string) (interface{}, bool) {
+	for record.Kind() == reflect.Ptr || record.Kind() == reflect.Interface {
+		if record.IsNil() {
+			return nil, false
+		}
+		record = record.Elem()
+	}
+	if record.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < record.NumField(); i++ {
+		field := record.Type().Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && tag == "" {
+			if value, ok := jsonField(record.Field(i), name); ok {
+				return value, true
+			}
+			continue
+		}
+		if field.IsExported() && (tag == name || tag == "" && field.Name == name) {
+			return record.Field(i).Interface(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,154 @@
+package paginate
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type item struct {
+	Uuid  string `json:"uuid"`
+	Score int    `json:"score"`
+}
+
+// items are sorted by score and uuid, c, d and e tie on their score so pages have to break ties on the key.
+var items = []item{{"a", 1}, {"b", 2}, {"c", 3}, {"d", 3}, {"e", 3}, {"f", 4}, {"g", 5}}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	conn, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "items.db"))
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.Exec("CREATE TABLE items (uuid TEXT PRIMARY KEY, score INTEGER NOT NULL)").Error; err != nil {
+		t.Fatalf("creating the table error = %v", err)
+	}
+	for _, i := range items {
+		if err := conn.Exec("INSERT INTO items (uuid, score) VALUES (?, ?)", i.Uuid, i.Score).Error; err != nil {
+			t.Fatalf("creating item %s error = %v", i.Uuid, err)
+		}
+	}
+	return conn
+}
+
+func cursorPage(cursor, sort string) request.Pagination {
+	return request.Pagination{Limit: util.Int(3), Order: "score", Sort: sort, Cursor: &cursor}
+}
+
+func uuids(records []item) []string {
+	var got []string
+	for _, record := range records {
+		got = append(got, record.Uuid)
+	}
+	return got
+}
+
+func TestFindByCursor(t *testing.T) {
+	conn := newTestDB(t)
+
+	// Each step follows the next or prev cursor of the page before it, the first step reads the first page
+	type step struct {
+		follow   string
+		want     []string
+		wantPrev bool
+		wantNext bool
+	}
+	tests := []struct {
+		name  string
+		sort  string
+		steps []step
+	}{
+		{
+			name: "Given ascending pages When paging forward to the end Then ties are split on the key and the last page has no next",
+			sort: "asc",
+			steps: []step{
+				{want: []string{"a", "b", "c"}, wantNext: true},
+				{follow: "next", want: []string{"d", "e", "f"}, wantPrev: true, wantNext: true},
+				{follow: "next", want: []string{"g"}, wantPrev: true},
+			},
+		},
+		{
+			name: "Given the last page When paging backward to the start Then pages are in order and the first has no prev",
+			sort: "asc",
+			steps: []step{
+				{want: []string{"a", "b", "c"}, wantNext: true},
+				{follow: "next", want: []string{"d", "e", "f"}, wantPrev: true, wantNext: true},
+				{follow: "next", want: []string{"g"}, wantPrev: true},
+				{follow: "prev", want: []string{"d", "e", "f"}, wantPrev: true, wantNext: true},
+				{follow: "prev", want: []string{"a", "b", "c"}, wantNext: true},
+			},
+		},
+		{
+			name: "Given descending pages When paging forward and back Then the records come highest first",
+			sort: "desc",
+			steps: []step{
+				{want: []string{"g", "f", "e"}, wantNext: true},
+				{follow: "next", want: []string{"d", "c", "b"}, wantPrev: true, wantNext: true},
+				{follow: "next", want: []string{"a"}, wantPrev: true},
+				{follow: "prev", want: []string{"d", "c", "b"}, wantPrev: true, wantNext: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page response.Pagination
+			for n, s := range tt.steps {
+				cursor := map[string]string{"": "", "next": page.NextCursor, "prev": page.PrevCursor}[s.follow]
+
+				var records []item
+				var err error
+				page, err = Find(conn.Table("items"), "items", "uuid", cursorPage(cursor, tt.sort), &records)
+				if err != nil {
+					t.Fatalf("step %d: Find() error = %v", n, err)
+				}
+
+				if got := uuids(records); !reflect.DeepEqual(got, s.want) {
+					t.Errorf("step %d: Find() = %v, want %v", n, got, s.want)
+				}
+				if hasPrev, hasNext := page.PrevCursor != "", page.NextCursor != ""; hasPrev != s.wantPrev || hasNext != s.wantNext {
+					t.Errorf("step %d: Find() has prev %v next %v, want prev %v next %v", n, hasPrev, hasNext, s.wantPrev, s.wantNext)
+				}
+			}
+		})
+	}
+
+	t.Run("Given count is asked When reading a cursor page Then the records are counted", func(t *testing.T) {
+		pagination := cursorPage("", "asc")
+		pagination.Count = true
+
+		var records []item
+		page, err := Find(conn.Table("items"), "items", "uuid", pagination, &records)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		if page.TotalCount != len(items) || page.TotalPages != 3 || page.PerPage != 3 {
+			t.Errorf("Find() = %+v, want %d records on 3 pages of 3", page, len(items))
+		}
+	})
+
+	t.Run("Given a cursor made for another order When paging with it Then it is refused", func(t *testing.T) {
+		var records []item
+		page, err := Find(conn.Table("items"), "items", "uuid", cursorPage("", "asc"), &records)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+
+		for _, pagination := range []request.Pagination{
+			cursorPage(page.NextCursor, "desc"),
+			{Limit: util.Int(3), Order: "uuid", Sort: "asc", Cursor: &page.NextCursor},
+		} {
+			if _, err := Find(conn.Table("items"), "items", "uuid", pagination, &records); !errors.Is(err, request.ErrInvalidCursor) {
+				t.Errorf("Find() ordered by %s %s error = %v, want %v", pagination.Order, pagination.Sort, err, request.ErrInvalidCursor)
+			}
+		}
+	})
+}
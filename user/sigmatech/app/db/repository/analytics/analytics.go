@@ -0,0 +1,200 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	db "user/sigmatech/app/db"
+	analytics_DBModels "user/sigmatech/app/db/dto/analytics"
+	transactionDelinquencies_DBModels "user/sigmatech/app/db/dto/transaction_delinquencies"
+	analyticsRequest "user/sigmatech/app/service/dto/request/analytics"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IAnalyticsRepository aggregates portfolio figures per period in SQL. Every series has one point per
+// period of the range, the range is widened to whole periods.
+type IAnalyticsRepository interface {
+	GetDisbursements(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.Disbursement, error)
+	GetInterestEarned(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.InterestEarned, error)
+	GetOutstanding(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.Outstanding, error)
+	GetConversionFunnel(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.ConversionFunnel, error)
+}
+
+type AnalyticsRepository struct {
+	DBService *db.DBService
+}
+
+func NewAnalyticsRepository(dbService *db.DBService) IAnalyticsRepository {
+	return &AnalyticsRepository{
+		DBService: dbService,
+	}
+}
+
+// periods is the CTE listing the periods of the range, as_of is the day a period is looked at for
+// point in time figures: its last day, or today for the current period.
+func periods(r analyticsRequest.Range) (string, []interface{}) {
+	query := fmt.Sprintf(`periods AS (
+		SELECT gs::date AS period,
+			(gs + interval '%[2]s')::date AS period_end,
+			LEAST((gs + interval '%[2]s')::date - 1, CURRENT_DATE) AS as_of
+		FROM generate_series(date_trunc('%[1]s', ?::timestamp), ?::timestamp, interval '%[2]s') gs
+	)`, r.GroupBy, r.Interval())
+
+	return query, []interface{}{r.GetFrom(), r.GetTo()}
+}
+
+func (u *AnalyticsRepository) GetDisbursements(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.Disbursement, error) {
+	cte, args := periods(r)
+	query := fmt.Sprintf(`WITH %s
+		SELECT p.period, COUNT(t.uuid) AS contracts, COALESCE(SUM(t.otr), 0) AS otr
+		FROM periods p
+		LEFT JOIN transactions t ON t.created_at >= p.period AND t.created_at < p.period_end
+		GROUP BY p.period
+		ORDER BY p.period`, cte)
+
+	var records []*analytics_DBModels.Disbursement
+	if err := u.DBService.GetDB().Raw(query, args...).Scan(&records).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (u *AnalyticsRepository) GetInterestEarned(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.InterestEarned, error) {
+	cte, args := periods(r)
+	query := fmt.Sprintf(`WITH %s
+		SELECT p.period, COALESCE(SUM(ti.amount_paid * t.total_interest / NULLIF(t.total, 0)), 0) AS interest
+		FROM periods p
+		LEFT JOIN transaction_installments ti ON ti.payment_at >= p.period AND ti.payment_at < p.period_end
+		LEFT JOIN transactions t ON t.uuid = ti.transaction_uuid
+		GROUP BY p.period
+		ORDER BY p.period`, cte)
+
+	var records []*analytics_DBModels.InterestEarned
+	if err := u.DBService.GetDB().Raw(query, args...).Scan(&records).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// outstandingRow is one row of the outstanding query, before the buckets are folded into a list
+type outstandingRow struct {
+	Period               time.Time
+	Contracts            int
+	OutstandingPrincipal float64
+	CurrentContracts     int
+	CurrentPrincipal     float64
+	Dpd1To30Contracts    int
+	Dpd1To30Principal    float64
+	Dpd31To60Contracts   int
+	Dpd31To60Principal   float64
+	Dpd61To90Contracts   int
+	Dpd61To90Principal   float64
+	Dpd90PlusContracts   int
+	Dpd90PlusPrincipal   float64
+}
+
+// GetOutstanding computes the position of every contract at the as_of day of each period. Paid amounts
+// only count once the installment is fully paid, as partial payments don't record when they were made.
+func (u *AnalyticsRepository) GetOutstanding(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.Outstanding, error) {
+	cte, args := periods(r)
+	query := fmt.Sprintf(`WITH %s,
+		positions AS (
+			SELECT p.period,
+				t.otr - COALESCE(paid.amount, 0) * t.otr / NULLIF(t.total, 0) AS principal,
+				COALESCE(p.as_of - overdue.due_date, 0) AS dpd
+			FROM periods p
+			JOIN transactions t ON t.created_at < p.period_end
+			LEFT JOIN LATERAL (
+				SELECT SUM(ti.amount_paid) AS amount
+				FROM transaction_installments ti
+				WHERE ti.transaction_uuid = t.uuid AND ti.payment_at <= p.as_of
+			) paid ON true
+			LEFT JOIN LATERAL (
+				SELECT MIN(ti.due_date) AS due_date
+				FROM transaction_installments ti
+				WHERE ti.transaction_uuid = t.uuid AND ti.due_date < p.as_of AND (ti.payment_at IS NULL OR ti.payment_at > p.as_of)
+			) overdue ON true
+		)
+		SELECT p.period,
+			COUNT(ps.period) AS contracts,
+			COALESCE(SUM(ps.principal), 0) AS outstanding_principal,
+			COUNT(ps.period) FILTER (WHERE ps.dpd = 0) AS current_contracts,
+			COALESCE(SUM(ps.principal) FILTER (WHERE ps.dpd = 0), 0) AS current_principal,
+			COUNT(ps.period) FILTER (WHERE ps.dpd BETWEEN 1 AND 30) AS dpd1_to30_contracts,
+			COALESCE(SUM(ps.principal) FILTER (WHERE ps.dpd BETWEEN 1 AND 30), 0) AS dpd1_to30_principal,
+			COUNT(ps.period) FILTER (WHERE ps.dpd BETWEEN 31 AND 60) AS dpd31_to60_contracts,
+			COALESCE(SUM(ps.principal) FILTER (WHERE ps.dpd BETWEEN 31 AND 60), 0) AS dpd31_to60_principal,
+			COUNT(ps.period) FILTER (WHERE ps.dpd BETWEEN 61 AND 90) AS dpd61_to90_contracts,
+			COALESCE(SUM(ps.principal) FILTER (WHERE ps.dpd BETWEEN 61 AND 90), 0) AS dpd61_to90_principal,
+			COUNT(ps.period) FILTER (WHERE ps.dpd > 90) AS dpd90_plus_contracts,
+			COALESCE(SUM(ps.principal) FILTER (WHERE ps.dpd > 90), 0) AS dpd90_plus_principal
+		FROM periods p
+		LEFT JOIN positions ps ON ps.period = p.period AND ps.principal > 0.005
+		GROUP BY p.period
+		ORDER BY p.period`, cte)
+
+	var rows []*outstandingRow
+	if err := u.DBService.GetDB().Raw(query, args...).Scan(&rows).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	records := make([]*analytics_DBModels.Outstanding, 0, len(rows))
+	for _, row := range rows {
+		record := &analytics_DBModels.Outstanding{
+			Period:               row.Period,
+			Contracts:            row.Contracts,
+			OutstandingPrincipal: row.OutstandingPrincipal,
+			NplRatio:             ratio(row.Dpd90PlusPrincipal, row.OutstandingPrincipal),
+			Buckets: []*analytics_DBModels.OutstandingBucket{
+				{Bucket: transactionDelinquencies_DBModels.BUCKET_CURRENT, Contracts: row.CurrentContracts, Principal: row.CurrentPrincipal},
+				{Bucket: transactionDelinquencies_DBModels.BUCKET_1_30, Contracts: row.Dpd1To30Contracts, Principal: row.Dpd1To30Principal},
+				{Bucket: transactionDelinquencies_DBModels.BUCKET_31_60, Contracts: row.Dpd31To60Contracts, Principal: row.Dpd31To60Principal},
+				{Bucket: transactionDelinquencies_DBModels.BUCKET_61_90, Contracts: row.Dpd61To90Contracts, Principal: row.Dpd61To90Principal},
+				{Bucket: transactionDelinquencies_DBModels.BUCKET_OVER_90, Contracts: row.Dpd90PlusContracts, Principal: row.Dpd90PlusPrincipal},
+			},
+		}
+		for _, bucket := range record.Buckets {
+			bucket.Ratio = ratio(bucket.Principal, row.OutstandingPrincipal)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// GetConversionFunnel groups customers by the period they signed up in
+func (u *AnalyticsRepository) GetConversionFunnel(ctx context.Context, r analyticsRequest.Range) ([]*analytics_DBModels.ConversionFunnel, error) {
+	cte, args := periods(r)
+	query := fmt.Sprintf(`WITH %s
+		SELECT p.period,
+			COUNT(c.uuid) AS signed_up,
+			COUNT(c.uuid) FILTER (WHERE c.is_active) AS approved,
+			COUNT(c.uuid) FILTER (WHERE EXISTS (SELECT 1 FROM transactions t WHERE t.customer_uuid = c.uuid)) AS transacted
+		FROM periods p
+		LEFT JOIN customers c ON c.created_at >= p.period AND c.created_at < p.period_end AND c.deleted_at IS NULL
+		GROUP BY p.period
+		ORDER BY p.period`, cte)
+
+	var records []*analytics_DBModels.ConversionFunnel
+	if err := u.DBService.GetDB().Raw(query, args...).Scan(&records).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	for _, record := range records {
+		record.ApprovalRate = ratio(float64(record.Approved), float64(record.SignedUp))
+		record.ConversionRate = ratio(float64(record.Transacted), float64(record.SignedUp))
+	}
+
+	return records, nil
+}
+
+func ratio(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total
+}
@@ -0,0 +1,93 @@
+package audit_log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	auditLogs_DBModels "user/sigmatech/app/db/dto/audit_logs"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IAuditLogRepository is append only, audit logs are never updated nor deleted.
+type IAuditLogRepository interface {
+	CreateAuditLog(ctx context.Context, auditLog *auditLogs_DBModels.AuditLog) error
+	GetAuditLog(ctx context.Context, whr where.Filter) (auditLogs_DBModels.AuditLog, error)
+	GetAuditLogs(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*auditLogs_DBModels.AuditLog, response.Pagination, error)
+}
+
+type AuditLogRepository struct {
+	DBService *db.DBService
+}
+
+func NewAuditLogRepository(dbService *db.DBService) IAuditLogRepository {
+	return &AuditLogRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = auditLogs_DBModels.TABLE_NAME
+
+func (u *AuditLogRepository) CreateAuditLog(ctx context.Context, auditLog *auditLogs_DBModels.AuditLog) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(auditLogs_DBModels.TABLE_NAME).Create(&auditLog).Error; err != nil {
+		return err // Return the error if audit log creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil
+}
+
+func (u *AuditLogRepository) GetAuditLog(ctx context.Context, whr where.Filter) (auditLogs_DBModels.AuditLog, error) {
+	tx := u.DBService.GetDB().Table(auditLogs_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer auditLogs_DBModels.AuditLog                       // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return auditLogs_DBModels.AuditLog{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *AuditLogRepository) GetAuditLogs(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*auditLogs_DBModels.AuditLog, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(auditLogs_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		auditLogs_DBModels.COLUMN_ACTION,
+		auditLogs_DBModels.COLUMN_ENTITY,
+		auditLogs_DBModels.COLUMN_ENTITY_ID,
+		auditLogs_DBModels.COLUMN_ACTOR_EMAIL,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, auditLogs_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
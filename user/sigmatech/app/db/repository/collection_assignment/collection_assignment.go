@@ -0,0 +1,166 @@
+package collection_assignment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	collectionAssignments_DBModels "user/sigmatech/app/db/dto/collection_assignments"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+type ICollectionAssignmentRepository interface {
+	CreateCollectionAssignment(ctx context.Context, customer *collectionAssignments_DBModels.CollectionAssignment) error
+	GetCollectionAssignment(ctx context.Context, whr where.Filter) (collectionAssignments_DBModels.CollectionAssignment, error)
+	GetCollectionAssignments(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*collectionAssignments_DBModels.CollectionAssignment, response.Pagination, error)
+	UpdateCollectionAssignment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteCollectionAssignment(ctx context.Context, filter where.Filter) error
+}
+
+type CollectionAssignmentRepository struct {
+	DBService *db.DBService
+}
+
+func NewCollectionAssignmentRepository(dbService *db.DBService) ICollectionAssignmentRepository {
+	return &CollectionAssignmentRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = collectionAssignments_DBModels.TABLE_NAME
+
+func (u *CollectionAssignmentRepository) CreateCollectionAssignment(ctx context.Context, customer *collectionAssignments_DBModels.CollectionAssignment) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(collectionAssignments_DBModels.TABLE_NAME).Create(&customer).Error; err != nil {
+		return err // Return the error if customer creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil // Return the created customer and no error
+}
+
+func (u *CollectionAssignmentRepository) GetCollectionAssignment(ctx context.Context, whr where.Filter) (collectionAssignments_DBModels.CollectionAssignment, error) {
+	tx := u.DBService.GetDB().Table(collectionAssignments_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer collectionAssignments_DBModels.CollectionAssignment           // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return collectionAssignments_DBModels.CollectionAssignment{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *CollectionAssignmentRepository) GetCollectionAssignments(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*collectionAssignments_DBModels.CollectionAssignment, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(collectionAssignments_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		collectionAssignments_DBModels.COLUMN_NOTE,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, collectionAssignments_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+func (u *CollectionAssignmentRepository) UpdateCollectionAssignment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	tx := u.DBService.GetDB().Table(collectionAssignments_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if customer update fails
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
+
+func (u *CollectionAssignmentRepository) DeleteCollectionAssignment(ctx context.Context, filter where.Filter) error {
+	tx := u.DBService.GetDB().Table(collectionAssignments_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(filter.Scope).Delete(&collectionAssignments_DBModels.CollectionAssignment{}).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if the deletion fails
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			// Foreign key constraint violation detected, rollback the transaction
+			tx.Rollback()
+
+			// Start a new transaction for the schema query
+			schemaTx := u.DBService.GetDB().Begin()
+			defer func() {
+				if r := recover(); r != nil {
+					schemaTx.Rollback() // Rollback the schema transaction if a panic occurs
+				}
+			}()
+
+			// Query the database schema to get tables with foreign key constraints referencing the specified table
+			var foreignKeys []struct {
+				ConstraintName   string
+				ReferencingTable string
+			}
+			err := schemaTx.Raw(`
+			SELECT con.conname AS constraint_name, con.conrelid::regclass AS referencing_table
+			FROM pg_constraint con
+			WHERE con.contype = 'f'
+			AND con.confrelid = ?::regclass`, tableName).Scan(&foreignKeys).Error
+
+			if err != nil {
+				return err
+			}
+
+			var referencingTables []string
+			// Print or process the foreign key information
+			for _, fk := range foreignKeys {
+				referencingTables = append(referencingTables, fk.ReferencingTable)
+			}
+
+			// Rollback the schema transaction
+			schemaTx.Rollback()
+
+			// Return the foreign key constraint violation error
+			return fmt.Errorf("cannot delete from table %s because of foreign key constraints on tables %s", tableName, strings.Join(referencingTables, ", "))
+		}
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
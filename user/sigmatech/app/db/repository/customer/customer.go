@@ -2,13 +2,16 @@ package customer
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
 	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/dto/request"
 	"user/sigmatech/app/service/dto/response"
 	"user/sigmatech/app/service/util"
@@ -19,10 +22,13 @@ import (
 
 type ICustomerRepository interface {
 	CreateCustomer(ctx context.Context, customer *customers_DBModels.Customer) error
-	GetCustomer(ctx context.Context, whr string) (customers_DBModels.Customer, error)
+	GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error)
 	GetCustomers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customers_DBModels.Customer, response.Pagination, error)
-	UpdateCustomer(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteCustomer(ctx context.Context, filter string) error
+	UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteCustomer(ctx context.Context, filter where.Filter) error
+	GetDeletedCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error)
+	RestoreCustomer(ctx context.Context, whr where.Filter) error
+	PurgeCustomers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type CustomerRepository struct {
@@ -50,11 +56,11 @@ func (u *CustomerRepository) CreateCustomer(ctx context.Context, customer *custo
 	return nil // Return the created customer and no error
 }
 
-func (u *CustomerRepository) GetCustomer(ctx context.Context, whr string) (customers_DBModels.Customer, error) {
+func (u *CustomerRepository) GetCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer customers_DBModels.Customer                       // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customers_DBModels.Customer{}, nil // Return an empty customer if the record is not found
 		}
@@ -73,16 +79,8 @@ func (u *CustomerRepository) GetCustomers(ctx context.Context, paginationRequest
 		customers_DBModels.COLUMN_EMAIL,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	// Count isn't given the model, so deleted rows are left out here rather than by gorm
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope, where.IsNull(tableName+"."+customers_DBModels.COLUMN_DELETED_AT).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -97,34 +95,11 @@ func (u *CustomerRepository) GetCustomers(ctx context.Context, paginationRequest
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, customers_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -133,7 +108,7 @@ func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, pat
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if customer update fails
 		return err
 	}
@@ -141,7 +116,8 @@ func (u *CustomerRepository) UpdateCustomer(ctx context.Context, whr string, pat
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter string) error {
+// DeleteCustomer soft deletes, gorm sets deleted_at since customers_DBModels.Customer has a DeletedAt field.
+func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(customers_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -150,7 +126,7 @@ func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter string)
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&customers_DBModels.Customer{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&customers_DBModels.Customer{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
@@ -196,3 +172,56 @@ func (u *CustomerRepository) DeleteCustomer(ctx context.Context, filter string)
 
 	return tx.Commit().Error // Commit the transaction and return any error
 }
+
+// GetDeletedCustomer returns a soft deleted customer, an empty one when there is none matching whr.
+func (u *CustomerRepository) GetDeletedCustomer(ctx context.Context, whr where.Filter) (customers_DBModels.Customer, error) {
+	tx := u.DBService.GetDB().Unscoped().Table(customers_DBModels.TABLE_NAME)
+	var customer customers_DBModels.Customer
+
+	if err := tx.Scopes(whr.IsNotNull(customers_DBModels.COLUMN_DELETED_AT).Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customers_DBModels.Customer{}, nil
+		}
+
+		return customer, err
+	}
+
+	return customer, nil
+}
+
+func (u *CustomerRepository) RestoreCustomer(ctx context.Context, whr where.Filter) error {
+	tx := u.DBService.GetDB().Unscoped().Table(customers_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer tx.Rollback()                                                               // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)                           // Set the database log mode
+
+	err := tx.Scopes(whr.Scope).Updates(map[string]interface{}{
+		customers_DBModels.COLUMN_DELETED_AT: nil,
+		customers_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// PurgeCustomers hard deletes the customers soft deleted before deletedBefore. Customers with transactions
+// are kept, their contracts would be deleted with them.
+func (u *CustomerRepository) PurgeCustomers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	result := tx.Exec(fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE %[1]s.%[2]s < ?
+		AND NOT EXISTS (SELECT 1 FROM %[3]s WHERE %[3]s.%[4]s = %[1]s.%[5]s)`,
+		customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_DELETED_AT,
+		transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CUSTOMER_UUID, customers_DBModels.COLUM_UUID,
+	), deletedBefore)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, tx.Commit().Error
+}
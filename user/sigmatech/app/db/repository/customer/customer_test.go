@@ -0,0 +1,219 @@
+package customer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/util"
+	"user/sigmatech/config"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema mirrors the columns and indexes the migrations give customers and transactions, in SQLite.
+const schema = `
+CREATE TABLE customers (
+	uuid TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL,
+	password TEXT NOT NULL,
+	is_active BOOLEAN NOT NULL DEFAULT false,
+	email_verified_at DATETIME NULL,
+	last_login DATETIME NULL,
+	last_login_ip TEXT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NULL,
+	deleted_at DATETIME NULL
+);
+CREATE UNIQUE INDEX customers_email_key ON customers (email) WHERE deleted_at IS NULL;
+CREATE TABLE transactions (
+	uuid TEXT PRIMARY KEY,
+	customer_uuid TEXT NOT NULL REFERENCES customers (uuid)
+);`
+
+func newTestRepository(t *testing.T) (*CustomerRepository, *gorm.DB) {
+	constants.Config = &config.ServiceConfig{}
+
+	conn, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "customers.db"))
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.Exec(schema).Error; err != nil {
+		t.Fatalf("creating the schema error = %v", err)
+	}
+	return &CustomerRepository{DBService: &db.DBService{DB: conn}}, conn
+}
+
+func createCustomer(t *testing.T, r *CustomerRepository, email string) customers_DBModels.Customer {
+	customer := customers_DBModels.Customer{
+		Uuid:      uuid.New(),
+		Name:      "Budi",
+		Email:     email,
+		Password:  "hashed",
+		IsActive:  util.Boolean(true),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := r.CreateCustomer(context.Background(), &customer); err != nil {
+		t.Fatalf("CreateCustomer() error = %v", err)
+	}
+	return customer
+}
+
+func TestDeleteCustomer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Given customers, When deleting some of them, Then only those are left out of reads and kept as deleted", func(t *testing.T) {
+		r, _ := newTestRepository(t)
+		a, b, kept := createCustomer(t, r, "a@sigmatech.id"), createCustomer(t, r, "b@sigmatech.id"), createCustomer(t, r, "c@sigmatech.id")
+
+		if err := r.DeleteCustomer(ctx, where.In(customers_DBModels.COLUM_UUID, []string{a.Uuid.String(), b.Uuid.String()})); err != nil {
+			t.Fatalf("DeleteCustomer() error = %v", err)
+		}
+
+		for _, deleted := range []customers_DBModels.Customer{a, b} {
+			filter := where.Eq(customers_DBModels.COLUM_UUID, deleted.Uuid)
+			if got, err := r.GetCustomer(ctx, filter); err != nil || got.Uuid != uuid.Nil {
+				t.Errorf("GetCustomer() = %v, %v, want no customer", got.Uuid, err)
+			}
+			if got, err := r.GetDeletedCustomer(ctx, filter); err != nil || got.Uuid != deleted.Uuid || got.DeletedAt == nil {
+				t.Errorf("GetDeletedCustomer() = %v, %v, want the deleted customer %s", got.Uuid, err, deleted.Uuid)
+			}
+		}
+		if got, err := r.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, kept.Uuid)); err != nil || got.Uuid != kept.Uuid {
+			t.Errorf("GetCustomer() = %v, %v, want the customer %s", got.Uuid, err, kept.Uuid)
+		}
+	})
+
+	t.Run("Given a deleted customer, When signing up with the same email, Then it is accepted", func(t *testing.T) {
+		r, _ := newTestRepository(t)
+		deleted := createCustomer(t, r, "budi@sigmatech.id")
+		if err := r.DeleteCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, deleted.Uuid)); err != nil {
+			t.Fatalf("DeleteCustomer() error = %v", err)
+		}
+
+		createCustomer(t, r, "budi@sigmatech.id")
+	})
+}
+
+func TestRestoreCustomer(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		emailUsed bool
+		wantErr   bool
+	}{
+		{
+			name: "Given a deleted customer, When restoring it, Then it is read again",
+		},
+		{
+			name:      "Given a deleted customer whose email was signed up with since, When restoring it, Then it is refused",
+			emailUsed: true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRepository(t)
+			customer := createCustomer(t, r, "budi@sigmatech.id")
+			filter := where.Eq(customers_DBModels.COLUM_UUID, customer.Uuid)
+
+			if err := r.DeleteCustomer(ctx, filter); err != nil {
+				t.Fatalf("DeleteCustomer() error = %v", err)
+			}
+			if tt.emailUsed {
+				createCustomer(t, r, customer.Email)
+			}
+
+			if err := r.RestoreCustomer(ctx, filter); (err != nil) != tt.wantErr {
+				t.Fatalf("RestoreCustomer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := r.GetCustomer(ctx, filter)
+			if err != nil {
+				t.Fatalf("GetCustomer() error = %v", err)
+			}
+			if restored := got.Uuid == customer.Uuid; restored == tt.wantErr {
+				t.Errorf("GetCustomer() found the customer = %v, want %v", restored, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPurgeCustomers(t *testing.T) {
+	ctx := context.Background()
+	r, conn := newTestRepository(t)
+	retention := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name            string
+		deletedAgo      time.Duration
+		hasTransactions bool
+		wantPurged      bool
+	}{
+		{
+			name:       "Given a customer deleted before the retention, When purging, Then it is deleted for good",
+			deletedAgo: retention + time.Hour,
+			wantPurged: true,
+		},
+		{
+			name:            "Given a customer with contracts deleted before the retention, When purging, Then it is kept",
+			deletedAgo:      retention + time.Hour,
+			hasTransactions: true,
+		},
+		{
+			name:       "Given a customer deleted within the retention, When purging, Then it is kept",
+			deletedAgo: time.Hour,
+		},
+		{
+			name: "Given a customer that isn't deleted, When purging, Then it is kept",
+		},
+	}
+
+	customers := make([]customers_DBModels.Customer, len(tests))
+	for i, tt := range tests {
+		customers[i] = createCustomer(t, r, uuid.NewString()+"@sigmatech.id")
+		filter := where.Eq(customers_DBModels.COLUM_UUID, customers[i].Uuid)
+
+		if tt.deletedAgo > 0 {
+			if err := r.UpdateCustomer(ctx, filter, map[string]interface{}{customers_DBModels.COLUMN_DELETED_AT: time.Now().Add(-tt.deletedAgo)}); err != nil {
+				t.Fatalf("UpdateCustomer() error = %v", err)
+			}
+		}
+		if tt.hasTransactions {
+			if err := conn.Exec("INSERT INTO transactions (uuid, customer_uuid) VALUES (?, ?)", uuid.New(), customers[i].Uuid).Error; err != nil {
+				t.Fatalf("creating a transaction error = %v", err)
+			}
+		}
+	}
+
+	purged, err := r.PurgeCustomers(ctx, time.Now().Add(-retention))
+	if err != nil {
+		t.Fatalf("PurgeCustomers() error = %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeCustomers() = %d, want 1", purged)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count int
+			if err := conn.Table(customers_DBModels.TABLE_NAME).Where("uuid = ?", customers[i].Uuid).Count(&count).Error; err != nil {
+				t.Fatalf("counting the customer error = %v", err)
+			}
+			if gotPurged := count == 0; gotPurged != tt.wantPurged {
+				t.Errorf("PurgeCustomers() purged the customer = %v, want %v", gotPurged, tt.wantPurged)
+			}
+		})
+	}
+}
@@ -2,11 +2,12 @@ package customer_information_file
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
@@ -21,10 +22,10 @@ import (
 
 type ICustomerInformationFileRepository interface {
 	CreateCustomerInformationFile(ctx context.Context, customer *customerInformationFiles_DBModels.CustomerInformationFile) error
-	GetCustomerInformationFile(ctx context.Context, whr string) (customerInformationFiles_DBModels.CustomerInformationFile, error)
+	GetCustomerInformationFile(ctx context.Context, whr where.Filter) (customerInformationFiles_DBModels.CustomerInformationFile, error)
 	GetCustomerInformationFiles(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customerInformationFiles_DBModels.CustomerInformationFile, response.Pagination, error)
-	UpdateCustomerInformationFile(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteCustomerInformationFile(ctx context.Context, filter string) error
+	UpdateCustomerInformationFile(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteCustomerInformationFile(ctx context.Context, filter where.Filter) error
 	GenerateCIFNumber(ctx context.Context) (string, error)
 }
 
@@ -53,11 +54,11 @@ func (u *CustomerInformationFileRepository) CreateCustomerInformationFile(ctx co
 	return nil // Return the created customer and no error
 }
 
-func (u *CustomerInformationFileRepository) GetCustomerInformationFile(ctx context.Context, whr string) (customerInformationFiles_DBModels.CustomerInformationFile, error) {
+func (u *CustomerInformationFileRepository) GetCustomerInformationFile(ctx context.Context, whr where.Filter) (customerInformationFiles_DBModels.CustomerInformationFile, error) {
 	tx := u.DBService.GetDB().Table(customerInformationFiles_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer customerInformationFiles_DBModels.CustomerInformationFile        // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customerInformationFiles_DBModels.CustomerInformationFile{}, nil // Return an empty customer if the record is not found
 		}
@@ -77,16 +78,7 @@ func (u *CustomerInformationFileRepository) GetCustomerInformationFiles(ctx cont
 		customerInformationFiles_DBModels.COLUMN_LEGAL_NAME,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -101,34 +93,11 @@ func (u *CustomerInformationFileRepository) GetCustomerInformationFiles(ctx cont
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, customerInformationFiles_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *CustomerInformationFileRepository) UpdateCustomerInformationFile(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *CustomerInformationFileRepository) UpdateCustomerInformationFile(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(customerInformationFiles_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -137,7 +106,7 @@ func (u *CustomerInformationFileRepository) UpdateCustomerInformationFile(ctx co
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if customer update fails
 		return err
 	}
@@ -145,7 +114,7 @@ func (u *CustomerInformationFileRepository) UpdateCustomerInformationFile(ctx co
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *CustomerInformationFileRepository) DeleteCustomerInformationFile(ctx context.Context, filter string) error {
+func (u *CustomerInformationFileRepository) DeleteCustomerInformationFile(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(customerInformationFiles_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -154,7 +123,7 @@ func (u *CustomerInformationFileRepository) DeleteCustomerInformationFile(ctx co
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&customerInformationFiles_DBModels.CustomerInformationFile{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&customerInformationFiles_DBModels.CustomerInformationFile{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
@@ -204,10 +173,10 @@ func (u *CustomerInformationFileRepository) DeleteCustomerInformationFile(ctx co
 func (u *CustomerInformationFileRepository) GenerateCIFNumber(ctx context.Context) (string, error) {
 	tx := u.DBService.GetDB().Table(customerInformationFiles_DBModels.TABLE_NAME)
 
-	latestData := fmt.Sprintf(" date(%s)='%s'", customerInformationFiles_DBModels.COLUMN_CREATED_AT, time.Now().Format("2006-01-02"))
+	latestData := where.Eq(where.Date(customerInformationFiles_DBModels.COLUMN_CREATED_AT), time.Now().Format("2006-01-02"))
 
 	var record customerInformationFiles_DBModels.CustomerInformationFile
-	if err := tx.Where(latestData).Order("id DESC").First(&record).Error; err != nil {
+	if err := tx.Scopes(latestData.Scope).Order("id DESC").First(&record).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Sprintf("CIF_%06d_%v", 1, time.Now().Unix()), nil
 		}
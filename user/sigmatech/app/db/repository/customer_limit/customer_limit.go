@@ -2,13 +2,14 @@ package customer_limit
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
 	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/dto/request"
 	"user/sigmatech/app/service/dto/response"
 	"user/sigmatech/app/service/util"
@@ -19,10 +20,10 @@ import (
 
 type ICustomerLimitRepository interface {
 	CreateCustomerLimit(ctx context.Context, customer *customerLimits_DBModels.CustomerLimit) error
-	GetCustomerLimit(ctx context.Context, whr string) (customerLimits_DBModels.CustomerLimit, error)
+	GetCustomerLimit(ctx context.Context, whr where.Filter) (customerLimits_DBModels.CustomerLimit, error)
 	GetCustomerLimits(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*customerLimits_DBModels.CustomerLimit, response.Pagination, error)
-	UpdateCustomerLimit(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteCustomerLimit(ctx context.Context, filter string) error
+	UpdateCustomerLimit(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteCustomerLimit(ctx context.Context, filter where.Filter) error
 }
 
 type CustomerLimitRepository struct {
@@ -50,11 +51,11 @@ func (u *CustomerLimitRepository) CreateCustomerLimit(ctx context.Context, custo
 	return nil // Return the created customer and no error
 }
 
-func (u *CustomerLimitRepository) GetCustomerLimit(ctx context.Context, whr string) (customerLimits_DBModels.CustomerLimit, error) {
+func (u *CustomerLimitRepository) GetCustomerLimit(ctx context.Context, whr where.Filter) (customerLimits_DBModels.CustomerLimit, error) {
 	tx := u.DBService.GetDB().Table(customerLimits_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer customerLimits_DBModels.CustomerLimit                  // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return customerLimits_DBModels.CustomerLimit{}, nil // Return an empty customer if the record is not found
 		}
@@ -72,16 +73,7 @@ func (u *CustomerLimitRepository) GetCustomerLimits(ctx context.Context, paginat
 		customerLimits_DBModels.COLUMN_TERM,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -96,34 +88,11 @@ func (u *CustomerLimitRepository) GetCustomerLimits(ctx context.Context, paginat
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, customerLimits_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *CustomerLimitRepository) UpdateCustomerLimit(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *CustomerLimitRepository) UpdateCustomerLimit(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(customerLimits_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -132,7 +101,7 @@ func (u *CustomerLimitRepository) UpdateCustomerLimit(ctx context.Context, whr s
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if customer update fails
 		return err
 	}
@@ -140,7 +109,7 @@ func (u *CustomerLimitRepository) UpdateCustomerLimit(ctx context.Context, whr s
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *CustomerLimitRepository) DeleteCustomerLimit(ctx context.Context, filter string) error {
+func (u *CustomerLimitRepository) DeleteCustomerLimit(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(customerLimits_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -149,7 +118,7 @@ func (u *CustomerLimitRepository) DeleteCustomerLimit(ctx context.Context, filte
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&customerLimits_DBModels.CustomerLimit{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&customerLimits_DBModels.CustomerLimit{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
@@ -0,0 +1,45 @@
+package email_verification
+
+import (
+	"context"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	emailVerifications_DBModels "user/sigmatech/app/db/dto/email_verifications"
+	"user/sigmatech/app/db/where"
+)
+
+type IEmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error
+	CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error)
+}
+
+type EmailVerificationRepository struct {
+	DBService *db.DBService
+}
+
+func NewEmailVerificationRepository(dbService *db.DBService) IEmailVerificationRepository {
+	return &EmailVerificationRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *EmailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(emailVerifications_DBModels.TABLE_NAME).Create(verification).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *EmailVerificationRepository) CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error) {
+	var count int
+	if err := u.DBService.GetDB().Table(emailVerifications_DBModels.TABLE_NAME).Scopes(whr.Scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	db "user/sigmatech/app/db"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	exportRequest "user/sigmatech/app/service/dto/request/export"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+)
+
+// IExportRepository runs export queries. GetRows hands back the cursor so rows are read one at a time.
+type IExportRepository interface {
+	CountRows(ctx context.Context, q exportRequest.Query) (int, error)
+	GetRows(ctx context.Context, q exportRequest.Query) (*sql.Rows, error)
+}
+
+type ExportRepository struct {
+	DBService *db.DBService
+}
+
+func NewExportRepository(dbService *db.DBService) IExportRepository {
+	return &ExportRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *ExportRepository) CountRows(ctx context.Context, q exportRequest.Query) (int, error) {
+	query, err := u.query(q)
+	if err != nil {
+		return 0, err
+	}
+
+	var totalCount int
+	if err := query.Count(&totalCount).Error; err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	return totalCount, nil
+}
+
+func (u *ExportRepository) GetRows(ctx context.Context, q exportRequest.Query) (*sql.Rows, error) {
+	query, err := u.query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Select(strings.Join(q.Select, ", ")).Order(request.OrderBy(q.Order, q.Sort, q.Table)).Rows()
+}
+
+func (u *ExportRepository) query(q exportRequest.Query) (*gorm.DB, error) {
+	tx := u.DBService.GetDB().Table(q.Table)
+	if q.Joins != "" {
+		tx = tx.Joins(q.Joins)
+	}
+	tx = tx.Scopes(q.Where.Scope)
+
+	var columnsToSearch []string
+	for _, column := range q.SearchColumns {
+		columnsToSearch = append(columnsToSearch, q.Table+"."+column)
+	}
+
+	query := tx.Scopes(where.Search(q.Search, columnsToSearch...).Scope)
+
+	// Add the table prefix to filter parameters that don't have a "." prefix, joined tables share column names
+	filter := make(map[string]interface{}, len(q.Filter))
+	for key, value := range q.Filter {
+		if !strings.Contains(key, ".") {
+			key = q.Table + "." + key
+		}
+		filter[key] = value
+	}
+
+	return util.ApplyFilterCondition(query, filter)
+}
@@ -0,0 +1,110 @@
+package login_throttle
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	loginThrottles_DBModels "user/sigmatech/app/db/dto/login_throttles"
+	"user/sigmatech/app/db/where"
+)
+
+type ILoginThrottleRepository interface {
+	GetLoginThrottles(ctx context.Context, whr where.Filter) ([]*loginThrottles_DBModels.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, throttle *loginThrottles_DBModels.LoginThrottle, windowStart, lockoutsReset time.Time) (loginThrottles_DBModels.LoginThrottle, error)
+	UpdateLoginThrottle(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	DeleteLoginThrottles(ctx context.Context, whr where.Filter) error
+	PurgeLoginThrottles(ctx context.Context, failedBefore time.Time) (int64, error)
+}
+
+type LoginThrottleRepository struct {
+	DBService *db.DBService
+}
+
+func NewLoginThrottleRepository(dbService *db.DBService) ILoginThrottleRepository {
+	return &LoginThrottleRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *LoginThrottleRepository) GetLoginThrottles(ctx context.Context, whr where.Filter) ([]*loginThrottles_DBModels.LoginThrottle, error) {
+	var throttles []*loginThrottles_DBModels.LoginThrottle
+	if err := u.DBService.GetDB().Table(loginThrottles_DBModels.TABLE_NAME).Scopes(whr.Scope).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+
+	return throttles, nil
+}
+
+// RecordLoginFailure counts a failure in a single upsert, so concurrent failures are all counted. Failures
+// before windowStart are forgotten, and so are the lockouts when the last failure is before lockoutsReset.
+func (u *LoginThrottleRepository) RecordLoginFailure(ctx context.Context, throttle *loginThrottles_DBModels.LoginThrottle, windowStart, lockoutsReset time.Time) (loginThrottles_DBModels.LoginThrottle, error) {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	var record loginThrottles_DBModels.LoginThrottle
+	err := tx.Raw(fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s, %[5]s, %[6]s, %[7]s, %[8]s, %[9]s, %[10]s)
+		VALUES (?, ?, ?, ?, 1, 0, ?, ?, ?)
+		ON CONFLICT (%[3]s, %[4]s, %[5]s) DO UPDATE SET
+			%[6]s = CASE WHEN %[1]s.%[8]s < ? THEN 1 ELSE %[1]s.%[6]s + 1 END,
+			%[7]s = CASE WHEN %[1]s.%[8]s < ? THEN 0 ELSE %[1]s.%[7]s END,
+			%[8]s = EXCLUDED.%[8]s,
+			%[10]s = EXCLUDED.%[10]s
+		RETURNING *`,
+		loginThrottles_DBModels.TABLE_NAME, loginThrottles_DBModels.COLUM_UUID,
+		loginThrottles_DBModels.COLUMN_SUBJECT_TYPE, loginThrottles_DBModels.COLUMN_SCOPE, loginThrottles_DBModels.COLUMN_IDENTIFIER,
+		loginThrottles_DBModels.COLUMN_FAILURES, loginThrottles_DBModels.COLUMN_LOCKOUTS, loginThrottles_DBModels.COLUMN_LAST_FAILURE_AT,
+		loginThrottles_DBModels.COLUMN_CREATED_AT, loginThrottles_DBModels.COLUMN_UPDATED_AT,
+	),
+		throttle.Uuid, throttle.SubjectType, throttle.Scope, throttle.Identifier,
+		throttle.LastFailureAt, throttle.CreatedAt, throttle.UpdatedAt,
+		windowStart, lockoutsReset,
+	).Scan(&record).Error
+	if err != nil {
+		return record, err
+	}
+
+	return record, tx.Commit().Error
+}
+
+// UpdateLoginThrottle returns how many throttles were updated, so a throttle changed concurrently can be told apart.
+func (u *LoginThrottleRepository) UpdateLoginThrottle(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(loginThrottles_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (u *LoginThrottleRepository) DeleteLoginThrottles(ctx context.Context, whr where.Filter) error {
+	tx := u.DBService.GetDB().Table(loginThrottles_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Scopes(whr.Scope).Delete(&loginThrottles_DBModels.LoginThrottle{}).Error
+}
+
+// PurgeLoginThrottles deletes the throttles without failures since failedBefore that aren't locked out.
+func (u *LoginThrottleRepository) PurgeLoginThrottles(ctx context.Context, failedBefore time.Time) (int64, error) {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	result := tx.Table(loginThrottles_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s < ? AND (%s IS NULL OR %s < ?)",
+			loginThrottles_DBModels.COLUMN_LAST_FAILURE_AT,
+			loginThrottles_DBModels.COLUMN_LOCKED_UNTIL, loginThrottles_DBModels.COLUMN_LOCKED_UNTIL,
+		), failedBefore, time.Now()).
+		Delete(&loginThrottles_DBModels.LoginThrottle{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, tx.Commit().Error
+}
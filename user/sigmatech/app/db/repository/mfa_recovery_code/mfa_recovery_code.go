@@ -0,0 +1,80 @@
+package mfa_recovery_code
+
+import (
+	"context"
+	"fmt"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	mfaRecoveryCodes_DBModels "user/sigmatech/app/db/dto/mfa_recovery_codes"
+	"user/sigmatech/app/db/where"
+
+	"github.com/google/uuid"
+)
+
+type IMfaRecoveryCodeRepository interface {
+	// ReplaceRecoveryCodes deletes the recovery codes of the user and stores codes instead.
+	ReplaceRecoveryCodes(ctx context.Context, userUuid uuid.UUID, codes []*mfaRecoveryCodes_DBModels.RecoveryCode) error
+	CountRecoveryCodes(ctx context.Context, whr where.Filter) (int, error)
+	UpdateRecoveryCode(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	DeleteRecoveryCodes(ctx context.Context, whr where.Filter) error
+}
+
+type MfaRecoveryCodeRepository struct {
+	DBService *db.DBService
+}
+
+func NewMfaRecoveryCodeRepository(dbService *db.DBService) IMfaRecoveryCodeRepository {
+	return &MfaRecoveryCodeRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *MfaRecoveryCodeRepository) ReplaceRecoveryCodes(ctx context.Context, userUuid uuid.UUID, codes []*mfaRecoveryCodes_DBModels.RecoveryCode) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	err := tx.Table(mfaRecoveryCodes_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s = ?", mfaRecoveryCodes_DBModels.COLUMN_USER_UUID), userUuid).
+		Delete(&mfaRecoveryCodes_DBModels.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if err := tx.Table(mfaRecoveryCodes_DBModels.TABLE_NAME).Create(code).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *MfaRecoveryCodeRepository) CountRecoveryCodes(ctx context.Context, whr where.Filter) (int, error) {
+	var count int
+	if err := u.DBService.GetDB().Table(mfaRecoveryCodes_DBModels.TABLE_NAME).Scopes(whr.Scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdateRecoveryCode returns how many codes were updated, so a code used concurrently can be told apart.
+func (u *MfaRecoveryCodeRepository) UpdateRecoveryCode(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(mfaRecoveryCodes_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (u *MfaRecoveryCodeRepository) DeleteRecoveryCodes(ctx context.Context, whr where.Filter) error {
+	tx := u.DBService.GetDB().Table(mfaRecoveryCodes_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Scopes(whr.Scope).Delete(&mfaRecoveryCodes_DBModels.RecoveryCode{}).Error
+}
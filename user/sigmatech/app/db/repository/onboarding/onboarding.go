@@ -0,0 +1,51 @@
+package onboarding
+
+import (
+	"context"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	cif_DBModels "user/sigmatech/app/db/dto/customer_information_files"
+	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	onboardings_DBModels "user/sigmatech/app/db/dto/onboardings"
+)
+
+// IOnboardingRepository creates customers together with their CIF and limits.
+type IOnboardingRepository interface {
+	CreateOnboardings(ctx context.Context, onboardings []*onboardings_DBModels.Onboarding) error
+}
+
+type OnboardingRepository struct {
+	DBService *db.DBService
+}
+
+func NewOnboardingRepository(dbService *db.DBService) IOnboardingRepository {
+	return &OnboardingRepository{
+		DBService: dbService,
+	}
+}
+
+// CreateOnboardings creates the onboardings in a single database transaction, either all of them are created or none.
+func (u *OnboardingRepository) CreateOnboardings(ctx context.Context, onboardings []*onboardings_DBModels.Onboarding) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	for _, onboarding := range onboardings {
+		if err := tx.Table(customers_DBModels.TABLE_NAME).Create(&onboarding.Customer).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table(cif_DBModels.TABLE_NAME).Create(&onboarding.CIF).Error; err != nil {
+			return err
+		}
+
+		for _, limit := range onboarding.CustomerLimits {
+			if err := tx.Table(customerLimits_DBModels.TABLE_NAME).Create(limit).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit().Error
+}
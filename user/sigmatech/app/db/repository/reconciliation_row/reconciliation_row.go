@@ -0,0 +1,200 @@
+package reconciliation_row
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	reconciliationRows_DBModels "user/sigmatech/app/db/dto/reconciliation_rows"
+	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+type IReconciliationRowRepository interface {
+	CreateReconciliationRow(ctx context.Context, customer *reconciliationRows_DBModels.ReconciliationRow) error
+	GetReconciliationRow(ctx context.Context, whr where.Filter) (reconciliationRows_DBModels.ReconciliationRow, error)
+	GetReconciliationRows(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*reconciliationRows_DBModels.ReconciliationRow, response.Pagination, error)
+	UpdateReconciliationRow(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	// ApplyReconciliationRow stores a matched row and pays its amount into its installment in one transaction.
+	// applied is false, and nothing is stored, when the installment has been paid off in the meantime.
+	ApplyReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow, tolerance float64, paidAt time.Time, installmentPatch map[string]interface{}) (applied bool, err error)
+	DeleteReconciliationRow(ctx context.Context, filter where.Filter) error
+}
+
+type ReconciliationRowRepository struct {
+	DBService *db.DBService
+}
+
+func NewReconciliationRowRepository(dbService *db.DBService) IReconciliationRowRepository {
+	return &ReconciliationRowRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = reconciliationRows_DBModels.TABLE_NAME
+
+func (u *ReconciliationRowRepository) CreateReconciliationRow(ctx context.Context, customer *reconciliationRows_DBModels.ReconciliationRow) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(reconciliationRows_DBModels.TABLE_NAME).Create(&customer).Error; err != nil {
+		return err // Return the error if customer creation fails
+	}
+	tx.Commit() // Commit the transaction
+
+	return nil // Return the created customer and no error
+}
+
+func (u *ReconciliationRowRepository) GetReconciliationRow(ctx context.Context, whr where.Filter) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	tx := u.DBService.GetDB().Table(reconciliationRows_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var customer reconciliationRows_DBModels.ReconciliationRow              // Variable to store the retrieved customer
+
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reconciliationRows_DBModels.ReconciliationRow{}, nil // Return an empty customer if the record is not found
+		}
+
+		return customer, err // Return the retrieved customer and error, if any
+	}
+
+	return customer, nil // Return the retrieved customer and no error
+}
+
+func (u *ReconciliationRowRepository) GetReconciliationRows(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}) (record []*reconciliationRows_DBModels.ReconciliationRow, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(reconciliationRows_DBModels.TABLE_NAME)
+
+	var columnsToSearch = []string{
+		reconciliationRows_DBModels.COLUMN_REFERENCE,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, reconciliationRows_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+// UpdateReconciliationRow returns how many rows were updated, so a row resolved concurrently can be told apart.
+func (u *ReconciliationRowRepository) UpdateReconciliationRow(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(reconciliationRows_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// ApplyReconciliationRow inserts the row first, so a mutation another import already applied fails on the
+// unique indexes over applied rows before anything is paid. The amount is added in SQL rather than written
+// back, and the installment is paid at paidAt once it covers the installment amount less tolerance.
+func (u *ReconciliationRowRepository) ApplyReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow, tolerance float64, paidAt time.Time, installmentPatch map[string]interface{}) (bool, error) {
+	if row.TransactionInstallmentUuid == nil {
+		return false, nil
+	}
+
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(reconciliationRows_DBModels.TABLE_NAME).Create(row).Error; err != nil {
+		return false, err
+	}
+
+	patch := map[string]interface{}{
+		transaction_installments_DBModels.COLUMN_AMOUNT_PAID: gorm.Expr("amount_paid + ?", row.Amount),
+		transaction_installments_DBModels.COLUMN_PAYMENT_AT:  gorm.Expr("CASE WHEN amount_paid + ? >= amount - ? THEN ?::date END", row.Amount, tolerance, paidAt),
+	}
+	for column, value := range installmentPatch {
+		patch[column] = value
+	}
+
+	fInstallment := where.Eq(transaction_installments_DBModels.COLUM_UUID, *row.TransactionInstallmentUuid).IsNull(transaction_installments_DBModels.COLUMN_PAYMENT_AT)
+	paid := tx.Table(transaction_installments_DBModels.TABLE_NAME).Scopes(fInstallment.Scope).Updates(patch)
+	if paid.Error != nil || paid.RowsAffected == 0 {
+		return false, paid.Error
+	}
+
+	return true, tx.Commit().Error
+}
+
+func (u *ReconciliationRowRepository) DeleteReconciliationRow(ctx context.Context, filter where.Filter) error {
+	tx := u.DBService.GetDB().Table(reconciliationRows_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback() // Rollback the transaction if a panic occurs
+		}
+	}()
+
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+	if err := tx.Scopes(filter.Scope).Delete(&reconciliationRows_DBModels.ReconciliationRow{}).Error; err != nil {
+		tx.Rollback() // Rollback the transaction if the deletion fails
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			// Foreign key constraint violation detected, rollback the transaction
+			tx.Rollback()
+
+			// Start a new transaction for the schema query
+			schemaTx := u.DBService.GetDB().Begin()
+			defer func() {
+				if r := recover(); r != nil {
+					schemaTx.Rollback() // Rollback the schema transaction if a panic occurs
+				}
+			}()
+
+			// Query the database schema to get tables with foreign key constraints referencing the specified table
+			var foreignKeys []struct {
+				ConstraintName   string
+				ReferencingTable string
+			}
+			err := schemaTx.Raw(`
+			SELECT con.conname AS constraint_name, con.conrelid::regclass AS referencing_table
+			FROM pg_constraint con
+			WHERE con.contype = 'f'
+			AND con.confrelid = ?::regclass`, tableName).Scan(&foreignKeys).Error
+
+			if err != nil {
+				return err
+			}
+
+			var referencingTables []string
+			// Print or process the foreign key information
+			for _, fk := range foreignKeys {
+				referencingTables = append(referencingTables, fk.ReferencingTable)
+			}
+
+			// Rollback the schema transaction
+			schemaTx.Rollback()
+
+			// Return the foreign key constraint violation error
+			return fmt.Errorf("cannot delete from table %s because of foreign key constraints on tables %s", tableName, strings.Join(referencingTables, ", "))
+		}
+		return err
+	}
+
+	return tx.Commit().Error // Commit the transaction and return any error
+}
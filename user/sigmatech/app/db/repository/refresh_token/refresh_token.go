@@ -0,0 +1,123 @@
+package refresh_token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	refreshTokens_DBModels "user/sigmatech/app/db/dto/refresh_tokens"
+	"user/sigmatech/app/db/where"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// IRefreshTokenRepository keeps the issued refresh tokens and their families.
+type IRefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *refreshTokens_DBModels.RefreshToken) error
+	GetRefreshToken(ctx context.Context, whr where.Filter) (refreshTokens_DBModels.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, tokenUuid uuid.UUID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyUuid uuid.UUID) error
+	RevokeSubjectRefreshTokens(ctx context.Context, subjectType string, subjectUuid uuid.UUID) error
+	PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+type RefreshTokenRepository struct {
+	DBService *db.DBService
+}
+
+func NewRefreshTokenRepository(dbService *db.DBService) IRefreshTokenRepository {
+	return &RefreshTokenRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *refreshTokens_DBModels.RefreshToken) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(refreshTokens_DBModels.TABLE_NAME).Create(token).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *RefreshTokenRepository) GetRefreshToken(ctx context.Context, whr where.Filter) (refreshTokens_DBModels.RefreshToken, error) {
+	tx := u.DBService.GetDB().Table(refreshTokens_DBModels.TABLE_NAME)
+	var token refreshTokens_DBModels.RefreshToken
+
+	if err := tx.Scopes(whr.Scope).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return refreshTokens_DBModels.RefreshToken{}, nil
+		}
+
+		return token, err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken marks the token rotated. It reports false when the token was already rotated,
+// revoked or expired, the single UPDATE makes concurrent refreshes with the same token rotate it once.
+func (u *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, tokenUuid uuid.UUID) (bool, error) {
+	tx := u.DBService.GetDB().Table(refreshTokens_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	now := time.Now()
+	result := tx.Where(fmt.Sprintf("%s = ? AND %s IS NULL AND %s IS NULL AND %s > ?",
+		refreshTokens_DBModels.COLUM_UUID,
+		refreshTokens_DBModels.COLUMN_ROTATED_AT,
+		refreshTokens_DBModels.COLUMN_REVOKED_AT,
+		refreshTokens_DBModels.COLUMN_EXPIRES_AT,
+	), tokenUuid, now).Updates(map[string]interface{}{
+		refreshTokens_DBModels.COLUMN_ROTATED_AT: now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (u *RefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyUuid uuid.UUID) error {
+	tx := u.DBService.GetDB().Table(refreshTokens_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Where(fmt.Sprintf("%s = ? AND %s IS NULL",
+		refreshTokens_DBModels.COLUMN_FAMILY_UUID,
+		refreshTokens_DBModels.COLUMN_REVOKED_AT,
+	), familyUuid).Updates(map[string]interface{}{
+		refreshTokens_DBModels.COLUMN_REVOKED_AT: time.Now(),
+	}).Error
+}
+
+func (u *RefreshTokenRepository) RevokeSubjectRefreshTokens(ctx context.Context, subjectType string, subjectUuid uuid.UUID) error {
+	tx := u.DBService.GetDB().Table(refreshTokens_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s IS NULL",
+		refreshTokens_DBModels.COLUMN_SUBJECT_TYPE,
+		refreshTokens_DBModels.COLUMN_SUBJECT_UUID,
+		refreshTokens_DBModels.COLUMN_REVOKED_AT,
+	), subjectType, subjectUuid).Updates(map[string]interface{}{
+		refreshTokens_DBModels.COLUMN_REVOKED_AT: time.Now(),
+	}).Error
+}
+
+// PurgeRefreshTokens deletes the tokens that expired before expiredBefore.
+func (u *RefreshTokenRepository) PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	tx := u.DBService.GetDB().Table(refreshTokens_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Where(fmt.Sprintf("%s < ?", refreshTokens_DBModels.COLUMN_EXPIRES_AT), expiredBefore).
+		Delete(&refreshTokens_DBModels.RefreshToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,129 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	rolePermissions_DBModels "user/sigmatech/app/db/dto/role_permissions"
+	roles_DBModels "user/sigmatech/app/db/dto/roles"
+	userRoles_DBModels "user/sigmatech/app/db/dto/user_roles"
+	"user/sigmatech/app/db/where"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// IRoleRepository reads the seeded roles and manages the roles of users.
+type IRoleRepository interface {
+	GetRole(ctx context.Context, whr where.Filter) (roles_DBModels.Role, error)
+	GetRoles(ctx context.Context, whr where.Filter) ([]*roles_DBModels.Role, error)
+	GetRolePermissions(ctx context.Context, roleUuids []uuid.UUID) ([]*rolePermissions_DBModels.RolePermission, error)
+	GetUserRoles(ctx context.Context, userUuid uuid.UUID) ([]*roles_DBModels.Role, error)
+	SetUserRoles(ctx context.Context, userUuid uuid.UUID, roleUuids []uuid.UUID, createdBy *uuid.UUID) error
+}
+
+type RoleRepository struct {
+	DBService *db.DBService
+}
+
+func NewRoleRepository(dbService *db.DBService) IRoleRepository {
+	return &RoleRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *RoleRepository) GetRole(ctx context.Context, whr where.Filter) (roles_DBModels.Role, error) {
+	tx := u.DBService.GetDB().Table(roles_DBModels.TABLE_NAME) // Get the database instance and set table name
+	var role roles_DBModels.Role                               // Variable to store the retrieved role
+
+	if err := tx.Scopes(whr.Scope).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return roles_DBModels.Role{}, nil // Return an empty role if the record is not found
+		}
+
+		return role, err
+	}
+
+	return role, nil
+}
+
+// GetRoles returns the roles matching whr, every role when whr is empty. Roles are few, they aren't paginated.
+func (u *RoleRepository) GetRoles(ctx context.Context, whr where.Filter) ([]*roles_DBModels.Role, error) {
+	var roles []*roles_DBModels.Role
+
+	query := u.DBService.GetDB().Table(roles_DBModels.TABLE_NAME).Scopes(whr.Scope)
+
+	if err := query.Order(fmt.Sprintf("%s asc", roles_DBModels.COLUMN_CODE)).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (u *RoleRepository) GetRolePermissions(ctx context.Context, roleUuids []uuid.UUID) ([]*rolePermissions_DBModels.RolePermission, error) {
+	var permissions []*rolePermissions_DBModels.RolePermission
+	if len(roleUuids) == 0 {
+		return permissions, nil
+	}
+
+	err := u.DBService.GetDB().Table(rolePermissions_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s IN (?)", rolePermissions_DBModels.COLUMN_ROLE_UUID), roleUuids).
+		Order(fmt.Sprintf("%s asc", rolePermissions_DBModels.COLUMN_PERMISSION)).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (u *RoleRepository) GetUserRoles(ctx context.Context, userUuid uuid.UUID) ([]*roles_DBModels.Role, error) {
+	var roles []*roles_DBModels.Role
+
+	err := u.DBService.GetDB().Table(roles_DBModels.TABLE_NAME).
+		Select(fmt.Sprintf("%s.*", roles_DBModels.TABLE_NAME)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.%s = %s.%s",
+			userRoles_DBModels.TABLE_NAME,
+			userRoles_DBModels.TABLE_NAME, userRoles_DBModels.COLUMN_ROLE_UUID,
+			roles_DBModels.TABLE_NAME, roles_DBModels.COLUM_UUID,
+		)).
+		Where(fmt.Sprintf("%s.%s = ?", userRoles_DBModels.TABLE_NAME, userRoles_DBModels.COLUMN_USER_UUID), userUuid).
+		Order(fmt.Sprintf("%s.%s asc", roles_DBModels.TABLE_NAME, roles_DBModels.COLUMN_CODE)).
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of a user in a single database transaction.
+func (u *RoleRepository) SetUserRoles(ctx context.Context, userUuid uuid.UUID, roleUuids []uuid.UUID, createdBy *uuid.UUID) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	err := tx.Table(userRoles_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s = ?", userRoles_DBModels.COLUMN_USER_UUID), userUuid).
+		Delete(&userRoles_DBModels.UserRole{}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, roleUuid := range roleUuids {
+		userRole := userRoles_DBModels.UserRole{
+			UserUuid:  userUuid,
+			RoleUuid:  roleUuid,
+			CreatedAt: time.Now(),
+			CreatedBy: createdBy,
+		}
+		if err := tx.Table(userRoles_DBModels.TABLE_NAME).Create(&userRole).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
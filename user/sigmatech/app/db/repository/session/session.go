@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	sessions_DBModels "user/sigmatech/app/db/dto/sessions"
+	"user/sigmatech/app/db/where"
+
+	"github.com/jinzhu/gorm"
+)
+
+type ISessionRepository interface {
+	CreateSession(ctx context.Context, session *sessions_DBModels.Session) error
+	GetSession(ctx context.Context, whr where.Filter) (sessions_DBModels.Session, error)
+	GetSessions(ctx context.Context, whr where.Filter) ([]*sessions_DBModels.Session, error)
+	CountSessions(ctx context.Context, whr where.Filter) (int, error)
+	UpdateSession(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	PurgeSessions(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+type SessionRepository struct {
+	DBService *db.DBService
+}
+
+func NewSessionRepository(dbService *db.DBService) ISessionRepository {
+	return &SessionRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *SessionRepository) CreateSession(ctx context.Context, session *sessions_DBModels.Session) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(sessions_DBModels.TABLE_NAME).Create(session).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *SessionRepository) GetSession(ctx context.Context, whr where.Filter) (sessions_DBModels.Session, error) {
+	tx := u.DBService.GetDB().Table(sessions_DBModels.TABLE_NAME)
+	var session sessions_DBModels.Session
+
+	if err := tx.Scopes(whr.Scope).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sessions_DBModels.Session{}, nil
+		}
+
+		return session, err
+	}
+
+	return session, nil
+}
+
+// GetSessions returns the sessions matching whr, the latest first.
+func (u *SessionRepository) GetSessions(ctx context.Context, whr where.Filter) ([]*sessions_DBModels.Session, error) {
+	var sessions []*sessions_DBModels.Session
+
+	err := u.DBService.GetDB().Table(sessions_DBModels.TABLE_NAME).
+		Scopes(whr.Scope).
+		Order(fmt.Sprintf("%s desc", sessions_DBModels.COLUMN_CREATED_AT)).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (u *SessionRepository) CountSessions(ctx context.Context, whr where.Filter) (int, error) {
+	var count int
+	if err := u.DBService.GetDB().Table(sessions_DBModels.TABLE_NAME).Scopes(whr.Scope).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (u *SessionRepository) UpdateSession(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	tx := u.DBService.GetDB().Table(sessions_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Scopes(whr.Scope).Updates(patch).Error
+}
+
+// PurgeSessions deletes the sessions that expired before expiredBefore.
+func (u *SessionRepository) PurgeSessions(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	result := tx.Table(sessions_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s < ?", sessions_DBModels.COLUMN_EXPIRES_AT), expiredBefore).
+		Delete(&sessions_DBModels.Session{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, tx.Commit().Error
+}
@@ -2,7 +2,6 @@ package transaction
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,6 +9,8 @@ import (
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
 	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/dto/request"
 	"user/sigmatech/app/service/dto/response"
 	"user/sigmatech/app/service/util"
@@ -20,10 +21,10 @@ import (
 
 type ITransactionRepository interface {
 	CreateTransaction(ctx context.Context, customer *transactions_DBModels.Transaction) error
-	GetTransaction(ctx context.Context, whr string) (transactions_DBModels.Transaction, error)
+	GetTransaction(ctx context.Context, whr where.Filter) (transactions_DBModels.Transaction, error)
 	GetTransactions(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transactions_DBModels.Transaction, response.Pagination, error)
-	UpdateTransaction(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteTransaction(ctx context.Context, filter string) error
+	UpdateTransaction(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteTransaction(ctx context.Context, filter where.Filter) error
 	GenerateContractNumber(ctx context.Context) (string, error)
 }
 
@@ -52,11 +53,11 @@ func (u *TransactionRepository) CreateTransaction(ctx context.Context, customer
 	return nil // Return the created customer and no error
 }
 
-func (u *TransactionRepository) GetTransaction(ctx context.Context, whr string) (transactions_DBModels.Transaction, error) {
+func (u *TransactionRepository) GetTransaction(ctx context.Context, whr where.Filter) (transactions_DBModels.Transaction, error) {
 	tx := u.DBService.GetDB().Table(transactions_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer transactions_DBModels.Transaction                    // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return transactions_DBModels.Transaction{}, nil // Return an empty customer if the record is not found
 		}
@@ -74,16 +75,7 @@ func (u *TransactionRepository) GetTransactions(ctx context.Context, paginationR
 		transactions_DBModels.COLUMN_CONTRACT_NUMBER,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -98,34 +90,11 @@ func (u *TransactionRepository) GetTransactions(ctx context.Context, paginationR
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, transactions_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *TransactionRepository) UpdateTransaction(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *TransactionRepository) UpdateTransaction(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(transactions_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -134,7 +103,7 @@ func (u *TransactionRepository) UpdateTransaction(ctx context.Context, whr strin
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if customer update fails
 		return err
 	}
@@ -142,7 +111,7 @@ func (u *TransactionRepository) UpdateTransaction(ctx context.Context, whr strin
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *TransactionRepository) DeleteTransaction(ctx context.Context, filter string) error {
+func (u *TransactionRepository) DeleteTransaction(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(transactions_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -151,7 +120,7 @@ func (u *TransactionRepository) DeleteTransaction(ctx context.Context, filter st
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&transactions_DBModels.Transaction{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&transactions_DBModels.Transaction{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
@@ -201,10 +170,10 @@ func (u *TransactionRepository) DeleteTransaction(ctx context.Context, filter st
 func (u *TransactionRepository) GenerateContractNumber(ctx context.Context) (string, error) {
 	tx := u.DBService.GetDB().Table(transactions_DBModels.TABLE_NAME)
 
-	latestData := fmt.Sprintf(" date(%s)='%s'", transactions_DBModels.COLUMN_CREATED_AT, time.Now().Format("2006-01-02"))
+	latestData := where.Eq(where.Date(transactions_DBModels.COLUMN_CREATED_AT), time.Now().Format("2006-01-02"))
 
 	var record transactions_DBModels.Transaction
-	if err := tx.Where(latestData).Order("id DESC").First(&record).Error; err != nil {
+	if err := tx.Scopes(latestData.Scope).Order("id DESC").First(&record).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Sprintf("TX_%06d_%v", 1, time.Now().Unix()), nil
 		}
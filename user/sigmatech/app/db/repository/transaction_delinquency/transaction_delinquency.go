@@ -0,0 +1,123 @@
+package transaction_delinquency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	db "user/sigmatech/app/db"
+	transactionDelinquencies_DBModels "user/sigmatech/app/db/dto/transaction_delinquencies"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/request/collection"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/util"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ITransactionDelinquencyRepository reads the transaction_delinquencies view, it is read only.
+type ITransactionDelinquencyRepository interface {
+	GetTransactionDelinquency(ctx context.Context, whr where.Filter) (transactionDelinquencies_DBModels.TransactionDelinquency, error)
+	GetTransactionDelinquencies(ctx context.Context, pagination request.Pagination, filter map[string]interface{}, worklist collection.WorklistFilter) ([]*transactionDelinquencies_DBModels.TransactionDelinquency, response.Pagination, error)
+	GetBucketSummaries(ctx context.Context, filter map[string]interface{}) ([]*transactionDelinquencies_DBModels.BucketSummary, error)
+}
+
+type TransactionDelinquencyRepository struct {
+	DBService *db.DBService
+}
+
+func NewTransactionDelinquencyRepository(dbService *db.DBService) ITransactionDelinquencyRepository {
+	return &TransactionDelinquencyRepository{
+		DBService: dbService,
+	}
+}
+
+var tableName = transactionDelinquencies_DBModels.TABLE_NAME
+
+func (u *TransactionDelinquencyRepository) GetTransactionDelinquency(ctx context.Context, whr where.Filter) (transactionDelinquencies_DBModels.TransactionDelinquency, error) {
+	tx := u.DBService.GetDB().Table(tableName)                          // Get the database instance and set view name
+	var record transactionDelinquencies_DBModels.TransactionDelinquency // Variable to store the retrieved delinquency
+
+	if err := tx.Scopes(whr.Scope).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return transactionDelinquencies_DBModels.TransactionDelinquency{}, nil // Return an empty delinquency if the contract is done or doesn't exist
+		}
+
+		return record, err
+	}
+
+	return record, nil
+}
+
+func (u *TransactionDelinquencyRepository) GetTransactionDelinquencies(ctx context.Context, paginationRequest request.Pagination, filter map[string]interface{}, worklist collection.WorklistFilter) (record []*transactionDelinquencies_DBModels.TransactionDelinquency, paginationResponse response.Pagination, err error) {
+	tx := u.DBService.GetDB().Table(tableName)
+
+	var columnsToSearch = []string{
+		transactionDelinquencies_DBModels.COLUMN_CONTRACT_NUMBER,
+		transactionDelinquencies_DBModels.COLUMN_CUSTOMER_NAME,
+	}
+
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
+
+	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
+	for key, value := range filter {
+		if !strings.Contains(key, ".") {
+			filter[tableName+"."+key] = value
+			delete(filter, key)
+		}
+	}
+
+	query, err = util.ApplyFilterCondition(query, filter)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	if worklist.DpdFrom != nil {
+		query = query.Where(fmt.Sprintf("%s.%s >= ?", tableName, transactionDelinquencies_DBModels.COLUMN_DPD), *worklist.DpdFrom)
+	}
+	if worklist.DpdTo != nil {
+		query = query.Where(fmt.Sprintf("%s.%s <= ?", tableName, transactionDelinquencies_DBModels.COLUMN_DPD), *worklist.DpdTo)
+	}
+
+	paginationResponse, err = paginate.Find(query, tableName, transactionDelinquencies_DBModels.COLUMN_TRANSACTION_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
+}
+
+// GetBucketSummaries counts the open contracts of every days past due bucket, empty buckets included.
+func (u *TransactionDelinquencyRepository) GetBucketSummaries(ctx context.Context, filter map[string]interface{}) ([]*transactionDelinquencies_DBModels.BucketSummary, error) {
+	query, err := util.ApplyFilterCondition(u.DBService.GetDB().Table(tableName), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*transactionDelinquencies_DBModels.BucketSummary
+	err = query.
+		Select(fmt.Sprintf("%s AS bucket, COUNT(*) AS contracts, COALESCE(SUM(%s), 0) AS overdue_amount, COALESCE(SUM(%s), 0) AS outstanding_amount",
+			transactionDelinquencies_DBModels.COLUMN_BUCKET,
+			transactionDelinquencies_DBModels.COLUMN_OVERDUE_AMOUNT,
+			transactionDelinquencies_DBModels.COLUMN_OUTSTANDING_AMOUNT,
+		)).
+		Group(transactionDelinquencies_DBModels.COLUMN_BUCKET).
+		Scan(&rows).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	byBucket := make(map[string]*transactionDelinquencies_DBModels.BucketSummary, len(rows))
+	for _, row := range rows {
+		byBucket[row.Bucket] = row
+	}
+
+	summaries := make([]*transactionDelinquencies_DBModels.BucketSummary, 0, len(transactionDelinquencies_DBModels.Buckets))
+	for _, bucket := range transactionDelinquencies_DBModels.Buckets {
+		if summary, ok := byBucket[bucket]; ok {
+			summaries = append(summaries, summary)
+			continue
+		}
+		summaries = append(summaries, &transactionDelinquencies_DBModels.BucketSummary{Bucket: bucket})
+	}
+
+	return summaries, nil
+}
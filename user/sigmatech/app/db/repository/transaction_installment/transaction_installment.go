@@ -2,13 +2,16 @@ package transaction_installment_installment
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
 	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 	"user/sigmatech/app/service/dto/request"
 	"user/sigmatech/app/service/dto/response"
 	"user/sigmatech/app/service/util"
@@ -19,10 +22,12 @@ import (
 
 type ITransactionInstallmentRepository interface {
 	CreateTransactionInstallment(ctx context.Context, customer *transaction_installments_DBModels.TransactionInstallment) error
-	GetTransactionInstallment(ctx context.Context, whr string) (transaction_installments_DBModels.TransactionInstallment, error)
+	GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error)
 	GetTransactionInstallments(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transaction_installments_DBModels.TransactionInstallment, response.Pagination, error)
-	UpdateTransactionInstallment(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteTransactionInstallment(ctx context.Context, filter string) error
+	UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	PayTransactionInstallment(ctx context.Context, whr where.Filter, amount, tolerance float64, paidAt time.Time, patch map[string]interface{}) (int64, error)
+	DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error
+	GetUnpaidTransactionInstallments(ctx context.Context, transactionColumn string, value string) ([]*transaction_installments_DBModels.TransactionInstallment, error)
 }
 
 type TransactionInstallmentRepository struct {
@@ -50,11 +55,11 @@ func (u *TransactionInstallmentRepository) CreateTransactionInstallment(ctx cont
 	return nil // Return the created customer and no error
 }
 
-func (u *TransactionInstallmentRepository) GetTransactionInstallment(ctx context.Context, whr string) (transaction_installments_DBModels.TransactionInstallment, error) {
+func (u *TransactionInstallmentRepository) GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error) {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var customer transaction_installments_DBModels.TransactionInstallment         // Variable to store the retrieved customer
 
-	if err := tx.Where(whr).First(&customer).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&customer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return transaction_installments_DBModels.TransactionInstallment{}, nil // Return an empty customer if the record is not found
 		}
@@ -70,16 +75,7 @@ func (u *TransactionInstallmentRepository) GetTransactionInstallments(ctx contex
 
 	var columnsToSearch = []string{}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope)
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -94,34 +90,11 @@ func (u *TransactionInstallmentRepository) GetTransactionInstallments(ctx contex
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, transaction_installments_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).Begin() // Start a database transaction_installment
 	defer func() {
 		if r := recover(); r != nil {
@@ -130,7 +103,7 @@ func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx cont
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction_installment if customer update fails
 		return err
 	}
@@ -138,7 +111,30 @@ func (u *TransactionInstallmentRepository) UpdateTransactionInstallment(ctx cont
 	return tx.Commit().Error // Commit the transaction_installment and return any error
 }
 
-func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx context.Context, filter string) error {
+// PayTransactionInstallment adds amount to the installments matched by whr, in SQL so concurrent payments
+// all add up, and sets their payment_at to paidAt once they are paid off within tolerance. It returns how
+// many installments were updated.
+func (u *TransactionInstallmentRepository) PayTransactionInstallment(ctx context.Context, whr where.Filter, amount, tolerance float64, paidAt time.Time, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	payment := map[string]interface{}{
+		transaction_installments_DBModels.COLUMN_AMOUNT_PAID: gorm.Expr("amount_paid + ?", amount),
+		transaction_installments_DBModels.COLUMN_PAYMENT_AT:  gorm.Expr("CASE WHEN amount_paid + ? >= amount - ? THEN ?::date END", amount, tolerance, paidAt),
+	}
+	for column, value := range patch {
+		payment[column] = value
+	}
+
+	result := tx.Scopes(whr.Scope).Updates(payment)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).Begin() // Start a database transaction_installment
 	defer func() {
 		if r := recover(); r != nil {
@@ -147,7 +143,7 @@ func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx cont
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&transaction_installments_DBModels.TransactionInstallment{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&transaction_installments_DBModels.TransactionInstallment{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction_installment if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction_installment
@@ -193,3 +189,28 @@ func (u *TransactionInstallmentRepository) DeleteTransactionInstallment(ctx cont
 
 	return tx.Commit().Error // Commit the transaction_installment and return any error
 }
+
+// GetUnpaidTransactionInstallments returns the unpaid installments of the contracts whose transactionColumn
+// equals value, such as every contract of a customer or one contract number, ordered by due date.
+func (u *TransactionInstallmentRepository) GetUnpaidTransactionInstallments(ctx context.Context, transactionColumn string, value string) ([]*transaction_installments_DBModels.TransactionInstallment, error) {
+	var records []*transaction_installments_DBModels.TransactionInstallment
+
+	err := u.DBService.GetDB().Table(transaction_installments_DBModels.TABLE_NAME).
+		Select(fmt.Sprintf("%s.*", transaction_installments_DBModels.TABLE_NAME)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.%s = %s.%s",
+			transactions_DBModels.TABLE_NAME,
+			transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUM_UUID,
+			transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_TRANSACTION_UUID,
+		)).
+		Where(fmt.Sprintf("%s.%s = ? AND %s.%s IS NULL",
+			transactions_DBModels.TABLE_NAME, transactionColumn,
+			transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_PAYMENT_AT,
+		), value).
+		Order(fmt.Sprintf("%s.%s ASC", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_DUE_DATE)).
+		Find(&records).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -2,10 +2,12 @@ package user
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+	"user/sigmatech/app/db/paginate"
+	"user/sigmatech/app/db/where"
 
 	"user/sigmatech/app/constants"
 	db "user/sigmatech/app/db"
@@ -20,10 +22,13 @@ import (
 
 type IUserRepository interface {
 	CreateUser(ctx context.Context, user *users_DBModels.User) error
-	GetUser(ctx context.Context, whr string) (users_DBModels.User, error)
+	GetUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error)
 	GetUsers(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*users_DBModels.User, response.Pagination, error)
-	UpdateUser(ctx context.Context, whr string, patch map[string]interface{}) error
-	DeleteUser(ctx context.Context, filter string) error
+	UpdateUser(ctx context.Context, whr where.Filter, patch map[string]interface{}) error
+	DeleteUser(ctx context.Context, filter where.Filter) error
+	GetDeletedUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error)
+	RestoreUser(ctx context.Context, whr where.Filter) error
+	PurgeUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type UserRepository struct {
@@ -51,11 +56,11 @@ func (u *UserRepository) CreateUser(ctx context.Context, user *users_DBModels.Us
 	return nil // Return the created user and no error
 }
 
-func (u *UserRepository) GetUser(ctx context.Context, whr string) (users_DBModels.User, error) {
+func (u *UserRepository) GetUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error) {
 	tx := u.DBService.GetDB().Table(users_DBModels.TABLE_NAME) // Get the database instance and set table name
 	var user users_DBModels.User                               // Variable to store the retrieved user
 
-	if err := tx.Where(whr).First(&user).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return users_DBModels.User{}, nil // Return an empty user if the record is not found
 		}
@@ -74,16 +79,8 @@ func (u *UserRepository) GetUsers(ctx context.Context, paginationRequest request
 		users_DBModels.COLUMN_EMAIL,
 	}
 
-	var whr string
-	if paginationRequest.Query != "" {
-		var orConditions []string
-		for _, column := range columnsToSearch {
-			orConditions = append(orConditions, fmt.Sprintf("coalesce(%s)", column))
-		}
-		whr = fmt.Sprintf("(%s) ILIKE '%%%s%%'", strings.Join(orConditions, " || "), paginationRequest.Query)
-	}
-
-	query := tx.Where(whr)
+	// Count isn't given the model, so deleted rows are left out here rather than by gorm
+	query := tx.Scopes(where.Search(paginationRequest.Query, columnsToSearch...).Scope).Where(fmt.Sprintf("%s.%s IS NULL", tableName, users_DBModels.COLUMN_DELETED_AT))
 
 	// Iterate through the filter map and add the TABLE_NAME prefix to filter parameters that don't have a "." prefix
 	for key, value := range filter {
@@ -98,34 +95,11 @@ func (u *UserRepository) GetUsers(ctx context.Context, paginationRequest request
 		return nil, response.Pagination{}, err
 	}
 
-	var totalCount int
-	if err := query.Count(&totalCount).Error; err == sql.ErrNoRows {
-		return nil, response.Pagination{}, nil
-	}
-
-	query = query.Limit(*paginationRequest.Limit).Offset((*paginationRequest.Page - 1) * *paginationRequest.Limit)
-
-	if totalCount == 0 || *paginationRequest.Page > ((totalCount+*paginationRequest.Limit-1) / *paginationRequest.Limit) {
-		return nil, response.Pagination{}, nil
-	}
-	paginationResponse.TotalCount = totalCount
-	paginationResponse.TotalPages = (totalCount + *paginationRequest.Limit - 1) / *paginationRequest.Limit
-	paginationResponse.Page = *paginationRequest.Page
-	paginationResponse.PerPage = *paginationRequest.Limit
-
-	query = query.Order(fmt.Sprintf("%s %s", paginationRequest.Order, paginationRequest.Sort))
-
-	if err := query.Find(&record).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, response.Pagination{}, nil
-		}
-		return record, paginationResponse, err
-	}
-
-	return record, paginationResponse, nil
+	paginationResponse, err = paginate.Find(query, tableName, users_DBModels.COLUM_UUID, paginationRequest, &record)
+	return record, paginationResponse, err
 }
 
-func (u *UserRepository) UpdateUser(ctx context.Context, whr string, patch map[string]interface{}) error {
+func (u *UserRepository) UpdateUser(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
 	tx := u.DBService.GetDB().Table(users_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -134,7 +108,7 @@ func (u *UserRepository) UpdateUser(ctx context.Context, whr string, patch map[s
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(whr).Updates(patch).Error; err != nil {
+	if err := tx.Scopes(whr.Scope).Updates(patch).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if user update fails
 		return err
 	}
@@ -142,7 +116,8 @@ func (u *UserRepository) UpdateUser(ctx context.Context, whr string, patch map[s
 	return tx.Commit().Error // Commit the transaction and return any error
 }
 
-func (u *UserRepository) DeleteUser(ctx context.Context, filter string) error {
+// DeleteUser soft deletes, gorm sets deleted_at since users_DBModels.User has a DeletedAt field.
+func (u *UserRepository) DeleteUser(ctx context.Context, filter where.Filter) error {
 	tx := u.DBService.GetDB().Table(users_DBModels.TABLE_NAME).Begin() // Start a database transaction
 	defer func() {
 		if r := recover(); r != nil {
@@ -151,7 +126,7 @@ func (u *UserRepository) DeleteUser(ctx context.Context, filter string) error {
 	}()
 
 	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
-	if err := tx.Where(filter).Delete(&users_DBModels.User{}).Error; err != nil {
+	if err := tx.Scopes(filter.Scope).Delete(&users_DBModels.User{}).Error; err != nil {
 		tx.Rollback() // Rollback the transaction if the deletion fails
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
 			// Foreign key constraint violation detected, rollback the transaction
@@ -197,3 +172,51 @@ func (u *UserRepository) DeleteUser(ctx context.Context, filter string) error {
 
 	return tx.Commit().Error // Commit the transaction and return any error
 }
+
+// GetDeletedUser returns a soft deleted user, an empty one when there is none matching whr.
+func (u *UserRepository) GetDeletedUser(ctx context.Context, whr where.Filter) (users_DBModels.User, error) {
+	tx := u.DBService.GetDB().Unscoped().Table(users_DBModels.TABLE_NAME)
+	var user users_DBModels.User
+
+	if err := tx.Scopes(whr.Scope).Where(fmt.Sprintf("%s IS NOT NULL", users_DBModels.COLUMN_DELETED_AT)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return users_DBModels.User{}, nil
+		}
+
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (u *UserRepository) RestoreUser(ctx context.Context, whr where.Filter) error {
+	tx := u.DBService.GetDB().Unscoped().Table(users_DBModels.TABLE_NAME).Begin() // Start a database transaction
+	defer tx.Rollback()                                                           // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)                       // Set the database log mode
+
+	err := tx.Scopes(whr.Scope).Updates(map[string]interface{}{
+		users_DBModels.COLUMN_DELETED_AT: nil,
+		users_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// PurgeUsers hard deletes the users soft deleted before deletedBefore.
+func (u *UserRepository) PurgeUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tx := u.DBService.GetDB().Unscoped().Begin()            // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	result := tx.Table(users_DBModels.TABLE_NAME).
+		Where(fmt.Sprintf("%s < ?", users_DBModels.COLUMN_DELETED_AT), deletedBefore).
+		Delete(&users_DBModels.User{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, tx.Commit().Error
+}
@@ -0,0 +1,76 @@
+package user_mfa
+
+import (
+	"context"
+	"errors"
+	"user/sigmatech/app/constants"
+	db "user/sigmatech/app/db"
+	userMfa_DBModels "user/sigmatech/app/db/dto/user_mfa"
+	"user/sigmatech/app/db/where"
+
+	"github.com/jinzhu/gorm"
+)
+
+type IUserMfaRepository interface {
+	CreateUserMfa(ctx context.Context, mfa *userMfa_DBModels.UserMfa) error
+	GetUserMfa(ctx context.Context, whr where.Filter) (userMfa_DBModels.UserMfa, error)
+	UpdateUserMfa(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error)
+	DeleteUserMfa(ctx context.Context, whr where.Filter) error
+}
+
+type UserMfaRepository struct {
+	DBService *db.DBService
+}
+
+func NewUserMfaRepository(dbService *db.DBService) IUserMfaRepository {
+	return &UserMfaRepository{
+		DBService: dbService,
+	}
+}
+
+func (u *UserMfaRepository) CreateUserMfa(ctx context.Context, mfa *userMfa_DBModels.UserMfa) error {
+	tx := u.DBService.GetDB().Begin()                       // Start a database transaction
+	defer tx.Rollback()                                     // Rollback the transaction if not committed
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE) // Set the database log mode
+
+	if err := tx.Table(userMfa_DBModels.TABLE_NAME).Create(mfa).Error; err != nil {
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (u *UserMfaRepository) GetUserMfa(ctx context.Context, whr where.Filter) (userMfa_DBModels.UserMfa, error) {
+	tx := u.DBService.GetDB().Table(userMfa_DBModels.TABLE_NAME)
+	var mfa userMfa_DBModels.UserMfa
+
+	if err := tx.Scopes(whr.Scope).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userMfa_DBModels.UserMfa{}, nil
+		}
+
+		return mfa, err
+	}
+
+	return mfa, nil
+}
+
+// UpdateUserMfa returns how many enrollments were updated, so a code accepted concurrently can be told apart.
+func (u *UserMfaRepository) UpdateUserMfa(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	tx := u.DBService.GetDB().Table(userMfa_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	result := tx.Scopes(whr.Scope).Updates(patch)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (u *UserMfaRepository) DeleteUserMfa(ctx context.Context, whr where.Filter) error {
+	tx := u.DBService.GetDB().Table(userMfa_DBModels.TABLE_NAME)
+	tx.LogMode(constants.Config.DatabaseConfig.DB_LOG_MODE)
+
+	return tx.Scopes(whr.Scope).Delete(&userMfa_DBModels.UserMfa{}).Error
+}
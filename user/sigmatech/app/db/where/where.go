@@ -0,0 +1,301 @@
+package where
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Operator compares a column with the value of a Condition.
+type Operator string
+
+const (
+	EQ          Operator = "="
+	NOT_EQ      Operator = "<>"
+	LT          Operator = "<"
+	LTE         Operator = "<="
+	GT          Operator = ">"
+	GTE         Operator = ">="
+	IN          Operator = "IN"
+	NOT_IN      Operator = "NOT IN"
+	ILIKE       Operator = "ILIKE"
+	IS_NULL     Operator = "IS NULL"
+	IS_NOT_NULL Operator = "IS NOT NULL"
+	IS_TRUE     Operator = "IS TRUE"
+	IS_NOT_TRUE Operator = "IS NOT TRUE"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// column matches a plain or table qualified column, optionally wrapped by Lower or Date.
+var column = regexp.MustCompile(`^(?:(?:lower|date)\()?[a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)?\)?$`)
+
+// Condition compares a column with a value. The value is always sent as a bound parameter, only the
+// column and operator end up in the SQL.
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    interface{}
+}
+
+// Filter selects the rows matching all of its conditions and, for each of its groups, at least one
+// of the filters in the group. The zero Filter matches every row.
+type Filter struct {
+	Conditions []Condition
+	Groups     [][]Filter
+}
+
+// Eq returns a filter matching the rows where the column equals value, Filter.Eq adds the condition
+// to an existing filter. The other constructors below work the same way.
+func Eq(column string, value interface{}) Filter {
+	return Filter{}.Eq(column, value)
+}
+
+func NotEq(column string, value interface{}) Filter {
+	return Filter{}.NotEq(column, value)
+}
+
+func Lt(column string, value interface{}) Filter {
+	return Filter{}.Lt(column, value)
+}
+
+func Lte(column string, value interface{}) Filter {
+	return Filter{}.Lte(column, value)
+}
+
+func Gt(column string, value interface{}) Filter {
+	return Filter{}.Gt(column, value)
+}
+
+func Gte(column string, value interface{}) Filter {
+	return Filter{}.Gte(column, value)
+}
+
+func In(column string, values interface{}) Filter {
+	return Filter{}.In(column, values)
+}
+
+func NotIn(column string, values interface{}) Filter {
+	return Filter{}.NotIn(column, values)
+}
+
+func ILike(column string, pattern string) Filter {
+	return Filter{}.ILike(column, pattern)
+}
+
+func IsNull(column string) Filter {
+	return Filter{}.IsNull(column)
+}
+
+func IsNotNull(column string) Filter {
+	return Filter{}.IsNotNull(column)
+}
+
+func IsTrue(column string) Filter {
+	return Filter{}.IsTrue(column)
+}
+
+func IsNotTrue(column string) Filter {
+	return Filter{}.IsNotTrue(column)
+}
+
+func Any(filters ...Filter) Filter {
+	return Filter{}.Any(filters...)
+}
+
+// Compare returns a filter with a single condition, for operators picked at runtime.
+func Compare(column string, operator Operator, value interface{}) Filter {
+	return Filter{}.Compare(column, operator, value)
+}
+
+// Search matches the rows where any of the columns contains text, ignoring case. An empty text
+// matches every row.
+func Search(text string, columns ...string) Filter {
+	return Filter{}.Search(text, columns...)
+}
+
+func (f Filter) Eq(column string, value interface{}) Filter {
+	return f.Compare(column, EQ, value)
+}
+
+func (f Filter) NotEq(column string, value interface{}) Filter {
+	return f.Compare(column, NOT_EQ, value)
+}
+
+func (f Filter) Lt(column string, value interface{}) Filter {
+	return f.Compare(column, LT, value)
+}
+
+func (f Filter) Lte(column string, value interface{}) Filter {
+	return f.Compare(column, LTE, value)
+}
+
+func (f Filter) Gt(column string, value interface{}) Filter {
+	return f.Compare(column, GT, value)
+}
+
+func (f Filter) Gte(column string, value interface{}) Filter {
+	return f.Compare(column, GTE, value)
+}
+
+func (f Filter) In(column string, values interface{}) Filter {
+	return f.Compare(column, IN, values)
+}
+
+func (f Filter) NotIn(column string, values interface{}) Filter {
+	return f.Compare(column, NOT_IN, values)
+}
+
+func (f Filter) ILike(column string, pattern string) Filter {
+	return f.Compare(column, ILIKE, pattern)
+}
+
+func (f Filter) IsNull(column string) Filter {
+	return f.Compare(column, IS_NULL, nil)
+}
+
+func (f Filter) IsNotNull(column string) Filter {
+	return f.Compare(column, IS_NOT_NULL, nil)
+}
+
+func (f Filter) IsTrue(column string) Filter {
+	return f.Compare(column, IS_TRUE, nil)
+}
+
+func (f Filter) IsNotTrue(column string) Filter {
+	return f.Compare(column, IS_NOT_TRUE, nil)
+}
+
+// Compare adds a condition to a copy of the filter.
+func (f Filter) Compare(column string, operator Operator, value interface{}) Filter {
+	conditions := make([]Condition, len(f.Conditions), len(f.Conditions)+1)
+	copy(conditions, f.Conditions)
+	f.Conditions = append(conditions, Condition{Column: column, Operator: operator, Value: value})
+	return f
+}
+
+// Any adds a group to a copy of the filter, at least one of the filters has to match.
+func (f Filter) Any(filters ...Filter) Filter {
+	groups := make([][]Filter, len(f.Groups), len(f.Groups)+1)
+	copy(groups, f.Groups)
+	f.Groups = append(groups, filters)
+	return f
+}
+
+// And adds all the conditions and groups of the filters to a copy of f.
+func (f Filter) And(filters ...Filter) Filter {
+	for _, filter := range filters {
+		for _, condition := range filter.Conditions {
+			f = f.Compare(condition.Column, condition.Operator, condition.Value)
+		}
+		for _, group := range filter.Groups {
+			f = f.Any(group...)
+		}
+	}
+	return f
+}
+
+// Search adds a group matching the rows where any of the columns contains text.
+func (f Filter) Search(text string, columns ...string) Filter {
+	if text == "" || len(columns) == 0 {
+		return f
+	}
+
+	var filters []Filter
+	for _, column := range columns {
+		filters = append(filters, ILike(column, Contains(text)))
+	}
+	return f.Any(filters...)
+}
+
+// IsEmpty reports whether the filter matches every row.
+func (f Filter) IsEmpty() bool {
+	return len(f.Conditions) == 0 && len(f.Groups) == 0
+}
+
+// SQL returns the WHERE clause of the filter with ? placeholders, and the values to bind to them.
+func (f Filter) SQL() (string, []interface{}, error) {
+	var clauses []string
+	var args []interface{}
+
+	for _, condition := range f.Conditions {
+		if !column.MatchString(condition.Column) {
+			return "", nil, fmt.Errorf("%w: column %q", ErrInvalidFilter, condition.Column)
+		}
+
+		switch condition.Operator {
+		case EQ, NOT_EQ, LT, LTE, GT, GTE:
+			clauses = append(clauses, fmt.Sprintf("%s %s ?", condition.Column, condition.Operator))
+			args = append(args, condition.Value)
+		case IN, NOT_IN:
+			clauses = append(clauses, fmt.Sprintf("%s %s (?)", condition.Column, condition.Operator))
+			args = append(args, condition.Value)
+		case ILIKE:
+			clauses = append(clauses, fmt.Sprintf("%s::text ILIKE ?", condition.Column))
+			args = append(args, condition.Value)
+		case IS_NULL, IS_NOT_NULL, IS_TRUE, IS_NOT_TRUE:
+			clauses = append(clauses, fmt.Sprintf("%s %s", condition.Column, condition.Operator))
+		default:
+			return "", nil, fmt.Errorf("%w: operator %q", ErrInvalidFilter, condition.Operator)
+		}
+	}
+
+	for _, group := range f.Groups {
+		var alternatives []string
+		var alternativeArgs []interface{}
+		for _, filter := range group {
+			query, groupArgs, err := filter.SQL()
+			if err != nil {
+				return "", nil, err
+			}
+			if query == "" {
+				// One of the alternatives matches every row, so does the group
+				alternatives = nil
+				break
+			}
+			alternatives = append(alternatives, "("+query+")")
+			alternativeArgs = append(alternativeArgs, groupArgs...)
+		}
+		if len(alternatives) > 0 {
+			clauses = append(clauses, "("+strings.Join(alternatives, " OR ")+")")
+			args = append(args, alternativeArgs...)
+		}
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// Scope adds the filter to the query, an invalid filter fails it instead. It is meant for
+// gorm's Scopes, e.g. tx.Scopes(whr.Scope).First(&record).
+func (f Filter) Scope(db *gorm.DB) *gorm.DB {
+	query, args, err := f.SQL()
+	if err != nil {
+		db = db.New()
+		db.AddError(err)
+		return db
+	}
+	if query == "" {
+		return db
+	}
+	return db.Where(query, args...)
+}
+
+// Lower compares the lower case value of the column.
+func Lower(column string) string {
+	return "lower(" + column + ")"
+}
+
+// Date compares the date part of a timestamp column.
+func Date(column string) string {
+	return "date(" + column + ")"
+}
+
+// Contains returns the ILIKE pattern matching values that contain text, with the wildcards in text
+// matched literally.
+func Contains(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(text) + "%"
+}
@@ -0,0 +1,55 @@
+package where
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFilterSQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    Filter
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{name: "Given an empty filter When rendering Then it matches every row"},
+		{
+			name:      "Given chained conditions When rendering Then they are ANDed with bound values",
+			filter:    Eq("uuid", "x' OR '1'='1").IsNull("deleted_at").Gt(Date("created_at"), "2024-01-01"),
+			wantQuery: "uuid = ? AND deleted_at IS NULL AND date(created_at) > ?",
+			wantArgs:  []interface{}{"x' OR '1'='1", "2024-01-01"},
+		},
+		{
+			name:      "Given a group When rendering Then its filters are ORed",
+			filter:    IsNull("deleted_at").Any(Eq(Lower("email"), "a@b.c"), In("status", []string{"a", "b"})),
+			wantQuery: "deleted_at IS NULL AND ((lower(email) = ?) OR (status IN (?)))",
+			wantArgs:  []interface{}{"a@b.c", []string{"a", "b"}},
+		},
+		{
+			name:      "Given a search text with wildcards When rendering Then they are escaped",
+			filter:    Search("50%_off", "name", "customers.email"),
+			wantQuery: "((name::text ILIKE ?) OR (customers.email::text ILIKE ?))",
+			wantArgs:  []interface{}{`%50\%\_off%`, `%50\%\_off%`},
+		},
+		{name: "Given an empty search text When rendering Then it matches every row", filter: Search("", "name")},
+		{name: "Given an injected column When rendering Then it fails", filter: Eq("uuid='' OR 1=1 --", 1), wantErr: true},
+		{name: "Given an unknown operator When rendering Then it fails", filter: Compare("uuid", "LIKE", 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.filter.SQL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("SQL() error = %v, want ErrInvalidFilter", err)
+			}
+			if query != tt.wantQuery || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("SQL() = %q %v, want %q %v", query, args, tt.wantQuery, tt.wantArgs)
+			}
+		})
+	}
+}
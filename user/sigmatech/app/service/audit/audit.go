@@ -0,0 +1,182 @@
+// Package audit records who changed what through the admin API.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	auditLogs_DBModels "user/sigmatech/app/db/dto/audit_logs"
+	auditLogDB "user/sigmatech/app/db/repository/audit_log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Entry is what a handler tells about its mutation. Snapshots are taken when they are set, so the
+// values can be changed afterwards, e.g. to clear a password before responding.
+type Entry struct {
+	Action   string
+	Entity   string
+	EntityId string
+	Before   map[string]interface{}
+	After    map[string]interface{}
+}
+
+// Change is the before and after value of a field.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type IAuditService interface {
+	// Record writes an audit log, its uuid and creation time are set here.
+	Record(ctx context.Context, log *auditLogs_DBModels.AuditLog) error
+}
+
+// AuditService is a struct that implements the IAuditService interface.
+type AuditService struct {
+	AuditLogDBClient auditLogDB.IAuditLogRepository
+}
+
+// NewAuditService is a constructor function that creates a new AuditService.
+func NewAuditService(AuditLogDBClient auditLogDB.IAuditLogRepository) *AuditService {
+	return &AuditService{
+		AuditLogDBClient: AuditLogDBClient,
+	}
+}
+
+func (s *AuditService) Record(ctx context.Context, log *auditLogs_DBModels.AuditLog) error {
+	log.Uuid = uuid.New()
+	log.CreatedAt = time.Now()
+	return s.AuditLogDBClient.CreateAuditLog(ctx, log)
+}
+
+// GetEntry returns the entry of the request, creating it on first use.
+func GetEntry(c *gin.Context) *Entry {
+	if v, ok := c.Get(constants.CTK_AUDIT_KEY.String()); ok {
+		if entry, ok := v.(*Entry); ok {
+			return entry
+		}
+	}
+
+	entry := &Entry{}
+	c.Set(constants.CTK_AUDIT_KEY.String(), entry)
+	return entry
+}
+
+// SetAction overrides the action derived from the handler name.
+func SetAction(c *gin.Context, action string) {
+	GetEntry(c).Action = action
+}
+
+// SetTarget overrides the entity derived from the controller name and the id taken from the id path parameter.
+func SetTarget(c *gin.Context, entity, id string) {
+	entry := GetEntry(c)
+	entry.Entity = entity
+	entry.EntityId = id
+}
+
+// SetBefore records the state of the target before the mutation.
+func SetBefore(c *gin.Context, v interface{}) {
+	GetEntry(c).Before = snapshot(v)
+}
+
+// SetAfter records the state of the target after the mutation.
+func SetAfter(c *gin.Context, v interface{}) {
+	GetEntry(c).After = snapshot(v)
+}
+
+// snapshot converts v to its JSON fields. Values that are not JSON objects are kept under "value".
+func snapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		var value interface{}
+		_ = json.Unmarshal(data, &value)
+		return map[string]interface{}{"value": value}
+	}
+	return fields
+}
+
+// Diff returns the fields whose value differs between before and after, with sensitive fields redacted.
+// A nil before is a creation and a nil after a deletion, every field is then part of the diff.
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+
+	for key, b := range before {
+		a, ok := after[key]
+		if after != nil && ok && reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes[key] = redact(key, Change{Before: b, After: a})
+	}
+
+	for key, a := range after {
+		if _, ok := before[key]; ok {
+			continue
+		}
+		changes[key] = redact(key, Change{After: a})
+	}
+
+	return changes
+}
+
+func redact(key string, change Change) Change {
+	if !IsSensitive(key) {
+		return Change{Before: redactValue(change.Before), After: redactValue(change.After)}
+	}
+
+	if change.Before != nil && change.Before != "" {
+		change.Before = REDACTED
+	}
+	if change.After != nil && change.After != "" {
+		change.After = REDACTED
+	}
+	return change
+}
+
+// redactValue redacts the sensitive fields of nested objects.
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(value))
+		for key, field := range value {
+			if IsSensitive(key) && field != nil && field != "" {
+				redacted[key] = REDACTED
+				continue
+			}
+			redacted[key] = redactValue(field)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(value))
+		for i, item := range value {
+			redacted[i] = redactValue(item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+// IsSensitive reports whether the field is redacted from the recorded changes.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range SensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
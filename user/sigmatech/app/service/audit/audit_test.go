@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   map[string]Change
+	}{
+		{
+			name:   "Given an update When diffing Then only the changed fields are returned",
+			before: map[string]interface{}{"name": "Budi", "email": "budi@example.com", "is_active": false},
+			after:  map[string]interface{}{"name": "Budi", "email": "budi@example.com", "is_active": true},
+			want:   map[string]Change{"is_active": {Before: false, After: true}},
+		},
+		{
+			name:   "Given a password reset When diffing Then the password is redacted",
+			before: map[string]interface{}{"password": "$2a$10$old"},
+			after:  map[string]interface{}{"password": "$2a$10$new"},
+			want:   map[string]Change{"password": {Before: REDACTED, After: REDACTED}},
+		},
+		{
+			name:   "Given a creation When diffing Then every field is returned",
+			before: nil,
+			after:  map[string]interface{}{"name": "Budi", "password": "$2a$10$new"},
+			want: map[string]Change{
+				"name":     {After: "Budi"},
+				"password": {After: REDACTED},
+			},
+		},
+		{
+			name:   "Given a deletion When diffing Then every field is returned",
+			before: map[string]interface{}{"name": "Budi"},
+			after:  nil,
+			want:   map[string]Change{"name": {Before: "Budi"}},
+		},
+		{
+			name:   "Given a nested secret When diffing Then it is redacted",
+			before: map[string]interface{}{"customer": map[string]interface{}{"name": "Budi", "password": "$2a$10$old"}},
+			after:  map[string]interface{}{"customer": map[string]interface{}{"name": "Budi Santoso", "password": "$2a$10$old"}},
+			want: map[string]Change{"customer": {
+				Before: map[string]interface{}{"name": "Budi", "password": REDACTED},
+				After:  map[string]interface{}{"name": "Budi Santoso", "password": REDACTED},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
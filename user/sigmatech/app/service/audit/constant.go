@@ -0,0 +1,6 @@
+package audit
+
+const REDACTED = "[REDACTED]"
+
+// SensitiveFields are redacted from the recorded changes, a field is sensitive when its name contains one of them.
+var SensitiveFields = []string{"password", "secret", "token", "hash", "otp"}
@@ -4,7 +4,9 @@ package s3
 import (
 	"bytes"
 	"context"
+	"io"
 	"net/http"
+	"time"
 	"user/sigmatech/app/constants"
 	"user/sigmatech/app/service/logger"
 
@@ -20,6 +22,8 @@ type IS3Client interface {
 	GetObject(objectName string) (*s3.GetObjectOutput, error)
 	GetObjectSize(objectName string) (float64, error)
 	PutObject(objectName string, body []byte) (*s3.PutObjectOutput, error)
+	PutObjectReader(objectName string, body io.ReadSeeker, contentType string) (*s3.PutObjectOutput, error)
+	PresignGetObject(objectName string, ttl time.Duration) (string, error)
 	DeleteObject(objectName string) (*s3.DeleteObjectOutput, error)
 	DeleteBucket() (*s3.DeleteBucketOutput, error)
 	CreateBucket() (*s3.CreateBucketOutput, error)
@@ -144,6 +148,36 @@ func (s *S3Service) PutObject(objectName string, body []byte) (*s3.PutObjectOutp
 	return resp, nil
 }
 
+// PutObjectReader uploads an S3 object from a reader, such as a file too large to hold in memory.
+func (s *S3Service) PutObjectReader(objectName string, body io.ReadSeeker, contentType string) (*s3.PutObjectOutput, error) {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(objectName),
+		Body:        body,
+		ContentType: &contentType,
+	}
+	resp, err := s.Client.PutObject(context.Background(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PresignGetObject returns a download link of an S3 object valid for ttl.
+func (s *S3Service) PresignGetObject(objectName string, ttl time.Duration) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectName),
+	}
+	resp, err := s3.NewPresignClient(s.Client).PresignGetObject(context.Background(), input, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+
+	return resp.URL, nil
+}
+
 // DeleteObject deletes an S3 object.
 func (s *S3Service) DeleteObject(objectName string) (*s3.DeleteObjectOutput, error) {
 	input := &s3.DeleteObjectInput{
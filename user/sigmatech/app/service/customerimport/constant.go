@@ -0,0 +1,44 @@
+package customerimport
+
+import "errors"
+
+const (
+	// Columns of an import file, matched against its header row
+	COLUMN_NAME           = "name"
+	COLUMN_EMAIL          = "email"
+	COLUMN_PASSWORD       = "password"
+	COLUMN_FULL_NAME      = "full_name"
+	COLUMN_LEGAL_NAME     = "legal_name"
+	COLUMN_NIK            = "nik"
+	COLUMN_PLACE_OF_BIRTH = "place_of_birth"
+	COLUMN_DATE_OF_BIRTH  = "date_of_birth"
+	COLUMN_GENDER         = "gender"
+	COLUMN_SALARY         = "salary"
+	COLUMN_CARD_PHOTO     = "card_photo"
+	COLUMN_SELFIE_PHOTO   = "selfie_photo"
+
+	// Status of an import line in the report
+	STATUS_CREATED = "created" // the customer was created
+	STATUS_VALID   = "valid"   // dry run, the customer would be created
+	STATUS_FAILED  = "failed"
+
+	DATE_FORMAT = "2006-01-02"
+)
+
+// RequiredColumns must be in the header row, the other columns are optional.
+var RequiredColumns = []string{
+	COLUMN_EMAIL, COLUMN_PASSWORD, COLUMN_FULL_NAME, COLUMN_LEGAL_NAME, COLUMN_NIK,
+	COLUMN_PLACE_OF_BIRTH, COLUMN_DATE_OF_BIRTH, COLUMN_SALARY,
+}
+
+var Columns = append([]string{COLUMN_NAME, COLUMN_GENDER, COLUMN_CARD_PHOTO, COLUMN_SELFIE_PHOTO}, RequiredColumns...)
+
+// Terms a customer gets a limit for, the same as on sign-up
+var Terms = []int{1, 2, 3, 6}
+
+var (
+	ErrEmptyFile     = errors.New("import file has no customers")
+	ErrMissingColumn = errors.New("import file is missing a column")
+	ErrUnknownColumn = errors.New("unknown import column")
+	ErrTooManyRows   = errors.New("import file has too many customers")
+)
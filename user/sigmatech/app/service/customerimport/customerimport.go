@@ -0,0 +1,385 @@
+// Package customerimport onboards customers in bulk from a CSV file, with the rules of sign-up.
+package customerimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+	"user/sigmatech/app/constants"
+	cif_DBModels "user/sigmatech/app/db/dto/customer_information_files"
+	customerLimits_DBModels "user/sigmatech/app/db/dto/customer_limits"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	onboardings_DBModels "user/sigmatech/app/db/dto/onboardings"
+	customerDB "user/sigmatech/app/db/repository/customer"
+	cifDB "user/sigmatech/app/db/repository/customer_information_file"
+	onboardingDB "user/sigmatech/app/db/repository/onboarding"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/aws/s3"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/emailverification"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Result is the outcome of one line of an import file.
+type Result struct {
+	Row          int        `json:"row"`
+	Status       string     `json:"status"`
+	Email        string     `json:"email,omitempty"`
+	Nik          string     `json:"nik,omitempty"`
+	CustomerUuid *uuid.UUID `json:"customer_uuid,omitempty"`
+	CifNumber    string     `json:"cif_number,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Report is the outcome of an import, with one result per line of the file.
+type Report struct {
+	DryRun  bool      `json:"dry_run"`
+	Total   int       `json:"total"`
+	Created int       `json:"created"`
+	Valid   int       `json:"valid"`
+	Failed  int       `json:"failed"`
+	Results []*Result `json:"results"`
+}
+
+type ICustomerImportService interface {
+	// Import validates the customers of a CSV file and, unless dryRun is set, creates the valid ones
+	// in batches. Invalid lines are reported and don't stop the other lines from being created.
+	Import(ctx context.Context, data []byte, dryRun bool) (*Report, error)
+}
+
+// CustomerImportService is a struct that implements the ICustomerImportService interface.
+type CustomerImportService struct {
+	CustomerDBClient   customerDB.ICustomerRepository
+	CifDBClient        cifDB.ICustomerInformationFileRepository
+	OnboardingDBClient onboardingDB.IOnboardingRepository
+	S3                 s3.IS3Client
+	EmailVerification  emailverification.IEmailVerificationService
+}
+
+// NewCustomerImportService is a constructor function that creates a new CustomerImportService.
+func NewCustomerImportService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	CifDBClient cifDB.ICustomerInformationFileRepository,
+	OnboardingDBClient onboardingDB.IOnboardingRepository,
+	S3 s3.IS3Client,
+	EmailVerification emailverification.IEmailVerificationService,
+) *CustomerImportService {
+	return &CustomerImportService{
+		CustomerDBClient:   CustomerDBClient,
+		CifDBClient:        CifDBClient,
+		OnboardingDBClient: OnboardingDBClient,
+		S3:                 S3,
+		EmailVerification:  EmailVerification,
+	}
+}
+
+// pending is a valid line waiting to be created.
+type pending struct {
+	result     *Result
+	onboarding *onboardings_DBModels.Onboarding
+}
+
+func (s *CustomerImportService) Import(ctx context.Context, data []byte, dryRun bool) (*Report, error) {
+	rows, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > constants.Config.CustomerImportConfig.CUSTOMER_IMPORT_MAX_ROWS {
+		return nil, fmt.Errorf("%w: %d, at most %d are allowed", ErrTooManyRows, len(rows), constants.Config.CustomerImportConfig.CUSTOMER_IMPORT_MAX_ROWS)
+	}
+
+	report := &Report{DryRun: dryRun, Total: len(rows)}
+	var valid []*pending
+
+	seenEmails := make(map[string]int)
+	seenNiks := make(map[string]int)
+	for _, row := range rows {
+		result := &Result{Row: row.Number, Email: row.Customer.Email, Nik: row.Customer.Nik}
+		report.Results = append(report.Results, result)
+
+		if err := s.validate(ctx, row, seenEmails, seenNiks); err != nil {
+			result.Status = STATUS_FAILED
+			result.Error = err.Error()
+			continue
+		}
+
+		seenEmails[row.Customer.Email] = row.Number
+		seenNiks[row.Customer.Nik] = row.Number
+
+		if dryRun {
+			result.Status = STATUS_VALID
+			continue
+		}
+
+		valid = append(valid, &pending{result: result, onboarding: newOnboarding(row)})
+	}
+
+	if !dryRun {
+		if err := hashPasswords(valid); err != nil {
+			return nil, err
+		}
+
+		batchSize := constants.Config.CustomerImportConfig.CUSTOMER_IMPORT_BATCH_SIZE
+		if batchSize <= 0 {
+			batchSize = 1
+		}
+		for start := 0; start < len(valid); start += batchSize {
+			end := start + batchSize
+			if end > len(valid) {
+				end = len(valid)
+			}
+			s.create(ctx, valid[start:end])
+		}
+
+		// Imported customers verify their email like the ones signing up, the report doesn't wait for the mails
+		go s.sendVerifications(correlation.ContextFromCorrelation(correlation.ContextCorrelationId(ctx)), valid)
+	}
+
+	for _, result := range report.Results {
+		switch result.Status {
+		case STATUS_CREATED:
+			report.Created++
+		case STATUS_VALID:
+			report.Valid++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// validate checks a line with the sign-up rules, and that neither its email nor its NIK is taken,
+// by a customer or by an earlier line of the file.
+func (s *CustomerImportService) validate(ctx context.Context, row Row, seenEmails, seenNiks map[string]int) error {
+	if row.Err != nil {
+		return row.Err
+	}
+
+	if err := row.Customer.Validate(); err != nil {
+		return err
+	}
+
+	if number, ok := seenEmails[row.Customer.Email]; ok {
+		return fmt.Errorf("email is duplicated on row %d", number)
+	}
+	if number, ok := seenNiks[row.Customer.Nik]; ok {
+		return fmt.Errorf("nik is duplicated on row %d", number)
+	}
+
+	customer, err := s.CustomerDBClient.GetCustomer(ctx, where.Eq(where.Lower(customers_DBModels.COLUMN_EMAIL), row.Customer.Email))
+	if err != nil {
+		return err
+	}
+	if customer.Uuid != uuid.Nil {
+		return errors.New("email already registered")
+	}
+
+	cif, err := s.CifDBClient.GetCustomerInformationFile(ctx, where.Eq(cif_DBModels.COLUMN_NIK, row.Customer.Nik))
+	if err != nil {
+		return err
+	}
+	if cif.Uuid != uuid.Nil {
+		return errors.New("NIK already registered")
+	}
+
+	for _, photo := range []struct{ name, key string }{
+		{"card photo", row.Customer.CardPhoto},
+		{"selfie photo", row.Customer.SelfiePhoto},
+	} {
+		if photo.key == "" {
+			continue
+		}
+		if size, _ := s.S3.GetObjectSize(photo.key); size == 0 {
+			return fmt.Errorf("%s %s is not in the bucket", photo.name, photo.key)
+		}
+	}
+
+	return nil
+}
+
+// create creates a batch in one transaction. When the batch fails, e.g. because a customer was
+// registered meanwhile, its lines are created one by one so only the offending ones are reported.
+func (s *CustomerImportService) create(ctx context.Context, batch []*pending) {
+	err := s.createBatch(ctx, batch)
+	if err == nil {
+		return
+	}
+
+	if len(batch) == 1 {
+		fail(batch[0], err)
+		return
+	}
+
+	logger.Logger(ctx).Errorf("Error creating customer import batch, retrying its lines one by one: %v", err)
+	for _, p := range batch {
+		if err := s.createBatch(ctx, []*pending{p}); err != nil {
+			fail(p, err)
+		}
+	}
+}
+
+func (s *CustomerImportService) createBatch(ctx context.Context, batch []*pending) error {
+	first, err := s.CifDBClient.GenerateCIFNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	onboardings := make([]*onboardings_DBModels.Onboarding, len(batch))
+	for i, p := range batch {
+		cifNumber, err := nextCIFNumber(first, i)
+		if err != nil {
+			return err
+		}
+		p.onboarding.CIF.CifNumber = cifNumber
+		onboardings[i] = p.onboarding
+	}
+
+	if err := s.OnboardingDBClient.CreateOnboardings(ctx, onboardings); err != nil {
+		return err
+	}
+
+	for _, p := range batch {
+		p.result.Status = STATUS_CREATED
+		p.result.CustomerUuid = &p.onboarding.Customer.Uuid
+		p.result.CifNumber = p.onboarding.CIF.CifNumber
+	}
+
+	return nil
+}
+
+// sendVerifications emails a verification token to the customers of the lines that were created.
+func (s *CustomerImportService) sendVerifications(ctx context.Context, lines []*pending) {
+	for _, p := range lines {
+		if p.result.Status != STATUS_CREATED {
+			continue
+		}
+		if err := s.EmailVerification.Send(ctx, p.onboarding.Customer); err != nil {
+			logger.Logger(ctx).Errorf("Error sending the email verification of customer %s: %v", p.onboarding.Customer.Uuid, err)
+		}
+	}
+}
+
+func fail(p *pending, err error) {
+	p.result.Status = STATUS_FAILED
+	p.result.Error = err.Error()
+	if constraintName := util.ExtractConstraintName(err.Error()); constraintName != "" {
+		p.result.Error = fmt.Sprintf("%s already exists", constraintName)
+	}
+}
+
+// newOnboarding builds what sign-up creates for a customer: an inactive customer waiting for approval,
+// its CIF and an empty limit per term. The CIF number is given when the customer is created.
+func newOnboarding(row Row) *onboardings_DBModels.Onboarding {
+	now := time.Now()
+	customer := row.Customer
+
+	onboarding := &onboardings_DBModels.Onboarding{
+		Customer: customers_DBModels.Customer{
+			Uuid:      uuid.New(),
+			Name:      customer.Name,
+			Email:     customer.Email,
+			Password:  customer.Password,
+			IsActive:  util.Boolean(false),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	var gender *string
+	if customer.Gender != "" {
+		gender = util.String(customer.Gender)
+	}
+
+	onboarding.CIF = cif_DBModels.CustomerInformationFile{
+		Uuid:         uuid.New(),
+		CustomerUuid: onboarding.Customer.Uuid,
+		Nik:          customer.Nik,
+		FullName:     customer.FullName,
+		LegalName:    customer.LegalName,
+		PlaceOfBirth: customer.PlaceOfBirth,
+		DateOfBirth:  customer.DateOfBirth,
+		Gender:       gender,
+		Salary:       customer.Salary,
+		CardPhoto:    customer.CardPhoto,
+		SelfiePhoto:  customer.SelfiePhoto,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	for _, term := range Terms {
+		onboarding.CustomerLimits = append(onboarding.CustomerLimits, &customerLimits_DBModels.CustomerLimit{
+			Uuid:           uuid.New(),
+			CustomerUuid:   onboarding.Customer.Uuid,
+			Term:           term,
+			Status:         util.Boolean(false),
+			AmountLimit:    0,
+			RemainingLimit: 0,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+
+	return onboarding
+}
+
+// hashPasswords hashes the passwords of the lines concurrently, bcrypt being the bulk of an import.
+// Passwords that are already bcrypt hashes, as exported by the legacy system, are kept as they are.
+func hashPasswords(batch []*pending) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	jobs := make(chan *pending)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				customer := &p.onboarding.Customer
+				if _, err := bcrypt.Cost([]byte(customer.Password)); err == nil {
+					continue
+				}
+
+				hashed, err := util.GenerateHash(customer.Password)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				customer.Password = hashed
+			}
+		}()
+	}
+
+	for _, p := range batch {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	return firstErr
+}
+
+// nextCIFNumber returns the CIF number offset numbers after first, both in the CIF_<sequence>_<unix time> format.
+func nextCIFNumber(first string, offset int) (string, error) {
+	var num int
+	var timestamp int64
+	if _, err := fmt.Sscanf(first, "CIF_%06d_%d", &num, &timestamp); err != nil {
+		return "", fmt.Errorf("failed to parse Cif Number: %v", err)
+	}
+	return fmt.Sprintf("CIF_%06d_%v", num+offset, timestamp), nil
+}
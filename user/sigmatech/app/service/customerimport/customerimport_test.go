@@ -0,0 +1,161 @@
+package customerimport
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	onboardings_DBModels "user/sigmatech/app/db/dto/onboardings"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte("\ufeffEmail,password,full_name,legal_name,nik,place_of_birth,date_of_birth,salary,card_photo\n" +
+		"Budi@Example.com,secret,Budi Santoso,Budi Santoso,3171234567890001,Jakarta,1990-01-31,7500000,customer/card/1.jpg\n" +
+		"\n" +
+		"siti@example.com,secret,Siti,Siti,3171234567890002,Bandung,31-01-1990,5000000,\n" +
+		"rina@example.com,secret,Rina,Rina,3171234567890003,Medan,1991-02-01,lima juta,\n")
+
+	rows, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		row        Row
+		wantNumber int
+		wantEmail  string
+		wantErr    bool
+	}{
+		{
+			name:       "Given a valid line When parsing Then the customer is read and the email lowercased",
+			row:        rows[0],
+			wantNumber: 2,
+			wantEmail:  "budi@example.com",
+		},
+		{
+			name:       "Given an invalid date after a blank line When parsing Then the line number skips the blank line",
+			row:        rows[1],
+			wantNumber: 4,
+			wantEmail:  "siti@example.com",
+			wantErr:    true,
+		},
+		{
+			name:       "Given an invalid salary When parsing Then the line has an error",
+			row:        rows[2],
+			wantNumber: 5,
+			wantEmail:  "rina@example.com",
+			wantErr:    true,
+		},
+	}
+
+	if len(rows) != len(tests) {
+		t.Fatalf("Parse() returned %d rows, want %d", len(rows), len(tests))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.row.Number != tt.wantNumber {
+				t.Errorf("Number = %d, want %d", tt.row.Number, tt.wantNumber)
+			}
+			if tt.row.Customer.Email != tt.wantEmail {
+				t.Errorf("Email = %s, want %s", tt.row.Customer.Email, tt.wantEmail)
+			}
+			if (tt.row.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, wantErr %v", tt.row.Err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := rows[0].Customer.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if rows[0].Customer.Name != "Budi Santoso" {
+		t.Errorf("Name = %s, want the full name", rows[0].Customer.Name)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name:    "Given an empty file When parsing Then ErrEmptyFile is returned",
+			data:    "",
+			wantErr: ErrEmptyFile,
+		},
+		{
+			name:    "Given a header without customers When parsing Then ErrEmptyFile is returned",
+			data:    "email,password,full_name,legal_name,nik,place_of_birth,date_of_birth,salary\n",
+			wantErr: ErrEmptyFile,
+		},
+		{
+			name:    "Given a header without the nik When parsing Then ErrMissingColumn is returned",
+			data:    "email,password,full_name,legal_name,place_of_birth,date_of_birth,salary\na,b,c,d,e,f,g\n",
+			wantErr: ErrMissingColumn,
+		},
+		{
+			name:    "Given an unknown column When parsing Then ErrUnknownColumn is returned",
+			data:    "email,password,full_name,legal_name,nik,place_of_birth,date_of_birth,salary,phone\n",
+			wantErr: ErrUnknownColumn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextCIFNumber(t *testing.T) {
+	got, err := nextCIFNumber("CIF_000041_1792400000", 2)
+	if err != nil {
+		t.Fatalf("nextCIFNumber() error = %v", err)
+	}
+	if want := "CIF_000043_1792400000"; got != want {
+		t.Errorf("nextCIFNumber() = %s, want %s", got, want)
+	}
+}
+
+// verifier stands in for the email verification service, it keeps the customers it was asked to verify.
+type verifier struct {
+	sent []string
+}
+
+func (v *verifier) Send(ctx context.Context, customer customers_DBModels.Customer) error {
+	v.sent = append(v.sent, customer.Email)
+	return nil
+}
+
+func TestSendVerifications(t *testing.T) {
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	line := func(email, status string) *pending {
+		return &pending{
+			result:     &Result{Email: email, Status: status},
+			onboarding: &onboardings_DBModels.Onboarding{Customer: customers_DBModels.Customer{Uuid: uuid.New(), Email: email}},
+		}
+	}
+
+	verifications := &verifier{}
+	s := &CustomerImportService{EmailVerification: verifications}
+	s.sendVerifications(context.Background(), []*pending{
+		line("budi@example.com", STATUS_CREATED),
+		line("siti@example.com", STATUS_FAILED),
+		line("rina@example.com", STATUS_CREATED),
+	})
+
+	if want := []string{"budi@example.com", "rina@example.com"}; !reflect.DeepEqual(verifications.sent, want) {
+		t.Errorf("sendVerifications() sent to %v, want %v", verifications.sent, want)
+	}
+}
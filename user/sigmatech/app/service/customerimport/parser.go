@@ -0,0 +1,149 @@
+package customerimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	reqCustomer "user/sigmatech/app/service/dto/request/customer"
+	"user/sigmatech/app/service/util"
+)
+
+// Row is one customer line of an import file. Err is set when the line could not be parsed.
+type Row struct {
+	Number   int // line number in the file, one based
+	Customer reqCustomer.ImportCustomerReq
+	Err      error
+}
+
+// Parse reads the customer lines of a CSV file whose first line names the columns. Lines that don't
+// parse are returned with Err set, so they end up in the report instead of failing the import.
+func Parse(data []byte) ([]Row, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := columnIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Number: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		// encoding/csv skips empty lines, so the line number has to come from the reader
+		number, _ := reader.FieldPos(0)
+
+		if isBlank(record) {
+			continue
+		}
+
+		rows = append(rows, parseRow(number, record, index))
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	return rows, nil
+}
+
+// columnIndex maps the columns of the header row to their position.
+func columnIndex(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		// spreadsheets may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !util.ContainsString(Columns, name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, name)
+		}
+		index[name] = i
+	}
+
+	for _, name := range RequiredColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, name)
+		}
+	}
+
+	return index, nil
+}
+
+func parseRow(number int, record []string, index map[string]int) Row {
+	value := func(name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := Row{
+		Number: number,
+		Customer: reqCustomer.ImportCustomerReq{
+			Name:         value(COLUMN_NAME),
+			Email:        strings.ToLower(value(COLUMN_EMAIL)),
+			FullName:     value(COLUMN_FULL_NAME),
+			LegalName:    value(COLUMN_LEGAL_NAME),
+			Nik:          value(COLUMN_NIK),
+			PlaceOfBirth: value(COLUMN_PLACE_OF_BIRTH),
+			Gender:       value(COLUMN_GENDER),
+			CardPhoto:    value(COLUMN_CARD_PHOTO),
+			SelfiePhoto:  value(COLUMN_SELFIE_PHOTO),
+			Password:     value(COLUMN_PASSWORD),
+		},
+	}
+
+	if v := value(COLUMN_DATE_OF_BIRTH); v != "" {
+		dob, err := time.Parse(DATE_FORMAT, v)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid date of birth %q", v)
+			return row
+		}
+		row.Customer.DateOfBirth = &dob
+	}
+
+	if v := value(COLUMN_SALARY); v != "" {
+		salary, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid salary %q", v)
+			return row
+		}
+		row.Customer.Salary = salary
+	}
+
+	return row
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"user/sigmatech/app/service/util"
+)
+
+const DATE_FORMAT = "2006-01-02"
+
+// Period a series is grouped by, the values are PostgreSQL date_trunc fields
+const (
+	GROUP_BY_DAY   = "day"
+	GROUP_BY_WEEK  = "week"
+	GROUP_BY_MONTH = "month"
+)
+
+var GroupBys = []string{GROUP_BY_DAY, GROUP_BY_WEEK, GROUP_BY_MONTH}
+
+// MAX_PERIODS bounds the number of points of a series
+const MAX_PERIODS = 400
+
+// Range is the date range and grouping of an analytics series. From and To are inclusive dates.
+type Range struct {
+	From    string `form:"from"`
+	To      string `form:"to"`
+	GroupBy string `form:"group_by"`
+
+	from time.Time
+	to   time.Time
+}
+
+// Validate parses the range. Without To it ends today, without From it covers the last twelve periods.
+func (s *Range) Validate() error {
+	if s.GroupBy == "" {
+		s.GroupBy = GROUP_BY_MONTH
+	}
+	if !util.ContainsElement(s.GroupBy, GroupBys) {
+		return fmt.Errorf("group_by must be one of %s", strings.Join(GroupBys, ", "))
+	}
+
+	s.to = time.Now().UTC().Truncate(24 * time.Hour)
+	if s.To != "" {
+		to, err := time.Parse(DATE_FORMAT, s.To)
+		if err != nil {
+			return fmt.Errorf("to must be formatted as %s", DATE_FORMAT)
+		}
+		s.to = to
+	}
+
+	s.from = s.add(s.to, -11)
+	if s.From != "" {
+		from, err := time.Parse(DATE_FORMAT, s.From)
+		if err != nil {
+			return fmt.Errorf("from must be formatted as %s", DATE_FORMAT)
+		}
+		s.from = from
+	}
+
+	if s.from.After(s.to) {
+		return errors.New("from can't be after to")
+	}
+	if s.add(s.from, MAX_PERIODS).Before(s.to) {
+		return fmt.Errorf("the range can't span more than %d periods of a %s", MAX_PERIODS, s.GroupBy)
+	}
+
+	return nil
+}
+
+// GetFrom returns the first day of the range parsed by Validate.
+func (s *Range) GetFrom() time.Time {
+	return s.from
+}
+
+// GetTo returns the last day of the range parsed by Validate.
+func (s *Range) GetTo() time.Time {
+	return s.to
+}
+
+// Interval returns the PostgreSQL interval of one period.
+func (s *Range) Interval() string {
+	return fmt.Sprintf("1 %s", s.GroupBy)
+}
+
+func (s *Range) add(t time.Time, periods int) time.Time {
+	switch s.GroupBy {
+	case GROUP_BY_DAY:
+		return t.AddDate(0, 0, periods)
+	case GROUP_BY_WEEK:
+		return t.AddDate(0, 0, 7*periods)
+	default:
+		return t.AddDate(0, periods, 0)
+	}
+}
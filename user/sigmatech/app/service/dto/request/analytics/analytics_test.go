@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"testing"
+)
+
+func TestRange_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        Range
+		wantFrom string
+		wantErr  bool
+	}{
+		{
+			name:     "Given only to, When call Validate, Then return the last twelve months",
+			r:        Range{To: "2026-10-19"},
+			wantFrom: "2025-11-19",
+		},
+		{
+			name:     "Given only to grouped by week, When call Validate, Then return the last twelve weeks",
+			r:        Range{To: "2026-10-19", GroupBy: GROUP_BY_WEEK},
+			wantFrom: "2026-08-03",
+		},
+		{
+			name:    "Given an unknown group by, When call Validate, Then return error",
+			r:       Range{GroupBy: "year"},
+			wantErr: true,
+		},
+		{
+			name:    "Given from after to, When call Validate, Then return error",
+			r:       Range{From: "2026-10-20", To: "2026-10-19"},
+			wantErr: true,
+		},
+		{
+			name:    "Given a range of more periods than allowed, When call Validate, Then return error",
+			r:       Range{From: "2020-01-01", To: "2026-10-19", GroupBy: GROUP_BY_DAY},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantFrom != "" && tt.r.GetFrom().Format(DATE_FORMAT) != tt.wantFrom {
+				t.Errorf("GetFrom() = %v, want %v", tt.r.GetFrom().Format(DATE_FORMAT), tt.wantFrom)
+			}
+		})
+	}
+}
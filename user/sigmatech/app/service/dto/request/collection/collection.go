@@ -0,0 +1,96 @@
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	collectionActivities_DBModels "user/sigmatech/app/db/dto/collection_activities"
+	"user/sigmatech/app/service/util"
+
+	"github.com/google/uuid"
+)
+
+const DATE_FORMAT = "2006-01-02"
+
+// WorklistFilter narrows the collections worklist down to a days past due range.
+type WorklistFilter struct {
+	DpdFrom *int `form:"dpd_from"`
+	DpdTo   *int `form:"dpd_to"`
+}
+
+func (s *WorklistFilter) Validate() error {
+	if s.DpdFrom != nil && *s.DpdFrom < 0 {
+		return errors.New("dpd_from can't be negative")
+	}
+	if s.DpdFrom != nil && s.DpdTo != nil && *s.DpdTo < *s.DpdFrom {
+		return errors.New("dpd_to can't be lower than dpd_from")
+	}
+	return nil
+}
+
+// AssignRequest assigns a contract to a collection agent, replacing the current agent.
+type AssignRequest struct {
+	AgentUuid uuid.UUID `json:"agent_uuid"`
+	Note      *string   `json:"note"`
+}
+
+func (s *AssignRequest) Validate() error {
+	if s.AgentUuid == uuid.Nil {
+		return errors.New("agent uuid can't be empty")
+	}
+	return nil
+}
+
+// ActivityRequest logs a call or visit made to a customer and its outcome.
+type ActivityRequest struct {
+	Type               string     `json:"type"`
+	Outcome            string     `json:"outcome"`
+	PromiseToPayDate   string     `json:"promise_to_pay_date"`
+	PromiseToPayAmount *float64   `json:"promise_to_pay_amount"`
+	Note               *string    `json:"note"`
+	ContactedAt        *time.Time `json:"contacted_at"`
+
+	promiseToPayDate *time.Time
+}
+
+func (s *ActivityRequest) Validate() error {
+	if !util.ContainsElement(s.Type, collectionActivities_DBModels.Types) {
+		return fmt.Errorf("type must be one of %s", strings.Join(collectionActivities_DBModels.Types, ", "))
+	}
+	if !util.ContainsElement(s.Outcome, collectionActivities_DBModels.Outcomes) {
+		return fmt.Errorf("outcome must be one of %s", strings.Join(collectionActivities_DBModels.Outcomes, ", "))
+	}
+	if s.ContactedAt != nil && s.ContactedAt.After(time.Now()) {
+		return errors.New("contacted at can't be in the future")
+	}
+
+	if s.Outcome != collectionActivities_DBModels.OUTCOME_PROMISE_TO_PAY {
+		if s.PromiseToPayDate != "" || s.PromiseToPayAmount != nil {
+			return errors.New("promise to pay is only allowed with the promise_to_pay outcome")
+		}
+		return nil
+	}
+
+	if s.PromiseToPayDate == "" {
+		return errors.New("promise to pay date can't be empty")
+	}
+	date, err := time.Parse(DATE_FORMAT, s.PromiseToPayDate)
+	if err != nil {
+		return fmt.Errorf("promise to pay date must be formatted as %s", DATE_FORMAT)
+	}
+	if s.PromiseToPayDate < time.Now().Format(DATE_FORMAT) {
+		return errors.New("promise to pay date can't be in the past")
+	}
+	if s.PromiseToPayAmount != nil && *s.PromiseToPayAmount <= 0 {
+		return errors.New("promise to pay amount must be greater than zero")
+	}
+	s.promiseToPayDate = &date
+
+	return nil
+}
+
+// GetPromiseToPayDate returns the promise to pay date parsed by Validate.
+func (s *ActivityRequest) GetPromiseToPayDate() *time.Time {
+	return s.promiseToPayDate
+}
@@ -0,0 +1,59 @@
+package collection
+
+import (
+	"testing"
+	"time"
+	collectionActivities_DBModels "user/sigmatech/app/db/dto/collection_activities"
+)
+
+func TestActivityRequest_Validate(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(DATE_FORMAT)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(DATE_FORMAT)
+	amount := 500000.0
+
+	tests := []struct {
+		name    string
+		request ActivityRequest
+		wantErr bool
+	}{
+		{
+			name:    "Given a call without answer, When call Validate, Then return no error",
+			request: ActivityRequest{Type: collectionActivities_DBModels.TYPE_CALL, Outcome: collectionActivities_DBModels.OUTCOME_NO_ANSWER},
+		},
+		{
+			name:    "Given an unknown type, When call Validate, Then return error",
+			request: ActivityRequest{Type: "email", Outcome: collectionActivities_DBModels.OUTCOME_NO_ANSWER},
+			wantErr: true,
+		},
+		{
+			name:    "Given a promise to pay without date, When call Validate, Then return error",
+			request: ActivityRequest{Type: collectionActivities_DBModels.TYPE_VISIT, Outcome: collectionActivities_DBModels.OUTCOME_PROMISE_TO_PAY},
+			wantErr: true,
+		},
+		{
+			name:    "Given a promise to pay in the past, When call Validate, Then return error",
+			request: ActivityRequest{Type: collectionActivities_DBModels.TYPE_CALL, Outcome: collectionActivities_DBModels.OUTCOME_PROMISE_TO_PAY, PromiseToPayDate: yesterday},
+			wantErr: true,
+		},
+		{
+			name:    "Given a promise to pay tomorrow, When call Validate, Then return no error",
+			request: ActivityRequest{Type: collectionActivities_DBModels.TYPE_CALL, Outcome: collectionActivities_DBModels.OUTCOME_PROMISE_TO_PAY, PromiseToPayDate: tomorrow, PromiseToPayAmount: &amount},
+		},
+		{
+			name:    "Given a promise to pay date with another outcome, When call Validate, Then return error",
+			request: ActivityRequest{Type: collectionActivities_DBModels.TYPE_CALL, Outcome: collectionActivities_DBModels.OUTCOME_REFUSED, PromiseToPayDate: tomorrow},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.request.PromiseToPayDate != "" && tt.request.GetPromiseToPayDate() == nil {
+				t.Errorf("GetPromiseToPayDate() = nil, want the parsed date")
+			}
+		})
+	}
+}
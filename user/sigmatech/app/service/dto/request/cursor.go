@@ -0,0 +1,81 @@
+package request
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a record in a list sorted by Order, Key telling apart the records with the
+// same Value. Clients get it encoded as the next_cursor and prev_cursor of a page and send it back as is.
+type Cursor struct {
+	Order    string      `json:"o"`
+	Sort     string      `json:"s"`
+	Value    interface{} `json:"v"`
+	Key      string      `json:"k"`
+	Backward bool        `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	value, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// DecodeCursor reads an encoded cursor, the empty cursor asks for the first page.
+func DecodeCursor(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// Numbers are kept as they were written, a float64 would round large ones
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
+	var cursor Cursor
+	if err := decoder.Decode(&cursor); err != nil || cursor.Key == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// IsCursor reports whether the list is paged with cursors rather than page numbers.
+func (r Pagination) IsCursor() bool {
+	return r.Cursor != nil
+}
+
+// checkCursor checks a cursor against the order and sort it is used with. Cursors page lists sorted by
+// a single column that can't be null, a null wouldn't compare with the cursor.
+func checkCursor(encoded, order, sort string, fields map[string]reflect.Type) error {
+	if order == "" {
+		order = defaultOrder
+	}
+	if sort == "" {
+		sort = defaultSort
+	}
+	if strings.Contains(order, ",") || strings.Contains(sort, ",") {
+		return fmt.Errorf("cursor pagination sorts by a single column")
+	}
+	if fieldType, ok := fields[order]; ok && fieldType.Kind() == reflect.Ptr {
+		return fmt.Errorf("cursor pagination can't sort by %q, it may be null", order)
+	}
+
+	cursor, err := DecodeCursor(encoded)
+	if err != nil {
+		return err
+	}
+	if cursor != nil && (cursor.Order != order || !strings.EqualFold(cursor.Sort, sort)) {
+		return fmt.Errorf("%w: the cursor was made for another order", ErrInvalidCursor)
+	}
+	return nil
+}
@@ -8,6 +8,9 @@ import (
 type ApproveCustomerReq struct {
 	CustomerUuid   uuid.UUID       `json:"customer_uuid"`
 	CustomerLimits []CustomerLimit `json:"customer_limits"`
+
+	// OverrideEmailVerification approves a customer whose email isn't verified
+	OverrideEmailVerification bool `json:"override_email_verification"`
 }
 
 type CustomerLimit struct {
@@ -0,0 +1,67 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"user/sigmatech/app/service/util"
+)
+
+// ImportCustomerReq is one line of a bulk customer import. It follows the sign-up rules, except that
+// the photos are optional and, when given, are the keys of objects already in the bucket.
+type ImportCustomerReq struct {
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	FullName     string     `json:"full_name"`
+	LegalName    string     `json:"legal_name"`
+	Nik          string     `json:"nik"`
+	PlaceOfBirth string     `json:"place_of_birth"`
+	DateOfBirth  *time.Time `json:"date_of_birth"`
+	Gender       string     `json:"gender"`
+	Salary       float64    `json:"salary"`
+	CardPhoto    string     `json:"card_photo"`
+	SelfiePhoto  string     `json:"selfie_photo"`
+	Password     string     `json:"-"`
+}
+
+func (u *ImportCustomerReq) Validate() error {
+	if u.Name == "" {
+		u.Name = u.FullName
+	}
+
+	if u.Name == "" {
+		return errors.New("name can't be empty")
+	}
+	if u.FullName == "" {
+		return errors.New("full name can't be empty")
+	}
+	if u.LegalName == "" {
+		return errors.New("legal name can't be empty")
+	}
+	if u.Nik == "" {
+		return errors.New("nik can't be empty")
+	}
+	if len(u.Nik) != 16 {
+		return errors.New("nik length must be 16")
+	}
+	if u.PlaceOfBirth == "" {
+		return errors.New("place of birth can't be empty")
+	}
+	if u.DateOfBirth == nil {
+		return errors.New("date of birth can't be empty")
+	}
+	if u.Salary <= 0 {
+		return errors.New("salary can't be empty")
+	}
+	if u.Email == "" {
+		return errors.New("email can't be empty")
+	}
+	if !util.IsValidEmail(u.Email) {
+		return fmt.Errorf("email is not valid")
+	}
+	if u.Password == "" {
+		return errors.New("password can't be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+)
+
+// Request is the export mode of a list endpoint, export holds the format and columns the
+// comma separated keys of the columns to write.
+type Request struct {
+	Format  string `form:"export"`
+	Columns string `form:"columns"`
+}
+
+// Query is an export query, it reads the rows of Table and its Joins matching Where, Filter and Search.
+type Query struct {
+	Table         string                 `json:"table"`
+	Joins         string                 `json:"joins,omitempty"`
+	Where         where.Filter           `json:"where"`
+	Select        []string               `json:"select"`
+	Filter        map[string]interface{} `json:"filter"`
+	Search        string                 `json:"search,omitempty"`
+	SearchColumns []string               `json:"search_columns,omitempty"`
+	Order         string                 `json:"order"`
+	Sort          string                 `json:"sort"`
+}
+
+func (s *Query) Validate() error {
+	if s.Table == "" {
+		return errors.New("table can't be empty")
+	}
+	if len(s.Select) == 0 {
+		return errors.New("select can't be empty")
+	}
+
+	s.Sort = strings.ToUpper(s.Sort)
+	if request.OrderBy(s.Order, s.Sort, "") == "" {
+		return fmt.Errorf("order must be columns and sort ASC or DESC")
+	}
+	return nil
+}
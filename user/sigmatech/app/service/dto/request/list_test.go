@@ -0,0 +1,89 @@
+package request
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExtractFilteredQueryParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cursor := Cursor{Order: "otr", Sort: "ASC", Value: 1000, Key: "2f1c4a52-6c55-4a3e-8d5e-0d9f0c8a1b2c"}.Encode()
+
+	tests := []struct {
+		name    string
+		query   string
+		model   interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:  "Given plain fields and endpoint parameters When extracting Then only the fields are kept",
+			query: "asset_name=car&export=csv&page=2",
+			model: transactions_DBModels.Transaction{},
+			want:  map[string]interface{}{"asset_name": "car"},
+		},
+		{
+			name:  "Given operators When extracting Then their values are parsed",
+			query: "otr[gte]=1000&otr[lt]=2000.5&contract_number[in]=TX_1,TX_2&merchant_uuid[null]=true&order=otr,created_at&sort=asc,desc",
+			model: transactions_DBModels.Transaction{},
+			want: map[string]interface{}{
+				"otr[gte]":            "1000",
+				"otr[lt]":             "2000.5",
+				"contract_number[in]": []string{"TX_1", "TX_2"},
+				"merchant_uuid[null]": true,
+			},
+		},
+		{name: "Given an unknown operator When extracting Then it fails", query: "otr[between]=1,2", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given an unknown field When extracting Then it fails", query: "nik[eq]=1", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a value of the wrong type When extracting Then it fails", query: "created_at[gte]=yesterday", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a bad uuid in a list When extracting Then it fails", query: "customer_uuid[in]=x", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given an order by an unknown column When extracting Then it fails", query: "order=otr%20desc", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given an order by a secret field When extracting Then it fails", query: "order=password", model: users_DBModels.User{}, wantErr: true},
+		{name: "Given a sort per column of the wrong length When extracting Then it fails", query: "order=otr,total&sort=asc,desc,asc", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a cursor of the order When extracting Then it is accepted", query: "order=otr&sort=asc&cursor=" + cursor, model: transactions_DBModels.Transaction{}, want: map[string]interface{}{}},
+		{name: "Given an empty cursor When extracting Then it is accepted", query: "cursor=&count=true", model: transactions_DBModels.Transaction{}, want: map[string]interface{}{}},
+		{name: "Given a cursor of another order When extracting Then it fails", query: "order=otr&sort=desc&cursor=" + cursor, model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a garbled cursor When extracting Then it fails", query: "cursor=bm90IGpzb24", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a cursor with several order columns When extracting Then it fails", query: "order=otr,created_at&cursor=", model: transactions_DBModels.Transaction{}, wantErr: true},
+		{name: "Given a cursor by a nullable column When extracting Then it fails", query: "order=merchant_uuid&cursor=", model: transactions_DBModels.Transaction{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			got, err := ExtractFilteredQueryParams(c, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractFilteredQueryParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractFilteredQueryParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		order, sort, table, want string
+	}{
+		{order: "created_at", sort: "desc", want: "created_at DESC"},
+		{order: "otr, created_at", sort: "ASC", table: "transactions", want: "transactions.otr ASC, transactions.created_at ASC"},
+		{order: "otr,created_at", sort: "asc,desc", want: "otr ASC, created_at DESC"},
+		{order: "otr desc; drop table users", sort: "ASC", want: ""},
+		{order: "otr", sort: "sideways", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := OrderBy(tt.order, tt.sort, tt.table); got != tt.want {
+			t.Errorf("OrderBy(%q, %q, %q) = %q, want %q", tt.order, tt.sort, tt.table, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package reconciliation
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ResolveRequest applies a row of the review queue. Without an installment the only candidate of the row is used.
+type ResolveRequest struct {
+	TransactionInstallmentUuid *uuid.UUID `json:"transaction_installment_uuid"`
+}
+
+func (s *ResolveRequest) Validate() error {
+	if s.TransactionInstallmentUuid != nil && *s.TransactionInstallmentUuid == uuid.Nil {
+		return errors.New("transaction installment uuid is not valid")
+	}
+	return nil
+}
+
+// DismissRequest discards a row of the review queue.
+type DismissRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *DismissRequest) Validate() error {
+	if s.Reason == "" {
+		return errors.New("reason can't be empty")
+	}
+	return nil
+}
@@ -51,3 +51,33 @@ func (s *UpdatePassword) Validate() error {
 
 	return nil
 }
+
+// MfaCodeRequest carries a code from the authenticator app, or a recovery code where one is accepted.
+type MfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (s *MfaCodeRequest) Validate() error {
+	if s.Code == "" {
+		return errors.New("code is required")
+	}
+
+	return nil
+}
+
+// VerifyMfaRequest completes a sign-in with the challenge token it returned and a code.
+type VerifyMfaRequest struct {
+	MfaToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+func (s *VerifyMfaRequest) Validate() error {
+	if s.MfaToken == "" {
+		return errors.New("mfa_token is required")
+	}
+	if s.Code == "" {
+		return errors.New("code is required")
+	}
+
+	return nil
+}
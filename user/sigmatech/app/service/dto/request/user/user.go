@@ -6,12 +6,13 @@ import (
 )
 
 type CreateUserReq struct {
-	Name       string `json:"name"`
-	Username   string `json:"username"`
-	Email      string `json:"email"`
-	Phone      string `json:"phone"`
-	Password   string `json:"password,omitempty"`
-	UserRoleID int    `json:"user_role_id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Password string `json:"password,omitempty"`
+	// Roles are role codes. On update, roles are replaced when the field is given, an empty list removes them all.
+	Roles []string `json:"roles"`
 }
 
 func (u *CreateUserReq) ValidateUser() error {
@@ -11,6 +11,18 @@ type TokenDetails struct {
 	RtExpires    int64
 }
 
+// MfaChallenge is returned by a sign-in that needs a second factor, instead of the tokens.
+type MfaChallenge struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// RecoveryCodes are shown once, when MFA is enabled or they are regenerated.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
 // ErrorResponseData -
 type ErrorResponseData struct {
 	Code    int    `json:"code"`
@@ -44,11 +56,15 @@ type ResponseV3 struct {
 	Request interface{} `json:"request,omitempty"`
 }
 
+// Pagination describes a page of a list. Pages picked by cursor carry the cursors of the pages around
+// them, and their totals only when they were asked for.
 type Pagination struct {
-	Page       int `json:"page" default:"1"`
-	PerPage    int `json:"per_page" default:"10"`
-	TotalPages int `json:"total_pages"`
-	TotalCount int `json:"total_count"`
+	Page       int    `json:"page" default:"1"`
+	PerPage    int    `json:"per_page" default:"10"`
+	TotalPages int    `json:"total_pages"`
+	TotalCount int    `json:"total_count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 type Data struct {
 	Message string `json:"message"`
@@ -0,0 +1,9 @@
+package emailverification
+
+const (
+	// tokenLength is the number of random bytes of a verification token, it is sent hex encoded.
+	tokenLength = 32
+
+	mailSubject = "Verify your email"
+	mailBody    = "Hi %s,\n\nUse %s to verify your email in the app. The token expires in %d hours.\n\nIf you didn't expect this email, ignore it.\n"
+)
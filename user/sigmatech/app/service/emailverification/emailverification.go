@@ -0,0 +1,95 @@
+// Package emailverification emails customers a token to verify the email an admin set for them, the
+// customer service verifies the token.
+package emailverification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	emailVerifications_DBModels "user/sigmatech/app/db/dto/email_verifications"
+	emailVerificationDB "user/sigmatech/app/db/repository/email_verification"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mailer"
+
+	"github.com/google/uuid"
+)
+
+type IEmailVerificationService interface {
+	// Send emails a verification token to the customer, unless the email already got too many lately.
+	Send(ctx context.Context, customer customers_DBModels.Customer) error
+}
+
+// EmailVerificationService is a struct that implements the IEmailVerificationService interface.
+type EmailVerificationService struct {
+	EmailVerificationDBClient emailVerificationDB.IEmailVerificationRepository
+	Mailer                    mailer.IMailer
+}
+
+// NewEmailVerificationService is a constructor function that creates a new EmailVerificationService.
+func NewEmailVerificationService(
+	EmailVerificationDBClient emailVerificationDB.IEmailVerificationRepository,
+	Mailer mailer.IMailer,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		EmailVerificationDBClient: EmailVerificationDBClient,
+		Mailer:                    Mailer,
+	}
+}
+
+func (s *EmailVerificationService) Send(ctx context.Context, customer customers_DBModels.Customer) error {
+	now := time.Now()
+	window := time.Duration(constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_WINDOW) * time.Second
+
+	sent, err := s.EmailVerificationDBClient.CountEmailVerifications(ctx, where.Eq(emailVerifications_DBModels.COLUMN_EMAIL, customer.Email).Gt(emailVerifications_DBModels.COLUMN_CREATED_AT, now.Add(-window).Format("2006-01-02 15:04:05")))
+	if err != nil {
+		return err
+	}
+	if sent >= constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT {
+		logger.Logger(ctx).Warnf("email verification of customer %s is rate limited, %d tokens sent in the last %s", customer.Uuid, sent, window)
+		return nil
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_TTL) * time.Second
+
+	if err := s.EmailVerificationDBClient.CreateEmailVerification(ctx, &emailVerifications_DBModels.EmailVerification{
+		Uuid:         uuid.New(),
+		CustomerUuid: customer.Uuid,
+		Email:        customer.Email,
+		TokenHash:    hashToken(token),
+		ExpiresAt:    now.Add(ttl),
+		CreatedAt:    now,
+	}); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, mailer.Mail{
+		To:      customer.Email,
+		Subject: mailSubject,
+		Body:    fmt.Sprintf(mailBody, customer.Name, token, int(ttl.Hours())),
+	})
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is what is stored, the same hash the customer service checks tokens against.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
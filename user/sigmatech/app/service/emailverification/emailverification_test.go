@@ -0,0 +1,102 @@
+package emailverification
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"user/sigmatech/app/constants"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	emailVerifications_DBModels "user/sigmatech/app/db/dto/email_verifications"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mailer"
+	"user/sigmatech/config"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// emailVerificationRepository keeps the verifications of a single email, it ignores the where clauses.
+type emailVerificationRepository struct {
+	verifications []*emailVerifications_DBModels.EmailVerification
+}
+
+func (r *emailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *emailVerifications_DBModels.EmailVerification) error {
+	r.verifications = append(r.verifications, verification)
+	return nil
+}
+
+func (r *emailVerificationRepository) CountEmailVerifications(ctx context.Context, whr where.Filter) (int, error) {
+	return len(r.verifications), nil
+}
+
+// outbox stands in for the mailer, it keeps the mails sent.
+type outbox struct {
+	mails []mailer.Mail
+}
+
+func (o *outbox) Send(ctx context.Context, mail mailer.Mail) error {
+	o.mails = append(o.mails, mail)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+func (o *outbox) lastToken() string {
+	return tokenPattern.FindString(o.mails[len(o.mails)-1].Body)
+}
+
+func newTestService() (*EmailVerificationService, *emailVerificationRepository, *outbox) {
+	constants.Config = &config.ServiceConfig{EmailVerificationConfig: config.EmailVerificationConfig{
+		EMAIL_VERIFICATION_TTL:    86400,
+		EMAIL_VERIFICATION_LIMIT:  2,
+		EMAIL_VERIFICATION_WINDOW: 3600,
+	}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	verifications := &emailVerificationRepository{}
+	mails := &outbox{}
+
+	return NewEmailVerificationService(verifications, mails), verifications, mails
+}
+
+func TestSend(t *testing.T) {
+	ctx := context.Background()
+	customer := customers_DBModels.Customer{Uuid: uuid.New(), Name: "Budi", Email: "budi@sigmatech.id"}
+
+	t.Run("Given a customer When sending Then the emailed token is stored hashed for its email", func(t *testing.T) {
+		s, verifications, mails := newTestService()
+
+		if err := s.Send(ctx, customer); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if len(mails.mails) != 1 || mails.mails[0].To != customer.Email {
+			t.Fatalf("Send() sent %v, want a mail to %s", mails.mails, customer.Email)
+		}
+
+		token := mails.lastToken()
+		if len(verifications.verifications) != 1 {
+			t.Fatalf("Send() stored %d verifications, want 1", len(verifications.verifications))
+		}
+		got := verifications.verifications[0]
+		if got.CustomerUuid != customer.Uuid || got.Email != customer.Email {
+			t.Errorf("Send() stored the verification of %s %s, want %s %s", got.CustomerUuid, got.Email, customer.Uuid, customer.Email)
+		}
+		if token == "" || got.TokenHash == token || got.TokenHash != hashToken(token) {
+			t.Errorf("Send() stored token hash %q, want the hash of the emailed token", got.TokenHash)
+		}
+	})
+
+	t.Run("Given the limit of tokens was sent When sending again Then no mail is sent", func(t *testing.T) {
+		s, _, mails := newTestService()
+
+		for i := 0; i < constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT+1; i++ {
+			if err := s.Send(ctx, customer); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+		}
+		if len(mails.mails) != constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT {
+			t.Errorf("Send() sent %d mails, want %d", len(mails.mails), constants.Config.EmailVerificationConfig.EMAIL_VERIFICATION_LIMIT)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package export
+
+import "errors"
+
+const (
+	// Format of an export, requested with the export query parameter
+	FORMAT_CSV  = "csv"
+	FORMAT_XLSX = "xlsx"
+
+	// Status of an export job
+	JOB_STATUS_PENDING   = "pending"
+	JOB_STATUS_RUNNING   = "running"
+	JOB_STATUS_COMPLETED = "completed"
+	JOB_STATUS_FAILED    = "failed"
+
+	// Datasets that can be exported, one per admin list endpoint
+	DATASET_CUSTOMERS           = "customers"
+	DATASET_CUSTOMER_DETAILS    = "customer_details"
+	DATASET_TRANSACTIONS        = "transactions"
+	DATASET_TRANSACTION_DETAILS = "transaction_details"
+
+	// Kind of a column, it decides how values are written
+	KIND_TEXT   = "text"
+	KIND_NUMBER = "number"
+	KIND_BOOL   = "bool"
+	KIND_DATE   = "date"
+	KIND_TIME   = "time"
+
+	TIME_FORMAT = "2006-01-02 15:04:05"
+	DATE_FORMAT = "2006-01-02"
+)
+
+var Formats = []string{FORMAT_CSV, FORMAT_XLSX}
+
+var (
+	ErrUnknownDataset     = errors.New("unknown export dataset")
+	ErrUnsupportedFormat  = errors.New("unsupported export format")
+	ErrUnknownColumn      = errors.New("unknown export column")
+	ErrExportJobNotFound  = errors.New("export job not found")
+	ErrExportNotCompleted = errors.New("export job is not completed")
+)
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	cif_DBModels "user/sigmatech/app/db/dto/customer_information_files"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	"user/sigmatech/app/db/where"
+)
+
+// Column is one exportable column. Key is what the columns query parameter and the header use,
+// Expr is the SQL expression it is read from.
+type Column struct {
+	Key  string
+	Expr string
+	Kind string
+}
+
+// Dataset is the query behind an admin list endpoint. Filters apply to Table, the table of the
+// struct given to ExtractFilteredQueryParams, Joins may add other tables to it. Where is matched by every
+// row, e.g. to leave deleted rows out.
+type Dataset struct {
+	Name          string
+	Table         string
+	Joins         string
+	Where         where.Filter
+	SearchColumns []string
+	Columns       []Column
+}
+
+func column(table, name, kind string) Column {
+	return Column{Key: name, Expr: table + "." + name, Kind: kind}
+}
+
+func prefixed(prefix, table, name, kind string) Column {
+	return Column{Key: prefix + "." + name, Expr: table + "." + name, Kind: kind}
+}
+
+var customerColumns = []Column{
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUM_UUID, KIND_TEXT),
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_NAME, KIND_TEXT),
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_EMAIL, KIND_TEXT),
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_IS_ACTIVE, KIND_BOOL),
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_CREATED_AT, KIND_TIME),
+	column(customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_UPDATED_AT, KIND_TIME),
+}
+
+var transactionColumns = []Column{
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUM_UUID, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CUSTOMER_UUID, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CUSTOMER_LIMIT_UUID, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_MERCHANT_UUID, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_ASSET_NAME, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CONTRACT_NUMBER, KIND_TEXT),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_IS_DONE, KIND_BOOL),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_OTR, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_ADMIN_FEE, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_TOTAL, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_INSTALLMENT_AMOUNT, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_INSTALLMENT_COUNT, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_TOTAL_INTEREST, KIND_NUMBER),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_CREATED_AT, KIND_TIME),
+	column(transactions_DBModels.TABLE_NAME, transactions_DBModels.COLUMN_UPDATED_AT, KIND_TIME),
+}
+
+var datasets = map[string]Dataset{
+	DATASET_CUSTOMERS: {
+		Name:          DATASET_CUSTOMERS,
+		Table:         customers_DBModels.TABLE_NAME,
+		Where:         where.IsNull(customers_DBModels.TABLE_NAME + "." + customers_DBModels.COLUMN_DELETED_AT),
+		SearchColumns: []string{customers_DBModels.COLUMN_NAME, customers_DBModels.COLUMN_EMAIL},
+		Columns:       customerColumns,
+	},
+	DATASET_CUSTOMER_DETAILS: {
+		Name:  DATASET_CUSTOMER_DETAILS,
+		Table: customers_DBModels.TABLE_NAME,
+		Joins: fmt.Sprintf("LEFT JOIN %[2]s ON %[2]s.%[3]s = %[1]s.%[4]s",
+			customers_DBModels.TABLE_NAME, cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_CUSTOMER_UUID, customers_DBModels.COLUM_UUID,
+		),
+		Where:         where.IsNull(customers_DBModels.TABLE_NAME + "." + customers_DBModels.COLUMN_DELETED_AT),
+		SearchColumns: []string{customers_DBModels.COLUMN_NAME, customers_DBModels.COLUMN_EMAIL},
+		Columns: append(append([]Column{}, customerColumns...),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_CIF_NUMBER, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_NIK, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_FULL_NAME, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_LEGAL_NAME, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_PLACE_OF_BIRTH, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_DATE_OF_BIRTH, KIND_DATE),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_GENDER, KIND_TEXT),
+			prefixed("cif", cif_DBModels.TABLE_NAME, cif_DBModels.COLUMN_SALARY, KIND_NUMBER),
+		),
+	},
+	DATASET_TRANSACTIONS: {
+		Name:          DATASET_TRANSACTIONS,
+		Table:         transactions_DBModels.TABLE_NAME,
+		SearchColumns: []string{transactions_DBModels.COLUMN_CONTRACT_NUMBER},
+		Columns:       transactionColumns,
+	},
+	// One row per installment, the contract and customer columns are repeated on each of them
+	DATASET_TRANSACTION_DETAILS: {
+		Name:  DATASET_TRANSACTION_DETAILS,
+		Table: transactions_DBModels.TABLE_NAME,
+		Joins: fmt.Sprintf("JOIN %[2]s ON %[2]s.%[3]s = %[1]s.%[4]s LEFT JOIN %[5]s ON %[5]s.%[6]s = %[1]s.%[7]s",
+			transactions_DBModels.TABLE_NAME,
+			customers_DBModels.TABLE_NAME, customers_DBModels.COLUM_UUID, transactions_DBModels.COLUMN_CUSTOMER_UUID,
+			transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_TRANSACTION_UUID, transactions_DBModels.COLUM_UUID,
+		),
+		SearchColumns: []string{transactions_DBModels.COLUMN_CONTRACT_NUMBER},
+		Columns: append(append([]Column{}, transactionColumns...),
+			prefixed("customer", customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_NAME, KIND_TEXT),
+			prefixed("customer", customers_DBModels.TABLE_NAME, customers_DBModels.COLUMN_EMAIL, KIND_TEXT),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_TERM, KIND_NUMBER),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_DUE_DATE, KIND_DATE),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_AMOUNT, KIND_NUMBER),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_AMOUNT_PAID, KIND_NUMBER),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_PAYMENT_AT, KIND_DATE),
+			prefixed("installment", transaction_installments_DBModels.TABLE_NAME, transaction_installments_DBModels.COLUMN_METHOD_PAYMENT, KIND_TEXT),
+		),
+	},
+}
+
+// GetDataset returns the dataset registered under name.
+func GetDataset(name string) (Dataset, error) {
+	dataset, ok := datasets[name]
+	if !ok {
+		return Dataset{}, fmt.Errorf("%w: %s", ErrUnknownDataset, name)
+	}
+	return dataset, nil
+}
+
+// SelectColumns returns the columns listed in keys, comma separated, in that order. Empty keys selects every column.
+func (d Dataset) SelectColumns(keys string) ([]Column, error) {
+	if strings.TrimSpace(keys) == "" {
+		return d.Columns, nil
+	}
+
+	byKey := make(map[string]Column, len(d.Columns))
+	for _, column := range d.Columns {
+		byKey[column.Key] = column
+	}
+
+	var columns []Column
+	for _, key := range strings.Split(keys, ",") {
+		column, ok := byKey[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, strings.TrimSpace(key))
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
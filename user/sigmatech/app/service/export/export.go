@@ -0,0 +1,245 @@
+// Package export writes the rows of admin list endpoints to CSV or XLSX files.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"user/sigmatech/app/constants"
+	exportJobs_DBModels "user/sigmatech/app/db/dto/export_jobs"
+	exportDB "user/sigmatech/app/db/repository/export"
+	exportJobDB "user/sigmatech/app/db/repository/export_job"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/aws/s3"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	exportRequest "user/sigmatech/app/service/dto/request/export"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/google/uuid"
+)
+
+type IExportService interface {
+	// Prepare builds the query of an export from the list endpoint filters and pagination, and counts its rows.
+	Prepare(ctx context.Context, dataset Dataset, columns []Column, filter map[string]interface{}, pagination request.Pagination) (exportRequest.Query, int, error)
+	// Write streams the rows of the query to w and returns how many were written.
+	Write(ctx context.Context, w io.Writer, format string, columns []Column, q exportRequest.Query) (int, error)
+	// Enqueue creates the job of an export and writes it to S3 in the background.
+	Enqueue(ctx context.Context, dataset Dataset, format string, columns []Column, q exportRequest.Query, createdBy *uuid.UUID) (exportJobs_DBModels.ExportJob, error)
+	// DownloadUrl returns a temporary link to the file of a completed job.
+	DownloadUrl(ctx context.Context, job exportJobs_DBModels.ExportJob) (string, error)
+}
+
+// ExportService is a struct that implements the IExportService interface.
+type ExportService struct {
+	ExportDBClient    exportDB.IExportRepository
+	ExportJobDBClient exportJobDB.IExportJobRepository
+	S3                s3.IS3Client
+}
+
+// NewExportService is a constructor function that creates a new ExportService.
+func NewExportService(
+	ExportDBClient exportDB.IExportRepository,
+	ExportJobDBClient exportJobDB.IExportJobRepository,
+	S3 s3.IS3Client,
+) *ExportService {
+	return &ExportService{
+		ExportDBClient:    ExportDBClient,
+		ExportJobDBClient: ExportJobDBClient,
+		S3:                S3,
+	}
+}
+
+func (s *ExportService) Prepare(ctx context.Context, dataset Dataset, columns []Column, filter map[string]interface{}, pagination request.Pagination) (exportRequest.Query, int, error) {
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = column.Expr
+	}
+
+	q := exportRequest.Query{
+		Table:         dataset.Table,
+		Joins:         dataset.Joins,
+		Where:         dataset.Where,
+		Select:        selects,
+		Filter:        filter,
+		Search:        pagination.Query,
+		SearchColumns: dataset.SearchColumns,
+		Order:         pagination.Order,
+		Sort:          pagination.Sort,
+	}
+	if err := q.Validate(); err != nil {
+		return q, 0, err
+	}
+
+	total, err := s.ExportDBClient.CountRows(ctx, q)
+	if err != nil {
+		return q, 0, err
+	}
+
+	return q, total, nil
+}
+
+func (s *ExportService) Write(ctx context.Context, w io.Writer, format string, columns []Column, q exportRequest.Query) (int, error) {
+	writer, err := NewWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.ExportDBClient.GetRows(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if err := writer.WriteHeader(columns); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+
+		row := make([]interface{}, len(values))
+		for i, value := range values {
+			row[i] = normalizeValue(columns[i].Kind, value)
+		}
+
+		if err := writer.WriteRow(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
+
+// params is what a job records of its export in its params column
+type params struct {
+	Columns []string            `json:"columns"`
+	Query   exportRequest.Query `json:"query"`
+}
+
+func (s *ExportService) Enqueue(ctx context.Context, dataset Dataset, format string, columns []Column, q exportRequest.Query, createdBy *uuid.UUID) (exportJobs_DBModels.ExportJob, error) {
+	p := params{Query: q}
+	for _, column := range columns {
+		p.Columns = append(p.Columns, column.Key)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return exportJobs_DBModels.ExportJob{}, err
+	}
+
+	job := exportJobs_DBModels.ExportJob{
+		Uuid:      uuid.New(),
+		Dataset:   dataset.Name,
+		Format:    format,
+		Params:    string(data),
+		Status:    JOB_STATUS_PENDING,
+		FileName:  util.String(fmt.Sprintf("%s_%s.%s", dataset.Name, time.Now().Format("20060102_150405"), format)),
+		CreatedAt: time.Now(),
+		CreatedBy: createdBy,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.ExportJobDBClient.CreateExportJob(ctx, &job); err != nil {
+		return exportJobs_DBModels.ExportJob{}, err
+	}
+
+	// The request context ends with the response, the job keeps only its correlation id
+	go s.run(correlation.ContextFromCorrelation(correlation.ContextCorrelationId(ctx)), job, columns, q)
+
+	return job, nil
+}
+
+// run writes the export to a temporary file, so it is never held in memory, and uploads it.
+func (s *ExportService) run(ctx context.Context, job exportJobs_DBModels.ExportJob, columns []Column, q exportRequest.Query) {
+	log := logger.Logger(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("export job %s panicked: %v", job.Uuid, r)
+			s.finish(ctx, job, 0, nil, fmt.Errorf("%v", r))
+		}
+	}()
+
+	s.updateJob(ctx, job.Uuid, map[string]interface{}{
+		exportJobs_DBModels.COLUMN_STATUS:     JOB_STATUS_RUNNING,
+		exportJobs_DBModels.COLUMN_STARTED_AT: time.Now(),
+	})
+
+	file, err := os.CreateTemp("", "export-*."+job.Format)
+	if err != nil {
+		s.finish(ctx, job, 0, nil, err)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	total, err := s.Write(ctx, file, job.Format, columns, q)
+	if err != nil {
+		s.finish(ctx, job, total, nil, err)
+		return
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		s.finish(ctx, job, total, nil, err)
+		return
+	}
+
+	objectKey := fmt.Sprintf("%s%s/%s", constants.Config.ExportConfig.EXPORT_OBJECT_PREFIX, job.Uuid, *job.FileName)
+	if _, err := s.S3.PutObjectReader(objectKey, file, ContentType(job.Format)); err != nil {
+		s.finish(ctx, job, total, nil, err)
+		return
+	}
+
+	s.finish(ctx, job, total, &objectKey, nil)
+}
+
+func (s *ExportService) finish(ctx context.Context, job exportJobs_DBModels.ExportJob, total int, objectKey *string, err error) {
+	patch := map[string]interface{}{
+		exportJobs_DBModels.COLUMN_STATUS:      JOB_STATUS_COMPLETED,
+		exportJobs_DBModels.COLUMN_TOTAL_ROWS:  total,
+		exportJobs_DBModels.COLUMN_OBJECT_KEY:  objectKey,
+		exportJobs_DBModels.COLUMN_FINISHED_AT: time.Now(),
+	}
+	if err != nil {
+		logger.Logger(ctx).Errorf("export job %s failed: %v", job.Uuid, err)
+		patch[exportJobs_DBModels.COLUMN_STATUS] = JOB_STATUS_FAILED
+		patch[exportJobs_DBModels.COLUMN_ERROR] = err.Error()
+	}
+
+	s.updateJob(ctx, job.Uuid, patch)
+}
+
+func (s *ExportService) updateJob(ctx context.Context, jobUuid uuid.UUID, patch map[string]interface{}) {
+	patch[exportJobs_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+	filter := where.Eq(exportJobs_DBModels.COLUM_UUID, jobUuid)
+	if err := s.ExportJobDBClient.UpdateExportJob(ctx, filter, patch); err != nil {
+		logger.Logger(ctx).Errorf("failed to update export job %s: %v", jobUuid, err)
+	}
+}
+
+func (s *ExportService) DownloadUrl(ctx context.Context, job exportJobs_DBModels.ExportJob) (string, error) {
+	if job.Status != JOB_STATUS_COMPLETED || job.ObjectKey == nil {
+		return "", ErrExportNotCompleted
+	}
+
+	return s.S3.PresignGetObject(*job.ObjectKey, time.Duration(constants.Config.ExportConfig.EXPORT_LINK_TTL)*time.Second)
+}
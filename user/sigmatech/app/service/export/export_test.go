@@ -0,0 +1,95 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSelectColumns(t *testing.T) {
+	dataset, err := GetDataset(DATASET_CUSTOMERS)
+	if err != nil {
+		t.Fatalf("GetDataset() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		keys     string
+		wantKeys []string
+		wantErr  error
+	}{
+		{
+			name:     "Given no columns When selecting Then every column is returned",
+			keys:     "",
+			wantKeys: []string{"uuid", "name", "email", "is_active", "created_at", "updated_at"},
+		},
+		{
+			name:     "Given columns When selecting Then they are returned in the requested order",
+			keys:     "email, name",
+			wantKeys: []string{"email", "name"},
+		},
+		{
+			name:    "Given an unknown column When selecting Then ErrUnknownColumn is returned",
+			keys:    "name,password",
+			wantErr: ErrUnknownColumn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := dataset.SelectColumns(tt.keys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SelectColumns() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(columns) != len(tt.wantKeys) {
+				t.Fatalf("SelectColumns() returned %d columns, want %d", len(columns), len(tt.wantKeys))
+			}
+			for i, column := range columns {
+				if column.Key != tt.wantKeys[i] {
+					t.Errorf("SelectColumns()[%d] = %s, want %s", i, column.Key, tt.wantKeys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	columns := []Column{
+		{Key: "name", Kind: KIND_TEXT},
+		{Key: "total", Kind: KIND_NUMBER},
+		{Key: "is_done", Kind: KIND_BOOL},
+		{Key: "created_at", Kind: KIND_TIME},
+	}
+	createdAt := time.Date(2026, 10, 19, 15, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(FORMAT_CSV, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := writer.WriteHeader(columns); err != nil {
+		t.Fatalf("WriteHeader() error = %v", err)
+	}
+
+	values := []interface{}{[]byte("Budi, S.E."), []byte("1500000.50"), true, createdAt}
+	for i := range values {
+		values[i] = normalizeValue(columns[i].Kind, values[i])
+	}
+	if err := writer.WriteRow(values); err != nil {
+		t.Fatalf("WriteRow() error = %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{nil, nil, nil, nil}); err != nil {
+		t.Fatalf("WriteRow() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "name,total,is_done,created_at\n" +
+		"\"Budi, S.E.\",1500000.5,true," + createdAt.Format(TIME_FORMAT) + "\n" +
+		",,,\n"
+	if buf.String() != want {
+		t.Errorf("CSV output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// IWriter writes an export one row at a time. Values are nil, string, float64, bool or time.Time.
+type IWriter interface {
+	WriteHeader(columns []Column) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter returns the writer of format writing to w.
+func NewWriter(format string, w io.Writer) (IWriter, error) {
+	switch format {
+	case FORMAT_CSV:
+		return &CSVWriter{writer: csv.NewWriter(w)}, nil
+	case FORMAT_XLSX:
+		return NewXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == FORMAT_XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+// CSVWriter writes rows through encoding/csv, which flushes its buffer to the underlying writer as it fills.
+type CSVWriter struct {
+	writer  *csv.Writer
+	columns []Column
+}
+
+func (w *CSVWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Key
+	}
+	return w.writer.Write(header)
+}
+
+func (w *CSVWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(w.columns[i].Kind, value)
+	}
+	return w.writer.Write(record)
+}
+
+func (w *CSVWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// XLSXWriter writes rows through the excelize stream writer, which spills them to a temporary file
+// instead of keeping the whole sheet in memory. The workbook is written to w on Close.
+type XLSXWriter struct {
+	w         io.Writer
+	file      *excelize.File
+	stream    *excelize.StreamWriter
+	columns   []Column
+	row       int
+	dateStyle int
+	timeStyle int
+}
+
+const xlsxSheet = "Sheet1"
+
+func NewXLSXWriter(w io.Writer) (*XLSXWriter, error) {
+	file := excelize.NewFile()
+
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, err
+	}
+
+	dateFormat, timeFormat := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return nil, err
+	}
+	timeStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &timeFormat})
+	if err != nil {
+		return nil, err
+	}
+
+	return &XLSXWriter{w: w, file: file, stream: stream, dateStyle: dateStyle, timeStyle: timeStyle}, nil
+}
+
+func (w *XLSXWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column.Key
+	}
+	return w.setRow(header)
+}
+
+func (w *XLSXWriter) WriteRow(values []interface{}) error {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		t, ok := value.(time.Time)
+		switch {
+		case ok && w.columns[i].Kind == KIND_DATE:
+			row[i] = excelize.Cell{StyleID: w.dateStyle, Value: t}
+		case ok:
+			row[i] = excelize.Cell{StyleID: w.timeStyle, Value: t}
+		default:
+			row[i] = value
+		}
+	}
+	return w.setRow(row)
+}
+
+func (w *XLSXWriter) setRow(values []interface{}) error {
+	w.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *XLSXWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.file.Write(w.w)
+}
+
+// normalizeValue converts a value scanned from the database to the types writers accept
+func normalizeValue(kind string, value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if kind == KIND_NUMBER {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+		return v
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+func formatValue(kind string, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if kind == KIND_DATE {
+			return v.Format(DATE_FORMAT)
+		}
+		return v.Format(TIME_FORMAT)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
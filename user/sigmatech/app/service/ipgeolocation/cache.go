@@ -0,0 +1,68 @@
+package ipgeolocation
+
+import (
+	"sync"
+	"time"
+)
+
+// CachedClient remembers the locations its Client resolved for TTL, an IP signing in again doesn't
+// call the API. Once MaxEntries are cached the expired ones are dropped, or all of them if none expired.
+type CachedClient struct {
+	Client     IClient
+	TTL        time.Duration
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cachedLocation
+}
+
+type cachedLocation struct {
+	location  LocationInfo
+	expiresAt time.Time
+}
+
+// NewCachedClient wraps client with a cache of its locations.
+func NewCachedClient(client IClient, ttl time.Duration, maxEntries int) *CachedClient {
+	return &CachedClient{
+		Client:     client,
+		TTL:        ttl,
+		MaxEntries: maxEntries,
+		entries:    make(map[string]cachedLocation),
+	}
+}
+
+func (c *CachedClient) GetLocationInfo(ipAddress string) (LocationInfo, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[ipAddress]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.location, nil
+	}
+
+	location, err := c.Client.GetLocationInfo(ipAddress)
+	if err != nil {
+		return LocationInfo{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.MaxEntries {
+		c.evict()
+	}
+	c.entries[ipAddress] = cachedLocation{location: location, expiresAt: time.Now().Add(c.TTL)}
+
+	return location, nil
+}
+
+func (c *CachedClient) evict() {
+	now := time.Now()
+	for ip, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, ip)
+		}
+	}
+	if len(c.entries) >= c.MaxEntries {
+		c.entries = make(map[string]cachedLocation)
+	}
+}
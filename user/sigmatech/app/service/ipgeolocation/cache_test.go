@@ -0,0 +1,68 @@
+package ipgeolocation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubClient struct {
+	calls int
+	err   error
+}
+
+func (s *stubClient) GetLocationInfo(ipAddress string) (LocationInfo, error) {
+	s.calls++
+	return LocationInfo{IP: ipAddress, CountryCode2: "ID"}, s.err
+}
+
+func TestCachedClient(t *testing.T) {
+	t.Run("Given a located IP When it is located again Then the cached location is returned", func(t *testing.T) {
+		stub := &stubClient{}
+		client := NewCachedClient(stub, time.Hour, 10)
+
+		client.GetLocationInfo("8.8.8.8")
+		location, err := client.GetLocationInfo("8.8.8.8")
+		if err != nil || location.CountryCode2 != "ID" {
+			t.Fatalf("GetLocationInfo() = %+v, %v", location, err)
+		}
+		if stub.calls != 1 {
+			t.Errorf("client called %d times, want 1", stub.calls)
+		}
+	})
+
+	t.Run("Given an expired location When the IP is located again Then the client is called again", func(t *testing.T) {
+		stub := &stubClient{}
+		client := NewCachedClient(stub, -time.Second, 10)
+
+		client.GetLocationInfo("8.8.8.8")
+		client.GetLocationInfo("8.8.8.8")
+		if stub.calls != 2 {
+			t.Errorf("client called %d times, want 2", stub.calls)
+		}
+	})
+
+	t.Run("Given a failing client When an IP is located Then the failure isn't cached", func(t *testing.T) {
+		stub := &stubClient{err: errors.New("unavailable")}
+		client := NewCachedClient(stub, time.Hour, 10)
+
+		if _, err := client.GetLocationInfo("8.8.8.8"); err == nil {
+			t.Fatal("GetLocationInfo() error = nil, want an error")
+		}
+		client.GetLocationInfo("8.8.8.8")
+		if stub.calls != 2 {
+			t.Errorf("client called %d times, want 2", stub.calls)
+		}
+	})
+
+	t.Run("Given a full cache When another IP is located Then the cache stays within its size", func(t *testing.T) {
+		client := NewCachedClient(&stubClient{}, time.Hour, 2)
+
+		for _, ip := range []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"} {
+			client.GetLocationInfo(ip)
+		}
+		if len(client.entries) > 2 {
+			t.Errorf("cache holds %d entries, want at most 2", len(client.entries))
+		}
+	})
+}
@@ -1,10 +1,15 @@
 package ipgeolocation
 
+import "time"
+
 // URL constants.
 const (
 	// Base URL
 	BaseURL = "https://api.ipgeolocation.io"
 
+	// requestTimeout bounds a call to the API, lookups run in the background and mustn't pile up
+	requestTimeout = 10 * time.Second
+
 	// Endpoints
 	GetLocationInfoEndpoint     = "/ipgeo"
 	GetLocationInfoBulkEndpoint = "/ipgeo-bulk"
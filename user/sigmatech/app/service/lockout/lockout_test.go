@@ -0,0 +1,45 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+	"user/sigmatech/app/constants"
+	"user/sigmatech/config"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	constants.Config = &config.ServiceConfig{LoginLockoutConfig: config.LoginLockoutConfig{
+		LOGIN_LOCKOUT_DURATION:     300,
+		LOGIN_LOCKOUT_MAX_DURATION: 3600,
+	}}
+
+	tests := []struct {
+		name     string
+		lockouts int
+		want     time.Duration
+	}{
+		{
+			name:     "Given the first lockout When computing its duration Then it lasts the configured duration",
+			lockouts: 1,
+			want:     5 * time.Minute,
+		},
+		{
+			name:     "Given a third lockout in a row When computing its duration Then it lasts four times as long",
+			lockouts: 3,
+			want:     20 * time.Minute,
+		},
+		{
+			name:     "Given many lockouts in a row When computing the duration Then it is capped at the maximum",
+			lockouts: 50,
+			want:     time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockoutDuration(tt.lockouts); got != tt.want {
+				t.Errorf("lockoutDuration(%d) = %s, want %s", tt.lockouts, got, tt.want)
+			}
+		})
+	}
+}
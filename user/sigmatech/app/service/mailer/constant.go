@@ -0,0 +1,7 @@
+package mailer
+
+const (
+	// Mailer drivers
+	DRIVER_SMTP = "smtp"
+	DRIVER_LOG  = "log"
+)
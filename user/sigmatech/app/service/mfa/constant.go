@@ -0,0 +1,22 @@
+package mfa
+
+import "errors"
+
+const (
+	// TOTP parameters of RFC 6238, the defaults every authenticator app supports
+	period       = 30
+	digits       = 6
+	secretLength = 20
+
+	// recoveryCodeLength is the number of characters of a recovery code, without the dash.
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrMfaEnabled    = errors.New("mfa is already enabled")
+	ErrMfaNotEnabled = errors.New("mfa is not enabled")
+	// ErrInvalidCode is returned for a wrong code, a code already used and a used recovery code alike.
+	ErrInvalidCode = errors.New("invalid mfa code")
+	// ErrNoEncryptionKey is returned when enrolling without MFA_ENCRYPTION_KEY configured.
+	ErrNoEncryptionKey = errors.New("mfa encryption key is not configured")
+)
@@ -0,0 +1,279 @@
+// Package mfa enrolls admin users in a TOTP second factor and verifies their codes. Each enrollment
+// comes with single use recovery codes for users who lost their authenticator.
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	mfaRecoveryCodes_DBModels "user/sigmatech/app/db/dto/mfa_recovery_codes"
+	userMfa_DBModels "user/sigmatech/app/db/dto/user_mfa"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	mfaRecoveryCodeDB "user/sigmatech/app/db/repository/mfa_recovery_code"
+	userMfaDB "user/sigmatech/app/db/repository/user_mfa"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/pkg/encrypt"
+
+	"github.com/google/uuid"
+)
+
+// Enrollment is what the authenticator app of the user is set up with, the URI is usually shown as a QR code.
+type Enrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type IMfaService interface {
+	// Enabled reports whether the user signs in with a second factor.
+	Enabled(ctx context.Context, userUuid uuid.UUID) (bool, error)
+	// Enroll starts an enrollment with a new secret, replacing one that wasn't activated yet.
+	Enroll(ctx context.Context, user users_DBModels.User) (*Enrollment, error)
+	// Activate enables the enrollment once the user sent a first code from their authenticator, and
+	// returns the recovery codes. They are only ever shown this once.
+	Activate(ctx context.Context, userUuid uuid.UUID, code string) ([]string, error)
+	// Verify checks a code from the authenticator or a recovery code, each is accepted once.
+	Verify(ctx context.Context, userUuid uuid.UUID, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes once the code is verified.
+	RegenerateRecoveryCodes(ctx context.Context, userUuid uuid.UUID, code string) ([]string, error)
+	// Disable removes the enrollment and its recovery codes once the code is verified.
+	Disable(ctx context.Context, userUuid uuid.UUID, code string) error
+}
+
+// MfaService is a struct that implements the IMfaService interface.
+type MfaService struct {
+	UserMfaDBClient      userMfaDB.IUserMfaRepository
+	RecoveryCodeDBClient mfaRecoveryCodeDB.IMfaRecoveryCodeRepository
+}
+
+// NewMfaService is a constructor function that creates a new MfaService.
+func NewMfaService(
+	UserMfaDBClient userMfaDB.IUserMfaRepository,
+	RecoveryCodeDBClient mfaRecoveryCodeDB.IMfaRecoveryCodeRepository,
+) *MfaService {
+	return &MfaService{
+		UserMfaDBClient:      UserMfaDBClient,
+		RecoveryCodeDBClient: RecoveryCodeDBClient,
+	}
+}
+
+func (s *MfaService) Enabled(ctx context.Context, userUuid uuid.UUID) (bool, error) {
+	mfa, err := s.getUserMfa(ctx, userUuid)
+	if err != nil {
+		return false, err
+	}
+	return mfa.EnabledAt != nil, nil
+}
+
+func (s *MfaService) Enroll(ctx context.Context, user users_DBModels.User) (*Enrollment, error) {
+	key := constants.Config.MfaConfig.MFA_ENCRYPTION_KEY
+	if key == "" {
+		return nil, ErrNoEncryptionKey
+	}
+
+	existing, err := s.getUserMfa(ctx, user.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	if existing.EnabledAt != nil {
+		return nil, ErrMfaEnabled
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := encrypt.EncryptWithKey([]byte(secret), key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if existing.UserUuid == uuid.Nil {
+		err = s.UserMfaDBClient.CreateUserMfa(ctx, &userMfa_DBModels.UserMfa{
+			UserUuid:  user.Uuid,
+			Secret:    sealed,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	} else {
+		_, err = s.UserMfaDBClient.UpdateUserMfa(ctx, where.Eq(userMfa_DBModels.COLUMN_USER_UUID, user.Uuid).IsNull(userMfa_DBModels.COLUMN_ENABLED_AT), map[string]interface{}{
+			userMfa_DBModels.COLUMN_SECRET:     sealed,
+			userMfa_DBModels.COLUMN_UPDATED_AT: now,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret:          secret,
+		ProvisioningUri: provisioningUri(constants.Config.MfaConfig.MFA_ISSUER, user.Email, secret),
+	}, nil
+}
+
+func (s *MfaService) Activate(ctx context.Context, userUuid uuid.UUID, code string) ([]string, error) {
+	mfa, err := s.getUserMfa(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.UserUuid == uuid.Nil {
+		return nil, ErrMfaNotEnabled
+	}
+	if mfa.EnabledAt != nil {
+		return nil, ErrMfaEnabled
+	}
+
+	step, err := s.matchCode(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Two activations racing, only one of them enables the enrollment
+	activated, err := s.UserMfaDBClient.UpdateUserMfa(ctx, where.Eq(userMfa_DBModels.COLUMN_USER_UUID, userUuid).IsNull(userMfa_DBModels.COLUMN_ENABLED_AT), map[string]interface{}{
+		userMfa_DBModels.COLUMN_ENABLED_AT:     time.Now(),
+		userMfa_DBModels.COLUMN_LAST_USED_STEP: step,
+		userMfa_DBModels.COLUMN_UPDATED_AT:     time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if activated == 0 {
+		return nil, ErrMfaEnabled
+	}
+
+	return s.replaceRecoveryCodes(ctx, userUuid)
+}
+
+func (s *MfaService) Verify(ctx context.Context, userUuid uuid.UUID, code string) error {
+	mfa, err := s.getUserMfa(ctx, userUuid)
+	if err != nil {
+		return err
+	}
+	if mfa.EnabledAt == nil {
+		return ErrMfaNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if !isTotp(code) {
+		return s.useRecoveryCode(ctx, userUuid, code)
+	}
+
+	step, err := s.matchCode(mfa, code)
+	if err != nil {
+		return err
+	}
+
+	// The step only moves forward, a code seen once (or an earlier one) is refused
+	updated, err := s.UserMfaDBClient.UpdateUserMfa(ctx, where.Eq(userMfa_DBModels.COLUMN_USER_UUID, userUuid).Lt(userMfa_DBModels.COLUMN_LAST_USED_STEP, step), map[string]interface{}{
+		userMfa_DBModels.COLUMN_LAST_USED_STEP: step,
+		userMfa_DBModels.COLUMN_UPDATED_AT:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+func (s *MfaService) RegenerateRecoveryCodes(ctx context.Context, userUuid uuid.UUID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userUuid, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userUuid)
+}
+
+func (s *MfaService) Disable(ctx context.Context, userUuid uuid.UUID, code string) error {
+	if err := s.Verify(ctx, userUuid, code); err != nil {
+		return err
+	}
+
+	if err := s.UserMfaDBClient.DeleteUserMfa(ctx, where.Eq(userMfa_DBModels.COLUMN_USER_UUID, userUuid)); err != nil {
+		return err
+	}
+
+	return s.RecoveryCodeDBClient.DeleteRecoveryCodes(ctx, where.Eq(mfaRecoveryCodes_DBModels.COLUMN_USER_UUID, userUuid))
+}
+
+func (s *MfaService) getUserMfa(ctx context.Context, userUuid uuid.UUID) (userMfa_DBModels.UserMfa, error) {
+	return s.UserMfaDBClient.GetUserMfa(ctx, where.Eq(userMfa_DBModels.COLUMN_USER_UUID, userUuid))
+}
+
+// matchCode returns the time step of a code from the authenticator of the enrollment.
+func (s *MfaService) matchCode(mfa userMfa_DBModels.UserMfa, code string) (int64, error) {
+	secret, err := encrypt.DecryptWithKey(mfa.Secret, constants.Config.MfaConfig.MFA_ENCRYPTION_KEY)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := matchStep(string(secret), strings.TrimSpace(code), time.Now(), constants.Config.MfaConfig.MFA_SKEW)
+	if !ok {
+		return 0, ErrInvalidCode
+	}
+	return step, nil
+}
+
+func (s *MfaService) useRecoveryCode(ctx context.Context, userUuid uuid.UUID, code string) error {
+	used, err := s.RecoveryCodeDBClient.UpdateRecoveryCode(ctx, where.Eq(mfaRecoveryCodes_DBModels.COLUMN_USER_UUID, userUuid).
+		Eq(mfaRecoveryCodes_DBModels.COLUMN_CODE_HASH, hashRecoveryCode(userUuid, code)).
+		IsNull(mfaRecoveryCodes_DBModels.COLUMN_USED_AT), map[string]interface{}{
+		mfaRecoveryCodes_DBModels.COLUMN_USED_AT: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+func (s *MfaService) replaceRecoveryCodes(ctx context.Context, userUuid uuid.UUID) ([]string, error) {
+	codes := make([]string, constants.Config.MfaConfig.MFA_RECOVERY_CODES)
+	stored := make([]*mfaRecoveryCodes_DBModels.RecoveryCode, len(codes))
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = code
+		stored[i] = &mfaRecoveryCodes_DBModels.RecoveryCode{
+			Uuid:      uuid.New(),
+			UserUuid:  userUuid,
+			CodeHash:  hashRecoveryCode(userUuid, code),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	if err := s.RecoveryCodeDBClient.ReplaceRecoveryCodes(ctx, userUuid, stored); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code like k3v9q-7hx2m, lowercase base32 so it reads without ambiguity.
+func generateRecoveryCode() (string, error) {
+	random := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(secretEncoding.EncodeToString(random))[:recoveryCodeLength]
+	return code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:], nil
+}
+
+// hashRecoveryCode binds the code to its user, ignoring case and the dash users may leave out.
+func hashRecoveryCode(userUuid uuid.UUID, code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(userUuid.String() + ":" + normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// secretEncoding is how secrets are shown to authenticator apps.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func generateSecret() (string, error) {
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+// totp returns the code of the secret for the time step.
+func totp(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, code%1000000)
+}
+
+// matchStep returns the time step the code was generated for, codes up to skew steps early or late
+// are accepted for clocks that drifted.
+func matchStep(encodedSecret, code string, now time.Time, skew int) (int64, bool) {
+	secret, err := secretEncoding.DecodeString(encodedSecret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / period
+	for i := -int64(skew); i <= int64(skew); i++ {
+		if subtle.ConstantTimeCompare([]byte(totp(secret, current+i)), []byte(code)) == 1 {
+			return current + i, true
+		}
+	}
+	return 0, false
+}
+
+// provisioningUri is the otpauth URI authenticator apps read from a QR code.
+func provisioningUri(issuer, account, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(digits))
+	values.Set("period", fmt.Sprint(period))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", url.PathEscape(issuer+":"+account), values.Encode())
+}
+
+// isTotp tells codes from authenticator apps apart from recovery codes.
+func isTotp(code string) bool {
+	if len(code) != digits {
+		return false
+	}
+	return strings.Trim(code, "0123456789") == ""
+}
@@ -0,0 +1,66 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTotp(t *testing.T) {
+	// Test vectors of RFC 6238 for SHA1, truncated to six digits
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "Given the first vector When computing the code Then it matches the RFC", unix: 59, want: "287082"},
+		{name: "Given a vector with a leading zero When computing the code Then it is zero padded", unix: 1111111109, want: "081804"},
+		{name: "Given a recent vector When computing the code Then it matches the RFC", unix: 1234567890, want: "005924"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := totp(secret, tt.unix/period); got != tt.want {
+				t.Errorf("totp() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchStep(t *testing.T) {
+	secret := secretEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / period
+
+	t.Run("Given a code of the previous step When the skew allows one step Then it matches that step", func(t *testing.T) {
+		got, ok := matchStep(secret, totp([]byte("12345678901234567890"), step-1), now, 1)
+		if !ok || got != step-1 {
+			t.Errorf("matchStep() = %d, %v, want %d, true", got, ok, step-1)
+		}
+	})
+
+	t.Run("Given a code two steps old When the skew allows one step Then it doesn't match", func(t *testing.T) {
+		if _, ok := matchStep(secret, totp([]byte("12345678901234567890"), step-2), now, 1); ok {
+			t.Error("matchStep() matched a code outside the skew")
+		}
+	})
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	userUuid := uuid.New()
+
+	t.Run("Given a recovery code typed without the dash in upper case When hashing it Then it matches the issued code", func(t *testing.T) {
+		if hashRecoveryCode(userUuid, "K3V9Q7HX2M") != hashRecoveryCode(userUuid, "k3v9q-7hx2m") {
+			t.Error("hashes differ")
+		}
+	})
+
+	t.Run("Given the same code When hashing it for another user Then the hashes differ", func(t *testing.T) {
+		if hashRecoveryCode(userUuid, "k3v9q-7hx2m") == hashRecoveryCode(uuid.New(), "k3v9q-7hx2m") {
+			t.Error("hashes match across users")
+		}
+	})
+}
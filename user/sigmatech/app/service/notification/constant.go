@@ -0,0 +1,67 @@
+package notification
+
+// EventType identifies the business event a notification is sent for.
+type EventType string
+
+// Event types.
+const (
+	EventCustomerApproved   EventType = "customer.approved"
+	EventTransactionCreated EventType = "transaction.created"
+	EventInstallmentPaid    EventType = "installment.paid"
+	EventInstallmentOverdue EventType = "installment.overdue"
+	EventPartnerConsent     EventType = "partner.consent"
+)
+
+// Channel is a delivery channel for a notification.
+type Channel string
+
+// Channels.
+const (
+	ChannelInApp Channel = "in_app"
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+// EventTypes lists every supported event type.
+var EventTypes = []EventType{
+	EventCustomerApproved,
+	EventTransactionCreated,
+	EventInstallmentPaid,
+	EventInstallmentOverdue,
+	EventPartnerConsent,
+}
+
+// Channels lists every supported channel in the order they are delivered.
+var Channels = []Channel{
+	ChannelInApp,
+	ChannelEmail,
+	ChannelSMS,
+}
+
+func (e EventType) String() string {
+	return string(e)
+}
+
+func (c Channel) String() string {
+	return string(c)
+}
+
+// IsValidEventType reports whether the given value is a supported event type.
+func IsValidEventType(value string) bool {
+	for _, e := range EventTypes {
+		if string(e) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidChannel reports whether the given value is a supported channel.
+func IsValidChannel(value string) bool {
+	for _, c := range Channels {
+		if string(c) == value {
+			return true
+		}
+	}
+	return false
+}
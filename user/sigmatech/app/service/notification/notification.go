@@ -0,0 +1,248 @@
+// Package notification renders templated notifications for customer events and
+// delivers them to the in-app inbox and any registered external channels.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"user/sigmatech/app/constants"
+	notificationPreferences_DBModels "user/sigmatech/app/db/dto/notification_preferences"
+	notificationTemplates_DBModels "user/sigmatech/app/db/dto/notification_templates"
+	notifications_DBModels "user/sigmatech/app/db/dto/notifications"
+	notificationDB "user/sigmatech/app/db/repository/notification"
+	notificationPreferenceDB "user/sigmatech/app/db/repository/notification_preference"
+	notificationTemplateDB "user/sigmatech/app/db/repository/notification_template"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+
+	"github.com/google/uuid"
+)
+
+// ISender delivers a rendered message through an external channel such as email or SMS.
+type ISender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type INotificationService interface {
+	Notify(ctx context.Context, recipient Recipient, event Event) error
+	RegisterSender(channel Channel, sender ISender)
+}
+
+// NotificationService is a struct that implements the INotificationService interface.
+type NotificationService struct {
+	NotificationTemplateDBClient   notificationTemplateDB.INotificationTemplateRepository
+	NotificationPreferenceDBClient notificationPreferenceDB.INotificationPreferenceRepository
+	NotificationDBClient           notificationDB.INotificationRepository
+
+	senders map[Channel]ISender // senders holds the delivery implementation of every external channel.
+}
+
+// NewNotificationService is a constructor function that creates a new NotificationService.
+// Only the in-app channel is available until a sender is registered for the other channels.
+func NewNotificationService(
+	NotificationTemplateDBClient notificationTemplateDB.INotificationTemplateRepository,
+	NotificationPreferenceDBClient notificationPreferenceDB.INotificationPreferenceRepository,
+	NotificationDBClient notificationDB.INotificationRepository,
+) *NotificationService {
+	return &NotificationService{
+		NotificationTemplateDBClient:   NotificationTemplateDBClient,
+		NotificationPreferenceDBClient: NotificationPreferenceDBClient,
+		NotificationDBClient:           NotificationDBClient,
+		senders:                        make(map[Channel]ISender),
+	}
+}
+
+// RegisterSender sets the sender used to deliver messages for an external channel.
+func (n *NotificationService) RegisterSender(channel Channel, sender ISender) {
+	n.senders[channel] = sender
+}
+
+// Notify renders the templates of the event for every channel the customer has enabled and delivers them.
+// A failing channel does not stop delivery on the other channels.
+func (n *NotificationService) Notify(ctx context.Context, recipient Recipient, event Event) error {
+	log := logger.Logger(ctx)
+
+	preference, err := n.getPreference(ctx, recipient.CustomerUuid)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	data["name"] = recipient.Name
+	data["email"] = recipient.Email
+
+	var failed []string
+	for _, channel := range Channels {
+		if !isChannelEnabled(preference, channel) {
+			continue
+		}
+
+		if _, ok := n.senders[channel]; channel != ChannelInApp && !ok {
+			log.Infof("no sender registered for channel %s, skipping %s notification", channel, event.Type)
+			continue
+		}
+
+		tmpl, err := n.getTemplate(ctx, event.Type, channel, preference.Locale)
+		if err != nil {
+			log.Errorf("unable to get %s template for %s: %v", channel, event.Type, err)
+			failed = append(failed, channel.String())
+			continue
+		}
+
+		if tmpl.Uuid == uuid.Nil {
+			log.Infof("no active %s template for %s, skipping", channel, event.Type)
+			continue
+		}
+
+		subject, err := Render(tmpl.Subject, data)
+		if err != nil {
+			log.Errorf("unable to render %s subject for %s: %v", channel, event.Type, err)
+			failed = append(failed, channel.String())
+			continue
+		}
+
+		body, err := Render(tmpl.Body, data)
+		if err != nil {
+			log.Errorf("unable to render %s body for %s: %v", channel, event.Type, err)
+			failed = append(failed, channel.String())
+			continue
+		}
+
+		message := Message{
+			Recipient:     recipient,
+			EventType:     event.Type,
+			Channel:       channel,
+			ReferenceUuid: event.ReferenceUuid,
+			Subject:       subject,
+			Body:          body,
+		}
+
+		if err := n.deliver(ctx, message); err != nil {
+			log.Errorf("unable to deliver %s notification for %s: %v", channel, event.Type, err)
+			failed = append(failed, channel.String())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deliver %s notification through %s", event.Type, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// deliver stores in-app messages in the customer inbox and hands the others to the channel sender.
+func (n *NotificationService) deliver(ctx context.Context, message Message) error {
+	if message.Channel != ChannelInApp {
+		return n.senders[message.Channel].Send(ctx, message)
+	}
+
+	now := time.Now()
+	data := notifications_DBModels.Notification{
+		Uuid:          uuid.New(),
+		CustomerUuid:  message.Recipient.CustomerUuid,
+		EventType:     message.EventType.String(),
+		ReferenceUuid: message.ReferenceUuid,
+		Title:         message.Subject,
+		Body:          message.Body,
+		IsRead:        util.Boolean(false),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	return n.NotificationDBClient.CreateNotification(ctx, &data)
+}
+
+// getPreference returns the stored preference of the customer, or the defaults when none is stored.
+func (n *NotificationService) getPreference(ctx context.Context, customerUuid uuid.UUID) (notificationPreferences_DBModels.NotificationPreference, error) {
+	filter := where.Eq(notificationPreferences_DBModels.COLUMN_CUSTOMER_UUID, customerUuid)
+
+	preference, err := n.NotificationPreferenceDBClient.GetNotificationPreference(ctx, filter)
+	if err != nil {
+		return preference, err
+	}
+
+	if preference.Uuid == uuid.Nil {
+		return DefaultPreference(customerUuid), nil
+	}
+
+	if preference.Locale == "" {
+		preference.Locale = constants.Config.NotificationConfig.NOTIFICATION_DEFAULT_LOCALE
+	}
+
+	return preference, nil
+}
+
+// getTemplate looks up the active template in the given locale and falls back to the default locale.
+func (n *NotificationService) getTemplate(ctx context.Context, eventType EventType, channel Channel, locale string) (notificationTemplates_DBModels.NotificationTemplate, error) {
+	defaultLocale := constants.Config.NotificationConfig.NOTIFICATION_DEFAULT_LOCALE
+
+	locales := []string{locale}
+	if locale != defaultLocale {
+		locales = append(locales, defaultLocale)
+	}
+
+	for _, l := range locales {
+		filter := where.Eq(notificationTemplates_DBModels.COLUMN_EVENT_TYPE, eventType).
+			Eq(notificationTemplates_DBModels.COLUMN_CHANNEL, channel).
+			Eq(notificationTemplates_DBModels.COLUMN_LOCALE, l).
+			Eq(notificationTemplates_DBModels.COLUMN_IS_ACTIVE, true)
+
+		tmpl, err := n.NotificationTemplateDBClient.GetNotificationTemplate(ctx, filter)
+		if err != nil {
+			return tmpl, err
+		}
+
+		if tmpl.Uuid != uuid.Nil {
+			return tmpl, nil
+		}
+	}
+
+	return notificationTemplates_DBModels.NotificationTemplate{}, nil
+}
+
+// DefaultPreference returns the preference used for customers that never changed their settings.
+func DefaultPreference(customerUuid uuid.UUID) notificationPreferences_DBModels.NotificationPreference {
+	return notificationPreferences_DBModels.NotificationPreference{
+		CustomerUuid: customerUuid,
+		Locale:       constants.Config.NotificationConfig.NOTIFICATION_DEFAULT_LOCALE,
+		InApp:        util.Boolean(true),
+		Email:        util.Boolean(true),
+		Sms:          util.Boolean(false),
+	}
+}
+
+func isChannelEnabled(preference notificationPreferences_DBModels.NotificationPreference, channel Channel) bool {
+	var enabled *bool
+	switch channel {
+	case ChannelInApp:
+		enabled = preference.InApp
+	case ChannelEmail:
+		enabled = preference.Email
+	case ChannelSMS:
+		enabled = preference.Sms
+	}
+	return enabled != nil && *enabled
+}
+
+// Render executes a notification template against the event data.
+func Render(text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New("notification").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,263 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"user/sigmatech/app/constants"
+	notificationPreferences_DBModels "user/sigmatech/app/db/dto/notification_preferences"
+	notificationTemplates_DBModels "user/sigmatech/app/db/dto/notification_templates"
+	notifications_DBModels "user/sigmatech/app/db/dto/notifications"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+	"user/sigmatech/config"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// templateRepository returns the template matching the equality conditions of the filter.
+type templateRepository struct {
+	templates []notificationTemplates_DBModels.NotificationTemplate
+}
+
+func (r *templateRepository) CreateNotificationTemplate(ctx context.Context, template *notificationTemplates_DBModels.NotificationTemplate) error {
+	return nil
+}
+
+func (r *templateRepository) GetNotificationTemplate(ctx context.Context, whr where.Filter) (notificationTemplates_DBModels.NotificationTemplate, error) {
+	for _, tmpl := range r.templates {
+		fields := map[string]interface{}{
+			notificationTemplates_DBModels.COLUMN_EVENT_TYPE: EventType(tmpl.EventType),
+			notificationTemplates_DBModels.COLUMN_CHANNEL:    Channel(tmpl.Channel),
+			notificationTemplates_DBModels.COLUMN_LOCALE:     tmpl.Locale,
+			notificationTemplates_DBModels.COLUMN_IS_ACTIVE:  *tmpl.IsActive,
+		}
+
+		matches := true
+		for _, condition := range whr.Conditions {
+			if fields[condition.Column] != condition.Value {
+				matches = false
+			}
+		}
+		if matches {
+			return tmpl, nil
+		}
+	}
+	return notificationTemplates_DBModels.NotificationTemplate{}, nil
+}
+
+func (r *templateRepository) GetNotificationTemplates(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*notificationTemplates_DBModels.NotificationTemplate, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *templateRepository) UpdateNotificationTemplate(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *templateRepository) DeleteNotificationTemplate(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// preferenceRepository holds the preference of a single customer, the zero preference when it has none.
+type preferenceRepository struct {
+	preference notificationPreferences_DBModels.NotificationPreference
+}
+
+func (r *preferenceRepository) CreateNotificationPreference(ctx context.Context, preference *notificationPreferences_DBModels.NotificationPreference) error {
+	return nil
+}
+
+func (r *preferenceRepository) GetNotificationPreference(ctx context.Context, whr where.Filter) (notificationPreferences_DBModels.NotificationPreference, error) {
+	return r.preference, nil
+}
+
+func (r *preferenceRepository) GetNotificationPreferences(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*notificationPreferences_DBModels.NotificationPreference, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *preferenceRepository) UpdateNotificationPreference(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *preferenceRepository) DeleteNotificationPreference(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// inbox keeps the in-app notifications created.
+type inbox struct {
+	notifications []notifications_DBModels.Notification
+}
+
+func (r *inbox) CreateNotification(ctx context.Context, notification *notifications_DBModels.Notification) error {
+	r.notifications = append(r.notifications, *notification)
+	return nil
+}
+
+func (r *inbox) GetNotification(ctx context.Context, whr where.Filter) (notifications_DBModels.Notification, error) {
+	return notifications_DBModels.Notification{}, nil
+}
+
+func (r *inbox) GetNotifications(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*notifications_DBModels.Notification, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *inbox) UpdateNotification(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *inbox) DeleteNotification(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// sender keeps the messages it is handed, or fails them with err.
+type sender struct {
+	err      error
+	messages []Message
+}
+
+func (s *sender) Send(ctx context.Context, message Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func newTemplate(channel Channel, locale, subject, body string) notificationTemplates_DBModels.NotificationTemplate {
+	return notificationTemplates_DBModels.NotificationTemplate{
+		Uuid:      uuid.New(),
+		EventType: EventInstallmentPaid.String(),
+		Channel:   channel.String(),
+		Locale:    locale,
+		Subject:   subject,
+		Body:      body,
+		IsActive:  util.Boolean(true),
+	}
+}
+
+func TestNotify(t *testing.T) {
+	constants.Config = &config.ServiceConfig{NotificationConfig: config.NotificationConfig{NOTIFICATION_DEFAULT_LOCALE: "id"}}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	templates := []notificationTemplates_DBModels.NotificationTemplate{
+		newTemplate(ChannelInApp, "id", "Pembayaran diterima", "Halo {{.name}}, cicilan ke-{{.term}} sudah dibayar."),
+		newTemplate(ChannelInApp, "en", "Payment received", "Hi {{.name}}, installment {{.term}} has been paid."),
+		newTemplate(ChannelEmail, "id", "Pembayaran cicilan {{.term}}", "Halo {{.name}}, terima kasih."),
+		newTemplate(ChannelSMS, "id", "", "Cicilan {{.term}} dibayar"),
+	}
+	recipient := Recipient{CustomerUuid: uuid.New(), Name: "Budi", Email: "budi@sigmatech.id"}
+	event := Event{Type: EventInstallmentPaid, Data: map[string]interface{}{"term": 2}}
+
+	tests := []struct {
+		name       string
+		preference notificationPreferences_DBModels.NotificationPreference
+		senders    map[Channel]error
+		wantInbox  []string
+		wantSent   map[Channel][]string
+		wantErr    bool
+	}{
+		{
+			name:      "Given no stored preference When notifying Then the inbox and email get the default locale",
+			senders:   map[Channel]error{ChannelEmail: nil, ChannelSMS: nil},
+			wantInbox: []string{"Halo Budi, cicilan ke-2 sudah dibayar."},
+			wantSent:  map[Channel][]string{ChannelEmail: {"Pembayaran cicilan 2"}},
+		},
+		{
+			name: "Given an english preference When notifying Then templates missing in english fall back to the default locale",
+			preference: notificationPreferences_DBModels.NotificationPreference{
+				Uuid: uuid.New(), Locale: "en", InApp: util.Boolean(true), Email: util.Boolean(true), Sms: util.Boolean(false),
+			},
+			senders:   map[Channel]error{ChannelEmail: nil},
+			wantInbox: []string{"Hi Budi, installment 2 has been paid."},
+			wantSent:  map[Channel][]string{ChannelEmail: {"Pembayaran cicilan 2"}},
+		},
+		{
+			name: "Given email and sms turned off When notifying Then only the inbox gets it",
+			preference: notificationPreferences_DBModels.NotificationPreference{
+				Uuid: uuid.New(), Locale: "id", InApp: util.Boolean(true), Email: util.Boolean(false), Sms: util.Boolean(false),
+			},
+			senders:   map[Channel]error{ChannelEmail: nil, ChannelSMS: nil},
+			wantInbox: []string{"Halo Budi, cicilan ke-2 sudah dibayar."},
+		},
+		{
+			name: "Given sms turned on without a sender When notifying Then sms is skipped without an error",
+			preference: notificationPreferences_DBModels.NotificationPreference{
+				Uuid: uuid.New(), Locale: "id", InApp: util.Boolean(false), Email: util.Boolean(false), Sms: util.Boolean(true),
+			},
+		},
+		{
+			name:      "Given a failing email sender When notifying Then the inbox still gets it and the failure is returned",
+			senders:   map[Channel]error{ChannelEmail: errors.New("smtp is down")},
+			wantInbox: []string{"Halo Budi, cicilan ke-2 sudah dibayar."},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifications := &inbox{}
+			s := NewNotificationService(&templateRepository{templates: templates}, &preferenceRepository{preference: tt.preference}, notifications)
+
+			senders := make(map[Channel]*sender)
+			for channel, err := range tt.senders {
+				senders[channel] = &sender{err: err}
+				s.RegisterSender(channel, senders[channel])
+			}
+
+			if err := s.Notify(context.Background(), recipient, event); (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var gotInbox []string
+			for _, n := range notifications.notifications {
+				if n.CustomerUuid != recipient.CustomerUuid || *n.IsRead {
+					t.Errorf("Notify() stored %+v, want an unread notification of %s", n, recipient.CustomerUuid)
+				}
+				gotInbox = append(gotInbox, n.Body)
+			}
+			if !reflect.DeepEqual(gotInbox, tt.wantInbox) {
+				t.Errorf("Notify() inbox = %q, want %q", gotInbox, tt.wantInbox)
+			}
+
+			for channel, sender := range senders {
+				var gotSent []string
+				for _, message := range sender.messages {
+					gotSent = append(gotSent, message.Subject)
+				}
+				if !reflect.DeepEqual(gotSent, tt.wantSent[channel]) {
+					t.Errorf("Notify() sent %q through %s, want %q", gotSent, channel, tt.wantSent[channel])
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Given a template with fields When rendering Then the data fills them", text: "Kontrak {{.contract_number}} sebesar {{.amount_paid}}", want: "Kontrak TX_000001 sebesar 1500000"},
+		{name: "Given a template without fields When rendering Then it is returned as is", text: "Pembayaran diterima", want: "Pembayaran diterima"},
+		{name: "Given a malformed template When rendering Then it fails", text: "Kontrak {{.contract_number", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.text, map[string]interface{}{"contract_number": "TX_000001", "amount_paid": 1500000})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package passwordreset
+
+import "errors"
+
+const (
+	// otpLength is the number of digits of a reset OTP.
+	otpLength = 6
+
+	mailSubject = "Reset your password"
+	mailBody    = "Hi %s,\n\nUse %s to reset your password. The code expires in %d minutes.\n\nIf you didn't ask for a password reset, ignore this email, your password won't change.\n"
+)
+
+// ErrInvalidOtp is returned for a wrong, expired or used OTP alike, and for an unknown email, so the
+// response doesn't tell which accounts exist.
+var ErrInvalidOtp = errors.New("invalid or expired otp")
@@ -0,0 +1,187 @@
+// Package passwordreset lets admin users who forgot their password set a new one with a one time
+// password emailed to them.
+package passwordreset
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"user/sigmatech/app/constants"
+	passwordResets_DBModels "user/sigmatech/app/db/dto/password_resets"
+	users_DBModels "user/sigmatech/app/db/dto/users"
+	passwordResetDB "user/sigmatech/app/db/repository/password_reset"
+	userDB "user/sigmatech/app/db/repository/user"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/mailer"
+	"user/sigmatech/app/service/util"
+
+	"github.com/google/uuid"
+)
+
+// ISessionRevoker ends every session of a user.
+type ISessionRevoker interface {
+	RevokeSessions(ctx context.Context, userUuid uuid.UUID) error
+}
+
+type IPasswordResetService interface {
+	// Request emails a reset OTP when the email belongs to a user. It returns nil whether it does or
+	// not, and when the email already got too many OTPs lately.
+	Request(ctx context.Context, email string) error
+	// Reset sets the password when the OTP matches the latest one sent to the email, then ends every
+	// session of the user.
+	Reset(ctx context.Context, email, otp, password string) error
+}
+
+// PasswordResetService is a struct that implements the IPasswordResetService interface.
+type PasswordResetService struct {
+	UserDBClient          userDB.IUserRepository
+	PasswordResetDBClient passwordResetDB.IPasswordResetRepository
+	Mailer                mailer.IMailer
+	Sessions              ISessionRevoker
+}
+
+// NewPasswordResetService is a constructor function that creates a new PasswordResetService.
+func NewPasswordResetService(
+	UserDBClient userDB.IUserRepository,
+	PasswordResetDBClient passwordResetDB.IPasswordResetRepository,
+	Mailer mailer.IMailer,
+	Sessions ISessionRevoker,
+) *PasswordResetService {
+	return &PasswordResetService{
+		UserDBClient:          UserDBClient,
+		PasswordResetDBClient: PasswordResetDBClient,
+		Mailer:                Mailer,
+		Sessions:              Sessions,
+	}
+}
+
+func (s *PasswordResetService) Request(ctx context.Context, email string) error {
+	log := logger.Logger(ctx)
+
+	user, err := s.getUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user.Uuid == uuid.Nil {
+		return nil
+	}
+
+	now := time.Now()
+	window := time.Duration(constants.Config.PasswordResetConfig.PASSWORD_RESET_WINDOW) * time.Second
+
+	sent, err := s.PasswordResetDBClient.CountPasswordResets(ctx, where.Eq(passwordResets_DBModels.COLUMN_SUBJECT_TYPE, passwordResets_DBModels.SUBJECT_USER).
+		Eq(passwordResets_DBModels.COLUMN_EMAIL, user.Email).
+		Gt(passwordResets_DBModels.COLUMN_CREATED_AT, now.Add(-window).Format("2006-01-02 15:04:05")))
+	if err != nil {
+		return err
+	}
+	if sent >= constants.Config.PasswordResetConfig.PASSWORD_RESET_LIMIT {
+		log.Warnf("password reset of user %s is rate limited, %d otps sent in the last %s", user.Uuid, sent, window)
+		return nil
+	}
+
+	otp, err := util.GenerateOTP(otpLength)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(constants.Config.PasswordResetConfig.PASSWORD_RESET_TTL) * time.Second
+
+	data := passwordResets_DBModels.PasswordReset{
+		Uuid:        uuid.New(),
+		SubjectUuid: user.Uuid,
+		SubjectType: passwordResets_DBModels.SUBJECT_USER,
+		Email:       user.Email,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	data.OtpHash = hashOtp(data.Uuid, otp)
+
+	if err := s.PasswordResetDBClient.CreatePasswordReset(ctx, &data); err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(ctx, mailer.Mail{
+		To:      user.Email,
+		Subject: mailSubject,
+		Body:    fmt.Sprintf(mailBody, user.Name, otp, int(ttl.Minutes())),
+	})
+}
+
+func (s *PasswordResetService) Reset(ctx context.Context, email, otp, password string) error {
+	user, err := s.getUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user.Uuid == uuid.Nil {
+		return ErrInvalidOtp
+	}
+
+	pending := where.Eq(passwordResets_DBModels.COLUMN_SUBJECT_TYPE, passwordResets_DBModels.SUBJECT_USER).
+		Eq(passwordResets_DBModels.COLUMN_SUBJECT_UUID, user.Uuid).
+		IsNull(passwordResets_DBModels.COLUMN_CONSUMED_AT)
+
+	reset, err := s.PasswordResetDBClient.GetPasswordReset(ctx, pending)
+	if err != nil {
+		return err
+	}
+	maxAttempts := constants.Config.PasswordResetConfig.PASSWORD_RESET_MAX_ATTEMPTS
+	if reset.Uuid == uuid.Nil || time.Now().After(reset.ExpiresAt) || reset.Attempts >= maxAttempts {
+		return ErrInvalidOtp
+	}
+
+	// The attempt is counted before the OTP is checked, so parallel guesses can't get past the limit
+	counted, err := s.PasswordResetDBClient.AddPasswordResetAttempt(ctx, where.Eq(passwordResets_DBModels.COLUM_UUID, reset.Uuid).
+		IsNull(passwordResets_DBModels.COLUMN_CONSUMED_AT), maxAttempts)
+	if err != nil {
+		return err
+	}
+	if counted == 0 {
+		return ErrInvalidOtp
+	}
+
+	if subtle.ConstantTimeCompare([]byte(reset.OtpHash), []byte(hashOtp(reset.Uuid, otp))) != 1 {
+		return ErrInvalidOtp
+	}
+
+	// Consuming every pending reset voids the older OTPs too, the one matched is only counted once
+	consumed, err := s.PasswordResetDBClient.UpdatePasswordReset(ctx, pending, map[string]interface{}{
+		passwordResets_DBModels.COLUMN_CONSUMED_AT: time.Now(),
+		passwordResets_DBModels.COLUMN_UPDATED_AT:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if consumed == 0 {
+		return ErrInvalidOtp
+	}
+
+	hashedPassword, err := util.GenerateHash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.UserDBClient.UpdateUser(ctx, where.Eq(users_DBModels.COLUM_UUID, user.Uuid), map[string]interface{}{
+		users_DBModels.COLUMN_PASSWORD:   hashedPassword,
+		users_DBModels.COLUMN_UPDATED_AT: time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	return s.Sessions.RevokeSessions(ctx, user.Uuid)
+}
+
+func (s *PasswordResetService) getUser(ctx context.Context, email string) (users_DBModels.User, error) {
+	return s.UserDBClient.GetUser(ctx, where.Eq(users_DBModels.COLUMN_EMAIL, email))
+}
+
+// hashOtp binds the OTP to its reset, so the stored hash can't be matched against other resets.
+func hashOtp(resetUuid uuid.UUID, otp string) string {
+	sum := sha256.Sum256([]byte(resetUuid.String() + ":" + otp))
+	return hex.EncodeToString(sum[:])
+}
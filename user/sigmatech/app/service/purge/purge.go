@@ -0,0 +1,106 @@
+// Package purge hard deletes the customers and users that were soft deleted longer ago than the retention,
+// along with the refresh tokens and sessions that expired before it and the sign-in throttles without
+// failures since the lockouts were last reset.
+package purge
+
+import (
+	"context"
+	"time"
+	"user/sigmatech/app/constants"
+	customerDB "user/sigmatech/app/db/repository/customer"
+	loginThrottleDB "user/sigmatech/app/db/repository/login_throttle"
+	refreshTokenDB "user/sigmatech/app/db/repository/refresh_token"
+	sessionDB "user/sigmatech/app/db/repository/session"
+	userDB "user/sigmatech/app/db/repository/user"
+	"user/sigmatech/app/service/logger"
+)
+
+type IPurgeService interface {
+	// Purge hard deletes the rows soft deleted before the retention and returns how many were deleted.
+	Purge(ctx context.Context) (int64, error)
+	// Run purges on every interval until the context is cancelled.
+	Run(ctx context.Context)
+}
+
+// PurgeService is a struct that implements the IPurgeService interface.
+type PurgeService struct {
+	CustomerDBClient      customerDB.ICustomerRepository
+	UserDBClient          userDB.IUserRepository
+	RefreshTokenDBClient  refreshTokenDB.IRefreshTokenRepository
+	LoginThrottleDBClient loginThrottleDB.ILoginThrottleRepository
+	SessionDBClient       sessionDB.ISessionRepository
+}
+
+// NewPurgeService is a constructor function that creates a new PurgeService.
+func NewPurgeService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	UserDBClient userDB.IUserRepository,
+	RefreshTokenDBClient refreshTokenDB.IRefreshTokenRepository,
+	LoginThrottleDBClient loginThrottleDB.ILoginThrottleRepository,
+	SessionDBClient sessionDB.ISessionRepository,
+) *PurgeService {
+	return &PurgeService{
+		CustomerDBClient:      CustomerDBClient,
+		UserDBClient:          UserDBClient,
+		RefreshTokenDBClient:  RefreshTokenDBClient,
+		LoginThrottleDBClient: LoginThrottleDBClient,
+		SessionDBClient:       SessionDBClient,
+	}
+}
+
+func (s *PurgeService) Purge(ctx context.Context) (int64, error) {
+	deletedBefore := time.Now().AddDate(0, 0, -constants.Config.PurgeConfig.PURGE_RETENTION_DAYS)
+
+	customers, err := s.CustomerDBClient.PurgeCustomers(ctx, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	users, err := s.UserDBClient.PurgeUsers(ctx, deletedBefore)
+	if err != nil {
+		return customers, err
+	}
+
+	refreshTokens, err := s.RefreshTokenDBClient.PurgeRefreshTokens(ctx, deletedBefore)
+	if err != nil {
+		return customers + users, err
+	}
+
+	failedBefore := time.Now().Add(-time.Duration(constants.Config.LoginLockoutConfig.LOGIN_LOCKOUT_RESET) * time.Second)
+
+	loginThrottles, err := s.LoginThrottleDBClient.PurgeLoginThrottles(ctx, failedBefore)
+	if err != nil {
+		return customers + users + refreshTokens, err
+	}
+
+	sessions, err := s.SessionDBClient.PurgeSessions(ctx, deletedBefore)
+	if err != nil {
+		return customers + users + refreshTokens + loginThrottles, err
+	}
+
+	return customers + users + refreshTokens + loginThrottles + sessions, nil
+}
+
+// Run is started once per instance, purging is idempotent so instances don't need to coordinate.
+func (s *PurgeService) Run(ctx context.Context) {
+	log := logger.Logger(ctx)
+
+	ticker := time.NewTicker(time.Duration(constants.Config.PurgeConfig.PURGE_INTERVAL) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.Purge(ctx)
+			if err != nil {
+				log.Errorf("unable to purge deleted rows: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Infof("purged %d deleted rows", purged)
+			}
+		}
+	}
+}
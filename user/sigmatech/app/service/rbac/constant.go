@@ -0,0 +1,52 @@
+package rbac
+
+import "errors"
+
+// Roles seeded by the roles migration
+const (
+	ROLE_SUPER_ADMIN     = "super_admin"
+	ROLE_CREDIT_APPROVER = "credit_approver"
+	ROLE_COLLECTIONS     = "collections"
+	ROLE_VIEWER          = "viewer"
+)
+
+// Permissions are <area>.<action>. A role with PERMISSION_ALL has every permission,
+// and one with <area>.* every permission of the area.
+const (
+	PERMISSION_ALL = "*"
+
+	PERMISSION_USER_READ  = "user.read"
+	PERMISSION_USER_WRITE = "user.write"
+
+	PERMISSION_CUSTOMER_READ    = "customer.read"
+	PERMISSION_CUSTOMER_WRITE   = "customer.write"
+	PERMISSION_CUSTOMER_APPROVE = "customer.approve"
+
+	PERMISSION_TRANSACTION_READ = "transaction.read"
+
+	PERMISSION_NOTIFICATION_READ  = "notification.read"
+	PERMISSION_NOTIFICATION_WRITE = "notification.write"
+
+	PERMISSION_WEBHOOK_READ  = "webhook.read"
+	PERMISSION_WEBHOOK_WRITE = "webhook.write"
+
+	PERMISSION_MERCHANT_READ  = "merchant.read"
+	PERMISSION_MERCHANT_WRITE = "merchant.write"
+
+	PERMISSION_RECONCILIATION_READ  = "reconciliation.read"
+	PERMISSION_RECONCILIATION_WRITE = "reconciliation.write"
+
+	PERMISSION_COLLECTION_READ  = "collection.read"
+	PERMISSION_COLLECTION_WRITE = "collection.write"
+
+	PERMISSION_ANALYTICS_READ = "analytics.read"
+
+	PERMISSION_EXPORT_READ = "export.read"
+
+	PERMISSION_AUDIT_READ = "audit.read"
+)
+
+var (
+	ErrUnknownRole      = errors.New("unknown role")
+	ErrRoleNotGrantable = errors.New("cannot grant a role with permissions you don't have")
+)
@@ -0,0 +1,229 @@
+// Package rbac resolves the roles and permissions of admin users.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"user/sigmatech/app/constants"
+	roles_DBModels "user/sigmatech/app/db/dto/roles"
+	roleDB "user/sigmatech/app/db/repository/role"
+	"user/sigmatech/app/db/where"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Access is what a user may do, it is carried by the access token.
+type Access struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	// MfaRequired is set when a role of the user requires a second factor, MfaVerified when the
+	// session was signed in with one.
+	MfaRequired bool `json:"mfa_required"`
+	MfaVerified bool `json:"mfa_verified"`
+}
+
+// MfaPending reports whether the permissions are withheld until the user signs in with a second factor.
+func (a *Access) MfaPending() bool {
+	return a != nil && a.MfaRequired && !a.MfaVerified
+}
+
+// Can reports whether the access grants the permission, directly or through a wildcard.
+func (a *Access) Can(permission string) bool {
+	if a == nil {
+		return false
+	}
+
+	area := permission
+	if i := strings.Index(permission, "."); i >= 0 {
+		area = permission[:i]
+	}
+
+	for _, granted := range a.Permissions {
+		if granted == PERMISSION_ALL || granted == permission || granted == area+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAll reports whether the access grants every one of the permissions.
+func (a *Access) CanAll(permissions ...string) bool {
+	for _, permission := range permissions {
+		if !a.Can(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetAccess returns the roles and permissions set by the authentication middleware, nil when there are none.
+func GetAccess(c *gin.Context) *Access {
+	if v, ok := c.Get(constants.CTK_ACCESS_KEY.String()); ok {
+		if access, ok := v.(*Access); ok {
+			return access
+		}
+	}
+	return nil
+}
+
+// RoleDetail is a role with its permissions.
+type RoleDetail struct {
+	roles_DBModels.Role
+	Permissions []string `json:"permissions"`
+}
+
+type IRbacService interface {
+	// GetAccess returns the roles of a user and the permissions they grant.
+	GetAccess(ctx context.Context, userUuid uuid.UUID) (*Access, error)
+	// GetRoles returns every role with its permissions.
+	GetRoles(ctx context.Context) ([]*RoleDetail, error)
+	// ResolveRoles returns the roles of the codes, checking the actor may grant them: the actor may
+	// only grant roles whose permissions they have themselves.
+	ResolveRoles(ctx context.Context, codes []string, actor *Access) ([]*RoleDetail, error)
+	// SetUserRoles replaces the roles of a user.
+	SetUserRoles(ctx context.Context, userUuid uuid.UUID, roles []*RoleDetail, actorUuid *uuid.UUID) error
+}
+
+// RbacService is a struct that implements the IRbacService interface.
+type RbacService struct {
+	RoleDBClient roleDB.IRoleRepository
+}
+
+// NewRbacService is a constructor function that creates a new RbacService.
+func NewRbacService(RoleDBClient roleDB.IRoleRepository) *RbacService {
+	return &RbacService{
+		RoleDBClient: RoleDBClient,
+	}
+}
+
+func (s *RbacService) GetAccess(ctx context.Context, userUuid uuid.UUID) (*Access, error) {
+	roles, err := s.RoleDBClient.GetUserRoles(ctx, userUuid)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := s.withPermissions(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &Access{Roles: []string{}, Permissions: []string{}}
+	seen := make(map[string]bool)
+	for _, role := range details {
+		access.Roles = append(access.Roles, role.Code)
+		access.MfaRequired = access.MfaRequired || role.MfaRequired
+		for _, permission := range role.Permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				access.Permissions = append(access.Permissions, permission)
+			}
+		}
+	}
+
+	return access, nil
+}
+
+func (s *RbacService) GetRoles(ctx context.Context) ([]*RoleDetail, error) {
+	roles, err := s.RoleDBClient.GetRoles(ctx, where.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withPermissions(ctx, roles)
+}
+
+func (s *RbacService) ResolveRoles(ctx context.Context, codes []string, actor *Access) ([]*RoleDetail, error) {
+	codes = normalizeCodes(codes)
+	if len(codes) == 0 {
+		return []*RoleDetail{}, nil
+	}
+
+	found, err := s.RoleDBClient.GetRoles(ctx, where.In(roles_DBModels.COLUMN_CODE, codes))
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.withPermissions(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+
+	if missing := missingCodes(codes, roles); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRole, strings.Join(missing, ", "))
+	}
+
+	for _, role := range roles {
+		if !actor.CanAll(role.Permissions...) {
+			return nil, fmt.Errorf("%w: %s", ErrRoleNotGrantable, role.Code)
+		}
+	}
+
+	return roles, nil
+}
+
+func (s *RbacService) SetUserRoles(ctx context.Context, userUuid uuid.UUID, roles []*RoleDetail, actorUuid *uuid.UUID) error {
+	roleUuids := make([]uuid.UUID, len(roles))
+	for i, role := range roles {
+		roleUuids[i] = role.Uuid
+	}
+
+	return s.RoleDBClient.SetUserRoles(ctx, userUuid, roleUuids, actorUuid)
+}
+
+// withPermissions loads the permissions of the roles.
+func (s *RbacService) withPermissions(ctx context.Context, roles []*roles_DBModels.Role) ([]*RoleDetail, error) {
+	roleUuids := make([]uuid.UUID, len(roles))
+	details := make([]*RoleDetail, len(roles))
+	byUuid := make(map[uuid.UUID]*RoleDetail, len(roles))
+	for i, role := range roles {
+		roleUuids[i] = role.Uuid
+		details[i] = &RoleDetail{Role: *role, Permissions: []string{}}
+		byUuid[role.Uuid] = details[i]
+	}
+
+	permissions, err := s.RoleDBClient.GetRolePermissions(ctx, roleUuids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		if role, ok := byUuid[permission.RoleUuid]; ok {
+			role.Permissions = append(role.Permissions, permission.Permission)
+		}
+	}
+
+	return details, nil
+}
+
+// normalizeCodes trims, lowercases and deduplicates role codes.
+func normalizeCodes(codes []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	return normalized
+}
+
+// missingCodes returns the codes that have no role.
+func missingCodes(codes []string, roles []*RoleDetail) []string {
+	found := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		found[role.Code] = true
+	}
+
+	var missing []string
+	for _, code := range codes {
+		if !found[code] {
+			missing = append(missing, code)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,135 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	rolePermissions_DBModels "user/sigmatech/app/db/dto/role_permissions"
+	roles_DBModels "user/sigmatech/app/db/dto/roles"
+	"user/sigmatech/app/db/where"
+
+	"github.com/google/uuid"
+)
+
+func TestAccessCan(t *testing.T) {
+	tests := []struct {
+		name       string
+		access     *Access
+		permission string
+		want       bool
+	}{
+		{
+			name:       "Given the permission When checking Then it is granted",
+			access:     &Access{Permissions: []string{PERMISSION_CUSTOMER_READ}},
+			permission: PERMISSION_CUSTOMER_READ,
+			want:       true,
+		},
+		{
+			name:       "Given another permission of the area When checking Then it is denied",
+			access:     &Access{Permissions: []string{PERMISSION_CUSTOMER_READ}},
+			permission: PERMISSION_CUSTOMER_APPROVE,
+			want:       false,
+		},
+		{
+			name:       "Given the wildcard of the area When checking Then it is granted",
+			access:     &Access{Permissions: []string{"customer.*"}},
+			permission: PERMISSION_CUSTOMER_APPROVE,
+			want:       true,
+		},
+		{
+			name:       "Given the wildcard When checking Then it is granted",
+			access:     &Access{Permissions: []string{PERMISSION_ALL}},
+			permission: PERMISSION_AUDIT_READ,
+			want:       true,
+		},
+		{
+			name:       "Given no access When checking Then it is denied",
+			access:     nil,
+			permission: PERMISSION_USER_READ,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.Can(tt.permission); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+// roleRepository is an in memory role repository.
+type roleRepository struct {
+	roles       []*roles_DBModels.Role
+	permissions []*rolePermissions_DBModels.RolePermission
+}
+
+func (r *roleRepository) GetRole(ctx context.Context, whr where.Filter) (roles_DBModels.Role, error) {
+	return roles_DBModels.Role{}, nil
+}
+
+// GetRoles ignores whr, ResolveRoles reports the codes that aren't returned.
+func (r *roleRepository) GetRoles(ctx context.Context, whr where.Filter) ([]*roles_DBModels.Role, error) {
+	return r.roles, nil
+}
+
+func (r *roleRepository) GetRolePermissions(ctx context.Context, roleUuids []uuid.UUID) ([]*rolePermissions_DBModels.RolePermission, error) {
+	return r.permissions, nil
+}
+
+func (r *roleRepository) GetUserRoles(ctx context.Context, userUuid uuid.UUID) ([]*roles_DBModels.Role, error) {
+	return r.roles, nil
+}
+
+func (r *roleRepository) SetUserRoles(ctx context.Context, userUuid uuid.UUID, roleUuids []uuid.UUID, createdBy *uuid.UUID) error {
+	return nil
+}
+
+func TestResolveRoles(t *testing.T) {
+	viewer := &roles_DBModels.Role{Uuid: uuid.New(), Code: ROLE_VIEWER}
+	s := NewRbacService(&roleRepository{
+		roles: []*roles_DBModels.Role{viewer},
+		permissions: []*rolePermissions_DBModels.RolePermission{
+			{RoleUuid: viewer.Uuid, Permission: PERMISSION_CUSTOMER_READ},
+			{RoleUuid: viewer.Uuid, Permission: PERMISSION_AUDIT_READ},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		codes   []string
+		actor   *Access
+		wantErr error
+	}{
+		{
+			name:  "Given an actor with every permission of the role When resolving Then the role is returned",
+			codes: []string{" Viewer ", ROLE_VIEWER},
+			actor: &Access{Permissions: []string{PERMISSION_ALL}},
+		},
+		{
+			name:    "Given an actor missing a permission of the role When resolving Then ErrRoleNotGrantable is returned",
+			codes:   []string{ROLE_VIEWER},
+			actor:   &Access{Permissions: []string{PERMISSION_CUSTOMER_READ, PERMISSION_USER_WRITE}},
+			wantErr: ErrRoleNotGrantable,
+		},
+		{
+			name:    "Given an unknown code When resolving Then ErrUnknownRole is returned",
+			codes:   []string{ROLE_VIEWER, "owner"},
+			actor:   &Access{Permissions: []string{PERMISSION_ALL}},
+			wantErr: ErrUnknownRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := s.ResolveRoles(context.Background(), tt.codes, tt.actor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveRoles() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(roles) != 1 || roles[0].Code != ROLE_VIEWER) {
+				t.Errorf("ResolveRoles() = %v, want the viewer role", roles)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package reconciliation
+
+import "errors"
+
+const (
+	// Status of an import job
+	JOB_STATUS_PENDING   = "pending"
+	JOB_STATUS_RUNNING   = "running"
+	JOB_STATUS_COMPLETED = "completed"
+	JOB_STATUS_FAILED    = "failed"
+
+	// Status of an imported row
+	ROW_STATUS_MATCHED   = "matched"   // applied to an installment automatically
+	ROW_STATUS_REVIEW    = "review"    // partial or ambiguous, waiting for finance
+	ROW_STATUS_UNMATCHED = "unmatched" // no open installment found
+	ROW_STATUS_DUPLICATE = "duplicate" // the mutation was already applied by an earlier import or a payment callback
+	ROW_STATUS_INVALID   = "invalid"   // the row could not be parsed
+	ROW_STATUS_RESOLVED  = "resolved"  // applied by finance from the review queue
+	ROW_STATUS_DISMISSED = "dismissed" // discarded by finance from the review queue
+
+	// METHOD_PAYMENT_BANK_TRANSFER is stored in method_payment of installments paid through reconciliation
+	METHOD_PAYMENT_BANK_TRANSFER = "bank_transfer:reconciliation"
+
+	// amountTolerance absorbs rounding of installment amounts when comparing them to paid amounts
+	amountTolerance = 0.01
+)
+
+var (
+	ErrInvalidLayout          = errors.New("invalid reconciliation layout")
+	ErrRowNotFound            = errors.New("reconciliation row not found")
+	ErrRowNotInReview         = errors.New("reconciliation row is not waiting for review")
+	ErrInstallmentNotFound    = errors.New("installment not found")
+	ErrInstallmentAlreadyPaid = errors.New("installment has already been paid")
+	ErrAmbiguousResolution    = errors.New("the row has several candidates, choose an installment")
+	ErrRowAlreadyApplied      = errors.New("the mutation of the row has already been applied by another row")
+)
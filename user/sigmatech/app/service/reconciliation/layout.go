@@ -0,0 +1,78 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"fmt"
+	"user/sigmatech/app/constants"
+)
+
+// Layout describes the columns of a bank mutation file. Columns are zero based, -1 when the
+// file does not have them. The amount and at least one of the VA and contract number are required.
+type Layout struct {
+	Delimiter            string `json:"delimiter"`
+	SkipRows             int    `json:"skip_rows"`
+	DateColumn           int    `json:"date_column"`
+	DescriptionColumn    int    `json:"description_column"`
+	ReferenceColumn      int    `json:"reference_column"`
+	VaNumberColumn       int    `json:"va_number_column"`
+	ContractNumberColumn int    `json:"contract_number_column"`
+	AmountColumn         int    `json:"amount_column"`
+	DateFormat           string `json:"date_format"`
+	DecimalSeparator     string `json:"decimal_separator"`
+}
+
+// DefaultLayout returns the layout configured with the RECONCILIATION_* variables.
+func DefaultLayout() Layout {
+	cfg := constants.Config.ReconciliationConfig
+	return Layout{
+		Delimiter:            cfg.RECONCILIATION_DELIMITER,
+		SkipRows:             cfg.RECONCILIATION_SKIP_ROWS,
+		DateColumn:           cfg.RECONCILIATION_DATE_COLUMN,
+		DescriptionColumn:    cfg.RECONCILIATION_DESCRIPTION_COLUMN,
+		ReferenceColumn:      cfg.RECONCILIATION_REFERENCE_COLUMN,
+		VaNumberColumn:       cfg.RECONCILIATION_VA_NUMBER_COLUMN,
+		ContractNumberColumn: cfg.RECONCILIATION_CONTRACT_NUMBER_COLUMN,
+		AmountColumn:         cfg.RECONCILIATION_AMOUNT_COLUMN,
+		DateFormat:           cfg.RECONCILIATION_DATE_FORMAT,
+		DecimalSeparator:     cfg.RECONCILIATION_DECIMAL_SEPARATOR,
+	}
+}
+
+// ParseLayout overrides the fields of base given in the JSON document s.
+func ParseLayout(base Layout, s string) (Layout, error) {
+	if s == "" {
+		return base, base.Validate()
+	}
+
+	if err := json.Unmarshal([]byte(s), &base); err != nil {
+		return Layout{}, fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+	}
+	return base, base.Validate()
+}
+
+func (l Layout) Validate() error {
+	if len([]rune(l.Delimiter)) != 1 {
+		return fmt.Errorf("%w: delimiter must be a single character", ErrInvalidLayout)
+	}
+	if l.DecimalSeparator != "." && l.DecimalSeparator != "," {
+		return fmt.Errorf("%w: decimal separator must be . or ,", ErrInvalidLayout)
+	}
+	if l.SkipRows < 0 {
+		return fmt.Errorf("%w: skip rows can't be negative", ErrInvalidLayout)
+	}
+	if l.AmountColumn < 0 {
+		return fmt.Errorf("%w: amount column is required", ErrInvalidLayout)
+	}
+	if l.VaNumberColumn < 0 && l.ContractNumberColumn < 0 {
+		return fmt.Errorf("%w: a va number or contract number column is required", ErrInvalidLayout)
+	}
+	if l.DateColumn >= 0 && l.DateFormat == "" {
+		return fmt.Errorf("%w: date format is required with a date column", ErrInvalidLayout)
+	}
+	return nil
+}
+
+func (l Layout) String() string {
+	b, _ := json.Marshal(l)
+	return string(b)
+}
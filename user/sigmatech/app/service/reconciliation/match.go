@@ -0,0 +1,76 @@
+package reconciliation
+
+import (
+	"fmt"
+	"math"
+	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+
+	"github.com/google/uuid"
+)
+
+// Match is the outcome of matching a mutation to the open installments it may pay.
+type Match struct {
+	Status      string
+	Reason      string
+	Installment *transaction_installments_DBModels.TransactionInstallment
+	Candidates  []uuid.UUID
+}
+
+// decide picks the installment paid by amount among the open installments found for a mutation,
+// ordered by due date. Only the next due installment of each contract is considered: the amount
+// must cover exactly what is outstanding on it and only one contract may qualify.
+func decide(amount float64, open []*transaction_installments_DBModels.TransactionInstallment) Match {
+	if len(open) == 0 {
+		return Match{Status: ROW_STATUS_UNMATCHED, Reason: "no open installment for the va number or contract number"}
+	}
+
+	var next []*transaction_installments_DBModels.TransactionInstallment
+	seen := make(map[uuid.UUID]bool)
+	for _, installment := range open {
+		if seen[installment.TransactionUuid] {
+			continue
+		}
+		seen[installment.TransactionUuid] = true
+		next = append(next, installment)
+	}
+
+	var exact []*transaction_installments_DBModels.TransactionInstallment
+	candidates := make([]uuid.UUID, 0, len(next))
+	for _, installment := range next {
+		candidates = append(candidates, installment.Uuid)
+		if math.Abs(outstanding(installment)-amount) <= amountTolerance {
+			exact = append(exact, installment)
+		}
+	}
+
+	switch {
+	case len(exact) == 1:
+		return Match{Status: ROW_STATUS_MATCHED, Installment: exact[0], Candidates: []uuid.UUID{exact[0].Uuid}}
+	case len(exact) > 1:
+		return Match{Status: ROW_STATUS_REVIEW, Reason: fmt.Sprintf("amount matches the next installment of %d contracts", len(exact)), Candidates: candidates}
+	case len(next) == 1 && amount < outstanding(next[0]):
+		return Match{Status: ROW_STATUS_REVIEW, Reason: fmt.Sprintf("partial payment of %.2f out of %.2f", amount, outstanding(next[0])), Candidates: candidates}
+	default:
+		return Match{Status: ROW_STATUS_REVIEW, Reason: "amount does not match the next open installment", Candidates: candidates}
+	}
+}
+
+// intersect keeps the installments found both by va number and by contract number.
+func intersect(a, b []*transaction_installments_DBModels.TransactionInstallment) []*transaction_installments_DBModels.TransactionInstallment {
+	in := make(map[uuid.UUID]bool, len(b))
+	for _, installment := range b {
+		in[installment.Uuid] = true
+	}
+
+	var out []*transaction_installments_DBModels.TransactionInstallment
+	for _, installment := range a {
+		if in[installment.Uuid] {
+			out = append(out, installment)
+		}
+	}
+	return out
+}
+
+func outstanding(installment *transaction_installments_DBModels.TransactionInstallment) float64 {
+	return installment.Amount - installment.AmountPaid
+}
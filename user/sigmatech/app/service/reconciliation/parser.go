@@ -0,0 +1,138 @@
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row is one parsed mutation line. Err is set when the line could not be parsed.
+type Row struct {
+	Number         int // line number in the file, one based
+	Date           *time.Time
+	Reference      string
+	Description    string
+	VaNumber       string
+	ContractNumber string
+	Amount         float64
+	Raw            string
+	Err            error
+}
+
+// Parse reads the mutation lines of a CSV file laid out as described by layout. Lines that don't
+// parse are returned with Err set, so they end up in the report instead of failing the import.
+func Parse(data []byte, layout Layout) ([]Row, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = []rune(layout.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Number: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		// encoding/csv skips empty lines, so the line number has to come from the reader
+		number, _ := reader.FieldPos(0)
+
+		if number <= layout.SkipRows || isBlank(record) {
+			continue
+		}
+
+		rows = append(rows, parseRow(number, record, layout))
+	}
+
+	return rows, nil
+}
+
+func parseRow(number int, record []string, layout Layout) Row {
+	row := Row{
+		Number:         number,
+		Raw:            strings.Join(record, layout.Delimiter),
+		Reference:      column(record, layout.ReferenceColumn),
+		Description:    column(record, layout.DescriptionColumn),
+		VaNumber:       column(record, layout.VaNumberColumn),
+		ContractNumber: column(record, layout.ContractNumberColumn),
+	}
+
+	if layout.AmountColumn >= len(record) {
+		row.Err = fmt.Errorf("amount column %d is missing", layout.AmountColumn)
+		return row
+	}
+
+	amount, err := parseAmount(record[layout.AmountColumn], layout.DecimalSeparator)
+	if err != nil {
+		row.Err = err
+		return row
+	}
+	if amount <= 0 {
+		row.Err = errors.New("amount is not a credit")
+		return row
+	}
+	row.Amount = amount
+
+	if value := column(record, layout.DateColumn); value != "" {
+		date, err := time.Parse(layout.DateFormat, value)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid date %q", value)
+			return row
+		}
+		row.Date = &date
+	}
+
+	if row.VaNumber == "" && row.ContractNumber == "" {
+		row.Err = errors.New("va number and contract number are empty")
+	}
+
+	return row
+}
+
+// parseAmount parses amounts such as "1.500.000,00" or "1,500,000.00" depending on the decimal separator.
+func parseAmount(value, decimalSeparator string) (float64, error) {
+	thousandSeparator := ","
+	if decimalSeparator == "," {
+		thousandSeparator = "."
+	}
+
+	normalized := strings.TrimSpace(value)
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	normalized = strings.ReplaceAll(normalized, thousandSeparator, "")
+	normalized = strings.Replace(normalized, decimalSeparator, ".", 1)
+
+	amount, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
+
+func column(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
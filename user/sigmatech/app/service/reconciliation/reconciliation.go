@@ -0,0 +1,602 @@
+// Package reconciliation imports bank mutation files and matches their lines to open installments.
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	customers_DBModels "user/sigmatech/app/db/dto/customers"
+	paymentCallbacks_DBModels "user/sigmatech/app/db/dto/payment_callbacks"
+	reconciliationJobs_DBModels "user/sigmatech/app/db/dto/reconciliation_jobs"
+	reconciliationRows_DBModels "user/sigmatech/app/db/dto/reconciliation_rows"
+	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+	transactions_DBModels "user/sigmatech/app/db/dto/transactions"
+	virtualAccounts_DBModels "user/sigmatech/app/db/dto/virtual_accounts"
+	customerDB "user/sigmatech/app/db/repository/customer"
+	paymentCallbackDB "user/sigmatech/app/db/repository/payment_callback"
+	reconciliationJobDB "user/sigmatech/app/db/repository/reconciliation_job"
+	reconciliationRowDB "user/sigmatech/app/db/repository/reconciliation_row"
+	transactionDB "user/sigmatech/app/db/repository/transaction"
+	transactionInstallmentDB "user/sigmatech/app/db/repository/transaction_installment"
+	virtualAccountDB "user/sigmatech/app/db/repository/virtual_account"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/notification"
+	"user/sigmatech/app/service/util"
+	"user/sigmatech/app/service/webhook"
+
+	"github.com/google/uuid"
+)
+
+type IReconciliationService interface {
+	// Import creates the job for a mutation file and reconciles it in the background.
+	Import(ctx context.Context, fileName string, data []byte, layout Layout, createdBy *uuid.UUID) (reconciliationJobs_DBModels.ReconciliationJob, error)
+	// Resolve applies a row from the review queue to the given installment, or to its only candidate.
+	Resolve(ctx context.Context, rowUuid uuid.UUID, installmentUuid *uuid.UUID, resolvedBy uuid.UUID) (reconciliationRows_DBModels.ReconciliationRow, error)
+	// Dismiss takes a row out of the review queue without applying it.
+	Dismiss(ctx context.Context, rowUuid uuid.UUID, reason string, resolvedBy uuid.UUID) (reconciliationRows_DBModels.ReconciliationRow, error)
+}
+
+// ReconciliationService is a struct that implements the IReconciliationService interface.
+type ReconciliationService struct {
+	CustomerDBClient               customerDB.ICustomerRepository
+	TransactionDBClient            transactionDB.ITransactionRepository
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository
+	VirtualAccountDBClient         virtualAccountDB.IVirtualAccountRepository
+	PaymentCallbackDBClient        paymentCallbackDB.IPaymentCallbackRepository
+	ReconciliationJobDBClient      reconciliationJobDB.IReconciliationJobRepository
+	ReconciliationRowDBClient      reconciliationRowDB.IReconciliationRowRepository
+	Notification                   notification.INotificationService
+	Webhook                        webhook.IWebhookService
+}
+
+// NewReconciliationService is a constructor function that creates a new ReconciliationService.
+func NewReconciliationService(
+	CustomerDBClient customerDB.ICustomerRepository,
+	TransactionDBClient transactionDB.ITransactionRepository,
+	TransactionInstallmentDBClient transactionInstallmentDB.ITransactionInstallmentRepository,
+	VirtualAccountDBClient virtualAccountDB.IVirtualAccountRepository,
+	PaymentCallbackDBClient paymentCallbackDB.IPaymentCallbackRepository,
+	ReconciliationJobDBClient reconciliationJobDB.IReconciliationJobRepository,
+	ReconciliationRowDBClient reconciliationRowDB.IReconciliationRowRepository,
+	Notification notification.INotificationService,
+	Webhook webhook.IWebhookService,
+) *ReconciliationService {
+	return &ReconciliationService{
+		CustomerDBClient:               CustomerDBClient,
+		TransactionDBClient:            TransactionDBClient,
+		TransactionInstallmentDBClient: TransactionInstallmentDBClient,
+		VirtualAccountDBClient:         VirtualAccountDBClient,
+		PaymentCallbackDBClient:        PaymentCallbackDBClient,
+		ReconciliationJobDBClient:      ReconciliationJobDBClient,
+		ReconciliationRowDBClient:      ReconciliationRowDBClient,
+		Notification:                   Notification,
+		Webhook:                        Webhook,
+	}
+}
+
+func (s *ReconciliationService) Import(ctx context.Context, fileName string, data []byte, layout Layout, createdBy *uuid.UUID) (reconciliationJobs_DBModels.ReconciliationJob, error) {
+	job := reconciliationJobs_DBModels.ReconciliationJob{
+		Uuid:      uuid.New(),
+		FileName:  fileName,
+		Layout:    layout.String(),
+		Status:    JOB_STATUS_PENDING,
+		CreatedAt: time.Now(),
+		CreatedBy: createdBy,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.ReconciliationJobDBClient.CreateReconciliationJob(ctx, &job); err != nil {
+		return reconciliationJobs_DBModels.ReconciliationJob{}, err
+	}
+
+	// The request context ends with the response, the job keeps only its correlation id
+	go s.run(correlation.ContextFromCorrelation(correlation.ContextCorrelationId(ctx)), job, data, layout)
+
+	return job, nil
+}
+
+// run reconciles every line of the file and stores the totals on the job as its report.
+func (s *ReconciliationService) run(ctx context.Context, job reconciliationJobs_DBModels.ReconciliationJob, data []byte, layout Layout) {
+	log := logger.Logger(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("reconciliation job %s panicked: %v", job.Uuid, r)
+			s.finish(ctx, job, fmt.Errorf("%v", r))
+		}
+	}()
+
+	s.updateJob(ctx, job.Uuid, map[string]interface{}{
+		reconciliationJobs_DBModels.COLUMN_STATUS:     JOB_STATUS_RUNNING,
+		reconciliationJobs_DBModels.COLUMN_STARTED_AT: time.Now(),
+	})
+
+	rows, err := Parse(data, layout)
+	if err != nil {
+		s.finish(ctx, job, err)
+		return
+	}
+
+	for _, row := range rows {
+		record, err := s.reconcile(ctx, job.Uuid, row)
+		if err != nil {
+			s.finish(ctx, job, fmt.Errorf("line %d: %v", row.Number, err))
+			return
+		}
+
+		job.TotalRows++
+		switch record.Status {
+		case ROW_STATUS_MATCHED:
+			job.MatchedRows++
+			job.MatchedAmount += record.Amount
+		case ROW_STATUS_REVIEW:
+			job.ReviewRows++
+		case ROW_STATUS_UNMATCHED:
+			job.UnmatchedRows++
+		case ROW_STATUS_DUPLICATE:
+			job.DuplicateRows++
+		case ROW_STATUS_INVALID:
+			job.InvalidRows++
+		}
+	}
+
+	s.finish(ctx, job, nil)
+}
+
+// reconcile matches one line, applies it when the match is exact and stores the outcome.
+func (s *ReconciliationService) reconcile(ctx context.Context, jobUuid uuid.UUID, row Row) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	record := reconciliationRows_DBModels.ReconciliationRow{
+		Uuid:            uuid.New(),
+		JobUuid:         jobUuid,
+		RowNumber:       row.Number,
+		TransactionDate: row.Date,
+		Reference:       optional(row.Reference),
+		Description:     optional(row.Description),
+		VaNumber:        optional(row.VaNumber),
+		ContractNumber:  optional(row.ContractNumber),
+		Amount:          row.Amount,
+		Raw:             row.Raw,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	match, err := s.match(ctx, row)
+	if err != nil {
+		return record, err
+	}
+
+	describe(&record, match)
+
+	if match.Status == ROW_STATUS_MATCHED {
+		// The row is stored with the payment, so a file imported again finds it and doesn't pay twice
+		record.TransactionInstallmentUuid = &match.Installment.Uuid
+		applied, err := s.ReconciliationRowDBClient.ApplyReconciliationRow(ctx, &record, amountTolerance, paidAt(row.Date), installmentPatch())
+		switch {
+		case util.IsUniqueViolation(err):
+			match = Match{Status: ROW_STATUS_DUPLICATE, Reason: "mutation already applied by a concurrent import"}
+		case err != nil:
+			match = Match{Status: ROW_STATUS_REVIEW, Reason: fmt.Sprintf("could not be applied: %v", err), Candidates: match.Candidates}
+		case !applied:
+			match = Match{Status: ROW_STATUS_REVIEW, Reason: fmt.Sprintf("could not be applied: %v", ErrInstallmentAlreadyPaid), Candidates: match.Candidates}
+		default:
+			// The payment is in, failing to settle it must not send the line to review and pay it again
+			if err := s.settle(ctx, match.Installment); err != nil {
+				logger.Logger(ctx).Errorf("Error settling installment %s: %v", match.Installment.Uuid, err)
+			}
+			return record, nil
+		}
+
+		record.TransactionInstallmentUuid = nil
+		describe(&record, match)
+	}
+
+	if err := s.ReconciliationRowDBClient.CreateReconciliationRow(ctx, &record); err != nil {
+		return record, err
+	}
+
+	return record, nil
+}
+
+// describe stores the outcome of the match on the row.
+func describe(record *reconciliationRows_DBModels.ReconciliationRow, match Match) {
+	record.Status = match.Status
+	record.Reason = optional(match.Reason)
+	record.CandidateUuids = nil
+	if len(match.Candidates) > 0 {
+		candidates := make([]string, 0, len(match.Candidates))
+		for _, c := range match.Candidates {
+			candidates = append(candidates, c.String())
+		}
+		record.CandidateUuids = util.String(strings.Join(candidates, ","))
+	}
+}
+
+// match finds the open installments a line may pay, by its va number and contract number.
+func (s *ReconciliationService) match(ctx context.Context, row Row) (Match, error) {
+	if row.Err != nil {
+		return Match{Status: ROW_STATUS_INVALID, Reason: row.Err.Error()}, nil
+	}
+
+	// Banks send the same mutation again in later files, a mutation that was applied stays applied
+	applied, err := s.ReconciliationRowDBClient.GetReconciliationRow(ctx, appliedRows(row))
+	if err != nil {
+		return Match{}, err
+	}
+	if applied.Uuid != uuid.Nil {
+		if row.Reference != "" {
+			return Match{Status: ROW_STATUS_DUPLICATE, Reason: fmt.Sprintf("reference already applied by row %s", applied.Uuid)}, nil
+		}
+		return Match{Status: ROW_STATUS_DUPLICATE, Reason: fmt.Sprintf("same account, date and amount already applied by row %s", applied.Uuid)}, nil
+	}
+
+	var byVa, byContract []*transaction_installments_DBModels.TransactionInstallment
+
+	if row.VaNumber != "" {
+		va, err := s.VirtualAccountDBClient.GetVirtualAccount(ctx, where.Eq(virtualAccounts_DBModels.COLUMN_ACCOUNT_NUMBER, row.VaNumber))
+		if err != nil {
+			return Match{}, err
+		}
+
+		// A payment into a virtual account is usually reported by the provider first, and already paid
+		callback, err := s.getAppliedCallback(ctx, row, va.Uuid)
+		if err != nil {
+			return Match{}, err
+		}
+		if callback.Uuid != uuid.Nil {
+			return Match{Status: ROW_STATUS_DUPLICATE, Reason: fmt.Sprintf("already applied by %s payment callback %s", callback.Provider, callback.ExternalId)}, nil
+		}
+
+		if va.Uuid != uuid.Nil && va.TransactionInstallmentUuid != nil {
+			installment, err := s.TransactionInstallmentDBClient.GetTransactionInstallment(ctx, where.Eq(transaction_installments_DBModels.COLUM_UUID, va.TransactionInstallmentUuid).IsNull(transaction_installments_DBModels.COLUMN_PAYMENT_AT))
+			if err != nil {
+				return Match{}, err
+			}
+			if installment.Uuid != uuid.Nil {
+				byVa = append(byVa, &installment)
+			}
+		} else if va.Uuid != uuid.Nil {
+			byVa, err = s.TransactionInstallmentDBClient.GetUnpaidTransactionInstallments(ctx, transactions_DBModels.COLUMN_CUSTOMER_UUID, va.CustomerUuid.String())
+			if err != nil {
+				return Match{}, err
+			}
+		}
+	}
+
+	if row.ContractNumber != "" {
+		var err error
+		byContract, err = s.TransactionInstallmentDBClient.GetUnpaidTransactionInstallments(ctx, transactions_DBModels.COLUMN_CONTRACT_NUMBER, row.ContractNumber)
+		if err != nil {
+			return Match{}, err
+		}
+	}
+
+	if len(byVa) > 0 && len(byContract) > 0 {
+		open := intersect(byVa, byContract)
+		if len(open) == 0 {
+			match := decide(row.Amount, append(byVa, byContract...))
+			return Match{Status: ROW_STATUS_REVIEW, Reason: "va number and contract number belong to different contracts", Candidates: match.Candidates}, nil
+		}
+		return decide(row.Amount, open), nil
+	}
+
+	return decide(row.Amount, append(byVa, byContract...)), nil
+}
+
+func (s *ReconciliationService) Resolve(ctx context.Context, rowUuid uuid.UUID, installmentUuid *uuid.UUID, resolvedBy uuid.UUID) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	row, err := s.getReviewRow(ctx, rowUuid)
+	if err != nil {
+		return row, err
+	}
+
+	if installmentUuid == nil {
+		candidates := row.GetCandidateUuids()
+		if len(candidates) != 1 {
+			return row, ErrAmbiguousResolution
+		}
+		id, err := uuid.Parse(candidates[0])
+		if err != nil {
+			return row, err
+		}
+		installmentUuid = &id
+	}
+
+	installment, err := s.TransactionInstallmentDBClient.GetTransactionInstallment(ctx, where.Eq(transaction_installments_DBModels.COLUM_UUID, installmentUuid))
+	if err != nil {
+		return row, err
+	}
+
+	if installment.Uuid == uuid.Nil {
+		return row, ErrInstallmentNotFound
+	}
+
+	if installment.PaymentAt != nil {
+		return row, ErrInstallmentAlreadyPaid
+	}
+
+	// Claim the row before paying, so a row resolved twice concurrently is only applied once
+	now := time.Now()
+	var patcher = make(map[string]interface{})
+	patcher[reconciliationRows_DBModels.COLUMN_STATUS] = ROW_STATUS_RESOLVED
+	patcher[reconciliationRows_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID] = installment.Uuid
+	patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_AT] = now
+	patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_BY] = resolvedBy
+	patcher[reconciliationRows_DBModels.COLUMN_UPDATED_AT] = now
+
+	claimed, err := s.ReconciliationRowDBClient.UpdateReconciliationRow(ctx, inReview(row.Uuid), patcher)
+	if util.IsUniqueViolation(err) {
+		return row, ErrRowAlreadyApplied
+	}
+	if err != nil {
+		return row, err
+	}
+	if claimed == 0 {
+		return row, ErrRowNotInReview
+	}
+
+	if err := s.apply(ctx, &installment, row.Amount, paidAt(row.TransactionDate)); err != nil {
+		// Put the row back in the review queue, nothing was paid
+		patcher = make(map[string]interface{})
+		patcher[reconciliationRows_DBModels.COLUMN_STATUS] = ROW_STATUS_REVIEW
+		patcher[reconciliationRows_DBModels.COLUMN_TRANSACTION_INSTALLMENT_UUID] = nil
+		patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_AT] = nil
+		patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_BY] = nil
+		patcher[reconciliationRows_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+		fResolved := where.Eq(reconciliationRows_DBModels.COLUM_UUID, row.Uuid).Eq(reconciliationRows_DBModels.COLUMN_STATUS, ROW_STATUS_RESOLVED)
+		if _, revertErr := s.ReconciliationRowDBClient.UpdateReconciliationRow(ctx, fResolved, patcher); revertErr != nil {
+			logger.Logger(ctx).Errorf("Error putting reconciliation row %s back in review: %v", row.Uuid, revertErr)
+		}
+		return row, err
+	}
+
+	row.Status = ROW_STATUS_RESOLVED
+	row.TransactionInstallmentUuid = &installment.Uuid
+	row.ResolvedAt = &now
+	row.ResolvedBy = &resolvedBy
+	row.UpdatedAt = now
+	return row, nil
+}
+
+func (s *ReconciliationService) Dismiss(ctx context.Context, rowUuid uuid.UUID, reason string, resolvedBy uuid.UUID) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	row, err := s.getReviewRow(ctx, rowUuid)
+	if err != nil {
+		return row, err
+	}
+
+	now := time.Now()
+	var patcher = make(map[string]interface{})
+	patcher[reconciliationRows_DBModels.COLUMN_STATUS] = ROW_STATUS_DISMISSED
+	patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_AT] = now
+	patcher[reconciliationRows_DBModels.COLUMN_RESOLVED_BY] = resolvedBy
+	patcher[reconciliationRows_DBModels.COLUMN_UPDATED_AT] = now
+	if reason != "" {
+		patcher[reconciliationRows_DBModels.COLUMN_REASON] = reason
+		row.Reason = &reason
+	}
+
+	dismissed, err := s.ReconciliationRowDBClient.UpdateReconciliationRow(ctx, inReview(row.Uuid), patcher)
+	if err != nil {
+		return row, err
+	}
+	if dismissed == 0 {
+		return row, ErrRowNotInReview
+	}
+
+	row.Status = ROW_STATUS_DISMISSED
+	row.ResolvedAt = &now
+	row.ResolvedBy = &resolvedBy
+	row.UpdatedAt = now
+	return row, nil
+}
+
+// apply adds amount to the installment and, once it is paid off, settles it. An installment paid off
+// concurrently isn't paid again, ErrInstallmentAlreadyPaid sends the line to review instead.
+func (s *ReconciliationService) apply(ctx context.Context, installment *transaction_installments_DBModels.TransactionInstallment, amount float64, paidAt time.Time) error {
+	// Guard on payment_at so a concurrent payment can't pay the installment twice
+	fInstallment := where.Eq(transaction_installments_DBModels.COLUM_UUID, installment.Uuid).IsNull(transaction_installments_DBModels.COLUMN_PAYMENT_AT)
+	paid, err := s.TransactionInstallmentDBClient.PayTransactionInstallment(ctx, fInstallment, amount, amountTolerance, paidAt, installmentPatch())
+	if err != nil {
+		return err
+	}
+	if paid == 0 {
+		return ErrInstallmentAlreadyPaid
+	}
+
+	// The payment is in, failing to settle it must not send the line to review and pay it again
+	if err := s.settle(ctx, installment); err != nil {
+		logger.Logger(ctx).Errorf("Error settling installment %s: %v", installment.Uuid, err)
+	}
+
+	return nil
+}
+
+// installmentPatch is written with every payment reconciliation makes into an installment.
+func installmentPatch() map[string]interface{} {
+	var patcher = make(map[string]interface{})
+	patcher[transaction_installments_DBModels.COLUMN_METHOD_PAYMENT] = METHOD_PAYMENT_BANK_TRANSFER
+	patcher[transaction_installments_DBModels.COLUMN_UPDATED_AT] = time.Now()
+	return patcher
+}
+
+// settle reads back the paid installment and, once it is paid off, marks the contract done when it was
+// the last one and lets the customer and subscribed partners know.
+func (s *ReconciliationService) settle(ctx context.Context, installment *transaction_installments_DBModels.TransactionInstallment) error {
+	log := logger.Logger(ctx)
+
+	paid, err := s.TransactionInstallmentDBClient.GetTransactionInstallment(ctx, where.Eq(transaction_installments_DBModels.COLUM_UUID, installment.Uuid))
+	if err != nil {
+		return err
+	}
+	*installment = paid
+
+	if installment.PaymentAt == nil {
+		return nil
+	}
+
+	transaction, err := s.TransactionDBClient.GetTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, installment.TransactionUuid))
+	if err != nil {
+		return err
+	}
+
+	p := request.Pagination{GetAllData: true}
+	p.Validate()
+
+	unpaid, _, err := s.TransactionInstallmentDBClient.GetTransactionInstallments(ctx, p, map[string]interface{}{
+		transaction_installments_DBModels.COLUMN_TRANSACTION_UUID: transaction.Uuid.String(),
+		transaction_installments_DBModels.COLUMN_PAYMENT_AT:       nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(unpaid) == 0 {
+		var patcher = make(map[string]interface{})
+		patcher[transactions_DBModels.COLUMN_IS_DONE] = true
+		patcher[transactions_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+		if err := s.TransactionDBClient.UpdateTransaction(ctx, where.Eq(transactions_DBModels.COLUM_UUID, transaction.Uuid), patcher); err != nil {
+			return err
+		}
+		transaction.IsDone = util.Boolean(true)
+	}
+
+	customer, err := s.CustomerDBClient.GetCustomer(ctx, where.Eq(customers_DBModels.COLUM_UUID, transaction.CustomerUuid))
+	if err != nil {
+		log.Errorf("Error getting customer %s to notify about payment: %v", transaction.CustomerUuid, err)
+	} else if err := s.Notification.Notify(ctx, notification.Recipient{
+		CustomerUuid: customer.Uuid,
+		Name:         customer.Name,
+		Email:        customer.Email,
+	}, notification.Event{
+		Type:          notification.EventInstallmentPaid,
+		ReferenceUuid: &installment.Uuid,
+		Data: map[string]interface{}{
+			"contract_number": transaction.ContractNumber,
+			"term":            installment.Term,
+			"amount_paid":     installment.AmountPaid,
+		},
+	}); err != nil {
+		log.Errorf("Error sending payment notification to customer %s: %v", customer.Uuid, err)
+	}
+
+	if err := s.Webhook.Publish(ctx, webhook.EventTransactionPaid, &transaction.Uuid, map[string]interface{}{
+		"transaction": transaction,
+		"installment": installment,
+	}); err != nil {
+		log.Errorf("Error publishing payment webhook for contract %s: %v", transaction.ContractNumber, err)
+	}
+
+	return nil
+}
+
+func (s *ReconciliationService) getReviewRow(ctx context.Context, rowUuid uuid.UUID) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	row, err := s.ReconciliationRowDBClient.GetReconciliationRow(ctx, where.Eq(reconciliationRows_DBModels.COLUM_UUID, rowUuid))
+	if err != nil {
+		return row, err
+	}
+
+	if row.Uuid == uuid.Nil {
+		return row, ErrRowNotFound
+	}
+
+	if row.Status != ROW_STATUS_REVIEW {
+		return row, ErrRowNotInReview
+	}
+
+	return row, nil
+}
+
+// getAppliedCallback returns the applied payment callback the line reports again: the callback with the
+// reference of the line as its provider id, or else the callback of the same amount into the virtual
+// account on the date of the line.
+func (s *ReconciliationService) getAppliedCallback(ctx context.Context, row Row, vaUuid uuid.UUID) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	applied := []string{paymentCallbacks_DBModels.STATUS_APPLIED, paymentCallbacks_DBModels.STATUS_OVERPAID}
+
+	if row.Reference != "" {
+		callback, err := s.PaymentCallbackDBClient.GetPaymentCallback(ctx, where.Eq(paymentCallbacks_DBModels.COLUMN_EXTERNAL_ID, row.Reference).In(paymentCallbacks_DBModels.COLUMN_STATUS, applied))
+		if err != nil || callback.Uuid != uuid.Nil {
+			return callback, err
+		}
+	}
+
+	if vaUuid == uuid.Nil || row.Date == nil {
+		return paymentCallbacks_DBModels.PaymentCallback{}, nil
+	}
+
+	return s.PaymentCallbackDBClient.GetPaymentCallback(ctx, where.Eq(paymentCallbacks_DBModels.COLUMN_VIRTUAL_ACCOUNT_UUID, vaUuid).
+		Eq(paymentCallbacks_DBModels.COLUMN_AMOUNT, row.Amount).
+		Eq(where.Date(paymentCallbacks_DBModels.COLUMN_PAID_AT), row.Date.Format("2006-01-02")).
+		In(paymentCallbacks_DBModels.COLUMN_STATUS, applied))
+}
+
+// appliedRows matches the applied rows of the mutation of the line, as the unique indexes over applied rows
+// do: by its reference, or without one by its va number, or else contract number, date and amount.
+func appliedRows(row Row) where.Filter {
+	whr := where.In(reconciliationRows_DBModels.COLUMN_STATUS, []string{ROW_STATUS_MATCHED, ROW_STATUS_RESOLVED})
+	switch {
+	case row.Reference != "":
+		return whr.Eq(reconciliationRows_DBModels.COLUMN_REFERENCE, row.Reference)
+	case row.VaNumber != "":
+		whr = whr.Eq(reconciliationRows_DBModels.COLUMN_VA_NUMBER, row.VaNumber)
+	default:
+		whr = whr.IsNull(reconciliationRows_DBModels.COLUMN_VA_NUMBER).Eq(reconciliationRows_DBModels.COLUMN_CONTRACT_NUMBER, row.ContractNumber)
+	}
+
+	whr = whr.IsNull(reconciliationRows_DBModels.COLUMN_REFERENCE).Eq(reconciliationRows_DBModels.COLUMN_AMOUNT, row.Amount)
+	if row.Date != nil {
+		return whr.Eq(reconciliationRows_DBModels.COLUMN_TRANSACTION_DATE, *row.Date)
+	}
+	return whr.IsNull(reconciliationRows_DBModels.COLUMN_TRANSACTION_DATE)
+}
+
+// inReview matches the row while it waits in the review queue, so it is resolved or dismissed only once.
+func inReview(rowUuid uuid.UUID) where.Filter {
+	return where.Eq(reconciliationRows_DBModels.COLUM_UUID, rowUuid).Eq(reconciliationRows_DBModels.COLUMN_STATUS, ROW_STATUS_REVIEW)
+}
+
+// finish stores the totals of the job, as completed or failed with err.
+func (s *ReconciliationService) finish(ctx context.Context, job reconciliationJobs_DBModels.ReconciliationJob, err error) {
+	var patcher = make(map[string]interface{})
+	patcher[reconciliationJobs_DBModels.COLUMN_STATUS] = JOB_STATUS_COMPLETED
+	patcher[reconciliationJobs_DBModels.COLUMN_TOTAL_ROWS] = job.TotalRows
+	patcher[reconciliationJobs_DBModels.COLUMN_MATCHED_ROWS] = job.MatchedRows
+	patcher[reconciliationJobs_DBModels.COLUMN_REVIEW_ROWS] = job.ReviewRows
+	patcher[reconciliationJobs_DBModels.COLUMN_UNMATCHED_ROWS] = job.UnmatchedRows
+	patcher[reconciliationJobs_DBModels.COLUMN_DUPLICATE_ROWS] = job.DuplicateRows
+	patcher[reconciliationJobs_DBModels.COLUMN_INVALID_ROWS] = job.InvalidRows
+	patcher[reconciliationJobs_DBModels.COLUMN_MATCHED_AMOUNT] = job.MatchedAmount
+	patcher[reconciliationJobs_DBModels.COLUMN_FINISHED_AT] = time.Now()
+	if err != nil {
+		patcher[reconciliationJobs_DBModels.COLUMN_STATUS] = JOB_STATUS_FAILED
+		patcher[reconciliationJobs_DBModels.COLUMN_ERROR] = err.Error()
+	}
+
+	s.updateJob(ctx, job.Uuid, patcher)
+}
+
+func (s *ReconciliationService) updateJob(ctx context.Context, jobUuid uuid.UUID, patcher map[string]interface{}) {
+	patcher[reconciliationJobs_DBModels.COLUMN_UPDATED_AT] = time.Now()
+
+	if err := s.ReconciliationJobDBClient.UpdateReconciliationJob(ctx, where.Eq(reconciliationJobs_DBModels.COLUM_UUID, jobUuid), patcher); err != nil {
+		logger.Logger(ctx).Errorf("Error updating reconciliation job %s: %v", jobUuid, err)
+	}
+}
+
+// paidAt is the mutation date of the line, or now when the file has no dates.
+func paidAt(date *time.Time) time.Time {
+	if date != nil {
+		return *date
+	}
+	return time.Now()
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
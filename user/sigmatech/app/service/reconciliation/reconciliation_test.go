@@ -0,0 +1,599 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"user/sigmatech/app/constants"
+	paymentCallbacks_DBModels "user/sigmatech/app/db/dto/payment_callbacks"
+	reconciliationRows_DBModels "user/sigmatech/app/db/dto/reconciliation_rows"
+	transaction_installments_DBModels "user/sigmatech/app/db/dto/transaction_installments"
+	virtualAccounts_DBModels "user/sigmatech/app/db/dto/virtual_accounts"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/dto/response"
+	"user/sigmatech/app/service/logger"
+	"user/sigmatech/app/service/util"
+	"user/sigmatech/config"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestParse(t *testing.T) {
+	layout := Layout{
+		Delimiter:            ";",
+		SkipRows:             1,
+		DateColumn:           0,
+		DescriptionColumn:    1,
+		ReferenceColumn:      2,
+		VaNumberColumn:       3,
+		ContractNumberColumn: -1,
+		AmountColumn:         4,
+		DateFormat:           "02/01/2006",
+		DecimalSeparator:     ",",
+	}
+	data := []byte("date;description;reference;va;amount\n" +
+		"19/10/2026;TRF VA;REF1;9881234;1.500.000,50\n" +
+		"\n" +
+		"19/10/2026;TRF VA;REF2;9881234;-10,00\n" +
+		"19-10-2026;TRF VA;REF3;9881234;10,00\n")
+
+	rows, err := Parse(data, layout)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		row        Row
+		wantNumber int
+		wantAmount float64
+		wantErr    bool
+	}{
+		{
+			name:       "Given a credit line, When call Parse, Then return its amount",
+			row:        rows[0],
+			wantNumber: 2,
+			wantAmount: 1500000.50,
+		},
+		{
+			name:       "Given a debit line, When call Parse, Then return the row with an error",
+			row:        rows[1],
+			wantNumber: 4,
+			wantErr:    true,
+		},
+		{
+			name:       "Given a line with an invalid date, When call Parse, Then return the row with an error",
+			row:        rows[2],
+			wantNumber: 5,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.row.Number != tt.wantNumber {
+				t.Errorf("Number = %v, want %v", tt.row.Number, tt.wantNumber)
+			}
+			if (tt.row.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, wantErr %v", tt.row.Err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.row.Amount != tt.wantAmount {
+				t.Errorf("Amount = %v, want %v", tt.row.Amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	contractA, contractB := uuid.New(), uuid.New()
+	installment := func(contract uuid.UUID, amount, paid float64) *transaction_installments_DBModels.TransactionInstallment {
+		return &transaction_installments_DBModels.TransactionInstallment{Uuid: uuid.New(), TransactionUuid: contract, Amount: amount, AmountPaid: paid}
+	}
+
+	a1, a2 := installment(contractA, 500000, 0), installment(contractA, 500000, 0)
+	b1 := installment(contractB, 500000, 0)
+	partial := installment(contractA, 500000, 200000)
+
+	tests := []struct {
+		name       string
+		amount     float64
+		open       []*transaction_installments_DBModels.TransactionInstallment
+		wantStatus string
+		want       *transaction_installments_DBModels.TransactionInstallment
+	}{
+		{
+			name:       "Given no open installment, When call decide, Then return unmatched",
+			amount:     500000,
+			wantStatus: ROW_STATUS_UNMATCHED,
+		},
+		{
+			name:       "Given the exact amount of the next installment, When call decide, Then return matched",
+			amount:     500000,
+			open:       []*transaction_installments_DBModels.TransactionInstallment{a1, a2},
+			wantStatus: ROW_STATUS_MATCHED,
+			want:       a1,
+		},
+		{
+			name:       "Given the outstanding amount of a partly paid installment, When call decide, Then return matched",
+			amount:     300000,
+			open:       []*transaction_installments_DBModels.TransactionInstallment{partial},
+			wantStatus: ROW_STATUS_MATCHED,
+			want:       partial,
+		},
+		{
+			name:       "Given less than the next installment, When call decide, Then return review",
+			amount:     100000,
+			open:       []*transaction_installments_DBModels.TransactionInstallment{a1, a2},
+			wantStatus: ROW_STATUS_REVIEW,
+		},
+		{
+			name:       "Given an amount matching two contracts, When call decide, Then return review",
+			amount:     500000,
+			open:       []*transaction_installments_DBModels.TransactionInstallment{a1, b1, a2},
+			wantStatus: ROW_STATUS_REVIEW,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decide(tt.amount, tt.open)
+			if got.Status != tt.wantStatus {
+				t.Errorf("decide() status = %v, want %v (%s)", got.Status, tt.wantStatus, got.Reason)
+			}
+			if tt.want != nil && got.Installment != tt.want {
+				t.Errorf("decide() installment = %v, want %v", got.Installment, tt.want)
+			}
+		})
+	}
+}
+
+// rowRepository returns the row as it was read by the caller, seen, and updates the stored row when the
+// status conditions of the filter still hold, as the database does.
+type rowRepository struct {
+	seen reconciliationRows_DBModels.ReconciliationRow
+	row  reconciliationRows_DBModels.ReconciliationRow
+}
+
+func (r *rowRepository) CreateReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow) error {
+	return nil
+}
+
+func (r *rowRepository) GetReconciliationRow(ctx context.Context, whr where.Filter) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	return r.seen, nil
+}
+
+func (r *rowRepository) GetReconciliationRows(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*reconciliationRows_DBModels.ReconciliationRow, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *rowRepository) UpdateReconciliationRow(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	for _, condition := range whr.Conditions {
+		if condition.Column == reconciliationRows_DBModels.COLUMN_STATUS && condition.Value != r.row.Status {
+			return 0, nil
+		}
+	}
+	r.row.Status = patch[reconciliationRows_DBModels.COLUMN_STATUS].(string)
+	return 1, nil
+}
+
+func (r *rowRepository) ApplyReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow, tolerance float64, paidAt time.Time, installmentPatch map[string]interface{}) (bool, error) {
+	return false, nil
+}
+
+func (r *rowRepository) DeleteReconciliationRow(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// installmentRepository returns the installment as it was first read by the caller, seen, then the stored
+// installment, which it pays while it is unpaid, as the database does.
+type installmentRepository struct {
+	seen        transaction_installments_DBModels.TransactionInstallment
+	installment transaction_installments_DBModels.TransactionInstallment
+	open        []*transaction_installments_DBModels.TransactionInstallment // unpaid installments of the customer
+}
+
+func (r *installmentRepository) CreateTransactionInstallment(ctx context.Context, installment *transaction_installments_DBModels.TransactionInstallment) error {
+	return nil
+}
+
+func (r *installmentRepository) GetTransactionInstallment(ctx context.Context, whr where.Filter) (transaction_installments_DBModels.TransactionInstallment, error) {
+	seen := r.seen
+	r.seen = r.installment
+	return seen, nil
+}
+
+func (r *installmentRepository) GetTransactionInstallments(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*transaction_installments_DBModels.TransactionInstallment, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *installmentRepository) UpdateTransactionInstallment(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *installmentRepository) PayTransactionInstallment(ctx context.Context, whr where.Filter, amount, tolerance float64, paidAt time.Time, patch map[string]interface{}) (int64, error) {
+	if r.installment.PaymentAt != nil {
+		return 0, nil
+	}
+	r.installment.AmountPaid += amount
+	if r.installment.AmountPaid >= r.installment.Amount-tolerance {
+		r.installment.PaymentAt = &paidAt
+	}
+	return 1, nil
+}
+
+func (r *installmentRepository) DeleteTransactionInstallment(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func (r *installmentRepository) GetUnpaidTransactionInstallments(ctx context.Context, transactionColumn string, value string) ([]*transaction_installments_DBModels.TransactionInstallment, error) {
+	return r.open, nil
+}
+
+func TestResolve(t *testing.T) {
+	constants.Config = &config.ServiceConfig{}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	paidAt := time.Now()
+	unpaid := transaction_installments_DBModels.TransactionInstallment{Uuid: uuid.New(), TransactionUuid: uuid.New(), Amount: 500000}
+	paid := unpaid
+	paid.AmountPaid, paid.PaymentAt = 500000, &paidAt
+
+	tests := []struct {
+		name           string
+		storedStatus   string
+		installment    transaction_installments_DBModels.TransactionInstallment
+		wantErr        error
+		wantStatus     string
+		wantAmountPaid float64
+	}{
+		{
+			name:           "Given a row in review, When call Resolve, Then the installment gets the amount and the row is resolved",
+			storedStatus:   ROW_STATUS_REVIEW,
+			installment:    unpaid,
+			wantStatus:     ROW_STATUS_RESOLVED,
+			wantAmountPaid: 200000,
+		},
+		{
+			name:           "Given a row resolved concurrently, When call Resolve, Then return not in review and pay nothing",
+			storedStatus:   ROW_STATUS_DISMISSED,
+			installment:    unpaid,
+			wantErr:        ErrRowNotInReview,
+			wantStatus:     ROW_STATUS_DISMISSED,
+			wantAmountPaid: 0,
+		},
+		{
+			name:           "Given an installment paid concurrently, When call Resolve, Then return already paid and put the row back in review",
+			storedStatus:   ROW_STATUS_REVIEW,
+			installment:    paid,
+			wantErr:        ErrInstallmentAlreadyPaid,
+			wantStatus:     ROW_STATUS_REVIEW,
+			wantAmountPaid: 500000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := reconciliationRows_DBModels.ReconciliationRow{Uuid: uuid.New(), Status: ROW_STATUS_REVIEW, Amount: 200000}
+			stored := row
+			stored.Status = tt.storedStatus
+
+			rows := &rowRepository{seen: row, row: stored}
+			installments := &installmentRepository{seen: unpaid, installment: tt.installment}
+			s := &ReconciliationService{ReconciliationRowDBClient: rows, TransactionInstallmentDBClient: installments}
+
+			_, err := s.Resolve(context.Background(), row.Uuid, &unpaid.Uuid, uuid.New())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if rows.row.Status != tt.wantStatus {
+				t.Errorf("Resolve() row status = %v, want %v", rows.row.Status, tt.wantStatus)
+			}
+			if installments.installment.AmountPaid != tt.wantAmountPaid {
+				t.Errorf("Resolve() amount paid = %v, want %v", installments.installment.AmountPaid, tt.wantAmountPaid)
+			}
+		})
+	}
+}
+
+func TestDismiss(t *testing.T) {
+	row := reconciliationRows_DBModels.ReconciliationRow{Uuid: uuid.New(), Status: ROW_STATUS_REVIEW}
+
+	tests := []struct {
+		name         string
+		storedStatus string
+		wantErr      error
+		wantStatus   string
+	}{
+		{
+			name:         "Given a row in review, When call Dismiss, Then the row is dismissed",
+			storedStatus: ROW_STATUS_REVIEW,
+			wantStatus:   ROW_STATUS_DISMISSED,
+		},
+		{
+			name:         "Given a row resolved concurrently, When call Dismiss, Then return not in review and keep it resolved",
+			storedStatus: ROW_STATUS_RESOLVED,
+			wantErr:      ErrRowNotInReview,
+			wantStatus:   ROW_STATUS_RESOLVED,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := row
+			stored.Status = tt.storedStatus
+
+			rows := &rowRepository{seen: row, row: stored}
+			s := &ReconciliationService{ReconciliationRowDBClient: rows}
+
+			if _, err := s.Dismiss(context.Background(), row.Uuid, "", uuid.New()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Dismiss() error = %v, want %v", err, tt.wantErr)
+			}
+			if rows.row.Status != tt.wantStatus {
+				t.Errorf("Dismiss() row status = %v, want %v", rows.row.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// matches reports whether the row, keyed by column, meets the conditions of the filter. Null columns are
+// left out of the row.
+func matches(whr where.Filter, row map[string]interface{}) bool {
+	for _, condition := range whr.Conditions {
+		value, ok := row[condition.Column]
+		switch condition.Operator {
+		case where.EQ:
+			if !ok || fmt.Sprint(value) != fmt.Sprint(condition.Value) {
+				return false
+			}
+		case where.IN:
+			in := false
+			for _, v := range condition.Value.([]string) {
+				in = in || (ok && fmt.Sprint(value) == v)
+			}
+			if !in {
+				return false
+			}
+		case where.IS_NULL:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// importRepository keeps the rows stored by an import. A matched row is stored with its payment, unless
+// applyErr is set or the installment was paid off in the meantime, as the database does.
+type importRepository struct {
+	rows     []reconciliationRows_DBModels.ReconciliationRow
+	paid     float64
+	applyErr error
+	paidOff  bool
+}
+
+func (r *importRepository) CreateReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow) error {
+	r.rows = append(r.rows, *row)
+	return nil
+}
+
+func (r *importRepository) GetReconciliationRow(ctx context.Context, whr where.Filter) (reconciliationRows_DBModels.ReconciliationRow, error) {
+	for _, row := range r.rows {
+		columns := map[string]interface{}{
+			reconciliationRows_DBModels.COLUM_UUID:    row.Uuid,
+			reconciliationRows_DBModels.COLUMN_AMOUNT: row.Amount,
+			reconciliationRows_DBModels.COLUMN_STATUS: row.Status,
+		}
+		for column, value := range map[string]*string{
+			reconciliationRows_DBModels.COLUMN_REFERENCE:       row.Reference,
+			reconciliationRows_DBModels.COLUMN_VA_NUMBER:       row.VaNumber,
+			reconciliationRows_DBModels.COLUMN_CONTRACT_NUMBER: row.ContractNumber,
+		} {
+			if value != nil {
+				columns[column] = *value
+			}
+		}
+		if row.TransactionDate != nil {
+			columns[reconciliationRows_DBModels.COLUMN_TRANSACTION_DATE] = *row.TransactionDate
+		}
+		if matches(whr, columns) {
+			return row, nil
+		}
+	}
+	return reconciliationRows_DBModels.ReconciliationRow{}, nil
+}
+
+func (r *importRepository) GetReconciliationRows(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*reconciliationRows_DBModels.ReconciliationRow, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *importRepository) UpdateReconciliationRow(ctx context.Context, whr where.Filter, patch map[string]interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (r *importRepository) ApplyReconciliationRow(ctx context.Context, row *reconciliationRows_DBModels.ReconciliationRow, tolerance float64, paidAt time.Time, installmentPatch map[string]interface{}) (bool, error) {
+	if r.applyErr != nil || r.paidOff {
+		return false, r.applyErr
+	}
+	r.rows = append(r.rows, *row)
+	r.paid += row.Amount
+	return true, nil
+}
+
+func (r *importRepository) DeleteReconciliationRow(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// virtualAccountRepository returns its virtual account when the account number matches.
+type virtualAccountRepository struct {
+	va virtualAccounts_DBModels.VirtualAccount
+}
+
+func (r *virtualAccountRepository) CreateVirtualAccount(ctx context.Context, va *virtualAccounts_DBModels.VirtualAccount) error {
+	return nil
+}
+
+func (r *virtualAccountRepository) GetVirtualAccount(ctx context.Context, whr where.Filter) (virtualAccounts_DBModels.VirtualAccount, error) {
+	if matches(whr, map[string]interface{}{virtualAccounts_DBModels.COLUMN_ACCOUNT_NUMBER: r.va.AccountNumber}) {
+		return r.va, nil
+	}
+	return virtualAccounts_DBModels.VirtualAccount{}, nil
+}
+
+func (r *virtualAccountRepository) GetVirtualAccounts(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*virtualAccounts_DBModels.VirtualAccount, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *virtualAccountRepository) UpdateVirtualAccount(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *virtualAccountRepository) DeleteVirtualAccount(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+// paymentCallbackRepository keeps the callbacks received from the payment provider.
+type paymentCallbackRepository struct {
+	callbacks []paymentCallbacks_DBModels.PaymentCallback
+}
+
+func (r *paymentCallbackRepository) CreatePaymentCallback(ctx context.Context, callback *paymentCallbacks_DBModels.PaymentCallback) error {
+	return nil
+}
+
+func (r *paymentCallbackRepository) GetPaymentCallback(ctx context.Context, whr where.Filter) (paymentCallbacks_DBModels.PaymentCallback, error) {
+	for _, callback := range r.callbacks {
+		columns := map[string]interface{}{
+			paymentCallbacks_DBModels.COLUMN_EXTERNAL_ID:         callback.ExternalId,
+			paymentCallbacks_DBModels.COLUMN_AMOUNT:              callback.Amount,
+			where.Date(paymentCallbacks_DBModels.COLUMN_PAID_AT): callback.PaidAt.Format("2006-01-02"),
+			paymentCallbacks_DBModels.COLUMN_STATUS:              callback.Status,
+		}
+		if callback.VirtualAccountUuid != nil {
+			columns[paymentCallbacks_DBModels.COLUMN_VIRTUAL_ACCOUNT_UUID] = *callback.VirtualAccountUuid
+		}
+		if matches(whr, columns) {
+			return callback, nil
+		}
+	}
+	return paymentCallbacks_DBModels.PaymentCallback{}, nil
+}
+
+func (r *paymentCallbackRepository) GetPaymentCallbacks(ctx context.Context, pagination request.Pagination, filter map[string]interface{}) ([]*paymentCallbacks_DBModels.PaymentCallback, response.Pagination, error) {
+	return nil, response.Pagination{}, nil
+}
+
+func (r *paymentCallbackRepository) UpdatePaymentCallback(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	return nil
+}
+
+func (r *paymentCallbackRepository) DeletePaymentCallback(ctx context.Context, filter where.Filter) error {
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	constants.Config = &config.ServiceConfig{}
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	va := virtualAccounts_DBModels.VirtualAccount{Uuid: uuid.New(), Provider: "simulator", AccountNumber: "9881234567890123", CustomerUuid: uuid.New()}
+	open := transaction_installments_DBModels.TransactionInstallment{Uuid: uuid.New(), TransactionUuid: uuid.New(), Amount: 500000}
+
+	line := Row{Number: 2, Date: &date, Reference: "REF1", VaNumber: va.AccountNumber, Amount: 500000}
+	withoutReference := line
+	withoutReference.Reference = ""
+
+	tests := []struct {
+		name       string
+		row        Row
+		applied    []reconciliationRows_DBModels.ReconciliationRow
+		callbacks  []paymentCallbacks_DBModels.PaymentCallback
+		applyErr   error
+		paidOff    bool
+		wantStatus string
+		wantPaid   float64
+	}{
+		{
+			name:       "Given a line paying the next installment, When call reconcile, Then the row is stored matched with its payment",
+			row:        line,
+			wantStatus: ROW_STATUS_MATCHED,
+			wantPaid:   500000,
+		},
+		{
+			name:       "Given a reference applied by an earlier import, When call reconcile, Then the row is a duplicate",
+			row:        line,
+			applied:    []reconciliationRows_DBModels.ReconciliationRow{{Uuid: uuid.New(), Reference: util.String("REF1"), Amount: 100, Status: ROW_STATUS_MATCHED}},
+			wantStatus: ROW_STATUS_DUPLICATE,
+		},
+		{
+			name:       "Given a line without reference like a resolved one, When call reconcile, Then the row is a duplicate",
+			row:        withoutReference,
+			applied:    []reconciliationRows_DBModels.ReconciliationRow{{Uuid: uuid.New(), VaNumber: &va.AccountNumber, TransactionDate: &date, Amount: 500000, Status: ROW_STATUS_RESOLVED}},
+			wantStatus: ROW_STATUS_DUPLICATE,
+		},
+		{
+			name:       "Given a line without reference like a dismissed one, When call reconcile, Then the row is matched",
+			row:        withoutReference,
+			applied:    []reconciliationRows_DBModels.ReconciliationRow{{Uuid: uuid.New(), VaNumber: &va.AccountNumber, TransactionDate: &date, Amount: 500000, Status: ROW_STATUS_DISMISSED}},
+			wantStatus: ROW_STATUS_MATCHED,
+			wantPaid:   500000,
+		},
+		{
+			name:       "Given a payment callback applied into the account that day, When call reconcile, Then the row is a duplicate",
+			row:        withoutReference,
+			callbacks:  []paymentCallbacks_DBModels.PaymentCallback{{Uuid: uuid.New(), ExternalId: "cb-1", VirtualAccountUuid: &va.Uuid, Amount: 500000, PaidAt: date.Add(10 * time.Hour), Status: paymentCallbacks_DBModels.STATUS_APPLIED}},
+			wantStatus: ROW_STATUS_DUPLICATE,
+		},
+		{
+			name:       "Given an overpaid callback with the reference as its id, When call reconcile, Then the row is a duplicate",
+			row:        line,
+			callbacks:  []paymentCallbacks_DBModels.PaymentCallback{{Uuid: uuid.New(), ExternalId: "REF1", Amount: 600000, PaidAt: date, Status: paymentCallbacks_DBModels.STATUS_OVERPAID}},
+			wantStatus: ROW_STATUS_DUPLICATE,
+		},
+		{
+			name:       "Given a callback still pending, When call reconcile, Then the row is matched",
+			row:        withoutReference,
+			callbacks:  []paymentCallbacks_DBModels.PaymentCallback{{Uuid: uuid.New(), ExternalId: "cb-1", VirtualAccountUuid: &va.Uuid, Amount: 500000, PaidAt: date, Status: paymentCallbacks_DBModels.STATUS_PENDING}},
+			wantStatus: ROW_STATUS_MATCHED,
+			wantPaid:   500000,
+		},
+		{
+			name:       "Given a concurrent import applied the mutation first, When call reconcile, Then the row is a duplicate and nothing is paid",
+			row:        line,
+			applyErr:   &pq.Error{Code: "23505", Constraint: "uq_reconciliation_rows_applied_reference"},
+			wantStatus: ROW_STATUS_DUPLICATE,
+		},
+		{
+			name:       "Given the installment was paid off in the meantime, When call reconcile, Then the row waits for review",
+			row:        line,
+			paidOff:    true,
+			wantStatus: ROW_STATUS_REVIEW,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := open
+			rows := &importRepository{rows: tt.applied, applyErr: tt.applyErr, paidOff: tt.paidOff}
+			s := &ReconciliationService{
+				ReconciliationRowDBClient:      rows,
+				TransactionInstallmentDBClient: &installmentRepository{seen: open, installment: open, open: []*transaction_installments_DBModels.TransactionInstallment{&next}},
+				VirtualAccountDBClient:         &virtualAccountRepository{va: va},
+				PaymentCallbackDBClient:        &paymentCallbackRepository{callbacks: tt.callbacks},
+			}
+
+			record, err := s.reconcile(context.Background(), uuid.New(), tt.row)
+			if err != nil {
+				t.Fatalf("reconcile() error = %v", err)
+			}
+			if record.Status != tt.wantStatus {
+				t.Errorf("reconcile() status = %v, want %v (%v)", record.Status, tt.wantStatus, record.Reason)
+			}
+			if stored := rows.rows[len(rows.rows)-1]; len(rows.rows) != len(tt.applied)+1 || stored.Uuid != record.Uuid || stored.Status != tt.wantStatus {
+				t.Errorf("reconcile() stored %d rows ending with %v, want one more %v row", len(rows.rows)-len(tt.applied), stored.Status, tt.wantStatus)
+			}
+			if rows.paid != tt.wantPaid {
+				t.Errorf("reconcile() paid = %v, want %v", rows.paid, tt.wantPaid)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ type IRedisClient interface {
 	Ping() error
 	Close() error
 	Set(key, value string, expiration time.Duration) error
+	SetNX(key, value string, expiration time.Duration) (bool, error)
 	Get(key string) (string, error)
 	Delete(keys ...string) (int64, error)
 	Exists(key string) (bool, error)
@@ -42,6 +43,7 @@ type IRedisClient interface {
 	PUnsubscribe(pubsub *redis.PubSub, patterns ...string) error
 	Expire(key string, expiration time.Duration) (bool, error)
 	Keys(pattern string) ([]string, error)
+	Eval(script string, keys []string, args ...interface{}) (interface{}, error)
 	ListenForKeyExpiration()
 }
 
@@ -102,6 +104,13 @@ func (rc *RedisClient) Set(key, value string, expiration time.Duration) error {
 	return rc.client.Set(ctx, key, value, expiration).Err()
 }
 
+// SetNX sets a key-value pair in Redis only when the key does not exist yet.
+// It returns false when the key was already set.
+func (rc *RedisClient) SetNX(key, value string, expiration time.Duration) (bool, error) {
+	ctx := context.Background()
+	return rc.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 // Get gets a value from Redis based on the key.
 func (rc *RedisClient) Get(key string) (string, error) {
 	ctx := context.Background()
@@ -354,6 +363,12 @@ func (rc *RedisClient) Keys(pattern string) ([]string, error) {
 	return result, nil
 }
 
+// Eval runs a Lua script, its commands are executed atomically.
+func (rc *RedisClient) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	ctx := context.Background()
+	return rc.client.Eval(ctx, script, keys, args...).Result()
+}
+
 // ListenForKeyExpiration listens for expired keys and deletes them.
 func (rc *RedisClient) ListenForKeyExpiration() {
 	ctx := context.Background()
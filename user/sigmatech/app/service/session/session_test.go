@@ -0,0 +1,446 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"user/sigmatech/app/constants"
+	sessions_DBModels "user/sigmatech/app/db/dto/sessions"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/correlation"
+	"user/sigmatech/app/service/ipgeolocation"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// sessionRepository keeps the sessions in memory, matching them on the conditions of the filters. Each
+// location it stores is sent to located with the context it was stored with.
+type sessionRepository struct {
+	mu       sync.Mutex
+	sessions []*sessions_DBModels.Session
+	located  chan context.Context
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *sessions_DBModels.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *session
+	r.sessions = append(r.sessions, &stored)
+	return nil
+}
+
+func (r *sessionRepository) GetSession(ctx context.Context, whr where.Filter) (sessions_DBModels.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if matches(session, whr) {
+			return *session, nil
+		}
+	}
+	return sessions_DBModels.Session{}, nil
+}
+
+func (r *sessionRepository) GetSessions(ctx context.Context, whr where.Filter) ([]*sessions_DBModels.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sessions []*sessions_DBModels.Session
+	for _, session := range r.sessions {
+		if matches(session, whr) {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) CountSessions(ctx context.Context, whr where.Filter) (int, error) {
+	sessions, err := r.GetSessions(ctx, whr)
+	return len(sessions), err
+}
+
+func (r *sessionRepository) UpdateSession(ctx context.Context, whr where.Filter, patch map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if !matches(session, whr) {
+			continue
+		}
+		for column, value := range patch {
+			switch column {
+			case sessions_DBModels.COLUMN_IP:
+				session.Ip = value.(string)
+			case sessions_DBModels.COLUMN_EXPIRES_AT:
+				session.ExpiresAt = value.(time.Time)
+			case sessions_DBModels.COLUMN_LAST_SEEN_AT:
+				session.LastSeenAt = value.(time.Time)
+			case sessions_DBModels.COLUMN_COUNTRY_CODE:
+				countryCode := value.(string)
+				session.CountryCode = &countryCode
+			case sessions_DBModels.COLUMN_NEW_COUNTRY:
+				session.NewCountry = value.(bool)
+			}
+		}
+		if _, ok := patch[sessions_DBModels.COLUMN_COUNTRY_CODE]; ok && r.located != nil {
+			r.located <- ctx
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepository) PurgeSessions(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *sessionRepository) get(sessionUuid uuid.UUID) sessions_DBModels.Session {
+	session, _ := r.GetSession(context.Background(), where.Eq(sessions_DBModels.COLUM_UUID, sessionUuid))
+	return session
+}
+
+// matches evaluates the equality and null conditions the service filters sessions with.
+func matches(session *sessions_DBModels.Session, whr where.Filter) bool {
+	var countryCode interface{}
+	if session.CountryCode != nil {
+		countryCode = *session.CountryCode
+	}
+	fields := map[string]interface{}{
+		sessions_DBModels.COLUM_UUID:          session.Uuid,
+		sessions_DBModels.COLUMN_SUBJECT_UUID: session.SubjectUuid,
+		sessions_DBModels.COLUMN_SUBJECT_TYPE: session.SubjectType,
+		sessions_DBModels.COLUMN_COUNTRY_CODE: countryCode,
+	}
+
+	for _, condition := range whr.Conditions {
+		field := fields[condition.Column]
+		switch condition.Operator {
+		case where.EQ:
+			if field != condition.Value {
+				return false
+			}
+		case where.NOT_EQ:
+			if field == condition.Value {
+				return false
+			}
+		case where.IS_NOT_NULL:
+			if field == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// geolocation locates the IPs it knows, or fails them with err. Locating waits for release when it is set.
+type geolocation struct {
+	mu        sync.Mutex
+	locations map[string]ipgeolocation.LocationInfo
+	err       error
+	release   chan struct{}
+	calls     []string
+}
+
+func (g *geolocation) GetLocationInfo(ipAddress string) (ipgeolocation.LocationInfo, error) {
+	if g.release != nil {
+		<-g.release
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.calls = append(g.calls, ipAddress)
+	if g.err != nil {
+		return ipgeolocation.LocationInfo{}, g.err
+	}
+	return g.locations[ipAddress], nil
+}
+
+func (g *geolocation) called() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]string(nil), g.calls...)
+}
+
+// tokens keeps the refresh token families revoked.
+type tokens struct {
+	revoked []uuid.UUID
+}
+
+func (t *tokens) Active(ctx context.Context, familyUuid uuid.UUID) (bool, error) {
+	for _, revoked := range t.revoked {
+		if revoked == familyUuid {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (t *tokens) Revoke(ctx context.Context, familyUuid uuid.UUID) error {
+	t.revoked = append(t.revoked, familyUuid)
+	return nil
+}
+
+var locations = map[string]ipgeolocation.LocationInfo{
+	"36.68.10.1":    {CountryCode2: "ID", CountryName: "Indonesia", City: "Jakarta"},
+	"36.68.10.2":    {CountryCode2: "ID", CountryName: "Indonesia", City: "Bandung"},
+	"103.6.150.1":   {CountryCode2: "SG", CountryName: "Singapore", City: "Singapore"},
+	"185.220.101.1": {},
+}
+
+func newTestService(geo *geolocation) (*SessionService, *sessionRepository, *tokens) {
+	constants.Config = nil
+	logger.SugarLogger = zap.NewNop().Sugar()
+
+	sessions := &sessionRepository{located: make(chan context.Context, 10)}
+	revoker := &tokens{}
+
+	var client ipgeolocation.IClient
+	if geo != nil {
+		client = geo
+	}
+	return NewSessionService(sessions, revoker, client, sessions_DBModels.SUBJECT_USER), sessions, revoker
+}
+
+// waitLocated returns the context the next location was stored with.
+func waitLocated(t *testing.T, sessions *sessionRepository) context.Context {
+	t.Helper()
+
+	select {
+	case ctx := <-sessions.located:
+		return ctx
+	case <-time.After(time.Second):
+		t.Fatalf("Record() didn't store a location")
+		return nil
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	login := Login{
+		SessionUuid: uuid.New(),
+		SubjectUuid: uuid.New(),
+		Ip:          "36.68.10.1",
+		UserAgent:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	t.Run("Given a new session When recording Then it is stored and located", func(t *testing.T) {
+		s, sessions, _ := newTestService(&geolocation{locations: locations})
+
+		if err := s.Record(ctx, login); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		waitLocated(t, sessions)
+
+		got := sessions.get(login.SessionUuid)
+		if got.SubjectUuid != login.SubjectUuid || got.SubjectType != sessions_DBModels.SUBJECT_USER || got.Device != sessions_DBModels.DEVICE_MOBILE {
+			t.Errorf("Record() stored %+v, want the mobile session of user %s", got, login.SubjectUuid)
+		}
+		if got.CountryCode == nil || *got.CountryCode != "ID" || got.NewCountry {
+			t.Errorf("Record() located the session in %v new %v, want ID not new", got.CountryCode, got.NewCountry)
+		}
+	})
+
+	t.Run("Given a recorded session When refreshed from the same ip Then it is seen again without locating it again", func(t *testing.T) {
+		geo := &geolocation{locations: locations}
+		s, sessions, _ := newTestService(geo)
+		_ = s.Record(ctx, login)
+		waitLocated(t, sessions)
+		firstSeen := sessions.get(login.SessionUuid).LastSeenAt
+
+		if err := s.Record(ctx, login); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		if got := sessions.get(login.SessionUuid); !got.LastSeenAt.After(firstSeen) {
+			t.Errorf("Record() last seen at %s, want after %s", got.LastSeenAt, firstSeen)
+		}
+		if calls := geo.called(); len(calls) != 1 {
+			t.Errorf("Record() located %v, want the first ip only", calls)
+		}
+	})
+
+	t.Run("Given a recorded session When refreshed from another country Then it is located again and flagged", func(t *testing.T) {
+		s, sessions, _ := newTestService(&geolocation{locations: locations})
+		_ = s.Record(ctx, login)
+		waitLocated(t, sessions)
+
+		other := login
+		other.SessionUuid = uuid.New()
+		_ = s.Record(ctx, other)
+		waitLocated(t, sessions)
+
+		refreshed := other
+		refreshed.Ip = "103.6.150.1"
+		if err := s.Record(ctx, refreshed); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		waitLocated(t, sessions)
+
+		if got := sessions.get(other.SessionUuid); got.CountryCode == nil || *got.CountryCode != "SG" || !got.NewCountry {
+			t.Errorf("Record() located the session in %v new %v, want SG new", got.CountryCode, got.NewCountry)
+		}
+	})
+
+	t.Run("Given the request is over When the location is resolved Then it is stored with the correlation id of the request", func(t *testing.T) {
+		geo := &geolocation{locations: locations, release: make(chan struct{})}
+		s, sessions, _ := newTestService(geo)
+
+		requestCtx, cancel := context.WithCancel(correlation.ContextFromCorrelation("req-1"))
+		if err := s.Record(requestCtx, login); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		cancel()
+		close(geo.release)
+
+		locatedCtx := waitLocated(t, sessions)
+		if locatedCtx.Err() != nil {
+			t.Errorf("Record() located the session with a context that is %v, want it detached from the request", locatedCtx.Err())
+		}
+		if id := correlation.ContextCorrelationId(locatedCtx); id != "req-1" {
+			t.Errorf("Record() located the session with correlation id %q, want %q", id, "req-1")
+		}
+	})
+}
+
+func TestLocate(t *testing.T) {
+	subjectUuid := uuid.New()
+	located := func(countryCode string) *sessions_DBModels.Session {
+		return &sessions_DBModels.Session{Uuid: uuid.New(), SubjectUuid: subjectUuid, SubjectType: sessions_DBModels.SUBJECT_USER, CountryCode: &countryCode}
+	}
+
+	tests := []struct {
+		name           string
+		geo            *geolocation
+		others         []*sessions_DBModels.Session
+		ip             string
+		wantCalled     bool
+		wantCountry    string
+		wantNewCountry bool
+	}{
+		{
+			name:        "Given no located session yet When locating Then the country is stored without a flag",
+			geo:         &geolocation{locations: locations},
+			ip:          "103.6.150.1",
+			wantCalled:  true,
+			wantCountry: "SG",
+		},
+		{
+			name:        "Given a session from the same country When locating Then the country is stored without a flag",
+			geo:         &geolocation{locations: locations},
+			others:      []*sessions_DBModels.Session{located("ID")},
+			ip:          "36.68.10.2",
+			wantCalled:  true,
+			wantCountry: "ID",
+		},
+		{
+			name:           "Given sessions from other countries only When locating Then the session is flagged",
+			geo:            &geolocation{locations: locations},
+			others:         []*sessions_DBModels.Session{located("ID")},
+			ip:             "103.6.150.1",
+			wantCalled:     true,
+			wantCountry:    "SG",
+			wantNewCountry: true,
+		},
+		{
+			name: "Given a private ip When locating Then the api isn't called",
+			geo:  &geolocation{locations: locations},
+			ip:   "10.0.0.7",
+		},
+		{
+			name:       "Given an ip without a country When locating Then nothing is stored",
+			geo:        &geolocation{locations: locations},
+			ip:         "185.220.101.1",
+			wantCalled: true,
+		},
+		{
+			name:       "Given the api fails When locating Then nothing is stored",
+			geo:        &geolocation{err: errors.New("api is down")},
+			ip:         "103.6.150.1",
+			wantCalled: true,
+		},
+		{
+			name: "Given no geolocation client When locating Then nothing is stored",
+			ip:   "103.6.150.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sessions, _ := newTestService(tt.geo)
+			sessions.sessions = append(sessions.sessions, tt.others...)
+
+			session := sessions_DBModels.Session{Uuid: uuid.New(), SubjectUuid: subjectUuid, SubjectType: sessions_DBModels.SUBJECT_USER, Ip: tt.ip}
+			_ = sessions.CreateSession(context.Background(), &session)
+
+			s.locate(context.Background(), session)
+
+			if tt.geo != nil {
+				if called := len(tt.geo.called()) > 0; called != tt.wantCalled {
+					t.Errorf("locate() called the api = %v, want %v", called, tt.wantCalled)
+				}
+			}
+
+			got := sessions.get(session.Uuid)
+			var gotCountry string
+			if got.CountryCode != nil {
+				gotCountry = *got.CountryCode
+			}
+			if gotCountry != tt.wantCountry || got.NewCountry != tt.wantNewCountry {
+				t.Errorf("locate() stored country %q new %v, want %q new %v", gotCountry, got.NewCountry, tt.wantCountry, tt.wantNewCountry)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	subjectUuid := uuid.New()
+
+	tests := []struct {
+		name        string
+		subjectUuid uuid.UUID
+		wantErr     error
+	}{
+		{
+			name:        "Given a session of the subject When revoking it Then its tokens are revoked and it expires",
+			subjectUuid: subjectUuid,
+		},
+		{
+			name:        "Given a session of another subject When revoking it Then it is not found",
+			subjectUuid: uuid.New(),
+			wantErr:     ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sessions, revoker := newTestService(nil)
+			session := sessions_DBModels.Session{
+				Uuid:        uuid.New(),
+				SubjectUuid: subjectUuid,
+				SubjectType: sessions_DBModels.SUBJECT_USER,
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}
+			_ = sessions.CreateSession(ctx, &session)
+
+			if err := s.Revoke(ctx, tt.subjectUuid, session.Uuid); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Revoke() error = %v, want %v", err, tt.wantErr)
+			}
+
+			revoked := len(revoker.revoked) == 1 && revoker.revoked[0] == session.Uuid
+			expired := !time.Now().Before(sessions.get(session.Uuid).ExpiresAt)
+			if want := tt.wantErr == nil; revoked != want || expired != want {
+				t.Errorf("Revoke() revoked the tokens = %v and expired the session = %v, want %v", revoked, expired, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"user/sigmatech/app/db/where"
+)
+
+// Operators of the list filter grammar, a query parameter field[operator]=value filters the field with
+// the operator. A plain field=value keeps the older guessing: equality for integers, a range for a~b,
+// ILIKE otherwise.
+const (
+	FILTER_EQ     = "eq"     // status[eq]=active, an exact match
+	FILTER_NOT    = "not"    // status[not]=closed
+	FILTER_IN     = "in"     // status[in]=matched,resolved
+	FILTER_NOT_IN = "not_in" // status[not_in]=matched,resolved
+	FILTER_GT     = "gt"     // otr[gt]=1000000
+	FILTER_GTE    = "gte"    // created_at[gte]=2024-01-01
+	FILTER_LT     = "lt"     // otr[lt]=5000000
+	FILTER_LTE    = "lte"    // created_at[lte]=2024-01-31 23:59:59
+	FILTER_LIKE   = "like"   // name[like]=budi, contains ignoring case
+	FILTER_NULL   = "null"   // payment_at[null]=true or payment_at[null]=false
+)
+
+var filterOperators = map[string]where.Operator{
+	FILTER_EQ:     where.EQ,
+	FILTER_NOT:    where.NOT_EQ,
+	FILTER_IN:     where.IN,
+	FILTER_NOT_IN: where.NOT_IN,
+	FILTER_GT:     where.GT,
+	FILTER_GTE:    where.GTE,
+	FILTER_LT:     where.LT,
+	FILTER_LTE:    where.LTE,
+	FILTER_LIKE:   where.ILIKE,
+}
+
+var filterKey = regexp.MustCompile(`^([a-zA-Z0-9_.]+)\[([a-z_]+)\]$`)
+
+// ParseFilterKey splits a field[operator] key, ok is false for plain fields.
+func ParseFilterKey(key string) (field, operator string, ok bool) {
+	parts := filterKey.FindStringSubmatch(key)
+	if parts == nil {
+		return key, "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// IsFilterOperator reports whether the operator is part of the filter grammar.
+func IsFilterOperator(operator string) bool {
+	_, ok := filterOperators[operator]
+	return ok || operator == FILTER_NULL
+}
+
+// FilterOf returns the where filter of a field[operator] condition. In and not_in take a slice or
+// a comma separated string, null takes a bool or "true"/"false".
+func FilterOf(column, operator string, value interface{}) (where.Filter, error) {
+	switch operator {
+	case FILTER_NULL:
+		isNull, err := parseBool(value)
+		if err != nil {
+			return where.Filter{}, fmt.Errorf("%s[%s]: %w", column, operator, err)
+		}
+		if isNull {
+			return where.IsNull(column), nil
+		}
+		return where.IsNotNull(column), nil
+	case FILTER_IN, FILTER_NOT_IN:
+		if s, ok := value.(string); ok {
+			value = strings.Split(s, ",")
+		}
+	case FILTER_LIKE:
+		value = where.Contains(fmt.Sprintf("%v", value))
+	}
+
+	whereOperator, ok := filterOperators[operator]
+	if !ok {
+		return where.Filter{}, fmt.Errorf("%s: unknown filter operator %q", column, operator)
+	}
+	return where.Compare(column, whereOperator, value), nil
+}
+
+func parseBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%v isn't true or false", value)
+}
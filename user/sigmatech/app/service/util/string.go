@@ -1,6 +1,8 @@
 package util
 
 import (
+	crand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"regexp"
 )
@@ -67,3 +69,16 @@ func IsValidEmail(email string) bool {
 
 	return match
 }
+
+// GenerateOTP returns a random numeric one time password of the given length.
+func GenerateOTP(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := crand.Int(crand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
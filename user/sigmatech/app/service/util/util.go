@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
@@ -35,6 +36,12 @@ func GenerateHash(password string) (string, error) {
 
 func Int(v int) *int { return &v }
 
+func Boolean(v bool) *bool { return &v }
+
+func String(v string) *string { return &v }
+
+func Time(v time.Time) *time.Time { return &v }
+
 func UnwrapInt(v *int) int {
 	if v == nil {
 		return 0
@@ -77,6 +84,12 @@ func ExtractConstraintName(errMsg string) string {
 	return ""
 }
 
+// IsUniqueViolation reports whether err is a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
 // HandleForeignKeyViolation formats the error message for foreign key constraint violation
 func HandleForeignKeyViolation(err error, tableName string) (string, bool) {
 	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
@@ -0,0 +1,65 @@
+package webhook
+
+// EventType identifies the lifecycle event a webhook is delivered for.
+type EventType string
+
+// Event types.
+const (
+	EventCustomerApproved     EventType = "customer.approved"
+	EventTransactionCreated   EventType = "transaction.created"
+	EventTransactionPaid      EventType = "transaction.paid"
+	EventTransactionOverdue   EventType = "transaction.overdue"
+	EventTransactionCancelled EventType = "transaction.cancelled"
+)
+
+// Status is the delivery state of a queued webhook.
+type Status string
+
+// Statuses.
+const (
+	StatusPending   Status = "pending"   // queued, never attempted
+	StatusRetrying  Status = "retrying"  // failed at least once, waiting for the next attempt
+	StatusSucceeded Status = "succeeded" // receiver answered with a 2xx status
+	StatusDead      Status = "dead"      // gave up after the last attempt, can be replayed manually
+)
+
+// Headers sent with every delivery.
+const (
+	HEADER_SIGNATURE   = "X-Webhook-Signature"
+	HEADER_TIMESTAMP   = "X-Webhook-Timestamp"
+	HEADER_EVENT       = "X-Webhook-Event"
+	HEADER_DELIVERY_ID = "X-Webhook-Delivery"
+
+	// SIGNATURE_PREFIX names the algorithm in the signature header value.
+	SIGNATURE_PREFIX = "sha256="
+
+	// maxResponseBody caps the part of the receiver response kept in the delivery log.
+	maxResponseBody = 2048
+)
+
+// EventTypes lists every supported event type.
+var EventTypes = []EventType{
+	EventCustomerApproved,
+	EventTransactionCreated,
+	EventTransactionPaid,
+	EventTransactionOverdue,
+	EventTransactionCancelled,
+}
+
+func (e EventType) String() string {
+	return string(e)
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// IsValidEventType reports whether the given value is a supported event type.
+func IsValidEventType(value string) bool {
+	for _, e := range EventTypes {
+		if string(e) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+// Package webhook queues lifecycle events for the partner endpoints subscribed to them
+// and delivers them signed, retrying failed deliveries with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"user/sigmatech/app/constants"
+	webhookDeliveries_DBModels "user/sigmatech/app/db/dto/webhook_deliveries"
+	webhookSubscriptions_DBModels "user/sigmatech/app/db/dto/webhook_subscriptions"
+	webhookDeliveryDB "user/sigmatech/app/db/repository/webhook_delivery"
+	webhookSubscriptionDB "user/sigmatech/app/db/repository/webhook_subscription"
+	"user/sigmatech/app/db/where"
+	"user/sigmatech/app/service/dto/request"
+	"user/sigmatech/app/service/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryNotDead  = errors.New("only dead webhook deliveries can be replayed")
+)
+
+type IWebhookService interface {
+	Publish(ctx context.Context, eventType EventType, referenceUuid *uuid.UUID, data interface{}) error
+	Dispatch(ctx context.Context) error
+	Replay(ctx context.Context, deliveryUuid uuid.UUID) (webhookDeliveries_DBModels.WebhookDelivery, error)
+	Run(ctx context.Context)
+}
+
+// WebhookService is a struct that implements the IWebhookService interface.
+type WebhookService struct {
+	WebhookSubscriptionDBClient webhookSubscriptionDB.IWebhookSubscriptionRepository
+	WebhookDeliveryDBClient     webhookDeliveryDB.IWebhookDeliveryRepository
+	Client                      IClient
+}
+
+// NewWebhookService is a constructor function that creates a new WebhookService.
+func NewWebhookService(
+	WebhookSubscriptionDBClient webhookSubscriptionDB.IWebhookSubscriptionRepository,
+	WebhookDeliveryDBClient webhookDeliveryDB.IWebhookDeliveryRepository,
+	Client IClient,
+) *WebhookService {
+	return &WebhookService{
+		WebhookSubscriptionDBClient: WebhookSubscriptionDBClient,
+		WebhookDeliveryDBClient:     WebhookDeliveryDBClient,
+		Client:                      Client,
+	}
+}
+
+// Publish queues the event for every active subscription listening to it.
+// Delivery happens in the background worker, so publishing never waits on a partner endpoint.
+func (w *WebhookService) Publish(ctx context.Context, eventType EventType, referenceUuid *uuid.UUID, data interface{}) error {
+	p := request.Pagination{
+		GetAllData: true,
+	}
+	p.Validate()
+
+	f := map[string]interface{}{
+		webhookSubscriptions_DBModels.COLUMN_IS_ACTIVE: "true",
+	}
+
+	subscriptions, _, err := w.WebhookSubscriptionDBClient.GetWebhookSubscriptions(ctx, p, f)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	payload := Payload{
+		Id:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, subscription := range subscriptions {
+		if !isSubscribed(subscription, eventType) {
+			continue
+		}
+
+		delivery := webhookDeliveries_DBModels.WebhookDelivery{
+			Uuid:             uuid.New(),
+			SubscriptionUuid: subscription.Uuid,
+			EventUuid:        payload.Id,
+			EventType:        eventType.String(),
+			ReferenceUuid:    referenceUuid,
+			Payload:          string(body),
+			Status:           StatusPending.String(),
+			NextAttemptAt:    &now,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+
+		if err := w.WebhookDeliveryDBClient.CreateWebhookDelivery(ctx, &delivery); err != nil {
+			failed = append(failed, subscription.Uuid.String())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to queue %s webhook for subscriptions %s", eventType, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// Dispatch attempts every delivery that is due, up to the configured batch size.
+func (w *WebhookService) Dispatch(ctx context.Context) error {
+	log := logger.Logger(ctx)
+
+	deliveries, err := w.WebhookDeliveryDBClient.GetDueWebhookDeliveries(ctx,
+		[]string{StatusPending.String(), StatusRetrying.String()},
+		time.Now(),
+		constants.Config.WebhookConfig.WEBHOOK_BATCH_SIZE,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if _, err := w.attempt(ctx, *delivery); err != nil {
+			log.Errorf("unable to record webhook delivery %s: %v", delivery.Uuid, err)
+		}
+	}
+
+	return nil
+}
+
+// Replay puts a dead delivery back in the queue with a fresh attempt budget and tries it right away.
+func (w *WebhookService) Replay(ctx context.Context, deliveryUuid uuid.UUID) (webhookDeliveries_DBModels.WebhookDelivery, error) {
+	filter := where.Eq(webhookDeliveries_DBModels.COLUM_UUID, deliveryUuid)
+
+	delivery, err := w.WebhookDeliveryDBClient.GetWebhookDelivery(ctx, filter)
+	if err != nil {
+		return delivery, err
+	}
+
+	if delivery.Uuid == uuid.Nil {
+		return delivery, ErrDeliveryNotFound
+	}
+
+	if delivery.Status != StatusDead.String() {
+		return delivery, ErrDeliveryNotDead
+	}
+
+	delivery.Attempts = 0
+	return w.attempt(ctx, delivery)
+}
+
+// Run dispatches due deliveries on every poll interval until the context is cancelled.
+// Only one worker should run per database, deliveries are not locked between instances.
+func (w *WebhookService) Run(ctx context.Context) {
+	log := logger.Logger(ctx)
+
+	ticker := time.NewTicker(time.Duration(constants.Config.WebhookConfig.WEBHOOK_POLL_INTERVAL) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Dispatch(ctx); err != nil {
+				log.Errorf("unable to dispatch webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// attempt posts the delivery once and records the outcome, scheduling the next attempt
+// or moving the delivery to the dead letter state once the attempts are used up.
+func (w *WebhookService) attempt(ctx context.Context, delivery webhookDeliveries_DBModels.WebhookDelivery) (webhookDeliveries_DBModels.WebhookDelivery, error) {
+	cfg := constants.Config.WebhookConfig
+
+	subscription, err := w.WebhookSubscriptionDBClient.GetWebhookSubscription(ctx,
+		where.Eq(webhookSubscriptions_DBModels.COLUM_UUID, delivery.SubscriptionUuid),
+	)
+	if err != nil {
+		return delivery, err
+	}
+
+	attempts := delivery.Attempts + 1
+
+	var patcher = make(map[string]interface{})
+	patcher[webhookDeliveries_DBModels.COLUMN_ATTEMPTS] = attempts
+
+	var sendErr error
+	if subscription.Uuid == uuid.Nil || subscription.IsActive == nil || !*subscription.IsActive {
+		sendErr = errors.New("subscription is missing or inactive")
+		attempts = cfg.WEBHOOK_MAX_ATTEMPTS // no point in retrying
+		patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_CODE] = nil
+		patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_BODY] = nil
+	} else {
+		var response Response
+		response, sendErr = w.Client.Post(ctx, subscription.Url, subscription.Secret, delivery.Uuid.String(), delivery.EventType, []byte(delivery.Payload))
+		if response.StatusCode != 0 {
+			patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_CODE] = response.StatusCode
+			patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_BODY] = response.Body
+		} else {
+			patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_CODE] = nil
+			patcher[webhookDeliveries_DBModels.COLUMN_LAST_RESPONSE_BODY] = nil
+		}
+	}
+
+	now := time.Now()
+
+	switch {
+	case sendErr == nil:
+		patcher[webhookDeliveries_DBModels.COLUMN_STATUS] = StatusSucceeded.String()
+		patcher[webhookDeliveries_DBModels.COLUMN_DELIVERED_AT] = now
+		patcher[webhookDeliveries_DBModels.COLUMN_NEXT_ATTEMPT_AT] = nil
+		patcher[webhookDeliveries_DBModels.COLUMN_LAST_ERROR] = nil
+	case attempts >= cfg.WEBHOOK_MAX_ATTEMPTS:
+		patcher[webhookDeliveries_DBModels.COLUMN_STATUS] = StatusDead.String()
+		patcher[webhookDeliveries_DBModels.COLUMN_NEXT_ATTEMPT_AT] = nil
+		patcher[webhookDeliveries_DBModels.COLUMN_LAST_ERROR] = sendErr.Error()
+	default:
+		backoff := Backoff(
+			time.Duration(cfg.WEBHOOK_BACKOFF_BASE)*time.Second,
+			time.Duration(cfg.WEBHOOK_BACKOFF_MAX)*time.Second,
+			attempts,
+		)
+		patcher[webhookDeliveries_DBModels.COLUMN_STATUS] = StatusRetrying.String()
+		patcher[webhookDeliveries_DBModels.COLUMN_NEXT_ATTEMPT_AT] = now.Add(backoff)
+		patcher[webhookDeliveries_DBModels.COLUMN_LAST_ERROR] = sendErr.Error()
+	}
+
+	patcher[webhookDeliveries_DBModels.COLUMN_UPDATED_AT] = now
+
+	filter := where.Eq(webhookDeliveries_DBModels.COLUM_UUID, delivery.Uuid)
+	if err := w.WebhookDeliveryDBClient.UpdateWebhookDelivery(ctx, filter, patcher); err != nil {
+		return delivery, err
+	}
+
+	return w.WebhookDeliveryDBClient.GetWebhookDelivery(ctx, filter)
+}
+
+// Backoff returns the wait before the attempt following the given one: base doubled for every
+// failed attempt, capped at max.
+func Backoff(base time.Duration, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	backoff := base
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+func isSubscribed(subscription *webhookSubscriptions_DBModels.WebhookSubscription, eventType EventType) bool {
+	for _, e := range subscription.GetEventTypes() {
+		if e == eventType.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClientPost(t *testing.T) {
+	const secret = "partner-secret"
+	body := []byte(`{"id":"6f1c2d1e-3a7b-4e6a-9d2f-0c1b2a3d4e5f","type":"transaction.created","data":{}}`)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "Given receiver answers 200, When call Post, Then signed request is accepted",
+			statusCode: http.StatusOK,
+			wantErr:    false,
+		},
+		{
+			name:       "Given receiver answers 204, When call Post, Then signed request is accepted",
+			statusCode: http.StatusNoContent,
+			wantErr:    false,
+		},
+		{
+			name:       "Given receiver answers 500, When call Post, Then return error with the status code",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				timestamp, err := strconv.ParseInt(r.Header.Get(HEADER_TIMESTAMP), 10, 64)
+				if err != nil {
+					t.Errorf("invalid %s header: %v", HEADER_TIMESTAMP, err)
+				}
+				if timestamp != now.Unix() {
+					t.Errorf("%s = %d, want %d", HEADER_TIMESTAMP, timestamp, now.Unix())
+				}
+				if !Verify(secret, timestamp, received, r.Header.Get(HEADER_SIGNATURE)) {
+					t.Errorf("signature %s does not verify", r.Header.Get(HEADER_SIGNATURE))
+				}
+				if got := r.Header.Get(HEADER_EVENT); got != "transaction.created" {
+					t.Errorf("%s = %s, want transaction.created", HEADER_EVENT, got)
+				}
+				if got := r.Header.Get(HEADER_DELIVERY_ID); got != "delivery-1" {
+					t.Errorf("%s = %s, want delivery-1", HEADER_DELIVERY_ID, got)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			client := NewClient(time.Second)
+			client.Now = func() time.Time { return now }
+
+			got, err := client.Post(context.Background(), server.URL, secret, "delivery-1", "transaction.created", body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Post() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.StatusCode != tt.statusCode {
+				t.Errorf("Post() StatusCode = %d, want %d", got.StatusCode, tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"customer.approved"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      bool
+	}{
+		{
+			name:      "Given matching secret, timestamp and body, When call Verify, Then return true",
+			secret:    "secret",
+			timestamp: 1700000000,
+			body:      body,
+			want:      true,
+		},
+		{
+			name:      "Given another secret, When call Verify, Then return false",
+			secret:    "other",
+			timestamp: 1700000000,
+			body:      body,
+			want:      false,
+		},
+		{
+			name:      "Given another timestamp, When call Verify, Then return false",
+			secret:    "secret",
+			timestamp: 1700000001,
+			body:      body,
+			want:      false,
+		},
+		{
+			name:      "Given a tampered body, When call Verify, Then return false",
+			secret:    "secret",
+			timestamp: 1700000000,
+			body:      []byte(`{"type":"customer.rejected"}`),
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.body, signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		want    time.Duration
+	}{
+		{
+			name:    "Given first attempt, When call Backoff, Then return base",
+			attempt: 1,
+			want:    30 * time.Second,
+		},
+		{
+			name:    "Given third attempt, When call Backoff, Then return base doubled twice",
+			attempt: 3,
+			want:    2 * time.Minute,
+		},
+		{
+			name:    "Given a high attempt, When call Backoff, Then return max",
+			attempt: 40,
+			want:    time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(30*time.Second, time.Hour, tt.attempt); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
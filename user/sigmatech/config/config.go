@@ -9,11 +9,16 @@ import (
 )
 
 type JwtConfig struct {
-	JWT_MAGIC_SECRET   string `env:"JWT_MAGIC_SECRET"`
-	JWT_ACCESS_SECRET  string `env:"JWT_ACCESS_SECRET"`
-	JWT_REFRESH_SECRET string `env:"JWT_REFRESH_SECRET"`
-	JWT_ACCESS_EXP     int    `env:"JWT_ACCESS_EXP"`
-	JWT_REFRESH_EXP    int    `env:"JWT_REFRESH_EXP"`
+	JWT_MAGIC_SECRET             string `env:"JWT_MAGIC_SECRET"`
+	JWT_ACCESS_SECRET            string `env:"JWT_ACCESS_SECRET"`
+	JWT_REFRESH_SECRET           string `env:"JWT_REFRESH_SECRET"`
+	JWT_ACCESS_EXP               int    `env:"JWT_ACCESS_EXP"`
+	JWT_REFRESH_EXP              int    `env:"JWT_REFRESH_EXP"`
+	JWT_REFRESH_STORE            string `env:"JWT_REFRESH_STORE" envDefault:"postgres"`          // postgres or redis
+	JWT_SIGNING_ALG              string `env:"JWT_SIGNING_ALG" envDefault:"HS256"`               // HS256, RS256 or EdDSA for access tokens
+	JWT_KEYS_DIR                 string `env:"JWT_KEYS_DIR"`                                     // directory of <kid>.pem keys for RS256 and EdDSA
+	JWT_KEY_ID                   string `env:"JWT_KEY_ID"`                                       // kid of the key signing new tokens
+	JWT_CUSTOMER_PRINCIPAL_CACHE string `env:"JWT_CUSTOMER_PRINCIPAL_CACHE" envDefault:"memory"` // JWT_PRINCIPAL_CACHE of the customer service
 }
 
 type DatabaseConfig struct {
@@ -43,14 +48,15 @@ type RedisConfig struct {
 }
 
 type HTTPServerConfig struct {
-	HTTPSERVER_URL                         string `env:"HTTPSERVER_URL"`
-	HTTPSERVER_LISTEN                      string `env:"HTTPSERVER_LISTEN"`
-	HTTPSERVER_PORT                        string `env:"HTTPSERVER_PORT"`
-	HTTPSERVER_READ_TIMEOUT                int    `env:"HTTPSERVER_READ_TIMEOUT"`
-	HTTPSERVER_WRITE_TIMEOUT               int    `env:"HTTPSERVER_WRITE_TIMEOUT"`
-	HTTPSERVER_MAX_CONNECTIONS_PER_IP      int    `env:"HTTPSERVER_MAX_CONNECTIONS_PER_IP"`
-	HTTPSERVER_MAX_REQUESTS_PER_CONNECTION int    `env:"HTTPSERVER_MAX_REQUESTS_PER_CONNECTION"`
-	HTTPSERVER_MAX_KEEP_ALIVE_DURATION     int    `env:"HTTPSERVER_MAX_KEEP_ALIVE_DURATION"`
+	HTTPSERVER_URL                         string   `env:"HTTPSERVER_URL"`
+	HTTPSERVER_LISTEN                      string   `env:"HTTPSERVER_LISTEN"`
+	HTTPSERVER_PORT                        string   `env:"HTTPSERVER_PORT"`
+	HTTPSERVER_READ_TIMEOUT                int      `env:"HTTPSERVER_READ_TIMEOUT"`
+	HTTPSERVER_WRITE_TIMEOUT               int      `env:"HTTPSERVER_WRITE_TIMEOUT"`
+	HTTPSERVER_MAX_CONNECTIONS_PER_IP      int      `env:"HTTPSERVER_MAX_CONNECTIONS_PER_IP"`
+	HTTPSERVER_MAX_REQUESTS_PER_CONNECTION int      `env:"HTTPSERVER_MAX_REQUESTS_PER_CONNECTION"`
+	HTTPSERVER_MAX_KEEP_ALIVE_DURATION     int      `env:"HTTPSERVER_MAX_KEEP_ALIVE_DURATION"`
+	HTTPSERVER_TRUSTED_PROXIES             []string `env:"HTTPSERVER_TRUSTED_PROXIES"` // IPs or CIDRs of the proxies whose X-Forwarded-For is trusted, none when empty
 }
 
 type LogConfig struct {
@@ -68,17 +74,30 @@ type LogConfig struct {
 }
 
 type ServiceConfig struct {
-	ProjectVersion      string `env:"VERSION"`
-	JwtConfig           JwtConfig
-	DatabaseConfig      DatabaseConfig
-	RedisConfig         RedisConfig
-	HTTPServerConfig    HTTPServerConfig
-	IntegrationConfig   IntegrationConfig
-	LogConfig           LogConfig
-	EncryptionConfig    EncryptionConfig
-	AWSConfig           AWSConfig
-	Environment         string `env:"ENVIRONMENT"`
-	IPGeoLocationConfig IPGeoLocationConfig
+	ProjectVersion          string `env:"VERSION"`
+	JwtConfig               JwtConfig
+	DatabaseConfig          DatabaseConfig
+	RedisConfig             RedisConfig
+	HTTPServerConfig        HTTPServerConfig
+	IntegrationConfig       IntegrationConfig
+	LogConfig               LogConfig
+	EncryptionConfig        EncryptionConfig
+	AWSConfig               AWSConfig
+	Environment             string `env:"ENVIRONMENT"`
+	IPGeoLocationConfig     IPGeoLocationConfig
+	NotificationConfig      NotificationConfig
+	WebhookConfig           WebhookConfig
+	SignatureConfig         SignatureConfig
+	ReconciliationConfig    ReconciliationConfig
+	ExportConfig            ExportConfig
+	CustomerImportConfig    CustomerImportConfig
+	PurgeConfig             PurgeConfig
+	MailConfig              MailConfig
+	PasswordResetConfig     PasswordResetConfig
+	EmailVerificationConfig EmailVerificationConfig
+	LoginLockoutConfig      LoginLockoutConfig
+	MfaConfig               MfaConfig
+	RateLimitConfig         RateLimitConfig
 }
 
 type IntegrationConfig struct {
@@ -87,7 +106,117 @@ type IntegrationConfig struct {
 }
 
 type IPGeoLocationConfig struct {
-	IPGEOLOCATION_API_KEY string `env:"IPGEOLOCATION_API_KEY"`
+	IPGEOLOCATION_API_KEY    string `env:"IPGEOLOCATION_API_KEY"`
+	IPGEOLOCATION_CACHE_TTL  int    `env:"IPGEOLOCATION_CACHE_TTL" envDefault:"86400"`  // seconds a resolved location is reused
+	IPGEOLOCATION_CACHE_SIZE int    `env:"IPGEOLOCATION_CACHE_SIZE" envDefault:"10000"` // ips cached per instance
+}
+
+type NotificationConfig struct {
+	NOTIFICATION_DEFAULT_LOCALE string `env:"NOTIFICATION_DEFAULT_LOCALE" envDefault:"id"`
+}
+
+type WebhookConfig struct {
+	WEBHOOK_TIMEOUT       int `env:"WEBHOOK_TIMEOUT" envDefault:"10"`        // seconds
+	WEBHOOK_MAX_ATTEMPTS  int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`    // dead letter after this many attempts
+	WEBHOOK_BACKOFF_BASE  int `env:"WEBHOOK_BACKOFF_BASE" envDefault:"30"`   // seconds before the first retry
+	WEBHOOK_BACKOFF_MAX   int `env:"WEBHOOK_BACKOFF_MAX" envDefault:"21600"` // seconds
+	WEBHOOK_POLL_INTERVAL int `env:"WEBHOOK_POLL_INTERVAL" envDefault:"15"`  // seconds
+	WEBHOOK_BATCH_SIZE    int `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+}
+
+type SignatureConfig struct {
+	SIGNATURE_ENABLED     bool   `env:"SIGNATURE_ENABLED" envDefault:"false"`
+	SIGNATURE_KEYS        string `env:"SIGNATURE_KEYS"`                           // comma separated key_id:secret pairs
+	SIGNATURE_TOLERANCE   int    `env:"SIGNATURE_TOLERANCE" envDefault:"300"`     // seconds of allowed clock skew
+	SIGNATURE_NONCE_STORE string `env:"SIGNATURE_NONCE_STORE" envDefault:"redis"` // memory or redis
+}
+
+// ReconciliationConfig is the default layout of bank mutation files, columns are zero based and -1 when absent
+type ReconciliationConfig struct {
+	RECONCILIATION_DELIMITER              string `env:"RECONCILIATION_DELIMITER" envDefault:","`
+	RECONCILIATION_SKIP_ROWS              int    `env:"RECONCILIATION_SKIP_ROWS" envDefault:"1"` // header rows
+	RECONCILIATION_DATE_COLUMN            int    `env:"RECONCILIATION_DATE_COLUMN" envDefault:"0"`
+	RECONCILIATION_DESCRIPTION_COLUMN     int    `env:"RECONCILIATION_DESCRIPTION_COLUMN" envDefault:"1"`
+	RECONCILIATION_REFERENCE_COLUMN       int    `env:"RECONCILIATION_REFERENCE_COLUMN" envDefault:"2"`
+	RECONCILIATION_VA_NUMBER_COLUMN       int    `env:"RECONCILIATION_VA_NUMBER_COLUMN" envDefault:"3"`
+	RECONCILIATION_CONTRACT_NUMBER_COLUMN int    `env:"RECONCILIATION_CONTRACT_NUMBER_COLUMN" envDefault:"4"`
+	RECONCILIATION_AMOUNT_COLUMN          int    `env:"RECONCILIATION_AMOUNT_COLUMN" envDefault:"5"`
+	RECONCILIATION_DATE_FORMAT            string `env:"RECONCILIATION_DATE_FORMAT" envDefault:"2006-01-02"`
+	RECONCILIATION_DECIMAL_SEPARATOR      string `env:"RECONCILIATION_DECIMAL_SEPARATOR" envDefault:"."`
+	RECONCILIATION_MAX_FILE_SIZE          int64  `env:"RECONCILIATION_MAX_FILE_SIZE" envDefault:"10485760"` // bytes
+}
+
+type ExportConfig struct {
+	EXPORT_MAX_SYNC_ROWS int    `env:"EXPORT_MAX_SYNC_ROWS" envDefault:"5000"` // larger exports run in the background
+	EXPORT_LINK_TTL      int    `env:"EXPORT_LINK_TTL" envDefault:"3600"`      // seconds a download link stays valid
+	EXPORT_OBJECT_PREFIX string `env:"EXPORT_OBJECT_PREFIX" envDefault:"exports/"`
+}
+
+// CustomerImportConfig bounds bulk customer imports, which are processed within the request
+type CustomerImportConfig struct {
+	CUSTOMER_IMPORT_MAX_ROWS      int   `env:"CUSTOMER_IMPORT_MAX_ROWS" envDefault:"200"`
+	CUSTOMER_IMPORT_BATCH_SIZE    int   `env:"CUSTOMER_IMPORT_BATCH_SIZE" envDefault:"50"`         // customers created per database transaction
+	CUSTOMER_IMPORT_MAX_FILE_SIZE int64 `env:"CUSTOMER_IMPORT_MAX_FILE_SIZE" envDefault:"2097152"` // bytes
+}
+
+// PurgeConfig is the retention of soft deleted customers and users before they are hard deleted
+type PurgeConfig struct {
+	PURGE_ENABLED        bool `env:"PURGE_ENABLED" envDefault:"true"`
+	PURGE_RETENTION_DAYS int  `env:"PURGE_RETENTION_DAYS" envDefault:"90"`
+	PURGE_INTERVAL       int  `env:"PURGE_INTERVAL" envDefault:"3600"` // seconds
+}
+
+type MailConfig struct {
+	MAIL_DRIVER        string `env:"MAIL_DRIVER" envDefault:"log"` // smtp or log
+	MAIL_FROM          string `env:"MAIL_FROM"`
+	MAIL_SMTP_HOST     string `env:"MAIL_SMTP_HOST"`
+	MAIL_SMTP_PORT     int    `env:"MAIL_SMTP_PORT" envDefault:"587"`
+	MAIL_SMTP_USERNAME string `env:"MAIL_SMTP_USERNAME"`
+	MAIL_SMTP_PASSWORD string `env:"MAIL_SMTP_PASSWORD"`
+}
+
+type PasswordResetConfig struct {
+	PASSWORD_RESET_TTL          int `env:"PASSWORD_RESET_TTL" envDefault:"900"`        // seconds the reset otp stays valid
+	PASSWORD_RESET_MAX_ATTEMPTS int `env:"PASSWORD_RESET_MAX_ATTEMPTS" envDefault:"5"` // wrong otps before the reset is void
+	PASSWORD_RESET_LIMIT        int `env:"PASSWORD_RESET_LIMIT" envDefault:"3"`        // otps sent to an email within the window
+	PASSWORD_RESET_WINDOW       int `env:"PASSWORD_RESET_WINDOW" envDefault:"3600"`    // seconds
+}
+
+type EmailVerificationConfig struct {
+	EMAIL_VERIFICATION_TTL    int `env:"EMAIL_VERIFICATION_TTL" envDefault:"86400"`   // seconds the verification token stays valid
+	EMAIL_VERIFICATION_LIMIT  int `env:"EMAIL_VERIFICATION_LIMIT" envDefault:"3"`     // tokens sent to an email within the window
+	EMAIL_VERIFICATION_WINDOW int `env:"EMAIL_VERIFICATION_WINDOW" envDefault:"3600"` // seconds
+}
+
+// LoginLockoutConfig locks accounts and IPs out of signing in after too many failures, a lockout lasts
+// twice as long as the one before it
+type LoginLockoutConfig struct {
+	LOGIN_LOCKOUT_ACCOUNT_THRESHOLD int `env:"LOGIN_LOCKOUT_ACCOUNT_THRESHOLD" envDefault:"5"` // failed sign-ins of an account before it is locked out
+	LOGIN_LOCKOUT_IP_THRESHOLD      int `env:"LOGIN_LOCKOUT_IP_THRESHOLD" envDefault:"20"`     // failed sign-ins from an IP before it is locked out
+	LOGIN_LOCKOUT_WINDOW            int `env:"LOGIN_LOCKOUT_WINDOW" envDefault:"900"`          // seconds failures are counted over
+	LOGIN_LOCKOUT_DURATION          int `env:"LOGIN_LOCKOUT_DURATION" envDefault:"300"`        // seconds of the first lockout
+	LOGIN_LOCKOUT_MAX_DURATION      int `env:"LOGIN_LOCKOUT_MAX_DURATION" envDefault:"86400"`  // seconds
+	LOGIN_LOCKOUT_RESET             int `env:"LOGIN_LOCKOUT_RESET" envDefault:"86400"`         // seconds without failures before lockouts start over
+}
+
+// MfaConfig configures the TOTP second factor of admin users
+type MfaConfig struct {
+	MFA_ISSUER         string `env:"MFA_ISSUER" envDefault:"Sigmatech"`  // shown next to the account in authenticator apps
+	MFA_ENCRYPTION_KEY string `env:"MFA_ENCRYPTION_KEY"`                 // encrypts the stored TOTP secrets
+	MFA_CHALLENGE_TTL  int    `env:"MFA_CHALLENGE_TTL" envDefault:"300"` // seconds to enter the code after the password
+	MFA_SKEW           int    `env:"MFA_SKEW" envDefault:"1"`            // time steps a code is accepted early or late
+	MFA_RECOVERY_CODES int    `env:"MFA_RECOVERY_CODES" envDefault:"10"` // recovery codes issued at once
+}
+
+// RateLimitConfig policies are "<limit>/<window>[,<key>[,<algorithm>]]", key is ip, principal or api_key and
+// algorithm token_bucket or sliding_window. An empty policy leaves the route unlimited.
+type RateLimitConfig struct {
+	RATE_LIMIT_ENABLED        bool   `env:"RATE_LIMIT_ENABLED" envDefault:"false"`
+	RATE_LIMIT_STORE          string `env:"RATE_LIMIT_STORE" envDefault:"memory"`                    // memory or redis
+	RATE_LIMIT_SIGN_IN        string `env:"RATE_LIMIT_SIGN_IN" envDefault:"10/1m,ip,sliding_window"` // shared by the second factor
+	RATE_LIMIT_SIGN_UP        string `env:"RATE_LIMIT_SIGN_UP" envDefault:"5/1h,ip,sliding_window"`
+	RATE_LIMIT_REFRESH_TOKEN  string `env:"RATE_LIMIT_REFRESH_TOKEN" envDefault:"30/1m,ip,token_bucket"`
+	RATE_LIMIT_PASSWORD_RESET string `env:"RATE_LIMIT_PASSWORD_RESET" envDefault:"10/1h,ip,sliding_window"` // shared by forgot and reset password
 }
 
 type ShopeeConfig struct {
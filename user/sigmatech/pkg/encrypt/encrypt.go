@@ -2,6 +2,7 @@ package encrypt
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"io"
@@ -61,3 +62,40 @@ func DecryptWithNaCl(encodedCiphertext string) ([]byte, error) {
 
 	return decrypted, nil
 }
+
+// EncryptWithKey encrypts plaintext using NaCl secretbox with a key derived from secret, unlike
+// EncryptWithNaCl the ciphertext can be decrypted by other instances and after a restart.
+func EncryptWithKey(plaintext []byte, secret string) (string, error) {
+	key := sha256.Sum256([]byte(secret))
+
+	var nonce [24]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", err
+	}
+
+	encrypted := secretbox.Seal(nonce[:], plaintext, &nonce, &key)
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptWithKey decrypts a Base64-encoded ciphertext of EncryptWithKey.
+func DecryptWithKey(encodedCiphertext string, secret string) ([]byte, error) {
+	key := sha256.Sum256([]byte(secret))
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encodedCiphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 24 {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], ciphertext[:24])
+
+	decrypted, ok := secretbox.Open(nil, ciphertext[24:], &nonce, &key)
+	if !ok {
+		return nil, errors.New("decryption failed")
+	}
+
+	return decrypted, nil
+}